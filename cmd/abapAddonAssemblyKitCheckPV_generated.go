@@ -9,17 +9,20 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperenv"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type abapAddonAssemblyKitCheckPVOptions struct {
-	AbapAddonAssemblyKitEndpoint string `json:"abapAddonAssemblyKitEndpoint,omitempty"`
-	Username                     string `json:"username,omitempty"`
-	Password                     string `json:"password,omitempty"`
-	AddonDescriptorFileName      string `json:"addonDescriptorFileName,omitempty"`
+	AbapAddonAssemblyKitEndpoint string `json:"abapAddonAssemblyKitEndpoint,omitempty" validate:"required"`
+	Username                     string `json:"username,omitempty" validate:"required"`
+	Password                     string `json:"password,omitempty" validate:"required"`
+	AddonDescriptorFileName      string `json:"addonDescriptorFileName,omitempty" validate:"required"`
 	AddonDescriptor              string `json:"addonDescriptor,omitempty"`
 }
 
@@ -77,11 +80,22 @@ It resolves the dotted version string into version, support package stack level
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Username)
 			log.RegisterSecret(stepConfig.Password)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -94,10 +108,16 @@ It resolves the dotted version string into version, support package stack level
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				commonPipelineEnvironment.persist(GeneralConfig.EnvRootPath, "commonPipelineEnvironment")
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
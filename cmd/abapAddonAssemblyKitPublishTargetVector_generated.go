@@ -8,17 +8,20 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type abapAddonAssemblyKitPublishTargetVectorOptions struct {
-	AbapAddonAssemblyKitEndpoint string `json:"abapAddonAssemblyKitEndpoint,omitempty"`
-	Username                     string `json:"username,omitempty"`
-	Password                     string `json:"password,omitempty"`
-	TargetVectorScope            string `json:"targetVectorScope,omitempty"`
-	AddonDescriptor              string `json:"addonDescriptor,omitempty"`
+	AbapAddonAssemblyKitEndpoint string `json:"abapAddonAssemblyKitEndpoint,omitempty" validate:"required"`
+	Username                     string `json:"username,omitempty" validate:"required"`
+	Password                     string `json:"password,omitempty" validate:"required"`
+	TargetVectorScope            string `json:"targetVectorScope,omitempty" validate:"possible-values=T P"`
+	AddonDescriptor              string `json:"addonDescriptor,omitempty" validate:"required"`
 }
 
 // AbapAddonAssemblyKitPublishTargetVectorCommand This step triggers the publication of the Target Vector according to the specified scope.
@@ -46,11 +49,22 @@ With targetVectorScope "T" the Target Vector will be published to the test envir
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Username)
 			log.RegisterSecret(stepConfig.Password)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -63,9 +77,15 @@ With targetVectorScope "T" the Target Vector will be published to the test envir
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -129,12 +149,13 @@ func abapAddonAssemblyKitPublishTargetVectorMetadata() config.StepData {
 						Aliases:     []config.Alias{},
 					},
 					{
-						Name:        "targetVectorScope",
-						ResourceRef: []config.ResourceReference{},
-						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
-						Type:        "string",
-						Mandatory:   false,
-						Aliases:     []config.Alias{},
+						Name:           "targetVectorScope",
+						ResourceRef:    []config.ResourceReference{},
+						Scope:          []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:           "string",
+						Mandatory:      false,
+						Aliases:        []config.Alias{},
+						PossibleValues: []interface{}{"T", "P"},
 					},
 					{
 						Name: "addonDescriptor",
@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperenv"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -22,11 +25,11 @@ type abapEnvironmentAssemblePackagesOptions struct {
 	CfServiceInstance           string `json:"cfServiceInstance,omitempty"`
 	CfServiceKeyName            string `json:"cfServiceKeyName,omitempty"`
 	Host                        string `json:"host,omitempty"`
-	Username                    string `json:"username,omitempty"`
-	Password                    string `json:"password,omitempty"`
-	AddonDescriptor             string `json:"addonDescriptor,omitempty"`
-	MaxRuntimeInMinutes         int    `json:"maxRuntimeInMinutes,omitempty"`
-	PollIntervalsInMilliseconds int    `json:"pollIntervalsInMilliseconds,omitempty"`
+	Username                    string `json:"username,omitempty" validate:"required"`
+	Password                    string `json:"password,omitempty" validate:"required"`
+	AddonDescriptor             string `json:"addonDescriptor,omitempty" validate:"required"`
+	MaxRuntimeInMinutes         int    `json:"maxRuntimeInMinutes,omitempty" validate:"required"`
+	PollIntervalsInMilliseconds int    `json:"pollIntervalsInMilliseconds,omitempty" validate:"required"`
 }
 
 type abapEnvironmentAssemblePackagesCommonPipelineEnvironment struct {
@@ -83,11 +86,22 @@ Platform ABAP Environment system and saves the corresponding [SAR archive](https
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Username)
 			log.RegisterSecret(stepConfig.Password)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -100,10 +114,16 @@ Platform ABAP Environment system and saves the corresponding [SAR archive](https
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				commonPipelineEnvironment.persist(GeneralConfig.EnvRootPath, "commonPipelineEnvironment")
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
@@ -8,6 +10,7 @@ import (
 	"github.com/SAP/jenkins-library/pkg/abaputils"
 	piperhttp "github.com/SAP/jenkins-library/pkg/http"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func testSetup(client piperhttp.Sender, buildID string) abapbuild.Build {
@@ -74,6 +77,17 @@ func TestStartingInvalidInput(t *testing.T) {
 }
 
 func TestStep(t *testing.T) {
+	// change into tmp dir since the step writes its reports and links into the working directory
+	dir, err := ioutil.TempDir("", "test-assemble-packages-")
+	require.NoError(t, err)
+	oldCWD, _ := os.Getwd()
+	require.NoError(t, os.Chdir(dir))
+	// clean up tmp dir
+	defer func() {
+		_ = os.Chdir(oldCWD)
+		_ = os.RemoveAll(dir)
+	}()
+
 	autils := &abaputils.AUtilsMock{
 		ReturnedConnectionDetailsHTTP: abaputils.ConnectionDetailsHTTP{
 			URL: `/sap/opu/odata/BUILD/CORE_SRV`,
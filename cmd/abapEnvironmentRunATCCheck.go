@@ -18,6 +18,7 @@ import (
 	piperhttp "github.com/SAP/jenkins-library/pkg/http"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/SAP/jenkins-library/pkg/reporting"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
@@ -211,6 +212,11 @@ func parseATCResult(body []byte, atcResultFileName string, generateHTML bool) (e
 				reports = append(reports, piperutils.Path{Target: atcResultFileName, Name: "ATC Results HTML file", Mandatory: true})
 			}
 		}
+		if sarifFileName, err := writeATCSarif(body, atcResultFileName); err == nil {
+			reports = append(reports, piperutils.Path{Target: sarifFileName, Name: "ATC Results SARIF file"})
+		} else {
+			log.Entry().WithError(err).Warning("Writing SARIF file failed")
+		}
 		piperutils.PersistReportsAndLinks("abapEnvironmentRunATCCheck", "", reports, nil)
 	}
 	if err != nil {
@@ -219,6 +225,29 @@ func parseATCResult(body []byte, atcResultFileName string, generateHTML bool) (e
 	return nil
 }
 
+func writeATCSarif(body []byte, atcResultFileName string) (string, error) {
+	findings, err := reporting.FindingsFromCheckstyle(body)
+	if err != nil {
+		return "", err
+	}
+	findingsReport := reporting.FindingsReport{
+		StepName:   "abapEnvironmentRunATCCheck",
+		ToolName:   "ABAP Test Cockpit",
+		Successful: true,
+		Findings:   findings,
+	}
+	sarif, err := findingsReport.ToSARIF()
+	if err != nil {
+		return "", err
+	}
+	sarifFileName := reporting.SarifFileName(atcResultFileName)
+	if err := ioutil.WriteFile(sarifFileName, sarif, 0644); err != nil {
+		return "", err
+	}
+	log.Entry().Infof("Writing %s file was successful", sarifFileName)
+	return sarifFileName, nil
+}
+
 func runATC(requestType string, details abaputils.ConnectionDetailsHTTP, body []byte, client piperhttp.Sender) (*http.Response, error) {
 
 	log.Entry().WithField("ABAP endpoint: ", details.URL).Info("Triggering ATC run")
@@ -250,6 +250,10 @@ func TestParseATCResult(t *testing.T) {
 		body := []byte(bodyString)
 		err = parseATCResult(body, "ATCResults.xml", false)
 		assert.Equal(t, nil, err)
+		sarif, err := ioutil.ReadFile("ATCResults.sarif")
+		assert.NoError(t, err)
+		assert.Contains(t, string(sarif), `"text": "testMessage2"`)
+		assert.Contains(t, string(sarif), `"uri": "testFile2"`)
 	})
 	t.Run("succes case: test parsing empty XML result", func(t *testing.T) {
 		dir, err := ioutil.TempDir("", "test get result ATC run")
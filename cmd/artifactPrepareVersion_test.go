@@ -210,7 +210,7 @@ func TestRunArtifactPrepareVersion(t *testing.T) {
 		assert.Equal(t, worktree.commitHash.String(), cpe.git.commitID)
 		assert.Equal(t, "Test commit message", cpe.git.commitMessage)
 
-		assert.Equal(t, telemetry.CustomData{Custom1Label: "buildTool", Custom1: "maven", Custom2Label: "filePath", Custom2: ""}, telemetryData)
+		assert.Equal(t, telemetry.CustomData{BuildTool: "maven"}, telemetryData)
 	})
 
 	t.Run("success case - cloud_noTag", func(t *testing.T) {
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type checkConfigCommandOptions struct {
+	schemaFile string
+	strict     bool
+}
+
+var checkConfigOptions checkConfigCommandOptions
+
+type checkConfigUtils interface {
+	FileWrite(path string, content []byte, perm os.FileMode) error
+	// OpenFile opens a local file or downloads it in case of an http(s) URL
+	OpenFile(name string) (io.ReadCloser, error)
+}
+
+type checkConfigUtilsBundle struct {
+	*piperutils.Files
+}
+
+func (c *checkConfigUtilsBundle) OpenFile(name string) (io.ReadCloser, error) {
+	return config.OpenPiperFile(name)
+}
+
+// CheckConfigCommand is the entry command for validating the project configuration
+func CheckConfigCommand() *cobra.Command {
+	var createCheckConfigCmd = &cobra.Command{
+		Use:   "checkConfig",
+		Short: "Validates the project 'Piper' configuration and its custom defaults against the step metadata.",
+		Long: `Validates the project configuration, e.g. .pipeline/config.yml, and the custom defaults it references
+against a schema which is generated from the metadata of all steps.
+
+Values of the wrong type, values which are not among the possible values of a parameter and parameters
+within a section which the parameter does not support are reported as errors.
+Unknown steps and parameters are reported as warnings since they may be consumed outside of the piper binary,
+a suggestion is added in case the name looks like a typo.
+
+With --schemaFile the schema is written as JSON Schema which can be used e.g. by editors.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			path, _ := os.Getwd()
+			fatalHook := &log.FatalHook{CorrelationID: GeneralConfig.CorrelationID, Path: path}
+			log.RegisterHook(fatalHook)
+		},
+		Run: func(cmd *cobra.Command, _ []string) {
+			utils := &checkConfigUtilsBundle{Files: &piperutils.Files{}}
+			if err := runCheckConfig(checkConfigOptions, GetAllStepMetadata(), utils, os.Stdout); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				log.Entry().WithError(err).Fatal("configuration check failed")
+			}
+		},
+	}
+
+	createCheckConfigCmd.Flags().StringVar(&checkConfigOptions.schemaFile, "schemaFile", "", "Writes the JSON Schema of the configuration to the file instead of validating the configuration")
+	createCheckConfigCmd.Flags().BoolVar(&checkConfigOptions.strict, "strict", false, "Fails also in case of warnings, e.g. unknown parameters")
+	return createCheckConfigCmd
+}
+
+func runCheckConfig(options checkConfigCommandOptions, metadata map[string]config.StepData, utils checkConfigUtils, out io.Writer) error {
+	schema := config.NewSchema(metadata)
+
+	if len(options.schemaFile) > 0 {
+		content, err := json.MarshalIndent(schema.JSONSchema(), "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal schema")
+		}
+		if err := utils.FileWrite(options.schemaFile, content, 0666); err != nil {
+			return errors.Wrapf(err, "failed to write schema to %v", options.schemaFile)
+		}
+		log.Entry().Infof("Schema written to %v", options.schemaFile)
+		return nil
+	}
+
+	projectConfigFile := getProjectConfigFile(GeneralConfig.CustomConfig)
+	content, err := readConfigFile(utils, projectConfigFile)
+	if err != nil {
+		return err
+	}
+	findings, err := schema.Validate(projectConfigFile, content)
+	if err != nil {
+		return err
+	}
+
+	if !GeneralConfig.IgnoreCustomDefaults {
+		var projectConfig config.Config
+		if err := yaml.Unmarshal(content, &projectConfig); err != nil {
+			return errors.Wrapf(err, "failed to read custom defaults of %v", projectConfigFile)
+		}
+		for _, customDefaults := range projectConfig.CustomDefaults {
+			defaultsContent, err := readConfigFile(utils, customDefaults)
+			if err != nil {
+				return err
+			}
+			defaultsFindings, err := schema.Validate(customDefaults, defaultsContent)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, defaultsFindings...)
+		}
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, finding := range findings {
+		fmt.Fprintln(out, finding.String())
+		if finding.Severity == config.SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+
+	if errorCount > 0 || (options.strict && warningCount > 0) {
+		return fmt.Errorf("configuration contains %v errors and %v warnings", errorCount, warningCount)
+	}
+	log.Entry().Infof("Configuration is valid (%v warnings)", warningCount)
+	return nil
+}
+
+func readConfigFile(utils checkConfigUtils, name string) ([]byte, error) {
+	file, err := utils.OpenFile(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %v", name)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %v", name)
+	}
+	return content, nil
+}
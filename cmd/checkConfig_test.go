@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type checkConfigMockUtils struct {
+	*mock.FilesMock
+}
+
+func (c *checkConfigMockUtils) OpenFile(name string) (io.ReadCloser, error) {
+	content, err := c.FileRead(name)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+func checkConfigTestMetadata() map[string]config.StepData {
+	metadata := GetAllStepMetadata()
+	return map[string]config.StepData{
+		"mavenBuild":       metadata["mavenBuild"],
+		"kubernetesDeploy": metadata["kubernetesDeploy"],
+	}
+}
+
+func TestRunCheckConfig(t *testing.T) {
+	customConfigBak := GeneralConfig.CustomConfig
+	GeneralConfig.CustomConfig = ".pipeline/config.yml"
+	defer func() { GeneralConfig.CustomConfig = customConfigBak }()
+
+	t.Run("valid configuration", func(t *testing.T) {
+		utils := &checkConfigMockUtils{FilesMock: &mock.FilesMock{}}
+		utils.AddFile(".pipeline/config.yml", []byte("customDefaults:\n  - defaults.yml\nsteps:\n  mavenBuild:\n    pomPath: pom.xml\n"))
+		utils.AddFile("defaults.yml", []byte("general:\n  verbose: true\nsteps:\n  kubernetesDeploy:\n    deployTool: helm3\n"))
+		out := bytes.Buffer{}
+
+		err := runCheckConfig(checkConfigCommandOptions{}, checkConfigTestMetadata(), utils, &out)
+		assert.NoError(t, err)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("findings in custom defaults", func(t *testing.T) {
+		utils := &checkConfigMockUtils{FilesMock: &mock.FilesMock{}}
+		utils.AddFile(".pipeline/config.yml", []byte("customDefaults:\n  - defaults.yml\nsteps:\n  mavenBuild:\n    pomPth: pom.xml\n"))
+		utils.AddFile("defaults.yml", []byte("steps:\n  kubernetesDeploy:\n    deployTool: kustomize\n"))
+		out := bytes.Buffer{}
+
+		err := runCheckConfig(checkConfigCommandOptions{}, checkConfigTestMetadata(), utils, &out)
+		assert.EqualError(t, err, "configuration contains 1 errors and 1 warnings")
+		assert.Equal(t, ".pipeline/config.yml:5:5: warning: unknown parameter 'pomPth', did you mean 'pomPath'?\n"+
+			"defaults.yml:3:17: error: value 'kustomize' is not possible for parameter 'deployTool', possible values: kubectl, helm, helm3\n", out.String())
+	})
+
+	t.Run("warnings in strict mode", func(t *testing.T) {
+		utils := &checkConfigMockUtils{FilesMock: &mock.FilesMock{}}
+		utils.AddFile(".pipeline/config.yml", []byte("steps:\n  mavenBuild:\n    pomPth: pom.xml\n"))
+
+		err := runCheckConfig(checkConfigCommandOptions{}, checkConfigTestMetadata(), utils, &bytes.Buffer{})
+		assert.NoError(t, err)
+
+		err = runCheckConfig(checkConfigCommandOptions{strict: true}, checkConfigTestMetadata(), utils, &bytes.Buffer{})
+		assert.EqualError(t, err, "configuration contains 0 errors and 1 warnings")
+	})
+
+	t.Run("missing custom defaults", func(t *testing.T) {
+		utils := &checkConfigMockUtils{FilesMock: &mock.FilesMock{}}
+		utils.AddFile(".pipeline/config.yml", []byte("customDefaults:\n  - defaults.yml\n"))
+
+		err := runCheckConfig(checkConfigCommandOptions{}, checkConfigTestMetadata(), utils, &bytes.Buffer{})
+		assert.Contains(t, err.Error(), "failed to open defaults.yml")
+	})
+
+	t.Run("schema file", func(t *testing.T) {
+		utils := &checkConfigMockUtils{FilesMock: &mock.FilesMock{}}
+
+		err := runCheckConfig(checkConfigCommandOptions{schemaFile: "schema.json"}, checkConfigTestMetadata(), utils, &bytes.Buffer{})
+		assert.NoError(t, err)
+		content, err := utils.FileRead("schema.json")
+		require.NoError(t, err)
+		schema := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(content, &schema))
+		steps := schema["properties"].(map[string]interface{})["steps"].(map[string]interface{})["properties"].(map[string]interface{})
+		assert.Contains(t, steps, "mavenBuild")
+		assert.Contains(t, steps, "kubernetesDeploy")
+	})
+}
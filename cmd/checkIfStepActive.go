@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type checkStepActiveCommandOptions struct {
+	stageConfigFile string
+	branch          string
+	stage           string
+	step            string
+	output          string
+}
+
+var checkStepActiveOptions checkStepActiveCommandOptions
+
+type checkStepActiveUtils interface {
+	config.ConditionUtils
+	FileWrite(path string, content []byte, perm os.FileMode) error
+	// OpenFile opens a local file or downloads it in case of an http(s) URL
+	OpenFile(name string) (io.ReadCloser, error)
+}
+
+type checkStepActiveUtilsBundle struct {
+	*piperutils.Files
+}
+
+func (c *checkStepActiveUtilsBundle) OpenFile(name string) (io.ReadCloser, error) {
+	return config.OpenPiperFile(name)
+}
+
+// CheckStepActiveCommand is the entry command for checking which stages and steps are active
+func CheckStepActiveCommand() *cobra.Command {
+	var checkStepActiveCmd = &cobra.Command{
+		Use:   "checkIfStepActive",
+		Short: "Checks which stages and steps of the pipeline are active based on the stage conditions.",
+		Long: `Evaluates the conditions of the stage configuration, e.g. .resources/piper-stage-config.yml, against the
+project configuration, the defaults, the files within the workspace, the commonPipelineEnvironment and the branch.
+
+The active stages and steps are written as JSON map which can be consumed by any orchestrator:
+
+	{"stages": {"Build": true, ...}, "steps": {"Build": {"mavenExecuteStaticCodeChecks": true, ...}, ...}}
+
+With --stage and --step the command fails in case the step is not active in the stage.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			path, _ := os.Getwd()
+			fatalHook := &log.FatalHook{CorrelationID: GeneralConfig.CorrelationID, Path: path}
+			log.RegisterHook(fatalHook)
+		},
+		Run: func(cmd *cobra.Command, _ []string) {
+			utils := &checkStepActiveUtilsBundle{Files: &piperutils.Files{}}
+			if err := runCheckStepActive(checkStepActiveOptions, utils, os.Stdout); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				log.Entry().WithError(err).Fatal("checking for active steps failed")
+			}
+		},
+	}
+
+	checkStepActiveCmd.Flags().StringVar(&checkStepActiveOptions.stageConfigFile, "stageConfig", ".resources/piper-stage-config.yml", "Stage configuration containing the conditions of stages and steps, can be a file or an http(s) URL")
+	checkStepActiveCmd.Flags().StringVar(&checkStepActiveOptions.branch, "branch", "", "Branch which is checked against the branch conditions, defaults to the branch of the git repository")
+	checkStepActiveCmd.Flags().StringVar(&checkStepActiveOptions.stage, "stage", "", "Stage of the step which needs to be active")
+	checkStepActiveCmd.Flags().StringVar(&checkStepActiveOptions.step, "step", "", "Step which needs to be active")
+	checkStepActiveCmd.Flags().StringVar(&checkStepActiveOptions.output, "output", "", "Writes the active stages and steps to the file instead of stdout")
+	return checkStepActiveCmd
+}
+
+func runCheckStepActive(options checkStepActiveCommandOptions, utils checkStepActiveUtils, out io.Writer) error {
+	stageConfigFile, err := utils.OpenFile(options.stageConfigFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open stage configuration %v", options.stageConfigFile)
+	}
+	conditions, err := config.ReadStageConditions(stageConfigFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read stage configuration %v", options.stageConfigFile)
+	}
+
+	var projectConfig config.Config
+	projectConfigFile := getProjectConfigFile(GeneralConfig.CustomConfig)
+	customConfig, err := utils.OpenFile(projectConfigFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return errors.Wrapf(err, "config: open configuration file '%v' failed", projectConfigFile)
+		}
+		customConfig = nil
+	}
+
+	defaultsResolver := newDefaultsResolver(utils.OpenFile)
+	defaultConfig := []io.ReadCloser{}
+	for _, f := range GeneralConfig.DefaultConfig {
+		fc, err := defaultsResolver.Open(f)
+		// only create error for non-default values
+		if err != nil && f != ".pipeline/defaults.yaml" {
+			return errors.Wrapf(err, "config: getting defaults failed: '%v'", f)
+		}
+		if err == nil {
+			defaultConfig = append(defaultConfig, fc)
+		}
+	}
+
+	projectConfig.SetEnvRootPath(GeneralConfig.EnvRootPath)
+	projectConfig.SetDefaultsResolver(defaultsResolver)
+	if err := projectConfig.InitializeConfig(customConfig, defaultConfig, GeneralConfig.IgnoreCustomDefaults); err != nil {
+		return err
+	}
+
+	activation, err := projectConfig.EvaluateStageConditions(conditions, options.branch, utils)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(activation)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal active stages and steps")
+	}
+	if len(options.output) > 0 {
+		if err := utils.FileWrite(options.output, content, 0666); err != nil {
+			return errors.Wrapf(err, "failed to write active stages and steps to %v", options.output)
+		}
+	} else {
+		fmt.Fprintln(out, string(content))
+	}
+
+	if len(options.step) > 0 {
+		if len(options.stage) == 0 {
+			return fmt.Errorf("the stage of step '%v' is required", options.step)
+		}
+		if !activation.Stages[options.stage] || !activation.Steps[options.stage][options.step] {
+			return fmt.Errorf("step '%v' in stage '%v' is not active", options.step, options.stage)
+		}
+		log.Entry().Infof("Step '%v' in stage '%v' is active", options.step, options.stage)
+	}
+	return nil
+}
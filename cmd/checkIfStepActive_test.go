@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type checkStepActiveMockUtils struct {
+	*mock.FilesMock
+}
+
+func (c *checkStepActiveMockUtils) OpenFile(name string) (io.ReadCloser, error) {
+	content, err := c.FileRead(name)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+func newCheckStepActiveMockUtils() *checkStepActiveMockUtils {
+	utils := &checkStepActiveMockUtils{FilesMock: &mock.FilesMock{}}
+	utils.AddFile("stage-config.yml", []byte(`stages:
+  Build:
+    stepConditions:
+      mavenExecuteStaticCodeChecks:
+        filePattern: pom.xml
+  Acceptance:
+    stepConditions:
+      cloudFoundryDeploy:
+        configKeys: cloudFoundry/space
+`))
+	utils.AddFile("pom.xml", []byte{})
+	return utils
+}
+
+func TestRunCheckStepActive(t *testing.T) {
+	customConfigBak := GeneralConfig.CustomConfig
+	GeneralConfig.CustomConfig = ".pipeline/config.yml"
+	defer func() { GeneralConfig.CustomConfig = customConfigBak }()
+
+	t.Run("active stages and steps", func(t *testing.T) {
+		utils := newCheckStepActiveMockUtils()
+		out := bytes.Buffer{}
+
+		err := runCheckStepActive(checkStepActiveCommandOptions{stageConfigFile: "stage-config.yml", branch: "master"}, utils, &out)
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"stages": {"Build": true, "Acceptance": false}, "steps": {"Build": {"mavenExecuteStaticCodeChecks": true}, "Acceptance": {"cloudFoundryDeploy": false}}}`, out.String())
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		utils := newCheckStepActiveMockUtils()
+		utils.AddFile(".pipeline/config.yml", []byte("steps:\n  cloudFoundryDeploy:\n    cloudFoundry:\n      space: dev\n"))
+		out := bytes.Buffer{}
+
+		err := runCheckStepActive(checkStepActiveCommandOptions{stageConfigFile: "stage-config.yml", branch: "master", output: "active.json", stage: "Acceptance", step: "cloudFoundryDeploy"}, utils, &out)
+
+		assert.NoError(t, err)
+		assert.Empty(t, out.String())
+		content, err := utils.FileRead("active.json")
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"stages": {"Build": true, "Acceptance": true}, "steps": {"Build": {"mavenExecuteStaticCodeChecks": true}, "Acceptance": {"cloudFoundryDeploy": true}}}`, string(content))
+	})
+
+	t.Run("inactive step", func(t *testing.T) {
+		utils := newCheckStepActiveMockUtils()
+
+		err := runCheckStepActive(checkStepActiveCommandOptions{stageConfigFile: "stage-config.yml", branch: "master", stage: "Acceptance", step: "cloudFoundryDeploy"}, utils, &bytes.Buffer{})
+
+		assert.EqualError(t, err, "step 'cloudFoundryDeploy' in stage 'Acceptance' is not active")
+	})
+
+	t.Run("missing stage configuration", func(t *testing.T) {
+		err := runCheckStepActive(checkStepActiveCommandOptions{stageConfigFile: "unknown.yml"}, &checkStepActiveMockUtils{FilesMock: &mock.FilesMock{}}, &bytes.Buffer{})
+
+		assert.EqualError(t, err, "failed to open stage configuration unknown.yml: file does not exist")
+	})
+}
@@ -20,6 +20,7 @@ import (
 	piperHttp "github.com/SAP/jenkins-library/pkg/http"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/SAP/jenkins-library/pkg/reporting"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
 	"github.com/bmatcuk/doublestar"
 	"github.com/pkg/errors"
@@ -291,6 +292,7 @@ func verifyCxProjectCompliance(config checkmarxExecuteScanOptions, sys checkmarx
 		return errors.Wrap(err, "failed to get detailed results")
 	}
 	reports = append(reports, piperutils.Path{Target: xmlReportName})
+	reports = append(reports, piperutils.Path{Target: reporting.SarifFileName(xmlReportName)})
 	links := []piperutils.Path{{Target: results["DeepLink"].(string), Name: "Checkmarx Web UI"}}
 	piperutils.PersistReportsAndLinks("checkmarxExecuteScan", utils.GetWorkspace(), reports, links)
 
@@ -614,6 +616,9 @@ func getDetailedResults(sys checkmarx.System, reportFileName string, scanID int,
 		resultMap["Preset"] = xmlResult.Preset
 		resultMap["DeepLink"] = xmlResult.DeepLink
 		resultMap["ReportCreationTime"] = xmlResult.ReportCreationTime
+		if err := writeCheckmarxSarif(xmlResult, reporting.SarifFileName(reportFileName), utils); err != nil {
+			log.Entry().WithError(err).Warning("failed to write SARIF report")
+		}
 		resultMap["High"] = map[string]int{}
 		resultMap["Medium"] = map[string]int{}
 		resultMap["Low"] = map[string]int{}
@@ -660,6 +665,43 @@ func getDetailedResults(sys checkmarx.System, reportFileName string, scanID int,
 	return resultMap, nil
 }
 
+func writeCheckmarxSarif(xmlResult checkmarx.DetailedResult, sarifFileName string, utils checkmarxExecuteScanUtils) error {
+	findingsReport := reporting.FindingsReport{
+		StepName:       "checkmarxExecuteScan",
+		ToolName:       "Checkmarx",
+		ToolVersion:    xmlResult.CheckmarxVersion,
+		InformationURI: xmlResult.DeepLink,
+		Successful:     true,
+		Findings:       []reporting.Finding{},
+	}
+	for _, query := range xmlResult.Queries {
+		ruleName := query.Name
+		if len(query.CweID) > 0 {
+			ruleName = fmt.Sprintf("%v (CWE-%v)", query.Name, query.CweID)
+		}
+		for _, result := range query.Results {
+			if result.FalsePositive == "True" {
+				continue
+			}
+			findingsReport.AddFinding(reporting.Finding{
+				RuleID:   query.Name,
+				RuleName: ruleName,
+				Message:  fmt.Sprintf("%v in %v line %v", query.Name, result.FileName, result.Line),
+				Severity: reporting.ParseSeverity(result.Severity),
+				Location: reporting.FindingLocation{File: result.FileName, StartLine: result.Line, StartColumn: result.Column},
+				HelpURI:  result.DeepLink,
+				// audit state "0" means "to verify"
+				Audited: len(result.State) > 0 && result.State != "0",
+			})
+		}
+	}
+	sarif, err := findingsReport.ToSARIF()
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(sarifFileName, sarif, 0700)
+}
+
 func zipFolder(source string, zipFile io.Writer, patterns []string, utils checkmarxExecuteScanUtils) error {
 	archive := zip.NewWriter(zipFile)
 	defer archive.Close()
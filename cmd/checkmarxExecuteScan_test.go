@@ -482,6 +482,12 @@ func TestGetDetailedResults(t *testing.T) {
 		assert.Equal(t, 2, result["High"].(map[string]int)["NotFalsePositive"], "Number of High NotFalsePositive issues incorrect")
 		assert.Equal(t, 1, result["Medium"].(map[string]int)["Issues"], "Number of Medium issues incorrect")
 		assert.Equal(t, 0, result["Medium"].(map[string]int)["NotFalsePositive"], "Number of Medium NotFalsePositive issues incorrect")
+		sarif, err := ioutil.ReadFile(filepath.Join(dir, "abc.sarif"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(sarif), `"ruleId": "SQL_Injection"`)
+		assert.Contains(t, string(sarif), `"text": "SQL_Injection (CWE-89)"`)
+		assert.Contains(t, string(sarif), `"text": "SQL_Injection in bookstore/Login.cs line 180"`)
+		assert.NotContains(t, string(sarif), "line 181", "false positives must not be contained")
 	})
 
 	t.Run("error on write file", func(t *testing.T) {
@@ -8,19 +8,22 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type cloudFoundryCreateServiceKeyOptions struct {
-	CfAPIEndpoint      string `json:"cfApiEndpoint,omitempty"`
-	Username           string `json:"username,omitempty"`
-	Password           string `json:"password,omitempty"`
-	CfOrg              string `json:"cfOrg,omitempty"`
-	CfSpace            string `json:"cfSpace,omitempty"`
-	CfServiceInstance  string `json:"cfServiceInstance,omitempty"`
-	CfServiceKeyName   string `json:"cfServiceKeyName,omitempty"`
+	CfAPIEndpoint      string `json:"cfApiEndpoint,omitempty" validate:"required"`
+	Username           string `json:"username,omitempty" validate:"required"`
+	Password           string `json:"password,omitempty" validate:"required"`
+	CfOrg              string `json:"cfOrg,omitempty" validate:"required"`
+	CfSpace            string `json:"cfSpace,omitempty" validate:"required"`
+	CfServiceInstance  string `json:"cfServiceInstance,omitempty" validate:"required"`
+	CfServiceKeyName   string `json:"cfServiceKeyName,omitempty" validate:"required"`
 	CfServiceKeyConfig string `json:"cfServiceKeyConfig,omitempty"`
 }
 
@@ -48,11 +51,22 @@ func CloudFoundryCreateServiceKeyCommand() *cobra.Command {
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Username)
 			log.RegisterSecret(stepConfig.Password)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -65,9 +79,15 @@ func CloudFoundryCreateServiceKeyCommand() *cobra.Command {
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -134,6 +154,36 @@ func cloudFoundryCreateServiceKeyMetadata() config.StepData {
 								Paths: []string{"$(vaultPath)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/$(vaultPipelineName)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/GROUP-SECRETS/cloudfoundry-$(org)-$(space)"},
 								Type:  "vaultSecret",
 							},
+
+							{
+								Name:  "",
+								Paths: []string{"cloudfoundry-$(org)-$(space)"},
+								Type:  "fileSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"PIPER_CLOUDFOUNDRY"},
+								Type:  "envSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "kubernetesSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper/cloudfoundry-$(org)-$(space)"},
+								Type:  "awsSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "azureSecret",
+							},
 						},
 						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
 						Type:      "string",
@@ -154,6 +204,36 @@ func cloudFoundryCreateServiceKeyMetadata() config.StepData {
 								Paths: []string{"$(vaultPath)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/$(vaultPipelineName)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/GROUP-SECRETS/cloudfoundry-$(org)-$(space)"},
 								Type:  "vaultSecret",
 							},
+
+							{
+								Name:  "",
+								Paths: []string{"cloudfoundry-$(org)-$(space)"},
+								Type:  "fileSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"PIPER_CLOUDFOUNDRY"},
+								Type:  "envSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "kubernetesSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper/cloudfoundry-$(org)-$(space)"},
+								Type:  "awsSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "azureSecret",
+							},
 						},
 						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
 						Type:      "string",
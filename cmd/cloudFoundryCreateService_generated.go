@@ -8,17 +8,20 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type cloudFoundryCreateServiceOptions struct {
-	CfAPIEndpoint          string   `json:"cfApiEndpoint,omitempty"`
-	Username               string   `json:"username,omitempty"`
-	Password               string   `json:"password,omitempty"`
-	CfOrg                  string   `json:"cfOrg,omitempty"`
-	CfSpace                string   `json:"cfSpace,omitempty"`
+	CfAPIEndpoint          string   `json:"cfApiEndpoint,omitempty" validate:"required"`
+	Username               string   `json:"username,omitempty" validate:"required"`
+	Password               string   `json:"password,omitempty" validate:"required"`
+	CfOrg                  string   `json:"cfOrg,omitempty" validate:"required"`
+	CfSpace                string   `json:"cfSpace,omitempty" validate:"required"`
 	CfService              string   `json:"cfService,omitempty"`
 	CfServicePlan          string   `json:"cfServicePlan,omitempty"`
 	CfServiceInstanceName  string   `json:"cfServiceInstanceName,omitempty"`
@@ -60,11 +63,22 @@ Please provide either of the following options:
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Username)
 			log.RegisterSecret(stepConfig.Password)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -77,9 +91,15 @@ Please provide either of the following options:
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -153,6 +173,36 @@ func cloudFoundryCreateServiceMetadata() config.StepData {
 								Paths: []string{"$(vaultPath)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/$(vaultPipelineName)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/GROUP-SECRETS/cloudfoundry-$(org)-$(space)"},
 								Type:  "vaultSecret",
 							},
+
+							{
+								Name:  "",
+								Paths: []string{"cloudfoundry-$(org)-$(space)"},
+								Type:  "fileSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"PIPER_CLOUDFOUNDRY"},
+								Type:  "envSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "kubernetesSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper/cloudfoundry-$(org)-$(space)"},
+								Type:  "awsSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "azureSecret",
+							},
 						},
 						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
 						Type:      "string",
@@ -173,6 +223,36 @@ func cloudFoundryCreateServiceMetadata() config.StepData {
 								Paths: []string{"$(vaultPath)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/$(vaultPipelineName)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/GROUP-SECRETS/cloudfoundry-$(org)-$(space)"},
 								Type:  "vaultSecret",
 							},
+
+							{
+								Name:  "",
+								Paths: []string{"cloudfoundry-$(org)-$(space)"},
+								Type:  "fileSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"PIPER_CLOUDFOUNDRY"},
+								Type:  "envSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "kubernetesSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper/cloudfoundry-$(org)-$(space)"},
+								Type:  "awsSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "azureSecret",
+							},
 						},
 						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
 						Type:      "string",
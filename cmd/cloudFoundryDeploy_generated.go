@@ -9,14 +9,17 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperenv"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type cloudFoundryDeployOptions struct {
-	APIEndpoint              string                 `json:"apiEndpoint,omitempty"`
+	APIEndpoint              string                 `json:"apiEndpoint,omitempty" validate:"required"`
 	AppName                  string                 `json:"appName,omitempty"`
 	ArtifactVersion          string                 `json:"artifactVersion,omitempty"`
 	CfHome                   string                 `json:"cfHome,omitempty"`
@@ -37,12 +40,12 @@ type cloudFoundryDeployOptions struct {
 	MtaExtensionDescriptor   string                 `json:"mtaExtensionDescriptor,omitempty"`
 	MtaExtensionCredentials  map[string]interface{} `json:"mtaExtensionCredentials,omitempty"`
 	MtaPath                  string                 `json:"mtaPath,omitempty"`
-	Org                      string                 `json:"org,omitempty"`
-	Password                 string                 `json:"password,omitempty"`
+	Org                      string                 `json:"org,omitempty" validate:"required"`
+	Password                 string                 `json:"password,omitempty" validate:"required"`
 	SmokeTestScript          string                 `json:"smokeTestScript,omitempty"`
 	SmokeTestStatusCode      int                    `json:"smokeTestStatusCode,omitempty"`
-	Space                    string                 `json:"space,omitempty"`
-	Username                 string                 `json:"username,omitempty"`
+	Space                    string                 `json:"space,omitempty" validate:"required"`
+	Username                 string                 `json:"username,omitempty" validate:"required"`
 }
 
 type cloudFoundryDeployInflux struct {
@@ -119,6 +122,9 @@ func CloudFoundryDeployCommand() *cobra.Command {
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.DockerPassword)
@@ -126,6 +132,14 @@ func CloudFoundryDeployCommand() *cobra.Command {
 			log.RegisterSecret(stepConfig.Password)
 			log.RegisterSecret(stepConfig.Username)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -138,10 +152,16 @@ func CloudFoundryDeployCommand() *cobra.Command {
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				influx.persist(GeneralConfig.EnvRootPath, "influx")
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -420,6 +440,36 @@ func cloudFoundryDeployMetadata() config.StepData {
 								Paths: []string{"$(vaultPath)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/$(vaultPipelineName)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/GROUP-SECRETS/cloudfoundry-$(org)-$(space)"},
 								Type:  "vaultSecret",
 							},
+
+							{
+								Name:  "",
+								Paths: []string{"cloudfoundry-$(org)-$(space)"},
+								Type:  "fileSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"PIPER_CLOUDFOUNDRY"},
+								Type:  "envSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "kubernetesSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper/cloudfoundry-$(org)-$(space)"},
+								Type:  "awsSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "azureSecret",
+							},
 						},
 						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
 						Type:      "string",
@@ -464,6 +514,36 @@ func cloudFoundryDeployMetadata() config.StepData {
 								Paths: []string{"$(vaultPath)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/$(vaultPipelineName)/cloudfoundry-$(org)-$(space)", "$(vaultBasePath)/GROUP-SECRETS/cloudfoundry-$(org)-$(space)"},
 								Type:  "vaultSecret",
 							},
+
+							{
+								Name:  "",
+								Paths: []string{"cloudfoundry-$(org)-$(space)"},
+								Type:  "fileSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"PIPER_CLOUDFOUNDRY"},
+								Type:  "envSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "kubernetesSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper/cloudfoundry-$(org)-$(space)"},
+								Type:  "awsSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-cloudfoundry-$(org)-$(space)"},
+								Type:  "azureSecret",
+							},
 						},
 						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
 						Type:      "string",
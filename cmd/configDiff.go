@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	piperGit "github.com/SAP/jenkins-library/pkg/git"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/go-git/go-git/v5"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type configDiffCommandOptions struct {
+	fromRef    string
+	toRef      string
+	fromStage  string
+	toStage    string
+	fromConfig string
+	toConfig   string
+	steps      []string
+	output     string
+}
+
+var configDiffOptions configDiffCommandOptions
+
+type configDiffUtils interface {
+	// OpenFile opens a local file or downloads it in case of an http(s) URL
+	OpenFile(name string) (io.ReadCloser, error)
+	// ReadFileAtRef returns the content of a file as it is committed at the git ref
+	ReadFileAtRef(ref, path string) ([]byte, error)
+}
+
+type configDiffUtilsBundle struct {
+	repository *git.Repository
+}
+
+func (c *configDiffUtilsBundle) OpenFile(name string) (io.ReadCloser, error) {
+	return config.OpenPiperFile(name)
+}
+
+func (c *configDiffUtilsBundle) ReadFileAtRef(ref, path string) ([]byte, error) {
+	if c.repository == nil {
+		repository, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open git repository")
+		}
+		c.repository = repository
+	}
+	return piperGit.ReadFileAtRef(c.repository, ref, path)
+}
+
+// configDiffSide is one side of the comparison, i.e. the configuration at a git ref, of a stage or of a configuration file
+type configDiffSide struct {
+	ref        string
+	stage      string
+	configFile string
+	// defaultsResolver is shared by all steps of the side, i.e. remote defaults are retrieved only once
+	defaultsResolver *config.DefaultsResolver
+}
+
+func (s configDiffSide) String() string {
+	parts := []string{}
+	if len(s.ref) > 0 {
+		parts = append(parts, fmt.Sprintf("ref '%v'", s.ref))
+	}
+	if len(s.stage) > 0 {
+		parts = append(parts, fmt.Sprintf("stage '%v'", s.stage))
+	}
+	return strings.Join(append(parts, s.configFile), ", ")
+}
+
+// ConfigDiffCommand is the entry command for comparing the resolved configuration of steps
+func ConfigDiffCommand() *cobra.Command {
+	var createConfigDiffCmd = &cobra.Command{
+		Use:   "configDiff",
+		Short: "Compares the resolved step configuration between git refs, stages or configuration files.",
+		Long: `Resolves the configuration of every step in the same way as getConfig does for two sides and prints the differences per step.
+
+A side consists of a git ref, a stage and a configuration file, e.g. to compare the configuration of the
+productive branch with the one of a pull request:
+
+	piper configDiff --fromRef origin/master --toRef HEAD
+
+or to compare the configuration of two stages:
+
+	piper configDiff --fromStage "Pull-Request Voting" --toStage Release
+
+The project configuration and local custom defaults are read as committed at the git ref, files which are not
+committed, e.g. defaults provided by the pipeline, are read from the workspace. Values of secrets are masked.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			path, _ := os.Getwd()
+			fatalHook := &log.FatalHook{CorrelationID: GeneralConfig.CorrelationID, Path: path}
+			log.RegisterHook(fatalHook)
+			initStageName(false)
+		},
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := runConfigDiff(configDiffOptions, GetAllStepMetadata(), &configDiffUtilsBundle{}, os.Stdout); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				log.Entry().WithError(err).Fatal("configuration comparison failed")
+			}
+		},
+	}
+
+	createConfigDiffCmd.Flags().StringVar(&configDiffOptions.fromRef, "fromRef", "", "Git ref of the configuration to compare, defaults to the workspace")
+	createConfigDiffCmd.Flags().StringVar(&configDiffOptions.toRef, "toRef", "", "Git ref of the configuration to compare with, defaults to the workspace")
+	createConfigDiffCmd.Flags().StringVar(&configDiffOptions.fromStage, "fromStage", "", "Stage of the configuration to compare, defaults to the current stage")
+	createConfigDiffCmd.Flags().StringVar(&configDiffOptions.toStage, "toStage", "", "Stage of the configuration to compare with, defaults to the current stage")
+	createConfigDiffCmd.Flags().StringVar(&configDiffOptions.fromConfig, "fromConfig", "", "Configuration file to compare, defaults to the project configuration")
+	createConfigDiffCmd.Flags().StringVar(&configDiffOptions.toConfig, "toConfig", "", "Configuration file to compare with, defaults to the project configuration")
+	createConfigDiffCmd.Flags().StringSliceVar(&configDiffOptions.steps, "steps", []string{}, "Steps to compare, defaults to all steps")
+	createConfigDiffCmd.Flags().StringVar(&configDiffOptions.output, "output", "text", "Defines the output format, either text or json")
+	return createConfigDiffCmd
+}
+
+func runConfigDiff(options configDiffCommandOptions, metadata map[string]config.StepData, utils configDiffUtils, out io.Writer) error {
+	if options.output != "text" && options.output != "json" {
+		return fmt.Errorf("output format '%v' is not supported, use text or json", options.output)
+	}
+	projectConfigFile := getProjectConfigFile(GeneralConfig.CustomConfig)
+	from := configDiffSide{ref: options.fromRef, stage: options.fromStage, configFile: options.fromConfig}
+	to := configDiffSide{ref: options.toRef, stage: options.toStage, configFile: options.toConfig}
+	for _, side := range []*configDiffSide{&from, &to} {
+		if len(side.stage) == 0 {
+			side.stage = GeneralConfig.StageName
+		}
+		if len(side.configFile) == 0 {
+			side.configFile = projectConfigFile
+		}
+		side.defaultsResolver = newDefaultsResolver(side.openFile(utils))
+	}
+
+	steps := options.steps
+	if len(steps) == 0 {
+		for name := range metadata {
+			steps = append(steps, name)
+		}
+		sort.Strings(steps)
+	}
+
+	differences := []config.Difference{}
+	for _, stepName := range steps {
+		stepMetadata, ok := metadata[stepName]
+		if !ok {
+			return fmt.Errorf("step '%v' does not exist", stepName)
+		}
+		fromConfig, err := from.resolve(stepMetadata, utils)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve configuration of step '%v' for %v", stepName, from)
+		}
+		toConfig, err := to.resolve(stepMetadata, utils)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve configuration of step '%v' for %v", stepName, to)
+		}
+		differences = append(differences, config.DiffStepConfig(stepName, fromConfig, toConfig, secretParameters(&stepMetadata))...)
+	}
+
+	if options.output == "json" {
+		content, err := json.MarshalIndent(differences, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal differences")
+		}
+		fmt.Fprintln(out, string(content))
+		return nil
+	}
+
+	if len(differences) == 0 {
+		log.Entry().Infof("No differences between %v and %v", from, to)
+		return nil
+	}
+	fmt.Fprintf(out, "--- %v\n+++ %v\n", from, to)
+	stepName := ""
+	for _, difference := range differences {
+		if difference.Step != stepName {
+			stepName = difference.Step
+			fmt.Fprintf(out, "%v:\n", stepName)
+		}
+		fmt.Fprintf(out, "  %v\n", difference.String())
+	}
+	return nil
+}
+
+// resolve resolves the configuration of the step for the side in the same way as getConfig.
+// Secrets are not fetched since their values are masked anyway.
+func (s configDiffSide) resolve(metadata config.StepData, utils configDiffUtils) (map[string]interface{}, error) {
+	customConfig, err := s.openProjectConfig(utils)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "config: open configuration file '%v' failed", s.configFile)
+		}
+		customConfig = nil
+	}
+	var myConfig config.Config
+	myConfig.SkipSecrets()
+	stepConfig, err := resolveStepConfig(&myConfig, &metadata, customConfig, s.defaultsResolver, s.stage)
+	if err != nil {
+		return nil, err
+	}
+	return stepConfig.Config, nil
+}
+
+func (s configDiffSide) openProjectConfig(utils configDiffUtils) (io.ReadCloser, error) {
+	if len(s.ref) == 0 {
+		return utils.OpenFile(s.configFile)
+	}
+	content, err := utils.ReadFileAtRef(s.ref, path.Clean(s.configFile))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+// openFile opens local files as committed at the ref of the side, files which are not committed are opened from the workspace.
+// Remote defaults are resolved by the defaults resolver and never reach openFile.
+func (s configDiffSide) openFile(utils configDiffUtils) func(name string) (io.ReadCloser, error) {
+	return func(name string) (io.ReadCloser, error) {
+		if len(s.ref) > 0 {
+			content, err := utils.ReadFileAtRef(s.ref, path.Clean(name))
+			if err == nil {
+				return ioutil.NopCloser(bytes.NewReader(content)), nil
+			}
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+		return utils.OpenFile(name)
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type configDiffMockUtils struct {
+	files map[string]string
+	// refs contains the committed files per git ref
+	refs map[string]map[string]string
+}
+
+func (c *configDiffMockUtils) OpenFile(name string) (io.ReadCloser, error) {
+	content, ok := c.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader([]byte(content))), nil
+}
+
+func (c *configDiffMockUtils) ReadFileAtRef(ref, path string) ([]byte, error) {
+	content, ok := c.refs[ref][path]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: ref + ":" + path, Err: os.ErrNotExist}
+	}
+	return []byte(content), nil
+}
+
+func configDiffTestMetadata() map[string]config.StepData {
+	metadata := GetAllStepMetadata()
+	return map[string]config.StepData{
+		"mavenBuild":           metadata["mavenBuild"],
+		"githubPublishRelease": metadata["githubPublishRelease"],
+	}
+}
+
+func TestRunConfigDiff(t *testing.T) {
+	customConfigBak := GeneralConfig.CustomConfig
+	GeneralConfig.CustomConfig = ".pipeline/config.yml"
+	defer func() { GeneralConfig.CustomConfig = customConfigBak }()
+
+	utils := &configDiffMockUtils{
+		files: map[string]string{
+			".pipeline/config.yml": "customDefaults: [defaults.yml]\nsteps:\n  mavenBuild:\n    pomPath: api/pom.xml\n  githubPublishRelease:\n    token: new-token\nstages:\n  Release:\n    verbose: true\n",
+			"defaults.yml":         "steps:\n  mavenBuild:\n    projectSettingsFile: settings.xml\n",
+		},
+		refs: map[string]map[string]string{
+			"master": {
+				".pipeline/config.yml": "customDefaults: [defaults.yml]\nsteps:\n  githubPublishRelease:\n    token: old-token\n",
+				"defaults.yml":         "steps:\n  mavenBuild:\n    projectSettingsFile: old-settings.xml\n",
+			},
+		},
+	}
+
+	t.Run("git refs", func(t *testing.T) {
+		out := bytes.Buffer{}
+
+		err := runConfigDiff(configDiffCommandOptions{fromRef: "master", output: "text"}, configDiffTestMetadata(), utils, &out)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `--- ref 'master', .pipeline/config.yml
++++ .pipeline/config.yml
+githubPublishRelease:
+  ~ token: **** -> ****
+mavenBuild:
+  + pomPath: api/pom.xml
+  ~ projectSettingsFile: old-settings.xml -> settings.xml
+`, out.String())
+	})
+
+	t.Run("stages as JSON", func(t *testing.T) {
+		out := bytes.Buffer{}
+
+		err := runConfigDiff(configDiffCommandOptions{toStage: "Release", steps: []string{"mavenBuild"}, output: "json"}, configDiffTestMetadata(), utils, &out)
+
+		assert.NoError(t, err)
+		var differences []config.Difference
+		require.NoError(t, json.Unmarshal(out.Bytes(), &differences))
+		assert.Equal(t, []config.Difference{{Step: "mavenBuild", Parameter: "verbose", Kind: config.DifferenceAdded, To: true}}, differences)
+	})
+
+	t.Run("no differences", func(t *testing.T) {
+		out := bytes.Buffer{}
+
+		err := runConfigDiff(configDiffCommandOptions{output: "text"}, configDiffTestMetadata(), utils, &out)
+
+		assert.NoError(t, err)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("unknown step", func(t *testing.T) {
+		err := runConfigDiff(configDiffCommandOptions{steps: []string{"unknown"}, output: "text"}, configDiffTestMetadata(), utils, &bytes.Buffer{})
+
+		assert.EqualError(t, err, "step 'unknown' does not exist")
+	})
+
+	t.Run("unsupported output", func(t *testing.T) {
+		err := runConfigDiff(configDiffCommandOptions{output: "yaml"}, configDiffTestMetadata(), utils, &bytes.Buffer{})
+
+		assert.EqualError(t, err, "output format 'yaml' is not supported, use text or json")
+	})
+}
@@ -10,9 +10,11 @@ import (
 	piperhttp "github.com/SAP/jenkins-library/pkg/http"
 	"github.com/SAP/jenkins-library/pkg/maven"
 
+	"github.com/SAP/jenkins-library/pkg/blackduck"
 	"github.com/SAP/jenkins-library/pkg/command"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/SAP/jenkins-library/pkg/reporting"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
 	"github.com/SAP/jenkins-library/pkg/versioning"
 )
@@ -36,6 +38,8 @@ type detectUtils interface {
 	RunShell(shell, script string) error
 
 	DownloadFile(url, filename string, header http.Header, cookies []*http.Cookie) error
+	SendRequest(method, url string, body io.Reader, header http.Header, cookies []*http.Cookie) (*http.Response, error)
+	SetOptions(options piperhttp.ClientOptions)
 }
 
 type detectUtilsBundle struct {
@@ -64,9 +68,12 @@ func newDetectUtils() detectUtils {
 	return &utils
 }
 
+const detectSarifFile = "detectExecuteScan.sarif"
+
 func detectExecuteScan(config detectExecuteScanOptions, _ *telemetry.CustomData) {
 	utils := newDetectUtils()
 	err := runDetect(config, utils)
+	piperutils.PersistReportsAndLinks("detectExecuteScan", "", []piperutils.Path{{Target: detectSarifFile}}, nil)
 
 	if err != nil {
 		log.Entry().
@@ -116,7 +123,57 @@ func runDetect(config detectExecuteScanOptions, utils detectUtils) error {
 	utils.SetDir(".")
 	utils.SetEnv(envs)
 
-	return utils.RunShell("/bin/bash", script)
+	err = utils.RunShell("/bin/bash", script)
+	if sarifErr := writeDetectSarif(config, err == nil, utils); sarifErr != nil {
+		log.Entry().WithError(sarifErr).Warning("failed to write SARIF report")
+	}
+	return err
+}
+
+// writeDetectSarif writes a SARIF report with the vulnerabilities which Black Duck reports for the scanned project version.
+// In case they cannot be retrieved, the report only contains the status of the scan.
+func writeDetectSarif(config detectExecuteScanOptions, successful bool, utils detectUtils) error {
+	report := reporting.FindingsReport{
+		StepName:       "detectExecuteScan",
+		ToolName:       "Synopsys Detect",
+		InformationURI: "https://www.synopsys.com/software-integrity/security-testing/software-composition-analysis.html",
+		Successful:     successful,
+	}
+	client := blackduck.NewClient(config.Token, config.ServerURL, utils)
+	components, err := client.GetVulnerableComponents(config.ProjectName, getDetectVersionName(config))
+	if err != nil {
+		log.Entry().WithError(err).Warning("failed to retrieve vulnerabilities from Black Duck, SARIF report contains no findings")
+	} else {
+		report.Findings = []reporting.Finding{}
+		for _, component := range components {
+			report.AddFinding(detectFinding(component))
+		}
+	}
+	sarif, err := report.ToSARIF()
+	if err != nil {
+		return err
+	}
+	return utils.FileWrite(detectSarifFile, sarif, 0666)
+}
+
+func detectFinding(component blackduck.VulnerableComponent) reporting.Finding {
+	vulnerability := component.Vulnerability
+	name := reporting.ComponentName(component.PackageName())
+	severity := reporting.SeverityFromCVSS(vulnerability.BaseScore)
+	if severity == reporting.SeverityUnknown {
+		severity = reporting.ParseSeverity(vulnerability.Severity)
+	}
+	return reporting.Finding{
+		RuleID:           vulnerability.Name,
+		RuleName:         vulnerability.Name,
+		Message:          fmt.Sprintf("%v in %v %v: %v", vulnerability.Name, name, component.Version, vulnerability.Description),
+		Severity:         severity,
+		Score:            vulnerability.BaseScore,
+		VulnerabilityID:  vulnerability.Name,
+		Component:        name,
+		ComponentVersion: component.Version,
+		Audited:          vulnerability.Audited(),
+	}
 }
 
 func getDetectScript(config detectExecuteScanOptions, utils detectUtils) error {
@@ -128,15 +185,9 @@ func getDetectScript(config detectExecuteScanOptions, utils detectUtils) error {
 
 func addDetectArgs(args []string, config detectExecuteScanOptions, utils detectUtils) ([]string, error) {
 
-	coordinates := versioning.Coordinates{
-		Version: config.Version,
-	}
-
-	detectVersionName := config.CustomScanVersion
-	if len(detectVersionName) > 0 {
+	detectVersionName := getDetectVersionName(config)
+	if len(config.CustomScanVersion) > 0 {
 		log.Entry().Infof("Using custom version: %v", detectVersionName)
-	} else {
-		detectVersionName = versioning.ApplyVersioningModel(config.VersioningModel, coordinates)
 	}
 	//Split on spaces, the scanPropeties, so that each property is available as a single string
 	//instead of all properties being part of a single string
@@ -227,3 +278,14 @@ func addDetectArgs(args []string, config detectExecuteScanOptions, utils detectU
 
 	return args, nil
 }
+
+// getDetectVersionName returns the name of the project version in Black Duck
+func getDetectVersionName(config detectExecuteScanOptions) string {
+	if len(config.CustomScanVersion) > 0 {
+		return config.CustomScanVersion
+	}
+	coordinates := versioning.Coordinates{
+		Version: config.Version,
+	}
+	return versioning.ApplyVersioningModel(config.VersioningModel, coordinates)
+}
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	piperhttp "github.com/SAP/jenkins-library/pkg/http"
@@ -16,6 +19,7 @@ import (
 type detectTestUtilsBundle struct {
 	expectedError   error
 	downloadedFiles map[string]string // src, dest
+	responses       map[string]string // url, body
 	*mock.ShellMockRunner
 	*mock.FilesMock
 }
@@ -41,6 +45,14 @@ func (c *detectTestUtilsBundle) DownloadFile(url, filename string, _ http.Header
 	return nil
 }
 
+func (c *detectTestUtilsBundle) SendRequest(method, url string, _ io.Reader, _ http.Header, _ []*http.Cookie) (*http.Response, error) {
+	body, ok := c.responses[url]
+	if !ok {
+		return &http.Response{StatusCode: 404}, fmt.Errorf("%v request to %v failed", method, url)
+	}
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+}
+
 func newDetectTestUtilsBundle() *detectTestUtilsBundle {
 	utilsBundle := detectTestUtilsBundle{
 		ShellMockRunner: &mock.ShellMockRunner{},
@@ -64,6 +76,32 @@ func TestRunDetect(t *testing.T) {
 		assert.Equal(t, "/bin/bash", utilsMock.Shell[0], "Bash shell expected")
 		expectedScript := "./detect.sh --blackduck.url= --blackduck.api.token= \"--detect.project.name=''\" \"--detect.project.version.name=''\" \"--detect.code.location.name=''\" --detect.source.path='.'"
 		assert.Equal(t, expectedScript, utilsMock.Calls[0])
+		sarif, err := utilsMock.FileRead("detectExecuteScan.sarif")
+		assert.NoError(t, err)
+		assert.Contains(t, string(sarif), `"executionSuccessful": true`)
+		assert.NotContains(t, string(sarif), `"results"`)
+	})
+
+	t.Run("success case with vulnerabilities", func(t *testing.T) {
+		t.Parallel()
+		utilsMock := newDetectTestUtilsBundle()
+		utilsMock.AddFile("detect.sh", []byte(""))
+		utilsMock.responses = map[string]string{
+			"https://blackduck.server/api/tokens/authenticate":                                                `{"bearerToken":"bearer"}`,
+			"https://blackduck.server/api/projects?limit=100&q=name%3AmyProject":                              `{"items":[{"name":"myProject","_meta":{"href":"https://blackduck.server/api/projects/1"}}]}`,
+			"https://blackduck.server/api/projects/1/versions?limit=100&q=versionName%3A1":                    `{"items":[{"versionName":"1","_meta":{"href":"https://blackduck.server/api/projects/1/versions/2"}}]}`,
+			"https://blackduck.server/api/projects/1/versions/2/vulnerable-bom-components?offset=0&limit=100": `{"totalCount":1,"items":[{"componentName":"Apache Commons Text","componentVersionName":"1.9","componentVersionOriginId":"org.apache.commons:commons-text:1.9","vulnerabilityWithRemediation":{"vulnerabilityName":"CVE-2022-42889","description":"remote code execution","severity":"CRITICAL","baseScore":9.8,"remediationStatus":"NEW"}}]}`,
+		}
+		config := detectExecuteScanOptions{ServerURL: "https://blackduck.server", Token: "token", ProjectName: "myProject", Version: "1.0.0", VersioningModel: "major"}
+
+		err := runDetect(config, utilsMock)
+
+		assert.NoError(t, err)
+		sarif, err := utilsMock.FileRead("detectExecuteScan.sarif")
+		assert.NoError(t, err)
+		assert.Contains(t, string(sarif), `"ruleId": "CVE-2022-42889"`)
+		assert.Contains(t, string(sarif), `"text": "CVE-2022-42889 in commons-text 1.9: remote code execution"`)
+		assert.Contains(t, string(sarif), `"level": "error"`)
 	})
 
 	t.Run("failure case", func(t *testing.T) {
@@ -74,6 +112,9 @@ func TestRunDetect(t *testing.T) {
 		err := runDetect(detectExecuteScanOptions{}, utilsMock)
 		assert.EqualError(t, err, "Test Error")
 		assert.True(t, utilsMock.HasRemovedFile("detect.sh"))
+		sarif, err := utilsMock.FileRead("detectExecuteScan.sarif")
+		assert.NoError(t, err)
+		assert.Contains(t, string(sarif), `"executionSuccessful": false`)
 	})
 
 	t.Run("maven parameters", func(t *testing.T) {
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperenv"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type envCommandOptions struct {
+	resource    string
+	output      string
+	withHistory bool
+}
+
+var envOptions envCommandOptions
+
+// EnvCommand is the entry command for inspecting the pipeline environment, e.g. the commonPipelineEnvironment
+func EnvCommand() *cobra.Command {
+	var createEnvCmd = &cobra.Command{
+		Use:   "env",
+		Short: "Inspects the pipeline environment which is shared between the steps.",
+		Long: `Lists, compares and exports the values of a pipeline environment resource like the commonPipelineEnvironment.
+Together with the values the version, the step which did the latest change and the time of the change are shown.`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			path, _ := os.Getwd()
+			fatalHook := &log.FatalHook{CorrelationID: GeneralConfig.CorrelationID, Path: path}
+			log.RegisterHook(fatalHook)
+		},
+	}
+	createEnvCmd.PersistentFlags().StringVar(&envOptions.resource, "resource", "commonPipelineEnvironment", "Name of the pipeline environment resource")
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "Lists all values of the pipeline environment.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := runEnvList(envStore(), os.Stdout); err != nil {
+				log.Entry().WithError(err).Fatal("failed to list pipeline environment")
+			}
+		},
+	}
+
+	var diffCmd = &cobra.Command{
+		Use:   "diff FROM [TO]",
+		Short: "Shows the differences between two pipeline environments.",
+		Long: `Shows the differences between two pipeline environments.
+FROM and TO can either be a directory containing a pipeline environment resource or a file created via 'piper env export'.
+If TO is omitted, the current pipeline environment is used.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runEnvDiff(args, os.Stdout); err != nil {
+				log.Entry().WithError(err).Fatal("failed to compare pipeline environments")
+			}
+		},
+	}
+
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Exports the pipeline environment in JSON format.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := runEnvExport(envStore(), os.Stdout); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				log.Entry().WithError(err).Fatal("failed to export pipeline environment")
+			}
+		},
+	}
+	exportCmd.Flags().StringVar(&envOptions.output, "output", "", "File to write the export to, defaults to stdout")
+	exportCmd.Flags().BoolVar(&envOptions.withHistory, "history", true, "Include the history of all changes")
+
+	createEnvCmd.AddCommand(listCmd, diffCmd, exportCmd)
+	return createEnvCmd
+}
+
+func envStore() *piperenv.Store {
+	return piperenv.NewStore(filepath.Join(GeneralConfig.EnvRootPath, envOptions.resource), "")
+}
+
+func runEnvList(store *piperenv.Store, out io.Writer) error {
+	entries, err := store.Entries()
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "KEY\tVALUE\tVERSION\tSTEP\tMODIFIED")
+	for _, entry := range entries {
+		modified := ""
+		if !entry.Modified.IsZero() {
+			modified = entry.Modified.Format("2006-01-02T15:04:05Z07:00")
+		}
+		value := fmt.Sprint(entry.Value)
+		if _, isString := entry.Value.(string); !isString {
+			content, _ := json.Marshal(entry.Value)
+			value = string(content)
+		}
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\n", entry.Key, value, entry.Version, entry.StepName, modified)
+	}
+	return writer.Flush()
+}
+
+func runEnvDiff(args []string, out io.Writer) error {
+	from, err := loadEnvEntries(args[0])
+	if err != nil {
+		return err
+	}
+	var to []piperenv.Entry
+	if len(args) > 1 {
+		to, err = loadEnvEntries(args[1])
+	} else {
+		to, err = envStore().Entries()
+	}
+	if err != nil {
+		return err
+	}
+	for _, difference := range piperenv.Diff(from, to) {
+		fmt.Fprintln(out, difference.String())
+	}
+	return nil
+}
+
+func runEnvExport(store *piperenv.Store, out io.Writer) error {
+	export, err := store.Export()
+	if err != nil {
+		return err
+	}
+	if !envOptions.withHistory {
+		export.History = nil
+	}
+	content, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal pipeline environment")
+	}
+	if len(envOptions.output) > 0 {
+		if err := ioutil.WriteFile(envOptions.output, content, 0666); err != nil {
+			return errors.Wrapf(err, "failed to write %v", envOptions.output)
+		}
+		return nil
+	}
+	_, err = fmt.Fprintln(out, string(content))
+	return err
+}
+
+// loadEnvEntries loads the entries either from an environment directory or from an export file
+func loadEnvEntries(path string) ([]piperenv.Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to access %v", path)
+	}
+	if info.IsDir() {
+		return piperenv.NewStore(path, "").Entries()
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %v", path)
+	}
+	export, err := piperenv.ReadExport(content)
+	if err != nil {
+		return nil, err
+	}
+	return export.Entries, nil
+}
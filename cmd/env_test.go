@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/piperenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEnvList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := piperenv.NewStore(filepath.Join(dir, "commonPipelineEnvironment"), "artifactPrepareVersion")
+	require.NoError(t, store.Set("artifactVersion", "1.0.0"))
+	require.NoError(t, store.Set("custom/images", []string{"a"}))
+
+	var out bytes.Buffer
+	assert.NoError(t, runEnvList(store, &out))
+	assert.Contains(t, out.String(), "KEY")
+	assert.Regexp(t, `artifactVersion\s+1\.0\.0\s+1\s+artifactPrepareVersion`, out.String())
+	assert.Regexp(t, `custom/images\s+\["a"\]\s+1\s+artifactPrepareVersion`, out.String())
+}
+
+func TestRunEnvDiffAndExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func() { envOptions = envCommandOptions{} }()
+	envOptions = envCommandOptions{resource: "commonPipelineEnvironment", withHistory: true}
+	previousEnvRootPath := GeneralConfig.EnvRootPath
+	defer func() { GeneralConfig.EnvRootPath = previousEnvRootPath }()
+	GeneralConfig.EnvRootPath = dir
+
+	store := envStore()
+	require.NoError(t, store.Set("artifactVersion", "1.0.0"))
+	require.NoError(t, store.Set("git/branch", "main"))
+
+	t.Run("export", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, runEnvExport(store, &out))
+		export, err := piperenv.ReadExport(out.Bytes())
+		assert.NoError(t, err)
+		assert.Len(t, export.Entries, 2)
+		assert.Len(t, export.History, 2)
+	})
+
+	exportFile := filepath.Join(dir, "export.json")
+	envOptions.output = exportFile
+	envOptions.withHistory = false
+	require.NoError(t, runEnvExport(store, nil))
+	envOptions.output = ""
+	content, err := ioutil.ReadFile(exportFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "history")
+
+	require.NoError(t, store.Set("artifactVersion", "1.0.1"))
+	require.NoError(t, store.Set("git/commitId", "abc"))
+
+	t.Run("diff with current environment", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, runEnvDiff([]string{exportFile}, &out))
+		assert.Equal(t, "~ artifactVersion: 1.0.0 -> 1.0.1\n+ git/commitId: abc\n", out.String())
+	})
+
+	t.Run("diff of directory and export", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, runEnvDiff([]string{store.Path, exportFile}, &out))
+		assert.Equal(t, "~ artifactVersion: 1.0.1 -> 1.0.0\n- git/commitId: abc\n", out.String())
+	})
+
+	t.Run("not existing", func(t *testing.T) {
+		err := runEnvDiff([]string{filepath.Join(dir, "notExisting.json")}, &bytes.Buffer{})
+		assert.Contains(t, err.Error(), "failed to access")
+	})
+}
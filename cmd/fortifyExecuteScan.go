@@ -27,6 +27,7 @@ import (
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/maven"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/SAP/jenkins-library/pkg/reporting"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
 	"github.com/SAP/jenkins-library/pkg/versioning"
 
@@ -174,6 +175,7 @@ func runFortifyScan(config fortifyExecuteScanOptions, sys fortify.System, utils
 
 	if config.VerifyOnly {
 		log.Entry().Infof("Starting audit status check on project %v with version %v and project version ID %v", fortifyProjectName, fortifyProjectVersion, projectVersion.ID)
+		reports = append(reports, piperutils.Path{Target: fortifySarifFile(config)})
 		return reports, verifyFFProjectCompliance(config, sys, project, projectVersion, filterSet, influx, auditStatus)
 	}
 
@@ -228,6 +230,7 @@ func runFortifyScan(config fortifyExecuteScanOptions, sys fortify.System, utils
 		return reports, err
 	}
 
+	reports = append(reports, piperutils.Path{Target: fortifySarifFile(config)})
 	return reports, verifyFFProjectCompliance(config, sys, project, projectVersion, filterSet, influx, auditStatus)
 }
 
@@ -256,6 +259,11 @@ func verifyFFProjectCompliance(config fortifyExecuteScanOptions, sys fortify.Sys
 		return errors.Wrapf(err, "failed to fetch project version issue filter selector for project version ID %v", projectVersion.ID)
 	}
 	log.Entry().Debugf("initial filter selector set: %v", issueFilterSelectorSet)
+	if sarif, err := createFortifySarif(sys, projectVersion, filterSet, issueFilterSelectorSet); err != nil {
+		log.Entry().WithError(err).Warning("failed to create SARIF report")
+	} else if err := ioutil.WriteFile(fortifySarifFile(config), sarif, 0644); err != nil {
+		log.Entry().WithError(err).Warning("failed to write SARIF report")
+	}
 	numberOfViolations, err := analyseUnauditedIssues(config, sys, projectVersion, filterSet, issueFilterSelectorSet, influx, auditStatus)
 	if err != nil {
 		return errors.Wrap(err, "failed to analyze unaudited issues")
@@ -274,6 +282,43 @@ func verifyFFProjectCompliance(config fortifyExecuteScanOptions, sys fortify.Sys
 	return nil
 }
 
+// createFortifySarif creates a SARIF report containing one result per issue category with unaudited issues
+// since Fortify SSC only provides aggregated issue information via the issue groups
+func createFortifySarif(sys fortify.System, projectVersion *models.ProjectVersion, filterSet *models.FilterSet, issueFilterSelectorSet *models.IssueFilterSelectorSet) ([]byte, error) {
+	reducedFilterSelectorSet := sys.ReduceIssueFilterSelectorSet(issueFilterSelectorSet, []string{"Category"}, nil)
+	fetchedIssueGroups, err := sys.GetProjectIssuesByIDAndFilterSetGroupedBySelector(projectVersion.ID, "", filterSet.GUID, reducedFilterSelectorSet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch project version issue groups by category for project version ID %v", projectVersion.ID)
+	}
+
+	report := reporting.FindingsReport{
+		StepName:       "fortifyExecuteScan",
+		ToolName:       "Fortify",
+		InformationURI: "https://www.microfocus.com/en-us/cyberres/application-security/static-code-analyzer",
+		Successful:     true,
+		Findings:       []reporting.Finding{},
+	}
+	for _, issueGroup := range fetchedIssueGroups {
+		if issueGroup == nil || issueGroup.ID == nil || issueGroup.TotalCount == nil || issueGroup.AuditedCount == nil {
+			continue
+		}
+		unaudited := int(*issueGroup.TotalCount) - int(*issueGroup.AuditedCount)
+		if unaudited <= 0 {
+			continue
+		}
+		report.AddFinding(reporting.Finding{
+			RuleID:   *issueGroup.ID,
+			RuleName: *issueGroup.ID,
+			Message:  fmt.Sprintf("%v of %v issues of category %v are not audited", unaudited, *issueGroup.TotalCount, *issueGroup.ID),
+		})
+	}
+	return report.ToSARIF()
+}
+
+func fortifySarifFile(config fortifyExecuteScanOptions) string {
+	return fmt.Sprintf("%vtarget/fortify.sarif", config.ModulePath)
+}
+
 func analyseUnauditedIssues(config fortifyExecuteScanOptions, sys fortify.System, projectVersion *models.ProjectVersion, filterSet *models.FilterSet, issueFilterSelectorSet *models.IssueFilterSelectorSet, influx *fortifyExecuteScanInflux, auditStatus map[string]string) (int, error) {
 	log.Entry().Info("Analyzing unaudited issues")
 	reducedFilterSelectorSet := sys.ReduceIssueFilterSelectorSet(issueFilterSelectorSet, []string{"Folder"}, nil)
@@ -375,7 +375,8 @@ func TestExecutions(t *testing.T) {
 		{
 			nameOfRun:             "golang verify only",
 			config:                fortifyExecuteScanOptions{BuildTool: "golang", BuildDescriptorFile: "go.mod", VerifyOnly: true},
-			expectedReportsLength: 0,
+			expectedReportsLength: 1,
+			expectedReports:       []string{"target/fortify.sarif"},
 		},
 	}
 
@@ -406,6 +407,21 @@ func TestExecutions(t *testing.T) {
 	}
 }
 
+func TestCreateFortifySarif(t *testing.T) {
+	ff := fortifyMock{}
+	name := "test"
+	projectVersion := models.ProjectVersion{ID: 4711, Name: &name}
+	filterSet := models.FilterSet{GUID: "a6a8c1b8-0b3c-4d8e-9e8f-1234567890ab"}
+
+	sarif, err := createFortifySarif(&ff, &projectVersion, &filterSet, &models.IssueFilterSelectorSet{})
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(sarif), `"ruleId": "Audit All"`)
+	assert.Contains(t, string(sarif), `"text": "3 of 15 issues of category Audit All are not audited"`)
+	assert.Contains(t, string(sarif), `"ruleId": "Corporate Security Requirements"`)
+	assert.Contains(t, string(sarif), `"name": "Fortify"`)
+}
+
 func TestAnalyseSuspiciousExploitable(t *testing.T) {
 	config := fortifyExecuteScanOptions{SpotCheckMinimum: 4, MustAuditIssueGroups: "Audit All, Corporate Security Requirements", SpotAuditIssueGroups: "Spot Checks of Each Category"}
 	ff := fortifyMock{}
@@ -21,6 +21,7 @@ type configCommandOptions struct {
 	stepMetadata   string //metadata to be considered, can be filePath or ENV containing JSON in format 'ENV:MY_ENV_VAR'
 	stepName       string
 	contextConfig  bool
+	explain        bool
 	openFile       func(s string) (io.ReadCloser, error)
 }
 
@@ -74,8 +75,6 @@ func generateConfig() error {
 	// Remark: This is so far only relevant for Jenkins environments where getConfig is executed
 	prepareOutputEnvironment(metadata.Spec.Outputs.Resources, GeneralConfig.EnvRootPath)
 
-	resourceParams := metadata.GetResourceParameters(GeneralConfig.EnvRootPath, "commonPipelineEnvironment")
-
 	projectConfigFile := getProjectConfigFile(GeneralConfig.CustomConfig)
 
 	customConfig, err := configOptions.openFile(projectConfigFile)
@@ -86,16 +85,44 @@ func generateConfig() error {
 		customConfig = nil
 	}
 
-	defaultConfig, paramFilter, err := defaultsAndFilters(&metadata, metadata.Metadata.Name)
+	if configOptions.explain {
+		myConfig.EnableProvenance()
+	}
+	// getConfig only displays the configuration, leases of dynamic Vault secrets are not needed afterwards
+	defer config.RevokeVaultLeases()
+	stepConfig, err = resolveStepConfig(&myConfig, &metadata, customConfig, newDefaultsResolver(configOptions.openFile), GeneralConfig.StageName)
 	if err != nil {
-		return errors.Wrap(err, "defaults: retrieving step defaults failed")
+		return err
+	}
+
+	if configOptions.explain {
+		explanationJSON, _ := config.GetJSON(stepConfig.Explain(secretParameters(&metadata)))
+		fmt.Println(explanationJSON)
+		return nil
+	}
+
+	myConfigJSON, _ := config.GetJSON(stepConfig.Config)
+
+	fmt.Println(myConfigJSON)
+
+	return nil
+}
+
+// resolveStepConfig resolves the configuration of a step in the same way as the step does, i.e. respecting
+// the defaults, the commonPipelineEnvironment and the parameters. Defaults are opened via the defaultsResolver.
+func resolveStepConfig(myConfig *config.Config, metadata *config.StepData, customConfig io.ReadCloser, defaultsResolver *config.DefaultsResolver, stageName string) (config.StepConfig, error) {
+	resourceParams := metadata.GetResourceParameters(GeneralConfig.EnvRootPath, "commonPipelineEnvironment")
+
+	defaultConfig, paramFilter, err := defaultsAndFilters(metadata, metadata.Metadata.Name)
+	if err != nil {
+		return config.StepConfig{}, errors.Wrap(err, "defaults: retrieving step defaults failed")
 	}
 
 	for _, f := range GeneralConfig.DefaultConfig {
-		fc, err := configOptions.openFile(f)
+		fc, err := defaultsResolver.Open(f)
 		// only create error for non-default values
 		if err != nil && f != ".pipeline/defaults.yaml" {
-			return errors.Wrapf(err, "config: getting defaults failed: '%v'", f)
+			return config.StepConfig{}, errors.Wrapf(err, "config: getting defaults failed: '%v'", f)
 		}
 		if err == nil {
 			defaultConfig = append(defaultConfig, fc)
@@ -109,21 +136,18 @@ func generateConfig() error {
 		params = metadata.Spec.Inputs.Parameters
 	}
 
-	stepConfig, err = myConfig.GetStepConfig(flags, GeneralConfig.ParametersJSON, customConfig, defaultConfig, GeneralConfig.IgnoreCustomDefaults, paramFilter, params, metadata.Spec.Inputs.Secrets, resourceParams, GeneralConfig.StageName, metadata.Metadata.Name, metadata.Metadata.Aliases)
+	myConfig.SetEnvRootPath(GeneralConfig.EnvRootPath)
+	myConfig.SetDefaultsResolver(defaultsResolver)
+	stepConfig, err := myConfig.GetStepConfig(flags, GeneralConfig.ParametersJSON, customConfig, defaultConfig, GeneralConfig.IgnoreCustomDefaults, paramFilter, params, metadata.Spec.Inputs.Secrets, resourceParams, stageName, metadata.Metadata.Name, metadata.Metadata.Aliases)
 	if err != nil {
-		return errors.Wrap(err, "getting step config failed")
+		return config.StepConfig{}, errors.Wrap(err, "getting step config failed")
 	}
 
 	// apply context conditions if context configuration is requested
 	if configOptions.contextConfig {
-		applyContextConditions(metadata, &stepConfig)
+		applyContextConditions(*metadata, &stepConfig)
 	}
-
-	myConfigJSON, _ := config.GetJSON(stepConfig.Config)
-
-	fmt.Println(myConfigJSON)
-
-	return nil
+	return stepConfig, nil
 }
 
 func addConfigFlags(cmd *cobra.Command) {
@@ -134,6 +158,7 @@ func addConfigFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&configOptions.parametersJSON, "parametersJSON", os.Getenv("PIPER_parametersJSON"), "Parameters to be considered in JSON format")
 	cmd.Flags().StringVar(&configOptions.stepMetadata, "stepMetadata", "", "Step metadata, passed as path to yaml")
 	cmd.Flags().BoolVar(&configOptions.contextConfig, "contextConfig", false, "Defines if step context configuration should be loaded instead of step config")
+	cmd.Flags().BoolVar(&configOptions.explain, "explain", false, "Outputs for every parameter the resulting value and the sources which provided or overrode values, secrets are redacted")
 
 	_ = cmd.MarkFlagRequired("stepMetadata")
 
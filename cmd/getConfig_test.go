@@ -50,7 +50,7 @@ func TestConfigCommand(t *testing.T) {
 	})
 
 	t.Run("Optional flags", func(t *testing.T) {
-		exp := []string{"contextConfig", "output", "parametersJSON"}
+		exp := []string{"contextConfig", "explain", "output", "parametersJSON"}
 		assert.Equal(t, exp, gotOpt, "optional flags incorrect")
 	})
 
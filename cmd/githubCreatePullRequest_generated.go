@@ -8,22 +8,25 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type githubCreatePullRequestOptions struct {
 	Assignees  []string `json:"assignees,omitempty"`
-	Base       string   `json:"base,omitempty"`
-	Body       string   `json:"body,omitempty"`
-	APIURL     string   `json:"apiUrl,omitempty"`
-	Head       string   `json:"head,omitempty"`
-	Owner      string   `json:"owner,omitempty"`
-	Repository string   `json:"repository,omitempty"`
-	ServerURL  string   `json:"serverUrl,omitempty"`
-	Title      string   `json:"title,omitempty"`
-	Token      string   `json:"token,omitempty"`
+	Base       string   `json:"base,omitempty" validate:"required"`
+	Body       string   `json:"body,omitempty" validate:"required"`
+	APIURL     string   `json:"apiUrl,omitempty" validate:"required"`
+	Head       string   `json:"head,omitempty" validate:"required"`
+	Owner      string   `json:"owner,omitempty" validate:"required"`
+	Repository string   `json:"repository,omitempty" validate:"required"`
+	ServerURL  string   `json:"serverUrl,omitempty" validate:"required"`
+	Title      string   `json:"title,omitempty" validate:"required"`
+	Token      string   `json:"token,omitempty" validate:"required"`
 	Labels     []string `json:"labels,omitempty"`
 }
 
@@ -53,10 +56,21 @@ It can for example be used for GitOps scenarios or for scenarios where you want
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Token)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -69,9 +83,15 @@ It can for example be used for GitOps scenarios or for scenarios where you want
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -216,6 +236,36 @@ func githubCreatePullRequestMetadata() config.StepData {
 								Paths: []string{"$(vaultPath)/github", "$(vaultBasePath)/$(vaultPipelineName)/github", "$(vaultBasePath)/GROUP-SECRETS/github"},
 								Type:  "vaultSecret",
 							},
+
+							{
+								Name:  "",
+								Paths: []string{"github"},
+								Type:  "fileSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"PIPER_GITHUB"},
+								Type:  "envSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-github"},
+								Type:  "kubernetesSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper/github"},
+								Type:  "awsSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-github"},
+								Type:  "azureSecret",
+							},
 						},
 						Scope:     []string{"GENERAL", "PARAMETERS", "STAGES", "STEPS"},
 						Type:      "string",
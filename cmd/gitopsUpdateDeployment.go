@@ -2,28 +2,37 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/SAP/jenkins-library/pkg/command"
 	"github.com/SAP/jenkins-library/pkg/docker"
 	gitUtil "github.com/SAP/jenkins-library/pkg/git"
+	piperGithub "github.com/SAP/jenkins-library/pkg/github"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	piperyaml "github.com/SAP/jenkins-library/pkg/yaml"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-github/v32/github"
 	"github.com/pkg/errors"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const toolKubectl = "kubectl"
 const toolHelm = "helm"
+const toolKustomize = "kustomize"
+const toolHelmValuesFile = "helmValuesFile"
 
 type iGitopsUpdateDeploymentGitUtils interface {
-	CommitSingleFile(filePath, commitMessage, author string) (plumbing.Hash, error)
+	CommitFiles(filePaths []string, commitMessage, author string) (plumbing.Hash, error)
 	PushChangesToRepository(username, password string) error
+	PushChangesToBranch(username, password, localBranch, remoteBranch string) error
 	PlainClone(username, password, serverURL, directory string) error
 	ChangeBranch(branchName string) error
 }
@@ -31,28 +40,40 @@ type iGitopsUpdateDeploymentGitUtils interface {
 type gitopsUpdateDeploymentFileUtils interface {
 	TempDir(dir, pattern string) (name string, err error)
 	RemoveAll(path string) error
+	FileRead(path string) ([]byte, error)
 	FileWrite(path string, content []byte, perm os.FileMode) error
 }
 
 type gitopsUpdateDeploymentExecRunner interface {
 	RunExecutable(executable string, params ...string) error
+	SetDir(dir string)
 	Stdout(out io.Writer)
 	Stderr(err io.Writer)
 }
 
+type gitopsUpdateDeploymentPullRequestUtils interface {
+	CreatePullRequest(ctx context.Context, owner, repository, head, base, title, body string) (*github.PullRequest, error)
+	WaitForChecks(ctx context.Context, owner, repository string, pullRequest *github.PullRequest, timeout time.Duration) error
+	MergePullRequest(ctx context.Context, owner, repository string, pullRequest *github.PullRequest, mergeMethod string) error
+}
+
 type gitopsUpdateDeploymentGitUtils struct {
 	worktree   *git.Worktree
 	repository *git.Repository
 }
 
-func (g *gitopsUpdateDeploymentGitUtils) CommitSingleFile(filePath, commitMessage, author string) (plumbing.Hash, error) {
-	return gitUtil.CommitSingleFile(filePath, commitMessage, author, g.worktree)
+func (g *gitopsUpdateDeploymentGitUtils) CommitFiles(filePaths []string, commitMessage, author string) (plumbing.Hash, error) {
+	return gitUtil.CommitFiles(filePaths, commitMessage, author, g.worktree)
 }
 
 func (g *gitopsUpdateDeploymentGitUtils) PushChangesToRepository(username, password string) error {
 	return gitUtil.PushChangesToRepository(username, password, g.repository)
 }
 
+func (g *gitopsUpdateDeploymentGitUtils) PushChangesToBranch(username, password, localBranch, remoteBranch string) error {
+	return gitUtil.PushChangesToBranch(username, password, localBranch, remoteBranch, g.repository)
+}
+
 func (g *gitopsUpdateDeploymentGitUtils) PlainClone(username, password, serverURL, directory string) error {
 	var err error
 	g.repository, err = gitUtil.PlainClone(username, password, serverURL, directory)
@@ -67,7 +88,7 @@ func (g *gitopsUpdateDeploymentGitUtils) ChangeBranch(branchName string) error {
 	return gitUtil.ChangeBranch(branchName, g.worktree)
 }
 
-func gitopsUpdateDeployment(config gitopsUpdateDeploymentOptions, _ *telemetry.CustomData) {
+func gitopsUpdateDeployment(config gitopsUpdateDeploymentOptions, _ *telemetry.CustomData, commonPipelineEnvironment *gitopsUpdateDeploymentCommonPipelineEnvironment) {
 	// for command execution use Command
 	var c gitopsUpdateDeploymentExecRunner = &command.Command{}
 	// reroute command output to logging framework
@@ -79,18 +100,39 @@ func gitopsUpdateDeployment(config gitopsUpdateDeploymentOptions, _ *telemetry.C
 	// Example: step checkmarxExecuteScan.go
 
 	// error situations should stop execution through log.Entry().Fatal() call which leads to an os.Exit(1) in the end
-	err := runGitopsUpdateDeployment(&config, c, &gitopsUpdateDeploymentGitUtils{}, piperutils.Files{})
+	var pullRequestUtils gitopsUpdateDeploymentPullRequestUtils
+	if config.CreatePullRequest {
+		token := config.GithubToken
+		if token == "" {
+			token = config.Password
+		}
+		_, client, err := piperGithub.NewClient(token, config.GithubAPIURL, "")
+		if err != nil {
+			log.Entry().WithError(err).Fatal("Failed to get GitHub client")
+		}
+		pullRequestUtils = piperGithub.NewPullRequestClient(client, 15*time.Second)
+	}
+
+	err := runGitopsUpdateDeployment(&config, c, &gitopsUpdateDeploymentGitUtils{}, piperutils.Files{}, pullRequestUtils, commonPipelineEnvironment)
 	if err != nil {
 		log.Entry().WithError(err).Fatal("step execution failed")
 	}
 }
 
-func runGitopsUpdateDeployment(config *gitopsUpdateDeploymentOptions, command gitopsUpdateDeploymentExecRunner, gitUtils iGitopsUpdateDeploymentGitUtils, fileUtils gitopsUpdateDeploymentFileUtils) error {
+func runGitopsUpdateDeployment(config *gitopsUpdateDeploymentOptions, command gitopsUpdateDeploymentExecRunner, gitUtils iGitopsUpdateDeploymentGitUtils, fileUtils gitopsUpdateDeploymentFileUtils, pullRequestUtils gitopsUpdateDeploymentPullRequestUtils, commonPipelineEnvironment *gitopsUpdateDeploymentCommonPipelineEnvironment) error {
 	err := checkRequiredFieldsForDeployTool(config)
 	if err != nil {
 		return err
 	}
 
+	var owner, repository string
+	if config.CreatePullRequest {
+		owner, repository, err = githubRepositoryFromURL(config.ServerURL)
+		if err != nil {
+			return err
+		}
+	}
+
 	temporaryFolder, err := fileUtils.TempDir(".", "temp-")
 	if err != nil {
 		return errors.Wrap(err, "failed to create temporary directory")
@@ -108,81 +150,130 @@ func runGitopsUpdateDeployment(config *gitopsUpdateDeploymentOptions, command gi
 		return errors.Wrap(err, "repository could not get prepared")
 	}
 
-	filePath := filepath.Join(temporaryFolder, config.FilePath)
+	filePaths := deploymentFilePaths(config)
 
-	var outputBytes []byte
 	if config.Tool == toolKubectl {
-		outputBytes, err = executeKubectl(config, command, outputBytes, filePath)
-		if err != nil {
-			return errors.Wrap(err, "error on kubectl execution")
+		for _, path := range filePaths {
+			filePath := filepath.Join(temporaryFolder, path)
+			outputBytes, err := executeKubectl(config, command, nil, filePath)
+			if err != nil {
+				return errors.Wrap(err, "error on kubectl execution")
+			}
+			err = fileUtils.FileWrite(filePath, outputBytes, 0755)
+			if err != nil {
+				return errors.Wrap(err, "failed to write file")
+			}
 		}
 	} else if config.Tool == toolHelm {
-		outputBytes, err = runHelmCommand(command, config)
+		outputBytes, err := runHelmCommand(command, config)
 		if err != nil {
 			return errors.Wrap(err, "failed to apply helm command")
 		}
+		err = fileUtils.FileWrite(filepath.Join(temporaryFolder, filePaths[0]), outputBytes, 0755)
+		if err != nil {
+			return errors.Wrap(err, "failed to write file")
+		}
+	} else if config.Tool == toolKustomize {
+		err = runKustomizeCommand(command, config, temporaryFolder, filePaths)
+		if err != nil {
+			return errors.Wrap(err, "failed to apply kustomize command")
+		}
+	} else if config.Tool == toolHelmValuesFile {
+		err = updateHelmValuesFiles(config, fileUtils, temporaryFolder, filePaths)
+		if err != nil {
+			return errors.Wrap(err, "failed to update helm values files")
+		}
 	} else {
 		log.SetErrorCategory(log.ErrorConfiguration)
 		return errors.New("tool " + config.Tool + " is not supported")
 	}
 
-	err = fileUtils.FileWrite(filePath, outputBytes, 0755)
-	if err != nil {
-		return errors.Wrap(err, "failed to write file")
+	commitMessage := config.CommitMessage
+	if commitMessage == "" {
+		commitMessage = defaultCommitMessage(config)
 	}
 
-	commit, err := commitAndPushChanges(config, gitUtils)
+	commit, pushedBranch, err := commitAndPushChanges(config, gitUtils, filePaths, commitMessage)
 	if err != nil {
 		return errors.Wrap(err, "failed to commit and push changes")
 	}
 
 	log.Entry().Infof("Changes committed with %s", commit.String())
 
-	return nil
+	if !config.CreatePullRequest {
+		return nil
+	}
+	return createPullRequest(config, pullRequestUtils, owner, repository, pushedBranch, commitMessage, filePaths, commonPipelineEnvironment)
 }
 
-func checkRequiredFieldsForDeployTool(config *gitopsUpdateDeploymentOptions) error {
-	if config.Tool == toolHelm {
-		err := checkRequiredFieldsForHelm(config)
-		if err != nil {
-			return errors.Wrap(err, "missing required fields for helm")
-		}
-		logNotRequiredButFilledFieldForHelm(config)
-	} else if config.Tool == toolKubectl {
-		err := checkRequiredFieldsForKubectl(config)
+// createPullRequest creates the pull request for the pushed changes, waits for its checks and merges it if configured
+func createPullRequest(config *gitopsUpdateDeploymentOptions, pullRequestUtils gitopsUpdateDeploymentPullRequestUtils, owner, repository, head, commitMessage string, filePaths []string, commonPipelineEnvironment *gitopsUpdateDeploymentCommonPipelineEnvironment) error {
+	ctx := context.Background()
+	body := fmt.Sprintf("%v\n\nUpdated files:\n", commitMessage)
+	for _, path := range filePaths {
+		body += fmt.Sprintf("- `%v`\n", path)
+	}
+
+	pullRequest, err := pullRequestUtils.CreatePullRequest(ctx, owner, repository, head, config.BranchName, strings.Split(commitMessage, "\n")[0], body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create pull request for branch %v", head)
+	}
+	commonPipelineEnvironment.custom.gitopsPullRequestURL = pullRequest.GetHTMLURL()
+
+	if !config.WaitForChecks && !config.AutoMerge {
+		return nil
+	}
+	err = pullRequestUtils.WaitForChecks(ctx, owner, repository, pullRequest, time.Duration(config.ChecksTimeout)*time.Second)
+	if err != nil {
+		return errors.Wrap(err, "pull request checks did not succeed")
+	}
+
+	if config.AutoMerge {
+		err = pullRequestUtils.MergePullRequest(ctx, owner, repository, pullRequest, config.MergeMethod)
 		if err != nil {
-			return errors.Wrap(err, "missing required fields for kubectl")
+			return errors.Wrap(err, "failed to merge pull request")
 		}
-		logNotRequiredButFilledFieldForKubectl(config)
 	}
-
 	return nil
 }
 
-func checkRequiredFieldsForHelm(config *gitopsUpdateDeploymentOptions) error {
-	var missingParameters []string
-	if config.ChartPath == "" {
-		missingParameters = append(missingParameters, "chartPath")
-	}
-	if config.DeploymentName == "" {
-		missingParameters = append(missingParameters, "deploymentName")
+// githubRepositoryFromURL extracts owner and repository from the URL of a GitHub repository, e.g. https://github.com/owner/repository.git
+func githubRepositoryFromURL(serverURL string) (string, string, error) {
+	repositoryURL, err := url.Parse(serverURL)
+	if err != nil {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return "", "", errors.Wrapf(err, "failed to parse server url %v", serverURL)
 	}
-	if len(missingParameters) > 0 {
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(repositoryURL.Path, ".git"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 		log.SetErrorCategory(log.ErrorConfiguration)
-		return errors.Errorf("the following parameters are necessary for helm: %v", missingParameters)
+		return "", "", errors.Errorf("server url %v does not point to a GitHub repository in the form https://github.com/<owner>/<repository>", serverURL)
 	}
-	return nil
+	return parts[0], parts[1], nil
 }
 
-func checkRequiredFieldsForKubectl(config *gitopsUpdateDeploymentOptions) error {
-	var missingParameters []string
-	if config.ContainerName == "" {
-		missingParameters = append(missingParameters, "containerName")
+func checkRequiredFieldsForDeployTool(config *gitopsUpdateDeploymentOptions) error {
+	filePaths := deploymentFilePaths(config)
+	if len(filePaths) == 0 {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return errors.New("at least one of the parameters filePath or filePaths is necessary")
+	}
+	if (config.Tool == toolHelm || config.Tool == toolKubectl) && len(config.ContainerImageNameTags) > 0 {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return errors.Errorf("containerImageNameTags is not supported for %v", config.Tool)
 	}
-	if len(missingParameters) > 0 {
+	if config.Tool == toolHelm && len(filePaths) > 1 {
 		log.SetErrorCategory(log.ErrorConfiguration)
-		return errors.Errorf("the following parameters are necessary for kubectl: %v", missingParameters)
+		return errors.Errorf("helm renders the template into a single file, but %v file paths are configured", len(filePaths))
 	}
+
+	// the parameters required by helm and kubectl are validated via their metadata
+	if config.Tool == toolHelm {
+		logNotRequiredButFilledFieldForHelm(config)
+	} else if config.Tool == toolKubectl {
+		logNotRequiredButFilledFieldForKubectl(config)
+	}
+
 	return nil
 }
 
@@ -204,6 +295,35 @@ func logNotRequiredButFilledFieldForKubectl(config *gitopsUpdateDeploymentOption
 	}
 }
 
+// deploymentFilePaths returns filePath together with filePaths without duplicates
+func deploymentFilePaths(config *gitopsUpdateDeploymentOptions) []string {
+	filePaths := []string{}
+	for _, path := range append([]string{config.FilePath}, config.FilePaths...) {
+		if path != "" && !piperutils.ContainsString(filePaths, path) {
+			filePaths = append(filePaths, path)
+		}
+	}
+	return filePaths
+}
+
+// containerImages returns containerImageNameTag including the registry together with containerImageNameTags
+func containerImages(config *gitopsUpdateDeploymentOptions) ([]piperyaml.ContainerImage, error) {
+	registryImage, imageTag, err := buildRegistryPlusImageAndTagSeparately(config)
+	if err != nil {
+		return nil, err
+	}
+	images := []piperyaml.ContainerImage{{Name: registryImage, Tag: imageTag}}
+	for _, imageNameTag := range config.ContainerImageNameTags {
+		index := strings.LastIndex(imageNameTag, ":")
+		if index <= 0 || index == len(imageNameTag)-1 || strings.Contains(imageNameTag[index:], "/") {
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return nil, errors.Errorf("image name and tag could not be extracted from '%v'", imageNameTag)
+		}
+		images = append(images, piperyaml.ContainerImage{Name: imageNameTag[:index], Tag: imageNameTag[index+1:]})
+	}
+	return images, nil
+}
+
 func cloneRepositoryAndChangeBranch(config *gitopsUpdateDeploymentOptions, gitUtils iGitopsUpdateDeploymentGitUtils, temporaryFolder string) error {
 	err := gitUtils.PlainClone(config.Username, config.Password, config.ServerURL, temporaryFolder)
 	if err != nil {
@@ -330,28 +450,103 @@ func buildRegistryPlusImageAndTagSeparately(config *gitopsUpdateDeploymentOption
 
 }
 
-func commitAndPushChanges(config *gitopsUpdateDeploymentOptions, gitUtils iGitopsUpdateDeploymentGitUtils) (plumbing.Hash, error) {
-	commitMessage := config.CommitMessage
+// runKustomizeCommand sets the images within each overlay directory via kustomize edit set image
+func runKustomizeCommand(runner gitopsUpdateDeploymentExecRunner, config *gitopsUpdateDeploymentOptions, temporaryFolder string, overlays []string) error {
+	images, err := containerImages(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to extract registry URL, image name, and image tag")
+	}
+	kustomizeParams := []string{"edit", "set", "image"}
+	for _, image := range images {
+		kustomizeParams = append(kustomizeParams, image.Name+":"+image.Tag)
+	}
 
-	if commitMessage == "" {
-		commitMessage = defaultCommitMessage(config)
+	runner.Stdout(log.Writer())
+	defer runner.SetDir("")
+	for _, overlay := range overlays {
+		runner.SetDir(filepath.Join(temporaryFolder, overlay))
+		err = runner.RunExecutable(toolKustomize, kustomizeParams...)
+		if err != nil {
+			return errors.Wrapf(err, "failed to execute kustomize command in %v", overlay)
+		}
+	}
+	return nil
+}
+
+// updateHelmValuesFiles updates the image tags within the values files, each image needs to be contained in at least one file
+func updateHelmValuesFiles(config *gitopsUpdateDeploymentOptions, fileUtils gitopsUpdateDeploymentFileUtils, temporaryFolder string, valuesFiles []string) error {
+	images, err := containerImages(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to extract registry URL, image name, and image tag")
+	}
+
+	foundImages := []string{}
+	for _, valuesFile := range valuesFiles {
+		filePath := filepath.Join(temporaryFolder, valuesFile)
+		content, err := fileUtils.FileRead(filePath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read file %v", valuesFile)
+		}
+		updated, found, err := piperyaml.UpdateImageTags(content, images)
+		if err != nil {
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return errors.Wrapf(err, "failed to update image tags in %v", valuesFile)
+		}
+		if len(found) == 0 {
+			log.Entry().Warnf("none of the images is contained in %v", valuesFile)
+			continue
+		}
+		foundImages = append(foundImages, found...)
+		err = fileUtils.FileWrite(filePath, updated, 0755)
+		if err != nil {
+			return errors.Wrap(err, "failed to write file")
+		}
 	}
 
-	commit, err := gitUtils.CommitSingleFile(config.FilePath, commitMessage, config.Username)
+	missingImages := []string{}
+	for _, image := range images {
+		if !piperutils.ContainsString(foundImages, image.Name) {
+			missingImages = append(missingImages, image.Name)
+		}
+	}
+	if len(missingImages) > 0 {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return errors.Errorf("the following images are not contained in any of the files %v: %v", valuesFiles, missingImages)
+	}
+	return nil
+}
+
+// commitAndPushChanges pushes the commit either to branchName or, in case a pull request shall be created, to a generated branch.
+// The name of the branch the commit has been pushed to is returned.
+func commitAndPushChanges(config *gitopsUpdateDeploymentOptions, gitUtils iGitopsUpdateDeploymentGitUtils, filePaths []string, commitMessage string) (plumbing.Hash, string, error) {
+	commit, err := gitUtils.CommitFiles(filePaths, commitMessage, config.Username)
 	if err != nil {
-		return [20]byte{}, errors.Wrap(err, "committing changes failed")
+		return [20]byte{}, "", errors.Wrap(err, "committing changes failed")
 	}
 
-	err = gitUtils.PushChangesToRepository(config.Username, config.Password)
+	branch := config.BranchName
+	if config.CreatePullRequest {
+		branch = config.PullRequestBranchPrefix + "/" + commit.String()[:8]
+		err = gitUtils.PushChangesToBranch(config.Username, config.Password, config.BranchName, branch)
+	} else {
+		err = gitUtils.PushChangesToRepository(config.Username, config.Password)
+	}
 	if err != nil {
-		return [20]byte{}, errors.Wrap(err, "pushing changes failed")
+		return [20]byte{}, "", errors.Wrap(err, "pushing changes failed")
 	}
 
-	return commit, nil
+	return commit, branch, nil
 }
 
 func defaultCommitMessage(config *gitopsUpdateDeploymentOptions) string {
-	image, tag, _ := buildRegistryPlusImageAndTagSeparately(config)
-	commitMessage := fmt.Sprintf("Updated %v to version %v", image, tag)
-	return commitMessage
+	images, err := containerImages(config)
+	if err != nil {
+		image, tag, _ := buildRegistryPlusImageAndTagSeparately(config)
+		return fmt.Sprintf("Updated %v to version %v", image, tag)
+	}
+	updates := []string{}
+	for _, image := range images {
+		updates = append(updates, fmt.Sprintf("%v to version %v", image.Name, image.Tag))
+	}
+	return "Updated " + strings.Join(updates, ", ")
 }
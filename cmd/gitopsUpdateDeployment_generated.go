@@ -5,28 +5,71 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperenv"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type gitopsUpdateDeploymentOptions struct {
-	BranchName            string   `json:"branchName,omitempty"`
-	CommitMessage         string   `json:"commitMessage,omitempty"`
-	ServerURL             string   `json:"serverUrl,omitempty"`
-	Username              string   `json:"username,omitempty"`
-	Password              string   `json:"password,omitempty"`
-	FilePath              string   `json:"filePath,omitempty"`
-	ContainerName         string   `json:"containerName,omitempty"`
-	ContainerRegistryURL  string   `json:"containerRegistryUrl,omitempty"`
-	ContainerImageNameTag string   `json:"containerImageNameTag,omitempty"`
-	ChartPath             string   `json:"chartPath,omitempty"`
-	HelmValues            []string `json:"helmValues,omitempty"`
-	DeploymentName        string   `json:"deploymentName,omitempty"`
-	Tool                  string   `json:"tool,omitempty"`
+	BranchName              string   `json:"branchName,omitempty" validate:"required"`
+	CommitMessage           string   `json:"commitMessage,omitempty"`
+	ServerURL               string   `json:"serverUrl,omitempty" validate:"required"`
+	Username                string   `json:"username,omitempty" validate:"required"`
+	Password                string   `json:"password,omitempty" validate:"required"`
+	CreatePullRequest       bool     `json:"createPullRequest,omitempty"`
+	PullRequestBranchPrefix string   `json:"pullRequestBranchPrefix,omitempty"`
+	GithubAPIURL            string   `json:"githubApiUrl,omitempty"`
+	GithubToken             string   `json:"githubToken,omitempty"`
+	WaitForChecks           bool     `json:"waitForChecks,omitempty"`
+	ChecksTimeout           int      `json:"checksTimeout,omitempty"`
+	AutoMerge               bool     `json:"autoMerge,omitempty"`
+	MergeMethod             string   `json:"mergeMethod,omitempty" validate:"possible-values=merge squash rebase"`
+	FilePath                string   `json:"filePath,omitempty"`
+	FilePaths               []string `json:"filePaths,omitempty"`
+	ContainerName           string   `json:"containerName,omitempty" validate:"required-if=tool:kubectl"`
+	ContainerRegistryURL    string   `json:"containerRegistryUrl,omitempty" validate:"required"`
+	ContainerImageNameTag   string   `json:"containerImageNameTag,omitempty" validate:"required"`
+	ContainerImageNameTags  []string `json:"containerImageNameTags,omitempty"`
+	ChartPath               string   `json:"chartPath,omitempty" validate:"required-if=tool:helm"`
+	HelmValues              []string `json:"helmValues,omitempty"`
+	DeploymentName          string   `json:"deploymentName,omitempty" validate:"required-if=tool:helm"`
+	Tool                    string   `json:"tool,omitempty" validate:"required,possible-values=kubectl helm kustomize helmValuesFile"`
+}
+
+type gitopsUpdateDeploymentCommonPipelineEnvironment struct {
+	custom struct {
+		gitopsPullRequestURL string
+	}
+}
+
+func (p *gitopsUpdateDeploymentCommonPipelineEnvironment) persist(path, resourceName string) {
+	content := []struct {
+		category string
+		name     string
+		value    interface{}
+	}{
+		{category: "custom", name: "gitopsPullRequestUrl", value: p.custom.gitopsPullRequestURL},
+	}
+
+	errCount := 0
+	for _, param := range content {
+		err := piperenv.SetResourceParameter(path, resourceName, filepath.Join(param.category, param.name), param.value)
+		if err != nil {
+			log.Entry().WithError(err).Error("Error persisting piper environment.")
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		log.Entry().Fatal("failed to persist Piper environment")
+	}
 }
 
 // GitopsUpdateDeploymentCommand Updates Kubernetes Deployment Manifest in an Infrastructure Git Repository
@@ -36,6 +79,7 @@ func GitopsUpdateDeploymentCommand() *cobra.Command {
 	metadata := gitopsUpdateDeploymentMetadata()
 	var stepConfig gitopsUpdateDeploymentOptions
 	var startTime time.Time
+	var commonPipelineEnvironment gitopsUpdateDeploymentCommonPipelineEnvironment
 
 	var createGitopsUpdateDeploymentCmd = &cobra.Command{
 		Use:   STEP_NAME,
@@ -44,9 +88,18 @@ func GitopsUpdateDeploymentCommand() *cobra.Command {
 
 It can for example be used for GitOps scenarios where the update of the manifests triggers an update of the corresponding deployment in Kubernetes.
 
-As of today, it supports the update of deployment yaml files via kubectl patch and update a whole helm template.
+As of today, it supports the update of deployment yaml files via kubectl patch, update a whole helm template, update kustomize overlays and update image tags within helm values files.
 For kubectl the container inside the yaml must be described within the following hierarchy: ` + "`" + `{"spec":{"template":{"spec":{"containers":[{...}]}}}}` + "`" + `
-For helm the whole template is generated into a file and uploaded into the repository.`,
+For helm the whole template is generated into a file and uploaded into the repository.
+For kustomize the images are set via ` + "`" + `kustomize edit set image` + "`" + ` in each overlay directory.
+For helmValuesFile the image tags are updated within the values files while comments and formatting are kept.
+Images are detected either as ` + "`" + `image: <image>:<tag>` + "`" + ` or as ` + "`" + `repository` + "`" + ` and ` + "`" + `tag` + "`" + ` (optionally with ` + "`" + `registry` + "`" + `) within the same map.
+
+Multiple files or overlays can be updated within one commit using ` + "`" + `filePaths` + "`" + `, additional images can be updated using ` + "`" + `containerImageNameTags` + "`" + ` for kustomize and helmValuesFile.
+
+For protected branches the changes can be pushed to a generated branch instead, for which a GitHub pull request into ` + "`" + `branchName` + "`" + ` is created with ` + "`" + `createPullRequest` + "`" + `.
+Optionally the step waits for the checks of the pull request and merges it once the checks succeeded.
+The URL of the pull request is provided in the commonPipelineEnvironment as ` + "`" + `custom/gitopsPullRequestUrl` + "`" + `.`,
 		PreRunE: func(cmd *cobra.Command, _ []string) error {
 			startTime = time.Now()
 			log.SetStepName(STEP_NAME)
@@ -59,10 +112,22 @@ For helm the whole template is generated into a file and uploaded into the repos
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Username)
 			log.RegisterSecret(stepConfig.Password)
+			log.RegisterSecret(stepConfig.GithubToken)
+
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
 
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
@@ -76,14 +141,21 @@ For helm the whole template is generated into a file and uploaded into the repos
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
+				commonPipelineEnvironment.persist(GeneralConfig.EnvRootPath, "commonPipelineEnvironment")
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
 			telemetry.Initialize(GeneralConfig.NoTelemetry, STEP_NAME)
-			gitopsUpdateDeployment(stepConfig, &telemetryData)
+			gitopsUpdateDeployment(stepConfig, &telemetryData, &commonPipelineEnvironment)
 			telemetryData.ErrorCode = "0"
 			log.Entry().Info("SUCCESS")
 		},
@@ -99,10 +171,20 @@ func addGitopsUpdateDeploymentFlags(cmd *cobra.Command, stepConfig *gitopsUpdate
 	cmd.Flags().StringVar(&stepConfig.ServerURL, "serverUrl", `https://github.com`, "GitHub server url to the repository.")
 	cmd.Flags().StringVar(&stepConfig.Username, "username", os.Getenv("PIPER_username"), "User name for git authentication")
 	cmd.Flags().StringVar(&stepConfig.Password, "password", os.Getenv("PIPER_password"), "Password/token for git authentication.")
+	cmd.Flags().BoolVar(&stepConfig.CreatePullRequest, "createPullRequest", false, "Pushes the changes to a generated branch and creates a pull request into `branchName` instead of pushing to `branchName` directly.")
+	cmd.Flags().StringVar(&stepConfig.PullRequestBranchPrefix, "pullRequestBranchPrefix", `gitops-update`, "Prefix of the generated branch, the branch name is completed by the commit id.")
+	cmd.Flags().StringVar(&stepConfig.GithubAPIURL, "githubApiUrl", `https://api.github.com`, "Set the GitHub API url.")
+	cmd.Flags().StringVar(&stepConfig.GithubToken, "githubToken", os.Getenv("PIPER_githubToken"), "GitHub personal access token for creating and merging the pull request. If not provided, `password` is used.")
+	cmd.Flags().BoolVar(&stepConfig.WaitForChecks, "waitForChecks", false, "Waits until the checks of the pull request succeeded. In case the target branch is protected only the required checks are considered, otherwise all reported checks. Without required checks the step waits until at least one check has been reported. Reading the required checks requires admin permission on the repository, the step fails in case the token lacks it.")
+	cmd.Flags().IntVar(&stepConfig.ChecksTimeout, "checksTimeout", 900, "Time in seconds to wait for the checks of the pull request.")
+	cmd.Flags().BoolVar(&stepConfig.AutoMerge, "autoMerge", false, "Merges the pull request as soon as its checks succeeded. Implies `waitForChecks`. The pull request is merged by the step itself within `checksTimeout`, the auto-merge feature of GitHub is not used, i.e. the pull request stays open in case the step is aborted or the checks finish later.")
+	cmd.Flags().StringVar(&stepConfig.MergeMethod, "mergeMethod", `squash`, "Merge method used for merging the pull request.")
 	cmd.Flags().StringVar(&stepConfig.FilePath, "filePath", os.Getenv("PIPER_filePath"), "Relative path in the git repository to the deployment descriptor file that shall be updated")
+	cmd.Flags().StringSliceVar(&stepConfig.FilePaths, "filePaths", []string{}, "Relative paths in the git repository to additional deployment descriptor files or kustomize overlay directories that shall be updated within the same commit")
 	cmd.Flags().StringVar(&stepConfig.ContainerName, "containerName", os.Getenv("PIPER_containerName"), "The name of the container to update")
 	cmd.Flags().StringVar(&stepConfig.ContainerRegistryURL, "containerRegistryUrl", os.Getenv("PIPER_containerRegistryUrl"), "http(s) url of the Container registry where the image is located")
 	cmd.Flags().StringVar(&stepConfig.ContainerImageNameTag, "containerImageNameTag", os.Getenv("PIPER_containerImageNameTag"), "Container image name with version tag to annotate in the deployment configuration.")
+	cmd.Flags().StringSliceVar(&stepConfig.ContainerImageNameTags, "containerImageNameTags", []string{}, "Additional container images with version tag including the registry, e.g. `my.registry.com/myImage:1.0.0`, which shall be updated together with `containerImageNameTag`.")
 	cmd.Flags().StringVar(&stepConfig.ChartPath, "chartPath", os.Getenv("PIPER_chartPath"), "Defines the chart path for deployments using helm.")
 	cmd.Flags().StringSliceVar(&stepConfig.HelmValues, "helmValues", []string{}, "List of helm values as YAML file reference or URL (as per helm parameter description for `-f` / `--values`)")
 	cmd.Flags().StringVar(&stepConfig.DeploymentName, "deploymentName", os.Getenv("PIPER_deploymentName"), "Defines the name of the deployment.")
@@ -112,7 +194,6 @@ func addGitopsUpdateDeploymentFlags(cmd *cobra.Command, stepConfig *gitopsUpdate
 	cmd.MarkFlagRequired("serverUrl")
 	cmd.MarkFlagRequired("username")
 	cmd.MarkFlagRequired("password")
-	cmd.MarkFlagRequired("filePath")
 	cmd.MarkFlagRequired("containerRegistryUrl")
 	cmd.MarkFlagRequired("containerImageNameTag")
 	cmd.MarkFlagRequired("tool")
@@ -184,12 +265,126 @@ func gitopsUpdateDeploymentMetadata() config.StepData {
 						Mandatory: true,
 						Aliases:   []config.Alias{},
 					},
+					{
+						Name:        "createPullRequest",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "bool",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "pullRequestBranchPrefix",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "githubApiUrl",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"GENERAL", "PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name: "githubToken",
+						ResourceRef: []config.ResourceReference{
+							{
+								Name: "githubTokenCredentialsId",
+								Type: "secret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"$(vaultPath)/github", "$(vaultBasePath)/$(vaultPipelineName)/github", "$(vaultBasePath)/GROUP-SECRETS/github"},
+								Type:  "vaultSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"github"},
+								Type:  "fileSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"PIPER_GITHUB"},
+								Type:  "envSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-github"},
+								Type:  "kubernetesSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper/github"},
+								Type:  "awsSecret",
+							},
+
+							{
+								Name:  "",
+								Paths: []string{"piper-github"},
+								Type:  "azureSecret",
+							},
+						},
+						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:      "string",
+						Mandatory: false,
+						Aliases:   []config.Alias{{Name: "access_token"}},
+					},
+					{
+						Name:        "waitForChecks",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "bool",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "checksTimeout",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "int",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "autoMerge",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "bool",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:           "mergeMethod",
+						ResourceRef:    []config.ResourceReference{},
+						Scope:          []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:           "string",
+						Mandatory:      false,
+						Aliases:        []config.Alias{},
+						PossibleValues: []interface{}{"merge", "squash", "rebase"},
+					},
 					{
 						Name:        "filePath",
 						ResourceRef: []config.ResourceReference{},
 						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
 						Type:        "string",
-						Mandatory:   true,
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "filePaths",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "[]string",
+						Mandatory:   false,
 						Aliases:     []config.Alias{},
 					},
 					{
@@ -224,7 +419,15 @@ func gitopsUpdateDeploymentMetadata() config.StepData {
 						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
 						Type:      "string",
 						Mandatory: true,
-						Aliases:   []config.Alias{{Name: "image"}, {Name: "containerImage"}},
+						Aliases:   []config.Alias{{Name: "image", Deprecated: true}, {Name: "containerImage"}},
+					},
+					{
+						Name:        "containerImageNameTags",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "[]string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
 					},
 					{
 						Name:        "chartPath",
@@ -251,18 +454,31 @@ func gitopsUpdateDeploymentMetadata() config.StepData {
 						Aliases:     []config.Alias{{Name: "helmDeploymentName"}},
 					},
 					{
-						Name:        "tool",
-						ResourceRef: []config.ResourceReference{},
-						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
-						Type:        "string",
-						Mandatory:   true,
-						Aliases:     []config.Alias{},
+						Name:           "tool",
+						ResourceRef:    []config.ResourceReference{},
+						Scope:          []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:           "string",
+						Mandatory:      true,
+						Aliases:        []config.Alias{},
+						PossibleValues: []interface{}{"kubectl", "helm", "kustomize", "helmValuesFile"},
 					},
 				},
 			},
 			Containers: []config.Container{
 				{Image: "dtzar/helm-kubectl:3.3.4", WorkingDir: "/config", Options: []config.Option{{Name: "-u", Value: "0"}}, Conditions: []config.Condition{{ConditionRef: "strings-equal", Params: []config.Param{{Name: "tool", Value: "helm"}}}}},
 				{Image: "dtzar/helm-kubectl:2.12.1", WorkingDir: "/config", Options: []config.Option{{Name: "-u", Value: "0"}}, Conditions: []config.Condition{{ConditionRef: "strings-equal", Params: []config.Param{{Name: "tool", Value: "kubectl"}}}}},
+				{Image: "k8s.gcr.io/kustomize/kustomize:v3.8.7", WorkingDir: "/config", Options: []config.Option{{Name: "-u", Value: "0"}}, Conditions: []config.Condition{{ConditionRef: "strings-equal", Params: []config.Param{{Name: "tool", Value: "kustomize"}}}}},
+			},
+			Outputs: config.StepOutputs{
+				Resources: []config.StepResources{
+					{
+						Name: "commonPipelineEnvironment",
+						Type: "piperEnvironment",
+						Parameters: []map[string]interface{}{
+							{"Name": "custom/gitopsPullRequestUrl"},
+						},
+					},
+				},
 			},
 		},
 	}
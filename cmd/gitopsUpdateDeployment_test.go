@@ -1,16 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-github/v32/github"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestBuildRegistryPlusImage(t *testing.T) {
@@ -97,7 +101,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(validConfiguration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, validConfiguration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -118,7 +122,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, validConfiguration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -139,7 +143,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, configuration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -159,7 +163,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, configuration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -179,7 +183,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, configuration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -196,18 +200,15 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		var configuration = *validConfiguration
 		configuration.ContainerName = ""
 
-		gitUtilsMock := &gitUtilsMock{}
-		runnerMock := &gitOpsExecRunnerMock{}
-
-		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{})
-		assert.EqualError(t, err, "missing required fields for kubectl: the following parameters are necessary for kubectl: [containerName]")
+		err := validation.ValidateStruct(configuration)
+		assert.EqualError(t, err, "the configuration is invalid: parameter 'containerName' is required since 'tool' is 'kubectl'")
 	})
 
 	t.Run("error on kubectl execution", func(t *testing.T) {
 		t.Parallel()
 		runner := &gitOpsExecRunnerMock{failOnRunExecutable: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, runner, &gitUtilsMock{}, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, runner, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "error on kubectl execution: failed to apply kubectl command: failed to apply kubectl command: error happened")
 	})
 
@@ -216,7 +217,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		var configuration = *validConfiguration
 		configuration.ContainerRegistryURL = "//myregistry.com/registry/containers"
 
-		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "error on kubectl execution: failed to apply kubectl command: registry URL could not be extracted: invalid registry url")
 	})
 
@@ -224,7 +225,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		t.Parallel()
 		gitUtils := &gitUtilsMock{failOnClone: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "repository could not get prepared: failed to plain clone repository: error on clone")
 	})
 
@@ -232,7 +233,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		t.Parallel()
 		gitUtils := &gitUtilsMock{failOnChangeBranch: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "repository could not get prepared: failed to change branch: error on change branch")
 	})
 
@@ -240,7 +241,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		t.Parallel()
 		gitUtils := &gitUtilsMock{failOnCommit: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to commit and push changes: committing changes failed: error on commit")
 	})
 
@@ -248,7 +249,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		t.Parallel()
 		gitUtils := &gitUtilsMock{failOnPush: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to commit and push changes: pushing changes failed: error on push")
 	})
 
@@ -256,7 +257,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		t.Parallel()
 		fileUtils := &filesMock{failOnCreation: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils)
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to create temporary directory: error appeared")
 	})
 
@@ -264,7 +265,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		t.Parallel()
 		fileUtils := &filesMock{failOnWrite: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils)
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to write file: error appeared")
 	})
 
@@ -272,7 +273,7 @@ func TestRunGitopsUpdateDeploymentWithKubectl(t *testing.T) {
 		t.Parallel()
 		fileUtils := &filesMock{failOnDeletion: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils)
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		_ = piperutils.Files{}.RemoveAll(fileUtils.path)
 	})
@@ -297,7 +298,7 @@ func TestRunGitopsUpdateDeploymentWithInvalid(t *testing.T) {
 			HelmValues:            []string{"./helm/additionalValues.yaml"},
 		}
 
-		err := runGitopsUpdateDeployment(configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{})
+		err := runGitopsUpdateDeployment(configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "tool invalid is not supported")
 	})
 }
@@ -324,7 +325,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(validConfiguration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, validConfiguration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -347,7 +348,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, configuration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -370,7 +371,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, configuration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -392,7 +393,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		gitUtilsMock := &gitUtilsMock{}
 		runnerMock := &gitOpsExecRunnerMock{}
 
-		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		assert.Equal(t, configuration.BranchName, gitUtilsMock.changedBranch)
 		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
@@ -409,7 +410,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		var configuration = *validConfiguration
 		configuration.ContainerRegistryURL = "://myregistry.com"
 
-		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, `failed to apply helm command: failed to extract registry URL, image name, and image tag: registry URL could not be extracted: invalid registry url: parse "://myregistry.com": missing protocol scheme`)
 	})
 
@@ -418,8 +419,8 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		var configuration = *validConfiguration
 		configuration.ChartPath = ""
 
-		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{})
-		assert.EqualError(t, err, "missing required fields for helm: the following parameters are necessary for helm: [chartPath]")
+		err := validation.ValidateStruct(configuration)
+		assert.EqualError(t, err, "the configuration is invalid: parameter 'chartPath' is required since 'tool' is 'helm'")
 	})
 
 	t.Run("missing DeploymentName", func(t *testing.T) {
@@ -427,8 +428,8 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		var configuration = *validConfiguration
 		configuration.DeploymentName = ""
 
-		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{})
-		assert.EqualError(t, err, "missing required fields for helm: the following parameters are necessary for helm: [deploymentName]")
+		err := validation.ValidateStruct(configuration)
+		assert.EqualError(t, err, "the configuration is invalid: parameter 'deploymentName' is required since 'tool' is 'helm'")
 	})
 
 	t.Run("missing DeploymentName and ChartPath", func(t *testing.T) {
@@ -437,8 +438,8 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		configuration.DeploymentName = ""
 		configuration.ChartPath = ""
 
-		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{})
-		assert.EqualError(t, err, "missing required fields for helm: the following parameters are necessary for helm: [chartPath deploymentName]")
+		err := validation.ValidateStruct(configuration)
+		assert.EqualError(t, err, "the configuration is invalid: parameter 'chartPath' is required since 'tool' is 'helm'; parameter 'deploymentName' is required since 'tool' is 'helm'")
 	})
 
 	t.Run("erroneous tag", func(t *testing.T) {
@@ -446,7 +447,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		var configuration = *validConfiguration
 		configuration.ContainerImageNameTag = "registry/containers/myFancyContainer:"
 
-		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to apply helm command: failed to extract registry URL, image name, and image tag: tag could not be extracted")
 	})
 
@@ -455,7 +456,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		var configuration = *validConfiguration
 		configuration.ContainerImageNameTag = ":1.0.1"
 
-		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{})
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to apply helm command: failed to extract registry URL, image name, and image tag: image name could not be extracted")
 	})
 
@@ -463,7 +464,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		t.Parallel()
 		runner := &gitOpsExecRunnerMock{failOnRunExecutable: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, runner, &gitUtilsMock{}, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, runner, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to apply helm command: failed to execute helm command: error happened")
 	})
 
@@ -471,7 +472,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		t.Parallel()
 		gitUtils := &gitUtilsMock{failOnClone: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "repository could not get prepared: failed to plain clone repository: error on clone")
 	})
 
@@ -479,7 +480,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		t.Parallel()
 		gitUtils := &gitUtilsMock{failOnChangeBranch: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "repository could not get prepared: failed to change branch: error on change branch")
 	})
 
@@ -487,7 +488,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		t.Parallel()
 		gitUtils := &gitUtilsMock{failOnCommit: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to commit and push changes: committing changes failed: error on commit")
 	})
 
@@ -495,7 +496,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		t.Parallel()
 		gitUtils := &gitUtilsMock{failOnPush: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{})
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtils, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to commit and push changes: pushing changes failed: error on push")
 	})
 
@@ -503,7 +504,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		t.Parallel()
 		fileUtils := &filesMock{failOnCreation: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils)
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to create temporary directory: error appeared")
 	})
 
@@ -511,7 +512,7 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		t.Parallel()
 		fileUtils := &filesMock{failOnWrite: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils)
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.EqualError(t, err, "failed to write file: error appeared")
 	})
 
@@ -519,19 +520,293 @@ func TestRunGitopsUpdateDeploymentWithHelm(t *testing.T) {
 		t.Parallel()
 		fileUtils := &filesMock{failOnDeletion: true}
 
-		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils)
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, fileUtils, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
 		assert.NoError(t, err)
 		_ = piperutils.Files{}.RemoveAll(fileUtils.path)
 	})
 }
 
+func TestRunGitopsUpdateDeploymentWithKustomize(t *testing.T) {
+	var validConfiguration = &gitopsUpdateDeploymentOptions{
+		BranchName:             "main",
+		ServerURL:              "https://github.com",
+		Username:               "admin3",
+		Password:               "validAccessToken",
+		FilePath:               "overlays/dev",
+		FilePaths:              []string{"overlays/prod"},
+		ContainerRegistryURL:   "https://myregistry.com",
+		ContainerImageNameTag:  "myFancyContainer:1337",
+		ContainerImageNameTags: []string{"myregistry.com/mySidecar:1.0"},
+		Tool:                   "kustomize",
+	}
+
+	t.Parallel()
+	t.Run("successful run", func(t *testing.T) {
+		t.Parallel()
+		gitUtilsMock := &gitUtilsMock{}
+		runnerMock := &gitOpsExecRunnerMock{}
+
+		err := runGitopsUpdateDeployment(validConfiguration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.NoError(t, err)
+		assert.Equal(t, "kustomize", runnerMock.executable)
+		assert.Equal(t, []string{"edit", "set", "image", "myregistry.com/myFancyContainer:1337", "myregistry.com/mySidecar:1.0"}, runnerMock.params)
+		if assert.Len(t, runnerMock.dirs, 3) {
+			assert.True(t, strings.HasSuffix(runnerMock.dirs[0], filepath.Join("overlays", "dev")))
+			assert.True(t, strings.HasSuffix(runnerMock.dirs[1], filepath.Join("overlays", "prod")))
+			assert.Equal(t, "", runnerMock.dirs[2])
+		}
+		assert.Equal(t, []string{"overlays/dev", "overlays/prod"}, gitUtilsMock.committedFiles)
+		assert.Equal(t, "Updated myregistry.com/myFancyContainer to version 1337, myregistry.com/mySidecar to version 1.0", gitUtilsMock.commitMessage)
+	})
+
+	t.Run("missing file paths", func(t *testing.T) {
+		t.Parallel()
+		var configuration = *validConfiguration
+		configuration.FilePath = ""
+		configuration.FilePaths = nil
+
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.EqualError(t, err, "at least one of the parameters filePath or filePaths is necessary")
+	})
+
+	t.Run("erroneous additional image", func(t *testing.T) {
+		t.Parallel()
+		var configuration = *validConfiguration
+		configuration.ContainerImageNameTags = []string{"myregistry.com:5000/mySidecar"}
+
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.EqualError(t, err, "failed to apply kustomize command: failed to extract registry URL, image name, and image tag: image name and tag could not be extracted from 'myregistry.com:5000/mySidecar'")
+	})
+
+	t.Run("error on kustomize execution", func(t *testing.T) {
+		t.Parallel()
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{failOnRunExecutable: true}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.EqualError(t, err, "failed to apply kustomize command: failed to execute kustomize command in overlays/dev: error happened")
+	})
+}
+
+func TestRunGitopsUpdateDeploymentWithHelmValuesFile(t *testing.T) {
+	var validConfiguration = &gitopsUpdateDeploymentOptions{
+		BranchName:             "main",
+		CommitMessage:          "This is the commit message",
+		ServerURL:              "https://github.com",
+		Username:               "admin3",
+		Password:               "validAccessToken",
+		FilePaths:              []string{"helm/values.yaml", "helm/values-prod.yaml"},
+		ContainerRegistryURL:   "https://myregistry.com",
+		ContainerImageNameTag:  "myFancyContainer:1337",
+		ContainerImageNameTags: []string{"myregistry.com/mySidecar:1.0"},
+		Tool:                   "helmValuesFile",
+	}
+
+	t.Parallel()
+	t.Run("successful run", func(t *testing.T) {
+		t.Parallel()
+		gitUtilsMock := &gitUtilsMock{}
+		runnerMock := &gitOpsExecRunnerMock{}
+
+		err := runGitopsUpdateDeployment(validConfiguration, runnerMock, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.NoError(t, err)
+		assert.Empty(t, runnerMock.executable)
+		assert.Equal(t, []string{"helm/values.yaml", "helm/values-prod.yaml"}, gitUtilsMock.committedFiles)
+		for _, valuesFile := range gitUtilsMock.committedFiles {
+			assert.Equal(t, expectedValues, gitUtilsMock.committedContent[valuesFile])
+		}
+	})
+
+	t.Run("image not contained", func(t *testing.T) {
+		t.Parallel()
+		var configuration = *validConfiguration
+		configuration.ContainerImageNameTags = []string{"myregistry.com/unknown:1.0"}
+
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.EqualError(t, err, "failed to update helm values files: the following images are not contained in any of the files [helm/values.yaml helm/values-prod.yaml]: [myregistry.com/unknown]")
+	})
+
+	t.Run("error on file read", func(t *testing.T) {
+		t.Parallel()
+		var configuration = *validConfiguration
+		configuration.FilePaths = []string{"helm/missing.yaml"}
+
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.Contains(t, err.Error(), "failed to update helm values files: failed to read file helm/missing.yaml")
+	})
+}
+
+func TestRunGitopsUpdateDeploymentWithMultipleFiles(t *testing.T) {
+	t.Parallel()
+	t.Run("multiple files not supported for helm", func(t *testing.T) {
+		t.Parallel()
+		var configuration = &gitopsUpdateDeploymentOptions{
+			FilePath:              "dir1/dir2/depl.yaml",
+			FilePaths:             []string{"dir1/dir2/other.yaml"},
+			ContainerImageNameTag: "myFancyContainer:1337",
+			Tool:                  "helm",
+			ChartPath:             "./helm",
+			DeploymentName:        "myFancyDeployment",
+		}
+
+		err := runGitopsUpdateDeployment(configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.EqualError(t, err, "helm renders the template into a single file, but 2 file paths are configured")
+	})
+
+	t.Run("additional images not supported for kubectl", func(t *testing.T) {
+		t.Parallel()
+		var configuration = &gitopsUpdateDeploymentOptions{
+			FilePath:               "dir1/dir2/depl.yaml",
+			ContainerName:          "myContainer",
+			ContainerImageNameTag:  "myFancyContainer:1337",
+			ContainerImageNameTags: []string{"mySidecar:1.0"},
+			Tool:                   "kubectl",
+		}
+
+		err := runGitopsUpdateDeployment(configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.EqualError(t, err, "containerImageNameTags is not supported for kubectl")
+	})
+
+	t.Run("duplicate file paths are committed once", func(t *testing.T) {
+		t.Parallel()
+		var configuration = &gitopsUpdateDeploymentOptions{
+			FilePath:              "dir1/dir2/depl.yaml",
+			FilePaths:             []string{"dir1/dir2/depl.yaml"},
+			ContainerName:         "myContainer",
+			ContainerRegistryURL:  "https://myregistry.com",
+			ContainerImageNameTag: "myFancyContainer:1337",
+			Tool:                  "kubectl",
+		}
+		gitUtilsMock := &gitUtilsMock{}
+
+		err := runGitopsUpdateDeployment(configuration, &gitOpsExecRunnerMock{}, gitUtilsMock, &filesMock{}, nil, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"dir1/dir2/depl.yaml"}, gitUtilsMock.committedFiles)
+		assert.Equal(t, expectedYaml, gitUtilsMock.savedFile)
+	})
+}
+
+func TestRunGitopsUpdateDeploymentWithPullRequest(t *testing.T) {
+	var validConfiguration = &gitopsUpdateDeploymentOptions{
+		BranchName:              "main",
+		ServerURL:               "https://github.com/myOrg/myDeployments.git",
+		Username:                "admin3",
+		Password:                "validAccessToken",
+		FilePath:                "dir1/dir2/depl.yaml",
+		ContainerName:           "myContainer",
+		ContainerRegistryURL:    "https://myregistry.com",
+		ContainerImageNameTag:   "myFancyContainer:1337",
+		Tool:                    "kubectl",
+		CreatePullRequest:       true,
+		PullRequestBranchPrefix: "gitops-update",
+		ChecksTimeout:           60,
+		MergeMethod:             "squash",
+	}
+
+	t.Parallel()
+	t.Run("pull request without waiting", func(t *testing.T) {
+		t.Parallel()
+		gitUtilsMock := &gitUtilsMock{}
+		pullRequestMock := &gitopsPullRequestMock{}
+		cpe := &gitopsUpdateDeploymentCommonPipelineEnvironment{}
+
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, gitUtilsMock, &filesMock{}, pullRequestMock, cpe)
+		assert.NoError(t, err)
+		assert.Equal(t, "main:gitops-update/7b000000", gitUtilsMock.pushedBranch)
+		assert.Equal(t, "myOrg/myDeployments", pullRequestMock.repository)
+		assert.Equal(t, "gitops-update/7b000000", pullRequestMock.head)
+		assert.Equal(t, "main", pullRequestMock.base)
+		assert.Equal(t, "Updated myregistry.com/myFancyContainer to version 1337", pullRequestMock.title)
+		assert.Equal(t, "Updated myregistry.com/myFancyContainer to version 1337\n\nUpdated files:\n- `dir1/dir2/depl.yaml`\n", pullRequestMock.body)
+		assert.Equal(t, "https://github.com/myOrg/myDeployments/pull/7", cpe.custom.gitopsPullRequestURL)
+		assert.Equal(t, time.Duration(0), pullRequestMock.timeout)
+		assert.Empty(t, pullRequestMock.mergeMethod)
+	})
+
+	t.Run("auto merge after checks", func(t *testing.T) {
+		t.Parallel()
+		var configuration = *validConfiguration
+		configuration.AutoMerge = true
+		pullRequestMock := &gitopsPullRequestMock{}
+
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, pullRequestMock, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, pullRequestMock.timeout)
+		assert.Equal(t, "squash", pullRequestMock.mergeMethod)
+	})
+
+	t.Run("failed checks prevent merge", func(t *testing.T) {
+		t.Parallel()
+		var configuration = *validConfiguration
+		configuration.AutoMerge = true
+		pullRequestMock := &gitopsPullRequestMock{failOnChecks: true}
+		cpe := &gitopsUpdateDeploymentCommonPipelineEnvironment{}
+
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, pullRequestMock, cpe)
+		assert.EqualError(t, err, "pull request checks did not succeed: checks failed")
+		assert.Empty(t, pullRequestMock.mergeMethod)
+		assert.Equal(t, "https://github.com/myOrg/myDeployments/pull/7", cpe.custom.gitopsPullRequestURL)
+	})
+
+	t.Run("error on pull request creation", func(t *testing.T) {
+		t.Parallel()
+		err := runGitopsUpdateDeployment(validConfiguration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, &gitopsPullRequestMock{failOnCreate: true}, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.EqualError(t, err, "failed to create pull request for branch gitops-update/7b000000: error on create")
+	})
+
+	t.Run("server url without repository", func(t *testing.T) {
+		t.Parallel()
+		var configuration = *validConfiguration
+		configuration.ServerURL = "https://github.com"
+
+		err := runGitopsUpdateDeployment(&configuration, &gitOpsExecRunnerMock{}, &gitUtilsMock{}, &filesMock{}, &gitopsPullRequestMock{}, &gitopsUpdateDeploymentCommonPipelineEnvironment{})
+		assert.EqualError(t, err, "server url https://github.com does not point to a GitHub repository in the form https://github.com/<owner>/<repository>")
+	})
+}
+
+type gitopsPullRequestMock struct {
+	repository   string
+	head         string
+	base         string
+	title        string
+	body         string
+	timeout      time.Duration
+	mergeMethod  string
+	failOnCreate bool
+	failOnChecks bool
+}
+
+func (p *gitopsPullRequestMock) CreatePullRequest(_ context.Context, owner, repository, head, base, title, body string) (*github.PullRequest, error) {
+	if p.failOnCreate {
+		return nil, errors.New("error on create")
+	}
+	p.repository = owner + "/" + repository
+	p.head, p.base, p.title, p.body = head, base, title, body
+	return &github.PullRequest{Number: github.Int(7), HTMLURL: github.String("https://github.com/myOrg/myDeployments/pull/7")}, nil
+}
+
+func (p *gitopsPullRequestMock) WaitForChecks(_ context.Context, _, _ string, _ *github.PullRequest, timeout time.Duration) error {
+	p.timeout = timeout
+	if p.failOnChecks {
+		return errors.New("checks failed")
+	}
+	return nil
+}
+
+func (p *gitopsPullRequestMock) MergePullRequest(_ context.Context, _, _ string, _ *github.PullRequest, mergeMethod string) error {
+	p.mergeMethod = mergeMethod
+	return nil
+}
+
 type gitOpsExecRunnerMock struct {
 	out                 io.Writer
 	params              []string
 	executable          string
+	dirs                []string
 	failOnRunExecutable bool
 }
 
+func (e *gitOpsExecRunnerMock) SetDir(dir string) {
+	e.dirs = append(e.dirs, dir)
+}
+
 func (e *gitOpsExecRunnerMock) Stdout(out io.Writer) {
 	e.out = out
 }
@@ -564,6 +839,10 @@ func (f filesMock) FileWrite(path string, content []byte, perm os.FileMode) erro
 	return piperutils.Files{}.FileWrite(path, content, perm)
 }
 
+func (f filesMock) FileRead(path string) ([]byte, error) {
+	return piperutils.Files{}.FileRead(path)
+}
+
 func (f filesMock) TempDir(dir string, pattern string) (name string, err error) {
 	if f.failOnCreation {
 		return "", errors.New("error appeared")
@@ -583,6 +862,9 @@ type gitUtilsMock struct {
 	savedFile          string
 	changedBranch      string
 	commitMessage      string
+	committedFiles     []string
+	committedContent   map[string]string
+	pushedBranch       string
 	temporaryDirectory string
 	failOnClone        bool
 	failOnChangeBranch bool
@@ -602,12 +884,19 @@ func (v *gitUtilsMock) ChangeBranch(branchName string) error {
 	return nil
 }
 
-func (v *gitUtilsMock) CommitSingleFile(_ string, commitMessage string, _ string) (plumbing.Hash, error) {
+func (v *gitUtilsMock) CommitFiles(filePaths []string, commitMessage string, _ string) (plumbing.Hash, error) {
 	if v.failOnCommit {
 		return [20]byte{}, errors.New("error on commit")
 	}
 
 	v.commitMessage = commitMessage
+	v.committedFiles = filePaths
+	v.committedContent = map[string]string{}
+	for _, filePath := range filePaths {
+		if content, err := (piperutils.Files{}).FileRead(filepath.Join(v.temporaryDirectory, filePath)); err == nil {
+			v.committedContent[filePath] = string(content)
+		}
+	}
 
 	matches, _ := piperutils.Files{}.Glob(v.temporaryDirectory + "/dir1/dir2/depl.yaml")
 	if len(matches) < 1 {
@@ -625,6 +914,14 @@ func (v gitUtilsMock) PushChangesToRepository(string, string) error {
 	return nil
 }
 
+func (v *gitUtilsMock) PushChangesToBranch(_, _, localBranch, remoteBranch string) error {
+	if v.failOnPush {
+		return errors.New("error on push")
+	}
+	v.pushedBranch = localBranch + ":" + remoteBranch
+	return nil
+}
+
 func (v *gitUtilsMock) PlainClone(_, _, _, directory string) error {
 	if v.failOnClone {
 		return errors.New("error on clone")
@@ -639,8 +936,18 @@ func (v *gitUtilsMock) PlainClone(_, _, _, directory string) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	err = piperutils.Files{}.MkdirAll(filepath.Join(directory, "helm"), 0755)
+	if err != nil {
+		return err
+	}
+	err = piperutils.Files{}.FileWrite(filepath.Join(directory, "helm/values.yaml"), []byte(existingValues), 0755)
+	if err != nil {
+		return err
+	}
+	return piperutils.Files{}.FileWrite(filepath.Join(directory, "helm/values-prod.yaml"), []byte(existingValues), 0755)
 }
 
 var existingYaml = "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: myFancyApp\n  labels:\n    tier: application\nspec:\n  replicas: 4\n  selector:\n    matchLabels:\n      run: myContainer\n  template:\n    metadata:\n      labels:\n        run: myContainer\n    spec:\n      containers:\n      - image: myregistry.com/myFancyContainer:1336\n        name: myContainer"
 var expectedYaml = "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: myFancyApp\n  labels:\n    tier: application\nspec:\n  replicas: 4\n  selector:\n    matchLabels:\n      run: myContainer\n  template:\n    metadata:\n      labels:\n        run: myContainer\n    spec:\n      containers:\n      - image: myregistry.com/myFancyContainer:1337\n        name: myContainer"
+var existingValues = "# values of myFancyApp\nimage:\n  repository: myregistry.com/myFancyContainer\n  tag: \"1336\" # set by the pipeline\nsidecar:\n  image: myregistry.com/mySidecar:0.9\n"
+var expectedValues = "# values of myFancyApp\nimage:\n  repository: myregistry.com/myFancyContainer\n  tag: \"1337\" # set by the pipeline\nsidecar:\n  image: myregistry.com/mySidecar:1.0\n"
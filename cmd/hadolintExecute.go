@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -11,6 +12,7 @@ import (
 	piperhttp "github.com/SAP/jenkins-library/pkg/http"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/SAP/jenkins-library/pkg/reporting"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
 	"github.com/pkg/errors"
 )
@@ -98,19 +100,52 @@ func runHadolint(config hadolintExecuteOptions, utils hadolintUtils) error {
 	//TODO: related to https://github.com/hadolint/hadolint/issues/391
 	// hadolint exists with 1 if there are processing issues but also if there are findings
 	// thus check stdout first if a report was created
+	reports := []piperutils.Path{{Target: config.ReportFile}}
 	if output := outputBuffer.String(); len(output) > 0 {
 		log.Entry().WithField("report", output).Debug("Report created")
 		utils.FileWrite(config.ReportFile, []byte(output), 0666)
+		if sarifFile, err := writeHadolintSarif(config, []byte(output), utils); err != nil {
+			log.Entry().WithError(err).Warning("failed to write SARIF report")
+		} else {
+			reports = append(reports, piperutils.Path{Target: sarifFile})
+		}
 	} else if err != nil {
 		// if stdout is empty a processing issue occured
 		return errors.Wrap(err, errorBuffer.String())
 	}
 	//TODO: mock away in tests
 	// persist report information
-	piperutils.PersistReportsAndLinks("hadolintExecute", "./", []piperutils.Path{{Target: config.ReportFile}}, []piperutils.Path{})
+	piperutils.PersistReportsAndLinks("hadolintExecute", "./", reports, []piperutils.Path{})
 	return nil
 }
 
+// writeHadolintSarif converts the checkstyle report into SARIF format and writes it next to the checkstyle report
+func writeHadolintSarif(config hadolintExecuteOptions, checkstyleReport []byte, utils hadolintUtils) (string, error) {
+	findings, err := reporting.FindingsFromCheckstyle(checkstyleReport)
+	if err != nil {
+		return "", err
+	}
+	for i := range findings {
+		findings[i].HelpURI = fmt.Sprintf("https://github.com/hadolint/hadolint/wiki/%v", findings[i].RuleID)
+	}
+	findingsReport := reporting.FindingsReport{
+		StepName:       "hadolintExecute",
+		ToolName:       "hadolint",
+		InformationURI: "https://github.com/hadolint/hadolint",
+		Successful:     true,
+		Findings:       findings,
+	}
+	sarif, err := findingsReport.ToSARIF()
+	if err != nil {
+		return "", err
+	}
+	sarifFile := reporting.SarifFileName(config.ReportFile)
+	if err := utils.FileWrite(sarifFile, sarif, 0666); err != nil {
+		return "", errors.Wrapf(err, "failed to write %v", sarifFile)
+	}
+	return sarifFile, nil
+}
+
 // loadConfigurationFile loads a file from the provided url
 func loadConfigurationFile(url, file string, utils hadolintUtils) error {
 	log.Entry().WithField("url", url).Debug("Loading configuration file from URL")
@@ -1,15 +1,29 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/SAP/jenkins-library/pkg/hadolint/mocks"
 	piperMocks "github.com/SAP/jenkins-library/pkg/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRunHadolintExecute(t *testing.T) {
+	// change into tmp dir since the step writes its reports and links into the working directory
+	dir, err := ioutil.TempDir("", "test-hadolint-")
+	require.NoError(t, err)
+	oldCWD, _ := os.Getwd()
+	require.NoError(t, os.Chdir(dir))
+	// clean up tmp dir
+	defer func() {
+		_ = os.Chdir(oldCWD)
+		_ = os.RemoveAll(dir)
+	}()
+
 	t.Run("default", func(t *testing.T) {
 		// init
 		fileMock := &mocks.HadolintPiperFileUtils{}
@@ -44,6 +58,43 @@ func TestRunHadolintExecute(t *testing.T) {
 		clientMock.AssertExpectations(t)
 	})
 
+	t.Run("with findings", func(t *testing.T) {
+		// init
+		fileMock := &mocks.HadolintPiperFileUtils{}
+		clientMock := &mocks.HadolintClient{}
+		runnerMock := &piperMocks.ExecMockRunner{
+			StdoutReturn: map[string]string{"hadolint": `<checkstyle version='4.3'><file name='./Dockerfile'><error line='3' column='1' severity='warning' message='Pin versions in apt get install.' source='DL3008' /></file></checkstyle>`},
+		}
+		config := hadolintExecuteOptions{
+			DockerFile:        "./Dockerfile",   // default
+			ConfigurationFile: ".hadolint.yaml", // default
+			ReportFile:        "hadolint.xml",   // default
+		}
+
+		var sarif []byte
+		fileMock.
+			On("FileExists", config.ConfigurationFile).Return(false, nil).
+			On("FileWrite", "hadolint.xml", mock.Anything, mock.Anything).Return(nil)
+		fileMock.On("FileWrite", "hadolint.sarif", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			sarif = args.Get(1).([]byte)
+		})
+
+		// test
+		err := runHadolint(config, hadolintUtils{
+			HadolintPiperFileUtils: fileMock,
+			HadolintClient:         clientMock,
+			hadolintRunner:         runnerMock,
+		})
+		// assert
+		assert.NoError(t, err)
+		assert.Contains(t, string(sarif), `"ruleId": "DL3008"`)
+		assert.Contains(t, string(sarif), `"uri": "./Dockerfile"`)
+		assert.Contains(t, string(sarif), `"helpUri": "https://github.com/hadolint/hadolint/wiki/DL3008"`)
+		// assert that mocks are called as previously defined
+		fileMock.AssertExpectations(t)
+		clientMock.AssertExpectations(t)
+	})
+
 	t.Run("with remote config", func(t *testing.T) {
 		// init
 		fileMock := &mocks.HadolintPiperFileUtils{}
@@ -83,4 +134,5 @@ func TestRunHadolintExecute(t *testing.T) {
 		fileMock.AssertExpectations(t)
 		clientMock.AssertExpectations(t)
 	})
+
 }
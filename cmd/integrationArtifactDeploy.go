@@ -65,7 +65,7 @@ func integrationArtifactDeploy(config integrationArtifactDeployOptions, telemetr
 }
 
 func runIntegrationArtifactDeploy(config *integrationArtifactDeployOptions, telemetryData *telemetry.CustomData, httpClient piperhttp.Sender) error {
-	clientOptions := piperhttp.ClientOptions{}
+	clientOptions := piperhttp.ClientOptions{}.WithServicePolicies()
 	header := make(http.Header)
 	header.Add("Accept", "application/json")
 	deployURL := fmt.Sprintf("%s/api/v1/DeployIntegrationDesigntimeArtifact?Id='%s'&Version='%s'", config.Host, config.IntegrationFlowID, config.IntegrationFlowVersion)
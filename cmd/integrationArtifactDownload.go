@@ -63,7 +63,7 @@ func integrationArtifactDownload(config integrationArtifactDownloadOptions, tele
 }
 
 func runIntegrationArtifactDownload(config *integrationArtifactDownloadOptions, telemetryData *telemetry.CustomData, httpClient piperhttp.Sender) error {
-	clientOptions := piperhttp.ClientOptions{}
+	clientOptions := piperhttp.ClientOptions{}.WithServicePolicies()
 	header := make(http.Header)
 	header.Add("Accept", "application/zip")
 	downloadArtifactURL := fmt.Sprintf("%s/api/v1/IntegrationDesigntimeArtifacts(Id='%s',Version='%s')/$value", config.Host, config.IntegrationFlowID, config.IntegrationFlowVersion)
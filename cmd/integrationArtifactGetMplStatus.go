@@ -60,7 +60,7 @@ func integrationArtifactGetMplStatus(config integrationArtifactGetMplStatusOptio
 }
 
 func runIntegrationArtifactGetMplStatus(config *integrationArtifactGetMplStatusOptions, telemetryData *telemetry.CustomData, httpClient piperhttp.Sender, commonPipelineEnvironment *integrationArtifactGetMplStatusCommonPipelineEnvironment) error {
-	clientOptions := piperhttp.ClientOptions{}
+	clientOptions := piperhttp.ClientOptions{}.WithServicePolicies()
 	httpClient.SetOptions(clientOptions)
 	header := make(http.Header)
 	header.Add("Accept", "application/json")
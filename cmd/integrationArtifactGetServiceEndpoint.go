@@ -61,7 +61,7 @@ func integrationArtifactGetServiceEndpoint(config integrationArtifactGetServiceE
 }
 
 func runIntegrationArtifactGetServiceEndpoint(config *integrationArtifactGetServiceEndpointOptions, telemetryData *telemetry.CustomData, httpClient piperhttp.Sender, commonPipelineEnvironment *integrationArtifactGetServiceEndpointCommonPipelineEnvironment) error {
-	clientOptions := piperhttp.ClientOptions{}
+	clientOptions := piperhttp.ClientOptions{}.WithServicePolicies()
 	header := make(http.Header)
 	header.Add("Accept", "application/json")
 
@@ -62,7 +62,7 @@ func integrationArtifactUpdateConfiguration(config integrationArtifactUpdateConf
 }
 
 func runIntegrationArtifactUpdateConfiguration(config *integrationArtifactUpdateConfigurationOptions, telemetryData *telemetry.CustomData, httpClient piperhttp.Sender) error {
-	clientOptions := piperhttp.ClientOptions{}
+	clientOptions := piperhttp.ClientOptions{}.WithServicePolicies()
 
 	configUpdateURL := fmt.Sprintf("%s/api/v1/IntegrationDesigntimeArtifacts(Id='%s',Version='%s')/$links/Configurations('%s')", config.Host, config.IntegrationFlowID, config.IntegrationFlowVersion, config.ParameterKey)
 	tokenParameters := cpi.TokenParameters{TokenURL: config.OAuthTokenProviderURL, Username: config.Username, Password: config.Password, Client: httpClient}
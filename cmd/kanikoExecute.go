@@ -46,8 +46,7 @@ func runKanikoExecute(config *kanikoExecuteOptions, telemetryData *telemetry.Cus
 	if len(config.ContainerBuildOptions) > 0 {
 		config.BuildOptions = strings.Split(config.ContainerBuildOptions, " ")
 		log.Entry().Warning("Parameter containerBuildOptions is deprecated, please use buildOptions instead.")
-		telemetryData.Custom1Label = "ContainerBuildOptions"
-		telemetryData.Custom1 = config.ContainerBuildOptions
+		telemetryData.ContainerBuildOptions = config.ContainerBuildOptions
 	}
 
 	// prepare kaniko container for running with proper Docker config.json and custom certificates
@@ -6,39 +6,51 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/SAP/jenkins-library/pkg/command"
+	piperhttp "github.com/SAP/jenkins-library/pkg/http"
+	"github.com/SAP/jenkins-library/pkg/kubernetes"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/SAP/jenkins-library/pkg/reporting"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/pkg/errors"
 )
 
-func kubernetesDeploy(config kubernetesDeployOptions, telemetryData *telemetry.CustomData) {
-	c := command.Command{
-		ErrorCategoryMapping: map[string][]string{
-			log.ErrorConfiguration.String(): {
-				"Error: Get * no such host",
-				"Error: path * not found",
-				"Error: rendered manifests contain a resource that already exists.",
-				"Error: unknown flag",
-				"Error: UPGRADE FAILED: * failed to replace object: * is invalid",
-				"Error: UPGRADE FAILED: * failed to create resource: * is invalid",
-				"Error: UPGRADE FAILED: an error occurred * not found",
-				"Error: UPGRADE FAILED: query: failed to query with labels:",
-				"Invalid value: \"\": field is immutable",
-			},
-			log.ErrorCustom.String(): {
-				"Error: release * failed, * timed out waiting for the condition",
-			},
-		},
-	}
+// smokeTestRetryInterval is the time between two attempts of the HTTP smoke test
+var smokeTestRetryInterval = 5 * time.Second
+
+type kubernetesDeployUtils interface {
+	FileRead(path string) ([]byte, error)
+	FileWrite(path string, content []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	SendRequest(method, url string, body io.Reader, header http.Header, cookies []*http.Cookie) (*http.Response, error)
+}
+
+type kubernetesDeployUtilsBundle struct {
+	*piperutils.Files
+	*piperhttp.Client
+}
+
+func kubernetesDeploy(config kubernetesDeployOptions, telemetryData *telemetry.CustomData, influx *kubernetesDeployInflux) {
+	// error categories are detected via the errorCategoryRules of the step metadata
+	c := command.Command{}
 	// reroute stderr output to logging framework, stdout will be used for command interactions
 	c.Stderr(log.Writer())
 
 	// error situations should stop execution through log.Entry().Fatal() call which leads to an os.Exit(1) in the end
-	err := runKubernetesDeploy(config, &c, log.Writer())
+	utils := &kubernetesDeployUtilsBundle{Files: &piperutils.Files{}, Client: &piperhttp.Client{}}
+	err := runKubernetesDeployWithVerification(config, &c, utils, log.Writer(), influx)
+	if exists, _ := utils.FileExists(deploymentVerificationReport); exists {
+		piperutils.PersistReportsAndLinks("kubernetesDeploy", "", []piperutils.Path{{Target: deploymentVerificationReport, Name: "Deployment verification"}}, nil)
+	}
 	if err != nil {
 		log.Entry().WithError(err).Fatal("step execution failed")
 	}
@@ -299,3 +311,335 @@ func splitFullImageName(image string) (imageName, tag string, err error) {
 	}
 	return "", "", fmt.Errorf("Failed to split image name '%v'", image)
 }
+
+// runKubernetesDeployWithVerification executes the deployment and verifies it afterwards in case verifyDeployment is active
+func runKubernetesDeployWithVerification(config kubernetesDeployOptions, command command.ExecRunner, utils kubernetesDeployUtils, stdout io.Writer, influx *kubernetesDeployInflux) error {
+	if !config.VerifyDeployment {
+		return runKubernetesDeploy(config, command, stdout)
+	}
+
+	// the previous configuration is required for restoring it with kubectl in case the verification fails
+	var previousManifest []byte
+	if config.DeployTool == "kubectl" {
+		previousManifest = lastAppliedManifest(config, command)
+	}
+
+	if err := runKubernetesDeploy(config, command, stdout); err != nil {
+		return err
+	}
+	return verifyKubernetesDeployment(config, command, utils, previousManifest, influx)
+}
+
+// deploymentCheck is the result of a single check of the deployment verification
+type deploymentCheck struct {
+	name    string
+	success bool
+	message string
+}
+
+type deploymentVerificationResult struct {
+	checks           []deploymentCheck
+	revision         int
+	previousRevision int
+	rolledBack       bool
+}
+
+func (r *deploymentVerificationResult) addCheck(name string, err error) error {
+	check := deploymentCheck{name: name, success: err == nil, message: "successful"}
+	if err != nil {
+		check.message = err.Error()
+	}
+	r.checks = append(r.checks, check)
+	return err
+}
+
+func verifyKubernetesDeployment(config kubernetesDeployOptions, command command.ExecRunner, utils kubernetesDeployUtils, previousManifest []byte, influx *kubernetesDeployInflux) error {
+	result := &deploymentVerificationResult{}
+	kubeParams := kubectlVerificationParams(config)
+
+	workloads, err := deployedWorkloads(config, command, utils, result)
+	if err != nil {
+		return errors.Wrap(err, "failed to determine the deployed resources")
+	}
+
+	verificationErr := verifyRollout(config, command, kubeParams, workloads, result)
+	if verificationErr == nil {
+		verificationErr = runSmokeTests(config, command, utils, kubeParams, result)
+	}
+	command.Stdout(log.Writer())
+
+	if verificationErr != nil {
+		log.Entry().WithError(verificationErr).Error("Deployment verification failed")
+		if config.RollbackOnFailure {
+			rolledBack, err := rollbackDeployment(config, command, utils, kubeParams, previousManifest, result)
+			if err != nil {
+				log.Entry().WithError(err).Error("Rollback of the deployment failed")
+			}
+			result.rolledBack = rolledBack
+		}
+	}
+
+	influx.deployment_data.fields.verified = verificationErr == nil
+	influx.deployment_data.fields.rolled_back = result.rolledBack
+	influx.deployment_data.fields.revision = result.revision
+	influx.deployment_data.fields.previous_revision = result.previousRevision
+
+	if err := writeDeploymentVerificationReport(config, utils, result, verificationErr == nil); err != nil {
+		log.Entry().WithError(err).Warning("Failed to write deployment verification report")
+	}
+
+	if verificationErr != nil {
+		return errors.Wrap(verificationErr, "deployment verification failed")
+	}
+	log.Entry().Info("Deployment verification successful")
+	return nil
+}
+
+// kubectlVerificationParams returns the global kubectl parameters for accessing the namespace of the deployment
+func kubectlVerificationParams(config kubernetesDeployOptions) []string {
+	kubeParams := []string{
+		"--insecure-skip-tls-verify=true",
+		fmt.Sprintf("--namespace=%v", config.Namespace),
+	}
+	if config.DeployTool == "kubectl" && len(config.KubeConfig) == 0 {
+		kubeParams = append(kubeParams, fmt.Sprintf("--server=%v", config.APIServer))
+		kubeParams = append(kubeParams, fmt.Sprintf("--token=%v", config.KubeToken))
+	} else if len(config.KubeContext) > 0 {
+		kubeParams = append(kubeParams, fmt.Sprintf("--context=%v", config.KubeContext))
+	}
+	return kubeParams
+}
+
+// kubectlArgs returns a new slice containing the global kubectl parameters followed by the command parameters
+func kubectlArgs(kubeParams []string, params ...string) []string {
+	return append(append([]string{}, kubeParams...), params...)
+}
+
+// helmReleaseParams returns the helm parameters for addressing the release of the deployment
+func helmReleaseParams(config kubernetesDeployOptions) []string {
+	helmParams := []string{}
+	if config.DeployTool == "helm3" {
+		helmParams = append(helmParams, "--namespace", config.Namespace)
+	}
+	if len(config.KubeContext) > 0 {
+		helmParams = append(helmParams, "--kube-context", config.KubeContext)
+	}
+	return helmParams
+}
+
+// lastAppliedManifest returns the configuration of the resources of the app template as applied by the previous deployment.
+// Nothing is returned if the resources have not been deployed before.
+func lastAppliedManifest(config kubernetesDeployOptions, command command.ExecRunner) []byte {
+	if len(config.KubeConfig) > 0 {
+		command.SetEnv([]string{fmt.Sprintf("KUBECONFIG=%v", config.KubeConfig)})
+	}
+	var manifest bytes.Buffer
+	command.Stdout(&manifest)
+	defer command.Stdout(log.Writer())
+
+	kubeParams := kubectlArgs(kubectlVerificationParams(config), "apply", "view-last-applied", "--filename", config.AppTemplate, "--output", "yaml")
+	if err := command.RunExecutable("kubectl", kubeParams...); err != nil {
+		log.Entry().WithError(err).Info("No previously applied configuration found, rollback will not be possible")
+		return nil
+	}
+	return manifest.Bytes()
+}
+
+// deployedWorkloads returns the Deployments and StatefulSets of the deployment, for helm also the revisions of the release are determined
+func deployedWorkloads(config kubernetesDeployOptions, command command.ExecRunner, utils kubernetesDeployUtils, result *deploymentVerificationResult) ([]kubernetes.Workload, error) {
+	if config.DeployTool == "kubectl" {
+		manifest, err := utils.FileRead(config.AppTemplate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read appTemplate '%v'", config.AppTemplate)
+		}
+		return kubernetes.WorkloadsFromManifest(manifest)
+	}
+
+	var history bytes.Buffer
+	command.Stdout(&history)
+	historyParams := append([]string{"history", config.DeploymentName, "--max", "10", "--output", "json"}, helmReleaseParams(config)...)
+	if err := command.RunExecutable("helm", historyParams...); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve helm history")
+	}
+	revision, previousRevision, err := kubernetes.HelmRevisions(history.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	result.revision, result.previousRevision = revision, previousRevision
+	log.Entry().Infof("Verifying revision %v of release %v", revision, config.DeploymentName)
+
+	var manifest bytes.Buffer
+	command.Stdout(&manifest)
+	manifestParams := append([]string{"get", "manifest", config.DeploymentName}, helmReleaseParams(config)...)
+	if err := command.RunExecutable("helm", manifestParams...); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve helm manifest")
+	}
+	return kubernetes.WorkloadsFromManifest(manifest.Bytes())
+}
+
+func verifyRollout(config kubernetesDeployOptions, command command.ExecRunner, kubeParams []string, workloads []kubernetes.Workload, result *deploymentVerificationResult) error {
+	command.Stdout(log.Writer())
+	for _, workload := range workloads {
+		log.Entry().Infof("Waiting for rollout of %v", workload)
+		rolloutParams := kubectlArgs(kubeParams, "rollout", "status", workload.String(), fmt.Sprintf("--timeout=%vs", config.VerificationTimeout))
+		err := command.RunExecutable("kubectl", rolloutParams...)
+		if err != nil {
+			err = errors.Wrapf(err, "rollout of %v did not finish successfully", workload)
+		}
+		if result.addCheck("rollout "+workload.String(), err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runSmokeTests(config kubernetesDeployOptions, command command.ExecRunner, utils kubernetesDeployUtils, kubeParams []string, result *deploymentVerificationResult) error {
+	if len(config.SmokeTestJob) > 0 {
+		if err := result.addCheck("smoke test job", runSmokeTestJob(config, command, kubeParams)); err != nil {
+			return err
+		}
+	}
+	if len(config.SmokeTestURL) > 0 {
+		if err := result.addCheck("smoke test "+config.SmokeTestURL, checkSmokeTestURL(config, utils)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runSmokeTestJob(config kubernetesDeployOptions, command command.ExecRunner, kubeParams []string) error {
+	// jobs are immutable, a job of a previous run needs to be removed first
+	deleteParams := kubectlArgs(kubeParams, "delete", "--filename", config.SmokeTestJob, "--ignore-not-found")
+	if err := command.RunExecutable("kubectl", deleteParams...); err != nil {
+		return errors.Wrap(err, "failed to delete previous smoke test job")
+	}
+
+	var jobName bytes.Buffer
+	command.Stdout(&jobName)
+	applyParams := kubectlArgs(kubeParams, "apply", "--filename", config.SmokeTestJob, "--output", "name")
+	if err := command.RunExecutable("kubectl", applyParams...); err != nil {
+		return errors.Wrap(err, "failed to create smoke test job")
+	}
+	command.Stdout(log.Writer())
+	name := strings.TrimSpace(strings.Split(jobName.String(), "\n")[0])
+	if len(name) == 0 {
+		return fmt.Errorf("smoke test job manifest '%v' does not contain a job", config.SmokeTestJob)
+	}
+
+	log.Entry().Infof("Waiting for smoke test %v", name)
+	waitParams := kubectlArgs(kubeParams, "wait", "--for=condition=complete", name, fmt.Sprintf("--timeout=%vs", config.VerificationTimeout))
+	if err := command.RunExecutable("kubectl", waitParams...); err != nil {
+		return errors.Wrapf(err, "smoke test %v did not complete successfully", name)
+	}
+	return nil
+}
+
+func checkSmokeTestURL(config kubernetesDeployOptions, utils kubernetesDeployUtils) error {
+	deadline := time.Now().Add(time.Duration(config.VerificationTimeout) * time.Second)
+	for {
+		response, err := utils.SendRequest(http.MethodGet, config.SmokeTestURL, nil, nil, nil)
+		if response != nil && response.Body != nil {
+			response.Body.Close()
+		}
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(smokeTestRetryInterval).After(deadline) {
+			return errors.Wrapf(err, "smoke test %v failed", config.SmokeTestURL)
+		}
+		log.Entry().WithError(err).Infof("Smoke test %v not yet successful, retrying in %v", config.SmokeTestURL, smokeTestRetryInterval)
+		time.Sleep(smokeTestRetryInterval)
+	}
+}
+
+// rollbackDeployment restores the state before the deployment, it returns whether a rollback has been performed
+func rollbackDeployment(config kubernetesDeployOptions, command command.ExecRunner, utils kubernetesDeployUtils, kubeParams []string, previousManifest []byte, result *deploymentVerificationResult) (bool, error) {
+	command.Stdout(log.Writer())
+	if config.DeployTool == "kubectl" {
+		if len(previousManifest) == 0 {
+			log.Entry().Warning("No previous configuration available, skipping rollback")
+			return false, nil
+		}
+		previousTemplate := config.AppTemplate + ".previous"
+		if err := utils.FileWrite(previousTemplate, previousManifest, 0700); err != nil {
+			return false, errors.Wrap(err, "failed to write previous configuration")
+		}
+		log.Entry().Info("Restoring previous configuration ...")
+		applyParams := kubectlArgs(kubeParams, "apply", "--filename", previousTemplate)
+		if err := command.RunExecutable("kubectl", applyParams...); err != nil {
+			return false, errors.Wrap(err, "failed to apply previous configuration")
+		}
+		return true, nil
+	}
+
+	if result.previousRevision == 0 {
+		log.Entry().Warningf("Release %v has no previous revision, skipping rollback", config.DeploymentName)
+		return false, nil
+	}
+	rollbackParams := []string{"rollback", config.DeploymentName, strconv.Itoa(result.previousRevision), "--wait", "--timeout"}
+	if config.DeployTool == "helm3" {
+		rollbackParams = append(rollbackParams, fmt.Sprintf("%vs", config.HelmDeployWaitSeconds))
+	} else {
+		rollbackParams = append(rollbackParams, strconv.Itoa(config.HelmDeployWaitSeconds))
+	}
+	rollbackParams = append(rollbackParams, helmReleaseParams(config)...)
+	log.Entry().Infof("Rolling back release %v to revision %v ...", config.DeploymentName, result.previousRevision)
+	if err := command.RunExecutable("helm", rollbackParams...); err != nil {
+		return false, errors.Wrap(err, "helm rollback failed")
+	}
+	return true, nil
+}
+
+// deploymentVerificationReport is not written into reporting.StepReportDirectory
+// since pipelineCreateScanSummary would consider it to be the result of a security scan
+const deploymentVerificationReport = ".pipeline/deploymentReports/kubernetesDeploy_verification.json"
+
+func writeDeploymentVerificationReport(config kubernetesDeployOptions, utils kubernetesDeployUtils, result *deploymentVerificationResult, verified bool) error {
+	outcome := "successful"
+	style := reporting.ColumnStyle(reporting.Green)
+	if !verified {
+		outcome, style = "failed", reporting.Red
+	}
+	report := reporting.ScanReport{
+		StepName: "kubernetesDeploy",
+		Title:    "Kubernetes Deployment Verification",
+		Subheaders: []reporting.Subheader{
+			{Description: "Deploy tool", Details: config.DeployTool},
+			{Description: "Namespace", Details: config.Namespace},
+		},
+		Overview: []reporting.OverviewRow{
+			{Description: "Verification", Details: outcome, Style: style},
+			{Description: "Rolled back", Details: fmt.Sprint(result.rolledBack)},
+		},
+		DetailTable: reporting.ScanDetailTable{
+			Headers:       []string{"Check", "Result"},
+			NoRowsMessage: "No checks executed",
+		},
+		ReportTime:     time.Now(),
+		SuccessfulScan: verified,
+	}
+	if config.DeployTool != "kubectl" {
+		report.AddSubHeader("Release", config.DeploymentName)
+		report.Overview = append(report.Overview,
+			reporting.OverviewRow{Description: "Revision", Details: fmt.Sprint(result.revision)},
+			reporting.OverviewRow{Description: "Previous revision", Details: fmt.Sprint(result.previousRevision)},
+		)
+	}
+	for _, check := range result.checks {
+		row := reporting.ScanRow{}
+		row.AddColumn(check.name, 0)
+		if check.success {
+			row.AddColumn(check.message, reporting.Green)
+		} else {
+			row.AddColumn(check.message, reporting.Red)
+		}
+		report.DetailTable.Rows = append(report.DetailTable.Rows, row)
+	}
+
+	// ignore JSON errors since structure is in our hands
+	jsonReport, _ := report.ToJSON()
+	if err := utils.MkdirAll(filepath.Dir(deploymentVerificationReport), 0777); err != nil {
+		return errors.Wrap(err, "failed to create reporting directory")
+	}
+	return utils.FileWrite(deploymentVerificationReport, jsonReport, 0666)
+}
@@ -5,11 +5,16 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperenv"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -19,23 +24,67 @@ type kubernetesDeployOptions struct {
 	AppTemplate                string   `json:"appTemplate,omitempty"`
 	ChartPath                  string   `json:"chartPath,omitempty"`
 	ContainerRegistryPassword  string   `json:"containerRegistryPassword,omitempty"`
-	ContainerRegistryURL       string   `json:"containerRegistryUrl,omitempty"`
+	ContainerRegistryURL       string   `json:"containerRegistryUrl,omitempty" validate:"required"`
 	ContainerRegistryUser      string   `json:"containerRegistryUser,omitempty"`
 	ContainerRegistrySecret    string   `json:"containerRegistrySecret,omitempty"`
 	CreateDockerRegistrySecret bool     `json:"createDockerRegistrySecret,omitempty"`
 	DeploymentName             string   `json:"deploymentName,omitempty"`
-	DeployTool                 string   `json:"deployTool,omitempty"`
+	DeployTool                 string   `json:"deployTool,omitempty" validate:"required,possible-values=kubectl helm helm3"`
 	ForceUpdates               bool     `json:"forceUpdates,omitempty"`
 	HelmDeployWaitSeconds      int      `json:"helmDeployWaitSeconds,omitempty"`
 	HelmValues                 []string `json:"helmValues,omitempty"`
-	Image                      string   `json:"image,omitempty"`
+	Image                      string   `json:"image,omitempty" validate:"required"`
 	IngressHosts               []string `json:"ingressHosts,omitempty"`
 	KeepFailedDeployments      bool     `json:"keepFailedDeployments,omitempty"`
 	KubeConfig                 string   `json:"kubeConfig,omitempty"`
 	KubeContext                string   `json:"kubeContext,omitempty"`
 	KubeToken                  string   `json:"kubeToken,omitempty"`
 	Namespace                  string   `json:"namespace,omitempty"`
+	RollbackOnFailure          bool     `json:"rollbackOnFailure,omitempty"`
+	SmokeTestJob               string   `json:"smokeTestJob,omitempty"`
+	SmokeTestURL               string   `json:"smokeTestUrl,omitempty"`
 	TillerNamespace            string   `json:"tillerNamespace,omitempty"`
+	VerificationTimeout        int      `json:"verificationTimeout,omitempty"`
+	VerifyDeployment           bool     `json:"verifyDeployment,omitempty"`
+}
+
+type kubernetesDeployInflux struct {
+	deployment_data struct {
+		fields struct {
+			verified          bool
+			rolled_back       bool
+			revision          int
+			previous_revision int
+		}
+		tags struct {
+		}
+	}
+}
+
+func (i *kubernetesDeployInflux) persist(path, resourceName string) {
+	measurementContent := []struct {
+		measurement string
+		valType     string
+		name        string
+		value       interface{}
+	}{
+		{valType: config.InfluxField, measurement: "deployment_data", name: "verified", value: i.deployment_data.fields.verified},
+		{valType: config.InfluxField, measurement: "deployment_data", name: "rolled_back", value: i.deployment_data.fields.rolled_back},
+		{valType: config.InfluxField, measurement: "deployment_data", name: "revision", value: i.deployment_data.fields.revision},
+		{valType: config.InfluxField, measurement: "deployment_data", name: "previous_revision", value: i.deployment_data.fields.previous_revision},
+	}
+
+	errCount := 0
+	for _, metric := range measurementContent {
+		err := piperenv.SetResourceParameter(path, resourceName, filepath.Join(metric.measurement, fmt.Sprintf("%vs", metric.valType), metric.name), metric.value)
+		if err != nil {
+			log.Entry().WithError(err).Error("Error persisting influx environment.")
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		log.Entry().Fatal("failed to persist Influx environment")
+	}
 }
 
 // KubernetesDeployCommand Deployment to Kubernetes test or production namespace within the specified Kubernetes cluster.
@@ -45,6 +94,7 @@ func KubernetesDeployCommand() *cobra.Command {
 	metadata := kubernetesDeployMetadata()
 	var stepConfig kubernetesDeployOptions
 	var startTime time.Time
+	var influx kubernetesDeployInflux
 
 	var createKubernetesDeployCmd = &cobra.Command{
 		Use:   STEP_NAME,
@@ -66,7 +116,18 @@ helm upgrade <deploymentName> <chartPath> --install --force --namespace <namespa
 
 * ` + "`" + `yourRegistry` + "`" + ` will be retrieved from ` + "`" + `containerRegistryUrl` + "`" + `
 * ` + "`" + `yourImageName` + "`" + `, ` + "`" + `yourImageTag` + "`" + ` will be retrieved from ` + "`" + `image` + "`" + `
-* ` + "`" + `dockerSecret` + "`" + ` will be calculated with a call to ` + "`" + `kubectl create secret docker-registry regsecret --docker-server=<yourRegistry> --docker-username=<containerRegistryUser> --docker-password=<containerRegistryPassword> --dry-run=true --output=json'` + "`" + ``,
+* ` + "`" + `dockerSecret` + "`" + ` will be calculated with a call to ` + "`" + `kubectl create secret docker-registry regsecret --docker-server=<yourRegistry> --docker-username=<containerRegistryUser> --docker-password=<containerRegistryPassword> --dry-run=true --output=json'` + "`" + `
+
+## Deployment verification
+With ` + "`" + `verifyDeployment: true` + "`" + ` the rollout status of all Deployments and StatefulSets of the deployment is watched via ` + "`" + `kubectl rollout status` + "`" + `.
+Afterwards an optional smoke test is executed, either as Kubernetes Job (` + "`" + `smokeTestJob` + "`" + `) or as HTTP check (` + "`" + `smokeTestUrl` + "`" + `).
+
+In case the verification fails and ` + "`" + `rollbackOnFailure` + "`" + ` is active, the previous state is restored:
+
+* for helm via ` + "`" + `helm rollback` + "`" + ` to the previous successfully deployed revision
+* for kubectl by applying the previously applied configuration of the resources as retrieved via ` + "`" + `kubectl apply view-last-applied` + "`" + ` before the deployment
+
+The outcome of the verification is provided to the influx measurement ` + "`" + `deployment_data` + "`" + ` as well as in a step report.`,
 		PreRunE: func(cmd *cobra.Command, _ []string) error {
 			startTime = time.Now()
 			log.SetStepName(STEP_NAME)
@@ -75,10 +136,14 @@ helm upgrade <deploymentName> <chartPath> --install --force --namespace <namespa
 			path, _ := os.Getwd()
 			fatalHook := &log.FatalHook{CorrelationID: GeneralConfig.CorrelationID, Path: path}
 			log.RegisterHook(fatalHook)
+			log.SetErrorCategoryRules(metadata.Spec.ErrorCategoryRules)
 
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.ContainerRegistryPassword)
@@ -86,6 +151,14 @@ helm upgrade <deploymentName> <chartPath> --install --force --namespace <namespa
 			log.RegisterSecret(stepConfig.KubeConfig)
 			log.RegisterSecret(stepConfig.KubeToken)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -98,14 +171,21 @@ helm upgrade <deploymentName> <chartPath> --install --force --namespace <namespa
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
+				influx.persist(GeneralConfig.EnvRootPath, "influx")
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
 			telemetry.Initialize(GeneralConfig.NoTelemetry, STEP_NAME)
-			kubernetesDeploy(stepConfig, &telemetryData)
+			kubernetesDeploy(stepConfig, &telemetryData, &influx)
 			telemetryData.ErrorCode = "0"
 			log.Entry().Info("SUCCESS")
 		},
@@ -137,7 +217,12 @@ func addKubernetesDeployFlags(cmd *cobra.Command, stepConfig *kubernetesDeployOp
 	cmd.Flags().StringVar(&stepConfig.KubeContext, "kubeContext", os.Getenv("PIPER_kubeContext"), "Defines the context to use from the \"kubeconfig\" file.")
 	cmd.Flags().StringVar(&stepConfig.KubeToken, "kubeToken", os.Getenv("PIPER_kubeToken"), "Contains the id_token used by kubectl for authentication. Consider using kubeConfig parameter instead.")
 	cmd.Flags().StringVar(&stepConfig.Namespace, "namespace", `default`, "Defines the target Kubernetes namespace for the deployment.")
+	cmd.Flags().BoolVar(&stepConfig.RollbackOnFailure, "rollbackOnFailure", true, "Only for `verifyDeployment: true`: Restores the previous state of the deployment in case the verification fails.")
+	cmd.Flags().StringVar(&stepConfig.SmokeTestJob, "smokeTestJob", os.Getenv("PIPER_smokeTestJob"), "Only for `verifyDeployment: true`: Path to the manifest of a Kubernetes Job which is executed as smoke test after the rollout finished. The verification fails in case the job does not complete successfully.")
+	cmd.Flags().StringVar(&stepConfig.SmokeTestURL, "smokeTestUrl", os.Getenv("PIPER_smokeTestUrl"), "Only for `verifyDeployment: true`: URL which is checked after the rollout finished. The verification fails in case the URL does not respond with a successful status code within `verificationTimeout`.")
 	cmd.Flags().StringVar(&stepConfig.TillerNamespace, "tillerNamespace", os.Getenv("PIPER_tillerNamespace"), "Defines optional tiller namespace for deployments using helm.")
+	cmd.Flags().IntVar(&stepConfig.VerificationTimeout, "verificationTimeout", 300, "Only for `verifyDeployment: true`: Number of seconds to wait for the rollout of each resource and for the smoke test.")
+	cmd.Flags().BoolVar(&stepConfig.VerifyDeployment, "verifyDeployment", false, "Verifies the deployment after it has been applied by watching the rollout status and optionally executing a smoke test.")
 
 	cmd.MarkFlagRequired("containerRegistryUrl")
 	cmd.MarkFlagRequired("deployTool")
@@ -256,12 +341,13 @@ func kubernetesDeployMetadata() config.StepData {
 						Aliases:     []config.Alias{{Name: "helmDeploymentName"}},
 					},
 					{
-						Name:        "deployTool",
-						ResourceRef: []config.ResourceReference{},
-						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
-						Type:        "string",
-						Mandatory:   true,
-						Aliases:     []config.Alias{},
+						Name:           "deployTool",
+						ResourceRef:    []config.ResourceReference{},
+						Scope:          []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:           "string",
+						Mandatory:      true,
+						Aliases:        []config.Alias{},
+						PossibleValues: []interface{}{"kubectl", "helm", "helm3"},
 					},
 					{
 						Name:        "forceUpdates",
@@ -364,6 +450,30 @@ func kubernetesDeployMetadata() config.StepData {
 						Mandatory:   false,
 						Aliases:     []config.Alias{{Name: "helmDeploymentNamespace"}, {Name: "k8sDeploymentNamespace"}},
 					},
+					{
+						Name:        "rollbackOnFailure",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "bool",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "smokeTestJob",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "smokeTestUrl",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
 					{
 						Name:        "tillerNamespace",
 						ResourceRef: []config.ResourceReference{},
@@ -372,6 +482,22 @@ func kubernetesDeployMetadata() config.StepData {
 						Mandatory:   false,
 						Aliases:     []config.Alias{{Name: "helmTillerNamespace"}},
 					},
+					{
+						Name:        "verificationTimeout",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "int",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "verifyDeployment",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "bool",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
 				},
 			},
 			Containers: []config.Container{
@@ -379,6 +505,28 @@ func kubernetesDeployMetadata() config.StepData {
 				{Image: "dtzar/helm-kubectl:2.12.1", WorkingDir: "/config", Options: []config.Option{{Name: "-u", Value: "0"}}, Conditions: []config.Condition{{ConditionRef: "strings-equal", Params: []config.Param{{Name: "deployTool", Value: "helm"}}}}},
 				{Image: "dtzar/helm-kubectl:2.12.1", WorkingDir: "/config", Options: []config.Option{{Name: "-u", Value: "0"}}, Conditions: []config.Condition{{ConditionRef: "strings-equal", Params: []config.Param{{Name: "deployTool", Value: "kubectl"}}}}},
 			},
+			ErrorCategoryRules: []log.ErrorCategoryRule{
+				{Category: "config", Pattern: "Error: Get .* no such host", Remediation: "Check the parameter apiServer and the kubeConfig."},
+				{Category: "config", Pattern: "Error: path .* not found", Remediation: "Check the parameter chartPath."},
+				{Category: "config", Pattern: "Error: rendered manifests contain a resource that already exists\\.", Remediation: "Remove the existing resource from the namespace or deploy it via the same Helm release."},
+				{Category: "config", Pattern: "Error: unknown flag", Remediation: "Check the parameter additionalParameters."},
+				{Category: "config", Pattern: "Error: UPGRADE FAILED: .*failed to (replace object|create resource): .* is invalid"},
+				{Category: "config", Pattern: "Error: UPGRADE FAILED: an error occurred .* not found"},
+				{Category: "config", Pattern: "Error: UPGRADE FAILED: query: failed to query with labels:"},
+				{Category: "config", Pattern: "Invalid value: \"\": field is immutable", Remediation: "Immutable fields cannot be changed by an upgrade, the resource needs to be deleted before."},
+				{Category: "custom", Pattern: "Error: release .* failed, .* timed out waiting for the condition", Remediation: "Check the events and the logs of the pods, increase helmDeployWaitSeconds in case the deployment needs more time."},
+			},
+			Outputs: config.StepOutputs{
+				Resources: []config.StepResources{
+					{
+						Name: "influx",
+						Type: "influx",
+						Parameters: []map[string]interface{}{
+							{"Name": "deployment_data"}, {"fields": []map[string]string{{"name": "verified"}, {"name": "rolled_back"}, {"name": "revision"}, {"name": "previous_revision"}}},
+						},
+					},
+				},
+			},
 		},
 	}
 	return theMetaData
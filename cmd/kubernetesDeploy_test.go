@@ -3,9 +3,12 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/SAP/jenkins-library/pkg/mock"
@@ -537,6 +540,175 @@ spec:
 	})
 }
 
+type kubernetesDeployMockUtils struct {
+	*mock.FilesMock
+	requestedURLs []string
+	statusCode    int
+}
+
+func (k *kubernetesDeployMockUtils) SendRequest(method, url string, body io.Reader, header http.Header, cookies []*http.Cookie) (*http.Response, error) {
+	k.requestedURLs = append(k.requestedURLs, url)
+	response := &http.Response{StatusCode: k.statusCode, Body: ioutil.NopCloser(strings.NewReader(""))}
+	if k.statusCode >= 300 {
+		return response, fmt.Errorf("request to %v returned with response %v", url, k.statusCode)
+	}
+	return response, nil
+}
+
+func TestRunKubernetesDeployWithVerification(t *testing.T) {
+	helmManifest := `---
+kind: Service
+metadata:
+  name: myService
+---
+kind: Deployment
+metadata:
+  name: myApp
+`
+	helmOptions := kubernetesDeployOptions{
+		ContainerRegistryURL:  "https://my.registry:55555",
+		ChartPath:             "path/to/chart",
+		DeploymentName:        "deploymentName",
+		DeployTool:            "helm3",
+		HelmDeployWaitSeconds: 400,
+		Image:                 "path/to/Image:latest",
+		Namespace:             "deploymentNamespace",
+		VerifyDeployment:      true,
+		VerificationTimeout:   60,
+		RollbackOnFailure:     true,
+	}
+
+	t.Run("helm - successful verification", func(t *testing.T) {
+		e := mock.ExecMockRunner{
+			StdoutReturn: map[string]string{
+				"helm history .*":      `[{"revision":1,"status":"superseded"},{"revision":2,"status":"deployed"}]`,
+				"helm get manifest .*": helmManifest,
+			},
+		}
+		utils := &kubernetesDeployMockUtils{FilesMock: &mock.FilesMock{}}
+		influx := kubernetesDeployInflux{}
+		var stdout bytes.Buffer
+
+		err := runKubernetesDeployWithVerification(helmOptions, &e, utils, &stdout, &influx)
+		assert.NoError(t, err)
+
+		if assert.Len(t, e.Calls, 4) {
+			assert.Equal(t, []string{"history", "deploymentName", "--max", "10", "--output", "json", "--namespace", "deploymentNamespace"}, e.Calls[1].Params)
+			assert.Equal(t, []string{"get", "manifest", "deploymentName", "--namespace", "deploymentNamespace"}, e.Calls[2].Params)
+			assert.Equal(t, "kubectl", e.Calls[3].Exec)
+			assert.Equal(t, []string{"--insecure-skip-tls-verify=true", "--namespace=deploymentNamespace", "rollout", "status", "deployment/myApp", "--timeout=60s"}, e.Calls[3].Params)
+		}
+		assert.True(t, influx.deployment_data.fields.verified)
+		assert.False(t, influx.deployment_data.fields.rolled_back)
+		assert.Equal(t, 2, influx.deployment_data.fields.revision)
+		assert.Equal(t, 1, influx.deployment_data.fields.previous_revision)
+
+		reportFile := deploymentVerificationReport
+		assert.True(t, utils.HasWrittenFile(reportFile))
+		report, _ := utils.FileRead(reportFile)
+		assert.Contains(t, string(report), `"successfulScan":true`)
+		assert.Contains(t, string(report), "rollout deployment/myApp")
+	})
+
+	t.Run("helm - rollback after failed rollout", func(t *testing.T) {
+		e := mock.ExecMockRunner{
+			StdoutReturn: map[string]string{
+				"helm history .*":      `[{"revision":1,"status":"superseded"},{"revision":2,"status":"deployed"}]`,
+				"helm get manifest .*": helmManifest,
+			},
+			ShouldFailOnCommand: map[string]error{
+				"kubectl .* rollout status .*": fmt.Errorf("timed out"),
+			},
+		}
+		utils := &kubernetesDeployMockUtils{FilesMock: &mock.FilesMock{}}
+		influx := kubernetesDeployInflux{}
+		var stdout bytes.Buffer
+
+		err := runKubernetesDeployWithVerification(helmOptions, &e, utils, &stdout, &influx)
+		assert.EqualError(t, err, "deployment verification failed: rollout of deployment/myApp did not finish successfully: timed out")
+
+		if assert.Len(t, e.Calls, 5) {
+			assert.Equal(t, "helm", e.Calls[4].Exec)
+			assert.Equal(t, []string{"rollback", "deploymentName", "1", "--wait", "--timeout", "400s", "--namespace", "deploymentNamespace"}, e.Calls[4].Params)
+		}
+		assert.False(t, influx.deployment_data.fields.verified)
+		assert.True(t, influx.deployment_data.fields.rolled_back)
+		report, _ := utils.FileRead(deploymentVerificationReport)
+		assert.Contains(t, string(report), `"successfulScan":false`)
+	})
+
+	t.Run("helm - no rollback for first revision", func(t *testing.T) {
+		e := mock.ExecMockRunner{
+			StdoutReturn: map[string]string{
+				"helm history .*":      `[{"revision":1,"status":"deployed"}]`,
+				"helm get manifest .*": helmManifest,
+			},
+			ShouldFailOnCommand: map[string]error{
+				"kubectl .* rollout status .*": fmt.Errorf("timed out"),
+			},
+		}
+		influx := kubernetesDeployInflux{}
+		var stdout bytes.Buffer
+
+		err := runKubernetesDeployWithVerification(helmOptions, &e, &kubernetesDeployMockUtils{FilesMock: &mock.FilesMock{}}, &stdout, &influx)
+		assert.Error(t, err)
+		assert.Len(t, e.Calls, 4)
+		assert.False(t, influx.deployment_data.fields.rolled_back)
+	})
+
+	t.Run("kubectl - smoke tests and rollback", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "")
+		defer os.RemoveAll(dir) // clean up
+		assert.NoError(t, err, "Error when creating temp dir")
+
+		kubeYaml := "kind: Deployment\nmetadata:\n  name: myApp\nspec:\n  spec:\n    image: <image-name>\n"
+		previousYaml := "kind: Deployment\nmetadata:\n  name: myApp\nspec:\n  spec:\n    image: my.registry:55555/path/to/Image:1.0\n"
+		opts := kubernetesDeployOptions{
+			AppTemplate:          filepath.Join(dir, "test.yaml"),
+			ContainerRegistryURL: "https://my.registry:55555",
+			DeployTool:           "kubectl",
+			Image:                "path/to/Image:latest",
+			APIServer:            "https://my.api.server",
+			KubeToken:            "testToken",
+			Namespace:            "deploymentNamespace",
+			VerifyDeployment:     true,
+			VerificationTimeout:  0,
+			RollbackOnFailure:    true,
+			SmokeTestJob:         "smokeTest.yaml",
+			SmokeTestURL:         "https://my.app/health",
+		}
+		ioutil.WriteFile(opts.AppTemplate, []byte(kubeYaml), 0755)
+
+		e := mock.ExecMockRunner{
+			StdoutReturn: map[string]string{
+				"kubectl .* apply view-last-applied .*":                    previousYaml,
+				"kubectl .* apply --filename smokeTest.yaml --output name": "job.batch/smoke-test\n",
+			},
+		}
+		utils := &kubernetesDeployMockUtils{FilesMock: &mock.FilesMock{}, statusCode: 503}
+		utils.AddFile(opts.AppTemplate, []byte(kubeYaml))
+		influx := kubernetesDeployInflux{}
+		var stdout bytes.Buffer
+
+		err = runKubernetesDeployWithVerification(opts, &e, utils, &stdout, &influx)
+		assert.EqualError(t, err, "deployment verification failed: smoke test https://my.app/health failed: request to https://my.app/health returned with response 503")
+
+		kubeParams := []string{"--insecure-skip-tls-verify=true", "--namespace=deploymentNamespace", "--server=https://my.api.server", "--token=testToken"}
+		if assert.Len(t, e.Calls, 7) {
+			assert.Equal(t, append(kubeParams, "apply", "view-last-applied", "--filename", opts.AppTemplate, "--output", "yaml"), e.Calls[0].Params)
+			assert.Equal(t, append(kubeParams, "rollout", "status", "deployment/myApp", "--timeout=0s"), e.Calls[2].Params)
+			assert.Equal(t, append(kubeParams, "delete", "--filename", "smokeTest.yaml", "--ignore-not-found"), e.Calls[3].Params)
+			assert.Equal(t, append(kubeParams, "wait", "--for=condition=complete", "job.batch/smoke-test", "--timeout=0s"), e.Calls[5].Params)
+			assert.Equal(t, append(kubeParams, "apply", "--filename", opts.AppTemplate+".previous"), e.Calls[6].Params)
+		}
+		assert.Equal(t, []string{"https://my.app/health"}, utils.requestedURLs)
+		previous, _ := utils.FileRead(opts.AppTemplate + ".previous")
+		assert.Equal(t, previousYaml, string(previous))
+		assert.False(t, influx.deployment_data.fields.verified)
+		assert.True(t, influx.deployment_data.fields.rolled_back)
+	})
+}
+
 func TestSplitRegistryURL(t *testing.T) {
 	tt := []struct {
 		in          string
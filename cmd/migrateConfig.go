@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type migrateConfigCommandOptions struct {
+	dryRun bool
+	diff   bool
+}
+
+var migrateConfigOptions migrateConfigCommandOptions
+
+type migrateConfigUtils interface {
+	FileExists(filename string) (bool, error)
+	FileRead(path string) ([]byte, error)
+	FileWrite(path string, content []byte, perm os.FileMode) error
+}
+
+// MigrateConfigCommand is the entry command for migrating the project configuration
+func MigrateConfigCommand() *cobra.Command {
+	var createMigrateConfigCmd = &cobra.Command{
+		Use:   "migrateConfig",
+		Short: "Rewrites deprecated parameters and renamed steps of the project 'Piper' configuration.",
+		Long: `Rewrites the project configuration, e.g. .pipeline/config.yml, in place based on the metadata of all steps:
+
+* renamed steps are replaced by their current name,
+* deprecated parameter aliases are replaced by the parameter name,
+* parameters within sections which do not support them are moved to a supported section.
+
+Comments and the order of the entries are kept. Changes which cannot be done automatically are reported.
+With --dryRun the file is not modified.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			path, _ := os.Getwd()
+			fatalHook := &log.FatalHook{CorrelationID: GeneralConfig.CorrelationID, Path: path}
+			log.RegisterHook(fatalHook)
+		},
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := runMigrateConfig(migrateConfigOptions, GetAllStepMetadata(), &piperutils.Files{}, os.Stdout); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				log.Entry().WithError(err).Fatal("configuration migration failed")
+			}
+		},
+	}
+
+	createMigrateConfigCmd.Flags().BoolVar(&migrateConfigOptions.dryRun, "dryRun", false, "Reports the changes without modifying the configuration file")
+	createMigrateConfigCmd.Flags().BoolVar(&migrateConfigOptions.diff, "diff", false, "Prints the difference between the current and the migrated configuration")
+	return createMigrateConfigCmd
+}
+
+func runMigrateConfig(options migrateConfigCommandOptions, metadata map[string]config.StepData, utils migrateConfigUtils, out io.Writer) error {
+	projectConfigFile := getProjectConfigFile(GeneralConfig.CustomConfig)
+	if exists, _ := utils.FileExists(projectConfigFile); !exists {
+		return fmt.Errorf("configuration file '%v' does not exist", projectConfigFile)
+	}
+	content, err := utils.FileRead(projectConfigFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %v", projectConfigFile)
+	}
+
+	migrated, changes, err := config.NewMigration(metadata).Migrate(content)
+	if err != nil {
+		return errors.Wrapf(err, "failed to migrate %v", projectConfigFile)
+	}
+	if len(changes) == 0 {
+		log.Entry().Infof("Configuration %v is up to date", projectConfigFile)
+		return nil
+	}
+
+	for _, change := range changes {
+		fmt.Fprintf(out, "%v:%v\n", projectConfigFile, change.String())
+	}
+	if bytes.Equal(migrated, content) {
+		log.Entry().Infof("Configuration %v requires %v manual changes", projectConfigFile, len(changes))
+		return nil
+	}
+	if options.diff || options.dryRun {
+		fmt.Fprint(out, lineDiff(projectConfigFile, string(content), string(migrated)))
+	}
+	if options.dryRun {
+		log.Entry().Infof("Dry run: %v changes for %v", len(changes), projectConfigFile)
+		return nil
+	}
+	if err := utils.FileWrite(projectConfigFile, migrated, 0666); err != nil {
+		return errors.Wrapf(err, "failed to write %v", projectConfigFile)
+	}
+	log.Entry().Infof("Migrated %v with %v changes", projectConfigFile, len(changes))
+	return nil
+}
+
+// lineDiff returns the changed lines in unified diff format with two lines of context
+func lineDiff(name, from, to string) string {
+	const context = 2
+	a := strings.Split(strings.TrimSuffix(from, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(to, "\n"), "\n")
+
+	// longest common subsequence of the lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		prefix string
+		text   string
+	}
+	lines := []line{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{" ", a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{"-", a[i]})
+			i++
+		default:
+			lines = append(lines, line{"+", b[j]})
+			j++
+		}
+	}
+
+	diff := strings.Builder{}
+	fmt.Fprintf(&diff, "--- %v\n+++ %v (migrated)\n", name, name)
+	lastPrinted := -1
+	for index, l := range lines {
+		if l.prefix == " " {
+			continue
+		}
+		start := index - context
+		if start < 0 {
+			start = 0
+		}
+		if start <= lastPrinted {
+			start = lastPrinted + 1
+		} else {
+			diff.WriteString("@@\n")
+		}
+		for k := start; k <= index; k++ {
+			fmt.Fprintf(&diff, "%v%v\n", lines[k].prefix, lines[k].text)
+		}
+		lastPrinted = index
+		// trailing context
+		for k := index + 1; k < len(lines) && k <= index+context && lines[k].prefix == " "; k++ {
+			if k > lastPrinted {
+				fmt.Fprintf(&diff, " %v\n", lines[k].text)
+				lastPrinted = k
+			}
+		}
+	}
+	return diff.String()
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func migrateConfigTestMetadata() map[string]config.StepData {
+	return map[string]config.StepData{
+		"mavenBuild": {
+			Metadata: config.StepMetadata{Name: "mavenBuild", Aliases: []config.Alias{{Name: "mavenExecute"}}},
+			Spec: config.StepSpec{Inputs: config.StepInputs{Parameters: []config.StepParameters{
+				{Name: "pomPath", Type: "string", Scope: []string{"PARAMETERS", "STEPS"}, Aliases: []config.Alias{{Name: "pom", Deprecated: true}}},
+			}}},
+		},
+	}
+}
+
+func TestRunMigrateConfig(t *testing.T) {
+	customConfigBak := GeneralConfig.CustomConfig
+	GeneralConfig.CustomConfig = ".pipeline/config.yml"
+	defer func() { GeneralConfig.CustomConfig = customConfigBak }()
+
+	const content = "steps:\n  mavenExecute:\n    pom: pom.xml\n"
+
+	t.Run("migrate", func(t *testing.T) {
+		utils := &mock.FilesMock{}
+		utils.AddFile(".pipeline/config.yml", []byte(content))
+		out := bytes.Buffer{}
+
+		err := runMigrateConfig(migrateConfigCommandOptions{}, migrateConfigTestMetadata(), utils, &out)
+		require.NoError(t, err)
+		assert.Equal(t, ".pipeline/config.yml:2: renamed step 'mavenExecute' to 'mavenBuild'\n"+
+			".pipeline/config.yml:3: replaced deprecated parameter 'pom' in steps/mavenBuild by 'pomPath'\n", out.String())
+		migrated, err := utils.FileRead(".pipeline/config.yml")
+		require.NoError(t, err)
+		assert.Equal(t, "steps:\n  mavenBuild:\n    pomPath: pom.xml\n", string(migrated))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		utils := &mock.FilesMock{}
+		utils.AddFile(".pipeline/config.yml", []byte(content))
+		out := bytes.Buffer{}
+
+		err := runMigrateConfig(migrateConfigCommandOptions{dryRun: true}, migrateConfigTestMetadata(), utils, &out)
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "--- .pipeline/config.yml\n+++ .pipeline/config.yml (migrated)\n")
+		assert.Contains(t, out.String(), "-  mavenExecute:\n-    pom: pom.xml\n+  mavenBuild:\n+    pomPath: pom.xml\n")
+		unchanged, err := utils.FileRead(".pipeline/config.yml")
+		require.NoError(t, err)
+		assert.Equal(t, content, string(unchanged))
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		utils := &mock.FilesMock{}
+		utils.AddFile(".pipeline/config.yml", []byte("steps:\n  mavenBuild:\n    pomPath: pom.xml\n"))
+		out := bytes.Buffer{}
+
+		err := runMigrateConfig(migrateConfigCommandOptions{}, migrateConfigTestMetadata(), utils, &out)
+		assert.NoError(t, err)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("manual changes only", func(t *testing.T) {
+		utils := &mock.FilesMock{}
+		original := "steps:\n  mavenBuild:\n    pomPath: pom.xml\n\n    pom: other.xml\n"
+		utils.AddFile(".pipeline/config.yml", []byte(original))
+		out := bytes.Buffer{}
+
+		err := runMigrateConfig(migrateConfigCommandOptions{}, migrateConfigTestMetadata(), utils, &out)
+		require.NoError(t, err)
+		assert.Equal(t, ".pipeline/config.yml:5: deprecated parameter 'pom' in steps/mavenBuild is kept since 'pomPath' is already configured, please remove it\n", out.String())
+		assert.False(t, utils.HasWrittenFile(".pipeline/config.yml"))
+	})
+
+	t.Run("missing configuration", func(t *testing.T) {
+		err := runMigrateConfig(migrateConfigCommandOptions{}, migrateConfigTestMetadata(), &mock.FilesMock{}, &bytes.Buffer{})
+		assert.EqualError(t, err, "configuration file '.pipeline/config.yml' does not exist")
+	})
+}
+
+func TestLineDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nH\n"
+
+	assert.Equal(t, "--- f\n+++ f (migrated)\n"+
+		"@@\n a\n-b\n+B\n c\n d\n"+
+		"@@\n f\n g\n-h\n+H\n", lineDiff("f", from, to))
+}
@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
@@ -15,12 +19,17 @@ type pipelineCreateScanSummaryUtils interface {
 	FileRead(path string) ([]byte, error)
 	FileWrite(path string, content []byte, perm os.FileMode) error
 	Glob(pattern string) (matches []string, err error)
+	Now() time.Time
 }
 
 type pipelineCreateScanSummaryUtilsBundle struct {
 	*piperutils.Files
 }
 
+func (p *pipelineCreateScanSummaryUtilsBundle) Now() time.Time {
+	return time.Now()
+}
+
 func newPipelineCreateScanSummaryUtils() pipelineCreateScanSummaryUtils {
 	utils := pipelineCreateScanSummaryUtilsBundle{
 		Files: &piperutils.Files{},
@@ -57,7 +66,17 @@ func runPipelineCreateScanSummary(config *pipelineCreateScanSummaryOptions, tele
 		scanReports = append(scanReports, scanReport)
 	}
 
+	summary, err := createAggregatedScanSummary(config, utils)
+	if err != nil {
+		return err
+	}
+
 	output := []byte{}
+	if summary != nil && (!config.FailedOnly || !summary.Compliant) {
+		summaryReport := summary.ToScanReport()
+		mdReport, _ := summaryReport.ToMarkdown()
+		output = append(output, mdReport...)
+	}
 	for _, scanReport := range scanReports {
 		if (config.FailedOnly && !scanReport.SuccessfulScan) || !config.FailedOnly {
 			mdReport, _ := scanReport.ToMarkdown()
@@ -70,5 +89,84 @@ func runPipelineCreateScanSummary(config *pipelineCreateScanSummaryOptions, tele
 		return errors.Wrapf(err, "failed to write %v", config.OutputFilePath)
 	}
 
+	if summary != nil && !summary.Compliant {
+		log.SetErrorCategory(log.ErrorCompliance)
+		return fmt.Errorf("scan results violate the policy: %v", strings.Join(summary.Violations, ", "))
+	}
 	return nil
 }
+
+// createAggregatedScanSummary combines the SARIF reports of all scans into one summary
+// and writes it in JSON and HTML format. No summary is created in case no SARIF report is available.
+func createAggregatedScanSummary(config *pipelineCreateScanSummaryOptions, utils pipelineCreateScanSummaryUtils) (*reporting.ScanSummary, error) {
+	sarifFiles := []string{}
+	for _, pattern := range config.SarifFiles {
+		matches, err := utils.Glob(pattern)
+		if err != nil {
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return nil, errors.Wrapf(err, "failed to find SARIF reports with pattern %v", pattern)
+		}
+		sarifFiles = append(sarifFiles, matches...)
+	}
+	sarifFiles, err := piperutils.ExcludeFiles(piperutils.UniqueStrings(sarifFiles), config.ExcludeSarifFiles)
+	if err != nil {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return nil, err
+	}
+	// ensure a stable order of the aggregated findings
+	sort.Strings(sarifFiles)
+
+	findingsReports := []reporting.FindingsReport{}
+	for _, sarifFile := range sarifFiles {
+		log.Entry().Debugf("reading SARIF report %v", sarifFile)
+		sarifContent, err := utils.FileRead(sarifFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read SARIF report %v", sarifFile)
+		}
+		reports, err := reporting.FindingsFromSARIF(sarifContent)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse SARIF report %v", sarifFile)
+		}
+		findingsReports = append(findingsReports, reports...)
+	}
+	if len(findingsReports) == 0 {
+		log.Entry().Info("no SARIF reports found, skipping creation of aggregated summary")
+		return nil, nil
+	}
+
+	summary := reporting.NewScanSummary(findingsReports)
+	summary.ReportTime = utils.Now()
+	if len(config.PolicyFile) > 0 {
+		policyContent, err := utils.FileRead(config.PolicyFile)
+		if err != nil {
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return nil, errors.Wrapf(err, "failed to read policy file %v", config.PolicyFile)
+		}
+		policy, err := reporting.ParseScanPolicy(policyContent)
+		if err != nil {
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return nil, errors.Wrapf(err, "invalid policy file %v", config.PolicyFile)
+		}
+		summary.ApplyPolicy(policy)
+	}
+	log.Entry().Infof("aggregated %v findings of %v scans (%v reported by more than one tool), compliant: %v", len(summary.Findings), len(summary.Scans), summary.Duplicates, summary.Compliant)
+
+	if len(config.JSONOutputFilePath) > 0 {
+		// ignore JSON errors since structure is in our hands
+		jsonReport, _ := summary.ToJSON()
+		if err := utils.FileWrite(config.JSONOutputFilePath, jsonReport, 0666); err != nil {
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return nil, errors.Wrapf(err, "failed to write %v", config.JSONOutputFilePath)
+		}
+	}
+	if len(config.HtmlOutputFilePath) > 0 {
+		// ignore templating errors since template is in our hands and issues will be detected with the automated tests
+		scanReport := summary.ToScanReport()
+		htmlReport, _ := scanReport.ToHTML()
+		if err := utils.FileWrite(config.HtmlOutputFilePath, htmlReport, 0666); err != nil {
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return nil, errors.Wrapf(err, "failed to write %v", config.HtmlOutputFilePath)
+		}
+	}
+	return &summary, nil
+}
@@ -8,14 +8,22 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type pipelineCreateScanSummaryOptions struct {
-	FailedOnly     bool   `json:"failedOnly,omitempty"`
-	OutputFilePath string `json:"outputFilePath,omitempty"`
+	FailedOnly         bool     `json:"failedOnly,omitempty"`
+	OutputFilePath     string   `json:"outputFilePath,omitempty"`
+	SarifFiles         []string `json:"sarifFiles,omitempty"`
+	ExcludeSarifFiles  []string `json:"excludeSarifFiles,omitempty"`
+	JSONOutputFilePath string   `json:"jsonOutputFilePath,omitempty"`
+	HtmlOutputFilePath string   `json:"htmlOutputFilePath,omitempty"`
+	PolicyFile         string   `json:"policyFile,omitempty"`
 }
 
 // PipelineCreateScanSummaryCommand Collect scan result information anc create a summary report
@@ -31,7 +39,26 @@ func PipelineCreateScanSummaryCommand() *cobra.Command {
 		Short: "Collect scan result information anc create a summary report",
 		Long: `This step allows you to create a summary report of your scan results.
 
-It is for example used to create a markdown file which can be used to create a GitHub issue.`,
+It is for example used to create a markdown file which can be used to create a GitHub issue.
+
+In addition the step combines the findings of all scan steps which provide a SARIF report into one aggregated summary.
+The same vulnerability (e.g. a CVE) reported by multiple tools for the same component is only counted once.
+The aggregated summary is available in JSON and HTML format and can be checked against a policy file, for example:
+
+` + "`" + `` + "`" + `` + "`" + `yaml
+# maximum number of findings per severity (critical, high, medium, low, info, unknown) across all tools
+maxFindings:
+  critical: 0
+  high: 5
+# maximum number of findings across all severities
+maxTotalFindings: 50
+# fail in case one of the scans did not finish successfully
+failOnUnsuccessfulScan: true
+# do not consider findings which have already been audited in the scan tool
+ignoreAudited: true
+` + "`" + `` + "`" + `` + "`" + `
+
+The step fails in case the aggregated findings violate the policy.`,
 		PreRunE: func(cmd *cobra.Command, _ []string) error {
 			startTime = time.Now()
 			log.SetStepName(STEP_NAME)
@@ -44,6 +71,17 @@ It is for example used to create a markdown file which can be used to create a G
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 
@@ -59,9 +97,15 @@ It is for example used to create a markdown file which can be used to create a G
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -79,6 +123,11 @@ It is for example used to create a markdown file which can be used to create a G
 func addPipelineCreateScanSummaryFlags(cmd *cobra.Command, stepConfig *pipelineCreateScanSummaryOptions) {
 	cmd.Flags().BoolVar(&stepConfig.FailedOnly, "failedOnly", false, "Defines if only failed scans should be included into the summary.")
 	cmd.Flags().StringVar(&stepConfig.OutputFilePath, "outputFilePath", `scanSummary.md`, "Defines the filepath to the target file which will be created by the step.")
+	cmd.Flags().StringSliceVar(&stepConfig.SarifFiles, "sarifFiles", []string{`**/*.sarif`}, "Defines the patterns of the SARIF reports which are combined into the aggregated summary.")
+	cmd.Flags().StringSliceVar(&stepConfig.ExcludeSarifFiles, "excludeSarifFiles", []string{`**/node_modules/**`}, "Defines the patterns of SARIF reports which are excluded from the aggregated summary.")
+	cmd.Flags().StringVar(&stepConfig.JSONOutputFilePath, "jsonOutputFilePath", `scanSummary.json`, "Defines the filepath to the JSON file containing the aggregated summary. If empty, no JSON file is created.")
+	cmd.Flags().StringVar(&stepConfig.HtmlOutputFilePath, "htmlOutputFilePath", `scanSummary.html`, "Defines the filepath to the HTML file containing the aggregated summary. If empty, no HTML file is created.")
+	cmd.Flags().StringVar(&stepConfig.PolicyFile, "policyFile", os.Getenv("PIPER_policyFile"), "Defines the filepath to a policy file (YAML or JSON) containing the limits for the aggregated findings. The step fails in case the limits are exceeded.")
 
 }
 
@@ -109,6 +158,46 @@ func pipelineCreateScanSummaryMetadata() config.StepData {
 						Mandatory:   false,
 						Aliases:     []config.Alias{},
 					},
+					{
+						Name:        "sarifFiles",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "[]string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "excludeSarifFiles",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "[]string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "jsonOutputFilePath",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "htmlOutputFilePath",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "policyFile",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
 				},
 			},
 		},
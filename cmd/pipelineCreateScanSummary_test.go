@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/SAP/jenkins-library/pkg/mock"
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,10 @@ type pipelineCreateScanSummaryMockUtils struct {
 	*mock.FilesMock
 }
 
+func (p pipelineCreateScanSummaryMockUtils) Now() time.Time {
+	return time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+}
+
 func newPipelineCreateScanSummaryTestsUtils() pipelineCreateScanSummaryMockUtils {
 	utils := pipelineCreateScanSummaryMockUtils{
 		FilesMock: &mock.FilesMock{},
@@ -108,3 +113,110 @@ func TestRunPipelineCreateScanSummary(t *testing.T) {
 	})
 
 }
+
+const protecodeSarif = `{"runs":[{"tool":{"driver":{"name":"Protecode","rules":[{"id":"CVE-2021-0001","properties":{"security-severity":"9.8"}}]}},
+"invocations":[{"executionSuccessful":true}],"properties":{"stepName":"protecodeExecuteScan"},
+"results":[{"ruleId":"CVE-2021-0001","ruleIndex":0,"level":"error","message":{"text":"CVE-2021-0001 in openssl"},"properties":{"severity":"critical","vulnerabilityId":"CVE-2021-0001","component":"openssl","componentVersion":"1.0.2"}}]}]}`
+
+const whitesourceSarif = `{"runs":[{"tool":{"driver":{"name":"WhiteSource","rules":[{"id":"CVE-2021-0001"},{"id":"CVE-2021-0002"}]}},
+"invocations":[{"executionSuccessful":true}],"properties":{"stepName":"whitesourceExecuteScan"},
+"results":[{"ruleId":"CVE-2021-0001","ruleIndex":0,"level":"error","message":{"text":"CVE-2021-0001 in OpenSSL"},"properties":{"severity":"critical","vulnerabilityId":"cve-2021-0001","component":"OpenSSL","componentVersion":"1.0.2"}},
+{"ruleId":"CVE-2021-0002","ruleIndex":1,"level":"warning","message":{"text":"CVE-2021-0002 in zlib"},"properties":{"severity":"medium","vulnerabilityId":"CVE-2021-0002","component":"zlib","componentVersion":"1.2.11"}}]}]}`
+
+const detectSarif = `{"runs":[{"tool":{"driver":{"name":"Synopsys Detect"}},"invocations":[{"executionSuccessful":false}],"properties":{"stepName":"detectExecuteScan"}}]}`
+
+func TestRunPipelineCreateScanSummaryAggregated(t *testing.T) {
+	t.Parallel()
+
+	config := func() pipelineCreateScanSummaryOptions {
+		return pipelineCreateScanSummaryOptions{
+			OutputFilePath:     "scanSummary.md",
+			SarifFiles:         []string{"**/*.sarif"},
+			ExcludeSarifFiles:  []string{"**/node_modules/**"},
+			JSONOutputFilePath: "scanSummary.json",
+			HtmlOutputFilePath: "scanSummary.html",
+		}
+	}
+	newUtils := func() pipelineCreateScanSummaryMockUtils {
+		utils := newPipelineCreateScanSummaryTestsUtils()
+		utils.AddFile("protecodeExecuteScan.sarif", []byte(protecodeSarif))
+		utils.AddFile("whitesource/piper_whitesource_vulnerability_report.sarif", []byte(whitesourceSarif))
+		utils.AddFile("detectExecuteScan.sarif", []byte(detectSarif))
+		utils.AddFile("node_modules/some-module/report.sarif", []byte(`invalid`))
+		return utils
+	}
+
+	t.Run("success - deduplicated summary", func(t *testing.T) {
+		t.Parallel()
+		c := config()
+		utils := newUtils()
+
+		err := runPipelineCreateScanSummary(&c, nil, utils)
+
+		assert.NoError(t, err)
+		jsonContent, err := utils.FileRead("scanSummary.json")
+		assert.NoError(t, err)
+		assert.Contains(t, string(jsonContent), `"totals":{"critical":1,"medium":1}`)
+		assert.Contains(t, string(jsonContent), `"duplicates":1`)
+		assert.Contains(t, string(jsonContent), `"tools":["Protecode","WhiteSource"]`)
+		assert.Contains(t, string(jsonContent), `"compliant":true`)
+		htmlContent, err := utils.FileRead("scanSummary.html")
+		assert.NoError(t, err)
+		assert.Contains(t, string(htmlContent), "Scan Summary")
+		mdContent, err := utils.FileRead("scanSummary.md")
+		assert.NoError(t, err)
+		assert.Contains(t, string(mdContent), "Scan Summary")
+		assert.Contains(t, string(mdContent), "Synopsys Detect (detectExecuteScan)")
+	})
+
+	t.Run("success - policy fulfilled", func(t *testing.T) {
+		t.Parallel()
+		c := config()
+		c.PolicyFile = "policy.yml"
+		utils := newUtils()
+		utils.AddFile("policy.yml", []byte("maxFindings:\n  critical: 1\n  high: 0\n"))
+
+		err := runPipelineCreateScanSummary(&c, nil, utils)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("error - policy violated", func(t *testing.T) {
+		t.Parallel()
+		c := config()
+		c.PolicyFile = "policy.yml"
+		utils := newUtils()
+		utils.AddFile("policy.yml", []byte("maxFindings:\n  critical: 0\nmaxTotalFindings: 1\nfailOnUnsuccessfulScan: true\n"))
+
+		err := runPipelineCreateScanSummary(&c, nil, utils)
+
+		assert.EqualError(t, err, "scan results violate the policy: 1 critical findings (max. 0), 2 findings in total (max. 1), scan Synopsys Detect (detectExecuteScan) was not successful")
+		jsonContent, _ := utils.FileRead("scanSummary.json")
+		assert.Contains(t, string(jsonContent), `"compliant":false`)
+		mdContent, _ := utils.FileRead("scanSummary.md")
+		assert.Contains(t, string(mdContent), "not compliant")
+	})
+
+	t.Run("error - invalid policy", func(t *testing.T) {
+		t.Parallel()
+		c := config()
+		c.PolicyFile = "policy.yml"
+		utils := newUtils()
+		utils.AddFile("policy.yml", []byte("maxFindings:\n  severe: 0\n"))
+
+		err := runPipelineCreateScanSummary(&c, nil, utils)
+
+		assert.Contains(t, fmt.Sprint(err), "invalid policy file policy.yml: failed to parse scan policy: unknown severity 'severe'")
+	})
+
+	t.Run("error - missing policy", func(t *testing.T) {
+		t.Parallel()
+		c := config()
+		c.PolicyFile = "policy.yml"
+		utils := newUtils()
+
+		err := runPipelineCreateScanSummary(&c, nil, utils)
+
+		assert.Contains(t, fmt.Sprint(err), "failed to read policy file policy.yml")
+	})
+}
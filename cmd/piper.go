@@ -9,10 +9,15 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperenv"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -23,6 +28,10 @@ type GeneralConfigOptions struct {
 	CustomConfig         string
 	DefaultConfig        []string //ordered list of Piper default configurations. Can be filePath or ENV containing JSON in format 'ENV:MY_ENV_VAR'
 	IgnoreCustomDefaults bool
+	DefaultsCacheDir     string
+	DefaultsCacheTTL     time.Duration
+	DefaultsLockFile     string
+	DefaultsPublicKey    string
 	ParametersJSON       string
 	EnvRootPath          string
 	NoTelemetry          bool
@@ -32,6 +41,7 @@ type GeneralConfigOptions struct {
 	StepName             string
 	Verbose              bool
 	LogFormat            string
+	EventStream          string
 	VaultRoleID          string
 	VaultRoleSecretID    string
 	VaultToken           string
@@ -41,9 +51,11 @@ type GeneralConfigOptions struct {
 	HookConfig           HookConfiguration
 }
 
-// HookConfiguration contains the configuration for supported hooks, so far only Sentry is supported.
+// HookConfiguration contains the configuration for supported hooks, so far Sentry, OpenTelemetry and telemetry sinks are supported.
 type HookConfiguration struct {
-	SentryConfig SentryConfiguration `json:"sentry,omitempty"`
+	SentryConfig        SentryConfiguration        `json:"sentry,omitempty"`
+	OpenTelemetryConfig OpenTelemetryConfiguration `json:"openTelemetry,omitempty"`
+	TelemetryConfig     TelemetryConfiguration     `json:"telemetry,omitempty"`
 }
 
 // SentryConfiguration defines the configuration options for the Sentry logging system
@@ -51,6 +63,19 @@ type SentryConfiguration struct {
 	Dsn string `json:"dsn,omitempty"`
 }
 
+// OpenTelemetryConfiguration defines the configuration options for the export of traces via OTLP
+type OpenTelemetryConfiguration struct {
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// TelemetryConfiguration defines the sinks which receive the telemetry data of the steps in addition to SWA
+type TelemetryConfiguration struct {
+	Sinks []telemetry.SinkConfiguration `json:"sinks,omitempty"`
+}
+
+// traceParentKey is the commonPipelineEnvironment key containing the trace context of the pipeline run
+const traceParentKey = "custom/traceParent"
+
 var rootCmd = &cobra.Command{
 	Use:   "piper",
 	Short: "Executes CI/CD steps from project 'Piper' ",
@@ -68,6 +93,12 @@ func Execute() {
 
 	rootCmd.AddCommand(ArtifactPrepareVersionCommand())
 	rootCmd.AddCommand(ConfigCommand())
+	rootCmd.AddCommand(CheckConfigCommand())
+	rootCmd.AddCommand(MigrateConfigCommand())
+	rootCmd.AddCommand(CheckStepActiveCommand())
+	rootCmd.AddCommand(ConfigDiffCommand())
+	rootCmd.AddCommand(EnvCommand())
+	rootCmd.AddCommand(RunCommand())
 	rootCmd.AddCommand(ContainerSaveImageCommand())
 	rootCmd.AddCommand(CommandLineCompletionCommand())
 	rootCmd.AddCommand(VersionCommand())
@@ -154,6 +185,10 @@ func addRootFlags(rootCmd *cobra.Command) {
 	rootCmd.PersistentFlags().StringVar(&GeneralConfig.CustomConfig, "customConfig", ".pipeline/config.yml", "Path to the pipeline configuration file")
 	rootCmd.PersistentFlags().StringSliceVar(&GeneralConfig.DefaultConfig, "defaultConfig", []string{".pipeline/defaults.yaml"}, "Default configurations, passed as path to yaml file")
 	rootCmd.PersistentFlags().BoolVar(&GeneralConfig.IgnoreCustomDefaults, "ignoreCustomDefaults", false, "Disables evaluation of the parameter 'customDefaults' in the pipeline configuration file")
+	rootCmd.PersistentFlags().StringVar(&GeneralConfig.DefaultsCacheDir, "defaultsCacheDir", "", "Directory of the cache for remote defaults, defaults to the user cache directory")
+	rootCmd.PersistentFlags().DurationVar(&GeneralConfig.DefaultsCacheTTL, "defaultsCacheTTL", 0, "Duration for which cached remote defaults are used without fetching them again, outdated entries are used in case the source is not reachable")
+	rootCmd.PersistentFlags().StringVar(&GeneralConfig.DefaultsLockFile, "defaultsLockFile", os.Getenv("PIPER_defaultsLockFile"), "Lock file which pins the resolved versions of remote defaults, e.g. .pipeline/defaults.lock")
+	rootCmd.PersistentFlags().StringVar(&GeneralConfig.DefaultsPublicKey, "defaultsPublicKey", os.Getenv("PIPER_defaultsPublicKey"), "Base64 encoded ed25519 public key for verifying the signatures (<source>.sig) of remote defaults")
 	rootCmd.PersistentFlags().StringVar(&GeneralConfig.ParametersJSON, "parametersJSON", os.Getenv("PIPER_parametersJSON"), "Parameters to be considered in JSON format")
 	rootCmd.PersistentFlags().StringVar(&GeneralConfig.EnvRootPath, "envRootPath", ".pipeline", "Root path to Piper pipeline shared environments")
 	rootCmd.PersistentFlags().StringVar(&GeneralConfig.StageName, "stageName", "", "Name of the stage for which configuration should be included")
@@ -161,6 +196,7 @@ func addRootFlags(rootCmd *cobra.Command) {
 	rootCmd.PersistentFlags().BoolVar(&GeneralConfig.NoTelemetry, "noTelemetry", false, "Disables telemetry reporting")
 	rootCmd.PersistentFlags().BoolVarP(&GeneralConfig.Verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringVar(&GeneralConfig.LogFormat, "logFormat", "default", "Log format to use. Options: default, timestamp, plain, full.")
+	rootCmd.PersistentFlags().StringVar(&GeneralConfig.EventStream, "eventStream", os.Getenv("PIPER_eventStream"), "File path or http(s) endpoint to which the events of the step execution are written as newline delimited JSON")
 	rootCmd.PersistentFlags().StringVar(&GeneralConfig.VaultServerURL, "vaultServerUrl", "", "The vault server which should be used to fetch credentials")
 	rootCmd.PersistentFlags().StringVar(&GeneralConfig.VaultNamespace, "vaultNamespace", "", "The vault namespace which should be used to fetch credentials")
 	rootCmd.PersistentFlags().StringVar(&GeneralConfig.VaultPath, "vaultPath", "", "The path which should be used to fetch credentials")
@@ -216,11 +252,14 @@ func initStageName(outputToLog bool) {
 
 // PrepareConfig reads step configuration from various sources and merges it (defaults, config file, flags, ...)
 func PrepareConfig(cmd *cobra.Command, metadata *config.StepData, stepName string, options interface{}, openFile func(s string) (io.ReadCloser, error)) error {
+	start := time.Now()
 
 	log.SetFormatter(GeneralConfig.LogFormat)
 
 	initStageName(true)
 
+	events.Initialize(GeneralConfig.EventStream, events.Source{Step: stepName, Stage: GeneralConfig.StageName, CorrelationID: GeneralConfig.CorrelationID})
+
 	filters := metadata.GetParameterFilters()
 
 	// add telemetry parameter "collectTelemetryData" to ALL, GENERAL and PARAMETER filters
@@ -245,6 +284,9 @@ func PrepareConfig(cmd *cobra.Command, metadata *config.StepData, stepName strin
 		GeneralConfig.VaultToken = os.Getenv("PIPER_vaultToken")
 	}
 	myConfig.SetVaultCredentials(GeneralConfig.VaultRoleID, GeneralConfig.VaultRoleSecretID, GeneralConfig.VaultToken)
+	myConfig.SetEnvRootPath(GeneralConfig.EnvRootPath)
+	defaultsResolver := newDefaultsResolver(openFile)
+	myConfig.SetDefaultsResolver(defaultsResolver)
 
 	if len(GeneralConfig.StepConfigJSON) != 0 {
 		// ignore config & defaults in favor of passed stepConfigJSON
@@ -273,7 +315,7 @@ func PrepareConfig(cmd *cobra.Command, metadata *config.StepData, stepName strin
 			log.Entry().Info("Project defaults: NONE")
 		}
 		for _, projectDefaultFile := range GeneralConfig.DefaultConfig {
-			fc, err := openFile(projectDefaultFile)
+			fc, err := defaultsResolver.Open(projectDefaultFile)
 			// only create error for non-default values
 			if err != nil {
 				if projectDefaultFile != ".pipeline/defaults.yaml" {
@@ -309,9 +351,44 @@ func PrepareConfig(cmd *cobra.Command, metadata *config.StepData, stepName strin
 
 	retrieveHookConfig(stepConfig.HookConfig, &GeneralConfig.HookConfig)
 
+	initTracing(stepName, start)
+
+	telemetry.ConfigureSinks(GeneralConfig.HookConfig.TelemetryConfig.Sinks)
+
+	events.ConfigResolved(stepConfig.Config, secretParameters(metadata))
+
 	return nil
 }
 
+// newDefaultsResolver creates the resolver for remote defaults, local files are opened via openFile
+func newDefaultsResolver(openFile func(s string) (io.ReadCloser, error)) *config.DefaultsResolver {
+	cacheDir := GeneralConfig.DefaultsCacheDir
+	if len(cacheDir) == 0 {
+		if userCacheDir, err := os.UserCacheDir(); err == nil {
+			cacheDir = filepath.Join(userCacheDir, "piper", "defaults")
+		}
+	}
+	return config.NewDefaultsResolver(cacheDir, GeneralConfig.DefaultsCacheTTL, GeneralConfig.DefaultsLockFile, GeneralConfig.DefaultsPublicKey, openFile)
+}
+
+// secretParameters returns the names of the parameters containing secrets
+func secretParameters(metadata *config.StepData) []string {
+	names := []string{}
+	for _, param := range metadata.Spec.Inputs.Parameters {
+		if param.Secret {
+			names = append(names, param.Name)
+			continue
+		}
+		for _, ref := range param.ResourceRef {
+			if ref.IsSecret() {
+				names = append(names, param.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
 func retrieveHookConfig(source *json.RawMessage, target *HookConfiguration) {
 	if source != nil {
 		log.Entry().Info("Retrieving hook configuration")
@@ -322,6 +399,33 @@ func retrieveHookConfig(source *json.RawMessage, target *HookConfiguration) {
 	}
 }
 
+// initTracing starts the tracing of the step run.
+// The trace context is taken from the environment variable TRACEPARENT, e.g. provided by the orchestrator,
+// or from the commonPipelineEnvironment so that all steps of a pipeline run belong to one trace.
+// The first traced step of a pipeline run stores its own trace context in the commonPipelineEnvironment.
+func initTracing(stepName string, start time.Time) {
+	traceParent := os.Getenv("TRACEPARENT")
+	if len(traceParent) == 0 {
+		traceParent = piperenv.GetResourceParameter(GeneralConfig.EnvRootPath, "commonPipelineEnvironment", traceParentKey)
+	}
+	tracing.Initialize(tracing.Options{
+		Endpoint:      GeneralConfig.HookConfig.OpenTelemetryConfig.Endpoint,
+		Step:          stepName,
+		Stage:         GeneralConfig.StageName,
+		CorrelationID: GeneralConfig.CorrelationID,
+		TraceParent:   traceParent,
+		Start:         start,
+	})
+	if !tracing.Enabled() || len(traceParent) > 0 {
+		return
+	}
+	store := piperenv.NewStore(filepath.Join(GeneralConfig.EnvRootPath, "commonPipelineEnvironment"), stepName)
+	// only the first step sets the trace context, steps running in parallel must not overwrite it
+	if err := store.CompareAndSet(traceParentKey, tracing.StepSpan().TraceParent(), 0); err != nil {
+		log.Entry().WithError(err).Debug("trace context has not been stored in the commonPipelineEnvironment")
+	}
+}
+
 var errIncompatibleTypes = fmt.Errorf("incompatible types")
 
 func checkTypes(config map[string]interface{}, options interface{}) map[string]interface{} {
@@ -8,15 +8,20 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/SAP/jenkins-library/pkg/log"
 
 	"github.com/SAP/jenkins-library/pkg/config"
 	"github.com/SAP/jenkins-library/pkg/mock"
+	"github.com/SAP/jenkins-library/pkg/piperenv"
+	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAddRootFlags(t *testing.T) {
@@ -29,6 +34,11 @@ func TestAddRootFlags(t *testing.T) {
 	assert.NotNil(t, testRootCmd.Flag("stageName"), "expected flag not available")
 	assert.NotNil(t, testRootCmd.Flag("stepConfigJSON"), "expected flag not available")
 	assert.NotNil(t, testRootCmd.Flag("verbose"), "expected flag not available")
+	assert.NotNil(t, testRootCmd.Flag("eventStream"), "expected flag not available")
+	assert.NotNil(t, testRootCmd.Flag("defaultsCacheDir"), "expected flag not available")
+	assert.NotNil(t, testRootCmd.Flag("defaultsCacheTTL"), "expected flag not available")
+	assert.NotNil(t, testRootCmd.Flag("defaultsLockFile"), "expected flag not available")
+	assert.NotNil(t, testRootCmd.Flag("defaultsPublicKey"), "expected flag not available")
 
 }
 
@@ -149,6 +159,22 @@ func TestPrepareConfig(t *testing.T) {
 	})
 }
 
+func TestSecretParameters(t *testing.T) {
+	metadata := config.StepData{
+		Spec: config.StepSpec{
+			Inputs: config.StepInputs{
+				Parameters: []config.StepParameters{
+					{Name: "username", ResourceRef: []config.ResourceReference{{Name: "credentialsId", Type: "secret", Param: "username"}}},
+					{Name: "password", Secret: true},
+					{Name: "token", ResourceRef: []config.ResourceReference{{Name: "tokenVaultSecretName", Type: "vaultSecret"}}},
+					{Name: "url", ResourceRef: []config.ResourceReference{{Name: "commonPipelineEnvironment", Param: "url"}}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, []string{"username", "password", "token"}, secretParameters(&metadata))
+}
+
 func TestRetrieveHookConfig(t *testing.T) {
 	tt := []struct {
 		hookJSON           []byte
@@ -156,6 +182,8 @@ func TestRetrieveHookConfig(t *testing.T) {
 	}{
 		{hookJSON: []byte(""), expectedHookConfig: HookConfiguration{}},
 		{hookJSON: []byte(`{"sentry":{"dsn":"https://my.sentry.dsn"}}`), expectedHookConfig: HookConfiguration{SentryConfig: SentryConfiguration{Dsn: "https://my.sentry.dsn"}}},
+		{hookJSON: []byte(`{"openTelemetry":{"endpoint":"http://localhost:4318"}}`), expectedHookConfig: HookConfiguration{OpenTelemetryConfig: OpenTelemetryConfiguration{Endpoint: "http://localhost:4318"}}},
+		{hookJSON: []byte(`{"telemetry":{"sinks":[{"type":"pushgateway","url":"http://pushgateway:9091","job":"myPipeline"}]}}`), expectedHookConfig: HookConfiguration{TelemetryConfig: TelemetryConfiguration{Sinks: []telemetry.SinkConfiguration{{Type: "pushgateway", URL: "http://pushgateway:9091", Job: "myPipeline"}}}}},
 	}
 
 	for _, test := range tt {
@@ -170,6 +198,61 @@ func TestRetrieveHookConfig(t *testing.T) {
 	}
 }
 
+func TestInitTracing(t *testing.T) {
+	envRootPathBak := GeneralConfig.EnvRootPath
+	hookConfigBak := GeneralConfig.HookConfig
+	defer func() {
+		GeneralConfig.EnvRootPath = envRootPathBak
+		GeneralConfig.HookConfig = hookConfigBak
+	}()
+	collector := tracing.NewCollector()
+	defer collector.Close()
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	GeneralConfig.EnvRootPath = dir
+	GeneralConfig.HookConfig = HookConfiguration{OpenTelemetryConfig: OpenTelemetryConfiguration{Endpoint: collector.Endpoint()}}
+
+	t.Run("disabled", func(t *testing.T) {
+		GeneralConfig.HookConfig = HookConfiguration{}
+		defer func() { GeneralConfig.HookConfig.OpenTelemetryConfig.Endpoint = collector.Endpoint() }()
+
+		initTracing("testStep", time.Now())
+
+		assert.False(t, tracing.Enabled())
+		assert.Empty(t, piperenv.GetResourceParameter(GeneralConfig.EnvRootPath, "commonPipelineEnvironment", traceParentKey))
+	})
+
+	t.Run("steps of a pipeline run share one trace", func(t *testing.T) {
+		initTracing("firstStep", time.Now())
+		require.True(t, tracing.Enabled())
+		traceParent := tracing.StepSpan().TraceParent()
+		tracing.StepFinished("0", "undefined")
+		assert.Equal(t, traceParent, piperenv.GetResourceParameter(GeneralConfig.EnvRootPath, "commonPipelineEnvironment", traceParentKey))
+
+		initTracing("secondStep", time.Now())
+		tracing.StepFinished("0", "undefined")
+
+		first, _ := collector.Span("firstStep")
+		second, _ := collector.Span("secondStep")
+		assert.Equal(t, first.TraceID, second.TraceID)
+		assert.Equal(t, first.SpanID, second.ParentSpanID)
+		assert.Equal(t, traceParent, piperenv.GetResourceParameter(GeneralConfig.EnvRootPath, "commonPipelineEnvironment", traceParentKey))
+	})
+
+	t.Run("trace context of the orchestrator", func(t *testing.T) {
+		os.Setenv("TRACEPARENT", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		defer os.Unsetenv("TRACEPARENT")
+
+		initTracing("thirdStep", time.Now())
+		tracing.StepFinished("0", "undefined")
+
+		third, _ := collector.Span("thirdStep")
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", third.TraceID)
+		assert.Equal(t, "b7ad6b7169203331", third.ParentSpanID)
+	})
+}
+
 func TestGetProjectConfigFile(t *testing.T) {
 
 	tt := []struct {
@@ -24,9 +24,10 @@ import (
 )
 
 const (
-	webReportPath  = "%s/products/%v/"
-	scanResultFile = "protecodescan_vulns.json"
-	stepResultFile = "protecodeExecuteScan.json"
+	webReportPath   = "%s/products/%v/"
+	scanResultFile  = "protecodescan_vulns.json"
+	stepResultFile  = "protecodeExecuteScan.json"
+	sarifResultFile = "protecodeExecuteScan.sarif"
 )
 
 var reportPath = "./"
@@ -190,6 +191,9 @@ func executeProtecodeScan(influx *protecodeExecuteScanInflux, client protecode.P
 		}, reportPath, stepResultFile, parsedResult, ioutil.WriteFile); err != nil {
 		log.Entry().Warningf("failed to write report: %v", err)
 	}
+	if err := protecode.WriteSarif(result.Result, config.ExcludeCVEs, reportPath, sarifResultFile, ioutil.WriteFile); err != nil {
+		log.Entry().Warningf("failed to write SARIF report: %v", err)
+	}
 
 	log.Entry().Debug("Write influx data")
 	setInfluxData(influx, parsedResult)
@@ -199,6 +203,7 @@ func executeProtecodeScan(influx *protecodeExecuteScanInflux, client protecode.P
 		{Target: config.ReportFileName, Mandatory: true},
 		{Target: stepResultFile, Mandatory: true},
 		{Target: scanResultFile, Mandatory: true},
+		{Target: sarifResultFile},
 	}
 	// write links JSON
 	links := []StepResults.Path{
@@ -95,13 +95,15 @@ func TestRunProtecodeScan(t *testing.T) {
 	require.NoError(t, err)
 	fileName := filepath.Base(testFile.Name())
 	path := strings.ReplaceAll(testFile.Name(), fileName, "")
+	testdata, err := filepath.Abs(filepath.Join("testdata", "TestProtecode"))
+	require.NoError(t, err)
 
 	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		requestURI = req.RequestURI
 		var b bytes.Buffer
 
 		if requestURI == "/api/product/4486/" || requestURI == "/api/product/4711/" {
-			violations := filepath.Join("testdata/TestProtecode", "protecode_result_violations.json")
+			violations := filepath.Join(testdata, "protecode_result_violations.json")
 			byteContent, err := ioutil.ReadFile(violations)
 			require.NoErrorf(t, err, "failed reading %v", violations)
 			response := protecode.ResultData{Result: protecode.Result{ProductID: 4711, ReportURL: requestURI}}
@@ -110,7 +112,7 @@ func TestRunProtecodeScan(t *testing.T) {
 			json.NewEncoder(&b).Encode(response)
 
 		} else if requestURI == "/api/fetch/" {
-			violations := filepath.Join("testdata/TestProtecode", "protecode_result_violations.json")
+			violations := filepath.Join(testdata, "protecode_result_violations.json")
 			byteContent, err := ioutil.ReadFile(violations)
 			require.NoErrorf(t, err, "failed reading %v", violations)
 			response := protecode.ResultData{Result: protecode.Result{ProductID: 4486, ReportURL: requestURI}}
@@ -147,6 +149,12 @@ func TestRunProtecodeScan(t *testing.T) {
 	reportPath = dir
 	cachePath = dir
 
+	// change into tmp dir since the step writes its reports and links into the working directory
+	resetDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(resetDir) }()
+	require.NoError(t, os.Chdir(dir))
+
 	t.Run("With tar as scan image", func(t *testing.T) {
 		config := protecodeExecuteScanOptions{ServerURL: server.URL, TimeoutMinutes: "1", VerifyOnly: false, CleanupMode: "none", Group: "13", FetchURL: "/api/fetch/", ExcludeCVEs: "CVE-2018-1, CVE-2017-1000382", ReportFileName: "./cache/report-file.txt"}
 		err = runProtecodeScan(&config, &influx, dClient)
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/command"
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/pipeline"
+	"github.com/SAP/jenkins-library/pkg/piperutils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type runCommandOptions struct {
+	pipelineFile        string
+	graphOnly           bool
+	graphFormat         string
+	parallel            int
+	failOnMissingInputs bool
+}
+
+var runOptions runCommandOptions
+
+type runUtils interface {
+	FileRead(path string) ([]byte, error)
+	// RunStep executes the piper binary with the given arguments
+	RunStep(args []string, out io.Writer) error
+}
+
+type runUtilsBundle struct {
+	*piperutils.Files
+}
+
+func (r *runUtilsBundle) RunStep(args []string, out io.Writer) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "failed to determine piper executable")
+	}
+	c := command.Command{}
+	c.Stdout(out)
+	c.Stderr(out)
+	return c.RunExecutable(executable, args...)
+}
+
+// RunCommand is the entry command for running a pipeline locally
+func RunCommand() *cobra.Command {
+	var createRunCmd = &cobra.Command{
+		Use:   "run",
+		Short: "Runs a pipeline locally without a CI/CD server.",
+		Long: `Runs the steps of a pipeline definition locally, e.g. on a developer's machine.
+The stages of the pipeline are executed one after the other. Within a stage, steps are executed in parallel
+unless a step reads a value of the commonPipelineEnvironment which another step writes.
+These dependencies are derived from the resource references in the step metadata.
+
+Example of a pipeline definition:
+
+    stages:
+      - name: Build
+        steps:
+          - name: artifactPrepareVersion
+          - name: mavenBuild
+            parameters:
+              flatten: false
+      - name: Acceptance
+        steps:
+          - name: kubernetesDeploy`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			path, _ := os.Getwd()
+			fatalHook := &log.FatalHook{CorrelationID: GeneralConfig.CorrelationID, Path: path}
+			log.RegisterHook(fatalHook)
+		},
+		Run: func(cmd *cobra.Command, _ []string) {
+			utils := &runUtilsBundle{Files: &piperutils.Files{}}
+			if err := runPipeline(runOptions, GetAllStepMetadata(), utils, os.Stdout); err != nil {
+				log.Entry().WithError(err).Fatal("pipeline execution failed")
+			}
+		},
+	}
+
+	createRunCmd.Flags().StringVar(&runOptions.pipelineFile, "pipeline", ".pipeline/pipeline.yml", "Path to the pipeline definition")
+	createRunCmd.Flags().BoolVar(&runOptions.graphOnly, "graph", false, "Only prints the dependency graph of the steps without executing them")
+	createRunCmd.Flags().StringVar(&runOptions.graphFormat, "graphFormat", "text", "Format of the dependency graph. Options: text, dot.")
+	createRunCmd.Flags().IntVar(&runOptions.parallel, "parallel", 4, "Maximum number of steps which are executed in parallel")
+	createRunCmd.Flags().BoolVar(&runOptions.failOnMissingInputs, "failOnMissingInputs", false, "Fails before the execution in case mandatory step inputs are not produced by any preceding step")
+	return createRunCmd
+}
+
+func runPipeline(options runCommandOptions, metadata map[string]config.StepData, utils runUtils, out io.Writer) error {
+	content, err := utils.FileRead(options.pipelineFile)
+	if err != nil {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return errors.Wrapf(err, "failed to read pipeline definition %v", options.pipelineFile)
+	}
+	definition, err := pipeline.ReadDefinition(content)
+	if err != nil {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return err
+	}
+	graph, err := pipeline.NewGraph(definition, metadata)
+	if err != nil {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return err
+	}
+
+	if options.graphOnly {
+		switch options.graphFormat {
+		case "text":
+			graph.WriteText(out)
+		case "dot":
+			graph.WriteDot(out)
+		default:
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return fmt.Errorf("graph format '%v' is not supported, supported are: text, dot", options.graphFormat)
+		}
+		return nil
+	}
+
+	graph.WriteText(out)
+	if len(graph.MissingInputs) > 0 {
+		if options.failOnMissingInputs {
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return fmt.Errorf("%v step inputs are not produced by any preceding step", len(graph.MissingInputs))
+		}
+		log.Entry().Warningf("%v step inputs are not produced by any preceding step, they need to be provided via configuration", len(graph.MissingInputs))
+	}
+
+	var outputMutex sync.Mutex
+	results, err := graph.Run(options.parallel, func(node *pipeline.Node) error {
+		args, err := stepArguments(node)
+		if err != nil {
+			return err
+		}
+		writer := &prefixWriter{prefix: fmt.Sprintf("[%v] ", node.Step.Name), out: out, mutex: &outputMutex}
+		defer writer.Flush()
+		log.Entry().Infof("starting step %v in stage %v", node.Step.Name, node.Stage)
+		start := time.Now()
+		err = utils.RunStep(args, writer)
+		log.Entry().Infof("step %v finished after %v", node.Step.Name, time.Since(start).Round(time.Millisecond))
+		return err
+	})
+
+	for _, result := range results {
+		status := "success"
+		if result.Skipped {
+			status = "skipped"
+		} else if result.Err != nil {
+			status = fmt.Sprintf("failure: %v", result.Err)
+		}
+		fmt.Fprintf(out, "%v: %v\n", result.Node.ID, status)
+	}
+	return err
+}
+
+// stepArguments returns the command line arguments of piper for executing the step of the node
+func stepArguments(node *pipeline.Node) ([]string, error) {
+	args := []string{node.Step.Name, "--stageName", node.Stage, "--envRootPath", GeneralConfig.EnvRootPath, "--customConfig", GeneralConfig.CustomConfig}
+	for _, defaultConfig := range GeneralConfig.DefaultConfig {
+		args = append(args, "--defaultConfig", defaultConfig)
+	}
+	if len(node.Step.Parameters) > 0 {
+		parameters, err := json.Marshal(node.Step.Parameters)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal parameters of step %v", node.Step.Name)
+		}
+		args = append(args, "--parametersJSON", string(parameters))
+	}
+	if len(GeneralConfig.CorrelationID) > 0 {
+		args = append(args, "--correlationID", GeneralConfig.CorrelationID)
+	}
+	if len(GeneralConfig.DefaultsCacheDir) > 0 {
+		args = append(args, "--defaultsCacheDir", GeneralConfig.DefaultsCacheDir)
+	}
+	if GeneralConfig.DefaultsCacheTTL > 0 {
+		args = append(args, "--defaultsCacheTTL", GeneralConfig.DefaultsCacheTTL.String())
+	}
+	if len(GeneralConfig.DefaultsLockFile) > 0 {
+		args = append(args, "--defaultsLockFile", GeneralConfig.DefaultsLockFile)
+	}
+	if len(GeneralConfig.DefaultsPublicKey) > 0 {
+		args = append(args, "--defaultsPublicKey", GeneralConfig.DefaultsPublicKey)
+	}
+	if len(GeneralConfig.EventStream) > 0 {
+		args = append(args, "--eventStream", GeneralConfig.EventStream)
+	}
+	if GeneralConfig.NoTelemetry {
+		args = append(args, "--noTelemetry")
+	}
+	if GeneralConfig.Verbose {
+		args = append(args, "--verbose")
+	}
+	return args, nil
+}
+
+// prefixWriter prefixes each line with the name of the step,
+// complete lines are written at once in order to not mix up the output of parallel steps
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	mutex  *sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buffer.Write(p)
+	for {
+		index := bytes.IndexByte(w.buffer.Bytes(), '\n')
+		if index < 0 {
+			return len(p), nil
+		}
+		line := w.buffer.Next(index + 1)
+		if err := w.writeLine(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush writes the remaining content which does not end with a line break
+func (w *prefixWriter) Flush() {
+	if w.buffer.Len() > 0 {
+		w.writeLine(append(w.buffer.Bytes(), '\n'))
+		w.buffer.Reset()
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, err := w.out.Write(append([]byte(w.prefix), line...))
+	return err
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type runMockUtils struct {
+	*mock.FilesMock
+	mutex       sync.Mutex
+	calls       [][]string
+	failingStep string
+}
+
+func (r *runMockUtils) RunStep(args []string, out io.Writer) error {
+	r.mutex.Lock()
+	r.calls = append(r.calls, args)
+	r.mutex.Unlock()
+	fmt.Fprintf(out, "executing %v\nstill executing", args[0])
+	if args[0] == r.failingStep {
+		return fmt.Errorf("step failed")
+	}
+	return nil
+}
+
+func newRunMockUtils() *runMockUtils {
+	utils := runMockUtils{FilesMock: &mock.FilesMock{}}
+	utils.AddFile(".pipeline/pipeline.yml", []byte(`stages:
+  - name: Build
+    steps:
+      - name: artifactPrepareVersion
+      - name: mavenBuild
+        parameters:
+          flatten: false
+      - name: kanikoExecute
+  - name: Acceptance
+    steps:
+      - name: kubernetesDeploy
+`))
+	return &utils
+}
+
+func runTestMetadata() map[string]config.StepData {
+	metadata := GetAllStepMetadata()
+	return map[string]config.StepData{
+		"artifactPrepareVersion": metadata["artifactPrepareVersion"],
+		"mavenBuild":             metadata["mavenBuild"],
+		"kanikoExecute":          metadata["kanikoExecute"],
+		"kubernetesDeploy":       metadata["kubernetesDeploy"],
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	options := runCommandOptions{pipelineFile: ".pipeline/pipeline.yml", graphFormat: "text", parallel: 1}
+
+	t.Run("success", func(t *testing.T) {
+		utils := newRunMockUtils()
+		var out bytes.Buffer
+		err := runPipeline(options, runTestMetadata(), utils, &out)
+		assert.NoError(t, err)
+		require.Len(t, utils.calls, 4)
+		assert.Equal(t, []string{"artifactPrepareVersion", "--stageName", "Build"}, utils.calls[0][:3])
+		assert.Equal(t, "mavenBuild", utils.calls[1][0])
+		assert.Contains(t, utils.calls[1], `{"flatten":false}`)
+		assert.Equal(t, "kanikoExecute", utils.calls[2][0])
+		assert.Equal(t, []string{"kubernetesDeploy", "--stageName", "Acceptance"}, utils.calls[3][:3])
+		assert.Contains(t, out.String(), "<- Build/artifactPrepareVersion (commonPipelineEnvironment/artifactVersion)")
+		assert.Contains(t, out.String(), "[mavenBuild] executing mavenBuild\n[mavenBuild] still executing\n")
+		assert.Contains(t, out.String(), "Acceptance/kubernetesDeploy: success\n")
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		utils := newRunMockUtils()
+		parallelOptions := options
+		parallelOptions.parallel = 4
+		err := runPipeline(parallelOptions, runTestMetadata(), utils, &bytes.Buffer{})
+		assert.NoError(t, err)
+		require.Len(t, utils.calls, 4)
+		assert.Equal(t, "kubernetesDeploy", utils.calls[3][0])
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		utils := newRunMockUtils()
+		utils.failingStep = "mavenBuild"
+		var out bytes.Buffer
+		err := runPipeline(options, runTestMetadata(), utils, &out)
+		assert.EqualError(t, err, "pipeline failed, steps with errors: Build/mavenBuild")
+		assert.Len(t, utils.calls, 2)
+		assert.Contains(t, out.String(), "Build/mavenBuild: failure: step failed\n")
+		assert.Contains(t, out.String(), "Build/kanikoExecute: skipped\n")
+		assert.Contains(t, out.String(), "Acceptance/kubernetesDeploy: skipped\n")
+	})
+
+	t.Run("graph only", func(t *testing.T) {
+		utils := newRunMockUtils()
+		var out bytes.Buffer
+		graphOptions := options
+		graphOptions.graphOnly = true
+		graphOptions.graphFormat = "dot"
+		err := runPipeline(graphOptions, runTestMetadata(), utils, &out)
+		assert.NoError(t, err)
+		assert.Empty(t, utils.calls)
+		assert.Contains(t, out.String(), `"Build/artifactPrepareVersion" -> "Build/kanikoExecute"`)
+
+		graphOptions.graphFormat = "svg"
+		err = runPipeline(graphOptions, runTestMetadata(), utils, &out)
+		assert.EqualError(t, err, "graph format 'svg' is not supported, supported are: text, dot")
+	})
+
+	t.Run("missing inputs", func(t *testing.T) {
+		utils := newRunMockUtils()
+		utils.AddFile(".pipeline/pipeline.yml", []byte(`stages: [{name: Acceptance, steps: [{name: kubernetesDeploy}]}]`))
+		var out bytes.Buffer
+		missingOptions := options
+		missingOptions.failOnMissingInputs = true
+		err := runPipeline(missingOptions, runTestMetadata(), utils, &out)
+		assert.EqualError(t, err, "2 step inputs are not produced by any preceding step")
+		assert.Contains(t, out.String(), "Acceptance/kubernetesDeploy: parameter 'image' references commonPipelineEnvironment/container/imageNameTag")
+		assert.Empty(t, utils.calls)
+	})
+
+	t.Run("unknown step", func(t *testing.T) {
+		utils := newRunMockUtils()
+		utils.AddFile(".pipeline/pipeline.yml", []byte(`stages: [{name: Build, steps: [{name: notExisting}]}]`))
+		err := runPipeline(options, runTestMetadata(), utils, &bytes.Buffer{})
+		assert.EqualError(t, err, "stage 'Build' contains unknown step 'notExisting'")
+	})
+
+	t.Run("missing definition", func(t *testing.T) {
+		err := runPipeline(options, runTestMetadata(), &runMockUtils{FilesMock: &mock.FilesMock{}}, &bytes.Buffer{})
+		assert.Contains(t, err.Error(), "failed to read pipeline definition .pipeline/pipeline.yml")
+	})
+}
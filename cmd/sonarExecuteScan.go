@@ -193,7 +193,10 @@ func runSonar(config sonarExecuteScanOptions, client piperhttp.Downloader, runne
 			Name:   "Sonar Web UI",
 		},
 	}
-	StepResults.PersistReportsAndLinks("sonarExecuteScan", sonar.workingDir, nil, links)
+	reports := []StepResults.Path{
+		{Target: SonarUtils.SarifReportFileName},
+	}
+	StepResults.PersistReportsAndLinks("sonarExecuteScan", sonar.workingDir, reports, links)
 
 	if len(config.Token) == 0 {
 		log.Entry().Warn("no measurements are fetched due to missing credentials")
@@ -228,7 +231,7 @@ func runSonar(config sonarExecuteScanOptions, client piperhttp.Downloader, runne
 		return err
 	}
 	log.Entry().Debugf("Influx values: %v", influx.sonarqube_data.fields)
-	err = SonarUtils.WriteReport(SonarUtils.ReportData{
+	reportData := SonarUtils.ReportData{
 		ServerURL:    taskReport.ServerURL,
 		ProjectKey:   taskReport.ProjectKey,
 		TaskID:       taskReport.TaskID,
@@ -242,10 +245,20 @@ func runSonar(config sonarExecuteScanOptions, client piperhttp.Downloader, runne
 			Minor:    influx.sonarqube_data.fields.minor_issues,
 			Info:     influx.sonarqube_data.fields.info_issues,
 		},
-	}, sonar.workingDir, ioutil.WriteFile)
+	}
+	err = SonarUtils.WriteReport(reportData, sonar.workingDir, ioutil.WriteFile)
 	if err != nil {
 		return err
 	}
+	// the SARIF report is optional, thus failures do not fail the step
+	issues, err := issueService.GetIssues()
+	if err != nil {
+		log.Entry().WithError(err).Warning("failed to fetch issues for SARIF report")
+		return nil
+	}
+	if err := SonarUtils.WriteSarif(issues, reportData, sonar.workingDir, ioutil.WriteFile); err != nil {
+		log.Entry().WithError(err).Warning("failed to write SARIF report")
+	}
 	return nil
 }
 
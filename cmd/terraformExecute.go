@@ -1,17 +1,26 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/SAP/jenkins-library/pkg/command"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/pkg/errors"
 )
 
 type terraformExecuteUtils interface {
 	command.ExecRunner
 
 	FileExists(filename string) (bool, error)
+	FileWrite(path string, content []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
 }
 
 type terraformExecuteUtilsBundle struct {
@@ -19,6 +28,23 @@ type terraformExecuteUtilsBundle struct {
 	*piperutils.Files
 }
 
+// terraformPlan contains the parts of the `terraform show -json` output which are evaluated by the step
+type terraformPlan struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// terraformPlanSummary contains the number of resources affected by a plan
+type terraformPlanSummary struct {
+	add     int
+	change  int
+	destroy int
+}
+
 func newTerraformExecuteUtils() terraformExecuteUtils {
 	utils := terraformExecuteUtilsBundle{
 		Command: &command.Command{},
@@ -30,31 +56,215 @@ func newTerraformExecuteUtils() terraformExecuteUtils {
 	return &utils
 }
 
-func terraformExecute(config terraformExecuteOptions, telemetryData *telemetry.CustomData) {
+func terraformExecute(config terraformExecuteOptions, telemetryData *telemetry.CustomData, commonPipelineEnvironment *terraformExecuteCommonPipelineEnvironment) {
 	utils := newTerraformExecuteUtils()
 
-	err := runTerraformExecute(&config, telemetryData, utils)
+	err := runTerraformExecute(&config, telemetryData, utils, commonPipelineEnvironment)
+
+	// the plan is also persisted in case it exceeds the limits in order to allow inspecting it
+	if config.Command == "plan" && len(config.PlanFile) > 0 {
+		reports := []piperutils.Path{
+			{Target: config.PlanFile, Name: "Terraform plan"},
+			{Target: terraformPlanJSONFile(config.PlanFile), Name: "Terraform plan (JSON)"},
+		}
+		piperutils.PersistReportsAndLinks("terraformExecute", "", reports, nil)
+	}
+
 	if err != nil {
 		log.Entry().WithError(err).Fatal("step execution failed")
 	}
 }
 
-func runTerraformExecute(config *terraformExecuteOptions, telemetryData *telemetry.CustomData, utils terraformExecuteUtils) error {
+func runTerraformExecute(config *terraformExecuteOptions, telemetryData *telemetry.CustomData, utils terraformExecuteUtils, commonPipelineEnvironment *terraformExecuteCommonPipelineEnvironment) error {
+	if config.Init || config.Command == "init" {
+		if err := runTerraformInit(config, utils); err != nil {
+			return err
+		}
+	}
+
+	if len(config.Workspace) > 0 {
+		if err := selectTerraformWorkspace(config.Workspace, utils); err != nil {
+			return err
+		}
+	}
+
+	switch config.Command {
+	case "init":
+		// already done above, init must not run twice
+		return nil
+	case "plan":
+		return runTerraformPlan(config, utils, commonPipelineEnvironment)
+	case "apply":
+		return runTerraformApply(config, utils)
+	}
+
 	args := []string{config.Command}
+	if config.AdditionalArgs != nil {
+		args = append(args, config.AdditionalArgs...)
+	}
+	if err := utils.RunExecutable("terraform", args...); err != nil {
+		return errors.Wrapf(err, "failed to execute terraform %v", config.Command)
+	}
+	return nil
+}
 
-	if config.Command == "apply" {
-		args = append(args, "-auto-approve")
+func runTerraformInit(config *terraformExecuteOptions, utils terraformExecuteUtils) error {
+	args := []string{"init", "-input=false"}
+	for _, backendConfigFile := range config.BackendConfigFiles {
+		args = append(args, fmt.Sprintf("-backend-config=%s", backendConfigFile))
+	}
+	if config.Command == "init" && config.AdditionalArgs != nil {
+		args = append(args, config.AdditionalArgs...)
+	}
+	if err := utils.RunExecutable("terraform", args...); err != nil {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return errors.Wrap(err, "failed to initialize terraform")
 	}
+	return nil
+}
 
-	if (config.Command == "apply" || config.Command == "plan") && config.TerraformSecrets != "" {
+func selectTerraformWorkspace(workspace string, utils terraformExecuteUtils) error {
+	if err := utils.RunExecutable("terraform", "workspace", "select", workspace); err == nil {
+		return nil
+	}
+	log.Entry().Infof("terraform workspace '%v' does not exist, creating it", workspace)
+	if err := utils.RunExecutable("terraform", "workspace", "new", workspace); err != nil {
+		return errors.Wrapf(err, "failed to select terraform workspace '%v'", workspace)
+	}
+	return nil
+}
+
+func runTerraformPlan(config *terraformExecuteOptions, utils terraformExecuteUtils, commonPipelineEnvironment *terraformExecuteCommonPipelineEnvironment) error {
+	args := []string{"plan"}
+	if config.TerraformSecrets != "" {
 		args = append(args, fmt.Sprintf("-var-file=%s", config.TerraformSecrets))
 	}
+	if len(config.PlanFile) > 0 {
+		if err := utils.MkdirAll(filepath.Dir(config.PlanFile), 0777); err != nil {
+			return errors.Wrapf(err, "failed to create directory for plan file %v", config.PlanFile)
+		}
+		args = append(args, fmt.Sprintf("-out=%s", config.PlanFile))
+	}
+	if config.AdditionalArgs != nil {
+		args = append(args, config.AdditionalArgs...)
+	}
 
+	if err := utils.RunExecutable("terraform", args...); err != nil {
+		return errors.Wrap(err, "failed to execute terraform plan")
+	}
+
+	if len(config.PlanFile) == 0 {
+		log.Entry().Debug("no plan file configured, skipping plan evaluation")
+		return nil
+	}
+
+	var planJSON bytes.Buffer
+	stdout := utils.GetStdout()
+	utils.Stdout(&planJSON)
+	err := utils.RunExecutable("terraform", "show", "-json", config.PlanFile)
+	utils.Stdout(stdout)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render plan %v as JSON", config.PlanFile)
+	}
+
+	if err := utils.FileWrite(terraformPlanJSONFile(config.PlanFile), planJSON.Bytes(), 0666); err != nil {
+		return errors.Wrapf(err, "failed to write JSON rendering of plan %v", config.PlanFile)
+	}
+
+	summary, err := summarizeTerraformPlan(planJSON.Bytes())
+	if err != nil {
+		return err
+	}
+	log.Entry().Infof("terraform plan: %v to add, %v to change, %v to destroy", summary.add, summary.change, summary.destroy)
+
+	commonPipelineEnvironment.custom.terraformPlanFile = config.PlanFile
+	commonPipelineEnvironment.custom.terraformResourcesToAdd = summary.add
+	commonPipelineEnvironment.custom.terraformResourcesToChange = summary.change
+	commonPipelineEnvironment.custom.terraformResourcesToDestroy = summary.destroy
+
+	return checkTerraformPlanLimits(config, summary)
+}
+
+func runTerraformApply(config *terraformExecuteOptions, utils terraformExecuteUtils) error {
+	args := []string{"apply", "-auto-approve"}
+
+	usePlanFile := len(config.PlanFile) > 0
+	if usePlanFile {
+		exists, err := utils.FileExists(config.PlanFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check for plan file %v", config.PlanFile)
+		}
+		if !exists {
+			// applying without the stored plan would apply changes which have not been checked against the limits
+			log.SetErrorCategory(log.ErrorConfiguration)
+			return fmt.Errorf("plan file %v not found, run terraform plan in the same workspace first or remove the planFile configuration in order to apply without a stored plan", config.PlanFile)
+		}
+	}
+
+	if usePlanFile {
+		// variables are already contained in the stored plan and must not be passed again
+		log.Entry().Infof("applying stored plan %v", config.PlanFile)
+	} else if config.TerraformSecrets != "" {
+		args = append(args, fmt.Sprintf("-var-file=%s", config.TerraformSecrets))
+	}
 	if config.AdditionalArgs != nil {
 		args = append(args, config.AdditionalArgs...)
 	}
+	if usePlanFile {
+		args = append(args, config.PlanFile)
+	}
 
-	utils.RunExecutable("terraform", args...)
+	if err := utils.RunExecutable("terraform", args...); err != nil {
+		return errors.Wrap(err, "failed to execute terraform apply")
+	}
+	return nil
+}
+
+func summarizeTerraformPlan(planJSON []byte) (terraformPlanSummary, error) {
+	summary := terraformPlanSummary{}
+	plan := terraformPlan{}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return summary, errors.Wrap(err, "failed to parse terraform plan")
+	}
 
+	for _, resourceChange := range plan.ResourceChanges {
+		actions := strings.Join(resourceChange.Change.Actions, ",")
+		switch actions {
+		case "create":
+			summary.add++
+		case "update":
+			summary.change++
+		case "delete":
+			summary.destroy++
+		case "delete,create", "create,delete":
+			// a replacement counts as add and destroy, the same as terraform does it
+			summary.add++
+			summary.destroy++
+		default:
+			log.Entry().Debugf("ignoring actions '%v' of resource %v", actions, resourceChange.Address)
+		}
+	}
+	return summary, nil
+}
+
+func checkTerraformPlanLimits(config *terraformExecuteOptions, summary terraformPlanSummary) error {
+	violations := []string{}
+	if config.MaxResourcesToAdd >= 0 && summary.add > config.MaxResourcesToAdd {
+		violations = append(violations, fmt.Sprintf("%v resources to add (max. %v)", summary.add, config.MaxResourcesToAdd))
+	}
+	if config.MaxResourcesToChange >= 0 && summary.change > config.MaxResourcesToChange {
+		violations = append(violations, fmt.Sprintf("%v resources to change (max. %v)", summary.change, config.MaxResourcesToChange))
+	}
+	if config.MaxResourcesToDestroy >= 0 && summary.destroy > config.MaxResourcesToDestroy {
+		violations = append(violations, fmt.Sprintf("%v resources to destroy (max. %v)", summary.destroy, config.MaxResourcesToDestroy))
+	}
+	if len(violations) > 0 {
+		log.SetErrorCategory(log.ErrorCompliance)
+		return fmt.Errorf("terraform plan exceeds the configured limits: %v", strings.Join(violations, ", "))
+	}
 	return nil
 }
+
+func terraformPlanJSONFile(planFile string) string {
+	return planFile + ".json"
+}
@@ -5,18 +5,64 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperenv"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type terraformExecuteOptions struct {
-	Command          string   `json:"command,omitempty"`
-	TerraformSecrets string   `json:"terraformSecrets,omitempty"`
-	AdditionalArgs   []string `json:"additionalArgs,omitempty"`
+	Command               string   `json:"command,omitempty"`
+	TerraformSecrets      string   `json:"terraformSecrets,omitempty"`
+	AdditionalArgs        []string `json:"additionalArgs,omitempty"`
+	Init                  bool     `json:"init,omitempty"`
+	BackendConfigFiles    []string `json:"backendConfigFiles,omitempty"`
+	Workspace             string   `json:"workspace,omitempty"`
+	PlanFile              string   `json:"planFile,omitempty"`
+	MaxResourcesToAdd     int      `json:"maxResourcesToAdd,omitempty"`
+	MaxResourcesToChange  int      `json:"maxResourcesToChange,omitempty"`
+	MaxResourcesToDestroy int      `json:"maxResourcesToDestroy,omitempty"`
+}
+
+type terraformExecuteCommonPipelineEnvironment struct {
+	custom struct {
+		terraformPlanFile           string
+		terraformResourcesToAdd     int
+		terraformResourcesToChange  int
+		terraformResourcesToDestroy int
+	}
+}
+
+func (p *terraformExecuteCommonPipelineEnvironment) persist(path, resourceName string) {
+	content := []struct {
+		category string
+		name     string
+		value    interface{}
+	}{
+		{category: "custom", name: "terraformPlanFile", value: p.custom.terraformPlanFile},
+		{category: "custom", name: "terraformResourcesToAdd", value: p.custom.terraformResourcesToAdd},
+		{category: "custom", name: "terraformResourcesToChange", value: p.custom.terraformResourcesToChange},
+		{category: "custom", name: "terraformResourcesToDestroy", value: p.custom.terraformResourcesToDestroy},
+	}
+
+	errCount := 0
+	for _, param := range content {
+		err := piperenv.SetResourceParameter(path, resourceName, filepath.Join(param.category, param.name), param.value)
+		if err != nil {
+			log.Entry().WithError(err).Error("Error persisting piper environment.")
+			errCount++
+		}
+	}
+	if errCount > 0 {
+		log.Entry().Fatal("failed to persist Piper environment")
+	}
 }
 
 // TerraformExecuteCommand Executes Terraform
@@ -26,11 +72,19 @@ func TerraformExecuteCommand() *cobra.Command {
 	metadata := terraformExecuteMetadata()
 	var stepConfig terraformExecuteOptions
 	var startTime time.Time
+	var commonPipelineEnvironment terraformExecuteCommonPipelineEnvironment
 
 	var createTerraformExecuteCmd = &cobra.Command{
 		Use:   STEP_NAME,
 		Short: "Executes Terraform",
-		Long:  `This step executes the terraform binary with the given command, and is able to fetch additional variables from vault.`,
+		Long: `This step executes the terraform binary with the given command, and is able to fetch additional variables from vault.
+
+The plan workflow is opt-in via the parameter ` + "`" + `planFile` + "`" + `: for the command ` + "`" + `plan` + "`" + ` the resulting plan is stored in this file together with its JSON rendering.
+The number of resources to be added, changed and destroyed is written to the commonPipelineEnvironment
+and the step fails in case one of the configured limits (e.g. ` + "`" + `maxResourcesToDestroy` + "`" + `) is exceeded.
+A subsequent execution with command ` + "`" + `apply` + "`" + ` consumes exactly this stored plan.
+
+The plan file is not transferred between stages or agents, thus ` + "`" + `plan` + "`" + ` and ` + "`" + `apply` + "`" + ` need to run in the same workspace.`,
 		PreRunE: func(cmd *cobra.Command, _ []string) error {
 			startTime = time.Now()
 			log.SetStepName(STEP_NAME)
@@ -43,6 +97,17 @@ func TerraformExecuteCommand() *cobra.Command {
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 
@@ -58,14 +123,21 @@ func TerraformExecuteCommand() *cobra.Command {
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
+				commonPipelineEnvironment.persist(GeneralConfig.EnvRootPath, "commonPipelineEnvironment")
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
 			telemetry.Initialize(GeneralConfig.NoTelemetry, STEP_NAME)
-			terraformExecute(stepConfig, &telemetryData)
+			terraformExecute(stepConfig, &telemetryData, &commonPipelineEnvironment)
 			telemetryData.ErrorCode = "0"
 			log.Entry().Info("SUCCESS")
 		},
@@ -76,9 +148,16 @@ func TerraformExecuteCommand() *cobra.Command {
 }
 
 func addTerraformExecuteFlags(cmd *cobra.Command, stepConfig *terraformExecuteOptions) {
-	cmd.Flags().StringVar(&stepConfig.Command, "command", `plan`, "")
-	cmd.Flags().StringVar(&stepConfig.TerraformSecrets, "terraformSecrets", os.Getenv("PIPER_terraformSecrets"), "")
-	cmd.Flags().StringSliceVar(&stepConfig.AdditionalArgs, "additionalArgs", []string{}, "")
+	cmd.Flags().StringVar(&stepConfig.Command, "command", `plan`, "The terraform command which should be executed, e.g. `plan` or `apply`.")
+	cmd.Flags().StringVar(&stepConfig.TerraformSecrets, "terraformSecrets", os.Getenv("PIPER_terraformSecrets"), "Path to a file containing terraform variables which are passed via `-var-file`.")
+	cmd.Flags().StringSliceVar(&stepConfig.AdditionalArgs, "additionalArgs", []string{}, "Additional arguments which are passed to the terraform command.")
+	cmd.Flags().BoolVar(&stepConfig.Init, "init", false, "Defines if `terraform init` is executed before the actual command. Alternatively `terraform init` can be executed via the command `init`.")
+	cmd.Flags().StringSliceVar(&stepConfig.BackendConfigFiles, "backendConfigFiles", []string{}, "List of files which are passed to `terraform init` via `-backend-config`.")
+	cmd.Flags().StringVar(&stepConfig.Workspace, "workspace", os.Getenv("PIPER_workspace"), "Name of the terraform workspace which is selected before the command is executed. The workspace is created in case it does not exist yet.")
+	cmd.Flags().StringVar(&stepConfig.PlanFile, "planFile", os.Getenv("PIPER_planFile"), "Path of the binary plan file which is written by `plan` and consumed by `apply`, e.g. `.pipeline/terraform/tfplan`. The JSON rendering of the plan is stored next to it with suffix `.json`. In case a plan file is configured but does not exist, `apply` fails. Without a plan file `apply` runs without a stored plan.")
+	cmd.Flags().IntVar(&stepConfig.MaxResourcesToAdd, "maxResourcesToAdd", -1, "Maximum number of resources which may be added by a plan. A negative value disables the check.")
+	cmd.Flags().IntVar(&stepConfig.MaxResourcesToChange, "maxResourcesToChange", -1, "Maximum number of resources which may be changed by a plan. A negative value disables the check.")
+	cmd.Flags().IntVar(&stepConfig.MaxResourcesToDestroy, "maxResourcesToDestroy", -1, "Maximum number of resources which may be destroyed by a plan. Set it to `0` in order to fail on any destroy. A negative value disables the check.")
 
 }
 
@@ -123,11 +202,81 @@ func terraformExecuteMetadata() config.StepData {
 						Mandatory:   false,
 						Aliases:     []config.Alias{},
 					},
+					{
+						Name:        "init",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "bool",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "backendConfigFiles",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "[]string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "workspace",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "planFile",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "maxResourcesToAdd",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "int",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "maxResourcesToChange",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "int",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "maxResourcesToDestroy",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "int",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
 				},
 			},
 			Containers: []config.Container{
 				{Name: "terraform", Image: "hashicorp/terraform:0.14.7"},
 			},
+			Outputs: config.StepOutputs{
+				Resources: []config.StepResources{
+					{
+						Name: "commonPipelineEnvironment",
+						Type: "piperEnvironment",
+						Parameters: []map[string]interface{}{
+							{"Name": "custom/terraformPlanFile"},
+							{"Name": "custom/terraformResourcesToAdd"},
+							{"Name": "custom/terraformResourcesToChange"},
+							{"Name": "custom/terraformResourcesToDestroy"},
+						},
+					},
+				},
+			},
 		},
 	}
 	return theMetaData
@@ -66,6 +66,7 @@ func TestRunTerraformExecute(t *testing.T) {
 	}
 
 	for i, test := range tt {
+		test := test
 		t.Run(fmt.Sprintf("That arguemtns are correct %d", i), func(t *testing.T) {
 			t.Parallel()
 			// init
@@ -73,7 +74,7 @@ func TestRunTerraformExecute(t *testing.T) {
 			utils := newTerraformExecuteTestsUtils()
 
 			// test
-			err := runTerraformExecute(&config, nil, utils)
+			err := runTerraformExecute(&config, nil, utils, &terraformExecuteCommonPipelineEnvironment{})
 
 			// assert
 			assert.NoError(t, err)
@@ -81,3 +82,188 @@ func TestRunTerraformExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestRunTerraformExecuteWorkflow(t *testing.T) {
+	t.Parallel()
+
+	planJSON := `{"resource_changes":[
+		{"address":"a.one","change":{"actions":["create"]}},
+		{"address":"a.two","change":{"actions":["update"]}},
+		{"address":"a.three","change":{"actions":["delete","create"]}},
+		{"address":"a.four","change":{"actions":["no-op"]}}
+	]}`
+
+	t.Run("init with backend config and workspace selection", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{
+			Command:            "validate",
+			Init:               true,
+			BackendConfigFiles: []string{"backend.hcl", "prod.hcl"},
+			Workspace:          "prod",
+		}
+		utils := newTerraformExecuteTestsUtils()
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &terraformExecuteCommonPipelineEnvironment{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []mock.ExecCall{
+			{Exec: "terraform", Params: []string{"init", "-input=false", "-backend-config=backend.hcl", "-backend-config=prod.hcl"}},
+			{Exec: "terraform", Params: []string{"workspace", "select", "prod"}},
+			{Exec: "terraform", Params: []string{"validate"}},
+		}, utils.Calls)
+	})
+
+	t.Run("init command runs init once", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{
+			Command:            "init",
+			Init:               true,
+			BackendConfigFiles: []string{"backend.hcl"},
+			AdditionalArgs:     []string{"-upgrade"},
+		}
+		utils := newTerraformExecuteTestsUtils()
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &terraformExecuteCommonPipelineEnvironment{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, []mock.ExecCall{
+			{Exec: "terraform", Params: []string{"init", "-input=false", "-backend-config=backend.hcl", "-upgrade"}},
+		}, utils.Calls)
+	})
+
+	t.Run("workspace is created if it does not exist", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{Command: "validate", Workspace: "prod"}
+		utils := newTerraformExecuteTestsUtils()
+		utils.ShouldFailOnCommand = map[string]error{"terraform workspace select prod": fmt.Errorf("not found")}
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &terraformExecuteCommonPipelineEnvironment{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, mock.ExecCall{Exec: "terraform", Params: []string{"workspace", "new", "prod"}}, utils.Calls[1])
+	})
+
+	t.Run("init failure", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{Command: "plan", Init: true}
+		utils := newTerraformExecuteTestsUtils()
+		utils.ShouldFailOnCommand = map[string]error{"terraform init -input=false": fmt.Errorf("no backend")}
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &terraformExecuteCommonPipelineEnvironment{})
+
+		// assert
+		assert.EqualError(t, err, "failed to initialize terraform: no backend")
+		assert.Len(t, utils.Calls, 1)
+	})
+
+	t.Run("plan is stored and evaluated", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{
+			Command:               "plan",
+			PlanFile:              ".pipeline/terraform/tfplan",
+			MaxResourcesToAdd:     -1,
+			MaxResourcesToChange:  -1,
+			MaxResourcesToDestroy: -1,
+		}
+		utils := newTerraformExecuteTestsUtils()
+		utils.StdoutReturn = map[string]string{"terraform show -json .pipeline/terraform/tfplan": planJSON}
+		cpe := terraformExecuteCommonPipelineEnvironment{}
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &cpe)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, mock.ExecCall{Exec: "terraform", Params: []string{"plan", "-out=.pipeline/terraform/tfplan"}}, utils.Calls[0])
+		assert.Equal(t, mock.ExecCall{Exec: "terraform", Params: []string{"show", "-json", ".pipeline/terraform/tfplan"}}, utils.Calls[1])
+		content, err := utils.FileRead(".pipeline/terraform/tfplan.json")
+		assert.NoError(t, err)
+		assert.Equal(t, planJSON, string(content))
+		assert.Equal(t, ".pipeline/terraform/tfplan", cpe.custom.terraformPlanFile)
+		assert.Equal(t, 2, cpe.custom.terraformResourcesToAdd)
+		assert.Equal(t, 1, cpe.custom.terraformResourcesToChange)
+		assert.Equal(t, 1, cpe.custom.terraformResourcesToDestroy)
+	})
+
+	t.Run("plan exceeds limits", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{
+			Command:               "plan",
+			PlanFile:              "tfplan",
+			MaxResourcesToAdd:     1,
+			MaxResourcesToChange:  -1,
+			MaxResourcesToDestroy: 0,
+		}
+		utils := newTerraformExecuteTestsUtils()
+		utils.StdoutReturn = map[string]string{"terraform show -json tfplan": planJSON}
+		cpe := terraformExecuteCommonPipelineEnvironment{}
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &cpe)
+
+		// assert
+		assert.EqualError(t, err, "terraform plan exceeds the configured limits: 2 resources to add (max. 1), 1 resources to destroy (max. 0)")
+		assert.Equal(t, 1, cpe.custom.terraformResourcesToDestroy)
+	})
+
+	t.Run("apply consumes stored plan", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{
+			Command:          "apply",
+			PlanFile:         ".pipeline/terraform/tfplan",
+			TerraformSecrets: "/tmp/test",
+			AdditionalArgs:   []string{"-arg1"},
+		}
+		utils := newTerraformExecuteTestsUtils()
+		utils.AddFile(".pipeline/terraform/tfplan", []byte("binary plan"))
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &terraformExecuteCommonPipelineEnvironment{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, mock.ExecCall{Exec: "terraform", Params: []string{"apply", "-auto-approve", "-arg1", ".pipeline/terraform/tfplan"}}, utils.Calls[0])
+	})
+
+	t.Run("apply fails for missing plan", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{Command: "apply", PlanFile: ".pipeline/terraform/tfplan"}
+		utils := newTerraformExecuteTestsUtils()
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &terraformExecuteCommonPipelineEnvironment{})
+
+		// assert
+		assert.EqualError(t, err, "plan file .pipeline/terraform/tfplan not found, run terraform plan in the same workspace first or remove the planFile configuration in order to apply without a stored plan")
+		assert.Empty(t, utils.Calls)
+	})
+
+	t.Run("apply failure", func(t *testing.T) {
+		t.Parallel()
+		// init
+		config := terraformExecuteOptions{Command: "apply"}
+		utils := newTerraformExecuteTestsUtils()
+		utils.ShouldFailOnCommand = map[string]error{"terraform apply -auto-approve": fmt.Errorf("error")}
+
+		// test
+		err := runTerraformExecute(&config, nil, utils, &terraformExecuteCommonPipelineEnvironment{})
+
+		// assert
+		assert.EqualError(t, err, "failed to execute terraform apply: error")
+	})
+}
@@ -8,8 +8,11 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -17,13 +20,13 @@ type transportRequestUploadCTSOptions struct {
 	Description            string   `json:"description,omitempty"`
 	Endpoint               string   `json:"endpoint,omitempty"`
 	Client                 string   `json:"client,omitempty"`
-	Username               string   `json:"username,omitempty"`
-	Password               string   `json:"password,omitempty"`
-	ApplicationName        string   `json:"applicationName,omitempty"`
-	AbapPackage            string   `json:"abapPackage,omitempty"`
+	Username               string   `json:"username,omitempty" validate:"required"`
+	Password               string   `json:"password,omitempty" validate:"required"`
+	ApplicationName        string   `json:"applicationName,omitempty" validate:"required"`
+	AbapPackage            string   `json:"abapPackage,omitempty" validate:"required"`
 	OsDeployUser           string   `json:"osDeployUser,omitempty"`
 	DeployConfigFile       string   `json:"deployConfigFile,omitempty"`
-	TransportRequestID     string   `json:"transportRequestId,omitempty"`
+	TransportRequestID     string   `json:"transportRequestId,omitempty" validate:"required"`
 	DeployToolDependencies []string `json:"deployToolDependencies,omitempty"`
 	NpmInstallOpts         []string `json:"npmInstallOpts,omitempty"`
 }
@@ -52,11 +55,22 @@ func TransportRequestUploadCTSCommand() *cobra.Command {
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Username)
 			log.RegisterSecret(stepConfig.Password)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -69,9 +83,15 @@ func TransportRequestUploadCTSCommand() *cobra.Command {
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
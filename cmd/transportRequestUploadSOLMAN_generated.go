@@ -9,21 +9,24 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperenv"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type transportRequestUploadSOLMANOptions struct {
-	Endpoint              string   `json:"endpoint,omitempty"`
-	Username              string   `json:"username,omitempty"`
-	Password              string   `json:"password,omitempty"`
-	ApplicationID         string   `json:"applicationId,omitempty"`
+	Endpoint              string   `json:"endpoint,omitempty" validate:"required"`
+	Username              string   `json:"username,omitempty" validate:"required"`
+	Password              string   `json:"password,omitempty" validate:"required"`
+	ApplicationID         string   `json:"applicationId,omitempty" validate:"required"`
 	ChangeDocumentID      string   `json:"changeDocumentId,omitempty"`
 	TransportRequestID    string   `json:"transportRequestId,omitempty"`
-	FilePath              string   `json:"filePath,omitempty"`
-	CmClientOpts          []string `json:"cmClientOpts,omitempty"`
+	FilePath              string   `json:"filePath,omitempty" validate:"required"`
+	CmClientOpts          []string `json:"cmClientOpts,omitempty" validate:"required"`
 	GitFrom               string   `json:"gitFrom,omitempty"`
 	GitTo                 string   `json:"gitTo,omitempty"`
 	ChangeDocumentLabel   string   `json:"changeDocumentLabel,omitempty"`
@@ -87,11 +90,22 @@ The application ID specifies how the file needs to be handled on server side.`,
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.Username)
 			log.RegisterSecret(stepConfig.Password)
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -104,10 +118,16 @@ The application ID specifies how the file needs to be handled on server side.`,
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				commonPipelineEnvironment.persist(GeneralConfig.EnvRootPath, "commonPipelineEnvironment")
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
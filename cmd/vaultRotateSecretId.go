@@ -1,12 +1,13 @@
 package cmd
 
 import (
-	"net/http"
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
 
-	"github.com/SAP/jenkins-library/pkg/jenkins"
+	"github.com/SAP/jenkins-library/pkg/secretstore"
 	"github.com/SAP/jenkins-library/pkg/vault"
 
 	"github.com/SAP/jenkins-library/pkg/log"
@@ -23,8 +24,8 @@ type vaultRotateSecretIDUtils interface {
 
 type vaultRotateSecretIDUtilsBundle struct {
 	*vault.Client
-	config     *vaultRotateSecretIdOptions
-	updateFunc func(config *vaultRotateSecretIdOptions, secretID string) error
+	config *vaultRotateSecretIdOptions
+	store  secretstore.SecretStore
 }
 
 func (v vaultRotateSecretIDUtilsBundle) GetConfig() *vaultRotateSecretIdOptions {
@@ -32,11 +33,18 @@ func (v vaultRotateSecretIDUtilsBundle) GetConfig() *vaultRotateSecretIdOptions
 }
 
 func (v vaultRotateSecretIDUtilsBundle) UpdateSecretInStore(config *vaultRotateSecretIdOptions, secretID string) error {
-	return v.updateFunc(config, secretID)
+	return v.store.SetSecret(config.VaultAppRoleSecretTokenCredentialsID, secretID)
 }
 
 func vaultRotateSecretId(config vaultRotateSecretIdOptions, telemetryData *telemetry.CustomData) {
 
+	// fail before a new secret ID is generated in case it cannot be stored
+	store, err := newVaultSecretStore(&config)
+	if err != nil {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		log.Entry().WithError(err).Fatal("invalid secret store configuration")
+	}
+
 	vaultConfig := &vault.Config{
 		Config: &api.Config{
 			Address: config.VaultServerURL,
@@ -50,9 +58,9 @@ func vaultRotateSecretId(config vaultRotateSecretIdOptions, telemetryData *telem
 	defer client.MustRevokeToken()
 
 	utils := vaultRotateSecretIDUtilsBundle{
-		Client:     &client,
-		config:     &config,
-		updateFunc: writeVaultSecretIDToStore,
+		Client: &client,
+		config: &config,
+		store:  store,
 	}
 
 	err = runVaultRotateSecretID(utils)
@@ -91,24 +99,23 @@ func runVaultRotateSecretID(utils vaultRotateSecretIDUtils) error {
 	}
 
 	if err = utils.UpdateSecretInStore(config, newSecretID); err != nil {
-		log.Entry().WithError(err).Warnf("Could not write secret back to secret store %s", config.SecretStore)
+		return errors.Wrapf(err, "could not write secret back to secret store %s", config.SecretStore)
 	}
 	log.Entry().Infof("Secret has been successfully updated in secret store %s", config.SecretStore)
 	return nil
 
 }
 
-func writeVaultSecretIDToStore(config *vaultRotateSecretIdOptions, secretID string) error {
+func newVaultSecretStore(config *vaultRotateSecretIdOptions) (secretstore.SecretStore, error) {
 	switch config.SecretStore {
 	case "jenkins":
-		instance, err := jenkins.Instance(&http.Client{}, config.JenkinsURL, config.JenkinsUsername, config.JenkinsToken)
-		if err != nil {
-			log.Entry().Warn("Could not write secret ID back to jenkins")
-			return err
-		}
-		credManager := jenkins.NewCredentialsManager(instance)
-		credential := jenkins.StringCredentials{ID: config.VaultAppRoleSecretTokenCredentialsID, Secret: secretID}
-		return jenkins.UpdateCredential(credManager, config.JenkinsCredentialDomain, credential)
+		return &secretstore.JenkinsStore{URL: config.JenkinsURL, Username: config.JenkinsUsername, Token: config.JenkinsToken, Domain: config.JenkinsCredentialDomain}, nil
+	case "github":
+		return secretstore.NewGitHubStore(config.GithubToken, config.GithubAPIURL, config.Owner, config.Repository, config.GithubSecretVisibility)
+	case "azureDevOps":
+		return secretstore.NewAzureDevOpsStore(config.AzureDevOpsOrganizationURL, config.AzureDevOpsProject, config.AzureDevOpsVariableGroupID, config.AzureDevOpsToken), nil
+	case "kubernetes":
+		return secretstore.NewKubernetesStore(config.KubernetesAPIServer, config.KubernetesNamespace, config.KubernetesSecretKey, config.KubernetesToken, config.KubernetesSkipTLSVerification), nil
 	}
-	return nil
+	return nil, fmt.Errorf("secret store '%v' is not supported, supported are: jenkins, github, azureDevOps, kubernetes", config.SecretStore)
 }
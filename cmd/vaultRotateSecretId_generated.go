@@ -8,19 +8,36 @@ import (
 	"time"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 type vaultRotateSecretIdOptions struct {
-	SecretStore                          string `json:"secretStore,omitempty"`
+	SecretStore                          string `json:"secretStore,omitempty" validate:"possible-values=jenkins github azureDevOps kubernetes"`
 	JenkinsURL                           string `json:"jenkinsUrl,omitempty"`
 	JenkinsCredentialDomain              string `json:"jenkinsCredentialDomain,omitempty"`
 	JenkinsUsername                      string `json:"jenkinsUsername,omitempty"`
 	JenkinsToken                         string `json:"jenkinsToken,omitempty"`
-	VaultAppRoleSecretTokenCredentialsID string `json:"vaultAppRoleSecretTokenCredentialsId,omitempty"`
-	VaultServerURL                       string `json:"vaultServerUrl,omitempty"`
+	GithubAPIURL                         string `json:"githubApiUrl,omitempty"`
+	GithubToken                          string `json:"githubToken,omitempty"`
+	Owner                                string `json:"owner,omitempty"`
+	Repository                           string `json:"repository,omitempty"`
+	GithubSecretVisibility               string `json:"githubSecretVisibility,omitempty" validate:"possible-values=all private"`
+	AzureDevOpsOrganizationURL           string `json:"azureDevOpsOrganizationUrl,omitempty"`
+	AzureDevOpsProject                   string `json:"azureDevOpsProject,omitempty"`
+	AzureDevOpsVariableGroupID           int    `json:"azureDevOpsVariableGroupId,omitempty"`
+	AzureDevOpsToken                     string `json:"azureDevOpsToken,omitempty"`
+	KubernetesAPIServer                  string `json:"kubernetesApiServer,omitempty"`
+	KubernetesNamespace                  string `json:"kubernetesNamespace,omitempty"`
+	KubernetesSecretKey                  string `json:"kubernetesSecretKey,omitempty"`
+	KubernetesToken                      string `json:"kubernetesToken,omitempty"`
+	KubernetesSkipTLSVerification        bool   `json:"kubernetesSkipTlsVerification,omitempty"`
+	VaultAppRoleSecretTokenCredentialsID string `json:"vaultAppRoleSecretTokenCredentialsId,omitempty" validate:"required"`
+	VaultServerURL                       string `json:"vaultServerUrl,omitempty" validate:"required"`
 	VaultNamespace                       string `json:"vaultNamespace,omitempty"`
 	DaysBeforeExpiry                     int    `json:"daysBeforeExpiry,omitempty"`
 }
@@ -49,11 +66,25 @@ func VaultRotateSecretIdCommand() *cobra.Command {
 			err := PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			log.RegisterSecret(stepConfig.JenkinsURL)
 			log.RegisterSecret(stepConfig.JenkinsUsername)
 			log.RegisterSecret(stepConfig.JenkinsToken)
+			log.RegisterSecret(stepConfig.GithubToken)
+			log.RegisterSecret(stepConfig.AzureDevOpsToken)
+			log.RegisterSecret(stepConfig.KubernetesToken)
+
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
 
 			if len(GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook(GeneralConfig.HookConfig.SentryConfig.Dsn, GeneralConfig.CorrelationID)
@@ -67,9 +98,15 @@ func VaultRotateSecretIdCommand() *cobra.Command {
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -90,7 +127,21 @@ func addVaultRotateSecretIdFlags(cmd *cobra.Command, stepConfig *vaultRotateSecr
 	cmd.Flags().StringVar(&stepConfig.JenkinsCredentialDomain, "jenkinsCredentialDomain", `_`, "The jenkins credential domain which should be used")
 	cmd.Flags().StringVar(&stepConfig.JenkinsUsername, "jenkinsUsername", os.Getenv("PIPER_jenkinsUsername"), "The jenkins username")
 	cmd.Flags().StringVar(&stepConfig.JenkinsToken, "jenkinsToken", os.Getenv("PIPER_jenkinsToken"), "The jenkins token")
-	cmd.Flags().StringVar(&stepConfig.VaultAppRoleSecretTokenCredentialsID, "vaultAppRoleSecretTokenCredentialsId", os.Getenv("PIPER_vaultAppRoleSecretTokenCredentialsId"), "The Jenkins credential ID for the Vault AppRole Secret ID credential")
+	cmd.Flags().StringVar(&stepConfig.GithubAPIURL, "githubApiUrl", `https://api.github.com`, "The GitHub API url, used in case `secretStore` is `github`.")
+	cmd.Flags().StringVar(&stepConfig.GithubToken, "githubToken", os.Getenv("PIPER_githubToken"), "GitHub personal access token with permission to write Actions secrets, used in case `secretStore` is `github`.")
+	cmd.Flags().StringVar(&stepConfig.Owner, "owner", os.Getenv("PIPER_owner"), "Name of the GitHub organization, used in case `secretStore` is `github`.")
+	cmd.Flags().StringVar(&stepConfig.Repository, "repository", os.Getenv("PIPER_repository"), "Name of the GitHub repository, used in case `secretStore` is `github` and the secret is a repository secret.")
+	cmd.Flags().StringVar(&stepConfig.GithubSecretVisibility, "githubSecretVisibility", os.Getenv("PIPER_githubSecretVisibility"), "If set, an organization secret with the given visibility is written instead of a repository secret, used in case `secretStore` is `github`.")
+	cmd.Flags().StringVar(&stepConfig.AzureDevOpsOrganizationURL, "azureDevOpsOrganizationUrl", os.Getenv("PIPER_azureDevOpsOrganizationUrl"), "The url of the Azure DevOps organization, e.g. `https://dev.azure.com/myOrganization`, used in case `secretStore` is `azureDevOps`.")
+	cmd.Flags().StringVar(&stepConfig.AzureDevOpsProject, "azureDevOpsProject", os.Getenv("PIPER_azureDevOpsProject"), "The Azure DevOps project containing the variable group, used in case `secretStore` is `azureDevOps`.")
+	cmd.Flags().IntVar(&stepConfig.AzureDevOpsVariableGroupID, "azureDevOpsVariableGroupId", 0, "The ID of the Azure DevOps variable group, used in case `secretStore` is `azureDevOps`.")
+	cmd.Flags().StringVar(&stepConfig.AzureDevOpsToken, "azureDevOpsToken", os.Getenv("PIPER_azureDevOpsToken"), "Azure DevOps personal access token with permission to manage variable groups, used in case `secretStore` is `azureDevOps`.")
+	cmd.Flags().StringVar(&stepConfig.KubernetesAPIServer, "kubernetesApiServer", os.Getenv("PIPER_kubernetesApiServer"), "The url of the Kubernetes API server, used in case `secretStore` is `kubernetes`.")
+	cmd.Flags().StringVar(&stepConfig.KubernetesNamespace, "kubernetesNamespace", `default`, "The Kubernetes namespace containing the secret, used in case `secretStore` is `kubernetes`.")
+	cmd.Flags().StringVar(&stepConfig.KubernetesSecretKey, "kubernetesSecretKey", `secretId`, "The key within the Kubernetes secret which contains the secret ID, used in case `secretStore` is `kubernetes`.")
+	cmd.Flags().StringVar(&stepConfig.KubernetesToken, "kubernetesToken", os.Getenv("PIPER_kubernetesToken"), "Token of a Kubernetes service account with permission to update secrets, used in case `secretStore` is `kubernetes`.")
+	cmd.Flags().BoolVar(&stepConfig.KubernetesSkipTLSVerification, "kubernetesSkipTlsVerification", false, "Disables the verification of the TLS certificate of the Kubernetes API server, used in case `secretStore` is `kubernetes`.")
+	cmd.Flags().StringVar(&stepConfig.VaultAppRoleSecretTokenCredentialsID, "vaultAppRoleSecretTokenCredentialsId", os.Getenv("PIPER_vaultAppRoleSecretTokenCredentialsId"), "The Jenkins credential ID for the Vault AppRole Secret ID credential. For other secret stores this is the name of the secret or variable which contains the Secret ID.")
 	cmd.Flags().StringVar(&stepConfig.VaultServerURL, "vaultServerUrl", os.Getenv("PIPER_vaultServerUrl"), "The URL for the Vault server to use")
 	cmd.Flags().StringVar(&stepConfig.VaultNamespace, "vaultNamespace", os.Getenv("PIPER_vaultNamespace"), "The vault namespace that should be used (optional)")
 	cmd.Flags().IntVar(&stepConfig.DaysBeforeExpiry, "daysBeforeExpiry", 15, "The amount of days before expiry until the secret ID gets rotated")
@@ -111,12 +162,13 @@ func vaultRotateSecretIdMetadata() config.StepData {
 			Inputs: config.StepInputs{
 				Parameters: []config.StepParameters{
 					{
-						Name:        "secretStore",
-						ResourceRef: []config.ResourceReference{},
-						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
-						Type:        "string",
-						Mandatory:   false,
-						Aliases:     []config.Alias{},
+						Name:           "secretStore",
+						ResourceRef:    []config.ResourceReference{},
+						Scope:          []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:           "string",
+						Mandatory:      false,
+						Aliases:        []config.Alias{},
+						PossibleValues: []interface{}{"jenkins", "github", "azureDevOps", "kubernetes"},
 					},
 					{
 						Name: "jenkinsUrl",
@@ -168,6 +220,147 @@ func vaultRotateSecretIdMetadata() config.StepData {
 						Mandatory: false,
 						Aliases:   []config.Alias{{Name: "token"}},
 					},
+					{
+						Name:        "githubApiUrl",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"GENERAL", "PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name: "githubToken",
+						ResourceRef: []config.ResourceReference{
+							{
+								Name:  "",
+								Paths: []string{"$(vaultPath)/github", "$(vaultBasePath)/$(vaultPipelineName)/github", "$(vaultBasePath)/GROUP-SECRETS/github"},
+								Type:  "vaultSecret",
+							},
+						},
+						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:      "string",
+						Mandatory: false,
+						Aliases:   []config.Alias{},
+					},
+					{
+						Name: "owner",
+						ResourceRef: []config.ResourceReference{
+							{
+								Name:  "commonPipelineEnvironment",
+								Param: "github/owner",
+							},
+						},
+						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:      "string",
+						Mandatory: false,
+						Aliases:   []config.Alias{{Name: "githubOrg"}},
+					},
+					{
+						Name: "repository",
+						ResourceRef: []config.ResourceReference{
+							{
+								Name:  "commonPipelineEnvironment",
+								Param: "github/repository",
+							},
+						},
+						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:      "string",
+						Mandatory: false,
+						Aliases:   []config.Alias{{Name: "githubRepo"}},
+					},
+					{
+						Name:           "githubSecretVisibility",
+						ResourceRef:    []config.ResourceReference{},
+						Scope:          []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:           "string",
+						Mandatory:      false,
+						Aliases:        []config.Alias{},
+						PossibleValues: []interface{}{"all", "private"},
+					},
+					{
+						Name:        "azureDevOpsOrganizationUrl",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"GENERAL", "PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "azureDevOpsProject",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"GENERAL", "PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "azureDevOpsVariableGroupId",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "int",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name: "azureDevOpsToken",
+						ResourceRef: []config.ResourceReference{
+							{
+								Name:  "",
+								Paths: []string{"$(vaultPath)/azure-devops", "$(vaultBasePath)/$(vaultPipelineName)/azure-devops", "$(vaultBasePath)/GROUP-SECRETS/azure-devops"},
+								Type:  "vaultSecret",
+							},
+						},
+						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:      "string",
+						Mandatory: false,
+						Aliases:   []config.Alias{},
+					},
+					{
+						Name:        "kubernetesApiServer",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"GENERAL", "PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "kubernetesNamespace",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"GENERAL", "PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name:        "kubernetesSecretKey",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "string",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
+					{
+						Name: "kubernetesToken",
+						ResourceRef: []config.ResourceReference{
+							{
+								Name:  "",
+								Paths: []string{"$(vaultPath)/kubernetes", "$(vaultBasePath)/$(vaultPipelineName)/kubernetes", "$(vaultBasePath)/GROUP-SECRETS/kubernetes"},
+								Type:  "vaultSecret",
+							},
+						},
+						Scope:     []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:      "string",
+						Mandatory: false,
+						Aliases:   []config.Alias{},
+					},
+					{
+						Name:        "kubernetesSkipTlsVerification",
+						ResourceRef: []config.ResourceReference{},
+						Scope:       []string{"PARAMETERS", "STAGES", "STEPS"},
+						Type:        "bool",
+						Mandatory:   false,
+						Aliases:     []config.Alias{},
+					},
 					{
 						Name:        "vaultAppRoleSecretTokenCredentialsId",
 						ResourceRef: []config.ResourceReference{},
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/SAP/jenkins-library/pkg/secretstore"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,16 +15,47 @@ type mockVaultRotateSecretIDUtilsBundle struct {
 	ttl              time.Duration
 	config           *vaultRotateSecretIdOptions
 	updateFuncCalled bool
+	updateErr        error
 }
 
 func TestRunVaultRotateSecretId(t *testing.T) {
 	t.Parallel()
-	mock := &mockVaultRotateSecretIDUtilsBundle{t, "test-secret", time.Hour, getTestConfig(), false}
+	mock := &mockVaultRotateSecretIDUtilsBundle{t, "test-secret", time.Hour, getTestConfig(), false, nil}
 	runVaultRotateSecretID(mock)
 	assert.True(t, mock.updateFuncCalled)
 
 }
 
+func TestRunVaultRotateSecretIdStoreFailure(t *testing.T) {
+	t.Parallel()
+	config := getTestConfig()
+	config.SecretStore = "github"
+	mock := &mockVaultRotateSecretIDUtilsBundle{t, "test-secret", time.Hour, config, false, fmt.Errorf("forbidden")}
+	err := runVaultRotateSecretID(mock)
+	assert.EqualError(t, err, "could not write secret back to secret store github: forbidden")
+}
+
+func TestNewVaultSecretStore(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		secretStore string
+		expected    interface{}
+	}{
+		{secretStore: "jenkins", expected: &secretstore.JenkinsStore{}},
+		{secretStore: "github", expected: &secretstore.GitHubStore{}},
+		{secretStore: "azureDevOps", expected: &secretstore.AzureDevOpsStore{}},
+		{secretStore: "kubernetes", expected: &secretstore.KubernetesStore{}},
+	}
+	for _, test := range tt {
+		store, err := newVaultSecretStore(&vaultRotateSecretIdOptions{SecretStore: test.secretStore, GithubAPIURL: "https://api.github.com"})
+		assert.NoError(t, err)
+		assert.IsType(t, test.expected, store)
+	}
+
+	_, err := newVaultSecretStore(&vaultRotateSecretIdOptions{SecretStore: "gitlab"})
+	assert.EqualError(t, err, "secret store 'gitlab' is not supported, supported are: jenkins, github, azureDevOps, kubernetes")
+}
+
 func (v *mockVaultRotateSecretIDUtilsBundle) GenerateNewAppRoleSecret(secretID string, roleName string) (string, error) {
 	return v.newSecret, nil
 }
@@ -36,7 +69,7 @@ func (v *mockVaultRotateSecretIDUtilsBundle) GetAppRoleName() (string, error) {
 func (v *mockVaultRotateSecretIDUtilsBundle) UpdateSecretInStore(config *vaultRotateSecretIdOptions, secretID string) error {
 	v.updateFuncCalled = true
 	assert.Equal(v.t, v.newSecret, secretID)
-	return nil
+	return v.updateErr
 }
 func (v *mockVaultRotateSecretIDUtilsBundle) GetConfig() *vaultRotateSecretIdOptions {
 	return v.config
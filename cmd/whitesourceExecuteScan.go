@@ -540,6 +540,11 @@ func checkSecurityViolations(config *ScanOptions, scan *ws.Scan, sys whitesource
 		if err != nil {
 			errorsOccured = append(errorsOccured, fmt.Sprint(err))
 		}
+		if sarifPath, err := writeWhitesourceSarif(allAlerts, utils); err != nil {
+			log.Entry().WithError(err).Warning("failed to write SARIF report")
+		} else {
+			reportPaths = append(reportPaths, sarifPath)
+		}
 
 		if len(errorsOccured) > 0 {
 			if vulnerabilitiesCount > 0 {
@@ -715,6 +720,46 @@ func writeCustomVulnerabilityReports(config *ScanOptions, scan *ws.Scan, scanRep
 	return reportPaths, nil
 }
 
+func writeWhitesourceSarif(alerts []ws.Alert, utils whitesourceUtils) (piperutils.Path, error) {
+	report := reporting.FindingsReport{
+		StepName:       "whitesourceExecuteScan",
+		ToolName:       "WhiteSource",
+		InformationURI: "https://www.whitesourcesoftware.com",
+		Successful:     true,
+		Findings:       []reporting.Finding{},
+	}
+	for _, alert := range alerts {
+		component := alert.Library.ArtifactID
+		if len(component) == 0 {
+			component = alert.Library.Name
+		}
+		component = reporting.ComponentName(component)
+		score := vulnerabilityScore(alert)
+		report.AddFinding(reporting.Finding{
+			RuleID:           alert.Vulnerability.Name,
+			RuleName:         alert.Vulnerability.Name,
+			Message:          fmt.Sprintf("%v in %v %v: %v", alert.Vulnerability.Name, component, alert.Library.Version, alert.Vulnerability.Description),
+			Severity:         reporting.SeverityFromCVSS(score),
+			Score:            score,
+			VulnerabilityID:  alert.Vulnerability.Name,
+			Component:        component,
+			ComponentVersion: alert.Library.Version,
+			Location:         reporting.FindingLocation{File: alert.Library.Filename},
+			HelpURI:          alert.Vulnerability.URL,
+		})
+	}
+
+	sarif, err := report.ToSARIF()
+	if err != nil {
+		return piperutils.Path{}, errors.Wrapf(err, "failed to create SARIF report")
+	}
+	sarifReportPath := filepath.Join(ws.ReportsDirectory, "piper_whitesource_vulnerability_report.sarif")
+	if err := utils.FileWrite(sarifReportPath, sarif, 0666); err != nil {
+		return piperutils.Path{}, errors.Wrapf(err, "failed to write SARIF report")
+	}
+	return piperutils.Path{Name: "WhiteSource Vulnerability Report (SARIF)", Target: sarifReportPath}, nil
+}
+
 func vulnerabilityScore(alert ws.Alert) float64 {
 	if alert.Vulnerability.CVSS3Score > 0 {
 		return alert.Vulnerability.CVSS3Score
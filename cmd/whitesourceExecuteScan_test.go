@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"github.com/SAP/jenkins-library/pkg/versioning"
 	ws "github.com/SAP/jenkins-library/pkg/whitesource"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type whitesourceUtilsMock struct {
@@ -62,7 +65,17 @@ func TestNewWhitesourceUtils(t *testing.T) {
 }
 
 func TestRunWhitesourceExecuteScan(t *testing.T) {
-	t.Parallel()
+	// not parallel since the step writes its reports and links into the working directory
+	dir, err := ioutil.TempDir("", "test-whitesource-")
+	require.NoError(t, err)
+	oldCWD, _ := os.Getwd()
+	require.NoError(t, os.Chdir(dir))
+	// clean up tmp dir
+	defer func() {
+		_ = os.Chdir(oldCWD)
+		_ = os.RemoveAll(dir)
+	}()
+
 	t.Run("fails for invalid configured project token", func(t *testing.T) {
 		// init
 		config := ScanOptions{
@@ -743,6 +756,41 @@ func TestWriteCustomVulnerabilityReports(t *testing.T) {
 
 }
 
+func TestWriteWhitesourceSarif(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		alerts := []ws.Alert{
+			{
+				Vulnerability: ws.Vulnerability{Name: "CVE-2021-0001", CVSS3Score: 9.1, URL: "https://cve.example.com/CVE-2021-0001"},
+				Library:       ws.Library{ArtifactID: "log4j-core", Version: "2.14.1", Filename: "log4j-core-2.14.1.jar"},
+			},
+		}
+		utilsMock := newWhitesourceUtilsMock()
+
+		reportPath, err := writeWhitesourceSarif(alerts, utilsMock)
+
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(ws.ReportsDirectory, "piper_whitesource_vulnerability_report.sarif"), reportPath.Target)
+		content, err := utilsMock.FileRead(reportPath.Target)
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), `"ruleId": "CVE-2021-0001"`)
+		assert.Contains(t, string(content), `"level": "error"`)
+		assert.Contains(t, string(content), `"uri": "log4j-core-2.14.1.jar"`)
+		assert.Contains(t, string(content), `"componentVersion": "2.14.1"`)
+	})
+
+	t.Run("failed to write SARIF report", func(t *testing.T) {
+		utilsMock := newWhitesourceUtilsMock()
+		utilsMock.FileWriteErrors = map[string]error{
+			filepath.Join(ws.ReportsDirectory, "piper_whitesource_vulnerability_report.sarif"): fmt.Errorf("write error"),
+		}
+
+		_, err := writeWhitesourceSarif([]ws.Alert{}, utilsMock)
+		assert.EqualError(t, err, "failed to write SARIF report: write error")
+	})
+}
+
 func TestVulnerabilityScore(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,199 @@
+package blackduck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	piperhttp "github.com/SAP/jenkins-library/pkg/http"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	authenticateURL = "/api/tokens/authenticate"
+	projectsURL     = "/api/projects"
+	// pageSize is the number of items which are requested at once
+	pageSize = 100
+)
+
+// Client is the client for the Black Duck REST API which retrieves the results of Synopsys Detect scans
+type Client struct {
+	serverURL   string
+	token       string
+	bearerToken string
+	httpClient  piperhttp.Sender
+}
+
+// NewClient creates a new Black Duck client which authenticates with an API token
+func NewClient(token, serverURL string, httpClient piperhttp.Sender) *Client {
+	return &Client{
+		serverURL:  strings.TrimSuffix(serverURL, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// VulnerableComponent defines a component of a project version which is affected by a vulnerability.
+// A component affected by several vulnerabilities is contained once per vulnerability.
+type VulnerableComponent struct {
+	Name    string `json:"componentName"`
+	Version string `json:"componentVersionName"`
+	// OriginID identifies the component for its package manager, e.g. org.apache.commons:commons-text:1.9 for Maven
+	OriginID      string        `json:"componentVersionOriginId"`
+	Vulnerability Vulnerability `json:"vulnerabilityWithRemediation"`
+}
+
+// Vulnerability defines a vulnerability as returned by Black Duck
+type Vulnerability struct {
+	Name              string  `json:"vulnerabilityName"`
+	Description       string  `json:"description"`
+	Severity          string  `json:"severity"`
+	BaseScore         float64 `json:"baseScore"`
+	OverallScore      float64 `json:"overallScore"`
+	RemediationStatus string  `json:"remediationStatus"`
+}
+
+// PackageName returns the name of the component as known by its package manager,
+// e.g. the artifact ID in case of Maven. It falls back to the name of the component in Black Duck.
+func (c VulnerableComponent) PackageName() string {
+	if parts := strings.Split(c.OriginID, ":"); len(parts) >= 2 {
+		// Maven like origin: group:artifact:version
+		return parts[1]
+	}
+	if index := strings.LastIndex(c.OriginID, "/"); index > 0 {
+		// npm like origin: name/version
+		return c.OriginID[:index]
+	}
+	return c.Name
+}
+
+// Audited returns true in case the vulnerability has already been assessed in Black Duck
+func (v Vulnerability) Audited() bool {
+	switch v.RemediationStatus {
+	case "", "NEW", "NEEDS_REVIEW":
+		return false
+	}
+	return true
+}
+
+type meta struct {
+	Href string `json:"href"`
+}
+
+type project struct {
+	Name string `json:"name"`
+	Meta meta   `json:"_meta"`
+}
+
+type projectVersion struct {
+	Name string `json:"versionName"`
+	Meta meta   `json:"_meta"`
+}
+
+// GetVulnerableComponents retrieves the vulnerable components of a project version
+func (b *Client) GetVulnerableComponents(projectName, versionName string) ([]VulnerableComponent, error) {
+	versionURL, err := b.getProjectVersionURL(projectName, versionName)
+	if err != nil {
+		return nil, err
+	}
+
+	components := []VulnerableComponent{}
+	for offset := 0; ; offset += pageSize {
+		page := struct {
+			TotalCount int                   `json:"totalCount"`
+			Items      []VulnerableComponent `json:"items"`
+		}{}
+		pageURL := fmt.Sprintf("%v/vulnerable-bom-components?offset=%v&limit=%v", versionURL, offset, pageSize)
+		if err := b.sendRequest(pageURL, "application/vnd.blackducksoftware.bill-of-materials-6+json", &page); err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve vulnerable components of project '%v' version '%v'", projectName, versionName)
+		}
+		components = append(components, page.Items...)
+		if len(page.Items) == 0 || len(components) >= page.TotalCount {
+			return components, nil
+		}
+	}
+}
+
+func (b *Client) getProjectVersionURL(projectName, versionName string) (string, error) {
+	projects := struct {
+		Items []project `json:"items"`
+	}{}
+	query := url.Values{"q": []string{"name:" + projectName}, "limit": []string{fmt.Sprint(pageSize)}}
+	if err := b.sendRequest(b.serverURL+projectsURL+"?"+query.Encode(), "application/vnd.blackducksoftware.project-detail-4+json", &projects); err != nil {
+		return "", errors.Wrapf(err, "failed to retrieve project '%v'", projectName)
+	}
+	projectURL := ""
+	for _, p := range projects.Items {
+		// the query also returns projects whose name only contains the project name
+		if p.Name == projectName {
+			projectURL = p.Meta.Href
+		}
+	}
+	if len(projectURL) == 0 {
+		return "", fmt.Errorf("project '%v' not found in Black Duck", projectName)
+	}
+
+	versions := struct {
+		Items []projectVersion `json:"items"`
+	}{}
+	query = url.Values{"q": []string{"versionName:" + versionName}, "limit": []string{fmt.Sprint(pageSize)}}
+	if err := b.sendRequest(projectURL+"/versions?"+query.Encode(), "application/vnd.blackducksoftware.project-detail-4+json", &versions); err != nil {
+		return "", errors.Wrapf(err, "failed to retrieve version '%v' of project '%v'", versionName, projectName)
+	}
+	for _, v := range versions.Items {
+		if v.Name == versionName {
+			return v.Meta.Href, nil
+		}
+	}
+	return "", fmt.Errorf("version '%v' of project '%v' not found in Black Duck", versionName, projectName)
+}
+
+// authenticate exchanges the API token for a bearer token which is used for all further requests
+func (b *Client) authenticate() error {
+	if len(b.bearerToken) > 0 {
+		return nil
+	}
+	header := http.Header{}
+	header.Add("Authorization", "token "+b.token)
+	header.Add("Accept", "application/vnd.blackducksoftware.user-4+json")
+	response, err := b.httpClient.SendRequest(http.MethodPost, b.serverURL+authenticateURL, nil, header, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to authenticate at Black Duck")
+	}
+	defer response.Body.Close()
+	auth := struct {
+		BearerToken string `json:"bearerToken"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&auth); err != nil {
+		return errors.Wrap(err, "failed to parse Black Duck authentication response")
+	}
+	log.RegisterSecret(auth.BearerToken)
+	b.bearerToken = auth.BearerToken
+	return nil
+}
+
+func (b *Client) sendRequest(requestURL, mediaType string, result interface{}) error {
+	if err := b.authenticate(); err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Add("Authorization", "Bearer "+b.bearerToken)
+	header.Add("Accept", mediaType)
+	response, err := b.httpClient.SendRequest(http.MethodGet, requestURL, nil, header, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read Black Duck response")
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return errors.Wrap(err, "failed to parse Black Duck response")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package blackduck
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	piperhttp "github.com/SAP/jenkins-library/pkg/http"
+	"github.com/stretchr/testify/assert"
+)
+
+type httpMockClient struct {
+	responses map[string]string
+	headers   map[string]http.Header
+}
+
+func (c *httpMockClient) SetOptions(opts piperhttp.ClientOptions) {
+	//noop
+}
+
+func (c *httpMockClient) SendRequest(method, url string, body io.Reader, header http.Header, cookies []*http.Cookie) (*http.Response, error) {
+	c.headers[url] = header
+	response, ok := c.responses[url]
+	if !ok {
+		return &http.Response{StatusCode: 404}, fmt.Errorf("not found")
+	}
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(response))}, nil
+}
+
+func newHTTPMockClient() *httpMockClient {
+	return &httpMockClient{
+		responses: map[string]string{
+			"https://my.blackduck.server/api/tokens/authenticate":                                   `{"bearerToken":"bearerToken"}`,
+			"https://my.blackduck.server/api/projects?limit=100&q=name%3Aproject":                   `{"items":[{"name":"project-x","_meta":{"href":"https://my.blackduck.server/api/projects/2"}},{"name":"project","_meta":{"href":"https://my.blackduck.server/api/projects/1"}}]}`,
+			"https://my.blackduck.server/api/projects/1/versions?limit=100&q=versionName%3Aversion": `{"items":[{"versionName":"version","_meta":{"href":"https://my.blackduck.server/api/projects/1/versions/3"}}]}`,
+		},
+		headers: map[string]http.Header{},
+	}
+}
+
+func TestGetVulnerableComponents(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		httpClient := newHTTPMockClient()
+		httpClient.responses["https://my.blackduck.server/api/projects/1/versions/3/vulnerable-bom-components?offset=0&limit=100"] = `{"totalCount":2,"items":[
+			{"componentName":"Apache Commons Text","componentVersionName":"1.9","componentVersionOriginId":"org.apache.commons:commons-text:1.9","vulnerabilityWithRemediation":{"vulnerabilityName":"CVE-2022-42889","severity":"CRITICAL","baseScore":9.8,"remediationStatus":"NEW"}},
+			{"componentName":"lodash","componentVersionName":"4.17.20","componentVersionOriginId":"lodash/4.17.20","vulnerabilityWithRemediation":{"vulnerabilityName":"CVE-2021-23337","severity":"HIGH","baseScore":7.2,"remediationStatus":"IGNORED"}}
+		]}`
+		client := NewClient("token", "https://my.blackduck.server/", httpClient)
+
+		components, err := client.GetVulnerableComponents("project", "version")
+
+		assert.NoError(t, err)
+		if assert.Len(t, components, 2) {
+			assert.Equal(t, "commons-text", components[0].PackageName())
+			assert.Equal(t, "1.9", components[0].Version)
+			assert.Equal(t, "CVE-2022-42889", components[0].Vulnerability.Name)
+			assert.Equal(t, 9.8, components[0].Vulnerability.BaseScore)
+			assert.False(t, components[0].Vulnerability.Audited())
+			assert.Equal(t, "lodash", components[1].PackageName())
+			assert.True(t, components[1].Vulnerability.Audited())
+		}
+		assert.Equal(t, "token token", httpClient.headers["https://my.blackduck.server/api/tokens/authenticate"].Get("Authorization"))
+		assert.Equal(t, "Bearer bearerToken", httpClient.headers["https://my.blackduck.server/api/projects/1/versions/3/vulnerable-bom-components?offset=0&limit=100"].Get("Authorization"))
+	})
+
+	t.Run("multiple pages", func(t *testing.T) {
+		httpClient := newHTTPMockClient()
+		component := `{"componentName":"lodash","componentVersionName":"4.17.20","vulnerabilityWithRemediation":{"vulnerabilityName":"CVE-2021-23337"}}`
+		httpClient.responses["https://my.blackduck.server/api/projects/1/versions/3/vulnerable-bom-components?offset=0&limit=100"] = fmt.Sprintf(`{"totalCount":101,"items":[%v]}`, strings.TrimSuffix(strings.Repeat(component+",", 100), ","))
+		httpClient.responses["https://my.blackduck.server/api/projects/1/versions/3/vulnerable-bom-components?offset=100&limit=100"] = fmt.Sprintf(`{"totalCount":101,"items":[%v]}`, component)
+		client := NewClient("token", "https://my.blackduck.server", httpClient)
+
+		components, err := client.GetVulnerableComponents("project", "version")
+
+		assert.NoError(t, err)
+		assert.Len(t, components, 101)
+		assert.Equal(t, "lodash", components[100].PackageName())
+	})
+
+	t.Run("version not found", func(t *testing.T) {
+		client := NewClient("token", "https://my.blackduck.server", newHTTPMockClient())
+
+		_, err := client.GetVulnerableComponents("project", "other")
+
+		assert.EqualError(t, err, "failed to retrieve version 'other' of project 'project': not found")
+	})
+
+	t.Run("project not found", func(t *testing.T) {
+		httpClient := newHTTPMockClient()
+		httpClient.responses["https://my.blackduck.server/api/projects?limit=100&q=name%3Aother"] = `{"items":[]}`
+		client := NewClient("token", "https://my.blackduck.server", httpClient)
+
+		_, err := client.GetVulnerableComponents("other", "version")
+
+		assert.EqualError(t, err, "project 'other' not found in Black Duck")
+	})
+
+	t.Run("authentication failure", func(t *testing.T) {
+		httpClient := newHTTPMockClient()
+		delete(httpClient.responses, "https://my.blackduck.server/api/tokens/authenticate")
+		client := NewClient("token", "https://my.blackduck.server", httpClient)
+
+		_, err := client.GetVulnerableComponents("project", "version")
+
+		assert.EqualError(t, err, "failed to retrieve project 'project': failed to authenticate at Black Duck: not found")
+	})
+}
@@ -160,6 +160,8 @@ type DetailedResult struct {
 // Query - Query Structure
 type Query struct {
 	XMLName xml.Name `xml:"Query"`
+	Name    string   `xml:"name,attr"`
+	CweID   string   `xml:"cweId,attr"`
 	Results []Result `xml:"Result"`
 }
 
@@ -169,6 +171,10 @@ type Result struct {
 	State         string   `xml:"state,attr"`
 	Severity      string   `xml:"Severity,attr"`
 	FalsePositive string   `xml:"FalsePositive,attr"`
+	FileName      string   `xml:"FileName,attr"`
+	Line          int      `xml:"Line,attr"`
+	Column        int      `xml:"Column,attr"`
+	DeepLink      string   `xml:"DeepLink,attr"`
 }
 
 // SystemInstance is the client communicating with the Checkmarx backend
@@ -227,7 +233,7 @@ func NewSystemInstance(client piperHttp.Uploader, serverURL, username, password
 	options := piperHttp.ClientOptions{
 		Token:            token,
 		TransportTimeout: time.Minute * 15,
-	}
+	}.WithServicePolicies()
 	sys.client.SetOptions(options)
 
 	return sys, nil
@@ -1,30 +1,44 @@
 package command
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/pkg/errors"
 )
 
 // Command defines the information required for executing a call to any executable
 type Command struct {
+	// ErrorCategoryMapping maps error categories to patterns where * matches any text, it is applied with priority 0
 	ErrorCategoryMapping map[string][]string
-	dir                  string
-	stdin                io.Reader
-	stdout               io.Writer
-	stderr               io.Writer
-	env                  []string
-	exitCode             int
+	// ErrorCategoryRules are applied in addition to the rules of the step, see log.SetErrorCategoryRules
+	ErrorCategoryRules []log.ErrorCategoryRule
+	dir                string
+	stdin              io.Reader
+	stdout             io.Writer
+	stderr             io.Writer
+	env                []string
+	exitCode           int
+	timeout            time.Duration
+	gracePeriod        time.Duration
 }
 
+// DefaultGracePeriod is the time a terminated command gets for shutting down before it is killed
+const DefaultGracePeriod = 10 * time.Second
+
+// timeoutOutputLines is the number of output lines which are contained in a TimeoutError
+const timeoutOutputLines = 20
+
 type runner interface {
 	SetDir(dir string)
 	SetEnv(env []string)
@@ -49,6 +63,20 @@ type ShellRunner interface {
 	RunShell(shell string, command string) error
 }
 
+// ContextExecRunner allows running executables which are terminated when the context is done
+type ContextExecRunner interface {
+	ExecRunner
+	SetTimeout(timeout time.Duration)
+	RunExecutableContext(ctx context.Context, executable string, params ...string) error
+}
+
+// ContextShellRunner allows running shell scripts which are terminated when the context is done
+type ContextShellRunner interface {
+	ShellRunner
+	SetTimeout(timeout time.Duration)
+	RunShellContext(ctx context.Context, shell string, command string) error
+}
+
 // SetDir sets the working directory for the execution
 func (c *Command) SetDir(dir string) {
 	c.dir = dir
@@ -79,6 +107,16 @@ func (c *Command) Stderr(stderr io.Writer) {
 	c.stderr = stderr
 }
 
+// SetTimeout sets the timeout for each following execution, a value of 0 disables the timeout
+func (c *Command) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetGracePeriod sets the time a command gets for shutting down after SIGTERM before the process group is killed
+func (c *Command) SetGracePeriod(gracePeriod time.Duration) {
+	c.gracePeriod = gracePeriod
+}
+
 // GetStdout Returns the writer for stdout
 func (c *Command) GetStdout() io.Writer {
 	return c.stdout
@@ -94,6 +132,12 @@ var ExecCommand = exec.Command
 
 // RunShell runs the specified command on the shell
 func (c *Command) RunShell(shell, script string) error {
+	return c.RunShellContext(context.Background(), shell, script)
+}
+
+// RunShellContext runs the specified command on the shell.
+// When the context is done or the timeout is exceeded, the process group of the shell is terminated.
+func (c *Command) RunShellContext(ctx context.Context, shell, script string) error {
 
 	c.prepareOut()
 
@@ -111,7 +155,13 @@ func (c *Command) RunShell(shell, script string) error {
 
 	log.Entry().Infof("running shell script: %v %v", shell, script)
 
-	if err := c.runCmd(cmd); err != nil {
+	span := tracing.StartSpan(ctx, shell, tracing.SpanKindInternal)
+	span.SetAttribute("process.executable.name", shell)
+	start := time.Now()
+	err := c.runCmd(ctx, cmd, shell)
+	c.emitExecution(map[string]interface{}{"executable": shell, "script": script}, start, err)
+	c.endSpan(span, err)
+	if err != nil {
 		return errors.Wrapf(err, "running shell script failed with %v", shell)
 	}
 	return nil
@@ -121,6 +171,12 @@ func (c *Command) RunShell(shell, script string) error {
 // !! While the cmd.Env is applied during command execution, it is NOT involved when the actual executable is resolved.
 //    Thus the executable needs to be on the PATH of the current process and it is not sufficient to alter the PATH on cmd.Env.
 func (c *Command) RunExecutable(executable string, params ...string) error {
+	return c.RunExecutableContext(context.Background(), executable, params...)
+}
+
+// RunExecutableContext runs the specified executable with parameters.
+// When the context is done or the timeout is exceeded, the process group of the executable is terminated.
+func (c *Command) RunExecutableContext(ctx context.Context, executable string, params ...string) error {
 
 	c.prepareOut()
 
@@ -138,7 +194,14 @@ func (c *Command) RunExecutable(executable string, params ...string) error {
 		cmd.Stdin = c.stdin
 	}
 
-	if err := c.runCmd(cmd); err != nil {
+	span := tracing.StartSpan(ctx, executable, tracing.SpanKindInternal)
+	span.SetAttribute("process.executable.name", executable)
+	span.SetAttribute("process.command_args", params)
+	start := time.Now()
+	err := c.runCmd(ctx, cmd, executable)
+	c.emitExecution(map[string]interface{}{"executable": executable, "params": params}, start, err)
+	c.endSpan(span, err)
+	if err != nil {
 		return errors.Wrapf(err, "running command '%v' failed", executable)
 	}
 	return nil
@@ -165,7 +228,31 @@ func (c *Command) RunExecutableInBackground(executable string, params ...string)
 		cmd.Stdin = c.stdin
 	}
 
-	execution, err := c.startCmd(cmd)
+	// background executions can always be terminated via Kill(), like other terminable commands
+	// they run in a process group of their own so that Kill() reaches the whole process tree
+	setProcessGroup(cmd)
+
+	span := tracing.StartSpan(context.Background(), executable, tracing.SpanKindInternal)
+	span.SetAttribute("process.executable.name", executable)
+	span.SetAttribute("process.command_args", params)
+	span.SetAttribute("piper.background", true)
+
+	execution, err := c.startCmd(cmd, nil, c.newErrorScanner(executable))
+	if err != nil {
+		span.SetError(err)
+		span.End()
+	} else {
+		// the span ends when waiting for the execution
+		execution.span = span
+	}
+
+	if events.Enabled() {
+		data := map[string]interface{}{"executable": executable, "params": params, "dir": c.dir, "background": true}
+		if err != nil {
+			data["error"] = err.Error()
+		}
+		events.Emit(events.TypeCommandExecuted, data)
+	}
 
 	if err != nil {
 		return nil, errors.Wrapf(err, "starting command '%v' failed", executable)
@@ -179,6 +266,27 @@ func (c *Command) GetExitCode() int {
 	return c.exitCode
 }
 
+// endSpan ends the span of a finished execution
+func (c *Command) endSpan(span *tracing.Span, err error) {
+	span.SetAttribute("process.exit_code", c.exitCode)
+	span.SetError(err)
+	span.End()
+}
+
+// emitExecution emits the commandExecuted event with the result of a finished execution
+func (c *Command) emitExecution(data map[string]interface{}, start time.Time, err error) {
+	if !events.Enabled() {
+		return
+	}
+	data["dir"] = c.dir
+	data["exitCode"] = c.exitCode
+	data["durationMs"] = time.Since(start).Milliseconds()
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	events.Emit(events.TypeCommandExecuted, data)
+}
+
 func appendEnvironment(cmd *exec.Cmd, env []string) {
 
 	if len(env) > 0 {
@@ -206,7 +314,8 @@ func appendEnvironment(cmd *exec.Cmd, env []string) {
 	}
 }
 
-func (c *Command) startCmd(cmd *exec.Cmd) (*execution, error) {
+// startCmd starts the command and copies its output, in addition into tail and the error scanner if provided
+func (c *Command) startCmd(cmd *exec.Cmd, tail *outputTail, errorScanner *errorScanner) (*execution, error) {
 
 	stdout, stderr, err := cmdPipes(cmd)
 
@@ -225,7 +334,7 @@ func (c *Command) startCmd(cmd *exec.Cmd) (*execution, error) {
 	srcOut := stdout
 	srcErr := stderr
 
-	if c.ErrorCategoryMapping != nil {
+	if errorScanner != nil {
 		prOut, pwOut := io.Pipe()
 		trOut := io.TeeReader(stdout, pwOut)
 		srcOut = prOut
@@ -239,41 +348,37 @@ func (c *Command) startCmd(cmd *exec.Cmd) (*execution, error) {
 		go func() {
 			defer execution.wg.Done()
 			defer pwOut.Close()
-			c.scanLog(trOut)
+			errorScanner.scanLog(trOut)
 		}()
 
 		go func() {
 			defer execution.wg.Done()
 			defer pwErr.Close()
-			c.scanLog(trErr)
+			errorScanner.scanLog(trErr)
 		}()
 	}
 
+	dstOut := c.stdout
+	dstErr := c.stderr
+	if tail != nil {
+		// stdout and stderr share the tail in order to keep the order of the lines
+		dstOut = io.MultiWriter(dstOut, tail)
+		dstErr = io.MultiWriter(dstErr, tail)
+	}
+
 	go func() {
-		_, execution.errCopyStdout = io.Copy(c.stdout, srcOut)
+		_, execution.errCopyStdout = io.Copy(dstOut, srcOut)
 		execution.wg.Done()
 	}()
 
 	go func() {
-		_, execution.errCopyStderr = io.Copy(c.stderr, srcErr)
+		_, execution.errCopyStderr = io.Copy(dstErr, srcErr)
 		execution.wg.Done()
 	}()
 
 	return &execution, nil
 }
 
-func (c *Command) scanLog(in io.Reader) {
-	scanner := bufio.NewScanner(in)
-	scanner.Split(scanShortLines)
-	for scanner.Scan() {
-		line := scanner.Text()
-		c.parseConsoleErrors(line)
-	}
-	if err := scanner.Err(); err != nil {
-		log.Entry().WithError(err).Info("failed to scan log file")
-	}
-}
-
 func scanShortLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	lenData := len(data)
 	if atEOF && lenData == 0 {
@@ -299,17 +404,6 @@ func scanShortLines(data []byte, atEOF bool) (advance int, token []byte, err err
 	return 0, nil, nil
 }
 
-func (c *Command) parseConsoleErrors(logLine string) {
-	for category, categoryErrors := range c.ErrorCategoryMapping {
-		for _, errorPart := range categoryErrors {
-			if matchPattern(logLine, errorPart) {
-				log.SetErrorCategory(log.ErrorCategoryByString(category))
-				return
-			}
-		}
-	}
-}
-
 func matchPattern(text, pattern string) bool {
 	if len(pattern) == 0 && len(text) != 0 {
 		return false
@@ -323,20 +417,52 @@ func matchPattern(text, pattern string) bool {
 	return true
 }
 
-func (c *Command) runCmd(cmd *exec.Cmd) error {
+func (c *Command) runCmd(ctx context.Context, cmd *exec.Cmd, name string) error {
+
+	start := time.Now()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
 
-	execution, err := c.startCmd(cmd)
+	// only commands which can be terminated run in a process group of their own,
+	// others keep receiving signals like SIGINT together with the current process
+	var tail *outputTail
+	if ctx.Done() != nil {
+		setProcessGroup(cmd)
+		tail = newOutputTail(timeoutOutputLines)
+	}
+
+	errorScanner := c.newErrorScanner(name)
+	execution, err := c.startCmd(cmd, tail, errorScanner)
 	if err != nil {
 		return err
 	}
 
-	err = execution.Wait()
+	done := make(chan error, 1)
+	go func() {
+		done <- execution.Wait()
+	}()
+
+	var ctxErr error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		select {
+		case err = <-done:
+			// finished in the meantime
+		default:
+			ctxErr = ctx.Err()
+			err = c.terminate(execution, done)
+		}
+	}
 
 	if execution.errCopyStdout != nil || execution.errCopyStderr != nil {
 		return fmt.Errorf("failed to capture stdout/stderr: '%v'/'%v'", execution.errCopyStdout, execution.errCopyStderr)
 	}
 
-	if err != nil {
+	if err != nil || ctxErr != nil {
 		// provide fallback to ensure a non 0 exit code in case of an error
 		c.exitCode = 1
 		// try to identify the detailed error code
@@ -345,12 +471,56 @@ func (c *Command) runCmd(cmd *exec.Cmd) error {
 				c.exitCode = status.ExitStatus()
 			}
 		}
+		if errorScanner != nil {
+			errorScanner.parseExitCode(c.exitCode)
+		}
+	}
+
+	// a command which has been terminated fails even if it shut down gracefully
+	if ctxErr == context.DeadlineExceeded {
+		log.SetErrorCategory(log.ErrorTimeout)
+		timeout := c.timeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = deadline.Sub(start).Round(time.Millisecond)
+		}
+		return &TimeoutError{Command: name, Timeout: timeout, Output: tail.Lines()}
+	}
+	if ctxErr != nil {
+		return errors.Wrap(ctxErr, "command has been terminated")
+	}
+	if err != nil {
 		return errors.Wrap(err, "cmd.Run() failed")
 	}
 	c.exitCode = 0
 	return nil
 }
 
+// terminate sends SIGTERM to the process group of the execution and kills it in case it did not finish within the grace period
+func (c *Command) terminate(execution *execution, done <-chan error) error {
+	gracePeriod := c.gracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultGracePeriod
+	}
+
+	log.Entry().Infof("terminating command, waiting %v before killing it", gracePeriod)
+	if err := terminateProcessGroup(execution.cmd); err != nil {
+		log.Entry().WithError(err).Warn("failed to terminate command")
+	}
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		log.Entry().Warnf("command did not terminate within %v, killing it", gracePeriod)
+		if err := execution.Kill(); err != nil {
+			log.Entry().WithError(err).Warn("failed to kill command")
+		}
+		return <-done
+	}
+}
+
 func (c *Command) prepareOut() {
 
 	//ToDo: check use of multiwriter instead to always write into os.Stdout and os.Stdin?
@@ -2,15 +2,25 @@ package command
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //based on https://golang.org/src/os/exec/exec_test.go
@@ -80,6 +90,7 @@ func TestExecutableRun(t *testing.T) {
 
 		t.Run("success case - log parsing", func(t *testing.T) {
 			log.SetErrorCategory(log.ErrorUndefined)
+			log.ResetErrorMatches()
 			ex := Command{stdout: stdout, stderr: stderr, ErrorCategoryMapping: map[string][]string{"config": {"command echo"}}}
 			ex.RunExecutable("echo", []string{"foo bar", "baz"}...)
 			assert.Equal(t, log.ErrorConfiguration, log.GetErrorCategory())
@@ -93,9 +104,79 @@ func TestExecutableRun(t *testing.T) {
 		})
 
 		log.SetErrorCategory(log.ErrorUndefined)
+		log.ResetErrorMatches()
 	})
 }
 
+func TestExecutionEvents(t *testing.T) {
+	ExecCommand = helperCommand
+	defer func() { ExecCommand = exec.Command }()
+
+	dir, err := ioutil.TempDir("", "events")
+	if err != nil {
+		t.Fatal("Failed to create temporary directory")
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.ndjson")
+
+	events.Initialize(path, events.Source{Step: "test"})
+	ex := Command{stdout: new(bytes.Buffer), stderr: new(bytes.Buffer)}
+	ex.RunExecutable("echo", "foo")
+	ex.RunExecutable("unknown")
+	events.StepFinished("0", "undefined", 0)
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if assert.Len(t, lines, 4) {
+		echo := events.Event{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &echo))
+		assert.Equal(t, events.TypeCommandExecuted, echo.Type)
+		assert.Equal(t, "echo", echo.Data["executable"])
+		assert.Equal(t, []interface{}{"foo"}, echo.Data["params"])
+		assert.Equal(t, float64(0), echo.Data["exitCode"])
+
+		unknown := events.Event{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[2]), &unknown))
+		assert.Equal(t, float64(2), unknown.Data["exitCode"])
+		assert.Contains(t, unknown.Data["error"], "exit status 2")
+	}
+}
+
+func TestExecutionTracing(t *testing.T) {
+	ExecCommand = helperCommand
+	defer func() { ExecCommand = exec.Command }()
+	collector := tracing.NewCollector()
+	defer collector.Close()
+
+	tracing.Initialize(tracing.Options{Endpoint: collector.Endpoint(), Step: "test"})
+	ex := Command{stdout: new(bytes.Buffer), stderr: new(bytes.Buffer)}
+	ex.RunExecutable("echo", "foo")
+	ex.RunExecutable("unknown")
+	ex.RunShell("/bin/bash", "script")
+	execution, err := ex.RunExecutableInBackground("echo", "bar")
+	require.NoError(t, err)
+	execution.Wait()
+	tracing.StepFinished("1", "undefined")
+
+	spans := collector.Spans()
+	require.Len(t, spans, 5)
+	step, _ := collector.Span("test")
+	for _, span := range spans[:4] {
+		assert.Equal(t, step.SpanID, span.ParentSpanID, span.Name)
+	}
+	assert.Equal(t, "echo", spans[0].Name)
+	assert.Equal(t, []interface{}{"foo"}, spans[0].Attribute("process.command_args"))
+	assert.Equal(t, int64(0), spans[0].Attribute("process.exit_code"))
+	assert.Equal(t, tracing.StatusUnset, spans[0].Status.Code)
+	assert.Equal(t, "unknown", spans[1].Name)
+	assert.Equal(t, int64(2), spans[1].Attribute("process.exit_code"))
+	assert.Equal(t, tracing.StatusError, spans[1].Status.Code)
+	assert.Equal(t, "/bin/bash", spans[2].Name)
+	assert.Equal(t, true, spans[3].Attribute("piper.background"))
+	assert.Equal(t, int64(0), spans[3].Attribute("process.exit_code"))
+}
+
 func TestEnvironmentVariables(t *testing.T) {
 
 	ExecCommand = helperCommand
@@ -176,12 +257,82 @@ func TestParseConsoleErrors(t *testing.T) {
 		{consoleLine: "the build failed", expectedCategory: log.ErrorBuild},
 	}
 
+	scanner := cmd.newErrorScanner("test")
 	for _, test := range tt {
 		log.SetErrorCategory(log.ErrorUndefined)
-		cmd.parseConsoleErrors(test.consoleLine)
+		log.ResetErrorMatches()
+		scanner.parseConsoleErrors(test.consoleLine)
 		assert.Equal(t, test.expectedCategory, log.GetErrorCategory(), test.consoleLine)
 	}
 	log.SetErrorCategory(log.ErrorUndefined)
+	log.ResetErrorMatches()
+}
+
+func TestErrorCategoryRules(t *testing.T) {
+	ExecCommand = helperCommand
+	defer func() { ExecCommand = exec.Command }()
+	defer log.SetErrorCategory(log.ErrorUndefined)
+	defer log.ResetErrorMatches()
+
+	t.Run("priority", func(t *testing.T) {
+		log.SetErrorCategory(log.ErrorUndefined)
+		log.ResetErrorMatches()
+		log.SetErrorCategoryRules([]log.ErrorCategoryRule{
+			{Category: "infrastructure", Pattern: `^Stderr: command \w+$`, Priority: 10, Remediation: "Check the infrastructure."},
+		})
+		defer log.SetErrorCategoryRules(nil)
+		ex := Command{
+			stdout:               new(bytes.Buffer),
+			stderr:               new(bytes.Buffer),
+			ErrorCategoryMapping: map[string][]string{"config": {"foo*baz"}},
+			ErrorCategoryRules:   []log.ErrorCategoryRule{{Category: "build", Pattern: "command e(ch|x)o"}},
+		}
+
+		assert.NoError(t, ex.RunExecutable("echo", "foo bar", "baz"))
+
+		assert.Equal(t, log.ErrorInfrastructure, log.GetErrorCategory())
+		assert.ElementsMatch(t, []log.ErrorMatch{
+			{Category: "config", Rule: "foo*baz", Command: "echo", Line: "foo bar baz"},
+			{Category: "infrastructure", Rule: `^Stderr: command \w+$`, Command: "echo", Line: "Stderr: command echo", Priority: 10, Remediation: "Check the infrastructure."},
+		}, log.GetErrorMatches())
+	})
+
+	t.Run("first match wins with equal priority", func(t *testing.T) {
+		log.SetErrorCategory(log.ErrorUndefined)
+		log.ResetErrorMatches()
+		scanner := (&Command{ErrorCategoryRules: []log.ErrorCategoryRule{
+			{Category: "build", Pattern: "BUILD FAILURE"},
+			{Category: "test", Pattern: "Tests run: .*, Failures: [1-9]"},
+		}}).newErrorScanner("mvn")
+
+		scanner.parseConsoleErrors("Tests run: 10, Failures: 2")
+		scanner.parseConsoleErrors("BUILD FAILURE")
+
+		assert.Equal(t, log.ErrorTest, log.GetErrorCategory())
+		assert.Len(t, log.GetErrorMatches(), 2)
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		log.SetErrorCategory(log.ErrorUndefined)
+		log.ResetErrorMatches()
+		ex := Command{
+			stdout:             new(bytes.Buffer),
+			stderr:             new(bytes.Buffer),
+			ErrorCategoryRules: []log.ErrorCategoryRule{{Category: "config", ExitCodes: []int{1, 2}, Remediation: "Check the parameters."}},
+		}
+
+		assert.Error(t, ex.RunExecutable("unknown"))
+
+		assert.Equal(t, log.ErrorConfiguration, log.GetErrorCategory())
+		assert.Equal(t, []log.ErrorMatch{
+			{Category: "config", Rule: "exitCodes [1 2]", Command: "unknown", ExitCode: 2, Remediation: "Check the parameters."},
+		}, log.GetErrorMatches())
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		scanner := (&Command{ErrorCategoryRules: []log.ErrorCategoryRule{{Category: "config", Pattern: "("}}}).newErrorScanner("mvn")
+		assert.Nil(t, scanner)
+	})
 }
 
 func TestMatchPattern(t *testing.T) {
@@ -232,6 +383,116 @@ func TestCmdPipes(t *testing.T) {
 	})
 }
 
+func TestTimeout(t *testing.T) {
+	ExecCommand = helperCommand
+	defer func() { ExecCommand = exec.Command }()
+	defer log.SetErrorCategory(log.ErrorUndefined)
+
+	// runWithDeadline fails the test instead of blocking in case the process tree is not terminated
+	runWithDeadline := func(t *testing.T, run func() error) error {
+		result := make(chan error, 1)
+		go func() { result <- run() }()
+		select {
+		case err := <-result:
+			return err
+		case <-time.After(30 * time.Second):
+			t.Fatal("command has not been terminated")
+			return nil
+		}
+	}
+
+	t.Run("timeout terminates process tree", func(t *testing.T) {
+		log.SetErrorCategory(log.ErrorUndefined)
+		stdout := new(bytes.Buffer)
+		ex := Command{stdout: stdout, stderr: new(bytes.Buffer)}
+		ex.SetTimeout(time.Second)
+
+		err := runWithDeadline(t, func() error { return ex.RunExecutable("hang", "3") })
+
+		var timeoutErr *TimeoutError
+		if assert.True(t, errors.As(err, &timeoutErr), "unexpected error %v", err) {
+			assert.Equal(t, "hang", timeoutErr.Command)
+			assert.Equal(t, time.Second, timeoutErr.Timeout)
+			assert.Equal(t, []string{"line 1", "line 2", "line 3"}, timeoutErr.Output)
+		}
+		assert.Contains(t, err.Error(), "running command 'hang' failed: command 'hang' timed out after 1s, last output:\nline 1\nline 2\nline 3")
+		assert.Equal(t, log.ErrorTimeout, log.GetErrorCategory())
+		assert.NotEqual(t, 0, ex.GetExitCode())
+		assert.Equal(t, "line 1\nline 2\nline 3\n", stdout.String())
+	})
+
+	t.Run("kill after grace period", func(t *testing.T) {
+		ex := Command{stdout: new(bytes.Buffer), stderr: new(bytes.Buffer)}
+		ex.SetTimeout(time.Second)
+		ex.SetGracePeriod(500 * time.Millisecond)
+
+		start := time.Now()
+		err := runWithDeadline(t, func() error { return ex.RunShell("ignoreTerm", "") })
+
+		var timeoutErr *TimeoutError
+		if assert.True(t, errors.As(err, &timeoutErr), "unexpected error %v", err) {
+			assert.Equal(t, []string{"ignoring SIGTERM"}, timeoutErr.Output)
+		}
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(1500*time.Millisecond))
+		assert.Equal(t, -1, ex.GetExitCode())
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		log.SetErrorCategory(log.ErrorUndefined)
+		ex := Command{stdout: new(bytes.Buffer), stderr: new(bytes.Buffer)}
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(time.Second, cancel)
+
+		err := runWithDeadline(t, func() error { return ex.RunExecutableContext(ctx, "hang", "1") })
+
+		assert.EqualError(t, err, "running command 'hang' failed: command has been terminated: context canceled")
+		assert.Equal(t, log.ErrorUndefined, log.GetErrorCategory())
+	})
+
+	t.Run("finished within timeout", func(t *testing.T) {
+		stdout := new(bytes.Buffer)
+		ex := Command{stdout: stdout, stderr: new(bytes.Buffer)}
+		ex.SetTimeout(time.Minute)
+
+		err := ex.RunExecutable("echo", "foo")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, ex.GetExitCode())
+		assert.Equal(t, "foo\n", stdout.String())
+	})
+
+	t.Run("kill background process tree", func(t *testing.T) {
+		ex := Command{stdout: new(bytes.Buffer), stderr: new(bytes.Buffer)}
+
+		execution, err := ex.RunExecutableInBackground("hang", "1")
+		if assert.NoError(t, err) {
+			time.Sleep(500 * time.Millisecond)
+			assert.NoError(t, execution.Kill())
+			err = runWithDeadline(t, execution.Wait)
+			assert.EqualError(t, err, "signal: killed")
+		}
+	})
+}
+
+func TestTimeoutError(t *testing.T) {
+	assert.Equal(t, "command 'mvn' timed out after 10m0s", (&TimeoutError{Command: "mvn", Timeout: 10 * time.Minute}).Error())
+	assert.Equal(t, "command 'mvn' timed out after 1m0s, last output:\n[INFO] a\n[INFO] b", (&TimeoutError{Command: "mvn", Timeout: time.Minute, Output: []string{"[INFO] a", "[INFO] b"}}).Error())
+}
+
+func TestOutputTail(t *testing.T) {
+	tail := newOutputTail(2)
+	assert.Empty(t, tail.Lines())
+
+	tail.Write([]byte("line 1\nline"))
+	assert.Equal(t, []string{"line 1", "line"}, tail.Lines())
+
+	tail.Write([]byte(" 2\r\nline 3\n"))
+	assert.Equal(t, []string{"line 2", "line 3"}, tail.Lines())
+
+	tail.Write([]byte("line 4"))
+	assert.Equal(t, []string{"line 3", "line 4"}, tail.Lines())
+}
+
 //based on https://golang.org/src/os/exec/exec_test.go
 //this is not directly executed
 func TestHelperProcess(*testing.T) {
@@ -277,6 +538,26 @@ func TestHelperProcess(*testing.T) {
 		b = bytes.Repeat(b, size)
 
 		fmt.Fprint(os.Stderr, b)
+	case "hang":
+		// starts a child process within the same process group which keeps stdout open
+		child := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", "sleep")
+		child.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		child.Stdout = os.Stdout
+		if err := child.Start(); err != nil {
+			os.Exit(2)
+		}
+		lines := 0
+		fmt.Sscan(args[0], &lines)
+		for i := 1; i <= lines; i++ {
+			fmt.Printf("line %v\n", i)
+		}
+		time.Sleep(time.Minute)
+	case "ignoreTerm":
+		signal.Ignore(syscall.SIGTERM)
+		fmt.Println("ignoring SIGTERM")
+		time.Sleep(time.Minute)
+	case "sleep":
+		time.Sleep(time.Minute)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command %q\n", cmd)
 		os.Exit(2)
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+)
+
+type errorCategoryRule struct {
+	log.ErrorCategoryRule
+	name  string
+	match func(line string) bool
+}
+
+// errorScanner detects error categories within the output and the exit code of a command
+type errorScanner struct {
+	command string
+	rules   []errorCategoryRule
+}
+
+// newErrorScanner combines the rules of the command, of the step and the ErrorCategoryMapping ordered by priority.
+// It returns nil in case no rule exists.
+func (c *Command) newErrorScanner(command string) *errorScanner {
+	rules := []errorCategoryRule{}
+	for _, rule := range append(append([]log.ErrorCategoryRule{}, c.ErrorCategoryRules...), log.GetErrorCategoryRules()...) {
+		compiled := errorCategoryRule{ErrorCategoryRule: rule, name: rule.Pattern}
+		if len(rule.Pattern) > 0 {
+			expression, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				log.Entry().WithError(err).Warnf("ignoring invalid error category rule '%v'", rule.Pattern)
+				continue
+			}
+			compiled.match = expression.MatchString
+		} else {
+			compiled.name = fmt.Sprintf("exitCodes %v", rule.ExitCodes)
+		}
+		rules = append(rules, compiled)
+	}
+
+	categories := []string{}
+	for category := range c.ErrorCategoryMapping {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		for _, pattern := range c.ErrorCategoryMapping[category] {
+			pattern := pattern
+			rules = append(rules, errorCategoryRule{
+				ErrorCategoryRule: log.ErrorCategoryRule{Category: category, Pattern: pattern},
+				name:              pattern,
+				match:             func(line string) bool { return matchPattern(line, pattern) },
+			})
+		}
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority > rules[j].Priority })
+	return &errorScanner{command: command, rules: rules}
+}
+
+func (s *errorScanner) scanLog(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	scanner.Split(scanShortLines)
+	for scanner.Scan() {
+		s.parseConsoleErrors(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		log.Entry().WithError(err).Info("failed to scan log file")
+	}
+}
+
+// parseConsoleErrors records the match of the rule with the highest priority matching the line
+func (s *errorScanner) parseConsoleErrors(logLine string) {
+	for _, rule := range s.rules {
+		if rule.match != nil && rule.match(logLine) {
+			log.AddErrorMatch(log.ErrorMatch{
+				Category:    rule.Category,
+				Rule:        rule.name,
+				Command:     s.command,
+				Line:        logLine,
+				Priority:    rule.Priority,
+				Remediation: rule.Remediation,
+			})
+			return
+		}
+	}
+}
+
+// parseExitCode records the match of the rule with the highest priority containing the exit code
+func (s *errorScanner) parseExitCode(exitCode int) {
+	for _, rule := range s.rules {
+		for _, code := range rule.ExitCodes {
+			if code == exitCode {
+				log.AddErrorMatch(log.ErrorMatch{
+					Category:    rule.Category,
+					Rule:        rule.name,
+					Command:     s.command,
+					ExitCode:    exitCode,
+					Priority:    rule.Priority,
+					Remediation: rule.Remediation,
+				})
+				return
+			}
+		}
+	}
+}
@@ -3,6 +3,8 @@ package command
 import (
 	"os/exec"
 	"sync"
+
+	"github.com/SAP/jenkins-library/pkg/tracing"
 )
 
 //errCopyStdout and errCopyStderr are filled after the command execution after Wait() terminates
@@ -11,15 +13,25 @@ type execution struct {
 	wg            sync.WaitGroup
 	errCopyStdout error
 	errCopyStderr error
+	span          *tracing.Span
 }
 
+// Kill kills the process together with its child processes in case it has been started in a process group of its own
 func (execution *execution) Kill() error {
-	return execution.cmd.Process.Kill()
+	return killProcessGroup(execution.cmd)
 }
 
 func (execution *execution) Wait() error {
 	execution.wg.Wait()
-	return execution.cmd.Wait()
+	err := execution.cmd.Wait()
+	if execution.span != nil {
+		if execution.cmd.ProcessState != nil {
+			execution.span.SetAttribute("process.exit_code", execution.cmd.ProcessState.ExitCode())
+		}
+		execution.span.SetError(err)
+		execution.span.End()
+	}
+	return err
 }
 
 // Execution references a background process which is started by RunExecutableInBackground
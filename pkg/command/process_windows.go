@@ -0,0 +1,21 @@
+package command
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is not supported on windows, only the direct child process is handled
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills the process since windows does not support graceful termination via signals
+func terminateProcessGroup(cmd *exec.Cmd) error {
+	return killProcessGroup(cmd)
+}
+
+// killProcessGroup kills the process
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
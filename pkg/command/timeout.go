@@ -0,0 +1,78 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimeoutError is returned in case a command has been terminated since it exceeded its timeout
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration
+	// Output contains the last lines written to stdout and stderr before the command has been terminated
+	Output []string
+}
+
+func (e *TimeoutError) Error() string {
+	message := fmt.Sprintf("command '%v' timed out after %v", e.Command, e.Timeout)
+	if len(e.Output) > 0 {
+		message += fmt.Sprintf(", last output:\n%v", strings.Join(e.Output, "\n"))
+	}
+	return message
+}
+
+// outputTail keeps the last lines written to it
+type outputTail struct {
+	mutex   sync.Mutex
+	max     int
+	lines   []string
+	partial []byte
+}
+
+func newOutputTail(max int) *outputTail {
+	return &outputTail{max: max}
+}
+
+func (t *outputTail) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	data := append(t.partial, p...)
+	for {
+		index := bytes.IndexByte(data, '\n')
+		if index < 0 {
+			break
+		}
+		t.add(strings.TrimSuffix(string(data[:index]), "\r"))
+		data = data[index+1:]
+	}
+	// keep incomplete lines limited, they are only relevant in case no newline follows
+	if len(data) > 32767 {
+		data = data[len(data)-32767:]
+	}
+	t.partial = append([]byte{}, data...)
+	return len(p), nil
+}
+
+func (t *outputTail) add(line string) {
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+}
+
+// Lines returns the last lines including a final line without newline
+func (t *outputTail) Lines() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	lines := append([]string{}, t.lines...)
+	if len(t.partial) > 0 {
+		lines = append(lines, string(t.partial))
+		if len(lines) > t.max {
+			lines = lines[len(lines)-t.max:]
+		}
+	}
+	return lines
+}
@@ -28,12 +28,21 @@ type Config struct {
 	initialized      bool
 	openFile         func(s string) (io.ReadCloser, error)
 	vaultCredentials VaultCredentials
+	envRootPath      string
+	// source is the name of the file the configuration was read from
+	source      string
+	provenance  bool
+	skipSecrets bool
+	// aliases contains the aliases used per section, e.g. steps/mavenBuild
+	aliases map[string]map[string]string
 }
 
 // StepConfig defines the structure for merged step configuration
 type StepConfig struct {
 	Config     map[string]interface{}
 	HookConfig *json.RawMessage
+	// Provenance is only available if enabled via Config.EnableProvenance
+	Provenance Provenance
 }
 
 // ReadConfig loads config and returns its content
@@ -45,6 +54,7 @@ func (c *Config) ReadConfig(configuration io.ReadCloser) error {
 		return errors.Wrapf(err, "error reading %v", configuration)
 	}
 
+	c.source = sourceName(configuration, "")
 	err = yaml.Unmarshal(content, &c)
 	if err != nil {
 		return NewParseError(fmt.Sprintf("format of configuration is invalid %q: %v", content, err))
@@ -59,22 +69,23 @@ func (c *Config) ApplyAliasConfig(parameters []StepParameters, secrets []StepSec
 		c.copyStepAliasConfig(stepName, stepAliases)
 	}
 	for _, p := range parameters {
-		c.General = setParamValueFromAlias(c.General, filters.General, p.Name, p.Aliases)
-		if c.Stages[stageName] != nil {
-			c.Stages[stageName] = setParamValueFromAlias(c.Stages[stageName], filters.Stages, p.Name, p.Aliases)
-		}
-		if c.Steps[stepName] != nil {
-			c.Steps[stepName] = setParamValueFromAlias(c.Steps[stepName], filters.Steps, p.Name, p.Aliases)
-		}
+		c.applyAlias(stageName, stepName, filters, p.Name, p.Aliases)
 	}
 	for _, s := range secrets {
-		c.General = setParamValueFromAlias(c.General, filters.General, s.Name, s.Aliases)
-		if c.Stages[stageName] != nil {
-			c.Stages[stageName] = setParamValueFromAlias(c.Stages[stageName], filters.Stages, s.Name, s.Aliases)
-		}
-		if c.Steps[stepName] != nil {
-			c.Steps[stepName] = setParamValueFromAlias(c.Steps[stepName], filters.Steps, s.Name, s.Aliases)
-		}
+		c.applyAlias(stageName, stepName, filters, s.Name, s.Aliases)
+	}
+}
+
+func (c *Config) applyAlias(stageName, stepName string, filters StepFilters, name string, aliases []Alias) {
+	c.recordAlias("general", c.General, filters.General, name, aliases)
+	c.General = setParamValueFromAlias(c.General, filters.General, name, aliases)
+	if c.Stages[stageName] != nil {
+		c.recordAlias(stageSection(stageName), c.Stages[stageName], filters.Stages, name, aliases)
+		c.Stages[stageName] = setParamValueFromAlias(c.Stages[stageName], filters.Stages, name, aliases)
+	}
+	if c.Steps[stepName] != nil {
+		c.recordAlias(stepSection(stepName), c.Steps[stepName], filters.Steps, name, aliases)
+		c.Steps[stepName] = setParamValueFromAlias(c.Steps[stepName], filters.Steps, name, aliases)
 	}
 }
 
@@ -151,7 +162,7 @@ func (c *Config) InitializeConfig(configuration io.ReadCloser, defaults []io.Rea
 			if err != nil {
 				return errors.Wrapf(err, "getting default '%v' failed", f)
 			}
-			defaults = append(defaults, fc)
+			defaults = append(defaults, &namedReadCloser{ReadCloser: fc, name: f})
 		}
 	}
 
@@ -176,19 +187,26 @@ func (c *Config) GetStepConfig(flagValues map[string]interface{}, paramJSON stri
 
 	c.ApplyAliasConfig(parameters, secrets, filters, stageName, stepName, stepAliases)
 
+	if c.provenance {
+		stepConfig.Provenance = Provenance{}
+	}
+
 	// initialize with defaults from step.yaml
 	stepConfig.mixInStepDefaults(parameters)
 
 	// merge parameters provided by Piper environment
 	stepConfig.mixIn(envParameters, filters.All)
+	stepConfig.record(filterMap(envParameters, filters.All), func(key string) Layer {
+		return Layer{Source: SourceResource, Path: resourcePath(parameters, key)}
+	})
 
 	// read defaults & merge general -> steps (-> general -> steps ...)
 	for _, def := range c.defaults.Defaults {
 		def.ApplyAliasConfig(parameters, secrets, filters, stageName, stepName, stepAliases)
-		stepConfig.mixIn(def.General, filters.General)
-		stepConfig.mixIn(def.Steps[stepName], filters.Steps)
-		stepConfig.mixIn(def.Stages[stageName], filters.Steps)
-		stepConfig.mixinVaultConfig(def.General, def.Steps[stepName], def.Stages[stageName])
+		stepConfig.mixInLayer(def.General, filters.General, SourceDefaults, def.source, "general", def.aliases["general"])
+		stepConfig.mixInLayer(def.Steps[stepName], filters.Steps, SourceDefaults, def.source, stepSection(stepName), def.aliases[stepSection(stepName)])
+		stepConfig.mixInLayer(def.Stages[stageName], filters.Steps, SourceDefaults, def.source, stageSection(stageName), def.aliases[stageSection(stageName)])
+		stepConfig.mixinVaultConfig(&def, SourceDefaults, stageName, stepName)
 
 		// process hook configuration - this is only supported via defaults
 		if stepConfig.HookConfig == nil {
@@ -197,12 +215,16 @@ func (c *Config) GetStepConfig(flagValues map[string]interface{}, paramJSON stri
 	}
 
 	// read config & merge - general -> steps -> stages
-	stepConfig.mixIn(c.General, filters.General)
-	stepConfig.mixIn(c.Steps[stepName], filters.Steps)
-	stepConfig.mixIn(c.Stages[stageName], filters.Stages)
+	stepConfig.mixInLayer(c.General, filters.General, SourceConfig, c.source, "general", c.aliases["general"])
+	stepConfig.mixInLayer(c.Steps[stepName], filters.Steps, SourceConfig, c.source, stepSection(stepName), c.aliases[stepSection(stepName)])
+	stepConfig.mixInLayer(c.Stages[stageName], filters.Stages, SourceConfig, c.source, stageSection(stageName), c.aliases[stageSection(stageName)])
 
 	// merge parameters provided via env vars
-	stepConfig.mixIn(envValues(filters.All), filters.All)
+	envConfig := envValues(filters.All)
+	stepConfig.mixIn(envConfig, filters.All)
+	stepConfig.record(envConfig, func(key string) Layer {
+		return Layer{Source: SourceEnvironment, Path: "PIPER_" + key}
+	})
 
 	// if parameters are provided in JSON format merge them
 	if len(paramJSON) != 0 {
@@ -212,20 +234,32 @@ func (c *Config) GetStepConfig(flagValues map[string]interface{}, paramJSON stri
 			log.Entry().Warnf("failed to parse parameters from environment: %v", err)
 		} else {
 			//apply aliases
+			aliases := map[string]string{}
 			for _, p := range parameters {
+				if alias := aliasUsed(params, p.Aliases); params[p.Name] == nil && len(alias) > 0 {
+					aliases[p.Name] = alias
+				}
 				params = setParamValueFromAlias(params, filters.Parameters, p.Name, p.Aliases)
 			}
 			for _, s := range secrets {
+				if alias := aliasUsed(params, s.Aliases); params[s.Name] == nil && len(alias) > 0 {
+					aliases[s.Name] = alias
+				}
 				params = setParamValueFromAlias(params, filters.Parameters, s.Name, s.Aliases)
 			}
 
-			stepConfig.mixIn(params, filters.Parameters)
+			stepConfig.mixInLayer(params, filters.Parameters, SourceParameterJSON, "", "", aliases)
 		}
 	}
 
 	// merge command line flags
 	if flagValues != nil {
-		stepConfig.mixIn(flagValues, filters.Parameters)
+		stepConfig.mixInLayer(flagValues, filters.Parameters, SourceFlag, "", "", nil)
+	}
+
+	// resolve expressions like ${{ cpe.git.commitId }} before the values are used
+	if err := c.resolveExpressions(&stepConfig); err != nil {
+		return StepConfig{}, err
 	}
 
 	if verbose, ok := stepConfig.Config["verbose"].(bool); ok && verbose {
@@ -234,20 +268,24 @@ func (c *Config) GetStepConfig(flagValues map[string]interface{}, paramJSON stri
 		log.Entry().Warnf("invalid value for parameter verbose: '%v'", stepConfig.Config["verbose"])
 	}
 
-	stepConfig.mixinVaultConfig(c.General, c.Steps[stepName], c.Stages[stageName])
+	stepConfig.mixinVaultConfig(c, SourceConfig, stageName, stepName)
 	// check whether vault should be skipped
-	if skip, ok := stepConfig.Config["skipVault"].(bool); !ok || !skip {
+	if skip, ok := stepConfig.Config["skipVault"].(bool); !c.skipSecrets && (!ok || !skip) {
 		// fetch secrets from vault
 		vaultClient, err := getVaultClientFromConfig(stepConfig, c.vaultCredentials)
 		if err != nil {
 			return StepConfig{}, err
 		}
 		if vaultClient != nil {
-			defer vaultClient.MustRevokeToken()
+			defer releaseVaultClient(vaultClient)
 			resolveAllVaultReferences(&stepConfig, vaultClient, parameters)
 			resolveVaultTestCredentials(&stepConfig, vaultClient)
 		}
 	}
+	// fetch secrets from the other providers, e.g. mounted files or Kubernetes secrets
+	if !c.skipSecrets {
+		resolveAllSecretReferences(&stepConfig, newSecretProviders(stepConfig, parameters), parameters)
+	}
 
 	// finally do the condition evaluation post processing
 	for _, p := range parameters {
@@ -261,12 +299,19 @@ func (c *Config) GetStepConfig(flagValues map[string]interface{}, paramJSON stri
 				} else {
 					stepConfig.Config[p.Name] = p.Default
 				}
+				stepConfig.recordLayer(p.Name, Layer{Source: SourceCondition, Path: fmt.Sprintf("%v=%v", cp.Name, cp.Value), Value: stepConfig.Config[p.Name]})
 			}
 		}
 	}
 	return stepConfig, nil
 }
 
+// SkipSecrets disables fetching secrets from Vault and the other secret providers during GetStepConfig,
+// e.g. in case the configuration is only compared
+func (c *Config) SkipSecrets() {
+	c.skipSecrets = true
+}
+
 // SetVaultCredentials sets the appRoleID and the appRoleSecretID or the vaultTokento load additional
 //configuration from vault
 // Either appRoleID and appRoleSecretID or vaultToken must be specified.
@@ -321,7 +366,7 @@ func OpenPiperFile(name string) (io.ReadCloser, error) {
 	if err != nil {
 		return nil, err
 	}
-	return response.Body, nil
+	return &namedReadCloser{ReadCloser: response.Body, name: name}, nil
 }
 
 func envValues(filter []string) map[string]interface{} {
@@ -351,6 +396,7 @@ func (s *StepConfig) mixInStepDefaults(stepParams []StepParameters) {
 	for _, p := range stepParams {
 		if p.Default != nil {
 			s.Config[p.Name] = p.Default
+			s.recordLayer(p.Name, Layer{Source: SourceStepDefault, Value: p.Default})
 		}
 	}
 }
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Kinds of differences between two step configurations
+const (
+	DifferenceAdded   = "added"
+	DifferenceRemoved = "removed"
+	DifferenceChanged = "changed"
+)
+
+// Difference is the difference of a parameter between two resolved step configurations
+type Difference struct {
+	Step string `json:"step"`
+	// Parameter is the name of the parameter, nested values are separated by slash, e.g. cloudFoundry/space
+	Parameter string      `json:"parameter"`
+	Kind      string      `json:"kind"`
+	From      interface{} `json:"from,omitempty"`
+	To        interface{} `json:"to,omitempty"`
+}
+
+// String returns the difference in the form "~ parameter: from -> to", "+ parameter: to" or "- parameter: from"
+func (d Difference) String() string {
+	switch d.Kind {
+	case DifferenceAdded:
+		return fmt.Sprintf("+ %v: %v", d.Parameter, formatDiffValue(d.To))
+	case DifferenceRemoved:
+		return fmt.Sprintf("- %v: %v", d.Parameter, formatDiffValue(d.From))
+	}
+	return fmt.Sprintf("~ %v: %v -> %v", d.Parameter, formatDiffValue(d.From), formatDiffValue(d.To))
+}
+
+// DiffStepConfig compares two resolved configurations of a step. Maps are compared entry by entry,
+// other values as a whole. The values of the given secrets are redacted.
+func DiffStepConfig(stepName string, from, to map[string]interface{}, secrets []string) []Difference {
+	differences := []Difference{}
+	diffMaps(stepName, "", from, to, &differences)
+	for i, difference := range differences {
+		name := strings.SplitN(difference.Parameter, "/", 2)[0]
+		if sliceContains(secrets, name) {
+			differences[i].From = redact(difference.From, true)
+			differences[i].To = redact(difference.To, true)
+		}
+	}
+	return differences
+}
+
+func diffMaps(stepName, prefix string, from, to map[string]interface{}, differences *[]Difference) {
+	keys := []string{}
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		parameter := prefix + key
+		switch {
+		case !inFrom || fromValue == nil:
+			if toValue != nil {
+				*differences = append(*differences, Difference{Step: stepName, Parameter: parameter, Kind: DifferenceAdded, To: toValue})
+			}
+		case !inTo || toValue == nil:
+			*differences = append(*differences, Difference{Step: stepName, Parameter: parameter, Kind: DifferenceRemoved, From: fromValue})
+		default:
+			fromMap, fromIsMap := fromValue.(map[string]interface{})
+			toMap, toIsMap := toValue.(map[string]interface{})
+			if fromIsMap && toIsMap {
+				diffMaps(stepName, parameter+"/", fromMap, toMap, differences)
+			} else if !reflect.DeepEqual(fromValue, toValue) {
+				*differences = append(*differences, Difference{Step: stepName, Parameter: parameter, Kind: DifferenceChanged, From: fromValue, To: toValue})
+			}
+		}
+	}
+}
+
+func formatDiffValue(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
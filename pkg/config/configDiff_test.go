@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffStepConfig(t *testing.T) {
+	from := map[string]interface{}{
+		"pomPath":      "pom.xml",
+		"verbose":      false,
+		"password":     "secret",
+		"goals":        []interface{}{"install"},
+		"cloudFoundry": map[string]interface{}{"org": "org", "space": "dev"},
+		"unchanged":    "value",
+		"removed":      "value",
+	}
+	to := map[string]interface{}{
+		"pomPath":      "api/pom.xml",
+		"verbose":      true,
+		"password":     "other",
+		"goals":        []interface{}{"install", "deploy"},
+		"cloudFoundry": map[string]interface{}{"org": "org", "space": "prod"},
+		"unchanged":    "value",
+		"added":        1,
+	}
+
+	differences := DiffStepConfig("mavenBuild", from, to, []string{"password"})
+
+	assert.Equal(t, []Difference{
+		{Step: "mavenBuild", Parameter: "added", Kind: DifferenceAdded, To: 1},
+		{Step: "mavenBuild", Parameter: "cloudFoundry/space", Kind: DifferenceChanged, From: "dev", To: "prod"},
+		{Step: "mavenBuild", Parameter: "goals", Kind: DifferenceChanged, From: []interface{}{"install"}, To: []interface{}{"install", "deploy"}},
+		{Step: "mavenBuild", Parameter: "password", Kind: DifferenceChanged, From: "****", To: "****"},
+		{Step: "mavenBuild", Parameter: "pomPath", Kind: DifferenceChanged, From: "pom.xml", To: "api/pom.xml"},
+		{Step: "mavenBuild", Parameter: "removed", Kind: DifferenceRemoved, From: "value"},
+		{Step: "mavenBuild", Parameter: "verbose", Kind: DifferenceChanged, From: false, To: true},
+	}, differences)
+
+	assert.Equal(t, "+ added: 1", differences[0].String())
+	assert.Equal(t, "~ goals: [\"install\"] -> [\"install\",\"deploy\"]", differences[2].String())
+	assert.Equal(t, "- removed: value", differences[5].String())
+	assert.Empty(t, DiffStepConfig("mavenBuild", from, from, nil))
+}
@@ -322,6 +322,60 @@ steps:
 		assert.Equal(t, "p1_value", stepConfig.Config["p0"])
 	})
 
+	t.Run("Resolve expressions", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal("Failed to create temporary directory")
+		}
+		defer os.RemoveAll(dir)
+		assert.NoError(t, piperenv.SetResourceParameter(dir, "commonPipelineEnvironment", "artifactVersion", "1.2.3"))
+		os.Setenv("PIPER_TEST_EXPRESSION", "Value")
+		defer os.Unsetenv("PIPER_TEST_EXPRESSION")
+
+		var c Config
+		c.SetEnvRootPath(dir)
+		testConf := `steps:
+  step1:
+    p0: "${{ cpe.artifactVersion }}-${{ lower(env.PIPER_TEST_EXPRESSION) }}"
+    p1: "${{ steps.step2.p1 }}"
+    p2: "${{ default(cpe.unknown, p3) }}"
+    p3: [a, b]
+  step2:
+    p1: "${{ semverBump(cpe.artifactVersion, 'minor') }}"
+`
+		stepConfig, err := c.GetStepConfig(nil, "", ioutil.NopCloser(strings.NewReader(testConf)), nil, false, StepFilters{Steps: []string{"p0", "p1", "p2", "p3"}}, nil, nil, nil, "stage1", "step1", []Alias{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2.3-value", stepConfig.Config["p0"])
+		assert.Equal(t, "1.3.0", stepConfig.Config["p1"])
+		assert.Equal(t, []interface{}{"a", "b"}, stepConfig.Config["p2"])
+	})
+
+	t.Run("Failure case expressions", func(t *testing.T) {
+		var c Config
+		testConf := "steps:\n  step1:\n    p0: ${{ cpe.unknown }}"
+		_, err := c.GetStepConfig(nil, "", ioutil.NopCloser(strings.NewReader(testConf)), nil, false, StepFilters{Steps: []string{"p0"}}, nil, nil, nil, "stage1", "step1", []Alias{})
+		assert.EqualError(t, err, "failed to resolve expressions of the configuration: failed to resolve 'p0': unresolved reference 'cpe.unknown'")
+	})
+
+	t.Run("Success case skip secrets", func(t *testing.T) {
+		os.Setenv("PIPER_TEST_SECRETS_TOKEN", "secret")
+		defer os.Unsetenv("PIPER_TEST_SECRETS_TOKEN")
+		params := []StepParameters{{Name: "token", ResourceRef: []ResourceReference{{Type: "envSecret", Paths: []string{"PIPER_TEST_SECRETS"}}}}}
+		testConf := "steps:\n  step1:\n    token: configured"
+
+		var c Config
+		stepConfig, err := c.GetStepConfig(nil, "", ioutil.NopCloser(strings.NewReader(testConf)), nil, false, StepFilters{Steps: []string{"token"}}, params, nil, nil, "stage1", "step1", []Alias{})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", stepConfig.Config["token"])
+
+		c = Config{}
+		c.SkipSecrets()
+		stepConfig, err = c.GetStepConfig(nil, "", ioutil.NopCloser(strings.NewReader(testConf)), nil, false, StepFilters{Steps: []string{"token"}}, params, nil, nil, "stage1", "step1", []Alias{})
+		assert.NoError(t, err)
+		assert.Equal(t, "configured", stepConfig.Config["token"])
+	})
+
 	t.Run("Failure case config", func(t *testing.T) {
 		var c Config
 		myConfig := ioutil.NopCloser(strings.NewReader("invalid config"))
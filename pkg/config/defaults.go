@@ -23,7 +23,7 @@ func (d *PipelineDefaults) ReadPipelineDefaults(defaultSources []io.ReadCloser)
 		}
 	}()
 
-	for _, def := range defaultSources {
+	for i, def := range defaultSources {
 		var c Config
 		var err error
 
@@ -37,6 +37,7 @@ func (d *PipelineDefaults) ReadPipelineDefaults(defaultSources []io.ReadCloser)
 			return NewParseError(fmt.Sprintf("error unmarshalling %q: %v", content, err))
 		}
 
+		c.source = sourceName(def, fmt.Sprintf("defaults #%v", i+1))
 		d.Defaults = append(d.Defaults, c)
 	}
 	return nil
@@ -0,0 +1,424 @@
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	piperhttp "github.com/SAP/jenkins-library/pkg/http"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultsSourceHTTP = "http"
+	defaultsSourceGit  = "git"
+	checksumSuffix     = "#sha256="
+	signatureSuffix    = ".sig"
+)
+
+// gitDefaultsRegex matches git sources of the form repository@ref:path,
+// e.g. https://github.com/org/defaults.git@v1.0.0:java/defaults.yml
+var gitDefaultsRegex = regexp.MustCompile(`^(?P<repository>.+)@(?P<ref>[^@:]+):(?P<path>[^@:]+)$`)
+
+// defaultsSource describes a remote source of custom defaults
+type defaultsSource struct {
+	kind       string
+	url        string
+	repository string
+	ref        string
+	path       string
+	// checksum is the expected sha256 of the content, provided via the suffix #sha256=<hex>
+	checksum string
+}
+
+// DefaultsLock pins the resolved versions of remote defaults
+type DefaultsLock struct {
+	Defaults []DefaultsLockEntry `json:"defaults"`
+}
+
+// DefaultsLockEntry contains the resolved version of a remote defaults source
+type DefaultsLockEntry struct {
+	Source string `json:"source"`
+	// Resolved is the commit in case of git sources, otherwise the URL
+	Resolved string `json:"resolved"`
+	SHA256   string `json:"sha256"`
+}
+
+// defaultsCacheIndex maps the sources to the content in the cache
+type defaultsCacheIndex map[string]defaultsCacheEntry
+
+type defaultsCacheEntry struct {
+	SHA256   string    `json:"sha256"`
+	Resolved string    `json:"resolved"`
+	Fetched  time.Time `json:"fetched"`
+}
+
+// DefaultsResolver opens custom defaults from local files, http(s) URLs and git repositories (repository@ref:path).
+// Fetched content is kept in a content-addressed cache which is also used in case the source is not reachable.
+type DefaultsResolver struct {
+	// CacheDir is the directory of the cache, caching is disabled if empty
+	CacheDir string
+	// TTL defines how long cached content is used without fetching it again
+	TTL time.Duration
+	// LockFile pins the resolved versions of remote defaults, pinning is disabled if empty
+	LockFile string
+	// PublicKey is a base64 encoded ed25519 key, if set the detached signatures (<source>.sig) of remote defaults are verified
+	PublicKey string
+
+	openFile func(name string) (io.ReadCloser, error)
+	fetchURL func(url string) ([]byte, error)
+	fetchGit func(repository, ref, path string) ([]byte, string, error)
+	now      func() time.Time
+	lock     *DefaultsLock
+}
+
+// NewDefaultsResolver creates a resolver which opens local files via openFile
+func NewDefaultsResolver(cacheDir string, ttl time.Duration, lockFile, publicKey string, openFile func(name string) (io.ReadCloser, error)) *DefaultsResolver {
+	if openFile == nil {
+		openFile = OpenPiperFile
+	}
+	return &DefaultsResolver{
+		CacheDir:  cacheDir,
+		TTL:       ttl,
+		LockFile:  lockFile,
+		PublicKey: publicKey,
+		openFile:  openFile,
+		fetchURL:  fetchURL,
+		fetchGit:  fetchGitFile,
+		now:       time.Now,
+	}
+}
+
+// SetDefaultsResolver defines the resolver which is used to open the custom defaults of the project configuration
+func (c *Config) SetDefaultsResolver(resolver *DefaultsResolver) {
+	c.openFile = resolver.Open
+}
+
+// Open returns the content of the defaults, remote sources are resolved via lock file, cache or by fetching them
+func (r *DefaultsResolver) Open(name string) (io.ReadCloser, error) {
+	source, remote := parseDefaultsSource(name)
+	if !remote {
+		return r.openFile(name)
+	}
+
+	content, err := r.resolve(name, source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve defaults '%v'", name)
+	}
+	return &namedReadCloser{ReadCloser: ioutil.NopCloser(bytes.NewReader(content)), name: name}, nil
+}
+
+func (r *DefaultsResolver) resolve(name string, source defaultsSource) ([]byte, error) {
+	locked, err := r.lockEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	if locked != nil {
+		return r.resolveLocked(name, source, *locked)
+	}
+
+	index := r.readCacheIndex()
+	cached, isCached := index[name]
+	if isCached && r.now().Sub(cached.Fetched) < r.TTL {
+		if content, err := r.readCache(cached.SHA256); err == nil {
+			log.Entry().Debugf("Using cached defaults '%v'", name)
+			return r.useCached(name, source, cached, content)
+		}
+	}
+
+	content, resolved, err := r.fetch(source, source.ref)
+	if err != nil {
+		if isCached {
+			if content, cacheErr := r.readCache(cached.SHA256); cacheErr == nil {
+				log.Entry().WithError(err).Warnf("Failed to fetch defaults '%v', using cached version from %v", name, cached.Fetched.Format(time.RFC3339))
+				return r.useCached(name, source, cached, content)
+			}
+		}
+		return nil, err
+	}
+	if err := verifyChecksum(content, source.checksum); err != nil {
+		return nil, err
+	}
+
+	checksum := sha256Hex(content)
+	r.writeCache(index, name, defaultsCacheEntry{SHA256: checksum, Resolved: resolved, Fetched: r.now()}, content)
+	if err := r.addLockEntry(DefaultsLockEntry{Source: name, Resolved: resolved, SHA256: checksum}); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// useCached returns the cached content, it is recorded in the lock file the same as freshly fetched content
+func (r *DefaultsResolver) useCached(name string, source defaultsSource, cached defaultsCacheEntry, content []byte) ([]byte, error) {
+	if err := verifyChecksum(content, source.checksum); err != nil {
+		return nil, err
+	}
+	if err := r.addLockEntry(DefaultsLockEntry{Source: name, Resolved: cached.Resolved, SHA256: cached.SHA256}); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// resolveLocked returns the content pinned by the lock file, the cache is used independent of the TTL since the content is identified by its checksum
+func (r *DefaultsResolver) resolveLocked(name string, source defaultsSource, locked DefaultsLockEntry) ([]byte, error) {
+	if content, err := r.readCache(locked.SHA256); err == nil {
+		log.Entry().Debugf("Using cached defaults '%v' pinned to %v", name, locked.SHA256)
+		return content, nil
+	}
+
+	ref := source.ref
+	if source.kind == defaultsSourceGit {
+		ref = locked.Resolved
+	}
+	content, resolved, err := r.fetch(source, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChecksum(content, locked.SHA256); err != nil {
+		return nil, errors.Wrapf(err, "content does not match the lock file %v", r.LockFile)
+	}
+	r.writeCache(r.readCacheIndex(), name, defaultsCacheEntry{SHA256: locked.SHA256, Resolved: resolved, Fetched: r.now()}, content)
+	return content, nil
+}
+
+// fetch retrieves the content and verifies its signature, it returns the resolved version, i.e. the commit in case of git sources
+func (r *DefaultsResolver) fetch(source defaultsSource, ref string) ([]byte, string, error) {
+	var content, signature []byte
+	var resolved string
+	var err error
+
+	if source.kind == defaultsSourceGit {
+		log.Entry().Infof("Fetching defaults '%v' from %v@%v", source.path, source.repository, ref)
+		if content, resolved, err = r.fetchGit(source.repository, ref, source.path); err != nil {
+			return nil, "", err
+		}
+		if len(r.PublicKey) > 0 {
+			if signature, _, err = r.fetchGit(source.repository, resolved, source.path+signatureSuffix); err != nil {
+				return nil, "", errors.Wrap(err, "failed to fetch signature")
+			}
+		}
+	} else {
+		log.Entry().Infof("Fetching defaults from %v", source.url)
+		if content, err = r.fetchURL(source.url); err != nil {
+			return nil, "", err
+		}
+		resolved = source.url
+		if len(r.PublicKey) > 0 {
+			if signature, err = r.fetchURL(source.url + signatureSuffix); err != nil {
+				return nil, "", errors.Wrap(err, "failed to fetch signature")
+			}
+		}
+	}
+
+	if len(r.PublicKey) > 0 {
+		if err := verifySignature(content, signature, r.PublicKey); err != nil {
+			return nil, "", err
+		}
+	}
+	return content, resolved, nil
+}
+
+func (r *DefaultsResolver) lockEntry(name string) (*DefaultsLockEntry, error) {
+	if len(r.LockFile) == 0 {
+		return nil, nil
+	}
+	if r.lock == nil {
+		r.lock = &DefaultsLock{}
+		content, err := ioutil.ReadFile(r.LockFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "failed to read lock file %v", r.LockFile)
+		}
+		if err == nil {
+			if err := yaml.Unmarshal(content, r.lock); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse lock file %v", r.LockFile)
+			}
+		}
+	}
+	for _, entry := range r.lock.Defaults {
+		if entry.Source == name {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *DefaultsResolver) addLockEntry(entry DefaultsLockEntry) error {
+	if len(r.LockFile) == 0 {
+		return nil
+	}
+	r.lock.Defaults = append(r.lock.Defaults, entry)
+	content, err := yaml.Marshal(r.lock)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal lock file")
+	}
+	if err := os.MkdirAll(filepath.Dir(r.LockFile), 0777); err != nil {
+		return errors.Wrapf(err, "failed to create directory of lock file %v", r.LockFile)
+	}
+	if err := ioutil.WriteFile(r.LockFile, content, 0666); err != nil {
+		return errors.Wrapf(err, "failed to write lock file %v", r.LockFile)
+	}
+	log.Entry().Infof("Pinned defaults '%v' to %v in %v", entry.Source, entry.Resolved, r.LockFile)
+	return nil
+}
+
+func (r *DefaultsResolver) readCacheIndex() defaultsCacheIndex {
+	index := defaultsCacheIndex{}
+	if len(r.CacheDir) == 0 {
+		return index
+	}
+	content, err := ioutil.ReadFile(filepath.Join(r.CacheDir, "index.json"))
+	if err != nil {
+		return index
+	}
+	if err := json.Unmarshal(content, &index); err != nil {
+		log.Entry().WithError(err).Warn("Ignoring invalid index of the defaults cache")
+		return defaultsCacheIndex{}
+	}
+	return index
+}
+
+func (r *DefaultsResolver) readCache(checksum string) ([]byte, error) {
+	if len(r.CacheDir) == 0 {
+		return nil, errors.New("cache disabled")
+	}
+	content, err := ioutil.ReadFile(filepath.Join(r.CacheDir, "sha256", checksum))
+	if err != nil {
+		return nil, err
+	}
+	// the content is identified by its checksum, modified files must not be used
+	if err := verifyChecksum(content, checksum); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// writeCache stores the content, failures are not critical since the content is available
+func (r *DefaultsResolver) writeCache(index defaultsCacheIndex, name string, entry defaultsCacheEntry, content []byte) {
+	if len(r.CacheDir) == 0 {
+		return
+	}
+	if err := os.MkdirAll(filepath.Join(r.CacheDir, "sha256"), 0777); err != nil {
+		log.Entry().WithError(err).Warn("Failed to create defaults cache")
+		return
+	}
+	if err := ioutil.WriteFile(filepath.Join(r.CacheDir, "sha256", entry.SHA256), content, 0666); err != nil {
+		log.Entry().WithError(err).Warn("Failed to write defaults cache")
+		return
+	}
+	index[name] = entry
+	indexContent, _ := json.MarshalIndent(index, "", "  ")
+	if err := ioutil.WriteFile(filepath.Join(r.CacheDir, "index.json"), indexContent, 0666); err != nil {
+		log.Entry().WithError(err).Warn("Failed to write index of defaults cache")
+	}
+}
+
+// parseDefaultsSource returns the remote source, local files are reported as not remote
+func parseDefaultsSource(name string) (defaultsSource, bool) {
+	source := defaultsSource{}
+	if index := strings.LastIndex(name, checksumSuffix); index >= 0 {
+		source.checksum = name[index+len(checksumSuffix):]
+		name = name[:index]
+	}
+	if matches := gitDefaultsRegex.FindStringSubmatch(name); matches != nil && strings.Contains(matches[1], "/") {
+		source.kind = defaultsSourceGit
+		source.repository = matches[1]
+		source.ref = matches[2]
+		source.path = matches[3]
+		return source, true
+	}
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		source.kind = defaultsSourceHTTP
+		source.url = name
+		return source, true
+	}
+	return source, false
+}
+
+func sha256Hex(content []byte) string {
+	checksum := sha256.Sum256(content)
+	return hex.EncodeToString(checksum[:])
+}
+
+func verifyChecksum(content []byte, expected string) error {
+	if len(expected) == 0 {
+		return nil
+	}
+	if actual := sha256Hex(content); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch: expected sha256 %v but got %v", expected, actual)
+	}
+	return nil
+}
+
+// verifySignature verifies the base64 encoded ed25519 signature of the content
+func verifySignature(content, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid public key: expected base64 encoded ed25519 key")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errors.Wrap(err, "invalid signature")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), content, decoded) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+func fetchURL(url string) ([]byte, error) {
+	client := piperhttp.Client{}
+	response, err := client.SendRequest("GET", url, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	return ioutil.ReadAll(response.Body)
+}
+
+// fetchGitFile reads a file of a branch, tag or commit and returns its content together with the commit
+func fetchGitFile(repository, ref, path string) ([]byte, string, error) {
+	repo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{URL: repository, Tags: git.AllTags})
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to clone %v", repository)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		// branches are only available as remote branches in a bare clone
+		if hash, err = repo.ResolveRevision(plumbing.Revision("origin/" + ref)); err != nil {
+			return nil, "", errors.Wrapf(err, "failed to resolve '%v' in %v", ref, repository)
+		}
+	}
+	if tag, err := repo.TagObject(*hash); err == nil {
+		// annotated tags point to the commit
+		hash = &tag.Target
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to read commit %v", hash)
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to read %v at %v", path, commit.Hash)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to read %v at %v", path, commit.Hash)
+	}
+	return []byte(content), commit.Hash.String(), nil
+}
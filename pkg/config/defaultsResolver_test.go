@@ -0,0 +1,232 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type defaultsFetchMock struct {
+	content map[string]string
+	calls   []string
+}
+
+func (f *defaultsFetchMock) fetchURL(url string) ([]byte, error) {
+	f.calls = append(f.calls, url)
+	if content, ok := f.content[url]; ok {
+		return []byte(content), nil
+	}
+	return nil, fmt.Errorf("%v not found", url)
+}
+
+func (f *defaultsFetchMock) fetchGit(repository, ref, path string) ([]byte, string, error) {
+	key := fmt.Sprintf("%v@%v:%v", repository, ref, path)
+	f.calls = append(f.calls, key)
+	if content, ok := f.content[key]; ok {
+		return []byte(content), "commit-" + ref, nil
+	}
+	return nil, "", fmt.Errorf("%v not found", key)
+}
+
+func newTestDefaultsResolver(dir string, fetch *defaultsFetchMock) *DefaultsResolver {
+	resolver := NewDefaultsResolver(filepath.Join(dir, "cache"), time.Hour, "", "", func(name string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("local: " + name)), nil
+	})
+	resolver.fetchURL = fetch.fetchURL
+	resolver.fetchGit = fetch.fetchGit
+	return resolver
+}
+
+func readAll(t *testing.T, r io.ReadCloser, err error) string {
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestParseDefaultsSource(t *testing.T) {
+	tt := []struct {
+		name     string
+		expected defaultsSource
+		remote   bool
+	}{
+		{name: ".pipeline/defaults.yaml", remote: false},
+		{name: "https://example.org/defaults.yml", expected: defaultsSource{kind: defaultsSourceHTTP, url: "https://example.org/defaults.yml"}, remote: true},
+		{name: "https://example.org/defaults.yml#sha256=abc", expected: defaultsSource{kind: defaultsSourceHTTP, url: "https://example.org/defaults.yml", checksum: "abc"}, remote: true},
+		{name: "https://github.com/org/defaults.git@v1.0.0:java/defaults.yml", expected: defaultsSource{kind: defaultsSourceGit, repository: "https://github.com/org/defaults.git", ref: "v1.0.0", path: "java/defaults.yml"}, remote: true},
+		{name: "git@github.com:org/defaults.git@feature/x:defaults.yml", expected: defaultsSource{kind: defaultsSourceGit, repository: "git@github.com:org/defaults.git", ref: "feature/x", path: "defaults.yml"}, remote: true},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			source, remote := parseDefaultsSource(test.name)
+			assert.Equal(t, test.remote, remote)
+			if test.remote {
+				assert.Equal(t, test.expected, source)
+			}
+		})
+	}
+}
+
+func TestDefaultsResolver(t *testing.T) {
+	const url = "https://example.org/defaults.yml"
+	const content = "general:\n  p0: remote\n"
+
+	t.Run("local file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		resolver := newTestDefaultsResolver(dir, &defaultsFetchMock{})
+
+		r, err := resolver.Open("defaults.yml")
+		assert.Equal(t, "local: defaults.yml", readAll(t, r, err))
+	})
+
+	t.Run("cache within TTL and offline fallback", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		fetch := &defaultsFetchMock{content: map[string]string{url: content}}
+		resolver := newTestDefaultsResolver(dir, fetch)
+		now := time.Now()
+		resolver.now = func() time.Time { return now }
+
+		r, err := resolver.Open(url)
+		assert.Equal(t, content, readAll(t, r, err))
+		assert.Equal(t, url, sourceName(r, ""))
+		r, err = resolver.Open(url)
+		assert.Equal(t, content, readAll(t, r, err))
+		assert.Equal(t, []string{url}, fetch.calls)
+
+		// outdated cache entries are only used if the source is not reachable
+		now = now.Add(2 * time.Hour)
+		fetch.content = map[string]string{}
+		r, err = resolver.Open(url)
+		assert.Equal(t, content, readAll(t, r, err))
+		assert.Equal(t, []string{url, url}, fetch.calls)
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		resolver := newTestDefaultsResolver(dir, &defaultsFetchMock{content: map[string]string{url: content}})
+
+		r, err := resolver.Open(url + "#sha256=" + sha256Hex([]byte(content)))
+		assert.Equal(t, content, readAll(t, r, err))
+
+		_, err = resolver.Open(url + "#sha256=0000")
+		assert.EqualError(t, err, "failed to resolve defaults 'https://example.org/defaults.yml#sha256=0000': checksum mismatch: expected sha256 0000 but got "+sha256Hex([]byte(content)))
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		publicKey, privateKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(content)))
+		fetch := &defaultsFetchMock{content: map[string]string{url: content, url + ".sig": signature, "https://example.org/other.yml": "other", "https://example.org/other.yml.sig": signature}}
+		resolver := newTestDefaultsResolver(dir, fetch)
+		resolver.PublicKey = base64.StdEncoding.EncodeToString(publicKey)
+
+		r, err := resolver.Open(url)
+		assert.Equal(t, content, readAll(t, r, err))
+
+		_, err = resolver.Open("https://example.org/other.yml")
+		assert.EqualError(t, err, "failed to resolve defaults 'https://example.org/other.yml': signature verification failed")
+	})
+
+	t.Run("lock file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		const source = "https://github.com/org/defaults.git@main:defaults.yml"
+		fetch := &defaultsFetchMock{content: map[string]string{"https://github.com/org/defaults.git@main:defaults.yml": content}}
+		resolver := newTestDefaultsResolver(dir, fetch)
+		resolver.LockFile = filepath.Join(dir, ".pipeline", "defaults.lock")
+
+		r, err := resolver.Open(source)
+		assert.Equal(t, content, readAll(t, r, err))
+		lock, err := ioutil.ReadFile(resolver.LockFile)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("defaults:\n- resolved: commit-main\n  sha256: %v\n  source: %v\n", sha256Hex([]byte(content)), source), string(lock))
+
+		// the pinned commit is fetched in case the content is not cached
+		fetch.content = map[string]string{"https://github.com/org/defaults.git@commit-main:defaults.yml": content}
+		resolver = newTestDefaultsResolver(filepath.Join(dir, "other"), fetch)
+		resolver.LockFile = filepath.Join(dir, ".pipeline", "defaults.lock")
+		r, err = resolver.Open(source)
+		assert.Equal(t, content, readAll(t, r, err))
+
+		// cached content is recorded in a new lock file, both within the TTL and as offline fallback
+		for _, offline := range []bool{false, true} {
+			resolver = newTestDefaultsResolver(filepath.Join(dir, "other"), fetch)
+			resolver.LockFile = filepath.Join(dir, fmt.Sprintf("offline-%v.lock", offline))
+			if offline {
+				resolver.TTL = 0
+				fetch.content = map[string]string{}
+			}
+			r, err = resolver.Open(source)
+			assert.Equal(t, content, readAll(t, r, err))
+			lock, err = ioutil.ReadFile(resolver.LockFile)
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("defaults:\n- resolved: commit-commit-main\n  sha256: %v\n  source: %v\n", sha256Hex([]byte(content)), source), string(lock))
+		}
+
+		// the content must match the lock file
+		fetch.content = map[string]string{"https://github.com/org/defaults.git@commit-main:defaults.yml": "changed"}
+		resolver = newTestDefaultsResolver(filepath.Join(dir, "another"), fetch)
+		resolver.LockFile = filepath.Join(dir, ".pipeline", "defaults.lock")
+		_, err = resolver.Open(source)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "content does not match the lock file")
+	})
+
+	t.Run("fetch failure", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		resolver := newTestDefaultsResolver(dir, &defaultsFetchMock{})
+
+		_, err = resolver.Open(url)
+		assert.EqualError(t, err, "failed to resolve defaults 'https://example.org/defaults.yml': https://example.org/defaults.yml not found")
+	})
+}
+
+func TestFetchGitFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "defaults.yml"), []byte("general:\n  p0: v1\n"), 0666))
+	_, err = worktree.Add("defaults.yml")
+	require.NoError(t, err)
+	commit, err := worktree.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.org", When: time.Now()}})
+	require.NoError(t, err)
+	_, err = repo.CreateTag("v1", commit, nil)
+	require.NoError(t, err)
+
+	content, resolved, err := fetchGitFile(dir, "v1", "defaults.yml")
+	require.NoError(t, err)
+	assert.Equal(t, "general:\n  p0: v1\n", string(content))
+	assert.Equal(t, commit.String(), resolved)
+
+	_, _, err = fetchGitFile(dir, "v1", "unknown.yml")
+	assert.Contains(t, fmt.Sprint(err), "failed to read unknown.yml")
+}
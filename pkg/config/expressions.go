@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/SAP/jenkins-library/pkg/config/interpolation"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/piperenv"
+	"github.com/go-git/go-git/v5"
+	"github.com/pkg/errors"
+)
+
+// SetEnvRootPath sets the root path of the pipeline environment which is referenced by expressions like ${{ cpe.git.commitId }}
+func (c *Config) SetEnvRootPath(path string) {
+	c.envRootPath = path
+}
+
+// resolveExpressions resolves the expressions of the form ${{ expression }} within the step configuration
+func (c *Config) resolveExpressions(stepConfig *StepConfig) error {
+	original := map[string]interface{}{}
+	for key, value := range stepConfig.Config {
+		original[key] = value
+	}
+	context := &interpolation.Context{
+		Values: stepConfig.Config,
+		Env:    os.LookupEnv,
+		CPE:    c.cpeValue,
+		Steps:  c.stepValues,
+		Git:    gitValue,
+	}
+	if err := context.ResolveValues(); err != nil {
+		log.SetErrorCategory(log.ErrorConfiguration)
+		return errors.Wrap(err, "failed to resolve expressions of the configuration")
+	}
+	for key, value := range stepConfig.Config {
+		if !reflect.DeepEqual(original[key], value) {
+			stepConfig.recordLayer(key, Layer{Source: SourceExpression, Path: fmt.Sprint(original[key]), Value: value})
+		}
+	}
+	return nil
+}
+
+// cpeValue reads a value of the commonPipelineEnvironment, non-string values are stored with the suffix .json
+func (c *Config) cpeValue(key string) (interface{}, bool) {
+	if len(c.envRootPath) == 0 {
+		return nil, false
+	}
+	if value := piperenv.GetResourceParameter(c.envRootPath, "commonPipelineEnvironment", key); len(value) > 0 {
+		return value, true
+	}
+	if value := piperenv.GetResourceParameter(c.envRootPath, "commonPipelineEnvironment", key+".json"); len(value) > 0 {
+		var unmarshalledValue interface{}
+		if err := json.Unmarshal([]byte(value), &unmarshalledValue); err != nil {
+			log.Entry().Debugf("Failed to unmarshal: %v", value)
+			return nil, false
+		}
+		return unmarshalledValue, true
+	}
+	return nil, false
+}
+
+// stepValues provides the configuration of a step from the defaults and the project configuration
+func (c *Config) stepValues(stepName string) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, def := range c.defaults.Defaults {
+		for key, value := range def.Steps[stepName] {
+			values[key] = value
+		}
+	}
+	for key, value := range c.Steps[stepName] {
+		values[key] = value
+	}
+	return values
+}
+
+// gitValue provides metadata of the git repository in the current directory
+func gitValue(name string) (string, bool) {
+	repository, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		log.Entry().Debugf("Failed to open git repository: %v", err)
+		return "", false
+	}
+	switch name {
+	case "commitId":
+		head, err := repository.Head()
+		if err != nil {
+			return "", false
+		}
+		return head.Hash().String(), true
+	case "branch":
+		// CI systems usually check out a detached HEAD and provide the branch via environment
+		if branch := os.Getenv("BRANCH_NAME"); len(branch) > 0 {
+			return branch, true
+		}
+		head, err := repository.Head()
+		if err != nil || !head.Name().IsBranch() {
+			return "", false
+		}
+		return head.Name().Short(), true
+	case "remoteUrl":
+		remote, err := repository.Remote("origin")
+		if err != nil || len(remote.Config().URLs) == 0 {
+			return "", false
+		}
+		return remote.Config().URLs[0], true
+	}
+	return "", false
+}
@@ -0,0 +1,344 @@
+package interpolation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+)
+
+// node is an element of a parsed expression
+type node interface {
+	evaluate(c *Context) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+type referenceNode struct {
+	path []string
+}
+
+type callNode struct {
+	name      string
+	arguments []node
+}
+
+// UnresolvedReferenceError indicates that a referenced value does not exist
+type UnresolvedReferenceError struct {
+	Reference string
+}
+
+func (e *UnresolvedReferenceError) Error() string {
+	return fmt.Sprintf("unresolved reference '%v'", e.Reference)
+}
+
+// function evaluates a function call, the arguments are evaluated lazily in order to allow fallbacks like in default
+type function func(c *Context, arguments []node) (interface{}, error)
+
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		"default":    defaultFunction,
+		"lower":      stringFunction(1, func(args []string) (interface{}, error) { return strings.ToLower(args[0]), nil }),
+		"upper":      stringFunction(1, func(args []string) (interface{}, error) { return strings.ToUpper(args[0]), nil }),
+		"replace":    stringFunction(3, func(args []string) (interface{}, error) { return strings.ReplaceAll(args[0], args[1], args[2]), nil }),
+		"semverBump": stringFunction(2, func(args []string) (interface{}, error) { return semverBump(args[0], args[1]) }),
+		"join":       joinFunction,
+	}
+}
+
+func (n *literalNode) evaluate(*Context) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *referenceNode) evaluate(c *Context) (interface{}, error) {
+	return c.lookup(n.path)
+}
+
+func (n *callNode) evaluate(c *Context) (interface{}, error) {
+	f, ok := functions[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%v'", n.name)
+	}
+	result, err := f(c, n.arguments)
+	if err != nil {
+		if _, ok := errors.Cause(err).(*UnresolvedReferenceError); ok {
+			return nil, err
+		}
+		return nil, errors.Wrapf(err, "%v()", n.name)
+	}
+	return result, nil
+}
+
+// defaultFunction returns the first argument unless it is unresolved, null or empty, otherwise the second one
+func defaultFunction(c *Context, arguments []node) (interface{}, error) {
+	if len(arguments) != 2 {
+		return nil, fmt.Errorf("expects 2 arguments but got %v", len(arguments))
+	}
+	value, err := arguments[0].evaluate(c)
+	if err != nil {
+		if _, ok := errors.Cause(err).(*UnresolvedReferenceError); !ok {
+			return nil, err
+		}
+	}
+	if err != nil || value == nil || value == "" {
+		return arguments[1].evaluate(c)
+	}
+	return value, nil
+}
+
+// joinFunction joins the entries of a list with a separator
+func joinFunction(c *Context, arguments []node) (interface{}, error) {
+	if len(arguments) != 2 {
+		return nil, fmt.Errorf("expects 2 arguments but got %v", len(arguments))
+	}
+	list, err := arguments[0].evaluate(c)
+	if err != nil {
+		return nil, err
+	}
+	separator, err := arguments[1].evaluate(c)
+	if err != nil {
+		return nil, err
+	}
+	entries := []string{}
+	switch values := list.(type) {
+	case []interface{}:
+		for _, value := range values {
+			entries = append(entries, toString(value))
+		}
+	case []string:
+		entries = values
+	default:
+		return nil, fmt.Errorf("expects a list but got '%v'", toString(list))
+	}
+	return strings.Join(entries, toString(separator)), nil
+}
+
+// stringFunction creates a function which expects a fixed number of arguments which are converted to strings
+func stringFunction(count int, f func(args []string) (interface{}, error)) function {
+	return func(c *Context, arguments []node) (interface{}, error) {
+		if len(arguments) != count {
+			return nil, fmt.Errorf("expects %v arguments but got %v", count, len(arguments))
+		}
+		values := []string{}
+		for _, argument := range arguments {
+			value, err := argument.evaluate(c)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, toString(value))
+		}
+		return f(values)
+	}
+}
+
+// semverBump increases the major, minor or patch part of a semantic version, a leading "v" is kept
+func semverBump(version, part string) (string, error) {
+	parsed, err := semver.NewVersion(version)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid version '%v'", version)
+	}
+	var bumped semver.Version
+	switch part {
+	case "major":
+		bumped = parsed.IncMajor()
+	case "minor":
+		bumped = parsed.IncMinor()
+	case "patch":
+		bumped = parsed.IncPatch()
+	default:
+		return "", fmt.Errorf("unknown version part '%v', supported are: major, minor, patch", part)
+	}
+	if strings.HasPrefix(version, "v") {
+		return "v" + bumped.String(), nil
+	}
+	return bumped.String(), nil
+}
+
+// toString formats values for the use within strings, lists are joined by commas
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		entries := []string{}
+		for _, entry := range v {
+			entries = append(entries, toString(entry))
+		}
+		return strings.Join(entries, ",")
+	case []string:
+		return strings.Join(v, ",")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// parser parses expressions of the form
+//
+//	reference:  name, env.HOME, cpe.git.commitId, steps.mavenBuild.pomPath, git.branch
+//	literal:    'text', "text", 42, 1.5, true, false, null
+//	call:       default(cpe.artifactVersion, '1.0.0'), lower(git.branch)
+type parser struct {
+	input    []rune
+	position int
+}
+
+// parseExpression parses the expression
+func parseExpression(expression string) (node, error) {
+	p := &parser{input: []rune(expression)}
+	result, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.position < len(p.input) {
+		return nil, p.errorf("unexpected '%v'", string(p.input[p.position]))
+	}
+	return result, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid expression '%v' at position %v: %v", string(p.input), p.position+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.position < len(p.input) && unicode.IsSpace(p.input[p.position]) {
+		p.position++
+	}
+}
+
+func (p *parser) parse() (node, error) {
+	p.skipSpace()
+	if p.position >= len(p.input) {
+		return nil, p.errorf("value expected")
+	}
+	current := p.input[p.position]
+	switch {
+	case current == '\'' || current == '"':
+		return p.parseString(current)
+	case current == '-' || unicode.IsDigit(current):
+		return p.parseNumber()
+	case isNameCharacter(current):
+		return p.parseName()
+	}
+	return nil, p.errorf("unexpected '%v'", string(current))
+}
+
+func (p *parser) parseString(quote rune) (node, error) {
+	p.position++
+	value := strings.Builder{}
+	for p.position < len(p.input) {
+		current := p.input[p.position]
+		p.position++
+		if current == '\\' && p.position < len(p.input) {
+			value.WriteRune(p.input[p.position])
+			p.position++
+			continue
+		}
+		if current == quote {
+			return &literalNode{value: value.String()}, nil
+		}
+		value.WriteRune(current)
+	}
+	return nil, p.errorf("unterminated string")
+}
+
+func (p *parser) parseNumber() (node, error) {
+	start := p.position
+	p.position++
+	for p.position < len(p.input) && (unicode.IsDigit(p.input[p.position]) || p.input[p.position] == '.') {
+		p.position++
+	}
+	text := string(p.input[start:p.position])
+	if number, err := strconv.Atoi(text); err == nil {
+		return &literalNode{value: number}, nil
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		p.position = start
+		return nil, p.errorf("invalid number '%v'", text)
+	}
+	return &literalNode{value: number}, nil
+}
+
+func (p *parser) parseName() (node, error) {
+	path := []string{}
+	for {
+		start := p.position
+		for p.position < len(p.input) && isNameCharacter(p.input[p.position]) {
+			p.position++
+		}
+		if start == p.position {
+			return nil, p.errorf("name expected")
+		}
+		path = append(path, string(p.input[start:p.position]))
+		if p.position < len(p.input) && p.input[p.position] == '.' {
+			p.position++
+			continue
+		}
+		break
+	}
+
+	p.skipSpace()
+	if p.position < len(p.input) && p.input[p.position] == '(' {
+		if len(path) > 1 {
+			return nil, p.errorf("unknown function '%v'", strings.Join(path, "."))
+		}
+		return p.parseCall(path[0])
+	}
+
+	if len(path) == 1 {
+		switch path[0] {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+	}
+	return &referenceNode{path: path}, nil
+}
+
+func (p *parser) parseCall(name string) (node, error) {
+	p.position++
+	call := &callNode{name: name, arguments: []node{}}
+	p.skipSpace()
+	if p.position < len(p.input) && p.input[p.position] == ')' {
+		p.position++
+		return call, nil
+	}
+	for {
+		argument, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		call.arguments = append(call.arguments, argument)
+		p.skipSpace()
+		if p.position >= len(p.input) {
+			return nil, p.errorf("')' expected")
+		}
+		switch p.input[p.position] {
+		case ',':
+			p.position++
+		case ')':
+			p.position++
+			return call, nil
+		default:
+			return nil, p.errorf("',' or ')' expected")
+		}
+	}
+}
+
+func isNameCharacter(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
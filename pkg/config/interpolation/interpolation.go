@@ -3,69 +3,287 @@ package interpolation
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/pkg/errors"
 )
 
 const (
-	maxLookupDepth = 10
+	maxLookupDepth  = 10
+	expressionStart = "${{"
+	expressionEnd   = "}}"
+	// escapedStart keeps a literal expressionStart, e.g. for GitHub Actions snippets within the configuration
+	escapedStart = "$" + expressionStart
 )
 
 var (
-	lookupRegex   *regexp.Regexp = regexp.MustCompile(`\$\((?P<property>[a-zA-Z0-9\.]*)\)`)
-	captureGroups                = setupCaptureGroups(lookupRegex.SubexpNames())
+	// lookupRegex matches the plain property lookups of the form $(property)
+	lookupRegex *regexp.Regexp = regexp.MustCompile(`\$\((?P<property>[a-zA-Z0-9\.]*)\)`)
 )
 
-// ResolveMap interpolates every string value of a map and tries to lookup references to other properties of that map
-func ResolveMap(config map[string]interface{}) bool {
-	for key, value := range config {
-		if str, ok := value.(string); ok {
-			resolvedStr, ok := ResolveString(str, config)
-			if !ok {
-				return false
+// Context provides the values which can be referenced within expressions of the form ${{ expression }}.
+// A literal ${{ is written as $${{.
+// An expression which makes up the complete string keeps the type of its result, e.g. a list or a boolean.
+// Within longer strings the results are formatted as text.
+//
+// Supported references:
+//
+//	name                     value of the map which is resolved, e.g. another parameter of the step
+//	env.NAME                 environment variable
+//	cpe.path.to.value        value of the commonPipelineEnvironment, e.g. cpe.git.commitId for git/commitId
+//	steps.stepName.param     configuration of another step
+//	git.name                 git metadata, e.g. git.commitId, git.branch, git.remoteUrl
+//
+// Supported functions: default(value, fallback), lower(text), upper(text), replace(text, old, new),
+// join(list, separator), semverBump(version, 'major'|'minor'|'patch')
+type Context struct {
+	// Values contains the values which are referenced by their name
+	Values map[string]interface{}
+	// Env looks up environment variables
+	Env func(name string) (string, bool)
+	// CPE looks up values of the commonPipelineEnvironment by their key, e.g. git/commitId
+	CPE func(key string) (interface{}, bool)
+	// Steps looks up the configuration of another step
+	Steps func(stepName string) map[string]interface{}
+	// Git looks up git metadata
+	Git func(name string) (string, bool)
+
+	// legacy enables the plain lookups of the form $(property)
+	legacy bool
+	// resolving contains the names of the values which are currently resolved in order to detect cycles
+	resolving []string
+	resolved  map[string]interface{}
+	// depth counts the nested contexts of other steps
+	depth int
+}
+
+// ResolveValues resolves the expressions within all values of the context in place
+func (c *Context) ResolveValues() error {
+	keys := []string{}
+	for key := range c.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := c.value(key); err != nil {
+			return errors.Wrapf(err, "failed to resolve '%v'", key)
+		}
+	}
+	for _, key := range keys {
+		c.Values[key] = c.resolved[key]
+	}
+	return nil
+}
+
+// Resolve resolves the expressions within a value, maps and lists are resolved recursively
+func (c *Context) Resolve(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return c.resolveString(v)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, entry := range v {
+			resolved, err := c.Resolve(entry)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = resolved
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, entry := range v {
+			resolved, err := c.Resolve(entry)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, resolved)
+		}
+		return result, nil
+	case []string:
+		result := make([]string, 0, len(v))
+		for _, entry := range v {
+			resolved, err := c.resolveString(entry)
+			if err != nil {
+				return nil, err
 			}
-			config[key] = resolvedStr
+			result = append(result, toString(resolved))
 		}
+		return result, nil
 	}
-	return true
+	return value, nil
 }
 
-func resolveString(str string, lookupMap map[string]interface{}, n int) (string, bool) {
-	matches := lookupRegex.FindAllStringSubmatch(str, -1)
-	if len(matches) == 0 {
-		return str, true
+func (c *Context) resolveString(str string) (interface{}, error) {
+	if c.legacy {
+		str = lookupRegex.ReplaceAllString(str, expressionStart+" $1 "+expressionEnd)
 	}
-	if n == maxLookupDepth {
-		log.Entry().Errorf("Property could not be resolved with a depth of %d. '%s' is still left to resolve", n, str)
-		return "", false
+	if !strings.Contains(str, expressionStart) {
+		return str, nil
 	}
-	for _, match := range matches {
-		property := match[captureGroups["property"]]
-		if propVal, ok := lookupMap[property]; ok {
-			str = strings.ReplaceAll(str, fmt.Sprintf("$(%s)", property), propVal.(string))
-		} else {
-			// value not found
-			log.Entry().Debugf("Can't interploate '%s'. Missing property '%s'", str, property)
-			return "", false
+
+	result := strings.Builder{}
+	remaining := str
+	for {
+		start := strings.Index(remaining, expressionStart)
+		if start < 0 {
+			result.WriteString(remaining)
+			break
+		}
+		if start > 0 && strings.HasPrefix(remaining[start-1:], escapedStart) {
+			result.WriteString(remaining[:start-1])
+			result.WriteString(expressionStart)
+			remaining = remaining[start+len(expressionStart):]
+			continue
+		}
+		end := strings.Index(remaining[start:], expressionEnd)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated expression in '%v'", str)
+		}
+		expression := remaining[start+len(expressionStart) : start+end]
+		value, err := c.evaluate(expression)
+		if err != nil {
+			return nil, err
+		}
+		// an expression making up the complete string keeps its type
+		if start == 0 && start+end+len(expressionEnd) == len(remaining) && result.Len() == 0 {
+			return value, nil
 		}
+		result.WriteString(remaining[:start])
+		result.WriteString(toString(value))
+		remaining = remaining[start+end+len(expressionEnd):]
 	}
-	return resolveString(str, lookupMap, n+1)
+	return result.String(), nil
 }
 
-// ResolveString takes a string and replaces all references inside of it with values from the given lookupMap.
-// This is being done recursively until the maxLookupDepth is reached.
-func ResolveString(str string, lookupMap map[string]interface{}) (string, bool) {
-	return resolveString(str, lookupMap, 0)
+func (c *Context) evaluate(expression string) (interface{}, error) {
+	parsed, err := parseExpression(strings.TrimSpace(expression))
+	if err != nil {
+		return nil, err
+	}
+	return parsed.evaluate(c)
 }
 
-func setupCaptureGroups(captureGroupsList []string) map[string]int {
-	groups := make(map[string]int, len(captureGroupsList))
-	for i, captureGroupName := range captureGroupsList {
-		if i == 0 {
-			continue
+// value returns the resolved value of the given name
+func (c *Context) value(name string) (interface{}, error) {
+	if resolved, ok := c.resolved[name]; ok {
+		return resolved, nil
+	}
+	for i, resolving := range c.resolving {
+		if resolving == name {
+			return nil, fmt.Errorf("cyclic reference %v", strings.Join(append(append([]string{}, c.resolving[i:]...), name), " -> "))
+		}
+	}
+
+	c.resolving = append(c.resolving, name)
+	resolved, err := c.Resolve(c.Values[name])
+	c.resolving = c.resolving[:len(c.resolving)-1]
+	if err != nil {
+		return nil, err
+	}
+	if c.resolved == nil {
+		c.resolved = map[string]interface{}{}
+	}
+	c.resolved[name] = resolved
+	return resolved, nil
+}
+
+// lookup returns the value of a reference
+func (c *Context) lookup(path []string) (interface{}, error) {
+	reference := strings.Join(path, ".")
+	unresolved := &UnresolvedReferenceError{Reference: reference}
+
+	// properties containing dots can be referenced as in $(property.with.dots)
+	if _, ok := c.Values[reference]; ok {
+		return c.value(reference)
+	}
+
+	if len(path) > 1 {
+		switch path[0] {
+		case "env":
+			if c.Env != nil && len(path) == 2 {
+				if value, ok := c.Env(path[1]); ok {
+					return value, nil
+				}
+			}
+			return nil, unresolved
+		case "cpe":
+			if c.CPE != nil {
+				if value, ok := c.CPE(strings.Join(path[1:], "/")); ok {
+					return value, nil
+				}
+			}
+			return nil, unresolved
+		case "git":
+			if c.Git != nil && len(path) == 2 {
+				if value, ok := c.Git(path[1]); ok {
+					return value, nil
+				}
+			}
+			return nil, unresolved
+		case "steps":
+			if c.Steps != nil && len(path) > 2 {
+				if value, ok := nestedValue(c.Steps(path[1]), path[2:]); ok {
+					if c.depth == maxLookupDepth {
+						return nil, fmt.Errorf("reference '%v' could not be resolved with a depth of %v", reference, maxLookupDepth)
+					}
+					// the configuration of other steps may contain expressions as well, which refer to values of that step
+					other := &Context{Values: c.Steps(path[1]), Env: c.Env, CPE: c.CPE, Steps: c.Steps, Git: c.Git, depth: c.depth + 1}
+					return other.Resolve(value)
+				}
+			}
+			return nil, unresolved
+		}
+	}
+
+	if _, ok := c.Values[path[0]]; !ok {
+		return nil, unresolved
+	}
+	value, err := c.value(path[0])
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := nestedValue(map[string]interface{}{path[0]: value}, path); ok {
+		return value, nil
+	}
+	return nil, unresolved
+}
+
+func nestedValue(values map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = values
+	for _, name := range path {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = currentMap[name]; !ok {
+			return nil, false
 		}
-		groups[captureGroupName] = i
 	}
-	return groups
+	return current, true
+}
+
+// ResolveMap interpolates every string value of a map and tries to lookup references to other properties of that map
+func ResolveMap(config map[string]interface{}) bool {
+	context := &Context{Values: config, legacy: true}
+	if err := context.ResolveValues(); err != nil {
+		log.Entry().Debugf("Can't interpolate configuration: %v", err)
+		return false
+	}
+	return true
+}
+
+// ResolveString takes a string and replaces all references inside of it with values from the given lookupMap.
+// References within the values of the lookupMap are resolved as well.
+func ResolveString(str string, lookupMap map[string]interface{}) (string, bool) {
+	context := &Context{Values: lookupMap, legacy: true}
+	resolved, err := context.Resolve(str)
+	if err != nil {
+		log.Entry().Debugf("Can't interpolate '%s': %v", str, err)
+		return "", false
+	}
+	return toString(resolved), true
 }
@@ -43,3 +43,108 @@ func TestResolveMap(t *testing.T) {
 	})
 
 }
+
+func TestResolveValues(t *testing.T) {
+	t.Parallel()
+
+	newContext := func(values map[string]interface{}) *Context {
+		return &Context{
+			Values: values,
+			Env: func(name string) (string, bool) {
+				value, ok := map[string]string{"HOME": "/home/piper"}[name]
+				return value, ok
+			},
+			CPE: func(key string) (interface{}, bool) {
+				value, ok := map[string]interface{}{"git/commitId": "abc123", "custom/tags": []interface{}{"a", "b"}}[key]
+				return value, ok
+			},
+			Steps: func(stepName string) map[string]interface{} {
+				return map[string]map[string]interface{}{
+					"mavenBuild": {"pomPath": "${{ folder }}/pom.xml", "folder": "api"},
+				}[stepName]
+			},
+			Git: func(name string) (string, bool) {
+				value, ok := map[string]string{"branch": "Feature/ABC"}[name]
+				return value, ok
+			},
+		}
+	}
+
+	tt := []struct {
+		name     string
+		values   map[string]interface{}
+		key      string
+		expected interface{}
+	}{
+		{name: "plain value", values: map[string]interface{}{"p": "$(text)"}, key: "p", expected: "$(text)"},
+		{name: "local reference", values: map[string]interface{}{"p": "${{ q }}/x", "q": "y"}, key: "p", expected: "y/x"},
+		{name: "keep type", values: map[string]interface{}{"p": "${{ q }}", "q": true}, key: "p", expected: true},
+		{name: "nested reference", values: map[string]interface{}{"p": "${{ q.r }}", "q": map[string]interface{}{"r": 1}}, key: "p", expected: 1},
+		{name: "environment", values: map[string]interface{}{"p": "${{env.HOME}}/.m2"}, key: "p", expected: "/home/piper/.m2"},
+		{name: "commonPipelineEnvironment", values: map[string]interface{}{"p": "${{ cpe.git.commitId }}"}, key: "p", expected: "abc123"},
+		{name: "other step", values: map[string]interface{}{"p": "${{ steps.mavenBuild.pomPath }}"}, key: "p", expected: "api/pom.xml"},
+		{name: "git", values: map[string]interface{}{"p": "${{ lower(git.branch) }}"}, key: "p", expected: "feature/abc"},
+		{name: "lists", values: map[string]interface{}{"p": []interface{}{"${{ cpe.custom.tags }}", "x-${{ cpe.custom.tags }}"}}, key: "p", expected: []interface{}{[]interface{}{"a", "b"}, "x-a,b"}},
+		{name: "default", values: map[string]interface{}{"p": "${{ default(cpe.unknown, 'fallback') }}"}, key: "p", expected: "fallback"},
+		{name: "default of empty value", values: map[string]interface{}{"p": "${{ default(q, 42) }}", "q": ""}, key: "p", expected: 42},
+		{name: "upper", values: map[string]interface{}{"p": "${{ upper('abc') }}"}, key: "p", expected: "ABC"},
+		{name: "replace", values: map[string]interface{}{"p": "${{ replace(git.branch, '/', '-') }}"}, key: "p", expected: "Feature-ABC"},
+		{name: "join", values: map[string]interface{}{"p": "${{ join(cpe.custom.tags, ' ') }}"}, key: "p", expected: "a b"},
+		{name: "semverBump", values: map[string]interface{}{"p": "${{ semverBump('v1.2.3', 'major') }}"}, key: "p", expected: "v2.0.0"},
+		{name: "escaped expression", values: map[string]interface{}{"p": "run: echo $${{ github.sha }}"}, key: "p", expected: "run: echo ${{ github.sha }}"},
+		{name: "escaped and resolved expression", values: map[string]interface{}{"p": "$${{ q }}=${{ q }}", "q": "y"}, key: "p", expected: "${{ q }}=y"},
+		{name: "escaped unterminated expression", values: map[string]interface{}{"p": "$${{ q"}, key: "p", expected: "${{ q"},
+		{name: "escaped quote", values: map[string]interface{}{"p": `${{ 'it\'s' }}`}, key: "p", expected: "it's"},
+	}
+
+	for _, test := range tt {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := newContext(test.values).ResolveValues()
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, test.values[test.key])
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		tt := []struct {
+			values   map[string]interface{}
+			expected string
+		}{
+			{values: map[string]interface{}{"p": "${{ q }}", "q": "${{ p }}"}, expected: "failed to resolve 'p': cyclic reference p -> q -> p"},
+			{values: map[string]interface{}{"p": "${{ cpe.unknown }}"}, expected: "failed to resolve 'p': unresolved reference 'cpe.unknown'"},
+			{values: map[string]interface{}{"p": "${{ lower(env.UNKNOWN) }}"}, expected: "failed to resolve 'p': unresolved reference 'env.UNKNOWN'"},
+			{values: map[string]interface{}{"p": "${{ unknown('a') }}"}, expected: "failed to resolve 'p': unknown function 'unknown'"},
+			{values: map[string]interface{}{"p": "${{ lower('a', 'b') }}"}, expected: "failed to resolve 'p': lower(): expects 1 arguments but got 2"},
+			{values: map[string]interface{}{"p": "${{ semverBump('1.0', 'build') }}"}, expected: "failed to resolve 'p': semverBump(): unknown version part 'build', supported are: major, minor, patch"},
+			{values: map[string]interface{}{"p": "${{ 'a' 'b' }}"}, expected: "failed to resolve 'p': invalid expression ''a' 'b'' at position 5: unexpected '''"},
+			{values: map[string]interface{}{"p": "${{ q"}, expected: "failed to resolve 'p': unterminated expression in '${{ q'"},
+		}
+		for _, test := range tt {
+			assert.EqualError(t, newContext(test.values).ResolveValues(), test.expected)
+		}
+	})
+}
+
+func TestResolveString(t *testing.T) {
+	t.Parallel()
+
+	t.Run("non-string values", func(t *testing.T) {
+		resolved, ok := ResolveString("$(prop1)/$(prop2)", map[string]interface{}{"prop1": 1, "prop2": []interface{}{"a", "b"}})
+		assert.True(t, ok)
+		assert.Equal(t, "1/a,b", resolved)
+	})
+
+	t.Run("dotted property", func(t *testing.T) {
+		resolved, ok := ResolveString("secret/$(vault.basePath)", map[string]interface{}{"vault.basePath": "piper"})
+		assert.True(t, ok)
+		assert.Equal(t, "secret/piper", resolved)
+	})
+
+	t.Run("unresolved property", func(t *testing.T) {
+		_, ok := ResolveString("$(prop1)", map[string]interface{}{})
+		assert.False(t, ok)
+	})
+}
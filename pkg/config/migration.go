@@ -0,0 +1,413 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Change describes a modification of a configuration file by the migration
+type Change struct {
+	// Line refers to the original file
+	Line    int
+	Message string
+}
+
+// String returns the change in the form <line>: <message>
+func (c Change) String() string {
+	return fmt.Sprintf("%v: %v", c.Line, c.Message)
+}
+
+// Migration rewrites outdated configuration files based on the metadata of the steps
+type Migration struct {
+	schema *Schema
+	// aliases contains per step the deprecated alias paths, e.g. detect/apiToken, and their parameter names
+	aliases map[string]map[string]string
+	// sectionAliases contains the aliases which are unique across all steps and can be replaced within the general and stages sections
+	sectionAliases map[string]string
+}
+
+// NewMigration creates the migration based on the metadata of all steps
+func NewMigration(metadata map[string]StepData) *Migration {
+	m := &Migration{schema: NewSchema(metadata), aliases: map[string]map[string]string{}, sectionAliases: map[string]string{}}
+	ambiguous := map[string]bool{}
+	for _, stepName := range sortedStepNames(metadata) {
+		m.aliases[stepName] = map[string]string{}
+		for _, param := range metadata[stepName].Spec.Inputs.Parameters {
+			for _, alias := range param.Aliases {
+				if !alias.Deprecated {
+					continue
+				}
+				m.aliases[stepName][alias.Name] = param.Name
+				if name, ok := m.sectionAliases[alias.Name]; ok && name != param.Name {
+					ambiguous[alias.Name] = true
+				}
+				m.sectionAliases[alias.Name] = param.Name
+			}
+		}
+	}
+	for alias := range ambiguous {
+		delete(m.sectionAliases, alias)
+	}
+	return m
+}
+
+// Migrate replaces renamed steps and deprecated aliases by their current names and moves parameters
+// into sections which support them.
+// Comments, blank lines and the order of the entries are kept.
+// The content is returned unchanged in case nothing is modified, e.g. if all changes need to be done manually.
+func (m *Migration) Migrate(content []byte) ([]byte, []Change, error) {
+	changes := &changeLog{changes: []Change{}}
+	// blank lines are only kept in case the markers do not change the content, e.g. due to unusual formatting
+	source := content
+	if marked := markBlankLines(content); equalYAML(content, marked) {
+		source = marked
+	}
+	document := yamlv3.Node{}
+	if err := yamlv3.NewDecoder(bytes.NewReader(source)).Decode(&document); err != nil {
+		if err == io.EOF {
+			return content, changes.changes, nil
+		}
+		return nil, nil, errors.Wrap(err, "failed to parse configuration")
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yamlv3.MappingNode {
+		return content, changes.changes, nil
+	}
+	root := document.Content[0]
+
+	if steps := mappingValue(root, "steps"); steps != nil {
+		m.renameSteps(steps, changes)
+		for _, stepName := range mappingKeys(steps) {
+			m.replaceAliases(mappingValue(steps, stepName), m.aliases[stepName], fmt.Sprintf("steps/%v", stepName), changes)
+		}
+	}
+	if general := mappingValue(root, "general"); general != nil {
+		m.replaceAliases(general, m.sectionAliases, "general", changes)
+	}
+	if stages := mappingValue(root, "stages"); stages != nil {
+		for _, stageName := range mappingKeys(stages) {
+			m.replaceAliases(mappingValue(stages, stageName), m.sectionAliases, stageSection(stageName), changes)
+		}
+	}
+	m.moveMisplacedParameters(root, changes)
+
+	sort.SliceStable(changes.changes, func(i, j int) bool { return changes.changes[i].Line < changes.changes[j].Line })
+	// changes which are only reported do not require rewriting the file, which would lose its formatting
+	if !changes.modified {
+		return content, changes.changes, nil
+	}
+
+	migrated := bytes.Buffer{}
+	encoder := yamlv3.NewEncoder(&migrated)
+	encoder.SetIndent(indentation(content))
+	if err := encoder.Encode(&document); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to write configuration")
+	}
+	encoder.Close()
+	return restoreBlankLines(migrated.Bytes()), changes.changes, nil
+}
+
+// changeLog collects the changes of a migration
+type changeLog struct {
+	changes []Change
+	// modified is true in case the configuration has been modified, in contrast to changes which are only reported
+	modified bool
+}
+
+// modify records a modification of the configuration
+func (c *changeLog) modify(node *yamlv3.Node, format string, args ...interface{}) {
+	c.modified = true
+	c.report(node, format, args...)
+}
+
+// report records a change which needs to be done manually
+func (c *changeLog) report(node *yamlv3.Node, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+const blankLineMarker = "#piper-blank-line"
+
+var (
+	blankLineMarked = regexp.MustCompile(`(?m)^[ \t]*` + blankLineMarker + `$`)
+	// blockScalar matches lines which start a multi-line string, e.g. "script: |" or "- >-"
+	blockScalar = regexp.MustCompile(`(^|[:-])\s+[|>][-+0-9]*\s*(#.*)?$`)
+)
+
+// markBlankLines replaces blank lines by comments, since the YAML encoder keeps comments but drops blank lines.
+// Blank lines within multi-line strings are part of the value and kept as they are.
+func markBlankLines(content []byte) []byte {
+	var result strings.Builder
+	// blockIndent is the indentation of the line which starts a multi-line string, -1 outside of multi-line strings
+	blockIndent := -1
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			if blockIndent < 0 && strings.HasSuffix(line, "\n") {
+				line = blankLineMarker + "\n"
+			}
+			result.WriteString(line)
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if blockIndent >= 0 && indent <= blockIndent {
+			blockIndent = -1
+		}
+		if blockIndent < 0 && blockScalar.MatchString(strings.TrimRight(line, "\r\n")) {
+			blockIndent = indent
+		}
+		result.WriteString(line)
+	}
+	return []byte(result.String())
+}
+
+func restoreBlankLines(content []byte) []byte {
+	return blankLineMarked.ReplaceAll(content, []byte{})
+}
+
+func equalYAML(a, b []byte) bool {
+	var valueA, valueB interface{}
+	if yamlv3.Unmarshal(a, &valueA) != nil || yamlv3.Unmarshal(b, &valueB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(valueA, valueB)
+}
+
+// renameSteps replaces the names of renamed steps, the configuration is merged in case both names are used
+func (m *Migration) renameSteps(steps *yamlv3.Node, changes *changeLog) {
+	for i := 0; i+1 < len(steps.Content); i += 2 {
+		key, value := steps.Content[i], steps.Content[i+1]
+		stepName, renamed := m.schema.stepAliases[key.Value]
+		if !renamed {
+			continue
+		}
+		target := mappingValue(steps, stepName)
+		if target == nil {
+			changes.modify(key, "renamed step '%v' to '%v'", key.Value, stepName)
+			key.Value = stepName
+			continue
+		}
+		if value.Kind != yamlv3.MappingNode || target.Kind != yamlv3.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); {
+			parameter := value.Content[j]
+			if mappingValue(target, parameter.Value) != nil {
+				changes.report(parameter, "parameter '%v' of step '%v' is kept since it is already configured for step '%v'", parameter.Value, key.Value, stepName)
+				j += 2
+				continue
+			}
+			changes.modify(parameter, "moved parameter '%v' of step '%v' to step '%v'", parameter.Value, key.Value, stepName)
+			target.Content = append(target.Content, parameter, value.Content[j+1])
+			value.Content = append(value.Content[:j], value.Content[j+2:]...)
+		}
+		if len(value.Content) == 0 {
+			changes.modify(key, "removed step '%v' which is replaced by '%v'", key.Value, stepName)
+			steps.Content = append(steps.Content[:i], steps.Content[i+2:]...)
+			i -= 2
+		}
+	}
+}
+
+// replaceAliases replaces deprecated aliases within a section, values of deep aliases are moved to the top level of the section
+func (m *Migration) replaceAliases(section *yamlv3.Node, aliases map[string]string, sectionName string, changes *changeLog) {
+	if section == nil || section.Kind != yamlv3.MappingNode {
+		return
+	}
+	aliasPaths := []string{}
+	for alias := range aliases {
+		aliasPaths = append(aliasPaths, alias)
+	}
+	sort.Strings(aliasPaths)
+
+	for _, alias := range aliasPaths {
+		name := aliases[alias]
+		path := strings.Split(alias, "/")
+		parent := section
+		for _, segment := range path[:len(path)-1] {
+			if parent = mappingValue(parent, segment); parent == nil || parent.Kind != yamlv3.MappingNode {
+				break
+			}
+		}
+		if parent == nil || parent.Kind != yamlv3.MappingNode {
+			continue
+		}
+		index := mappingIndex(parent, path[len(path)-1])
+		if index < 0 {
+			continue
+		}
+		key := parent.Content[index]
+		if mappingValue(section, name) != nil {
+			changes.report(key, "deprecated parameter '%v' in %v is kept since '%v' is already configured, please remove it", alias, sectionName, name)
+			continue
+		}
+		changes.modify(key, "replaced deprecated parameter '%v' in %v by '%v'", alias, sectionName, name)
+		if len(path) == 1 {
+			key.Value = name
+			continue
+		}
+		value := parent.Content[index+1]
+		parent.Content = append(parent.Content[:index], parent.Content[index+2:]...)
+		key.Value = name
+		section.Content = append(section.Content, key, value)
+		removeEmptyMaps(section, path[:len(path)-1])
+	}
+}
+
+// moveMisplacedParameters moves parameters into a section which supports them.
+// Parameters of general are only moved to a step if exactly one step supports them.
+// Parameters of stages are only reported since moving them would apply them to all stages.
+func (m *Migration) moveMisplacedParameters(root *yamlv3.Node, changes *changeLog) {
+	general := mappingValue(root, "general")
+	m.moveToStep(root, general, "general", scopeGeneral, true, changes)
+	if stages := mappingValue(root, "stages"); stages != nil {
+		for _, stageName := range mappingKeys(stages) {
+			m.moveToStep(root, mappingValue(stages, stageName), stageSection(stageName), scopeStages, false, changes)
+		}
+	}
+
+	steps := mappingValue(root, "steps")
+	if steps == nil {
+		return
+	}
+	for _, stepName := range mappingKeys(steps) {
+		step := mappingValue(steps, stepName)
+		parameters := m.schema.steps[stepName]
+		if step == nil || step.Kind != yamlv3.MappingNode || parameters == nil {
+			continue
+		}
+		for i := 0; i+1 < len(step.Content); {
+			key := step.Content[i]
+			parameter := parameters[key.Value]
+			if parameter == nil || parameter.scopes[scopeSteps] || !parameter.scopes[scopeGeneral] {
+				i += 2
+				continue
+			}
+			if general == nil {
+				general = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+				root.Content = append(root.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "general"}, general)
+			}
+			if mappingValue(general, key.Value) != nil {
+				changes.report(key, "parameter '%v' of step '%v' is only supported in section general which already configures it, please remove it", key.Value, stepName)
+				i += 2
+				continue
+			}
+			changes.modify(key, "moved parameter '%v' of step '%v' to section general", key.Value, stepName)
+			general.Content = append(general.Content, key, step.Content[i+1])
+			step.Content = append(step.Content[:i], step.Content[i+2:]...)
+		}
+	}
+}
+
+func (m *Migration) moveToStep(root, section *yamlv3.Node, sectionName, scope string, move bool, changes *changeLog) {
+	if section == nil || section.Kind != yamlv3.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(section.Content); {
+		key := section.Content[i]
+		parameter := m.schema.parameters[key.Value]
+		if parameter == nil || parameter.scopes[scope] {
+			i += 2
+			continue
+		}
+		candidates := m.stepsSupporting(key.Value)
+		if !move || len(candidates) != 1 {
+			changes.report(key, "parameter '%v' is not supported in %v and needs to be moved manually, supported in: %v", key.Value, sectionName, allowedSections(parameter))
+			i += 2
+			continue
+		}
+		steps := mappingValue(root, "steps")
+		if steps == nil {
+			steps = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+			root.Content = append(root.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "steps"}, steps)
+		}
+		step := mappingValue(steps, candidates[0])
+		if step == nil {
+			step = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+			steps.Content = append(steps.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: candidates[0]}, step)
+		}
+		if step.Kind != yamlv3.MappingNode || mappingValue(step, key.Value) != nil {
+			changes.report(key, "parameter '%v' is not supported in %v and step '%v' already configures it, please remove it", key.Value, sectionName, candidates[0])
+			i += 2
+			continue
+		}
+		changes.modify(key, "moved parameter '%v' from %v to step '%v'", key.Value, sectionName, candidates[0])
+		step.Content = append(step.Content, key, section.Content[i+1])
+		section.Content = append(section.Content[:i], section.Content[i+2:]...)
+	}
+}
+
+func (m *Migration) stepsSupporting(name string) []string {
+	steps := []string{}
+	for stepName, parameters := range m.schema.steps {
+		if parameter := parameters[name]; parameter != nil && parameter.scopes[scopeSteps] && len(parameter.deprecatedFor) == 0 {
+			steps = append(steps, stepName)
+		}
+	}
+	sort.Strings(steps)
+	return steps
+}
+
+func mappingIndex(node *yamlv3.Node, key string) int {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if index := mappingIndex(node, key); index >= 0 {
+		return node.Content[index+1]
+	}
+	return nil
+}
+
+func mappingKeys(node *yamlv3.Node) []string {
+	keys := []string{}
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return keys
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "<<" {
+			keys = append(keys, node.Content[i].Value)
+		}
+	}
+	return keys
+}
+
+// removeEmptyMaps removes the maps along the path which became empty by moving values
+func removeEmptyMaps(node *yamlv3.Node, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	index := mappingIndex(node, path[0])
+	if index < 0 {
+		return
+	}
+	child := node.Content[index+1]
+	removeEmptyMaps(child, path[1:])
+	if child.Kind == yamlv3.MappingNode && len(child.Content) == 0 {
+		node.Content = append(node.Content[:index], node.Content[index+2:]...)
+	}
+}
+
+// indentation returns the indentation used within the content, defaulting to two spaces
+func indentation(content []byte) int {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && len(trimmed) > 0 && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "-") {
+			return indent
+		}
+	}
+	return 2
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func migrationTestMetadata() map[string]StepData {
+	return map[string]StepData{
+		"newStep": {
+			Metadata: StepMetadata{Name: "newStep", Aliases: []Alias{{Name: "oldStep"}}},
+			Spec: StepSpec{Inputs: StepInputs{Parameters: []StepParameters{
+				{Name: "pomPath", Type: "string", Scope: []string{"PARAMETERS", "STEPS"}, Aliases: []Alias{{Name: "pom", Deprecated: true}}},
+				{Name: "token", Type: "string", Scope: []string{"GENERAL", "STEPS"}, Aliases: []Alias{{Name: "detect/apiToken", Deprecated: true}}},
+				{Name: "flatten", Type: "bool", Scope: []string{"STEPS"}},
+			}}},
+		},
+		"otherStep": {
+			Metadata: StepMetadata{Name: "otherStep"},
+			Spec: StepSpec{Inputs: StepInputs{Parameters: []StepParameters{
+				{Name: "buildTool", Type: "string", Scope: []string{"GENERAL"}},
+				{Name: "goals", Type: "[]string", Scope: []string{"STEPS"}},
+			}}},
+		},
+	}
+}
+
+const migrationTestConfig = `# project configuration
+general:
+  flatten: true # only for steps
+  buildTool: maven
+steps:
+  # the old name
+  oldStep:
+    pom: pom.xml
+    detect:
+      apiToken: abc
+  otherStep:
+    buildTool: npm
+`
+
+func TestMigrate(t *testing.T) {
+	migration := NewMigration(migrationTestMetadata())
+
+	t.Run("rewrite", func(t *testing.T) {
+		migrated, changes, err := migration.Migrate([]byte(migrationTestConfig))
+
+		assert.NoError(t, err)
+		assert.Equal(t, `# project configuration
+general:
+  buildTool: maven
+steps:
+  # the old name
+  newStep:
+    pomPath: pom.xml
+    token: abc
+    flatten: true # only for steps
+  otherStep:
+    buildTool: npm
+`, string(migrated))
+		assert.Equal(t, []Change{
+			{Line: 3, Message: "moved parameter 'flatten' from general to step 'newStep'"},
+			{Line: 7, Message: "renamed step 'oldStep' to 'newStep'"},
+			{Line: 8, Message: "replaced deprecated parameter 'pom' in steps/newStep by 'pomPath'"},
+			{Line: 10, Message: "replaced deprecated parameter 'detect/apiToken' in steps/newStep by 'token'"},
+			{Line: 12, Message: "parameter 'buildTool' of step 'otherStep' is only supported in section general which already configures it, please remove it"},
+		}, changes)
+	})
+
+	t.Run("merge renamed step", func(t *testing.T) {
+		migrated, changes, err := migration.Migrate([]byte("steps:\n  newStep:\n    pomPath: pom.xml\n  oldStep:\n    pom: other.xml\n    flatten: true\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "steps:\n  newStep:\n    pomPath: pom.xml\n    pom: other.xml\n    flatten: true\n", string(migrated))
+		assert.Equal(t, []Change{
+			{Line: 4, Message: "removed step 'oldStep' which is replaced by 'newStep'"},
+			{Line: 5, Message: "moved parameter 'pom' of step 'oldStep' to step 'newStep'"},
+			{Line: 5, Message: "deprecated parameter 'pom' in steps/newStep is kept since 'pomPath' is already configured, please remove it"},
+			{Line: 6, Message: "moved parameter 'flatten' of step 'oldStep' to step 'newStep'"},
+		}, changes)
+	})
+
+	t.Run("stages", func(t *testing.T) {
+		migrated, changes, err := migration.Migrate([]byte("stages:\n  Build:\n    pom: pom.xml\n    buildTool: maven\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "stages:\n  Build:\n    pomPath: pom.xml\n    buildTool: maven\n", string(migrated))
+		assert.Equal(t, []Change{
+			{Line: 3, Message: "replaced deprecated parameter 'pom' in stages/Build by 'pomPath'"},
+			{Line: 3, Message: "parameter 'pomPath' is not supported in stages/Build and needs to be moved manually, supported in: steps"},
+			{Line: 4, Message: "parameter 'buildTool' is not supported in stages/Build and needs to be moved manually, supported in: general"},
+		}, changes)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		content := "general:\n    buildTool:   maven\n"
+		migrated, changes, err := migration.Migrate([]byte(content))
+
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(migrated))
+		assert.Empty(t, changes)
+	})
+
+	t.Run("blank lines", func(t *testing.T) {
+		migrated, _, err := migration.Migrate([]byte("general:\n  buildTool: maven\n\nsteps:\n  oldStep:\n    pom: pom.xml\n\n    flatten: true\n\n  otherStep:\n    script: |\n      first\n\n      second\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "general:\n  buildTool: maven\n\nsteps:\n  newStep:\n    pomPath: pom.xml\n\n    flatten: true\n\n  otherStep:\n    script: |\n      first\n\n      second\n", string(migrated))
+	})
+
+	t.Run("only manual changes", func(t *testing.T) {
+		content := "general:\n  buildTool:   maven\n\nsteps:\n  otherStep:\n    buildTool: npm\n"
+		migrated, changes, err := migration.Migrate([]byte(content))
+
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(migrated))
+		assert.Equal(t, []Change{
+			{Line: 6, Message: "parameter 'buildTool' of step 'otherStep' is only supported in section general which already configures it, please remove it"},
+		}, changes)
+	})
+
+	t.Run("indentation", func(t *testing.T) {
+		migrated, _, err := migration.Migrate([]byte("steps:\n    oldStep:\n        pom: pom.xml\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "steps:\n    newStep:\n        pomPath: pom.xml\n", string(migrated))
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		_, _, err := migration.Migrate([]byte("steps: ["))
+		assert.Contains(t, err.Error(), "failed to parse configuration")
+	})
+}
@@ -32,7 +32,72 @@ func (_m *VaultMock) GetKvSecret(_a0 string) (map[string]string, error) {
 	return r0, r1
 }
 
+// GetDynamicSecret provides a mock function with given fields: _a0
+func (_m *VaultMock) GetDynamicSecret(_a0 string) (map[string]string, error) {
+	ret := _m.Called(_a0)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(string) map[string]string); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HasLeases provides a mock function with given fields:
+func (_m *VaultMock) HasLeases() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // MustRevokeToken provides a mock function with given fields:
 func (_m *VaultMock) MustRevokeToken() {
 	_m.Called()
 }
+
+// RevokeLeases provides a mock function with given fields:
+func (_m *VaultMock) RevokeLeases() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields:
+func (_m *VaultMock) RevokeToken() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Sources of configuration values as reported by the provenance
+const (
+	SourceStepDefault   = "stepDefault"
+	SourceResource      = "resource"
+	SourceDefaults      = "defaults"
+	SourceConfig        = "config"
+	SourceEnvironment   = "environment"
+	SourceParameterJSON = "parametersJSON"
+	SourceFlag          = "flag"
+	SourceExpression    = "expression"
+	SourceVault         = "vault"
+	SourceSecret        = "secret"
+	SourceCondition     = "condition"
+)
+
+// Layer describes a source which provided a value for a parameter
+type Layer struct {
+	Source string `json:"source"`
+	// File is the configuration file which contains the value
+	File string `json:"file,omitempty"`
+	// Path is the key path within the file, the environment variable, the vault path, the secret or the resource path
+	Path string `json:"path,omitempty"`
+	// Alias is the name which was used instead of the parameter name
+	Alias      string      `json:"alias,omitempty"`
+	Value      interface{} `json:"value"`
+	Overridden bool        `json:"overridden"`
+}
+
+// Provenance contains the layers which provided values for the parameters in the order of their application
+type Provenance map[string][]Layer
+
+// Explanation describes how the value of a parameter was determined
+type Explanation struct {
+	Parameter string      `json:"parameter"`
+	Value     interface{} `json:"value"`
+	Layers    []Layer     `json:"layers"`
+}
+
+// namedReadCloser keeps the name of a configuration source whose content does not come from a local file
+type namedReadCloser struct {
+	io.ReadCloser
+	name string
+}
+
+func (n *namedReadCloser) Name() string {
+	return n.name
+}
+
+// sourceName returns the name of a configuration source, e.g. the path of an *os.File
+func sourceName(source io.ReadCloser, fallback string) string {
+	if named, ok := source.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return fallback
+}
+
+// EnableProvenance records the sources of all values during the next call of GetStepConfig
+func (c *Config) EnableProvenance() {
+	c.provenance = true
+}
+
+// record adds the values to the provenance in case it is enabled
+func (s *StepConfig) record(values map[string]interface{}, layer func(key string) Layer) {
+	if s.Provenance == nil {
+		return
+	}
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		l := layer(key)
+		l.Value = values[key]
+		s.recordLayer(key, l)
+	}
+}
+
+func (s *StepConfig) recordLayer(key string, layer Layer) {
+	if s.Provenance == nil {
+		return
+	}
+	// maps are merged, hence only other values override previous layers
+	if _, isMap := layer.Value.(map[string]interface{}); !isMap {
+		for i := range s.Provenance[key] {
+			s.Provenance[key][i].Overridden = true
+		}
+	}
+	s.Provenance[key] = append(s.Provenance[key], layer)
+}
+
+// mixInLayer merges the filtered values and records them with the given section path, e.g. steps/mavenBuild
+func (s *StepConfig) mixInLayer(mergeData map[string]interface{}, filter []string, source, file, section string, aliases map[string]string) {
+	s.mixIn(mergeData, filter)
+	s.record(filterMap(mergeData, filter), func(key string) Layer {
+		layer := Layer{Source: source, File: file, Path: key, Alias: aliases[key]}
+		if len(section) > 0 {
+			layer.Path = section + "/" + key
+		}
+		return layer
+	})
+}
+
+// Explain returns for every parameter the resulting value and the layers which provided values.
+// Values of secrets and values from vault or other secret providers are redacted.
+func (s *StepConfig) Explain(secrets []string) []Explanation {
+	names := []string{}
+	for name := range s.Config {
+		names = append(names, name)
+	}
+	for name := range s.Provenance {
+		if _, ok := s.Config[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	explanations := []Explanation{}
+	for _, name := range names {
+		secret := sliceContains(secrets, name)
+		for _, layer := range s.Provenance[name] {
+			secret = secret || layer.Source == SourceVault || layer.Source == SourceSecret
+		}
+		explanation := Explanation{Parameter: name, Value: redact(s.Config[name], secret), Layers: []Layer{}}
+		for _, layer := range s.Provenance[name] {
+			layer.Value = redact(layer.Value, secret)
+			explanation.Layers = append(explanation.Layers, layer)
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations
+}
+
+func redact(value interface{}, secret bool) interface{} {
+	if secret && value != nil {
+		return "****"
+	}
+	return value
+}
+
+// aliasUsed returns the alias which provides the value of a parameter in the same way as setParamValueFromAlias
+func aliasUsed(configMap map[string]interface{}, aliases []Alias) string {
+	for _, a := range aliases {
+		if getDeepAliasValue(configMap, a.Name) != nil {
+			return a.Name
+		}
+	}
+	return ""
+}
+
+// recordAlias keeps the alias which was used for a parameter within a section, e.g. steps/mavenBuild
+func (c *Config) recordAlias(section string, configMap map[string]interface{}, filter []string, name string, aliases []Alias) {
+	if configMap == nil || configMap[name] != nil || !sliceContains(filter, name) {
+		return
+	}
+	if alias := aliasUsed(configMap, aliases); len(alias) > 0 {
+		if c.aliases == nil {
+			c.aliases = map[string]map[string]string{}
+		}
+		if c.aliases[section] == nil {
+			c.aliases[section] = map[string]string{}
+		}
+		c.aliases[section][name] = alias
+	}
+}
+
+// resourcePath returns the path of the resource which provides the value of a parameter, e.g. commonPipelineEnvironment/git/commitId
+func resourcePath(parameters []StepParameters, name string) string {
+	for _, param := range parameters {
+		if param.Name != name {
+			continue
+		}
+		for _, ref := range param.ResourceRef {
+			if len(ref.Param) > 0 {
+				return ref.Name + "/" + ref.Param
+			}
+		}
+	}
+	return ""
+}
+
+func stageSection(stageName string) string {
+	return fmt.Sprintf("stages/%v", stageName)
+}
+
+func stepSection(stepName string) string {
+	return fmt.Sprintf("steps/%v", stepName)
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/config/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Run("layers", func(t *testing.T) {
+		os.Setenv("PIPER_p3", "p3_env")
+		defer os.Unsetenv("PIPER_p3")
+
+		var c Config
+		c.EnableProvenance()
+		testConfig := "general:\n  p1: p1_general\nsteps:\n  step1:\n    p1: p1_step\n    p2Alias: p2_alias\n    p3: p3_step\n"
+		defaults := []io.ReadCloser{ioutil.NopCloser(strings.NewReader("general:\n  p0: p0_default\n  p1: p1_default\n"))}
+		parameters := []StepParameters{
+			{Name: "p0", Default: "p0_step_default"},
+			{Name: "p2", Aliases: []Alias{{Name: "p2Alias"}}},
+			{Name: "p5", ResourceRef: []ResourceReference{{Name: "commonPipelineEnvironment", Param: "custom/p5"}}},
+		}
+		filters := StepFilters{
+			All:        []string{"p0", "p1", "p2", "p3", "p4", "p5"},
+			General:    []string{"p0", "p1"},
+			Steps:      []string{"p0", "p1", "p2", "p3"},
+			Parameters: []string{"p4"},
+		}
+
+		stepConfig, err := c.GetStepConfig(map[string]interface{}{"p4": "p4_flag"}, "", ioutil.NopCloser(strings.NewReader(testConfig)), defaults, false, filters, parameters, nil, map[string]interface{}{"p5": "p5_cpe"}, "stage1", "step1", []Alias{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Layer{
+			{Source: SourceStepDefault, Value: "p0_step_default", Overridden: true},
+			{Source: SourceDefaults, File: "defaults #1", Path: "general/p0", Value: "p0_default"},
+		}, stepConfig.Provenance["p0"])
+		assert.Equal(t, []Layer{
+			{Source: SourceDefaults, File: "defaults #1", Path: "general/p1", Value: "p1_default", Overridden: true},
+			{Source: SourceConfig, Path: "general/p1", Value: "p1_general", Overridden: true},
+			{Source: SourceConfig, Path: "steps/step1/p1", Value: "p1_step"},
+		}, stepConfig.Provenance["p1"])
+		assert.Equal(t, []Layer{
+			{Source: SourceConfig, Path: "steps/step1/p2", Alias: "p2Alias", Value: "p2_alias"},
+		}, stepConfig.Provenance["p2"])
+		assert.Equal(t, []Layer{
+			{Source: SourceConfig, Path: "steps/step1/p3", Value: "p3_step", Overridden: true},
+			{Source: SourceEnvironment, Path: "PIPER_p3", Value: "p3_env"},
+		}, stepConfig.Provenance["p3"])
+		assert.Equal(t, []Layer{{Source: SourceFlag, Path: "p4", Value: "p4_flag"}}, stepConfig.Provenance["p4"])
+		assert.Equal(t, []Layer{{Source: SourceResource, Path: "commonPipelineEnvironment/custom/p5", Value: "p5_cpe"}}, stepConfig.Provenance["p5"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var c Config
+		stepConfig, err := c.GetStepConfig(nil, "", ioutil.NopCloser(strings.NewReader("general:\n  p1: p1_general\n")), nil, false, StepFilters{General: []string{"p1"}}, nil, nil, nil, "stage1", "step1", []Alias{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, stepConfig.Provenance)
+	})
+
+	t.Run("vault", func(t *testing.T) {
+		vaultMock := &mocks.VaultMock{}
+		stepConfig := StepConfig{Config: map[string]interface{}{"vaultBasePath": "team1"}, Provenance: Provenance{}}
+		stepParams := []StepParameters{stepParam("password", "vaultSecret", "$(vaultBasePath)/pipelineA")}
+		vaultMock.On("GetKvSecret", "team1/pipelineA").Return(map[string]string{"password": "secret"}, nil)
+
+		resolveAllVaultReferences(&stepConfig, vaultMock, stepParams)
+
+		assert.Equal(t, []Layer{{Source: SourceVault, Path: "team1/pipelineA", Value: "secret"}}, stepConfig.Provenance["password"])
+	})
+}
+
+func TestExplain(t *testing.T) {
+	stepConfig := StepConfig{
+		Config: map[string]interface{}{"user": "me", "password": "secret", "token": "vaultToken"},
+		Provenance: Provenance{
+			"user":     {{Source: SourceConfig, Path: "general/user", Value: "me"}},
+			"password": {{Source: SourceFlag, Path: "password", Value: "secret"}},
+			"token":    {{Source: SourceVault, Path: "team1/pipelineA", Value: "vaultToken"}},
+		},
+	}
+
+	explanations := stepConfig.Explain([]string{"password"})
+
+	assert.Equal(t, []Explanation{
+		{Parameter: "password", Value: "****", Layers: []Layer{{Source: SourceFlag, Path: "password", Value: "****"}}},
+		{Parameter: "token", Value: "****", Layers: []Layer{{Source: SourceVault, Path: "team1/pipelineA", Value: "****"}}},
+		{Parameter: "user", Value: "me", Layers: []Layer{{Source: SourceConfig, Path: "general/user", Value: "me"}}},
+	}, explanations)
+}
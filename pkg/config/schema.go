@@ -0,0 +1,556 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Severities of configuration findings
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+const (
+	scopeGeneral = "GENERAL"
+	scopeStages  = "STAGES"
+	scopeSteps   = "STEPS"
+)
+
+// sectionScopes maps the sections of the configuration file to the parameter scopes of the metadata
+var sectionScopes = map[string]string{"general": scopeGeneral, "stages": scopeStages, "steps": scopeSteps}
+
+// sections contains the top level keys of the configuration file
+var sections = []string{"customDefaults", "general", "hooks", "stages", "steps"}
+
+// Finding describes a problem within a configuration file
+type Finding struct {
+	File     string
+	Line     int
+	Column   int
+	Severity string
+	Message  string
+}
+
+// String returns the finding in the form <file>:<line>:<column>: <severity>: <message>
+func (f Finding) String() string {
+	return fmt.Sprintf("%v:%v:%v: %v: %v", f.File, f.Line, f.Column, f.Severity, f.Message)
+}
+
+// schemaParameter describes a configuration key, a key may be typed by several steps
+type schemaParameter struct {
+	name           string
+	description    string
+	types          []string
+	possibleValues []interface{}
+	scopes         map[string]bool
+	mandatory      bool
+	// deprecatedFor contains the name of the parameter in case the key is a deprecated alias
+	deprecatedFor string
+}
+
+// Schema describes the valid content of a configuration file based on the metadata of the steps
+type Schema struct {
+	steps       map[string]map[string]*schemaParameter
+	stepAliases map[string]string
+	// parameters contains the keys of all steps which are valid within the general and stages sections
+	parameters map[string]*schemaParameter
+}
+
+// NewSchema creates the schema of the configuration file from the metadata of all steps
+func NewSchema(metadata map[string]StepData) *Schema {
+	schema := &Schema{steps: map[string]map[string]*schemaParameter{}, stepAliases: map[string]string{}, parameters: map[string]*schemaParameter{}}
+
+	for _, stepName := range sortedStepNames(metadata) {
+		stepData := metadata[stepName]
+		parameters := stepParameters(stepData)
+		schema.steps[stepName] = parameters
+		for _, alias := range stepData.Metadata.Aliases {
+			schema.stepAliases[alias.Name] = stepName
+		}
+		for _, name := range sortedParameterNames(parameters) {
+			schema.mergeParameter(parameters[name])
+		}
+	}
+	return schema
+}
+
+func sortedStepNames(metadata map[string]StepData) []string {
+	names := []string{}
+	for name := range metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedParameterNames(parameters map[string]*schemaParameter) []string {
+	names := []string{}
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stepParameters returns all configuration keys of a step, including the keys which are not described as parameter like container options
+func stepParameters(stepData StepData) map[string]*schemaParameter {
+	parameters := map[string]*schemaParameter{}
+	for _, param := range stepData.Spec.Inputs.Parameters {
+		parameter := &schemaParameter{
+			name:           param.Name,
+			description:    param.Description,
+			possibleValues: param.PossibleValues,
+			scopes:         map[string]bool{},
+			mandatory:      param.Mandatory,
+		}
+		if len(param.Type) > 0 {
+			parameter.types = []string{param.Type}
+		}
+		for _, scope := range param.Scope {
+			parameter.scopes[scope] = true
+		}
+		parameters[param.Name] = parameter
+		for _, alias := range param.Aliases {
+			aliasParameter := *parameter
+			aliasParameter.name = alias.Name
+			if strings.Contains(alias.Name, "/") {
+				// deep aliases reference a value within a map
+				aliasParameter.name = strings.Split(alias.Name, "/")[0]
+				aliasParameter.types = nil
+				aliasParameter.possibleValues = nil
+			}
+			if alias.Deprecated {
+				aliasParameter.deprecatedFor = param.Name
+			}
+			parameters[aliasParameter.name] = &aliasParameter
+		}
+	}
+
+	filters := stepData.GetParameterFilters()
+	contextFilters := stepData.GetContextParameterFilters()
+	generalKeys := append([]string{"collectTelemetryData"}, vaultFilter...)
+	scopeKeys := map[string][]string{
+		scopeGeneral: append(append(filters.General, contextFilters.General...), generalKeys...),
+		scopeStages:  append(append(filters.Stages, contextFilters.Stages...), generalKeys...),
+		scopeSteps:   append(append(filters.Steps, contextFilters.Steps...), generalKeys...),
+	}
+	for scope, keys := range scopeKeys {
+		for _, key := range keys {
+			if parameters[key] == nil {
+				parameters[key] = &schemaParameter{name: key, scopes: map[string]bool{}}
+			}
+			parameters[key].scopes[scope] = true
+		}
+	}
+	return parameters
+}
+
+// mergeParameter adds the parameter of a step to the parameters valid within the general and stages sections
+func (s *Schema) mergeParameter(parameter *schemaParameter) {
+	merged := s.parameters[parameter.name]
+	if merged == nil {
+		copied := *parameter
+		copied.scopes = map[string]bool{}
+		for scope := range parameter.scopes {
+			copied.scopes[scope] = true
+		}
+		s.parameters[parameter.name] = &copied
+		return
+	}
+	for scope := range parameter.scopes {
+		merged.scopes[scope] = true
+	}
+	for _, parameterType := range parameter.types {
+		if !sliceContains(merged.types, parameterType) {
+			merged.types = append(merged.types, parameterType)
+		}
+	}
+	// values are only restricted if all steps restrict them
+	if len(merged.possibleValues) == 0 || len(parameter.possibleValues) == 0 {
+		merged.possibleValues = nil
+	} else {
+		for _, value := range parameter.possibleValues {
+			if !containsValue(merged.possibleValues, value) {
+				merged.possibleValues = append(merged.possibleValues, value)
+			}
+		}
+	}
+	if len(merged.deprecatedFor) > 0 && len(parameter.deprecatedFor) == 0 {
+		merged.deprecatedFor = ""
+	}
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if fmt.Sprint(v) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONSchema returns the schema as JSON Schema (draft-07) which can be used e.g. by editors for code completion.
+// Mandatory parameters are marked in the description only since they can be provided by any section, defaults or flags.
+func (s *Schema) JSONSchema() map[string]interface{} {
+	stepProperties := map[string]interface{}{}
+	for stepName, parameters := range s.steps {
+		stepProperties[stepName] = map[string]interface{}{
+			"type":       "object",
+			"properties": jsonSchemaProperties(parameters, scopeSteps),
+		}
+	}
+	for alias, stepName := range s.stepAliases {
+		if _, ok := stepProperties[alias]; !ok {
+			stepProperties[alias] = map[string]interface{}{"$ref": "#/properties/steps/properties/" + stepName}
+		}
+	}
+
+	return map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "Project 'Piper' configuration",
+		"description": "Configuration of the project 'Piper' steps, e.g. .pipeline/config.yml",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"customDefaults": map[string]interface{}{
+				"description": "Custom default configurations, passed as path or URL of a yaml file",
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+			},
+			"general": map[string]interface{}{
+				"type":       "object",
+				"properties": jsonSchemaProperties(s.parameters, scopeGeneral),
+			},
+			"stages": map[string]interface{}{
+				"type": "object",
+				"additionalProperties": map[string]interface{}{
+					"type":       "object",
+					"properties": jsonSchemaProperties(s.parameters, scopeStages),
+				},
+			},
+			"steps": map[string]interface{}{
+				"type":       "object",
+				"properties": stepProperties,
+			},
+			"hooks": map[string]interface{}{"type": "object"},
+		},
+		"additionalProperties": false,
+	}
+}
+
+func jsonSchemaProperties(parameters map[string]*schemaParameter, scope string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for name, parameter := range parameters {
+		if !parameter.scopes[scope] {
+			continue
+		}
+		property := map[string]interface{}{}
+		description := parameter.description
+		if parameter.mandatory {
+			description = strings.TrimSpace(description + " (mandatory)")
+		}
+		if len(parameter.deprecatedFor) > 0 {
+			description = fmt.Sprintf("Deprecated, use '%v' instead.", parameter.deprecatedFor)
+		}
+		if len(description) > 0 {
+			property["description"] = description
+		}
+		if len(parameter.types) == 1 {
+			for key, value := range jsonSchemaType(parameter.types[0]) {
+				property[key] = value
+			}
+		}
+		if len(parameter.possibleValues) > 0 {
+			property["enum"] = parameter.possibleValues
+		}
+		properties[name] = property
+	}
+	return properties
+}
+
+func jsonSchemaType(parameterType string) map[string]interface{} {
+	switch parameterType {
+	case "string":
+		return map[string]interface{}{"type": "string"}
+	case "bool":
+		return map[string]interface{}{"type": "boolean"}
+	case "int":
+		return map[string]interface{}{"type": "integer"}
+	case "[]string":
+		return map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+	case "map[string]interface{}":
+		return map[string]interface{}{"type": "object"}
+	}
+	return map[string]interface{}{}
+}
+
+// Validate checks the content of a configuration file or a custom default against the schema.
+// Unknown steps and parameters are reported as warnings since they may be consumed outside of the piper binary.
+// Values of the wrong type, values which are not possible and parameters within a section which the metadata does not allow are reported as errors.
+func (s *Schema) Validate(file string, content []byte) ([]Finding, error) {
+	findings := []Finding{}
+	report := func(node *yamlv3.Node, severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{File: file, Line: node.Line, Column: node.Column, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	document := yamlv3.Node{}
+	if err := yamlv3.NewDecoder(bytes.NewReader(content)).Decode(&document); err != nil {
+		if err == io.EOF {
+			return findings, nil
+		}
+		return nil, errors.Wrapf(err, "failed to parse %v", file)
+	}
+	root := resolveAlias(&document)
+	if len(root.Content) > 0 {
+		root = resolveAlias(root.Content[0])
+	}
+	if root.Kind == yamlv3.ScalarNode && root.Tag == "!!null" {
+		return findings, nil
+	}
+	if root.Kind != yamlv3.MappingNode {
+		report(root, SeverityError, "configuration needs to be a map with the sections %v", strings.Join(sections, ", "))
+		return findings, nil
+	}
+
+	forEachEntry(root, func(key, value *yamlv3.Node) {
+		switch key.Value {
+		case "general":
+			s.validateSection(value, "general", s.parameters, report)
+		case "stages":
+			forEachEntry(value, func(stage, stageValue *yamlv3.Node) {
+				s.validateSection(stageValue, "stages", s.parameters, report)
+			})
+		case "steps":
+			forEachEntry(value, func(step, stepValue *yamlv3.Node) {
+				parameters, ok := s.steps[step.Value]
+				if !ok {
+					if stepName, isAlias := s.stepAliases[step.Value]; isAlias {
+						report(step, SeverityWarning, "step '%v' is deprecated, use '%v' instead", step.Value, stepName)
+						parameters = s.steps[stepName]
+					} else {
+						report(step, SeverityWarning, "unknown step '%v'%v", step.Value, suggestion(step.Value, s.stepNames()))
+						return
+					}
+				}
+				s.validateSection(stepValue, "steps", parameters, report)
+			})
+		case "customDefaults":
+			if value.Kind != yamlv3.SequenceNode {
+				report(value, SeverityError, "customDefaults needs to be a list of files")
+			}
+		case "hooks":
+		default:
+			report(key, SeverityError, "unknown section '%v'%v", key.Value, suggestion(key.Value, sections))
+		}
+	})
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings, nil
+}
+
+func (s *Schema) stepNames() []string {
+	names := []string{}
+	for name := range s.steps {
+		names = append(names, name)
+	}
+	for alias := range s.stepAliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Schema) validateSection(node *yamlv3.Node, section string, parameters map[string]*schemaParameter, report func(*yamlv3.Node, string, string, ...interface{})) {
+	names := []string{}
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	forEachEntry(node, func(key, value *yamlv3.Node) {
+		parameter, ok := parameters[key.Value]
+		if !ok {
+			report(key, SeverityWarning, "unknown parameter '%v'%v", key.Value, suggestion(key.Value, names))
+			return
+		}
+		if scope := sectionScopes[section]; !parameter.scopes[scope] {
+			report(key, SeverityError, "parameter '%v' is not allowed in section '%v', allowed sections: %v", key.Value, section, allowedSections(parameter))
+			return
+		}
+		if len(parameter.deprecatedFor) > 0 {
+			report(key, SeverityWarning, "parameter '%v' is deprecated, use '%v' instead", key.Value, parameter.deprecatedFor)
+		}
+		if value.Kind == yamlv3.ScalarNode && value.Tag == "!!null" {
+			return
+		}
+		if len(parameter.types) > 0 && !matchesType(value, parameter.types) {
+			report(value, SeverityError, "parameter '%v' expects a value of type %v", key.Value, strings.Join(parameter.types, " or "))
+			return
+		}
+		if len(parameter.possibleValues) > 0 {
+			possibleValues := []string{}
+			for _, possibleValue := range parameter.possibleValues {
+				possibleValues = append(possibleValues, fmt.Sprint(possibleValue))
+			}
+			for _, scalar := range scalars(value) {
+				if !sliceContains(possibleValues, scalar.Value) {
+					report(scalar, SeverityError, "value '%v' is not possible for parameter '%v', possible values: %v%v", scalar.Value, key.Value, strings.Join(possibleValues, ", "), suggestion(scalar.Value, possibleValues))
+				}
+			}
+		}
+	})
+}
+
+func allowedSections(parameter *schemaParameter) string {
+	allowed := []string{}
+	for _, section := range []string{"general", "stages", "steps"} {
+		if parameter.scopes[sectionScopes[section]] {
+			allowed = append(allowed, section)
+		}
+	}
+	if len(allowed) == 0 {
+		return "none, the parameter can only be passed to the step directly"
+	}
+	return strings.Join(allowed, ", ")
+}
+
+// matchesType checks the value against the types of the metadata respecting the conversions which are done when the configuration is applied
+func matchesType(node *yamlv3.Node, types []string) bool {
+	node = resolveAlias(node)
+	for _, parameterType := range types {
+		switch parameterType {
+		case "string":
+			if node.Kind == yamlv3.ScalarNode {
+				return true
+			}
+		case "bool":
+			if node.Kind == yamlv3.ScalarNode && (node.Tag == "!!bool" || strings.ToLower(node.Value) == "true" || strings.ToLower(node.Value) == "false") {
+				return true
+			}
+		case "int":
+			if node.Kind == yamlv3.ScalarNode && node.Tag == "!!int" {
+				return true
+			}
+		case "[]string":
+			if node.Kind == yamlv3.SequenceNode {
+				return true
+			}
+		case "map[string]interface{}":
+			if node.Kind == yamlv3.MappingNode {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// scalars returns the value itself or the entries of a list
+func scalars(node *yamlv3.Node) []*yamlv3.Node {
+	node = resolveAlias(node)
+	if node.Kind == yamlv3.ScalarNode {
+		return []*yamlv3.Node{node}
+	}
+	result := []*yamlv3.Node{}
+	if node.Kind == yamlv3.SequenceNode {
+		for _, entry := range node.Content {
+			if entry = resolveAlias(entry); entry.Kind == yamlv3.ScalarNode {
+				result = append(result, entry)
+			}
+		}
+	}
+	return result
+}
+
+func forEachEntry(node *yamlv3.Node, handle func(key, value *yamlv3.Node)) {
+	node = resolveAlias(node)
+	if node.Kind != yamlv3.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolveAlias(node.Content[i+1])
+		if key.Value == "<<" {
+			// merge keys of anchors
+			if value.Kind == yamlv3.SequenceNode {
+				for _, merged := range value.Content {
+					forEachEntry(merged, handle)
+				}
+			} else {
+				forEachEntry(value, handle)
+			}
+			continue
+		}
+		handle(key, value)
+	}
+}
+
+func resolveAlias(node *yamlv3.Node) *yamlv3.Node {
+	for node.Kind == yamlv3.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// suggestion returns a hint to the closest candidate in case the name looks like a typo of it
+func suggestion(name string, candidates []string) string {
+	closest := ""
+	closestDistance := 0
+	for _, candidate := range candidates {
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		if len(closest) == 0 || distance < closestDistance {
+			closest, closestDistance = candidate, distance
+		}
+	}
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	if len(closest) == 0 || closestDistance > maxDistance {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean '%v'?", closest)
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(target)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func schemaTestMetadata() map[string]StepData {
+	return map[string]StepData{
+		"mavenBuild": {
+			Metadata: StepMetadata{Name: "mavenBuild"},
+			Spec: StepSpec{
+				Inputs: StepInputs{
+					Parameters: []StepParameters{
+						{Name: "goals", Type: "[]string", Scope: []string{"PARAMETERS", "STEPS"}},
+						{Name: "flatten", Type: "bool", Scope: []string{"PARAMETERS", "STAGES", "STEPS"}},
+						{Name: "logSuccessfulMavenTransfers", Type: "bool", Scope: []string{"GENERAL", "STEPS"}, Aliases: []Alias{{Name: "logMavenTransfers", Deprecated: true}}},
+					},
+					Secrets: []StepSecrets{{Name: "altDeploymentRepositoryPasswordId", Type: "jenkins"}},
+				},
+				Containers: []Container{{Name: "mvn", Image: "maven:3.6-jdk-8"}},
+			},
+		},
+		"kubernetesDeploy": {
+			Metadata: StepMetadata{Name: "kubernetesDeploy", Aliases: []Alias{{Name: "deployToKubernetes"}}},
+			Spec: StepSpec{
+				Inputs: StepInputs{
+					Parameters: []StepParameters{
+						{Name: "deployTool", Type: "string", Scope: []string{"GENERAL", "PARAMETERS", "STAGES", "STEPS"}, PossibleValues: []interface{}{"kubectl", "helm", "helm3"}, Mandatory: true},
+						{Name: "helmValues", Type: "[]string", Scope: []string{"PARAMETERS", "STAGES", "STEPS"}},
+						{Name: "verificationTimeout", Type: "int", Scope: []string{"PARAMETERS", "STAGES", "STEPS"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := NewSchema(schemaTestMetadata())
+
+	t.Run("valid configuration", func(t *testing.T) {
+		content := `
+general:
+  deployTool: helm
+  verbose: true
+  logSuccessfulMavenTransfers: "true"
+stages:
+  Build:
+    flatten: false
+steps:
+  mavenBuild:
+    goals:
+      - install
+    dockerImage: maven:3.8
+    altDeploymentRepositoryPasswordId: myCredentials
+  kubernetesDeploy:
+    deployTool: helm3
+    verificationTimeout: 300
+    helmValues: ~
+`
+		findings, err := schema.Validate("config.yml", []byte(content))
+		assert.NoError(t, err)
+		assert.Empty(t, findings)
+	})
+
+	t.Run("typos and invalid values", func(t *testing.T) {
+		content := `general:
+  flatten: true
+  logMavenTransfers: true
+stages:
+  Build:
+    goals: [install]
+steps:
+  mavenBuil:
+    goals: install
+  kubernetesDeploy:
+    deployTol: helm
+    deployTool: helm4
+    verificationTimeout: "300"
+  deployToKubernetes:
+    helmValues: [values.yaml]
+  someGroovyStep:
+    any: value
+step:
+  mavenBuild: {}
+`
+		findings, err := schema.Validate("config.yml", []byte(content))
+		assert.NoError(t, err)
+		messages := []string{}
+		for _, finding := range findings {
+			messages = append(messages, finding.String())
+		}
+		assert.Equal(t, []string{
+			"config.yml:2:3: error: parameter 'flatten' is not allowed in section 'general', allowed sections: stages, steps",
+			"config.yml:3:3: warning: parameter 'logMavenTransfers' is deprecated, use 'logSuccessfulMavenTransfers' instead",
+			"config.yml:6:5: error: parameter 'goals' is not allowed in section 'stages', allowed sections: steps",
+			"config.yml:8:3: warning: unknown step 'mavenBuil', did you mean 'mavenBuild'?",
+			"config.yml:11:5: warning: unknown parameter 'deployTol', did you mean 'deployTool'?",
+			"config.yml:12:17: error: value 'helm4' is not possible for parameter 'deployTool', possible values: kubectl, helm, helm3, did you mean 'helm'?",
+			"config.yml:13:26: error: parameter 'verificationTimeout' expects a value of type int",
+			"config.yml:14:3: warning: step 'deployToKubernetes' is deprecated, use 'kubernetesDeploy' instead",
+			"config.yml:16:3: warning: unknown step 'someGroovyStep'",
+			"config.yml:18:1: error: unknown section 'step', did you mean 'steps'?",
+		}, messages)
+	})
+
+	t.Run("wrong type of list", func(t *testing.T) {
+		findings, err := schema.Validate("defaults.yml", []byte("steps:\n  mavenBuild:\n    goals: install\n"))
+		assert.NoError(t, err)
+		require.Len(t, findings, 1)
+		assert.Equal(t, Finding{File: "defaults.yml", Line: 3, Column: 12, Severity: SeverityError, Message: "parameter 'goals' expects a value of type []string"}, findings[0])
+	})
+
+	t.Run("anchors", func(t *testing.T) {
+		content := `
+general: &common
+  deployTool: helm
+steps:
+  kubernetesDeploy:
+    <<: *common
+    verificationTimeout: 10
+`
+		findings, err := schema.Validate("config.yml", []byte(content))
+		assert.NoError(t, err)
+		assert.Empty(t, findings)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		findings, err := schema.Validate("config.yml", []byte(""))
+		assert.NoError(t, err)
+		assert.Empty(t, findings)
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		_, err := schema.Validate("config.yml", []byte("general:\n  a: [\n"))
+		assert.Contains(t, err.Error(), "failed to parse config.yml")
+	})
+
+	t.Run("no map", func(t *testing.T) {
+		findings, err := schema.Validate("config.yml", []byte("- general\n"))
+		assert.NoError(t, err)
+		require.Len(t, findings, 1)
+		assert.Equal(t, "configuration needs to be a map with the sections customDefaults, general, hooks, stages, steps", findings[0].Message)
+	})
+}
+
+func TestSchemaJSONSchema(t *testing.T) {
+	jsonSchema := NewSchema(schemaTestMetadata()).JSONSchema()
+	properties := jsonSchema["properties"].(map[string]interface{})
+
+	general := properties["general"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"description": "(mandatory)", "type": "string", "enum": []interface{}{"kubectl", "helm", "helm3"}}, general["deployTool"])
+	assert.NotContains(t, general, "flatten")
+	assert.Equal(t, map[string]interface{}{"description": "Deprecated, use 'logSuccessfulMavenTransfers' instead.", "type": "boolean"}, general["logMavenTransfers"])
+
+	stages := properties["stages"].(map[string]interface{})["additionalProperties"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Contains(t, stages, "flatten")
+	assert.NotContains(t, stages, "goals")
+
+	steps := properties["steps"].(map[string]interface{})["properties"].(map[string]interface{})
+	mavenBuild := steps["mavenBuild"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}, mavenBuild["goals"])
+	assert.Equal(t, map[string]interface{}{}, mavenBuild["dockerImage"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/properties/steps/properties/kubernetesDeploy"}, steps["deployToKubernetes"])
+}
+
+func TestSuggestion(t *testing.T) {
+	candidates := []string{"mavenBuild", "mavenExecute", "npmExecuteScripts"}
+	assert.Equal(t, ", did you mean 'mavenBuild'?", suggestion("mavenbuild", candidates))
+	assert.Equal(t, ", did you mean 'mavenExecute'?", suggestion("mvnExecute", candidates))
+	assert.Equal(t, "", suggestion("kanikoExecute", candidates))
+	assert.Equal(t, "", suggestion("x", []string{}))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+}
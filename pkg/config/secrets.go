@@ -0,0 +1,327 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/SAP/jenkins-library/pkg/config/interpolation"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// SecretProvider resolves secrets which are referenced by step parameters via resourceRef.
+// References of type <provider>Secret set the value of the parameter, references of type <provider>SecretFile
+// set the path of a temporary file containing the value, e.g. vaultSecret or kubernetesSecretFile.
+type SecretProvider interface {
+	// GetSecret returns the fields of the secret at the path, nil in case the secret does not exist
+	GetSecret(path string) (map[string]string, error)
+}
+
+const (
+	vaultProvider        = "vault"
+	vaultDynamicProvider = "vaultDynamic"
+	fileProvider         = "file"
+	envProvider          = "env"
+	kubernetesProvider   = "kubernetes"
+	awsProvider          = "aws"
+	azureProvider        = "azure"
+	secretRefSuffix      = "Secret"
+	secretFileRefSuffix  = "SecretFile"
+)
+
+var (
+	secretProviderFilter = []string{
+		"secretFilesPath",
+		"kubernetesSecretsApiServer",
+		"kubernetesSecretsNamespace",
+		"awsSecretsRegion",
+		"awsSecretsEndpoint",
+		"azureKeyVaultUrl",
+	}
+
+	// secretProviderFactories create the providers besides vault, nil is returned in case a provider is not configured
+	secretProviderFactories = map[string]func(config StepConfig) (SecretProvider, error){
+		fileProvider:       newFileSecretProvider,
+		envProvider:        newEnvSecretProvider,
+		kubernetesProvider: newKubernetesSecretProvider,
+		awsProvider:        newAWSSecretProvider,
+		azureProvider:      newAzureSecretProvider,
+	}
+)
+
+// secretProviderName returns the provider of a reference of type <provider>Secret or <provider>SecretFile
+func secretProviderName(refType string) (string, bool) {
+	for _, suffix := range []string{secretFileRefSuffix, secretRefSuffix} {
+		if name := strings.TrimSuffix(refType, suffix); name != refType && len(name) > 0 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isVaultProvider checks whether the secrets are provided by vault, either by the KV or by dynamic secrets engines
+func isVaultProvider(name string) bool {
+	return name == vaultProvider || name == vaultDynamicProvider
+}
+
+// IsSecret checks whether the reference refers to a secret, i.e. a Jenkins credential or a secret of a secret provider
+func (r *ResourceReference) IsSecret() bool {
+	_, ok := secretProviderName(r.Type)
+	return ok || r.Type == "secret"
+}
+
+// newSecretProviders creates the providers besides vault which are referenced by the parameters
+func newSecretProviders(config StepConfig, params []StepParameters) map[string]SecretProvider {
+	providers := map[string]SecretProvider{}
+	for _, param := range params {
+		for _, ref := range param.ResourceRef {
+			name, ok := secretProviderName(ref.Type)
+			if _, created := providers[name]; !ok || created || isVaultProvider(name) {
+				continue
+			}
+			factory, ok := secretProviderFactories[name]
+			if !ok {
+				log.Entry().Warnf("Unknown secret provider '%v' referenced by parameter '%v'", name, param.Name)
+				providers[name] = nil
+				continue
+			}
+			provider, err := factory(config)
+			if err != nil {
+				log.Entry().WithError(err).Warnf("Skipping secrets of provider '%v'", name)
+			}
+			providers[name] = provider
+		}
+	}
+	return providers
+}
+
+// resolveAllSecretReferences resolves the parameters which reference secrets of the given providers
+func resolveAllSecretReferences(config *StepConfig, providers map[string]SecretProvider, params []StepParameters) {
+	for _, param := range params {
+		for _, ref := range param.ResourceRef {
+			name, ok := secretProviderName(ref.Type)
+			if provider := providers[name]; ok && provider != nil {
+				resolveSecretReference(ref, config, name, provider, param)
+			}
+		}
+	}
+}
+
+func resolveSecretReference(ref ResourceReference, config *StepConfig, providerName string, provider SecretProvider, param StepParameters) {
+	vaultDisableOverwrite, _ := config.Config["vaultDisableOverwrite"].(bool)
+	if _, ok := config.Config[param.Name].(string); isVaultProvider(providerName) && vaultDisableOverwrite && ok {
+		log.Entry().Debugf("Not fetching '%s' from vault since it has already been set", param.Name)
+		return
+	}
+
+	var secretValue *string
+	for _, secretPath := range ref.Paths {
+		// it should be possible to configure the root path were the secret is stored
+		secretPath, ok := interpolation.ResolveString(secretPath, config.Config)
+		if !ok {
+			continue
+		}
+
+		secretValue = lookupPath(provider, providerName, secretPath, &param)
+		if secretValue != nil {
+			log.Entry().Debugf("Resolved param '%s' with %s path '%s'", param.Name, providerName, secretPath)
+			if strings.HasSuffix(ref.Type, secretFileRefSuffix) {
+				filePath, err := createTemporarySecretFile(param.Name, *secretValue)
+				if err != nil {
+					log.Entry().WithError(err).Warnf("Couldn't create temporary secret file for '%s'", param.Name)
+					return
+				}
+				config.Config[param.Name] = filePath
+			} else {
+				config.Config[param.Name] = *secretValue
+			}
+			if isVaultProvider(providerName) {
+				config.recordLayer(param.Name, Layer{Source: SourceVault, Path: secretPath, Value: config.Config[param.Name]})
+			} else {
+				config.recordLayer(param.Name, Layer{Source: SourceSecret, Path: providerName + ":" + secretPath, Value: config.Config[param.Name]})
+			}
+			break
+		}
+	}
+	if secretValue == nil {
+		// the further providers are referenced besides vault for the same parameters, thus a missing secret is expected
+		if isVaultProvider(providerName) {
+			log.Entry().Warnf("Could not resolve param '%s' from %s", param.Name, providerName)
+		} else {
+			log.Entry().Debugf("Could not resolve param '%s' from %s", param.Name, providerName)
+		}
+	}
+}
+
+func lookupPath(provider SecretProvider, providerName, path string, param *StepParameters) *string {
+	log.Entry().Debugf("Trying to resolve %s parameter '%s' at '%s'", providerName, param.Name, path)
+	secret, err := provider.GetSecret(path)
+	if err != nil {
+		log.Entry().WithError(err).Warnf("Couldn't fetch secret at '%s'", path)
+		return nil
+	}
+	if secret == nil {
+		return nil
+	}
+
+	field := secretField(secret, param.Name)
+	if field != "" {
+		log.RegisterSecret(field)
+		return &field
+	}
+	log.Entry().Debugf("Secret did not contain a field name '%s'", param.Name)
+	// try parameter aliases
+	for _, alias := range param.Aliases {
+		log.Entry().Debugf("Trying alias field name '%s'", alias.Name)
+		field := secretField(secret, alias.Name)
+		if field != "" {
+			log.RegisterSecret(field)
+			if alias.Deprecated {
+				log.Entry().WithField("package", "SAP/jenkins-library/pkg/config").Warningf("DEPRECATION NOTICE: old step config key '%s' used in %s. Please switch to '%s'!", alias.Name, providerName, param.Name)
+			}
+			return &field
+		}
+	}
+	return nil
+}
+
+// secretField returns the field of the secret, fields named like environment variables, e.g. GITHUB_TOKEN for githubToken, are considered as well
+func secretField(secret map[string]string, name string) string {
+	if field := secret[name]; field != "" {
+		return field
+	}
+	return secret[envVarName(name)]
+}
+
+// envVarName converts a parameter name into the name of an environment variable, e.g. githubToken into GITHUB_TOKEN
+func envVarName(name string) string {
+	result := strings.Builder{}
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			result.WriteRune('_')
+		}
+		result.WriteRune(unicode.ToUpper(r))
+	}
+	return convertEnvVar(result.String())
+}
+
+// secretFields converts the value of a secret into fields. JSON objects provide a field per entry,
+// other values are provided as single field with the given name.
+func secretFields(name string, value []byte) map[string]string {
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(value, &values); err != nil {
+		return map[string]string{name: string(value)}
+	}
+	return stringFields(values)
+}
+
+func stringFields(values map[string]interface{}) map[string]string {
+	fields := map[string]string{}
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			fields[key] = v
+		case nil:
+		default:
+			content, _ := json.Marshal(v)
+			fields[key] = string(content)
+		}
+	}
+	return fields
+}
+
+// fileSecretProvider reads secrets from files, e.g. mounted Kubernetes or Docker secrets.
+// The path refers either to a directory containing one file per field or to a JSON or YAML file.
+type fileSecretProvider struct {
+	root string
+}
+
+func newFileSecretProvider(config StepConfig) (SecretProvider, error) {
+	root, _ := config.Config["secretFilesPath"].(string)
+	return &fileSecretProvider{root: root}, nil
+}
+
+// GetSecret returns the fields of the secret directory or file, relative paths are only considered if secretFilesPath is configured
+func (f *fileSecretProvider) GetSecret(path string) (map[string]string, error) {
+	if !filepath.IsAbs(path) {
+		if len(f.root) == 0 {
+			return nil, nil
+		}
+		path = filepath.Join(f.root, path)
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &values); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse secret file %v", path)
+		}
+		return stringFields(values), nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	secret := map[string]string{}
+	for _, entry := range entries {
+		// mounted Kubernetes secrets contain hidden directories like ..data
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		fieldPath := filepath.Join(path, entry.Name())
+		// entries of mounted secrets are symbolic links
+		if info, err := os.Stat(fieldPath); err != nil || info.IsDir() {
+			continue
+		}
+		content, err := ioutil.ReadFile(fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		secret[entry.Name()] = strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r")
+	}
+	return secret, nil
+}
+
+// envSecretProvider reads secrets from environment variables, the path is the prefix of the variables.
+// E.g. the path MY_SECRETS refers to the fields GITHUB_TOKEN and password of MY_SECRETS_GITHUB_TOKEN and MY_SECRETS_password.
+type envSecretProvider struct {
+	environ func() []string
+}
+
+func newEnvSecretProvider(StepConfig) (SecretProvider, error) {
+	return &envSecretProvider{environ: os.Environ}, nil
+}
+
+// GetSecret returns the fields of the environment variables with the path as prefix
+func (e *envSecretProvider) GetSecret(path string) (map[string]string, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("the prefix of the environment variables is required")
+	}
+	secret := map[string]string{}
+	for _, variable := range e.environ() {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) == 2 && strings.HasPrefix(parts[0], path+"_") {
+			secret[strings.TrimPrefix(parts[0], path+"_")] = parts[1]
+		}
+	}
+	if len(secret) == 0 {
+		return nil, nil
+	}
+	return secret, nil
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const awsSecretsManagerService = "secretsmanager"
+
+// awsSecretProvider reads secrets from the AWS Secrets Manager, the path is the name or the ARN of the secret.
+// The credentials are taken from the environment variables AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type awsSecretProvider struct {
+	endpoint        string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	client          *http.Client
+	now             func() time.Time
+}
+
+func newAWSSecretProvider(config StepConfig) (SecretProvider, error) {
+	region, _ := config.Config["awsSecretsRegion"].(string)
+	if len(region) == 0 {
+		region = os.Getenv("AWS_REGION")
+	}
+	if len(region) == 0 {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	accessKeyID, secretAccessKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if len(region) == 0 || len(accessKeyID) == 0 || len(secretAccessKey) == 0 {
+		log.Entry().Debug("Skipping fetching secrets from AWS Secrets Manager since it is not configured")
+		return nil, nil
+	}
+	endpoint, _ := config.Config["awsSecretsEndpoint"].(string)
+	if len(endpoint) == 0 {
+		endpoint = fmt.Sprintf("https://%v.%v.amazonaws.com", awsSecretsManagerService, region)
+	}
+	return &awsSecretProvider{
+		endpoint:        strings.TrimSuffix(endpoint, "/"),
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		client:          &http.Client{Timeout: time.Minute},
+		now:             time.Now,
+	}, nil
+}
+
+// GetSecret returns the value of the secret, JSON objects provide a field per entry,
+// other values are provided as field named like the last segment of the path
+func (a *awsSecretProvider) GetSecret(path string) (map[string]string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	a.sign(request, body)
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve secret %v", path)
+	}
+	defer response.Body.Close()
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read secret %v", path)
+	}
+
+	result := struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		SecretString string
+		SecretBinary []byte
+	}{}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse secret %v", path)
+	}
+	if response.StatusCode != http.StatusOK {
+		if strings.HasSuffix(result.Type, "ResourceNotFoundException") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve secret %v: %v %v %v", path, response.Status, result.Type, result.Message)
+	}
+
+	name := path[strings.LastIndexAny(path, "/:")+1:]
+	if result.SecretBinary != nil {
+		return secretFields(name, result.SecretBinary), nil
+	}
+	return secretFields(name, []byte(result.SecretString)), nil
+}
+
+// sign adds the AWS signature version 4 to the request
+func (a *awsSecretProvider) sign(request *http.Request, body []byte) {
+	now := a.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	request.Header.Set("X-Amz-Date", amzDate)
+	if len(a.sessionToken) > 0 {
+		request.Header.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%v:%v\n", name, strings.TrimSpace(headers[name]))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := request.URL.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		path,
+		canonicalQuery(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := fmt.Sprintf("%v/%v/%v/aws4_request", date, a.region, awsSecretsManagerService)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(awsSigningKey(a.secretAccessKey, date, a.region, awsSecretsManagerService), stringToSign))
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%v/%v, SignedHeaders=%v, Signature=%v", a.accessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	// Encode sorts by key, AWS expects spaces to be encoded as %20
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func awsSigningKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
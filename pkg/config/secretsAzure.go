@@ -0,0 +1,118 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	azureKeyVaultAPIVersion = "7.2"
+	azureKeyVaultScope      = "https://vault.azure.net/.default"
+	azureAuthorityHost      = "https://login.microsoftonline.com"
+)
+
+// azureSecretProvider reads secrets from an Azure Key Vault, the path is the name of the secret optionally followed by the version.
+// A service principal is used for the authentication which is taken from the environment variables
+// AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET.
+type azureSecretProvider struct {
+	vaultURL      string
+	authorityHost string
+	tenantID      string
+	clientID      string
+	clientSecret  string
+	token         string
+	client        *http.Client
+}
+
+func newAzureSecretProvider(config StepConfig) (SecretProvider, error) {
+	vaultURL, _ := config.Config["azureKeyVaultUrl"].(string)
+	tenantID, clientID, clientSecret := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_CLIENT_SECRET")
+	if len(vaultURL) == 0 || len(tenantID) == 0 || len(clientID) == 0 || len(clientSecret) == 0 {
+		log.Entry().Debug("Skipping fetching secrets from Azure Key Vault since it is not configured")
+		return nil, nil
+	}
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if len(authorityHost) == 0 {
+		authorityHost = azureAuthorityHost
+	}
+	return &azureSecretProvider{
+		vaultURL:      strings.TrimSuffix(vaultURL, "/"),
+		authorityHost: strings.TrimSuffix(authorityHost, "/"),
+		tenantID:      tenantID,
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		client:        &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// GetSecret returns the value of the secret, JSON objects provide a field per entry,
+// other values are provided as field named like the secret
+func (a *azureSecretProvider) GetSecret(path string) (map[string]string, error) {
+	path = strings.Trim(path, "/")
+	if err := a.login(); err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/secrets/%v?api-version=%v", a.vaultURL, path, azureKeyVaultAPIVersion), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+a.token)
+	request.Header.Set("Accept", "application/json")
+	response, err := a.client.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve secret %v", path)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve secret %v: %v", path, response.Status)
+	}
+
+	secret := struct {
+		Value string `json:"value"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse secret %v", path)
+	}
+	return secretFields(strings.SplitN(path, "/", 2)[0], []byte(secret.Value)), nil
+}
+
+// login obtains an access token for the key vault via the client credentials flow
+func (a *azureSecretProvider) login() error {
+	if len(a.token) > 0 {
+		return nil
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {a.clientID},
+		"client_secret": {a.clientSecret},
+		"scope":         {azureKeyVaultScope},
+	}
+	response, err := a.client.PostForm(fmt.Sprintf("%v/%v/oauth2/v2.0/token", a.authorityHost, a.tenantID), form)
+	if err != nil {
+		return errors.Wrap(err, "failed to authenticate at Azure Active Directory")
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to authenticate at Azure Active Directory: %v", response.Status)
+	}
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return errors.Wrap(err, "failed to parse the token of Azure Active Directory")
+	}
+	log.RegisterSecret(token.AccessToken)
+	a.token = token.AccessToken
+	return nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// kubernetesServiceAccountDir contains the credentials of the service account of a pod
+var kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubernetesSecretProvider reads secrets via the Kubernetes API using the service account of the pod.
+// The path is the name of the secret, optionally prefixed by the namespace, e.g. build/piper-credentials.
+type kubernetesSecretProvider struct {
+	apiServer string
+	namespace string
+	token     string
+	client    *http.Client
+}
+
+func newKubernetesSecretProvider(config StepConfig) (SecretProvider, error) {
+	apiServer, _ := config.Config["kubernetesSecretsApiServer"].(string)
+	namespace, _ := config.Config["kubernetesSecretsNamespace"].(string)
+	// within a pod the API server of the cluster is used once the namespace of the secrets is configured
+	if len(apiServer) == 0 && len(namespace) > 0 && len(os.Getenv("KUBERNETES_SERVICE_HOST")) > 0 {
+		apiServer = "https://" + net.JoinHostPort(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"))
+	}
+	if len(apiServer) == 0 {
+		log.Entry().Debug("Skipping fetching secrets from Kubernetes since it is not configured")
+		return nil, nil
+	}
+
+	if len(namespace) == 0 {
+		content, _ := ioutil.ReadFile(filepath.Join(kubernetesServiceAccountDir, "namespace"))
+		namespace = strings.TrimSpace(string(content))
+	}
+	token, err := ioutil.ReadFile(filepath.Join(kubernetesServiceAccountDir, "token"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the token of the service account")
+	}
+	log.RegisterSecret(string(token))
+
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if certificate, err := ioutil.ReadFile(filepath.Join(kubernetesServiceAccountDir, "ca.crt")); err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(certificate)
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &kubernetesSecretProvider{
+		apiServer: strings.TrimSuffix(apiServer, "/"),
+		namespace: namespace,
+		token:     strings.TrimSpace(string(token)),
+		client:    &http.Client{Transport: transport, Timeout: time.Minute},
+	}, nil
+}
+
+// GetSecret returns the decoded data of the Kubernetes secret
+func (k *kubernetesSecretProvider) GetSecret(path string) (map[string]string, error) {
+	namespace, name := k.namespace, path
+	if parts := strings.SplitN(path, "/", 2); len(parts) == 2 {
+		namespace, name = parts[0], parts[1]
+	}
+	if len(namespace) == 0 {
+		return nil, fmt.Errorf("the namespace of secret '%v' is unknown", name)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/api/v1/namespaces/%v/secrets/%v", k.apiServer, namespace, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+k.token)
+	request.Header.Set("Accept", "application/json")
+	response, err := k.client.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve secret %v", path)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve secret %v: %v", path, response.Status)
+	}
+
+	// the values are base64 encoded which is decoded by unmarshalling into []byte
+	secret := struct {
+		Data map[string][]byte `json:"data"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse secret %v", path)
+	}
+	fields := map[string]string{}
+	for key, value := range secret.Data {
+		fields[key] = string(value)
+	}
+	return fields, nil
+}
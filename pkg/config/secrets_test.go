@@ -0,0 +1,258 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretProviderName(t *testing.T) {
+	tt := []struct {
+		refType  string
+		provider string
+		ok       bool
+	}{
+		{refType: "vaultSecret", provider: "vault", ok: true},
+		{refType: "kubernetesSecretFile", provider: "kubernetes", ok: true},
+		{refType: "secret", ok: false},
+		{refType: "commonPipelineEnvironment", ok: false},
+	}
+	for _, test := range tt {
+		t.Run(test.refType, func(t *testing.T) {
+			provider, ok := secretProviderName(test.refType)
+			assert.Equal(t, test.provider, provider)
+			assert.Equal(t, test.ok, ok)
+			ref := ResourceReference{Type: test.refType}
+			assert.Equal(t, test.ok || test.refType == "secret", ref.IsSecret())
+		})
+	}
+}
+
+func TestResolveAllSecretReferences(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func() {
+		RemoveVaultSecretFiles()
+		VaultSecretFileDirectory = ""
+	}()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "github"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "github", "githubToken"), []byte("token\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "keys.yml"), []byte("privateKey: key\n"), 0600))
+
+	params := []StepParameters{
+		{Name: "githubToken", ResourceRef: []ResourceReference{{Type: "fileSecret", Paths: []string{"unknown", "$(name)"}}}},
+		{Name: "password", Aliases: []Alias{{Name: "token"}}, ResourceRef: []ResourceReference{{Type: "envSecret", Paths: []string{"MY_SECRETS"}}}},
+		{Name: "privateKey", ResourceRef: []ResourceReference{{Type: "fileSecretFile", Paths: []string{"keys.yml"}}}},
+		{Name: "unresolved", ResourceRef: []ResourceReference{{Type: "fileSecret", Paths: []string{"github"}}}},
+	}
+	stepConfig := StepConfig{Config: map[string]interface{}{"name": "github", "unresolved": "keep"}, Provenance: Provenance{}}
+	providers := map[string]SecretProvider{
+		"file": &fileSecretProvider{root: dir},
+		"env":  &envSecretProvider{environ: func() []string { return []string{"MY_SECRETS_TOKEN=secret", "OTHER_PASSWORD=other"} }},
+	}
+
+	resolveAllSecretReferences(&stepConfig, providers, params)
+
+	assert.Equal(t, "token", stepConfig.Config["githubToken"])
+	assert.Equal(t, "secret", stepConfig.Config["password"])
+	assert.Equal(t, "keep", stepConfig.Config["unresolved"])
+	content, err := ioutil.ReadFile(stepConfig.Config["privateKey"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "key", string(content))
+	assert.Equal(t, []Layer{{Source: SourceSecret, Path: "file:github", Value: "token"}}, stepConfig.Provenance["githubToken"])
+}
+
+func TestNewSecretProviders(t *testing.T) {
+	params := []StepParameters{
+		{Name: "p0", ResourceRef: []ResourceReference{{Type: "vaultSecret"}, {Type: "fileSecret"}, {Type: "secret"}}},
+		{Name: "p1", ResourceRef: []ResourceReference{{Type: "fileSecretFile"}, {Type: "unknownSecret"}}},
+	}
+
+	providers := newSecretProviders(StepConfig{Config: map[string]interface{}{"secretFilesPath": "/etc/secrets"}}, params)
+
+	assert.Equal(t, map[string]SecretProvider{"file": &fileSecretProvider{root: "/etc/secrets"}, "unknown": nil}, providers)
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// layout of a mounted Kubernetes secret
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "mounted", "..data"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "mounted", "..data", "username"), []byte("user"), 0600))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "username"), filepath.Join(dir, "mounted", "username")))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret.json"), []byte(`{"password": "secret", "port": 22}`), 0600))
+	provider := &fileSecretProvider{root: dir}
+
+	secret, err := provider.GetSecret("mounted")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"username": "user"}, secret)
+
+	secret, err = provider.GetSecret(filepath.Join(dir, "secret.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "secret", "port": "22"}, secret)
+
+	secret, err = provider.GetSecret("unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, secret)
+
+	t.Run("relative path without secretFilesPath", func(t *testing.T) {
+		// the working directory of the test contains the source files
+		secret, err := (&fileSecretProvider{}).GetSecret(".")
+		assert.NoError(t, err)
+		assert.Nil(t, secret)
+	})
+}
+
+func TestSecretField(t *testing.T) {
+	secret := map[string]string{"password": "p", "GITHUB_TOKEN": "t"}
+	assert.Equal(t, "p", secretField(secret, "password"))
+	assert.Equal(t, "t", secretField(secret, "githubToken"))
+	assert.Equal(t, "", secretField(secret, "user"))
+}
+
+func TestKubernetesSecretProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/v1/namespaces/build/secrets/credentials" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"password": base64.StdEncoding.EncodeToString([]byte("secret"))}})
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("token\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "namespace"), []byte("build"), 0600))
+	serviceAccountDir := kubernetesServiceAccountDir
+	kubernetesServiceAccountDir = dir
+	defer func() { kubernetesServiceAccountDir = serviceAccountDir }()
+
+	provider, err := newKubernetesSecretProvider(StepConfig{Config: map[string]interface{}{"kubernetesSecretsApiServer": server.URL}})
+	require.NoError(t, err)
+
+	secret, err := provider.GetSecret("credentials")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "secret"}, secret)
+
+	secret, err = provider.GetSecret("other/credentials")
+	assert.NoError(t, err)
+	assert.Nil(t, secret)
+
+	t.Run("within a pod", func(t *testing.T) {
+		os.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+		os.Setenv("KUBERNETES_SERVICE_PORT", "443")
+		defer os.Unsetenv("KUBERNETES_SERVICE_HOST")
+		defer os.Unsetenv("KUBERNETES_SERVICE_PORT")
+
+		provider, err := newKubernetesSecretProvider(StepConfig{Config: map[string]interface{}{}})
+		assert.NoError(t, err)
+		assert.Nil(t, provider)
+
+		provider, err = newKubernetesSecretProvider(StepConfig{Config: map[string]interface{}{"kubernetesSecretsNamespace": "build"}})
+		assert.NoError(t, err)
+		if assert.NotNil(t, provider) {
+			assert.Equal(t, "https://10.0.0.1:443", provider.(*kubernetesSecretProvider).apiServer)
+		}
+	})
+}
+
+func TestAWSSecretProvider(t *testing.T) {
+	t.Run("signing key", func(t *testing.T) {
+		// example of the AWS documentation
+		key := awsSigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+		assert.Equal(t, "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9", hex.EncodeToString(key))
+	})
+
+	t.Run("get secret", func(t *testing.T) {
+		var authorization string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			request := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&request)
+			switch request["SecretId"] {
+			case "piper/credentials":
+				json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"password": "secret"}`})
+			case "piper/token":
+				json.NewEncoder(w).Encode(map[string]string{"SecretString": "plain"})
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException", "message": "not found"})
+			}
+		}))
+		defer server.Close()
+		provider := &awsSecretProvider{endpoint: server.URL, region: "eu-central-1", accessKeyID: "AKID", secretAccessKey: "key", client: server.Client(),
+			now: func() time.Time { return time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC) }}
+
+		secret, err := provider.GetSecret("piper/credentials")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "secret"}, secret)
+		assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKID/20210301/eu-central-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature="), authorization)
+
+		secret, err = provider.GetSecret("piper/token")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"token": "plain"}, secret)
+
+		secret, err = provider.GetSecret("unknown")
+		assert.NoError(t, err)
+		assert.Nil(t, secret)
+	})
+}
+
+func TestAzureSecretProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/tenant/oauth2/v2.0/token":
+			r.ParseForm()
+			if r.Form.Get("client_secret") != "clientSecret" || r.Form.Get("scope") != azureKeyVaultScope {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "token"})
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/secrets/credentials" && r.URL.Query().Get("api-version") == azureKeyVaultAPIVersion:
+			json.NewEncoder(w).Encode(map[string]string{"value": `{"password": "secret"}`})
+		case r.URL.Path == "/secrets/token/v1":
+			json.NewEncoder(w).Encode(map[string]string{"value": "plain"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	provider := &azureSecretProvider{vaultURL: server.URL, authorityHost: server.URL, tenantID: "tenant", clientID: "client", clientSecret: "clientSecret", client: server.Client()}
+
+	secret, err := provider.GetSecret("credentials")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "secret"}, secret)
+
+	secret, err = provider.GetSecret("token/v1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"token": "plain"}, secret)
+
+	secret, err = provider.GetSecret("unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, secret)
+
+	provider = &azureSecretProvider{vaultURL: server.URL, authorityHost: server.URL, tenantID: "tenant", clientID: "client", clientSecret: "wrong", client: server.Client()}
+	_, err = provider.GetSecret("credentials")
+	assert.EqualError(t, err, "failed to authenticate at Azure Active Directory: 401 Unauthorized")
+}
@@ -0,0 +1,333 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// StageConditions describe when stages and their steps are active, e.g.
+//
+//	stages:
+//	  Build:
+//	    stepConditions:
+//	      sonarExecuteScan:
+//	        filePattern: '**/sonar-project.properties'
+type StageConditions struct {
+	Stages map[string]StageCondition `json:"stages"`
+}
+
+// StageCondition describes the activation of a stage, a stage is active in case any of its steps is active
+type StageCondition struct {
+	StepConditions map[string]StepCondition `json:"stepConditions,omitempty"`
+	// ExtensionExists activates the stage in case a project extension exists for the stage
+	ExtensionExists bool `json:"extensionExists,omitempty"`
+}
+
+// StepCondition describes the activation of a step, a step is active in case any of the conditions is fulfilled
+type StepCondition struct {
+	// Config requires one of the values for a configuration key of the step, any value in case no values are given
+	Config ConditionValues `json:"config,omitempty"`
+	// ConfigKeys requires a value for one of the configuration keys of the step, e.g. cloudFoundry/space
+	ConfigKeys StringList `json:"configKeys,omitempty"`
+	// FilePatternFromConfig requires files matching the pattern which is configured for the step with the given key
+	FilePatternFromConfig string `json:"filePatternFromConfig,omitempty"`
+	// FilePattern requires files matching one of the patterns within the workspace
+	FilePattern StringList `json:"filePattern,omitempty"`
+	// NpmScripts requires one of the scripts within a package.json of the workspace
+	NpmScripts StringList `json:"npmScripts,omitempty"`
+	// CommonPipelineEnvironment requires one of the values for a key of the commonPipelineEnvironment, any value in case no values are given
+	CommonPipelineEnvironment ConditionValues `json:"commonPipelineEnvironment,omitempty"`
+	// BranchPattern requires the branch to match one of the regular expressions
+	BranchPattern StringList `json:"branchPattern,omitempty"`
+}
+
+// StringList is a list of strings which can also be given as single string
+type StringList []string
+
+// UnmarshalJSON accepts a single value or a list of values, values like true or 1 are taken as string
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		list = []interface{}{value}
+	}
+	*l = StringList{}
+	for _, entry := range list {
+		switch entry.(type) {
+		case string, bool, float64:
+			*l = append(*l, fmt.Sprint(entry))
+		default:
+			return fmt.Errorf("expected a string or a list of strings but got %v", string(data))
+		}
+	}
+	return nil
+}
+
+// ConditionValues maps keys to the expected values, a key without values requires any non-empty value.
+// A single key or a list of keys can be given instead of a map.
+type ConditionValues map[string][]string
+
+// UnmarshalJSON accepts a single key, a list of keys or a map of keys to a single value or a list of values
+func (v *ConditionValues) UnmarshalJSON(data []byte) error {
+	var keys StringList
+	if err := json.Unmarshal(data, &keys); err == nil {
+		*v = ConditionValues{}
+		for _, key := range keys {
+			(*v)[key] = nil
+		}
+		return nil
+	}
+	values := map[string]StringList{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("expected a key, a list of keys or a map of keys to values but got %v", string(data))
+	}
+	*v = ConditionValues{}
+	for key, value := range values {
+		(*v)[key] = value
+	}
+	return nil
+}
+
+// Activation contains the active stages and the active steps per stage
+type Activation struct {
+	Stages map[string]bool            `json:"stages"`
+	Steps  map[string]map[string]bool `json:"steps"`
+}
+
+// ConditionUtils provides access to the workspace for the evaluation of conditions
+type ConditionUtils interface {
+	FileExists(filename string) (bool, error)
+	FileRead(path string) ([]byte, error)
+	Glob(pattern string) ([]string, error)
+}
+
+// ReadStageConditions reads the stage conditions in YAML format
+func ReadStageConditions(conditions io.ReadCloser) (StageConditions, error) {
+	defer conditions.Close()
+	var result StageConditions
+	content, err := ioutil.ReadAll(conditions)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to read stage conditions")
+	}
+	if err := yaml.Unmarshal(content, &result); err != nil {
+		return result, errors.Wrap(err, "failed to parse stage conditions")
+	}
+	return result, nil
+}
+
+// EvaluateStageConditions evaluates the conditions of all stages and steps against the configuration,
+// the workspace and the commonPipelineEnvironment. The configuration needs to be initialized, see InitializeConfig.
+// A stage which is only active on the productive branch (runInAllBranches: false) is inactive on other branches,
+// a stage with project configuration is always active.
+func (c *Config) EvaluateStageConditions(conditions StageConditions, branch string, utils ConditionUtils) (Activation, error) {
+	activation := Activation{Stages: map[string]bool{}, Steps: map[string]map[string]bool{}}
+	if len(branch) == 0 {
+		branch, _ = gitValue("branch")
+	}
+	general := c.conditionConfig("", "")
+	productiveBranch, _ := general["productiveBranch"].(string)
+	if len(productiveBranch) == 0 {
+		productiveBranch = "master"
+	}
+
+	stageNames := []string{}
+	for stageName := range conditions.Stages {
+		stageNames = append(stageNames, stageName)
+	}
+	sort.Strings(stageNames)
+
+	for _, stageName := range stageNames {
+		stage := conditions.Stages[stageName]
+		activation.Steps[stageName] = map[string]bool{}
+		anyStepActive := false
+		for stepName, condition := range stage.StepConditions {
+			active, err := c.evaluateStepCondition(condition, stageName, stepName, branch, utils)
+			if err != nil {
+				return activation, errors.Wrapf(err, "failed to evaluate conditions of step '%v' in stage '%v'", stepName, stageName)
+			}
+			activation.Steps[stageName][stepName] = active
+			anyStepActive = anyStepActive || active
+		}
+
+		stageConfig := c.conditionStageConfig(stageName)
+		switch {
+		case stageConfig["runInAllBranches"] == false && branch != productiveBranch:
+			activation.Stages[stageName] = false
+		case len(c.Stages[stageName]) > 0:
+			activation.Stages[stageName] = true
+		case stage.ExtensionExists && !anyStepActive:
+			extensionsDirectory, _ := general["projectExtensionsDirectory"].(string)
+			if len(extensionsDirectory) == 0 {
+				extensionsDirectory = ".pipeline/extensions/"
+			}
+			exists, _ := utils.FileExists(path.Join(extensionsDirectory, stageName+".groovy"))
+			activation.Stages[stageName] = exists
+		default:
+			activation.Stages[stageName] = anyStepActive
+		}
+		log.Entry().Debugf("Stage '%v' active: %v, steps: %v", stageName, activation.Stages[stageName], activation.Steps[stageName])
+	}
+	return activation, nil
+}
+
+func (c *Config) evaluateStepCondition(condition StepCondition, stageName, stepName, branch string, utils ConditionUtils) (bool, error) {
+	stepConfig := c.conditionConfig(stageName, stepName)
+
+	if matchesValues(condition.Config, func(key string) (interface{}, bool) {
+		value := valueByPath(stepConfig, key)
+		return value, value != nil
+	}) {
+		return true, nil
+	}
+	for _, key := range condition.ConfigKeys {
+		if !isEmptyValue(valueByPath(stepConfig, key)) {
+			return true, nil
+		}
+	}
+	if matchesValues(condition.CommonPipelineEnvironment, c.cpeValue) {
+		return true, nil
+	}
+	for _, pattern := range condition.BranchPattern {
+		expression, err := regexp.Compile("^(" + pattern + ")$")
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid branch pattern '%v'", pattern)
+		}
+		if len(branch) > 0 && expression.MatchString(branch) {
+			return true, nil
+		}
+	}
+
+	patterns := append([]string{}, condition.FilePattern...)
+	if len(condition.FilePatternFromConfig) > 0 {
+		if pattern, ok := valueByPath(stepConfig, condition.FilePatternFromConfig).(string); ok && len(pattern) > 0 {
+			patterns = append(patterns, pattern)
+		}
+	}
+	for _, pattern := range patterns {
+		matches, err := utils.Glob(pattern)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid file pattern '%v'", pattern)
+		}
+		if len(matches) > 0 {
+			return true, nil
+		}
+	}
+
+	if len(condition.NpmScripts) > 0 {
+		return npmScriptExists(condition.NpmScripts, utils)
+	}
+	return false, nil
+}
+
+// conditionConfig merges the general, step and stage configuration of the defaults and the project configuration.
+// Like in the Groovy layer the configuration is not filtered since conditions may refer to keys which are only known there.
+func (c *Config) conditionConfig(stageName, stepName string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, def := range c.defaults.Defaults {
+		result = merge(result, def.General)
+		result = merge(result, def.Steps[stepName])
+		result = merge(result, def.Stages[stageName])
+	}
+	result = merge(result, c.General)
+	result = merge(result, c.Steps[stepName])
+	return merge(result, c.Stages[stageName])
+}
+
+// conditionStageConfig merges the stage configuration of the defaults and the project configuration
+func (c *Config) conditionStageConfig(stageName string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, def := range c.defaults.Defaults {
+		result = merge(result, def.Stages[stageName])
+	}
+	return merge(result, c.Stages[stageName])
+}
+
+// matchesValues checks whether any key has one of the expected values or any non-empty value in case no values are expected
+func matchesValues(expected ConditionValues, lookup func(key string) (interface{}, bool)) bool {
+	for key, values := range expected {
+		value, ok := lookup(key)
+		if !ok || isEmptyValue(value) {
+			continue
+		}
+		if len(values) == 0 {
+			return true
+		}
+		for _, expectedValue := range values {
+			if fmt.Sprint(value) == expectedValue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func npmScriptExists(scripts []string, utils ConditionUtils) (bool, error) {
+	packageJSONFiles, err := utils.Glob("**/package.json")
+	if err != nil {
+		return false, err
+	}
+	for _, file := range packageJSONFiles {
+		if strings.Contains(file, "node_modules/") {
+			continue
+		}
+		content, err := utils.FileRead(file)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to read %v", file)
+		}
+		packageJSON := struct {
+			Scripts map[string]interface{} `json:"scripts"`
+		}{}
+		if err := json.Unmarshal(content, &packageJSON); err != nil {
+			return false, errors.Wrapf(err, "failed to parse %v", file)
+		}
+		for _, script := range scripts {
+			if packageJSON.Scripts[script] != nil {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// valueByPath returns the value of a key path like cloudFoundry/space
+func valueByPath(data map[string]interface{}, keyPath string) interface{} {
+	var value interface{} = data
+	for _, key := range strings.Split(keyPath, "/") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// isEmptyValue follows the Groovy truth, i.e. nil, false, empty strings, lists and maps are empty
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadStageConditions(t *testing.T) {
+	conditions, err := ReadStageConditions(ioutil.NopCloser(strings.NewReader(`
+stages:
+  Acceptance:
+    extensionExists: true
+    stepConditions:
+      cloudFoundryDeploy:
+        configKeys:
+          - cloudFoundry/space
+      healthExecuteCheck:
+        config: testServerUrl
+      newmanExecute:
+        filePatternFromConfig: newmanCollection
+        config:
+          testRepository: true
+          deployTool: [cf_native, mtaDeployPlugin]
+  Build:
+    stepConditions:
+      npmExecuteScripts:
+        npmScripts: [ci-build]
+      sonarExecuteScan:
+        filePattern: '**/sonar-project.properties'
+        branchPattern: master
+`)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, StageConditions{Stages: map[string]StageCondition{
+		"Acceptance": {ExtensionExists: true, StepConditions: map[string]StepCondition{
+			"cloudFoundryDeploy": {ConfigKeys: StringList{"cloudFoundry/space"}},
+			"healthExecuteCheck": {Config: ConditionValues{"testServerUrl": nil}},
+			"newmanExecute":      {FilePatternFromConfig: "newmanCollection", Config: ConditionValues{"testRepository": {"true"}, "deployTool": {"cf_native", "mtaDeployPlugin"}}},
+		}},
+		"Build": {StepConditions: map[string]StepCondition{
+			"npmExecuteScripts": {NpmScripts: StringList{"ci-build"}},
+			"sonarExecuteScan":  {FilePattern: StringList{"**/sonar-project.properties"}, BranchPattern: StringList{"master"}},
+		}},
+	}}, conditions)
+
+	_, err = ReadStageConditions(ioutil.NopCloser(strings.NewReader("stages:\n  Build:\n    stepConditions:\n      step:\n        configKeys: {a: {b: c}}\n")))
+	assert.Contains(t, fmt.Sprint(err), "failed to parse stage conditions")
+}
+
+func TestEvaluateStageConditions(t *testing.T) {
+	conditions := StageConditions{Stages: map[string]StageCondition{
+		"Build": {StepConditions: map[string]StepCondition{
+			"mavenExecuteStaticCodeChecks": {FilePattern: StringList{"pom.xml", "**/pom.xml"}},
+			"npmExecuteScripts":            {NpmScripts: StringList{"ci-build"}},
+			"kanikoExecute":                {ConfigKeys: StringList{"containerImageName"}},
+		}},
+		"Acceptance": {StepConditions: map[string]StepCondition{
+			"cloudFoundryDeploy": {ConfigKeys: StringList{"cloudFoundry/space"}},
+			"newmanExecute":      {FilePatternFromConfig: "newmanCollection"},
+		}},
+		"Security": {ExtensionExists: true, StepConditions: map[string]StepCondition{
+			"whitesourceExecuteScan": {Config: ConditionValues{"buildTool": {"npm"}}},
+		}},
+		"Release": {StepConditions: map[string]StepCondition{
+			"githubPublishRelease": {CommonPipelineEnvironment: ConditionValues{"artifactVersion": nil}},
+		}},
+		"Promote": {StepConditions: map[string]StepCondition{
+			"containerPushToRegistry": {BranchPattern: StringList{"release/.*"}},
+		}},
+	}}
+	defaults := `
+general:
+  buildTool: maven
+stages:
+  Release:
+    runInAllBranches: false
+steps:
+  newmanExecute:
+    newmanCollection: '**/*.postman_collection.json'
+`
+
+	envRootPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(envRootPath)
+	require.NoError(t, os.MkdirAll(filepath.Join(envRootPath, "commonPipelineEnvironment"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(envRootPath, "commonPipelineEnvironment", "artifactVersion"), []byte("1.0.0"), 0600))
+
+	newConfig := func(t *testing.T, projectConfig string) *Config {
+		c := &Config{}
+		c.SetEnvRootPath(envRootPath)
+		require.NoError(t, c.InitializeConfig(ioutil.NopCloser(strings.NewReader(projectConfig)), []io.ReadCloser{ioutil.NopCloser(strings.NewReader(defaults))}, false))
+		return c
+	}
+
+	t.Run("conditions of steps", func(t *testing.T) {
+		utils := &mock.FilesMock{}
+		utils.AddFile("pom.xml", []byte{})
+		utils.AddFile("package.json", []byte(`{"scripts": {"ci-build": "ui5 build"}}`))
+		utils.AddFile("tests/api.postman_collection.json", []byte{})
+		c := newConfig(t, "steps:\n  cloudFoundryDeploy:\n    cloudFoundry:\n      space: dev\n")
+
+		activation, err := c.EvaluateStageConditions(conditions, "master", utils)
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]map[string]bool{
+			"Build":      {"mavenExecuteStaticCodeChecks": true, "npmExecuteScripts": true, "kanikoExecute": false},
+			"Acceptance": {"cloudFoundryDeploy": true, "newmanExecute": true},
+			"Security":   {"whitesourceExecuteScan": false},
+			"Release":    {"githubPublishRelease": true},
+			"Promote":    {"containerPushToRegistry": false},
+		}, activation.Steps)
+		assert.Equal(t, map[string]bool{"Build": true, "Acceptance": true, "Security": false, "Release": true, "Promote": false}, activation.Stages)
+	})
+
+	t.Run("stage configuration, extensions and branches", func(t *testing.T) {
+		utils := &mock.FilesMock{}
+		utils.AddFile("node_modules/lib/package.json", []byte(`{"scripts": {"ci-build": "build"}}`))
+		utils.AddFile("extensions/Security.groovy", []byte{})
+		c := newConfig(t, "general:\n  projectExtensionsDirectory: extensions/\n  productiveBranch: main\nstages:\n  Build:\n    mavenExecuteStaticCodeChecks: false\n")
+
+		activation, err := c.EvaluateStageConditions(conditions, "release/1.0", utils)
+
+		assert.NoError(t, err)
+		assert.False(t, activation.Steps["Build"]["npmExecuteScripts"], "scripts of dependencies are not considered")
+		assert.True(t, activation.Steps["Promote"]["containerPushToRegistry"])
+		assert.True(t, activation.Steps["Release"]["githubPublishRelease"])
+		assert.Equal(t, map[string]bool{"Build": true, "Acceptance": false, "Security": true, "Release": false, "Promote": true}, activation.Stages)
+	})
+
+	t.Run("config values", func(t *testing.T) {
+		c := newConfig(t, "steps:\n  whitesourceExecuteScan:\n    buildTool: npm\n")
+
+		activation, err := c.EvaluateStageConditions(conditions, "master", &mock.FilesMock{})
+
+		assert.NoError(t, err)
+		assert.True(t, activation.Steps["Security"]["whitesourceExecuteScan"])
+		assert.True(t, activation.Stages["Security"])
+	})
+
+	t.Run("invalid branch pattern", func(t *testing.T) {
+		invalid := StageConditions{Stages: map[string]StageCondition{"Promote": {StepConditions: map[string]StepCondition{
+			"containerPushToRegistry": {BranchPattern: StringList{"release/("}},
+		}}}}
+
+		_, err := newConfig(t, "").EvaluateStageConditions(invalid, "master", &mock.FilesMock{})
+
+		assert.Contains(t, fmt.Sprint(err), "failed to evaluate conditions of step 'containerPushToRegistry' in stage 'Promote': invalid branch pattern 'release/('")
+	})
+}
+
+func TestIsEmptyValue(t *testing.T) {
+	assert.True(t, isEmptyValue(nil))
+	assert.True(t, isEmptyValue(false))
+	assert.True(t, isEmptyValue(""))
+	assert.True(t, isEmptyValue([]interface{}{}))
+	assert.False(t, isEmptyValue(true))
+	assert.False(t, isEmptyValue(0))
+	assert.False(t, isEmptyValue(map[string]interface{}{"a": 1}))
+}
@@ -34,6 +34,8 @@ type StepSpec struct {
 	Outputs    StepOutputs `json:"outputs,omitempty"`
 	Containers []Container `json:"containers,omitempty"`
 	Sidecars   []Container `json:"sidecars,omitempty"`
+	// ErrorCategoryRules detect the error category based on the output and the exit code of the commands executed by the step
+	ErrorCategoryRules []log.ErrorCategoryRule `json:"errorCategoryRules,omitempty"`
 }
 
 // StepInputs defines the spec details for a step, like step inputs, containers, sidecars, ...
@@ -57,6 +59,11 @@ type StepParameters struct {
 	Aliases         []Alias             `json:"aliases,omitempty"`
 	Conditions      []Condition         `json:"conditions,omitempty"`
 	Secret          bool                `json:"secret,omitempty"`
+	// MandatoryIf makes the parameter mandatory in case another parameter is set or has a certain value
+	MandatoryIf []Param `json:"mandatoryIf,omitempty"`
+	// Validation contains further rules for the value separated by comma, e.g. url, duration or regex=^v\d+$,
+	// see package validation for the supported rules
+	Validation string `json:"validation,omitempty"`
 }
 
 // ResourceReference defines the parameters of a resource reference
@@ -2,48 +2,298 @@ package validation
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
+	"regexp"
 	"strings"
+	"time"
 )
 
+// Tag is the struct tag containing the validation rules of a field separated by comma, e.g.
+//
+//	Tool string `json:"tool,omitempty" validate:"required,possible-values=helm kubectl"`
+//
+// Supported rules are
+//
+//	required                  the field must not be empty
+//	required-if=other         the field must not be empty in case the field other is set
+//	required-if=other:value   the field must not be empty in case the field other has the value
+//	excludes=other            the field must not be set together with the field other
+//	possible-values=a b       the value, or each value of a list, must be one of the values separated by space
+//	url                       the value must be an absolute URL
+//	duration                  the value must be a duration like 10m or 1h30m
+//	regex=expression          the value must match the regular expression, needs to be the last rule
+//
+// Other fields are referenced by their JSON name. Booleans and numbers are never considered empty.
+const Tag = "validate"
+
+// FieldError is the violation of a validation rule by a field
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// Errors contains all violations of validation rules within a struct
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := []string{}
+	for _, fieldError := range e {
+		messages = append(messages, fieldError.Message)
+	}
+	return fmt.Sprintf("the configuration is invalid: %v", strings.Join(messages, "; "))
+}
+
+// ValidateStruct checks the fields of a struct against the rules of their validate tags, nested structs are checked as well.
+// All violations are returned at once as Errors, an invalid rule results in a different error.
+func ValidateStruct(v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("'%v' (%T) is not a struct", v, v)
+	}
+	violations := Errors{}
+	if err := validateFields(value, "", &violations); err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+func validateFields(value reflect.Value, prefix string, violations *Errors) error {
+	fields := map[string]reflect.Value{}
+	for i := 0; i < value.NumField(); i++ {
+		fields[fieldName(value.Type().Field(i))] = value.Field(i)
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if len(field.PkgPath) > 0 {
+			// unexported field
+			continue
+		}
+		fieldValue := value.Field(i)
+		name := prefix + fieldName(field)
+
+		nested := fieldValue
+		if nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct {
+			if err := validateFields(nested, name+".", violations); err != nil {
+				return err
+			}
+		}
+
+		for _, rule := range parseRules(field.Tag.Get(Tag)) {
+			message, err := checkRule(rule, name, fieldValue, fields, prefix)
+			if err != nil {
+				return err
+			}
+			if len(message) > 0 {
+				*violations = append(*violations, FieldError{Field: name, Rule: rule.name, Message: message})
+			}
+		}
+	}
+	return nil
+}
+
+type rule struct {
+	name     string
+	argument string
+}
+
+// parseRules splits the rules of a tag, a regular expression takes the rest of the tag since it may contain commas
+func parseRules(tag string) []rule {
+	rules := []rule{}
+	parts := strings.Split(tag, ",")
+	for i, part := range parts {
+		if len(strings.TrimSpace(part)) == 0 {
+			continue
+		}
+		name, argument := part, ""
+		if index := strings.Index(part, "="); index >= 0 {
+			name, argument = part[:index], part[index+1:]
+		}
+		if name == "regex" {
+			argument = strings.Join(append([]string{argument}, parts[i+1:]...), ",")
+			rules = append(rules, rule{name: name, argument: argument})
+			break
+		}
+		rules = append(rules, rule{name: strings.TrimSpace(name), argument: argument})
+	}
+	return rules
+}
+
+func checkRule(r rule, name string, value reflect.Value, fields map[string]reflect.Value, prefix string) (string, error) {
+	switch r.name {
+	case "required":
+		if isEmpty(value) {
+			return fmt.Sprintf("parameter '%v' is required", name), nil
+		}
+	case "required-if":
+		other, expected := r.argument, ""
+		if index := strings.Index(r.argument, ":"); index >= 0 {
+			other, expected = r.argument[:index], r.argument[index+1:]
+		}
+		otherValue, ok := fields[other]
+		if !ok {
+			return "", fmt.Errorf("invalid rule '%v' of field '%v': unknown field '%v'", r.name, name, other)
+		}
+		if !isSet(otherValue) || !isEmpty(value) {
+			return "", nil
+		}
+		if len(expected) == 0 {
+			return fmt.Sprintf("parameter '%v' is required since '%v' is set", name, prefix+other), nil
+		}
+		if containsValue(otherValue, expected) {
+			return fmt.Sprintf("parameter '%v' is required since '%v' is '%v'", name, prefix+other, expected), nil
+		}
+	case "excludes":
+		otherValue, ok := fields[r.argument]
+		if !ok {
+			return "", fmt.Errorf("invalid rule '%v' of field '%v': unknown field '%v'", r.name, name, r.argument)
+		}
+		if isSet(value) && isSet(otherValue) {
+			return fmt.Sprintf("parameters '%v' and '%v' must not be set together", name, prefix+r.argument), nil
+		}
+	case "possible-values":
+		possibleValues := strings.Fields(r.argument)
+		for _, v := range values(value) {
+			if !contains(possibleValues, v) {
+				return fmt.Sprintf("value '%v' of parameter '%v' is not one of the possible values %v", v, name, possibleValues), nil
+			}
+		}
+	case "url":
+		for _, v := range values(value) {
+			if u, err := url.Parse(v); err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+				return fmt.Sprintf("value '%v' of parameter '%v' is not a valid URL", v, name), nil
+			}
+		}
+	case "duration":
+		for _, v := range values(value) {
+			if _, err := time.ParseDuration(v); err != nil {
+				return fmt.Sprintf("value '%v' of parameter '%v' is not a valid duration like 10m or 1h30m", v, name), nil
+			}
+		}
+	case "regex":
+		expression, err := regexp.Compile(r.argument)
+		if err != nil {
+			return "", fmt.Errorf("invalid rule '%v' of field '%v': %w", r.name, name, err)
+		}
+		for _, v := range values(value) {
+			if !expression.MatchString(v) {
+				return fmt.Sprintf("value '%v' of parameter '%v' does not match '%v'", v, name, r.argument), nil
+			}
+		}
+	default:
+		return "", fmt.Errorf("unknown validation rule '%v' of field '%v'", r.name, name)
+	}
+	return "", nil
+}
+
+// fieldName returns the JSON name of a field which is the name of the step parameter
+func fieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; len(name) > 0 && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// isEmpty checks whether a value is missing, booleans and numbers are never empty since their zero value is a valid value
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
+
+// isSet checks whether a value is provided, i.e. not empty and for booleans and numbers not the zero value
+func isSet(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array, reflect.Ptr, reflect.Interface:
+		return !isEmpty(value)
+	}
+	return value.IsValid() && !value.IsZero()
+}
+
+// values returns the string representation of a value or of the entries of a list, empty values are skipped
+func values(value reflect.Value) []string {
+	if value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		result := []string{}
+		for i := 0; i < value.Len(); i++ {
+			result = append(result, values(value.Index(i))...)
+		}
+		return result
+	case reflect.Map, reflect.Struct:
+		return nil
+	case reflect.String:
+		if value.Len() == 0 {
+			return nil
+		}
+	}
+	return []string{fmt.Sprint(value.Interface())}
+}
+
+func containsValue(value reflect.Value, expected string) bool {
+	return contains(values(value), expected)
+}
+
+func contains(list []string, find string) bool {
+	for _, entry := range list {
+		if entry == find {
+			return true
+		}
+	}
+	return false
+}
+
 // FindEmptyStringsInConfigStruct finds empty strings in a struct.
 // In case the struct contains other nested structs, these struct are also checked.
+//
+// Deprecated: use ValidateStruct with the rule required instead.
 func FindEmptyStringsInConfigStruct(v interface{}) ([]string, error) {
 	emptyStrings := []string{}
 	if reflect.ValueOf(v).Kind() != reflect.Struct {
 		return emptyStrings, fmt.Errorf("'%v' (%T) is not a struct", v, v)
 	}
-	err := findNestedEmptyStrings(v, &emptyStrings, []string{})
-	return emptyStrings, err
+	findNestedEmptyStrings(reflect.ValueOf(v), &emptyStrings, []string{})
+	return emptyStrings, nil
 }
 
-func findNestedEmptyStrings(v interface{}, emptyStrings *[]string, prefix []string) error {
-	fields := reflect.TypeOf(v)
-	values := reflect.ValueOf(v)
+func findNestedEmptyStrings(values reflect.Value, emptyStrings *[]string, prefix []string) {
+	fields := values.Type()
 	for i := 0; i < fields.NumField(); i++ {
-		switch values.Field(i).Kind() {
+		value := values.Field(i)
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		switch value.Kind() {
 		case reflect.String:
-			if len(values.Field(i).String()) == 0 {
+			if len(value.String()) == 0 {
 				*emptyStrings = append(*emptyStrings, strings.Join(append(prefix, fields.Field(i).Name), "."))
 			}
 		case reflect.Struct:
-			err := findNestedEmptyStrings(values.Field(i).Interface(), emptyStrings, append(prefix, fields.Field(i).Name))
-			if err != nil {
-				return err
-			}
-		case reflect.Int:
-		case reflect.Int32:
-		case reflect.Int64:
-		case reflect.Bool:
-		case reflect.Slice:
-		// in case someone needs more types these types can be added here
-		default:
-			return fmt.Errorf("unexpected type '%v' of field: '%v', value: '%v'",
-				values.Field(i).Kind(),
-				fields.Field(i).Name,
-				values.Field(i),
-			)
+			findNestedEmptyStrings(value, emptyStrings, append(prefix, fields.Field(i).Name))
 		}
 	}
-	return nil
 }
@@ -26,16 +26,23 @@ func TestWeProvideNotAStruct(t *testing.T) {
 	assert.EqualError(t, err, "'Hello World' (string) is not a struct")
 }
 
-func TestUnsupportedType(t *testing.T) {
+func TestFurtherTypes(t *testing.T) {
 
-	type DummyWithUnsupportedType struct {
+	type DummyWithFurtherTypes struct {
 		Dummy
-		NotExpected float32
+		Float       float32
+		Map         map[string]interface{}
+		Pointer     *Connection
+		NilPointer  *Connection
+		StringSlice []string
 	}
 
-	_, err := FindEmptyStringsInConfigStruct(DummyWithUnsupportedType{})
-	assert.EqualError(t, err, "unexpected type 'float32' of field: 'NotExpected', value: '0'")
-
+	emptyStrings, err := FindEmptyStringsInConfigStruct(DummyWithFurtherTypes{Pointer: &Connection{Endpoint: "<set>", User: "<set>"}})
+	if assert.NoError(t, err) {
+		assert.Contains(t, emptyStrings, "Pointer.Password")
+		assert.NotContains(t, emptyStrings, "Pointer.User")
+		assert.Contains(t, emptyStrings, "Dummy.Prop1")
+	}
 }
 
 func TestFindEmptyStringsInConfig(t *testing.T) {
@@ -62,3 +69,83 @@ func TestFindEmptyStringsInConfig(t *testing.T) {
 		})
 	}
 }
+
+type validatedConnection struct {
+	Endpoint string `json:"endpoint,omitempty" validate:"required,url"`
+	User     string `json:"user,omitempty" validate:"required-if=password"`
+	Password string `json:"password,omitempty" validate:"excludes=token"`
+	Token    string `json:"token,omitempty"`
+}
+
+type validatedOptions struct {
+	Connection validatedConnection    `json:"connection,omitempty"`
+	Tool       string                 `json:"tool,omitempty" validate:"required,possible-values=helm kubectl"`
+	Scanners   []string               `json:"scanners,omitempty" validate:"possible-values=signature source"`
+	Timeout    string                 `json:"timeout,omitempty" validate:"duration"`
+	Version    string                 `json:"version,omitempty" validate:"regex=^v\\d{1,2}(\\.\\d+)?$"`
+	Values     map[string]interface{} `json:"values,omitempty" validate:"required-if=tool:helm"`
+	Wait       bool                   `json:"wait,omitempty" validate:"required"`
+	Retries    *int                   `json:"retries,omitempty"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		options := validatedOptions{
+			Connection: validatedConnection{Endpoint: "https://example.org", User: "me", Password: "secret"},
+			Tool:       "helm",
+			Scanners:   []string{"signature"},
+			Timeout:    "1h30m",
+			Version:    "v1.2",
+			Values:     map[string]interface{}{"replicas": 2},
+		}
+		assert.NoError(t, ValidateStruct(options))
+		assert.NoError(t, ValidateStruct(&options))
+	})
+
+	t.Run("all violations at once", func(t *testing.T) {
+		options := validatedOptions{
+			Connection: validatedConnection{Endpoint: "example.org", Password: "secret", Token: "token"},
+			Tool:       "helm",
+			Scanners:   []string{"signature", "binary"},
+			Timeout:    "10",
+			Version:    "1.2",
+		}
+
+		err := ValidateStruct(options)
+
+		if assert.IsType(t, Errors{}, err) {
+			assert.Equal(t, []string{"connection.endpoint", "connection.user", "connection.password", "scanners", "timeout", "version", "values"}, fields(err.(Errors)))
+		}
+		assert.EqualError(t, err, "the configuration is invalid: "+
+			"value 'example.org' of parameter 'connection.endpoint' is not a valid URL; "+
+			"parameter 'connection.user' is required since 'connection.password' is set; "+
+			"parameters 'connection.password' and 'connection.token' must not be set together; "+
+			"value 'binary' of parameter 'scanners' is not one of the possible values [signature source]; "+
+			"value '10' of parameter 'timeout' is not a valid duration like 10m or 1h30m; "+
+			"value '1.2' of parameter 'version' does not match '^v\\d{1,2}(\\.\\d+)?$'; "+
+			"parameter 'values' is required since 'tool' is 'helm'")
+	})
+
+	t.Run("required", func(t *testing.T) {
+		err := ValidateStruct(validatedOptions{})
+		assert.EqualError(t, err, "the configuration is invalid: parameter 'connection.endpoint' is required; parameter 'tool' is required")
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		assert.EqualError(t, ValidateStruct(struct {
+			Name string `validate:"unknown"`
+		}{}), "unknown validation rule 'unknown' of field 'Name'")
+		assert.EqualError(t, ValidateStruct(struct {
+			Name string `validate:"required-if=other"`
+		}{}), "invalid rule 'required-if' of field 'Name': unknown field 'other'")
+		assert.EqualError(t, ValidateStruct("Hello World"), "'Hello World' (string) is not a struct")
+	})
+}
+
+func fields(errors Errors) []string {
+	names := []string{}
+	for _, fieldError := range errors {
+		names = append(names, fieldError.Field)
+	}
+	return names
+}
@@ -35,6 +35,9 @@ var (
 
 	// VaultSecretFileDirectory holds the directory for the current step run to temporarily store secret files fetched from vault
 	VaultSecretFileDirectory = ""
+
+	// vaultLeaseClients hold the clients with leases of dynamic secrets which are revoked at the end of the step
+	vaultLeaseClients []vaultClient
 )
 
 // VaultCredentials hold all the auth information needed to fetch configuration from vault
@@ -47,13 +50,19 @@ type VaultCredentials struct {
 // vaultClient interface for mocking
 type vaultClient interface {
 	GetKvSecret(string) (map[string]string, error)
+	GetDynamicSecret(string) (map[string]string, error)
+	HasLeases() bool
+	RevokeLeases() error
+	RevokeToken() error
 	MustRevokeToken()
 }
 
-func (s *StepConfig) mixinVaultConfig(configs ...map[string]interface{}) {
-	for _, config := range configs {
-		s.mixIn(config, vaultFilter)
-	}
+func (s *StepConfig) mixinVaultConfig(config *Config, source, stageName, stepName string) {
+	// the configuration of the other secret providers is handled like the vault configuration
+	filter := append(append([]string{}, vaultFilter...), secretProviderFilter...)
+	s.mixInLayer(config.General, filter, source, config.source, "general", nil)
+	s.mixInLayer(config.Steps[stepName], filter, source, config.source, stepSection(stepName), nil)
+	s.mixInLayer(config.Stages[stageName], filter, source, config.source, stageSection(stageName), nil)
 }
 
 func getVaultClientFromConfig(config StepConfig, creds VaultCredentials) (vaultClient, error) {
@@ -89,51 +98,54 @@ func getVaultClientFromConfig(config StepConfig, creds VaultCredentials) (vaultC
 	return client, nil
 }
 
+// vaultSecretProvider provides the secrets of the vault KV secrets engine
+type vaultSecretProvider struct {
+	client vaultClient
+}
+
+// GetSecret returns the fields of the KV secret at the path
+func (v *vaultSecretProvider) GetSecret(path string) (map[string]string, error) {
+	return v.client.GetKvSecret(path)
+}
+
+// vaultDynamicSecretProvider provides the credentials of dynamic secrets engines, e.g. database/creds/my-role
+type vaultDynamicSecretProvider struct {
+	client vaultClient
+}
+
+// GetSecret returns the fields of the dynamic secret at the path, its lease is held until the end of the step
+func (v *vaultDynamicSecretProvider) GetSecret(path string) (map[string]string, error) {
+	return v.client.GetDynamicSecret(path)
+}
+
 func resolveAllVaultReferences(config *StepConfig, client vaultClient, params []StepParameters) {
-	for _, param := range params {
-		if ref := param.GetReference("vaultSecret"); ref != nil {
-			resolveVaultReference(ref, config, client, param)
-		}
-		if ref := param.GetReference("vaultSecretFile"); ref != nil {
-			resolveVaultReference(ref, config, client, param)
-		}
-	}
+	resolveAllSecretReferences(config, map[string]SecretProvider{
+		vaultProvider:        &vaultSecretProvider{client: client},
+		vaultDynamicProvider: &vaultDynamicSecretProvider{client: client},
+	}, params)
 }
 
-func resolveVaultReference(ref *ResourceReference, config *StepConfig, client vaultClient, param StepParameters) {
-	vaultDisableOverwrite, _ := config.Config["vaultDisableOverwrite"].(bool)
-	if _, ok := config.Config[param.Name].(string); vaultDisableOverwrite && ok {
-		log.Entry().Debugf("Not fetching '%s' from vault since it has already been set", param.Name)
+// releaseVaultClient revokes the token of the client unless it holds leases of dynamic secrets.
+// Since revoking the token revokes its leases as well, the token is kept until RevokeVaultLeases is called.
+func releaseVaultClient(client vaultClient) {
+	if client.HasLeases() {
+		vaultLeaseClients = append(vaultLeaseClients, client)
 		return
 	}
+	client.MustRevokeToken()
+}
 
-	var secretValue *string
-	for _, vaultPath := range ref.Paths {
-		// it should be possible to configure the root path were the secret is stored
-		vaultPath, ok := interpolation.ResolveString(vaultPath, config.Config)
-		if !ok {
-			continue
+// RevokeVaultLeases revokes the leases of dynamic secrets and the corresponding vault tokens, it is called at the end of each step
+func RevokeVaultLeases() {
+	for _, client := range vaultLeaseClients {
+		if err := client.RevokeLeases(); err != nil {
+			log.Entry().WithError(err).Warn("Could not revoke the leases of dynamic secrets")
 		}
-
-		secretValue = lookupPath(client, vaultPath, &param)
-		if secretValue != nil {
-			log.Entry().Debugf("Resolved param '%s' with vault path '%s'", param.Name, vaultPath)
-			if ref.Type == "vaultSecret" {
-				config.Config[param.Name] = *secretValue
-			} else if ref.Type == "vaultSecretFile" {
-				filePath, err := createTemporarySecretFile(param.Name, *secretValue)
-				if err != nil {
-					log.Entry().WithError(err).Warnf("Couldn't create temporary secret file for '%s'", param.Name)
-					return
-				}
-				config.Config[param.Name] = filePath
-			}
-			break
+		if err := client.RevokeToken(); err != nil {
+			log.Entry().WithError(err).Warn("Could not revoke vault token")
 		}
 	}
-	if secretValue == nil {
-		log.Entry().Warnf("Could not resolve param '%s' from vault", param.Name)
-	}
+	vaultLeaseClients = nil
 }
 
 // resolve test credential keys and expose as environment variables
@@ -246,35 +258,3 @@ func createTemporarySecretFile(namePattern string, content string) (string, erro
 	}
 	return file.Name(), nil
 }
-
-func lookupPath(client vaultClient, path string, param *StepParameters) *string {
-	log.Entry().Debugf("Trying to resolve vault parameter '%s' at '%s'", param.Name, path)
-	secret, err := client.GetKvSecret(path)
-	if err != nil {
-		log.Entry().WithError(err).Warnf("Couldn't fetch secret at '%s'", path)
-		return nil
-	}
-	if secret == nil {
-		return nil
-	}
-
-	field := secret[param.Name]
-	if field != "" {
-		log.RegisterSecret(field)
-		return &field
-	}
-	log.Entry().Debugf("Secret did not contain a field name '%s'", param.Name)
-	// try parameter aliases
-	for _, alias := range param.Aliases {
-		log.Entry().Debugf("Trying alias field name '%s'", alias.Name)
-		field := secret[alias.Name]
-		if field != "" {
-			log.RegisterSecret(field)
-			if alias.Deprecated {
-				log.Entry().WithField("package", "SAP/jenkins-library/pkg/config").Warningf("DEPRECATION NOTICE: old step config key '%s' used in vault. Please switch to '%s'!", alias.Name, param.Name)
-			}
-			return &field
-		}
-	}
-	return nil
-}
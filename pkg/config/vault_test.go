@@ -176,6 +176,44 @@ func TestVaultSecretFiles(t *testing.T) {
 	})
 }
 
+func TestVaultDynamicSecrets(t *testing.T) {
+	t.Run("Load dynamic secret from vault", func(t *testing.T) {
+		vaultMock := &mocks.VaultMock{}
+		stepConfig := StepConfig{Config: map[string]interface{}{"databaseRole": "readonly"}}
+		stepParams := []StepParameters{stepParam("password", "vaultDynamicSecret", "database/creds/$(databaseRole)")}
+		vaultMock.On("GetDynamicSecret", "database/creds/readonly").Return(map[string]string{"username": "v-user", "password": "v-password"}, nil)
+
+		resolveAllVaultReferences(&stepConfig, vaultMock, stepParams)
+		assert.Equal(t, "v-password", stepConfig.Config["password"])
+	})
+
+	t.Run("Token is kept until the leases are revoked", func(t *testing.T) {
+		vaultMock := &mocks.VaultMock{}
+		vaultMock.On("HasLeases").Return(true)
+		vaultMock.On("RevokeLeases").Return(nil)
+		vaultMock.On("RevokeToken").Return(nil)
+
+		releaseVaultClient(vaultMock)
+		vaultMock.AssertNotCalled(t, "MustRevokeToken")
+		vaultMock.AssertNotCalled(t, "RevokeToken")
+
+		RevokeVaultLeases()
+		vaultMock.AssertCalled(t, "RevokeLeases")
+		vaultMock.AssertCalled(t, "RevokeToken")
+		assert.Empty(t, vaultLeaseClients)
+	})
+
+	t.Run("Token is revoked without leases", func(t *testing.T) {
+		vaultMock := &mocks.VaultMock{}
+		vaultMock.On("HasLeases").Return(false)
+		vaultMock.On("MustRevokeToken").Return()
+
+		releaseVaultClient(vaultMock)
+		vaultMock.AssertCalled(t, "MustRevokeToken")
+		assert.Empty(t, vaultLeaseClients)
+	})
+}
+
 func TestMixinVault(t *testing.T) {
 	vaultServerUrl := "https://testServer"
 	vaultPath := "testPath"
@@ -183,15 +221,19 @@ func TestMixinVault(t *testing.T) {
 		Config:     map[string]interface{}{},
 		HookConfig: nil,
 	}
-	general := map[string]interface{}{
-		"vaultPath": vaultPath,
-	}
-	steps := map[string]interface{}{
-		"vaultServerUrl": vaultServerUrl,
-		"unknownConfig":  "test",
+	c := Config{
+		General: map[string]interface{}{
+			"vaultPath": vaultPath,
+		},
+		Steps: map[string]map[string]interface{}{
+			"step1": {
+				"vaultServerUrl": vaultServerUrl,
+				"unknownConfig":  "test",
+			},
+		},
 	}
 
-	config.mixinVaultConfig(general, steps)
+	config.mixinVaultConfig(&c, SourceConfig, "stage1", "step1")
 
 	assert.Contains(t, config.Config, "vaultServerUrl")
 	assert.Equal(t, vaultServerUrl, config.Config["vaultServerUrl"])
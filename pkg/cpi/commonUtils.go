@@ -29,7 +29,7 @@ func (tokenParameters TokenParameters) GetBearerToken() (string, error) {
 	clientOptions := piperhttp.ClientOptions{
 		Username: tokenParameters.Username,
 		Password: tokenParameters.Password,
-	}
+	}.WithServicePolicies()
 	httpClient.SetOptions(clientOptions)
 
 	header := make(http.Header)
@@ -0,0 +1,194 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+)
+
+// Types of the events which are emitted during a step run
+const (
+	TypeStepStarted     = "stepStarted"
+	TypeConfigResolved  = "configResolved"
+	TypeCommandExecuted = "commandExecuted"
+	TypeHTTPRequest     = "httpRequest"
+	TypeStepFinished    = "stepFinished"
+)
+
+const redacted = "****"
+
+// Source identifies the step run which emits the events
+type Source struct {
+	Step          string `json:"step"`
+	Stage         string `json:"stage,omitempty"`
+	CorrelationID string `json:"correlationId,omitempty"`
+}
+
+// Event is a single entry of the event stream, it is written as one line of JSON
+type Event struct {
+	Source
+	Time     time.Time              `json:"time"`
+	Type     string                 `json:"type"`
+	Sequence int                    `json:"sequence"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// Emitter writes the events of a step run into a sink
+type Emitter struct {
+	sink     Sink
+	source   Source
+	sequence int
+	mutex    sync.Mutex
+}
+
+var emitter *Emitter
+
+// NewEmitter creates an emitter writing the events of the source into the sink
+func NewEmitter(sink Sink, source Source) *Emitter {
+	return &Emitter{sink: sink, source: source}
+}
+
+// Emit writes an event, registered secrets are masked in the written data
+func (e *Emitter) Emit(eventType string, data map[string]interface{}) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.sequence++
+	event := Event{Source: e.source, Time: time.Now().UTC(), Type: eventType, Sequence: e.sequence}
+	if data != nil {
+		event.Data = maskSecrets(data).(map[string]interface{})
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.Entry().WithError(err).Debugf("failed to marshal %v event", eventType)
+		return
+	}
+	if err := e.sink.Write(append(line, '\n')); err != nil {
+		log.Entry().WithError(err).Debugf("failed to write %v event", eventType)
+	}
+}
+
+// maskSecrets returns a copy of the value in which the registered secrets are masked.
+// Masking has to happen before marshalling since JSON escapes characters like " or & which may be part of a secret.
+func maskSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return log.MaskSecrets(v)
+	case []string:
+		masked := make([]string, len(v))
+		for i, item := range v {
+			masked[i] = log.MaskSecrets(item)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = maskSecrets(item)
+		}
+		return masked
+	case map[string]string:
+		masked := make(map[string]string, len(v))
+		for key, item := range v {
+			masked[log.MaskSecrets(key)] = log.MaskSecrets(item)
+		}
+		return masked
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			masked[log.MaskSecrets(key)] = maskSecrets(item)
+		}
+		return masked
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return value
+	}
+	// any other value is transferred into its generic JSON representation in order to reach all contained strings
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return value
+	}
+	return maskSecrets(generic)
+}
+
+// Close closes the sink of the emitter
+func (e *Emitter) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.sink.Close()
+}
+
+// Initialize sets up the event stream of the step run and emits the stepStarted event.
+// The target is either the path of a file or an http(s) endpoint, the stream is disabled if it is empty.
+// A failure to set up the stream is logged but does not fail the step.
+func Initialize(target string, source Source) {
+	emitter = nil
+	if len(target) == 0 {
+		return
+	}
+	sink, err := NewSink(target)
+	if err != nil {
+		log.Entry().WithError(err).Warning("Event stream deactivated")
+		return
+	}
+	emitter = NewEmitter(sink, source)
+	Emit(TypeStepStarted, nil)
+}
+
+// Enabled returns whether events are emitted
+func Enabled() bool {
+	return emitter != nil
+}
+
+// Emit writes an event into the event stream of the step run if it is enabled
+func Emit(eventType string, data map[string]interface{}) {
+	if emitter == nil {
+		return
+	}
+	emitter.Emit(eventType, data)
+}
+
+// ConfigResolved emits the resolved step configuration, the values of the secret parameters are redacted
+func ConfigResolved(config map[string]interface{}, secretParameters []string) {
+	if emitter == nil {
+		return
+	}
+	secret := map[string]bool{}
+	for _, name := range secretParameters {
+		secret[name] = true
+	}
+	resolved := map[string]interface{}{}
+	for name, value := range config {
+		if secret[name] && value != nil && value != "" {
+			value = redacted
+		}
+		resolved[name] = value
+	}
+	Emit(TypeConfigResolved, map[string]interface{}{"config": resolved})
+}
+
+// StepFinished emits the result of the step run and closes the event stream
+func StepFinished(errorCode, errorCategory string, duration time.Duration) {
+	if emitter == nil {
+		return
+	}
+	Emit(TypeStepFinished, map[string]interface{}{
+		"errorCode":     errorCode,
+		"errorCategory": errorCategory,
+		"durationMs":    duration.Milliseconds(),
+	})
+	if err := emitter.Close(); err != nil {
+		log.Entry().WithError(err).Warning("Failed to close event stream")
+	}
+	emitter = nil
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEvents(t *testing.T, content []byte) []Event {
+	events := []Event{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		event := Event{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestFileEventStream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "events")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stream", "events.ndjson")
+
+	log.RegisterSecret("top-secret")
+	Initialize(path, Source{Step: "mavenBuild", Stage: "Build", CorrelationID: "1234"})
+	assert.True(t, Enabled())
+	ConfigResolved(map[string]interface{}{"password": "abc", "token": "", "goals": []string{"install"}}, []string{"password", "token"})
+	Emit(TypeCommandExecuted, map[string]interface{}{"executable": "mvn", "params": []string{"-Dtoken=top-secret"}})
+	StepFinished("1", "build", 1500*time.Millisecond)
+	assert.False(t, Enabled())
+
+	// ignored since the stream is closed
+	Emit(TypeHTTPRequest, nil)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "top-secret")
+	events := readEvents(t, content)
+	require.Len(t, events, 4)
+
+	assert.Equal(t, TypeStepStarted, events[0].Type)
+	assert.Equal(t, Source{Step: "mavenBuild", Stage: "Build", CorrelationID: "1234"}, events[0].Source)
+	assert.Equal(t, 1, events[0].Sequence)
+
+	assert.Equal(t, TypeConfigResolved, events[1].Type)
+	assert.Equal(t, map[string]interface{}{"password": "****", "token": "", "goals": []interface{}{"install"}}, events[1].Data["config"])
+
+	assert.Equal(t, TypeCommandExecuted, events[2].Type)
+	assert.Equal(t, []interface{}{"-Dtoken=****"}, events[2].Data["params"])
+
+	assert.Equal(t, TypeStepFinished, events[3].Type)
+	assert.Equal(t, 4, events[3].Sequence)
+	assert.Equal(t, map[string]interface{}{"errorCode": "1", "errorCategory": "build", "durationMs": float64(1500)}, events[3].Data)
+}
+
+func TestEventMasking(t *testing.T) {
+	var sink bytes.Buffer
+	emitter := NewEmitter(&bufferSink{&sink}, Source{Step: "mavenBuild"})
+	// JSON escapes the characters, the secret would not be found in the marshalled event
+	log.RegisterSecret(`pa"ss&<word>`)
+
+	emitter.Emit(TypeCommandExecuted, map[string]interface{}{
+		"params":  []string{`-Dpassword=pa"ss&<word>`},
+		"env":     map[string]string{"PASSWORD": `pa"ss&<word>`},
+		"nested":  []interface{}{map[string]interface{}{"value": `pa"ss&<word>`}},
+		"details": struct{ Value string }{Value: `pa"ss&<word>`},
+		"count":   2,
+	})
+
+	assert.NotContains(t, sink.String(), `pa\"ss`)
+	assert.NotContains(t, sink.String(), `\u0026`)
+	events := readEvents(t, sink.Bytes())
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string]interface{}{
+		"params":  []interface{}{"-Dpassword=****"},
+		"env":     map[string]interface{}{"PASSWORD": "****"},
+		"nested":  []interface{}{map[string]interface{}{"value": "****"}},
+		"details": map[string]interface{}{"Value": "****"},
+		"count":   float64(2),
+	}, events[0].Data)
+}
+
+func TestHTTPEventStream(t *testing.T) {
+	t.Run("events are posted when the step finished", func(t *testing.T) {
+		requests := 0
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+			body, _ = ioutil.ReadAll(r.Body)
+		}))
+		defer server.Close()
+
+		Initialize(server.URL, Source{Step: "kubernetesDeploy"})
+		Emit(TypeHTTPRequest, map[string]interface{}{"method": "GET"})
+		assert.Equal(t, 0, requests)
+		StepFinished("0", "undefined", time.Second)
+
+		assert.Equal(t, 1, requests)
+		events := readEvents(t, body)
+		require.Len(t, events, 3)
+		assert.Equal(t, TypeHTTPRequest, events[1].Type)
+		assert.Equal(t, "kubernetesDeploy", events[2].Step)
+	})
+
+	t.Run("endpoint failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		sink, err := NewSink(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, sink.Write([]byte("{}\n")))
+		assert.EqualError(t, sink.Close(), "failed to send events: endpoint responded with 503 Service Unavailable")
+	})
+}
+
+func TestDisabledEventStream(t *testing.T) {
+	Initialize("", Source{Step: "mavenBuild"})
+	assert.False(t, Enabled())
+	// no-ops without stream
+	Emit(TypeStepStarted, nil)
+	ConfigResolved(map[string]interface{}{}, nil)
+	StepFinished("0", "undefined", time.Second)
+}
+
+type bufferSink struct {
+	buffer *bytes.Buffer
+}
+
+func (s *bufferSink) Write(line []byte) error {
+	_, err := s.buffer.Write(line)
+	return err
+}
+
+func (s *bufferSink) Close() error {
+	return nil
+}
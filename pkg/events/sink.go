@@ -0,0 +1,83 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Sink receives the events as lines of newline delimited JSON (NDJSON)
+type Sink interface {
+	Write(line []byte) error
+	Close() error
+}
+
+// NewSink creates the sink for the target which is either an http(s) endpoint or the path of a file
+func NewSink(target string) (Sink, error) {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return &httpSink{url: target, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+	return newFileSink(target)
+}
+
+// fileSink appends the events to a file. Since each event is written at once,
+// several steps running in parallel can share the same file.
+type fileSink struct {
+	file *os.File
+}
+
+func newFileSink(path string) (*fileSink, error) {
+	if dir := filepath.Dir(path); len(dir) > 0 {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return nil, errors.Wrapf(err, "failed to create directory of event stream file %v", path)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open event stream file %v", path)
+	}
+	return &fileSink{file: file}, nil
+}
+
+func (s *fileSink) Write(line []byte) error {
+	_, err := s.file.Write(line)
+	return err
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// httpSink collects the events of the step run and posts them at once when it is closed,
+// this avoids slowing down the step by a request per event
+type httpSink struct {
+	url    string
+	client *http.Client
+	buffer bytes.Buffer
+}
+
+func (s *httpSink) Write(line []byte) error {
+	_, err := s.buffer.Write(line)
+	return err
+}
+
+func (s *httpSink) Close() error {
+	if s.buffer.Len() == 0 {
+		return nil
+	}
+	response, err := s.client.Post(s.url, "application/x-ndjson", &s.buffer)
+	if err != nil {
+		return errors.Wrap(err, "failed to send events")
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("failed to send events: endpoint responded with %v", response.Status)
+	}
+	return nil
+}
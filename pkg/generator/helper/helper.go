@@ -8,33 +8,37 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/SAP/jenkins-library/pkg/piperutils"
 )
 
 type stepInfo struct {
-	CobraCmdFuncName string
-	CreateCmdVar     string
-	ExportPrefix     string
-	FlagsFunc        string
-	Long             string
-	StepParameters   []config.StepParameters
-	StepAliases      []config.Alias
-	OSImport         bool
-	OutputResources  []map[string]string
-	Short            string
-	StepFunc         string
-	StepName         string
-	StepSecrets      []string
-	Containers       []config.Container
-	Sidecars         []config.Container
-	Outputs          config.StepOutputs
-	Resources        []config.StepResources
+	CobraCmdFuncName   string
+	CreateCmdVar       string
+	ExportPrefix       string
+	FlagsFunc          string
+	Long               string
+	StepParameters     []config.StepParameters
+	StepAliases        []config.Alias
+	OSImport           bool
+	OutputResources    []map[string]string
+	Short              string
+	StepFunc           string
+	StepName           string
+	StepSecrets        []string
+	Containers         []config.Container
+	Sidecars           []config.Container
+	Outputs            config.StepOutputs
+	Resources          []config.StepResources
+	ErrorCategoryRules []log.ErrorCategoryRule
 }
 
 //StepGoTemplate ...
@@ -54,11 +58,14 @@ import (
 	{{ .ExportPrefix }} "github.com/SAP/jenkins-library/cmd"
 	{{ end -}}
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/config/validation"
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
 	{{ if .OutputResources -}}
 	"github.com/SAP/jenkins-library/pkg/piperenv"
 	{{ end -}}
 	"github.com/SAP/jenkins-library/pkg/telemetry"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -66,7 +73,7 @@ type {{ .StepName }}Options struct {
 	{{- $names := list ""}}
 	{{- range $key, $value := uniqueName .StepParameters }}
 	{{ if ne (has $value.Name $names) true -}}
-	{{ $names | last }}{{ $value.Name | golangName }} {{ $value.Type }} ` + "`json:\"{{$value.Name}},omitempty\"`" + `
+	{{ $names | last }}{{ $value.Name | golangName }} {{ $value.Type }} ` + "`json:\"{{$value.Name}},omitempty\"{{ validateTag $value $.StepParameters }}`" + `
 	{{- else -}}
 	{{- $names = append $names $value.Name }} {{ end -}}
 	{{ end }}
@@ -98,15 +105,29 @@ func {{.CobraCmdFuncName}}() *cobra.Command {
 			path, _ := os.Getwd()
 			fatalHook := &log.FatalHook{CorrelationID: {{if .ExportPrefix}}{{ .ExportPrefix }}.{{end}}GeneralConfig.CorrelationID, Path: path}
 			log.RegisterHook(fatalHook)
+			{{- if .ErrorCategoryRules }}
+			log.SetErrorCategoryRules(metadata.Spec.ErrorCategoryRules)
+			{{- end }}
 
 			err := {{if .ExportPrefix}}{{ .ExportPrefix }}.{{end}}PrepareConfig(cmd, &metadata, STEP_NAME, &stepConfig, config.OpenPiperFile)
 			if err != nil {
 				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
 				return err
 			}
 			{{- range $key, $value := .StepSecrets }}
 			log.RegisterSecret(stepConfig.{{ $value | golangName  }}){{end}}
 
+			if err = validation.ValidateStruct(stepConfig); err != nil {
+				log.SetErrorCategory(log.ErrorConfiguration)
+				events.StepFinished("1", log.ErrorConfiguration.String(), time.Since(startTime))
+				tracing.StepFinished("1", log.ErrorConfiguration.String())
+				config.RevokeVaultLeases()
+				return err
+			}
+
 			if len({{if .ExportPrefix}}{{ .ExportPrefix }}.{{end}}GeneralConfig.HookConfig.SentryConfig.Dsn) > 0 {
 				sentryHook := log.NewSentryHook({{if .ExportPrefix}}{{ .ExportPrefix }}.{{end}}GeneralConfig.HookConfig.SentryConfig.Dsn, {{if .ExportPrefix}}{{ .ExportPrefix }}.{{end}}GeneralConfig.CorrelationID)
 				log.RegisterHook(&sentryHook)
@@ -119,11 +140,17 @@ func {{.CobraCmdFuncName}}() *cobra.Command {
 			telemetryData.ErrorCode = "1"
 			handler := func() {
 				config.RemoveVaultSecretFiles()
+				config.RevokeVaultLeases()
+				if err := log.WriteErrorReport("."); err != nil {
+					log.Entry().WithError(err).Warn("failed to write error report")
+				}
 				{{- range $notused, $oRes := .OutputResources }}
 				{{ index $oRes "name" }}.persist({{if $.ExportPrefix}}{{ $.ExportPrefix }}.{{end}}GeneralConfig.EnvRootPath, "{{ index $oRes "name" }}"){{ end }}
 				telemetryData.Duration = fmt.Sprintf("%v", time.Since(startTime).Milliseconds())
 				telemetryData.ErrorCategory = log.GetErrorCategory().String()
 				telemetry.Send(&telemetryData)
+				events.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory, time.Since(startTime))
+				tracing.StepFinished(telemetryData.ErrorCode, telemetryData.ErrorCategory)
 			}
 			log.DeferExitHandler(handler)
 			defer handler()
@@ -191,7 +218,10 @@ func {{ .StepName }}Metadata() config.StepData {
 						Scope:     []string{{ "{" }}{{ range $notused, $scope := $value.Scope }}"{{ $scope }}",{{ end }}{{ "}" }},
 						Type:      "{{ $value.Type }}",
 						Mandatory: {{ $value.Mandatory }},
-						Aliases:   []config.Alias{{ "{" }}{{ range $notused, $alias := $value.Aliases }}{{ "{" }}Name: "{{ $alias.Name }}"{{ "}" }},{{ end }}{{ "}" }},
+						Aliases:   []config.Alias{{ "{" }}{{ range $notused, $alias := $value.Aliases }}{{ "{" }}Name: "{{ $alias.Name }}"{{ if $alias.Deprecated }}, Deprecated: true{{ end }}{{ "}" }},{{ end }}{{ "}" }},
+						{{- if $value.PossibleValues }}
+						PossibleValues: []interface{}{{ "{" }}{{ range $notused, $possibleValue := $value.PossibleValues }}{{ $possibleValue | goLiteral }}, {{ end }}{{ "}" }},
+						{{- end }}
 					},{{ end }}
 				},
 			},
@@ -221,6 +251,17 @@ func {{ .StepName }}Metadata() config.StepData {
 				}, {{ end }}
 			},
 			{{ end -}}
+			{{ if .ErrorCategoryRules -}}
+			ErrorCategoryRules: []log.ErrorCategoryRule{
+				{{- range $rule := .ErrorCategoryRules }}
+				{Category: {{ $rule.Category | goLiteral }}
+				{{- if $rule.Pattern }}, Pattern: {{ $rule.Pattern | goLiteral }}{{ end }}
+				{{- if $rule.ExitCodes }}, ExitCodes: {{ $rule.ExitCodes | goLiteral }}{{ end }}
+				{{- if $rule.Priority }}, Priority: {{ $rule.Priority }}{{ end }}
+				{{- if $rule.Remediation }}, Remediation: {{ $rule.Remediation | goLiteral }}{{ end }}},
+				{{- end }}
+			},
+			{{ end -}}
 			{{- if .Outputs.Resources -}}
 			Outputs: config.StepOutputs{
 				Resources: []config.StepResources{
@@ -537,29 +578,49 @@ func setDefaultParameters(stepData *config.StepData) (bool, error) {
 }
 
 func getStepInfo(stepData *config.StepData, osImport bool, exportPrefix string) (stepInfo, error) {
+	if err := checkErrorCategoryRules(stepData); err != nil {
+		return stepInfo{}, err
+	}
 	oRes, err := getOutputResourceDetails(stepData)
 
 	return stepInfo{
-			StepName:         stepData.Metadata.Name,
-			CobraCmdFuncName: fmt.Sprintf("%vCommand", strings.Title(stepData.Metadata.Name)),
-			CreateCmdVar:     fmt.Sprintf("create%vCmd", strings.Title(stepData.Metadata.Name)),
-			Short:            stepData.Metadata.Description,
-			Long:             stepData.Metadata.LongDescription,
-			StepParameters:   stepData.Spec.Inputs.Parameters,
-			StepAliases:      stepData.Metadata.Aliases,
-			FlagsFunc:        fmt.Sprintf("add%vFlags", strings.Title(stepData.Metadata.Name)),
-			OSImport:         osImport,
-			OutputResources:  oRes,
-			ExportPrefix:     exportPrefix,
-			StepSecrets:      getSecretFields(stepData),
-			Containers:       stepData.Spec.Containers,
-			Sidecars:         stepData.Spec.Sidecars,
-			Outputs:          stepData.Spec.Outputs,
-			Resources:        stepData.Spec.Inputs.Resources,
+			StepName:           stepData.Metadata.Name,
+			CobraCmdFuncName:   fmt.Sprintf("%vCommand", strings.Title(stepData.Metadata.Name)),
+			CreateCmdVar:       fmt.Sprintf("create%vCmd", strings.Title(stepData.Metadata.Name)),
+			Short:              stepData.Metadata.Description,
+			Long:               stepData.Metadata.LongDescription,
+			StepParameters:     stepData.Spec.Inputs.Parameters,
+			StepAliases:        stepData.Metadata.Aliases,
+			FlagsFunc:          fmt.Sprintf("add%vFlags", strings.Title(stepData.Metadata.Name)),
+			OSImport:           osImport,
+			OutputResources:    oRes,
+			ExportPrefix:       exportPrefix,
+			StepSecrets:        getSecretFields(stepData),
+			Containers:         stepData.Spec.Containers,
+			Sidecars:           stepData.Spec.Sidecars,
+			Outputs:            stepData.Spec.Outputs,
+			Resources:          stepData.Spec.Inputs.Resources,
+			ErrorCategoryRules: stepData.Spec.ErrorCategoryRules,
 		},
 		err
 }
 
+// checkErrorCategoryRules ensures that invalid rules fail the generation instead of being ignored during the step execution
+func checkErrorCategoryRules(stepData *config.StepData) error {
+	for i, rule := range stepData.Spec.ErrorCategoryRules {
+		if log.ErrorCategoryByString(rule.Category) == log.ErrorUndefined {
+			return fmt.Errorf("error category rule %v of step %v: unknown category '%v'", i, stepData.Metadata.Name, rule.Category)
+		}
+		if len(rule.Pattern) == 0 && len(rule.ExitCodes) == 0 {
+			return fmt.Errorf("error category rule %v of step %v: pattern or exitCodes required", i, stepData.Metadata.Name)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("error category rule %v of step %v: invalid pattern: %w", i, stepData.Metadata.Name, err)
+		}
+	}
+	return nil
+}
+
 func getSecretFields(stepData *config.StepData) []string {
 	var secretFields []string
 
@@ -669,6 +730,8 @@ func stepTemplate(myStepInfo stepInfo, templateName, goTemplate string) []byte {
 	funcMap["longName"] = longName
 	funcMap["uniqueName"] = mustUniqName
 	funcMap["isCLIParam"] = isCLIParam
+	funcMap["goLiteral"] = goLiteral
+	funcMap["validateTag"] = validateTag
 
 	return generateCode(myStepInfo, templateName, goTemplate, funcMap)
 }
@@ -717,6 +780,58 @@ func golangName(name string) string {
 	return properName
 }
 
+// validateTag returns the struct tag with the validation rules of a parameter, see package validation.
+// All parameters are passed since a parameter may be defined several times with different conditions.
+func validateTag(param config.StepParameters, parameters []config.StepParameters) string {
+	rules := []string{}
+	// parameters with conditions may be mandatory only for some of the conditions
+	if param.Mandatory && len(param.Conditions) == 0 {
+		rules = append(rules, "required")
+	}
+	for _, dependence := range param.MandatoryIf {
+		if len(dependence.Value) > 0 {
+			rules = append(rules, fmt.Sprintf("required-if=%v:%v", dependence.Name, dependence.Value))
+		} else {
+			rules = append(rules, "required-if="+dependence.Name)
+		}
+	}
+	if values := possibleValues(param.Name, parameters); len(values) > 0 && (param.Type == "string" || param.Type == "[]string") {
+		rules = append(rules, "possible-values="+strings.Join(values, " "))
+	}
+	if len(param.Validation) > 0 {
+		rules = append(rules, param.Validation)
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return " validate:" + strconv.Quote(strings.Join(rules, ","))
+}
+
+// possibleValues merges the possible values of all definitions of a parameter,
+// there are none in case one of the definitions accepts any value
+func possibleValues(name string, parameters []config.StepParameters) []string {
+	values := []string{}
+	for _, param := range parameters {
+		if param.Name != name {
+			continue
+		}
+		if len(param.PossibleValues) == 0 {
+			return nil
+		}
+		for _, value := range param.PossibleValues {
+			if !piperutils.ContainsString(values, fmt.Sprint(value)) {
+				values = append(values, fmt.Sprint(value))
+			}
+		}
+	}
+	return values
+}
+
+// goLiteral returns the Go representation of a value, e.g. a quoted string
+func goLiteral(value interface{}) string {
+	return fmt.Sprintf("%#v", value)
+}
+
 func golangNameTitle(name string) string {
 	return strings.Title(golangName(name))
 }
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,12 +60,26 @@ spec:
         description: param1 description
         scope:
         - PARAMETERS
+        possibleValues:
+        - value1
+        - value2
+        mandatoryIf:
+        - name: param0
+          value: val0
       - name: param2
         type: string
         description: param1 description
         scope:
         - PARAMETERS
         mandatory: true
+        validation: url
+  errorCategoryRules:
+    - category: config
+      pattern: 'Invalid value for \w+'
+      priority: 10
+      remediation: Check the step configuration.
+    - category: infrastructure
+      exitCodes: [137]
 `
 	var r string
 	switch name {
@@ -283,6 +298,30 @@ func TestFlagType(t *testing.T) {
 	}
 }
 
+func TestValidateTag(t *testing.T) {
+	t.Run("rules", func(t *testing.T) {
+		param := config.StepParameters{Name: "tool", Type: "string", Mandatory: true, PossibleValues: []interface{}{"helm", "kubectl"}, Validation: "excludes=other"}
+		assert.Equal(t, ` validate:"required,possible-values=helm kubectl,excludes=other"`, validateTag(param, []config.StepParameters{param}))
+		assert.Equal(t, "", validateTag(config.StepParameters{Name: "other", Type: "string"}, nil))
+	})
+
+	t.Run("possible values of conditional definitions are merged", func(t *testing.T) {
+		params := []config.StepParameters{
+			{Name: "buildTool", Type: "string", PossibleValues: []interface{}{"maven", "npm"}, Conditions: []config.Condition{{ConditionRef: "strings-equal", Params: []config.Param{{Name: "language", Value: "java"}}}}},
+			{Name: "buildTool", Type: "string", PossibleValues: []interface{}{"npm", "yarn"}, Conditions: []config.Condition{{ConditionRef: "strings-equal", Params: []config.Param{{Name: "language", Value: "js"}}}}},
+		}
+		assert.Equal(t, ` validate:"possible-values=maven npm yarn"`, validateTag(params[0], params))
+	})
+
+	t.Run("no possible values in case one definition accepts any value", func(t *testing.T) {
+		params := []config.StepParameters{
+			{Name: "buildTool", Type: "string", PossibleValues: []interface{}{"maven"}},
+			{Name: "buildTool", Type: "string"},
+		}
+		assert.Equal(t, "", validateTag(params[0], params))
+	})
+}
+
 func TestGetStringSliceFromInterface(t *testing.T) {
 	tt := []struct {
 		input    interface{}
@@ -296,3 +335,25 @@ func TestGetStringSliceFromInterface(t *testing.T) {
 		assert.Equal(t, v.expected, getStringSliceFromInterface(v.input), "interface conversion failed")
 	}
 }
+
+func TestCheckErrorCategoryRules(t *testing.T) {
+	tt := []struct {
+		rules    []log.ErrorCategoryRule
+		expected string
+	}{
+		{rules: []log.ErrorCategoryRule{{Category: "config", Pattern: "^Error: .*"}, {Category: "infrastructure", ExitCodes: []int{137}}}},
+		{rules: []log.ErrorCategoryRule{{Category: "unknown", Pattern: "error"}}, expected: "error category rule 0 of step testStep: unknown category 'unknown'"},
+		{rules: []log.ErrorCategoryRule{{Category: "config"}}, expected: "error category rule 0 of step testStep: pattern or exitCodes required"},
+		{rules: []log.ErrorCategoryRule{{Category: "config", Pattern: "("}}, expected: "error category rule 0 of step testStep: invalid pattern: error parsing regexp: missing closing ): `(`"},
+	}
+
+	for _, test := range tt {
+		stepData := config.StepData{Metadata: config.StepMetadata{Name: "testStep"}, Spec: config.StepSpec{ErrorCategoryRules: test.rules}}
+		err := checkErrorCategoryRules(&stepData)
+		if len(test.expected) == 0 {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, test.expected)
+		}
+	}
+}
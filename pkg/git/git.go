@@ -1,13 +1,16 @@
 package git
 
 import (
+	"os"
+	"time"
+
 	"github.com/SAP/jenkins-library/pkg/log"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/pkg/errors"
-	"time"
 )
 
 // utilsWorkTree interface abstraction of git.Worktree to enable tests
@@ -36,9 +39,21 @@ func CommitSingleFile(filePath, commitMessage, author string, worktree *git.Work
 }
 
 func commitSingleFile(filePath, commitMessage, author string, worktree utilsWorkTree) (plumbing.Hash, error) {
-	_, err := worktree.Add(filePath)
-	if err != nil {
-		return [20]byte{}, errors.Wrap(err, "failed to add file to git")
+	return commitFiles([]string{filePath}, commitMessage, author, worktree)
+}
+
+// CommitFiles Commits the files or directories located in the relative file paths with the commitMessage to the given worktree.
+// In case of errors, the error is returned. In the successful case the commit is provided.
+func CommitFiles(filePaths []string, commitMessage, author string, worktree *git.Worktree) (plumbing.Hash, error) {
+	return commitFiles(filePaths, commitMessage, author, worktree)
+}
+
+func commitFiles(filePaths []string, commitMessage, author string, worktree utilsWorkTree) (plumbing.Hash, error) {
+	for _, filePath := range filePaths {
+		_, err := worktree.Add(filePath)
+		if err != nil {
+			return [20]byte{}, errors.Wrap(err, "failed to add file to git")
+		}
 	}
 
 	commit, err := worktree.Commit(commitMessage, &git.CommitOptions{
@@ -68,6 +83,28 @@ func pushChangesToRepository(username, password string, repository utilsReposito
 	return nil
 }
 
+// PushChangesToBranch Pushes the committed changes of the local branch to a branch with a different name in the remote repository.
+// The remote branch is created if it does not exist yet.
+func PushChangesToBranch(username, password, localBranch, remoteBranch string, repository *git.Repository) error {
+	return pushChangesToBranch(username, password, localBranch, remoteBranch, repository)
+}
+
+func pushChangesToBranch(username, password, localBranch, remoteBranch string, repository utilsRepository) error {
+	refSpec := config.RefSpec(plumbing.NewBranchReferenceName(localBranch).String() + ":" + plumbing.NewBranchReferenceName(remoteBranch).String())
+	if err := refSpec.Validate(); err != nil {
+		return errors.Wrap(err, "invalid branch name")
+	}
+	pushOptions := &git.PushOptions{
+		Auth:     &http.BasicAuth{Username: username, Password: password},
+		RefSpecs: []config.RefSpec{refSpec},
+	}
+	err := repository.Push(pushOptions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push commit to branch %v", remoteBranch)
+	}
+	return nil
+}
+
 // PlainClone Clones a non-bare repository to the provided directory
 func PlainClone(username, password, serverURL, directory string) (*git.Repository, error) {
 	abstractedGit := &abstractionGit{}
@@ -157,6 +194,27 @@ func LogRange(repo *git.Repository, from, to string) (object.CommitIter, error)
 	return object.NewCommitPreorderIter(cTo, map[plumbing.Hash]bool{}, ignore), nil
 }
 
+// ReadFileAtRef returns the content of a file as it is committed at the given ref, e.g. a branch, a tag or HEAD~1.
+// In case the file does not exist at the ref an error is returned for which os.IsNotExist is true.
+func ReadFileAtRef(repo *git.Repository, ref, path string) ([]byte, error) {
+	commit, err := getCommitObject(ref, repo)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(path)
+	if err == object.ErrFileNotFound {
+		return nil, &os.PathError{Op: "open", Path: ref + ":" + path, Err: os.ErrNotExist}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot read '%s' at '%s'", path, ref)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot read '%s' at '%s'", path, ref)
+	}
+	return []byte(content), nil
+}
+
 func getCommitObject(ref string, repo *git.Repository) (*object.Commit, error) {
 	if len(ref) == 0 {
 		// with go-git v5.1.0 we panic otherwise inside ResolveRevision
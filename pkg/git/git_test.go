@@ -6,10 +6,12 @@ import (
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
+	"os"
 	"testing"
 )
 
@@ -42,6 +44,27 @@ func TestCommit(t *testing.T) {
 	})
 }
 
+func TestCommitFiles(t *testing.T) {
+	t.Parallel()
+	t.Run("successful run", func(t *testing.T) {
+		t.Parallel()
+		worktreeMock := WorktreeMock{}
+		hash, err := commitFiles([]string{"overlays/dev", "values.yaml"}, "message", "user", &worktreeMock)
+		assert.NoError(t, err)
+		assert.Equal(t, plumbing.Hash([20]byte{4, 5, 6}), hash)
+		assert.Equal(t, []string{"overlays/dev", "values.yaml"}, worktreeMock.added)
+		assert.Equal(t, "user", worktreeMock.author)
+	})
+
+	t.Run("error adding file", func(t *testing.T) {
+		t.Parallel()
+		_, err := commitFiles([]string{"values.yaml"}, "message", "user", WorktreeMockFailing{
+			failingAdd: true,
+		})
+		assert.EqualError(t, err, "failed to add file to git: failed to add file")
+	})
+}
+
 func TestPushChangesToRepository(t *testing.T) {
 	t.Parallel()
 	t.Run("successful push", func(t *testing.T) {
@@ -59,6 +82,23 @@ func TestPushChangesToRepository(t *testing.T) {
 	})
 }
 
+func TestPushChangesToBranch(t *testing.T) {
+	t.Parallel()
+	t.Run("successful push", func(t *testing.T) {
+		t.Parallel()
+		repository := &RepositoryMockRefSpecs{}
+		err := pushChangesToBranch("user", "password", "main", "gitops-update/abc", repository)
+		assert.NoError(t, err)
+		assert.Equal(t, []config.RefSpec{"refs/heads/main:refs/heads/gitops-update/abc"}, repository.refSpecs)
+	})
+
+	t.Run("error pushing", func(t *testing.T) {
+		t.Parallel()
+		err := pushChangesToBranch("user", "password", "main", "update", RepositoryMockError{})
+		assert.EqualError(t, err, "failed to push commit to branch update: error on push commits")
+	})
+}
+
 func TestPlainClone(t *testing.T) {
 	t.Parallel()
 	t.Run("successful clone", func(t *testing.T) {
@@ -328,6 +368,46 @@ func TestLogRange(t *testing.T) {
 	})
 }
 
+func TestReadFileAtRef(t *testing.T) {
+	fs := memfs.New()
+	r, err := git.Init(memory.NewStorage(), fs)
+	assert.NoError(t, err)
+	w, err := r.Worktree()
+	assert.NoError(t, err)
+	commit := func(content string) {
+		f, err := fs.Create(".pipeline/config.yml")
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+		_, err = w.Add(".pipeline/config.yml")
+		assert.NoError(t, err)
+		_, err = w.Commit("update config", &git.CommitOptions{Author: &object.Signature{Name: "me", Email: "me@example.org"}})
+		assert.NoError(t, err)
+	}
+	commit("general:\n  verbose: false\n")
+	commit("general:\n  verbose: true\n")
+
+	t.Run("current version", func(t *testing.T) {
+		content, err := ReadFileAtRef(r, "HEAD", ".pipeline/config.yml")
+		assert.NoError(t, err)
+		assert.Equal(t, "general:\n  verbose: true\n", string(content))
+	})
+	t.Run("previous version", func(t *testing.T) {
+		content, err := ReadFileAtRef(r, "HEAD~1", ".pipeline/config.yml")
+		assert.NoError(t, err)
+		assert.Equal(t, "general:\n  verbose: false\n", string(content))
+	})
+	t.Run("missing file", func(t *testing.T) {
+		_, err := ReadFileAtRef(r, "master", ".pipeline/config.yaml")
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("unknown ref", func(t *testing.T) {
+		_, err := ReadFileAtRef(r, "unknown", ".pipeline/config.yml")
+		assert.EqualError(t, err, "Trouble resolving 'unknown': reference not found")
+	})
+}
+
 type RepositoryMock struct {
 	worktree *git.Worktree
 	test     *testing.T
@@ -345,6 +425,19 @@ func (r RepositoryMock) Push(o *git.PushOptions) error {
 	return nil
 }
 
+type RepositoryMockRefSpecs struct {
+	refSpecs []config.RefSpec
+}
+
+func (r *RepositoryMockRefSpecs) Worktree() (*git.Worktree, error) {
+	return &git.Worktree{}, nil
+}
+
+func (r *RepositoryMockRefSpecs) Push(o *git.PushOptions) error {
+	r.refSpecs = o.RefSpecs
+	return nil
+}
+
 type RepositoryMockError struct{}
 
 func (RepositoryMockError) Worktree() (*git.Worktree, error) {
@@ -388,9 +481,11 @@ type WorktreeMock struct {
 	create             bool
 	author             string
 	commitAll          bool
+	added              []string
 }
 
-func (WorktreeMock) Add(string) (plumbing.Hash, error) {
+func (w *WorktreeMock) Add(path string) (plumbing.Hash, error) {
+	w.added = append(w.added, path)
 	return [20]byte{1, 2, 3}, nil
 }
 
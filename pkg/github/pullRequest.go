@@ -0,0 +1,202 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/pkg/errors"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+)
+
+const (
+	checkPending = "pending"
+	checkSuccess = "success"
+	checkFailure = "failure"
+)
+
+type pullRequestService interface {
+	Create(ctx context.Context, owner string, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error)
+	Merge(ctx context.Context, owner string, repo string, number int, commitMessage string, options *github.PullRequestOptions) (*github.PullRequestMergeResult, *github.Response, error)
+}
+
+type statusService interface {
+	GetRequiredStatusChecks(ctx context.Context, owner, repo, branch string) (*github.RequiredStatusChecks, *github.Response, error)
+	GetCombinedStatus(ctx context.Context, owner, repo, ref string, opts *github.ListOptions) (*github.CombinedStatus, *github.Response, error)
+}
+
+type checkRunService interface {
+	ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *github.ListCheckRunsOptions) (*github.ListCheckRunsResults, *github.Response, error)
+}
+
+// PullRequestClient creates pull requests, waits for their checks and merges them
+type PullRequestClient struct {
+	pullRequests pullRequestService
+	statuses     statusService
+	checkRuns    checkRunService
+	pollInterval time.Duration
+}
+
+// NewPullRequestClient creates a PullRequestClient which polls the state of the checks in the given interval
+func NewPullRequestClient(client *github.Client, pollInterval time.Duration) *PullRequestClient {
+	return &PullRequestClient{pullRequests: client.PullRequests, statuses: client.Repositories, checkRuns: client.Checks, pollInterval: pollInterval}
+}
+
+// CreatePullRequest creates a pull request for merging head into base
+func (c *PullRequestClient) CreatePullRequest(ctx context.Context, owner, repository, head, base, title, body string) (*github.PullRequest, error) {
+	pullRequest, _, err := c.pullRequests.Create(ctx, owner, repository, &github.NewPullRequest{
+		Title: &title,
+		Head:  &head,
+		Base:  &base,
+		Body:  &body,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pull request")
+	}
+	log.Entry().Infof("Pull request %v created", pullRequest.GetHTMLURL())
+	return pullRequest, nil
+}
+
+// WaitForChecks waits until the checks of the head commit of the pull request are finished.
+// In case the base branch is protected only the required checks are considered, otherwise all reported checks.
+// Without required checks at least one check needs to be reported, since the checks of a new pull request are usually not registered yet.
+// An error is returned if a check failed or the checks did not finish within the timeout.
+func (c *PullRequestClient) WaitForChecks(ctx context.Context, owner, repository string, pullRequest *github.PullRequest, timeout time.Duration) error {
+	required, err := c.requiredChecks(ctx, owner, repository, pullRequest.GetBase().GetRef())
+	if err != nil {
+		return err
+	}
+	ref := pullRequest.GetHead().GetSHA()
+	deadline := time.Now().Add(timeout)
+	for {
+		states, err := c.checkStates(ctx, owner, repository, ref)
+		if err != nil {
+			return err
+		}
+		pending, failed := evaluateChecks(states, required)
+		if len(failed) > 0 {
+			return fmt.Errorf("checks of pull request %v failed: %v", pullRequest.GetNumber(), strings.Join(failed, ", "))
+		}
+		if len(pending) == 0 {
+			log.Entry().Infof("All checks of pull request %v succeeded", pullRequest.GetNumber())
+			return nil
+		}
+		if time.Now().Add(c.pollInterval).After(deadline) {
+			return fmt.Errorf("checks of pull request %v did not finish within %v, pending: %v", pullRequest.GetNumber(), timeout, strings.Join(pending, ", "))
+		}
+		log.Entry().Infof("Waiting for checks of pull request %v: %v", pullRequest.GetNumber(), strings.Join(pending, ", "))
+		time.Sleep(c.pollInterval)
+	}
+}
+
+// MergePullRequest merges the pull request with the given merge method, i.e. merge, squash or rebase
+func (c *PullRequestClient) MergePullRequest(ctx context.Context, owner, repository string, pullRequest *github.PullRequest, mergeMethod string) error {
+	result, _, err := c.pullRequests.Merge(ctx, owner, repository, pullRequest.GetNumber(), "", &github.PullRequestOptions{
+		MergeMethod: mergeMethod,
+		SHA:         pullRequest.GetHead().GetSHA(),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to merge pull request %v", pullRequest.GetNumber())
+	}
+	if !result.GetMerged() {
+		return fmt.Errorf("pull request %v has not been merged: %v", pullRequest.GetNumber(), result.GetMessage())
+	}
+	log.Entry().Infof("Pull request %v merged", pullRequest.GetNumber())
+	return nil
+}
+
+// requiredChecks returns the names of the checks which are required by the branch protection, an unprotected branch has no required checks
+func (c *PullRequestClient) requiredChecks(ctx context.Context, owner, repository, branch string) ([]string, error) {
+	checks, response, err := c.statuses.GetRequiredStatusChecks(ctx, owner, repository, branch)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound && isUnprotected(err) {
+			return []string{}, nil
+		}
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			// GitHub also responds with 404 in case the token is not allowed to read the branch protection
+			return nil, errors.Wrapf(err, "failed to retrieve required checks of branch %v, reading the branch protection requires admin permission on the repository", branch)
+		}
+		return nil, errors.Wrapf(err, "failed to retrieve required checks of branch %v", branch)
+	}
+	return checks.Contexts, nil
+}
+
+// isUnprotected returns true in case GitHub responded that the branch has no protection or no required checks
+func isUnprotected(err error) bool {
+	var errorResponse *github.ErrorResponse
+	if !errors.As(err, &errorResponse) {
+		return false
+	}
+	return errorResponse.Message == "Branch not protected" || errorResponse.Message == "Required status checks not enabled"
+}
+
+// checkStates returns the state of all commit statuses and check runs of the ref by their name
+func (c *PullRequestClient) checkStates(ctx context.Context, owner, repository, ref string) (map[string]string, error) {
+	states := map[string]string{}
+
+	combinedStatus, _, err := c.statuses.GetCombinedStatus(ctx, owner, repository, ref, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve status of %v", ref)
+	}
+	for _, status := range combinedStatus.Statuses {
+		switch status.GetState() {
+		case "success":
+			states[status.GetContext()] = checkSuccess
+		case "pending":
+			states[status.GetContext()] = checkPending
+		default:
+			states[status.GetContext()] = checkFailure
+		}
+	}
+
+	checkRuns, _, err := c.checkRuns.ListCheckRunsForRef(ctx, owner, repository, ref, &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve check runs of %v", ref)
+	}
+	for _, checkRun := range checkRuns.CheckRuns {
+		if checkRun.GetStatus() != "completed" {
+			states[checkRun.GetName()] = checkPending
+			continue
+		}
+		switch checkRun.GetConclusion() {
+		case "success", "neutral", "skipped":
+			states[checkRun.GetName()] = checkSuccess
+		default:
+			states[checkRun.GetName()] = checkFailure
+		}
+	}
+	return states, nil
+}
+
+// evaluateChecks returns the pending and failed checks, required checks which have not been reported yet are pending.
+// Without required checks the evaluation is pending until a check has been reported.
+func evaluateChecks(states map[string]string, required []string) ([]string, []string) {
+	names := required
+	if len(names) == 0 {
+		if len(states) == 0 {
+			return []string{"no checks reported yet"}, []string{}
+		}
+		names = []string{}
+		for name := range states {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	pending, failed := []string{}, []string{}
+	for _, name := range names {
+		switch states[name] {
+		case checkSuccess:
+		case checkFailure:
+			failed = append(failed, name)
+		default:
+			pending = append(pending, name)
+		}
+	}
+	return pending, failed
+}
@@ -0,0 +1,179 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+)
+
+type pullRequestServiceMock struct {
+	created      *github.NewPullRequest
+	mergeOptions *github.PullRequestOptions
+	merged       bool
+}
+
+func (p *pullRequestServiceMock) Create(_ context.Context, _ string, _ string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+	p.created = pull
+	return &github.PullRequest{Number: github.Int(42), HTMLURL: github.String("https://github.com/owner/repo/pull/42")}, nil, nil
+}
+
+func (p *pullRequestServiceMock) Merge(_ context.Context, _ string, _ string, _ int, _ string, options *github.PullRequestOptions) (*github.PullRequestMergeResult, *github.Response, error) {
+	p.mergeOptions = options
+	return &github.PullRequestMergeResult{Merged: github.Bool(p.merged), Message: github.String("not mergeable")}, nil, nil
+}
+
+type statusServiceMock struct {
+	required []string
+	// notFoundMessage is the message of the 404 response in case no checks are required, defaults to an unprotected branch
+	notFoundMessage string
+	// statuses contains the statuses returned on subsequent calls, the last one is returned repeatedly
+	statuses [][]*github.RepoStatus
+	calls    int
+}
+
+func (s *statusServiceMock) GetRequiredStatusChecks(context.Context, string, string, string) (*github.RequiredStatusChecks, *github.Response, error) {
+	if s.required == nil {
+		message := s.notFoundMessage
+		if len(message) == 0 {
+			message = "Branch not protected"
+		}
+		request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/owner/repo/branches/main/protection/required_status_checks", nil)
+		response := &http.Response{StatusCode: http.StatusNotFound, Request: request}
+		return nil, &github.Response{Response: response}, &github.ErrorResponse{Response: response, Message: message}
+	}
+	return &github.RequiredStatusChecks{Contexts: s.required}, nil, nil
+}
+
+func (s *statusServiceMock) GetCombinedStatus(context.Context, string, string, string, *github.ListOptions) (*github.CombinedStatus, *github.Response, error) {
+	index := s.calls
+	if index >= len(s.statuses) {
+		index = len(s.statuses) - 1
+	}
+	s.calls++
+	return &github.CombinedStatus{Statuses: s.statuses[index]}, nil, nil
+}
+
+type checkRunServiceMock struct {
+	checkRuns []*github.CheckRun
+}
+
+func (c *checkRunServiceMock) ListCheckRunsForRef(context.Context, string, string, string, *github.ListCheckRunsOptions) (*github.ListCheckRunsResults, *github.Response, error) {
+	return &github.ListCheckRunsResults{CheckRuns: c.checkRuns}, nil, nil
+}
+
+func status(context, state string) *github.RepoStatus {
+	return &github.RepoStatus{Context: github.String(context), State: github.String(state)}
+}
+
+func testPullRequest() *github.PullRequest {
+	return &github.PullRequest{
+		Number: github.Int(42),
+		Head:   &github.PullRequestBranch{SHA: github.String("abc123")},
+		Base:   &github.PullRequestBranch{Ref: github.String("main")},
+	}
+}
+
+func TestCreatePullRequest(t *testing.T) {
+	pullRequests := &pullRequestServiceMock{}
+	client := &PullRequestClient{pullRequests: pullRequests}
+
+	pullRequest, err := client.CreatePullRequest(context.Background(), "owner", "repo", "update", "main", "title", "body")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, pullRequest.GetNumber())
+	assert.Equal(t, "update", pullRequests.created.GetHead())
+	assert.Equal(t, "main", pullRequests.created.GetBase())
+	assert.Equal(t, "title", pullRequests.created.GetTitle())
+	assert.Equal(t, "body", pullRequests.created.GetBody())
+}
+
+func TestWaitForChecks(t *testing.T) {
+	t.Run("required checks succeed", func(t *testing.T) {
+		statuses := &statusServiceMock{
+			required: []string{"build", "lint"},
+			statuses: [][]*github.RepoStatus{
+				{status("build", "pending")},
+				{status("build", "success"), status("optional", "failure")},
+			},
+		}
+		checkRuns := &checkRunServiceMock{checkRuns: []*github.CheckRun{{Name: github.String("lint"), Status: github.String("completed"), Conclusion: github.String("success")}}}
+		client := &PullRequestClient{statuses: statuses, checkRuns: checkRuns, pollInterval: time.Millisecond}
+
+		err := client.WaitForChecks(context.Background(), "owner", "repo", testPullRequest(), time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, statuses.calls)
+	})
+
+	t.Run("all checks of unprotected branch", func(t *testing.T) {
+		statuses := &statusServiceMock{statuses: [][]*github.RepoStatus{{status("build", "success"), status("optional", "error")}}}
+		client := &PullRequestClient{statuses: statuses, checkRuns: &checkRunServiceMock{}, pollInterval: time.Millisecond}
+
+		err := client.WaitForChecks(context.Background(), "owner", "repo", testPullRequest(), time.Second)
+		assert.EqualError(t, err, "checks of pull request 42 failed: optional")
+	})
+
+	t.Run("waits for the first check of unprotected branch", func(t *testing.T) {
+		statuses := &statusServiceMock{statuses: [][]*github.RepoStatus{{}, {status("build", "success")}}}
+		client := &PullRequestClient{statuses: statuses, checkRuns: &checkRunServiceMock{}, pollInterval: time.Millisecond}
+
+		err := client.WaitForChecks(context.Background(), "owner", "repo", testPullRequest(), time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, statuses.calls)
+	})
+
+	t.Run("no checks reported for unprotected branch", func(t *testing.T) {
+		statuses := &statusServiceMock{statuses: [][]*github.RepoStatus{{}}}
+		client := &PullRequestClient{statuses: statuses, checkRuns: &checkRunServiceMock{}, pollInterval: time.Millisecond}
+
+		err := client.WaitForChecks(context.Background(), "owner", "repo", testPullRequest(), 5*time.Millisecond)
+		assert.EqualError(t, err, "checks of pull request 42 did not finish within 5ms, pending: no checks reported yet")
+	})
+
+	t.Run("branch protection not readable", func(t *testing.T) {
+		statuses := &statusServiceMock{notFoundMessage: "Not Found", statuses: [][]*github.RepoStatus{{status("build", "success")}}}
+		client := &PullRequestClient{statuses: statuses, checkRuns: &checkRunServiceMock{}, pollInterval: time.Millisecond}
+
+		err := client.WaitForChecks(context.Background(), "owner", "repo", testPullRequest(), time.Second)
+		assert.Contains(t, err.Error(), "failed to retrieve required checks of branch main, reading the branch protection requires admin permission on the repository")
+		assert.Equal(t, 0, statuses.calls)
+	})
+
+	t.Run("failed check run", func(t *testing.T) {
+		statuses := &statusServiceMock{required: []string{"test"}, statuses: [][]*github.RepoStatus{{}}}
+		checkRuns := &checkRunServiceMock{checkRuns: []*github.CheckRun{{Name: github.String("test"), Status: github.String("completed"), Conclusion: github.String("failure")}}}
+		client := &PullRequestClient{statuses: statuses, checkRuns: checkRuns, pollInterval: time.Millisecond}
+
+		err := client.WaitForChecks(context.Background(), "owner", "repo", testPullRequest(), time.Second)
+		assert.EqualError(t, err, "checks of pull request 42 failed: test")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		statuses := &statusServiceMock{required: []string{"build"}, statuses: [][]*github.RepoStatus{{status("build", "pending")}}}
+		client := &PullRequestClient{statuses: statuses, checkRuns: &checkRunServiceMock{}, pollInterval: time.Millisecond}
+
+		err := client.WaitForChecks(context.Background(), "owner", "repo", testPullRequest(), 5*time.Millisecond)
+		assert.EqualError(t, err, "checks of pull request 42 did not finish within 5ms, pending: build")
+	})
+}
+
+func TestMergePullRequest(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		pullRequests := &pullRequestServiceMock{merged: true}
+		client := &PullRequestClient{pullRequests: pullRequests}
+
+		err := client.MergePullRequest(context.Background(), "owner", "repo", testPullRequest(), "squash")
+		assert.NoError(t, err)
+		assert.Equal(t, "squash", pullRequests.mergeOptions.MergeMethod)
+		assert.Equal(t, "abc123", pullRequests.mergeOptions.SHA)
+	})
+
+	t.Run("not merged", func(t *testing.T) {
+		client := &PullRequestClient{pullRequests: &pullRequestServiceMock{}}
+
+		err := client.MergePullRequest(context.Background(), "owner", "repo", testPullRequest(), "merge")
+		assert.EqualError(t, err, "pull request 42 has not been merged: not mergeable")
+	})
+}
@@ -16,11 +16,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/motemen/go-nuts/roundtime"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
 // Client defines an http client object
@@ -37,6 +40,9 @@ type Client struct {
 	doLogRequestBodyOnDebug   bool
 	doLogResponseBodyOnDebug  bool
 	useDefaultTransport       bool
+	retryPolicy               *RetryPolicy
+	rateLimiter               *rate.Limiter
+	circuitBreaker            *circuitBreaker
 }
 
 // ClientOptions defines the options to be set on the client
@@ -59,6 +65,12 @@ type ClientOptions struct {
 	DoLogRequestBodyOnDebug   bool
 	DoLogResponseBodyOnDebug  bool
 	UseDefaultTransport       bool
+	// RetryPolicy replaces the default retry behavior, MaxRetries is ignored in case it is set
+	RetryPolicy *RetryPolicy
+	// RateLimit limits the requests of the client, by default the requests are not limited
+	RateLimit *RateLimitPolicy
+	// CircuitBreaker lets requests fail fast after repeated service errors, by default requests are always sent
+	CircuitBreaker *CircuitBreakerPolicy
 }
 
 // TransportWrapper is a wrapper for central logging capabilities
@@ -182,14 +194,63 @@ func (c *Client) SendRequest(method, url string, body io.Reader, header http.Hea
 
 // Send sends an http request
 func (c *Client) Send(request *http.Request) (*http.Response, error) {
-	httpClient := c.initialize()
+	httpClient := c.initialize(request.Method)
+	span := tracing.StartSpan(request.Context(), "HTTP "+request.Method, tracing.SpanKindClient)
+	if span != nil {
+		request = request.WithContext(tracing.ContextWithSpan(request.Context(), span))
+	}
+	start := time.Now()
 	response, err := httpClient.Do(request)
+	emitRequestEvent(request, response, start, err)
+	endRequestSpan(span, request, response, err)
 	if err != nil {
 		return response, errors.Wrapf(err, "HTTP %v request to %v failed", request.Method, request.URL)
 	}
 	return c.handleResponse(response, request.URL.String())
 }
 
+// emitRequestEvent emits the httpRequest event, credentials and query of the URL are omitted
+func emitRequestEvent(request *http.Request, response *http.Response, start time.Time, err error) {
+	if !events.Enabled() {
+		return
+	}
+	url := *request.URL
+	url.User = nil
+	url.RawQuery = ""
+	data := map[string]interface{}{
+		"method":     request.Method,
+		"url":        url.String(),
+		"durationMs": time.Since(start).Milliseconds(),
+	}
+	if response != nil {
+		data["statusCode"] = response.StatusCode
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	events.Emit(events.TypeHTTPRequest, data)
+}
+
+// endRequestSpan ends the span of a request, credentials and query of the URL are omitted
+func endRequestSpan(span *tracing.Span, request *http.Request, response *http.Response, err error) {
+	if span == nil {
+		return
+	}
+	url := *request.URL
+	url.User = nil
+	url.RawQuery = ""
+	span.SetAttribute("http.method", request.Method)
+	span.SetAttribute("http.url", url.String())
+	if response != nil {
+		span.SetAttribute("http.status_code", response.StatusCode)
+		if response.StatusCode >= 400 {
+			span.SetStatus(tracing.StatusError, response.Status)
+		}
+	}
+	span.SetError(err)
+	span.End()
+}
+
 // SetOptions sets options used for the http client
 func (c *Client) SetOptions(options ClientOptions) {
 	c.doLogRequestBodyOnDebug = options.DoLogRequestBodyOnDebug
@@ -202,6 +263,9 @@ func (c *Client) SetOptions(options ClientOptions) {
 	c.password = options.Password
 	c.token = options.Token
 	c.maxRetries = options.MaxRetries
+	c.retryPolicy = options.RetryPolicy
+	c.rateLimiter = newRateLimiter(options.RateLimit)
+	c.circuitBreaker = newCircuitBreaker(options.CircuitBreaker)
 
 	if options.Logger != nil {
 		c.logger = options.Logger
@@ -211,7 +275,7 @@ func (c *Client) SetOptions(options ClientOptions) {
 	c.cookieJar = options.CookieJar
 }
 
-func (c *Client) initialize() *http.Client {
+func (c *Client) initialize(method string) *http.Client {
 	c.applyDefaults()
 	c.logger = log.Entry().WithField("package", "SAP/jenkins-library/pkg/http")
 
@@ -231,30 +295,44 @@ func (c *Client) initialize() *http.Client {
 		doLogResponseBodyOnDebug: c.doLogResponseBodyOnDebug,
 	}
 
+	var roundTripper http.RoundTripper
+	if !c.useDefaultTransport {
+		roundTripper = transport
+	}
+	if c.rateLimiter != nil || c.circuitBreaker != nil {
+		roundTripper = &policyTransport{transport: roundTripper, limiter: c.rateLimiter, breaker: c.circuitBreaker}
+	}
+
+	retryPolicy := RetryPolicy{MaxRetries: c.maxRetries}
+	if c.retryPolicy != nil {
+		retryPolicy = *c.retryPolicy
+	}
+	retryPolicy = retryPolicy.withDefaults()
+
 	var httpClient *http.Client
-	if c.maxRetries > 0 {
+	if retries := retryPolicy.retries(method); retries > 0 {
 		retryClient := retryablehttp.NewClient()
 		retryClient.HTTPClient.Timeout = c.maxRequestDuration
 		retryClient.HTTPClient.Jar = c.cookieJar
-		retryClient.RetryMax = c.maxRetries
-		if !c.useDefaultTransport {
-			retryClient.HTTPClient.Transport = transport
+		retryClient.RetryMax = retries
+		retryClient.RetryWaitMin = retryPolicy.MinWait
+		retryClient.RetryWaitMax = retryPolicy.MaxWait
+		if roundTripper != nil {
+			retryClient.HTTPClient.Transport = roundTripper
 		}
-		retryClient.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
-			if err != nil && (strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "timed out") || strings.Contains(err.Error(), "connection refused")) {
-				// Assuming timeouts could be retried
-				return true, nil
+		retryClient.RequestLogHook = func(_ retryablehttp.Logger, request *http.Request, attempt int) {
+			if attempt > 0 {
+				tracing.SpanFromContext(request.Context()).SetAttribute("http.retry_count", attempt)
 			}
-			return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
 		}
+		retryClient.CheckRetry = retryPolicy.checkRetry
+		retryClient.Backoff = retryPolicy.backoff
 		httpClient = retryClient.StandardClient()
 	} else {
 		httpClient = &http.Client{}
 		httpClient.Timeout = c.maxRequestDuration
 		httpClient.Jar = c.cookieJar
-		if !c.useDefaultTransport {
-			httpClient.Transport = transport
-		}
+		httpClient.Transport = roundTripper
 	}
 
 	if c.transportSkipVerification {
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -12,6 +13,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,7 +24,9 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/SAP/jenkins-library/pkg/events"
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/tracing"
 )
 
 func TestSend(t *testing.T) {
@@ -58,6 +63,67 @@ func TestSend(t *testing.T) {
 	})
 }
 
+func TestSendEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "events")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.ndjson")
+
+	events.Initialize(path, events.Source{Step: "test"})
+	client := Client{}
+	_, err = client.SendRequest(http.MethodGet, strings.Replace(server.URL, "http://", "http://user:pass@", 1)+"/api?token=secret", nil, nil, nil)
+	assert.Error(t, err)
+	events.StepFinished("1", "undefined", 0)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 3)
+	event := events.Event{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, events.TypeHTTPRequest, event.Type)
+	assert.Equal(t, http.MethodGet, event.Data["method"])
+	assert.Equal(t, server.URL+"/api", event.Data["url"])
+	assert.Equal(t, float64(404), event.Data["statusCode"])
+}
+
+func TestSendTracing(t *testing.T) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	collector := tracing.NewCollector()
+	defer collector.Close()
+
+	tracing.Initialize(tracing.Options{Endpoint: collector.Endpoint(), Step: "test"})
+	client := Client{retryPolicy: &RetryPolicy{MaxRetries: 1, MinWait: time.Millisecond}}
+	_, err := client.SendRequest(http.MethodGet, strings.Replace(server.URL, "http://", "http://user:pass@", 1)+"/api?token=secret", nil, nil, nil)
+	assert.Error(t, err)
+	// the spans are exported to the collector when the step finishes
+	tracing.StepFinished("1", "undefined")
+
+	span, ok := collector.Span("HTTP GET")
+	require.True(t, ok)
+	step, _ := collector.Span("test")
+	assert.Equal(t, step.SpanID, span.ParentSpanID)
+	assert.Equal(t, tracing.SpanKindClient, span.Kind)
+	assert.Equal(t, server.URL+"/api", span.Attribute("http.url"))
+	assert.Equal(t, int64(404), span.Attribute("http.status_code"))
+	assert.Equal(t, int64(1), span.Attribute("http.retry_count"))
+	assert.Equal(t, tracing.StatusError, span.Status.Code)
+}
+
 func TestDefaultTransport(t *testing.T) {
 	const testURL string = "https://localhost/api"
 
@@ -0,0 +1,307 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/hashicorp/go-retryablehttp"
+	"golang.org/x/time/rate"
+)
+
+// RetryPolicy defines which requests are retried and how long to wait between the attempts
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries of a request
+	MaxRetries int
+	// StatusCodes of responses which are retried, defaults to 429 and all 5xx codes except 501.
+	// Connection errors and timeouts are always retried.
+	StatusCodes []int
+	// IdempotentOnly restricts retries to requests with an idempotent method, i.e. requests with POST or PATCH are not retried
+	IdempotentOnly bool
+	// MinWait is the wait before the first retry, it doubles with each further retry (default 1s)
+	MinWait time.Duration
+	// MaxWait limits the wait between two attempts (default 30s)
+	MaxWait time.Duration
+	// Jitter randomizes each wait by up to the given fraction in order to avoid that several clients retry at the same time, e.g. 0.2 for ±20%
+	Jitter float64
+	// IgnoreRetryAfter disables waiting for the time requested by the Retry-After header of 429 and 503 responses
+	IgnoreRetryAfter bool
+	// MaxRetryAfter is the longest wait requested via Retry-After which is accepted, a request is not retried in case the service asks for a longer wait (default 5m)
+	MaxRetryAfter time.Duration
+}
+
+// RateLimitPolicy limits the rate of the requests sent by a client, including retries
+type RateLimitPolicy struct {
+	RequestsPerSecond float64
+	// Burst is the number of requests which may be sent at once (default 1)
+	Burst int
+}
+
+// CircuitBreakerPolicy defines when requests to a service fail fast without being sent
+type CircuitBreakerPolicy struct {
+	// FailureThreshold is the number of consecutive service errors (5xx responses, connection errors and timeouts) which opens the circuit (default 5)
+	FailureThreshold int
+	// OpenDuration is the time in which requests fail fast once the circuit is open (default 1m).
+	// Afterwards a single request is sent, the circuit closes again in case it succeeds.
+	OpenDuration time.Duration
+}
+
+// defaultServiceRetries is the number of retries of throttled requests to service APIs in case no MaxRetries are given
+const defaultServiceRetries = 3
+
+// WithServicePolicies returns the options with the policies for the APIs of services like Checkmarx, WhiteSource, Fortify and CPI:
+// requests throttled via 429 and 503 responses are retried with exponential backoff respecting Retry-After, up to MaxRetries times (default 3),
+// and requests fail fast after repeated service errors.
+func (o ClientOptions) WithServicePolicies() ClientOptions {
+	retries := o.MaxRetries
+	if retries <= 0 {
+		retries = defaultServiceRetries
+	}
+	o.RetryPolicy = &RetryPolicy{
+		MaxRetries:  retries,
+		StatusCodes: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		Jitter:      0.2,
+	}
+	o.CircuitBreaker = &CircuitBreakerPolicy{}
+	return o
+}
+
+// ErrCircuitOpen is returned wrapped for requests which are not sent since the circuit breaker of the client is open, use errors.Is to detect it
+var ErrCircuitOpen = errors.New("circuit breaker is open after repeated service errors")
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// retries returns the maximum number of retries of a request with the method
+func (p RetryPolicy) retries(method string) int {
+	if p.IdempotentOnly && !idempotentMethods[method] {
+		return 0
+	}
+	return p.MaxRetries
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MinWait == 0 {
+		p.MinWait = 1 * time.Second
+	}
+	if p.MaxWait == 0 {
+		p.MaxWait = 30 * time.Second
+	}
+	if p.MaxRetryAfter == 0 {
+		p.MaxRetryAfter = 5 * time.Minute
+	}
+	return p
+}
+
+// checkRetry decides whether a request is retried
+func (p RetryPolicy) checkRetry(ctx context.Context, response *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return false, err
+	}
+	if err != nil {
+		var netErr net.Error
+		if (errors.As(err, &netErr) && netErr.Timeout()) || errors.Is(err, syscall.ECONNREFUSED) {
+			// Assuming timeouts could be retried
+			return true, nil
+		}
+		return retryablehttp.DefaultRetryPolicy(ctx, response, err)
+	}
+	if !p.retryStatusCode(response.StatusCode) {
+		return false, nil
+	}
+	if retryAfter, ok := p.retryAfter(response); ok && retryAfter > p.MaxRetryAfter {
+		log.Entry().Warningf("not retrying request since the service asks to wait %v", retryAfter)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p RetryPolicy) retryStatusCode(statusCode int) bool {
+	if len(p.StatusCodes) == 0 {
+		return statusCode == 0 || statusCode == http.StatusTooManyRequests || (statusCode >= 500 && statusCode != http.StatusNotImplemented)
+	}
+	for _, code := range p.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the next attempt
+func (p RetryPolicy) backoff(min, max time.Duration, attempt int, response *http.Response) time.Duration {
+	if retryAfter, ok := p.retryAfter(response); ok {
+		return retryAfter
+	}
+	wait := float64(min) * math.Pow(2, float64(attempt))
+	if p.Jitter > 0 {
+		wait = wait * (1 + p.Jitter*(2*rand.Float64()-1))
+	}
+	if wait > float64(max) {
+		return max
+	}
+	return time.Duration(wait)
+}
+
+// retryAfter returns the wait requested by the Retry-After header, either in seconds or as HTTP date
+func (p RetryPolicy) retryAfter(response *http.Response) (time.Duration, bool) {
+	if p.IgnoreRetryAfter || response == nil || (response.StatusCode != http.StatusTooManyRequests && response.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	value := strings.TrimSpace(response.Header.Get("Retry-After"))
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func newRateLimiter(policy *RateLimitPolicy) *rate.Limiter {
+	if policy == nil || policy.RequestsPerSecond <= 0 {
+		return nil
+	}
+	burst := policy.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(policy.RequestsPerSecond), burst)
+}
+
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker counts consecutive service errors, it is shared by all requests of a client
+type circuitBreaker struct {
+	policy   CircuitBreakerPolicy
+	mutex    sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func newCircuitBreaker(policy *CircuitBreakerPolicy) *circuitBreaker {
+	if policy == nil {
+		return nil
+	}
+	breaker := &circuitBreaker{policy: *policy, now: time.Now}
+	if breaker.policy.FailureThreshold <= 0 {
+		breaker.policy.FailureThreshold = 5
+	}
+	if breaker.policy.OpenDuration <= 0 {
+		breaker.policy.OpenDuration = 1 * time.Minute
+	}
+	return breaker
+}
+
+// allow returns ErrCircuitOpen in case the request must not be sent
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.policy.OpenDuration {
+			return ErrCircuitOpen
+		}
+		// let a single request pass in order to check whether the service is back
+		b.state = circuitHalfOpen
+	case circuitHalfOpen:
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// record updates the state with the result of a request
+func (b *circuitBreaker) record(response *http.Response, err error) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err == nil && response.StatusCode < 500 {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.policy.FailureThreshold {
+		if b.state != circuitOpen {
+			log.Entry().Warningf("%v consecutive service errors, requests fail without being sent for %v", b.failures, b.policy.OpenDuration)
+		}
+		b.state = circuitOpen
+		b.openedAt = b.now()
+		log.SetErrorCategory(log.ErrorService)
+	}
+}
+
+// policyTransport applies the rate limit and the circuit breaker to each attempt of a request
+type policyTransport struct {
+	transport http.RoundTripper
+	limiter   *rate.Limiter
+	breaker   *circuitBreaker
+}
+
+func (t *policyTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := t.breaker.allow(); err != nil {
+		log.SetErrorCategory(log.ErrorService)
+		return nil, fmt.Errorf("request to %v not sent: %w", request.URL.Host, err)
+	}
+	if t.limiter != nil {
+		if err := t.limiter.Wait(request.Context()); err != nil {
+			t.breaker.release()
+			return nil, err
+		}
+	}
+	transport := t.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	response, err := transport.RoundTrip(request)
+	t.breaker.record(response, err)
+	return response, err
+}
+
+// release reopens the circuit in case the request which has been let pass in half open state has not been sent
+func (b *circuitBreaker) release() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+	}
+}
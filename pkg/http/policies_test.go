@@ -0,0 +1,189 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("status codes", func(t *testing.T) {
+		policy := RetryPolicy{}.withDefaults()
+		for statusCode, expected := range map[int]bool{200: false, 404: false, 429: true, 500: true, 501: false, 503: true} {
+			retry, err := policy.checkRetry(context.Background(), &http.Response{StatusCode: statusCode}, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, retry, statusCode)
+		}
+
+		policy = RetryPolicy{StatusCodes: []int{409}}.withDefaults()
+		retry, _ := policy.checkRetry(context.Background(), &http.Response{StatusCode: 409}, nil)
+		assert.True(t, retry)
+		retry, _ = policy.checkRetry(context.Background(), &http.Response{StatusCode: 500}, nil)
+		assert.False(t, retry)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		policy := RetryPolicy{}.withDefaults()
+		refused := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}
+		retry, err := policy.checkRetry(context.Background(), nil, refused)
+		assert.NoError(t, err)
+		assert.True(t, retry)
+		timeout := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}
+		retry, err = policy.checkRetry(context.Background(), nil, timeout)
+		assert.NoError(t, err)
+		assert.True(t, retry)
+		circuitOpen := &url.Error{Op: "Get", URL: "http://localhost", Err: fmt.Errorf("request to localhost not sent: %w", ErrCircuitOpen)}
+		retry, err = policy.checkRetry(context.Background(), nil, circuitOpen)
+		assert.Equal(t, circuitOpen, err)
+		assert.False(t, retry)
+	})
+
+	t.Run("idempotent methods only", func(t *testing.T) {
+		policy := RetryPolicy{MaxRetries: 3, IdempotentOnly: true}
+		assert.Equal(t, 3, policy.retries(http.MethodGet))
+		assert.Equal(t, 3, policy.retries(http.MethodPut))
+		assert.Equal(t, 0, policy.retries(http.MethodPost))
+		assert.Equal(t, 0, policy.retries(http.MethodPatch))
+		assert.Equal(t, 3, RetryPolicy{MaxRetries: 3}.retries(http.MethodPost))
+	})
+
+	t.Run("exponential backoff", func(t *testing.T) {
+		policy := RetryPolicy{}
+		assert.Equal(t, 1*time.Second, policy.backoff(time.Second, 10*time.Second, 0, nil))
+		assert.Equal(t, 4*time.Second, policy.backoff(time.Second, 10*time.Second, 2, nil))
+		assert.Equal(t, 10*time.Second, policy.backoff(time.Second, 10*time.Second, 5, nil))
+
+		policy = RetryPolicy{Jitter: 0.5}
+		for i := 0; i < 20; i++ {
+			wait := policy.backoff(time.Second, time.Minute, 2, nil)
+			assert.True(t, wait >= 2*time.Second && wait <= 6*time.Second, wait)
+		}
+	})
+
+	t.Run("Retry-After", func(t *testing.T) {
+		policy := RetryPolicy{}.withDefaults()
+		response := &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"7"}}}
+		assert.Equal(t, 7*time.Second, policy.backoff(time.Second, 30*time.Second, 0, response))
+
+		response = &http.Response{StatusCode: 503, Header: http.Header{"Retry-After": []string{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}}
+		wait := policy.backoff(time.Second, 30*time.Second, 0, response)
+		assert.True(t, wait > 50*time.Second && wait <= time.Minute, wait)
+
+		response = &http.Response{StatusCode: 500, Header: http.Header{"Retry-After": []string{"7"}}}
+		assert.Equal(t, 1*time.Second, policy.backoff(time.Second, 30*time.Second, 0, response), "only considered for 429 and 503")
+
+		response = &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"7"}}}
+		assert.Equal(t, 1*time.Second, RetryPolicy{IgnoreRetryAfter: true}.backoff(time.Second, 30*time.Second, 0, response))
+
+		response = &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"3600"}}}
+		retry, _ := policy.checkRetry(context.Background(), response, nil)
+		assert.False(t, retry, "waits longer than MaxRetryAfter are not accepted")
+	})
+}
+
+func TestSendWithRetryPolicy(t *testing.T) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := Client{}
+	client.SetOptions(ClientOptions{RetryPolicy: &RetryPolicy{MaxRetries: 2, IdempotentOnly: true, MinWait: time.Millisecond}})
+
+	t.Run("retried", func(t *testing.T) {
+		count = 0
+		response, err := client.SendRequest(http.MethodGet, server.URL, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("not retried", func(t *testing.T) {
+		count = 0
+		_, err := client.SendRequest(http.MethodPost, server.URL, nil, nil, nil)
+		assert.Error(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestSendWithRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := Client{}
+	client.SetOptions(ClientOptions{RateLimit: &RateLimitPolicy{RequestsPerSecond: 20}})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := client.SendRequest(http.MethodGet, server.URL, nil, nil, nil)
+		require.NoError(t, err)
+	}
+	assert.True(t, time.Since(start) >= 90*time.Millisecond, time.Since(start))
+}
+
+func TestSendWithCircuitBreaker(t *testing.T) {
+	defer log.SetErrorCategory(log.ErrorUndefined)
+	log.SetErrorCategory(log.ErrorUndefined)
+	count := 0
+	statusCode := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	client := Client{}
+	client.SetOptions(ClientOptions{
+		RetryPolicy:    &RetryPolicy{MaxRetries: 5, MinWait: time.Millisecond},
+		CircuitBreaker: &CircuitBreakerPolicy{FailureThreshold: 2, OpenDuration: time.Hour},
+	})
+
+	_, err := client.SendRequest(http.MethodGet, server.URL, nil, nil, nil)
+	assert.True(t, errors.Is(err, ErrCircuitOpen), err)
+	assert.Equal(t, 2, count, "retries must stop once the circuit is open")
+	assert.Equal(t, log.ErrorService, log.GetErrorCategory())
+
+	_, err = client.SendRequest(http.MethodGet, server.URL, nil, nil, nil)
+	assert.True(t, errors.Is(err, ErrCircuitOpen), err)
+	assert.Equal(t, 2, count, "requests must fail fast")
+
+	// after the open duration a single request is sent
+	client.circuitBreaker.now = func() time.Time { return time.Now().Add(time.Hour) }
+	statusCode = http.StatusOK
+	_, err = client.SendRequest(http.MethodGet, server.URL, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, circuitClosed, client.circuitBreaker.state)
+}
+
+func TestWithServicePolicies(t *testing.T) {
+	options := ClientOptions{Token: "token"}.WithServicePolicies()
+	assert.Equal(t, "token", options.Token)
+	assert.Equal(t, 3, options.RetryPolicy.MaxRetries)
+	assert.True(t, options.RetryPolicy.retryStatusCode(http.StatusTooManyRequests))
+	assert.True(t, options.RetryPolicy.retryStatusCode(http.StatusServiceUnavailable))
+	assert.False(t, options.RetryPolicy.retryStatusCode(http.StatusInternalServerError))
+	assert.NotNil(t, options.CircuitBreaker)
+
+	options = ClientOptions{MaxRetries: 5}.WithServicePolicies()
+	assert.Equal(t, 5, options.RetryPolicy.MaxRetries)
+}
@@ -0,0 +1,76 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Workload identifies a Kubernetes resource whose rollout status can be watched
+type Workload struct {
+	Kind string
+	Name string
+}
+
+// String returns the workload in the form expected by kubectl, e.g. deployment/myApp
+func (w Workload) String() string {
+	return strings.ToLower(w.Kind) + "/" + w.Name
+}
+
+// rolloutKinds contains the kinds of resources for which kubectl supports rollout status
+var rolloutKinds = map[string]bool{"Deployment": true, "StatefulSet": true}
+
+// WorkloadsFromManifest returns the Deployments and StatefulSets contained in a manifest consisting of one or more YAML documents
+func WorkloadsFromManifest(manifest []byte) ([]Workload, error) {
+	workloads := []Workload{}
+	decoder := yaml.NewDecoder(bytes.NewReader(manifest))
+	for {
+		var resource struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+		if err := decoder.Decode(&resource); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errors.Wrap(err, "failed to parse manifest")
+		}
+		if rolloutKinds[resource.Kind] && len(resource.Metadata.Name) > 0 {
+			workloads = append(workloads, Workload{Kind: resource.Kind, Name: resource.Metadata.Name})
+		}
+	}
+	return workloads, nil
+}
+
+// HelmRevisions returns the current revision of a release and the latest previous revision which has been deployed successfully
+// based on the output of `helm history --output json`. The previous revision is 0 in case there is none.
+func HelmRevisions(history []byte) (int, int, error) {
+	var releases []struct {
+		Revision int    `json:"revision"`
+		Status   string `json:"status"`
+	}
+	if err := json.Unmarshal(history, &releases); err != nil {
+		return 0, 0, errors.Wrap(err, "failed to parse helm history")
+	}
+	if len(releases) == 0 {
+		return 0, 0, errors.New("helm history does not contain any revision")
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Revision > releases[j].Revision })
+
+	previous := 0
+	for _, release := range releases[1:] {
+		status := strings.ToLower(release.Status)
+		if status == "superseded" || status == "deployed" {
+			previous = release.Revision
+			break
+		}
+	}
+	return releases[0].Revision, previous, nil
+}
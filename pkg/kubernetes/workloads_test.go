@@ -0,0 +1,81 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkloadsFromManifest(t *testing.T) {
+	t.Run("multiple documents", func(t *testing.T) {
+		manifest := `---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: myApp
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myApp
+spec:
+  template:
+    spec:
+      containers:
+      - image: <image-name>
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: myDatabase
+`
+		workloads, err := WorkloadsFromManifest([]byte(manifest))
+		assert.NoError(t, err)
+		assert.Equal(t, []Workload{{Kind: "Deployment", Name: "myApp"}, {Kind: "StatefulSet", Name: "myDatabase"}}, workloads)
+		assert.Equal(t, "deployment/myApp", workloads[0].String())
+		assert.Equal(t, "statefulset/myDatabase", workloads[1].String())
+	})
+
+	t.Run("empty manifest", func(t *testing.T) {
+		workloads, err := WorkloadsFromManifest([]byte{})
+		assert.NoError(t, err)
+		assert.Empty(t, workloads)
+	})
+
+	t.Run("invalid manifest", func(t *testing.T) {
+		_, err := WorkloadsFromManifest([]byte("kind: [Deployment"))
+		assert.Contains(t, err.Error(), "failed to parse manifest")
+	})
+}
+
+func TestHelmRevisions(t *testing.T) {
+	t.Run("previous deployed revision", func(t *testing.T) {
+		history := `[{"revision":3,"status":"superseded"},{"revision":4,"status":"failed"},{"revision":5,"status":"deployed"}]`
+		current, previous, err := HelmRevisions([]byte(history))
+		assert.NoError(t, err)
+		assert.Equal(t, 5, current)
+		assert.Equal(t, 3, previous)
+	})
+
+	t.Run("helm 2 status", func(t *testing.T) {
+		history := `[{"revision":1,"status":"SUPERSEDED"},{"revision":2,"status":"DEPLOYED"}]`
+		current, previous, err := HelmRevisions([]byte(history))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, current)
+		assert.Equal(t, 1, previous)
+	})
+
+	t.Run("first revision", func(t *testing.T) {
+		current, previous, err := HelmRevisions([]byte(`[{"revision":1,"status":"deployed"}]`))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, current)
+		assert.Equal(t, 0, previous)
+	})
+
+	t.Run("no revision", func(t *testing.T) {
+		_, _, err := HelmRevisions([]byte(`[]`))
+		assert.EqualError(t, err, "helm history does not contain any revision")
+	})
+}
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+)
+
+// ErrorCategoryRule maps the output or the exit code of a command to an error category.
+// A rule matches in case its pattern matches an output line or the exit code is one of its exit codes.
+type ErrorCategoryRule struct {
+	// Category is the error category, e.g. config or infrastructure
+	Category string `json:"category"`
+	// Pattern is a regular expression matching a single output line
+	Pattern   string `json:"pattern,omitempty"`
+	ExitCodes []int  `json:"exitCodes,omitempty"`
+	// Priority decides about the category in case several rules match, the first match wins in case of equal priorities
+	Priority int `json:"priority,omitempty"`
+	// Remediation is a hint for the user how to solve the error
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// ErrorMatch is the match of an error category rule
+type ErrorMatch struct {
+	Category    string `json:"category"`
+	Rule        string `json:"rule"`
+	Command     string `json:"command,omitempty"`
+	Line        string `json:"line,omitempty"`
+	ExitCode    int    `json:"exitCode,omitempty"`
+	Priority    int    `json:"priority"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// ErrorReport contains the error category of a step together with the matches of error category rules
+type ErrorReport struct {
+	Category string       `json:"category"`
+	Matches  []ErrorMatch `json:"matches"`
+}
+
+// maxErrorMatches limits the matches kept in the report, e.g. in case a rule matches every line of a long output
+const maxErrorMatches = 100
+
+var (
+	errorRules        []ErrorCategoryRule
+	errorMatches      []ErrorMatch
+	errorMatchesMutex sync.Mutex
+)
+
+// SetErrorCategoryRules sets the error category rules of the step, they are applied to each command executed via pkg/command
+func SetErrorCategoryRules(rules []ErrorCategoryRule) {
+	errorRules = rules
+}
+
+// GetErrorCategoryRules returns the error category rules of the step
+func GetErrorCategoryRules() []ErrorCategoryRule {
+	return errorRules
+}
+
+// AddErrorMatch records the match of an error category rule.
+// The error category is set in case the match has a higher priority than all previous matches.
+func AddErrorMatch(match ErrorMatch) {
+	errorMatchesMutex.Lock()
+	defer errorMatchesMutex.Unlock()
+
+	match.Line = MaskSecrets(match.Line)
+	highest := true
+	for _, previous := range errorMatches {
+		if previous.Priority >= match.Priority {
+			highest = false
+		}
+		if previous.Rule == match.Rule && previous.Line == match.Line && previous.ExitCode == match.ExitCode {
+			return
+		}
+	}
+	if highest {
+		SetErrorCategory(ErrorCategoryByString(match.Category))
+	}
+	if len(errorMatches) < maxErrorMatches {
+		errorMatches = append(errorMatches, match)
+	}
+}
+
+// GetErrorMatches returns the recorded matches of error category rules
+func GetErrorMatches() []ErrorMatch {
+	errorMatchesMutex.Lock()
+	defer errorMatchesMutex.Unlock()
+	return append([]ErrorMatch{}, errorMatches...)
+}
+
+// ResetErrorMatches removes all recorded matches
+func ResetErrorMatches() {
+	errorMatchesMutex.Lock()
+	defer errorMatchesMutex.Unlock()
+	errorMatches = nil
+}
+
+// WriteErrorReport writes the recorded matches as <stepName>_errorReport.json into the directory.
+// Nothing is written in case no rule matched.
+func WriteErrorReport(path string) error {
+	matches := GetErrorMatches()
+	if len(matches) == 0 {
+		return nil
+	}
+	report, err := json.Marshal(ErrorReport{Category: GetErrorCategory().String(), Matches: matches})
+	if err != nil {
+		return fmt.Errorf("failed to marshal error report: %w", err)
+	}
+
+	fileName := "errorReport.json"
+	if stepName := Entry().Data["stepName"]; stepName != nil {
+		fileName = fmt.Sprintf("%v_%v", stepName, fileName)
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, fileName), report, 0666); err != nil {
+		return fmt.Errorf("failed to write error report: %w", err)
+	}
+	return nil
+}
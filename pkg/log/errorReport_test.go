@@ -0,0 +1,91 @@
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddErrorMatch(t *testing.T) {
+	defer SetErrorCategory(ErrorUndefined)
+	defer ResetErrorMatches()
+	RegisterSecret("s3cr3t")
+
+	SetErrorCategory(ErrorUndefined)
+	ResetErrorMatches()
+
+	AddErrorMatch(ErrorMatch{Category: "build", Rule: "BUILD FAILURE", Line: "BUILD FAILURE"})
+	assert.Equal(t, ErrorBuild, GetErrorCategory())
+
+	AddErrorMatch(ErrorMatch{Category: "test", Rule: "Tests", Line: "Tests failed"})
+	assert.Equal(t, ErrorBuild, GetErrorCategory(), "equal priority must not change the category")
+
+	AddErrorMatch(ErrorMatch{Category: "infrastructure", Rule: "401", Line: "401 for token s3cr3t", Priority: 5})
+	assert.Equal(t, ErrorInfrastructure, GetErrorCategory())
+
+	AddErrorMatch(ErrorMatch{Category: "build", Rule: "BUILD FAILURE", Line: "BUILD FAILURE"})
+	assert.Equal(t, []ErrorMatch{
+		{Category: "build", Rule: "BUILD FAILURE", Line: "BUILD FAILURE"},
+		{Category: "test", Rule: "Tests", Line: "Tests failed"},
+		{Category: "infrastructure", Rule: "401", Line: "401 for token ****", Priority: 5},
+	}, GetErrorMatches())
+}
+
+func TestWriteErrorReport(t *testing.T) {
+	defer SetErrorCategory(ErrorUndefined)
+	defer ResetErrorMatches()
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("no matches", func(t *testing.T) {
+		ResetErrorMatches()
+
+		assert.NoError(t, WriteErrorReport(dir))
+
+		files, _ := ioutil.ReadDir(dir)
+		assert.Empty(t, files)
+	})
+
+	t.Run("matches", func(t *testing.T) {
+		ResetErrorMatches()
+		SetStepName("testStep")
+		defer func() { logger = nil }()
+		AddErrorMatch(ErrorMatch{Category: "config", Rule: "exitCodes [2]", Command: "mvn", ExitCode: 2, Remediation: "Check the parameters."})
+
+		assert.NoError(t, WriteErrorReport(dir))
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "testStep_errorReport.json"))
+		require.NoError(t, err)
+		report := ErrorReport{}
+		require.NoError(t, json.Unmarshal(content, &report))
+		assert.Equal(t, ErrorReport{Category: "config", Matches: []ErrorMatch{
+			{Category: "config", Rule: "exitCodes [2]", Command: "mvn", ExitCode: 2, Remediation: "Check the parameters."},
+		}}, report)
+	})
+}
+
+func TestFatalHookErrorMatches(t *testing.T) {
+	defer SetErrorCategory(ErrorUndefined)
+	defer ResetErrorMatches()
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ResetErrorMatches()
+	AddErrorMatch(ErrorMatch{Category: "service", Rule: "503", Line: "503 Service Unavailable", Remediation: "Retry later."})
+	hook := FatalHook{Path: dir}
+
+	assert.NoError(t, hook.Fire(&logrus.Entry{Data: logrus.Fields{"stepName": "testStep"}, Message: "the error message"}))
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "testStep_errorDetails.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"category":"service"`)
+	assert.Contains(t, string(content), `"errorMatches":[{"category":"service","rule":"503","line":"503 Service Unavailable","priority":0,"remediation":"Retry later."}]`)
+}
@@ -17,3 +17,9 @@ func TestGetErrorCategory(t *testing.T) {
 	errorCategory = ErrorCompliance
 	assert.Equal(t, GetErrorCategory(), errorCategory)
 }
+
+func TestErrorCategoryByString(t *testing.T) {
+	assert.Equal(t, ErrorTimeout, ErrorCategoryByString("timeout"))
+	assert.Equal(t, "timeout", ErrorTimeout.String())
+	assert.Equal(t, ErrorUndefined, ErrorCategoryByString("unknown"))
+}
@@ -23,6 +23,7 @@ func (f *FatalHook) Levels() []logrus.Level {
 }
 
 // Fire persists the error message of the fatal error as json file into the file system.
+// Matches of error category rules are attached in order to provide the triggering lines and remediation hints.
 func (f *FatalHook) Fire(entry *logrus.Entry) error {
 	details := entry.Data
 	if details == nil {
@@ -34,6 +35,9 @@ func (f *FatalHook) Fire(entry *logrus.Entry) error {
 	details["category"] = GetErrorCategory().String()
 	details["result"] = "failure"
 	details["correlationId"] = f.CorrelationID
+	if matches := GetErrorMatches(); len(matches) > 0 {
+		details["errorMatches"] = matches
+	}
 
 	fileName := "errorDetails.json"
 	if details["stepName"] != nil {
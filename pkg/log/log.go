@@ -56,11 +56,7 @@ func (formatter *PiperLogFormatter) Format(entry *logrus.Entry) (bytes []byte, e
 		message = string(formattedMessage)
 	}
 
-	for _, secret := range secrets {
-		message = strings.Replace(message, secret, "****", -1)
-	}
-
-	return []byte(message), nil
+	return []byte(MaskSecrets(message)), nil
 }
 
 // LibraryRepository that is passed into with -ldflags
@@ -111,6 +107,14 @@ func RegisterHook(hook logrus.Hook) {
 	logrus.AddHook(hook)
 }
 
+// MaskSecrets replaces all registered secrets within the message
+func MaskSecrets(message string) string {
+	for _, secret := range secrets {
+		message = strings.Replace(message, secret, "****", -1)
+	}
+	return message
+}
+
 // RegisterSecret registers a value which should be masked in every log message
 func RegisterSecret(secret string) {
 	if len(secret) > 0 {
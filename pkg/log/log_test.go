@@ -65,3 +65,9 @@ func TestWriteLargeBuffer(t *testing.T) {
 		assert.True(t, size != written)
 	})
 }
+
+func TestMaskSecrets(t *testing.T) {
+	RegisterSecret("mask-me")
+	assert.Equal(t, "token=****", MaskSecrets("token=mask-me"))
+	assert.Equal(t, "nothing to mask", MaskSecrets("nothing to mask"))
+}
@@ -3,10 +3,12 @@
 package mock
 
 import (
+	"context"
 	"io"
 	"io/ioutil"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/SAP/jenkins-library/pkg/command"
 )
@@ -15,6 +17,7 @@ type ExecMockRunner struct {
 	Dir                 []string
 	Env                 []string
 	ExitCode            int
+	Timeout             time.Duration
 	Calls               []ExecCall
 	stdin               io.Reader
 	stdout              io.Writer
@@ -38,6 +41,7 @@ type ShellMockRunner struct {
 	Dir                 string
 	Env                 []string
 	ExitCode            int
+	Timeout             time.Duration
 	Calls               []string
 	Shell               []string
 	stdin               io.Reader
@@ -69,6 +73,19 @@ func (m *ExecMockRunner) RunExecutable(e string, p ...string) error {
 	return handleCall(c, m.StdoutReturn, m.ShouldFailOnCommand, m.stdout)
 }
 
+// RunExecutableContext records the call like RunExecutable, it fails with the error of the context in case it is already done
+func (m *ExecMockRunner) RunExecutableContext(ctx context.Context, e string, p ...string) error {
+	if err := ctx.Err(); err != nil {
+		m.Calls = append(m.Calls, ExecCall{Exec: e, Params: p})
+		return err
+	}
+	return m.RunExecutable(e, p...)
+}
+
+func (m *ExecMockRunner) SetTimeout(timeout time.Duration) {
+	m.Timeout = timeout
+}
+
 func (m *ExecMockRunner) GetExitCode() int {
 	return m.ExitCode
 }
@@ -128,6 +145,20 @@ func (m *ShellMockRunner) RunShell(s string, c string) error {
 	return handleCall(c, m.StdoutReturn, m.ShouldFailOnCommand, m.stdout)
 }
 
+// RunShellContext records the call like RunShell, it fails with the error of the context in case it is already done
+func (m *ShellMockRunner) RunShellContext(ctx context.Context, s string, c string) error {
+	if err := ctx.Err(); err != nil {
+		m.Shell = append(m.Shell, s)
+		m.Calls = append(m.Calls, c)
+		return err
+	}
+	return m.RunShell(s, c)
+}
+
+func (m *ShellMockRunner) SetTimeout(timeout time.Duration) {
+	m.Timeout = timeout
+}
+
 func (m *ShellMockRunner) GetExitCode() int {
 	return m.ExitCode
 }
@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// Definition describes a pipeline which can be executed locally, consisting of stages which contain steps
+type Definition struct {
+	Stages []Stage `json:"stages"`
+}
+
+// Stage describes a stage of the pipeline. Stages are executed one after the other.
+type Stage struct {
+	Name  string `json:"name"`
+	Steps []Step `json:"steps"`
+}
+
+// Step describes the execution of a step within a stage
+type Step struct {
+	// Name is the name of the step, e.g. mavenBuild
+	Name string `json:"name"`
+	// Parameters are passed to the step in addition to the pipeline configuration
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// ReadDefinition parses a pipeline definition in YAML or JSON format
+func ReadDefinition(data []byte) (Definition, error) {
+	definition := Definition{}
+	if err := yaml.Unmarshal(data, &definition); err != nil {
+		return definition, errors.Wrap(err, "failed to parse pipeline definition")
+	}
+	if len(definition.Stages) == 0 {
+		return definition, fmt.Errorf("pipeline definition does not contain any stage")
+	}
+	stageNames := map[string]bool{}
+	for _, stage := range definition.Stages {
+		if len(stage.Name) == 0 {
+			return definition, fmt.Errorf("pipeline definition contains a stage without name")
+		}
+		if stageNames[stage.Name] {
+			return definition, fmt.Errorf("pipeline definition contains stage '%v' more than once", stage.Name)
+		}
+		stageNames[stage.Name] = true
+		stepNames := map[string]bool{}
+		for _, step := range stage.Steps {
+			if len(step.Name) == 0 {
+				return definition, fmt.Errorf("stage '%v' contains a step without name", stage.Name)
+			}
+			if stepNames[step.Name] {
+				return definition, fmt.Errorf("stage '%v' contains step '%v' more than once", stage.Name, step.Name)
+			}
+			stepNames[step.Name] = true
+		}
+	}
+	return definition, nil
+}
@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDefinition(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		definition, err := ReadDefinition([]byte(`stages:
+  - name: Build
+    steps:
+      - name: artifactPrepareVersion
+      - name: mavenBuild
+        parameters:
+          flatten: false
+  - name: Acceptance
+    steps:
+      - name: kubernetesDeploy
+`))
+		require.NoError(t, err)
+		require.Len(t, definition.Stages, 2)
+		assert.Equal(t, "Build", definition.Stages[0].Name)
+		assert.Equal(t, []Step{{Name: "artifactPrepareVersion"}, {Name: "mavenBuild", Parameters: map[string]interface{}{"flatten": false}}}, definition.Stages[0].Steps)
+		assert.Equal(t, "kubernetesDeploy", definition.Stages[1].Steps[0].Name)
+	})
+
+	tt := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "no stages", content: `stages: []`, expected: "pipeline definition does not contain any stage"},
+		{name: "stage without name", content: `stages: [{steps: []}]`, expected: "pipeline definition contains a stage without name"},
+		{name: "duplicate stage", content: `stages: [{name: Build}, {name: Build}]`, expected: "pipeline definition contains stage 'Build' more than once"},
+		{name: "step without name", content: `stages: [{name: Build, steps: [{}]}]`, expected: "stage 'Build' contains a step without name"},
+		{name: "duplicate step", content: `stages: [{name: Build, steps: [{name: mavenBuild}, {name: mavenBuild}]}]`, expected: "stage 'Build' contains step 'mavenBuild' more than once"},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadDefinition([]byte(test.content))
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		_, err := ReadDefinition([]byte(`stages: {`))
+		assert.Contains(t, err.Error(), "failed to parse pipeline definition")
+	})
+}
@@ -0,0 +1,203 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+)
+
+const piperEnvironmentType = "piperEnvironment"
+
+// Node is the execution of a step within a stage
+type Node struct {
+	// ID identifies the node within the graph in the form <stage>/<step>
+	ID    string
+	Stage string
+	Step  Step
+	// Level is the position in the execution order, nodes with the same level can run in parallel
+	Level int
+
+	dependencies []*Dependency
+	// previous contains the nodes of the previous stage which need to finish first
+	previous []*Node
+}
+
+// Dependency describes that a node consumes values which are produced by another node
+type Dependency struct {
+	Producer *Node
+	// Params contains the resource parameters in the form <resource>/<param>
+	Params []string
+}
+
+// MissingInput describes a mandatory step input which is not produced by any preceding step
+type MissingInput struct {
+	Node      *Node
+	Parameter string
+	Resource  string
+	Param     string
+}
+
+// String returns a human readable description of the missing input
+func (m MissingInput) String() string {
+	return fmt.Sprintf("%v: parameter '%v' references %v/%v which is not produced by any preceding step", m.Node.ID, m.Parameter, m.Resource, m.Param)
+}
+
+// Graph contains the dependencies of all steps of a pipeline
+type Graph struct {
+	Nodes         []*Node
+	MissingInputs []MissingInput
+}
+
+// Dependencies returns the nodes which produce values consumed by the node, sorted by ID
+func (n *Node) Dependencies() []*Dependency {
+	return n.dependencies
+}
+
+// requires returns all nodes which need to be finished before the node can be executed
+func (n *Node) requires() []*Node {
+	required := append([]*Node{}, n.previous...)
+	for _, dependency := range n.dependencies {
+		required = append(required, dependency.Producer)
+	}
+	return required
+}
+
+// NewGraph creates the dependency graph of the pipeline based on the resource references in the step metadata.
+// A step depends on the closest preceding step which writes a value of the pipeline environment the step reads.
+// Since the stages are executed one after the other, each step additionally waits for all steps of the previous stage.
+func NewGraph(definition Definition, metadata map[string]config.StepData) (*Graph, error) {
+	graph := &Graph{Nodes: []*Node{}, MissingInputs: []MissingInput{}}
+	// producers contains the nodes which write a resource parameter in definition order
+	producers := map[string][]*Node{}
+	var previousStage []*Node
+
+	for _, stage := range definition.Stages {
+		currentStage := []*Node{}
+		for _, step := range stage.Steps {
+			stepData, ok := metadata[step.Name]
+			if !ok {
+				return nil, fmt.Errorf("stage '%v' contains unknown step '%v'", stage.Name, step.Name)
+			}
+			node := &Node{ID: stage.Name + "/" + step.Name, Stage: stage.Name, Step: step, previous: previousStage}
+			graph.addDependencies(node, stepData, producers)
+			for _, output := range outputs(stepData) {
+				producers[output] = append(producers[output], node)
+			}
+			graph.Nodes = append(graph.Nodes, node)
+			currentStage = append(currentStage, node)
+		}
+		if len(currentStage) > 0 {
+			previousStage = currentStage
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		for _, required := range node.requires() {
+			if required.Level+1 > node.Level {
+				node.Level = required.Level + 1
+			}
+		}
+	}
+	return graph, nil
+}
+
+func (g *Graph) addDependencies(node *Node, stepData config.StepData, producers map[string][]*Node) {
+	dependencies := map[*Node]*Dependency{}
+	for _, param := range stepData.Spec.Inputs.Parameters {
+		for _, ref := range param.ResourceRef {
+			// references with type point to secrets or vault
+			if len(ref.Type) > 0 || len(ref.Param) == 0 {
+				continue
+			}
+			key := ref.Name + "/" + ref.Param
+			candidates := producers[key]
+			if len(candidates) == 0 {
+				if param.Mandatory {
+					g.MissingInputs = append(g.MissingInputs, MissingInput{Node: node, Parameter: param.Name, Resource: ref.Name, Param: ref.Param})
+				}
+				continue
+			}
+			producer := candidates[len(candidates)-1]
+			if dependencies[producer] == nil {
+				dependencies[producer] = &Dependency{Producer: producer}
+			}
+			dependencies[producer].Params = append(dependencies[producer].Params, key)
+		}
+	}
+	for _, dependency := range dependencies {
+		sort.Strings(dependency.Params)
+		node.dependencies = append(node.dependencies, dependency)
+	}
+	sort.Slice(node.dependencies, func(i, j int) bool { return node.dependencies[i].Producer.ID < node.dependencies[j].Producer.ID })
+}
+
+// outputs returns the parameters of the pipeline environment which a step writes in the form <resource>/<param>
+func outputs(stepData config.StepData) []string {
+	result := []string{}
+	for _, resource := range stepData.Spec.Outputs.Resources {
+		if resource.Type != piperEnvironmentType {
+			continue
+		}
+		for _, param := range resource.Parameters {
+			// the metadata compiled into the binary uses "Name" while the metadata files use "name"
+			name, ok := param["name"].(string)
+			if !ok {
+				name, ok = param["Name"].(string)
+			}
+			if ok {
+				result = append(result, resource.Name+"/"+name)
+			}
+		}
+	}
+	return result
+}
+
+// WriteText writes a textual visualization of the graph
+func (g *Graph) WriteText(w io.Writer) {
+	stage := ""
+	for _, node := range g.Nodes {
+		if node.Stage != stage {
+			stage = node.Stage
+			fmt.Fprintf(w, "stage %v\n", stage)
+		}
+		fmt.Fprintf(w, "  [%v] %v\n", node.Level, node.Step.Name)
+		for _, dependency := range node.dependencies {
+			fmt.Fprintf(w, "        <- %v (%v)\n", dependency.Producer.ID, strings.Join(dependency.Params, ", "))
+		}
+	}
+	if len(g.MissingInputs) > 0 {
+		fmt.Fprintln(w, "missing inputs:")
+		for _, missing := range g.MissingInputs {
+			fmt.Fprintf(w, "  %v\n", missing.String())
+		}
+	}
+}
+
+// WriteDot writes the graph in the DOT format of Graphviz
+func (g *Graph) WriteDot(w io.Writer) {
+	fmt.Fprintln(w, "digraph pipeline {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	stage := ""
+	for _, node := range g.Nodes {
+		if node.Stage != stage {
+			if len(stage) > 0 {
+				fmt.Fprintln(w, "  }")
+			}
+			stage = node.Stage
+			fmt.Fprintf(w, "  subgraph %q {\n    label=%q;\n", "cluster_"+stage, stage)
+		}
+		fmt.Fprintf(w, "    %q [label=%q];\n", node.ID, node.Step.Name)
+	}
+	if len(stage) > 0 {
+		fmt.Fprintln(w, "  }")
+	}
+	for _, node := range g.Nodes {
+		for _, dependency := range node.dependencies {
+			fmt.Fprintf(w, "  %q -> %q [label=%q];\n", dependency.Producer.ID, node.ID, strings.Join(dependency.Params, ", "))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
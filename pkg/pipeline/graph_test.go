@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stepData(name string, inputs []config.StepParameters, outputs ...string) config.StepData {
+	params := []map[string]interface{}{}
+	for _, output := range outputs {
+		params = append(params, map[string]interface{}{"name": output})
+	}
+	return config.StepData{
+		Metadata: config.StepMetadata{Name: name},
+		Spec: config.StepSpec{
+			Inputs: config.StepInputs{Parameters: inputs},
+			Outputs: config.StepOutputs{Resources: []config.StepResources{
+				{Name: "commonPipelineEnvironment", Type: "piperEnvironment", Parameters: params},
+				{Name: "influx", Type: "influx", Parameters: []map[string]interface{}{{"name": "step_data"}}},
+			}},
+		},
+	}
+}
+
+func cpeInput(name, param string, mandatory bool) config.StepParameters {
+	return config.StepParameters{
+		Name:        name,
+		Mandatory:   mandatory,
+		ResourceRef: []config.ResourceReference{{Name: "commonPipelineEnvironment", Param: param}},
+	}
+}
+
+func testMetadata() map[string]config.StepData {
+	return map[string]config.StepData{
+		"artifactPrepareVersion": stepData("artifactPrepareVersion", nil, "artifactVersion", "git/commitId"),
+		"mavenBuild":             stepData("mavenBuild", []config.StepParameters{cpeInput("version", "artifactVersion", false)}),
+		"kanikoExecute": stepData("kanikoExecute", []config.StepParameters{
+			cpeInput("containerImageTag", "artifactVersion", false),
+			{Name: "dockerConfigJSON", ResourceRef: []config.ResourceReference{{Name: "dockerConfigJsonCredentialsId", Type: "secret"}}},
+		}, "container/registryUrl", "container/imageNameTag"),
+		"kubernetesDeploy": stepData("kubernetesDeploy", []config.StepParameters{
+			cpeInput("containerRegistryUrl", "container/registryUrl", true),
+			cpeInput("image", "container/imageNameTag", true),
+			cpeInput("commitId", "git/commitId", false),
+		}),
+		"sonarExecuteScan": stepData("sonarExecuteScan", []config.StepParameters{cpeInput("changeId", "custom/changeId", true)}),
+	}
+}
+
+func testDefinition() Definition {
+	return Definition{Stages: []Stage{
+		{Name: "Build", Steps: []Step{{Name: "artifactPrepareVersion"}, {Name: "mavenBuild"}, {Name: "kanikoExecute"}, {Name: "sonarExecuteScan"}}},
+		{Name: "Acceptance", Steps: []Step{{Name: "kubernetesDeploy"}}},
+	}}
+}
+
+func TestNewGraph(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		graph, err := NewGraph(testDefinition(), testMetadata())
+		require.NoError(t, err)
+		require.Len(t, graph.Nodes, 5)
+
+		version, maven, kaniko, sonar, deploy := graph.Nodes[0], graph.Nodes[1], graph.Nodes[2], graph.Nodes[3], graph.Nodes[4]
+		assert.Equal(t, "Build/artifactPrepareVersion", version.ID)
+		assert.Empty(t, version.Dependencies())
+		assert.Equal(t, 0, version.Level)
+
+		require.Len(t, maven.Dependencies(), 1)
+		assert.Equal(t, version, maven.Dependencies()[0].Producer)
+		assert.Equal(t, []string{"commonPipelineEnvironment/artifactVersion"}, maven.Dependencies()[0].Params)
+		assert.Equal(t, 1, maven.Level)
+		assert.Equal(t, 1, kaniko.Level)
+		// no dependency within the stage
+		assert.Equal(t, 0, sonar.Level)
+
+		require.Len(t, deploy.Dependencies(), 2)
+		assert.Equal(t, version, deploy.Dependencies()[0].Producer)
+		assert.Equal(t, kaniko, deploy.Dependencies()[1].Producer)
+		assert.Equal(t, []string{"commonPipelineEnvironment/container/imageNameTag", "commonPipelineEnvironment/container/registryUrl"}, deploy.Dependencies()[1].Params)
+		assert.Equal(t, 2, deploy.Level)
+
+		require.Len(t, graph.MissingInputs, 1)
+		assert.Equal(t, "Build/sonarExecuteScan: parameter 'changeId' references commonPipelineEnvironment/custom/changeId which is not produced by any preceding step", graph.MissingInputs[0].String())
+	})
+
+	t.Run("producer in later stage", func(t *testing.T) {
+		definition := Definition{Stages: []Stage{
+			{Name: "Acceptance", Steps: []Step{{Name: "kubernetesDeploy"}}},
+			{Name: "Build", Steps: []Step{{Name: "kanikoExecute"}}},
+		}}
+		graph, err := NewGraph(definition, testMetadata())
+		require.NoError(t, err)
+		assert.Len(t, graph.MissingInputs, 2)
+		assert.Equal(t, 1, graph.Nodes[1].Level)
+	})
+
+	t.Run("unknown step", func(t *testing.T) {
+		_, err := NewGraph(Definition{Stages: []Stage{{Name: "Build", Steps: []Step{{Name: "notExisting"}}}}}, testMetadata())
+		assert.EqualError(t, err, "stage 'Build' contains unknown step 'notExisting'")
+	})
+}
+
+func TestGraphVisualization(t *testing.T) {
+	graph, err := NewGraph(testDefinition(), testMetadata())
+	require.NoError(t, err)
+
+	t.Run("text", func(t *testing.T) {
+		var out bytes.Buffer
+		graph.WriteText(&out)
+		assert.Equal(t, `stage Build
+  [0] artifactPrepareVersion
+  [1] mavenBuild
+        <- Build/artifactPrepareVersion (commonPipelineEnvironment/artifactVersion)
+  [1] kanikoExecute
+        <- Build/artifactPrepareVersion (commonPipelineEnvironment/artifactVersion)
+  [0] sonarExecuteScan
+stage Acceptance
+  [2] kubernetesDeploy
+        <- Build/artifactPrepareVersion (commonPipelineEnvironment/git/commitId)
+        <- Build/kanikoExecute (commonPipelineEnvironment/container/imageNameTag, commonPipelineEnvironment/container/registryUrl)
+missing inputs:
+  Build/sonarExecuteScan: parameter 'changeId' references commonPipelineEnvironment/custom/changeId which is not produced by any preceding step
+`, out.String())
+	})
+
+	t.Run("dot", func(t *testing.T) {
+		var out bytes.Buffer
+		graph.WriteDot(&out)
+		assert.Contains(t, out.String(), "digraph pipeline {")
+		assert.Contains(t, out.String(), `subgraph "cluster_Build" {`)
+		assert.Contains(t, out.String(), `"Build/artifactPrepareVersion" [label="artifactPrepareVersion"];`)
+		assert.Contains(t, out.String(), `"Build/artifactPrepareVersion" -> "Build/mavenBuild" [label="commonPipelineEnvironment/artifactVersion"];`)
+	})
+}
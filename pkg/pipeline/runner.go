@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Result describes the outcome of the execution of a node
+type Result struct {
+	Node *Node
+	// Err is set in case the step failed
+	Err error
+	// Skipped indicates that the step has not been executed since a preceding step failed
+	Skipped bool
+}
+
+type nodeState int
+
+const (
+	pending nodeState = iota
+	running
+	succeeded
+	failed
+)
+
+// Run executes all nodes of the graph. Nodes are started as soon as all nodes they depend on have finished successfully,
+// at most maxParallel nodes are executed at the same time.
+// After a failure no further nodes are started, the results of all nodes are returned in the order of the graph.
+func (g *Graph) Run(maxParallel int, execute func(node *Node) error) ([]Result, error) {
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+
+	type finished struct {
+		node *Node
+		err  error
+	}
+	done := make(chan finished)
+	states := map[*Node]nodeState{}
+	errs := map[*Node]error{}
+	active := 0
+	failure := false
+
+	ready := func(node *Node) bool {
+		for _, required := range node.requires() {
+			if states[required] != succeeded {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		if !failure {
+			for _, node := range g.Nodes {
+				if active >= maxParallel {
+					break
+				}
+				if states[node] != pending || !ready(node) {
+					continue
+				}
+				states[node] = running
+				active++
+				go func(node *Node) {
+					done <- finished{node: node, err: execute(node)}
+				}(node)
+			}
+		}
+		if active == 0 {
+			break
+		}
+		result := <-done
+		active--
+		states[result.node] = succeeded
+		if result.err != nil {
+			states[result.node] = failed
+			errs[result.node] = result.err
+			failure = true
+		}
+	}
+
+	results := []Result{}
+	failedSteps := []string{}
+	for _, node := range g.Nodes {
+		results = append(results, Result{Node: node, Err: errs[node], Skipped: states[node] == pending})
+		if states[node] == failed {
+			failedSteps = append(failedSteps, node.ID)
+		}
+	}
+	if len(failedSteps) > 0 {
+		return results, fmt.Errorf("pipeline failed, steps with errors: %v", strings.Join(failedSteps, ", "))
+	}
+	return results, nil
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphRun(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		graph, err := NewGraph(testDefinition(), testMetadata())
+		require.NoError(t, err)
+
+		var mutex sync.Mutex
+		finished := map[string]bool{}
+		active, maxActive := 0, 0
+		results, err := graph.Run(4, func(node *Node) error {
+			mutex.Lock()
+			for _, required := range node.requires() {
+				assert.True(t, finished[required.ID], "%v started before %v finished", node.ID, required.ID)
+			}
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mutex.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mutex.Lock()
+			active--
+			finished[node.ID] = true
+			mutex.Unlock()
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, results, 5)
+		assert.Len(t, finished, 5)
+		// artifactPrepareVersion & sonarExecuteScan as well as mavenBuild & kanikoExecute run in parallel
+		assert.Equal(t, 2, maxActive)
+	})
+
+	t.Run("limited parallelism", func(t *testing.T) {
+		graph, err := NewGraph(testDefinition(), testMetadata())
+		require.NoError(t, err)
+
+		var mutex sync.Mutex
+		active, maxActive := 0, 0
+		_, err = graph.Run(0, func(node *Node) error {
+			mutex.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mutex.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mutex.Lock()
+			active--
+			mutex.Unlock()
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, maxActive)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		graph, err := NewGraph(testDefinition(), testMetadata())
+		require.NoError(t, err)
+
+		results, err := graph.Run(1, func(node *Node) error {
+			if node.Step.Name == "mavenBuild" {
+				return fmt.Errorf("build failed")
+			}
+			return nil
+		})
+		assert.EqualError(t, err, "pipeline failed, steps with errors: Build/mavenBuild")
+		require.Len(t, results, 5)
+		assert.False(t, results[0].Skipped)
+		assert.NoError(t, results[0].Err)
+		assert.EqualError(t, results[1].Err, "build failed")
+		assert.True(t, results[4].Skipped)
+	})
+}
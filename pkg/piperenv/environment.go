@@ -1,35 +1,22 @@
 package piperenv
 
 import (
-	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/SAP/jenkins-library/pkg/log"
-	"github.com/pkg/errors"
 )
 
 // This file contains functions used to read/write pipeline environment data from/to disk.
 // The content of a written file is the value. For the custom parameters this could for example also be a JSON representation of a more complex value.
 
 // SetResourceParameter sets a resource parameter in the environment stored in the file system
+// The change is recorded in the history of the resource, see Store.
 func SetResourceParameter(path, resourceName, paramName string, value interface{}) error {
-	var content []byte
-	paramPath := filepath.Join(path, resourceName, paramName)
-	switch typedValue := value.(type) {
-	case string:
-		content = []byte(typedValue)
-	default:
-		var err error
-		paramPath += ".json"
-		content, err = json.Marshal(typedValue)
-		if err != nil {
-			return errors.Wrapf(err, "failed to marshal resource parameter value %v", typedValue)
-		}
-	}
-	return writeToDisk(paramPath, content)
+	store := NewStore(filepath.Join(path, resourceName), currentStepName())
+	return store.Set(filepath.ToSlash(paramName), value)
 }
 
 // GetResourceParameter reads a resource parameter from the environment stored in the file system
@@ -51,6 +38,14 @@ func GetParameter(path, name string) string {
 	return readFromDisk(paramPath)
 }
 
+// currentStepName returns the name of the step which is currently executed
+func currentStepName() string {
+	if stepName, ok := log.Entry().Data["stepName"].(string); ok {
+		return stepName
+	}
+	return ""
+}
+
 func writeToDisk(filename string, data []byte) error {
 
 	if _, err := os.Stat(filepath.Dir(filename)); os.IsNotExist(err) {
@@ -58,16 +53,14 @@ func writeToDisk(filename string, data []byte) error {
 		os.MkdirAll(filepath.Dir(filename), 0777)
 	}
 
-	//ToDo: make sure to not overwrite file but rather add another file? Create error if already existing?
 	if len(data) > 0 {
 		log.Entry().Debugf("Writing file to disk: %v", filename)
-		return ioutil.WriteFile(filename, data, 0766)
+		return writeFileAtomic(filename, data)
 	}
 	return nil
 }
 
 func readFromDisk(filename string) string {
-	//ToDo: if multiple files exist, read from latest file
 	log.Entry().Debugf("Reading file from disk: %v", filename)
 	v, err := ioutil.ReadFile(filename)
 	val := string(v)
@@ -0,0 +1,98 @@
+package piperenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Export defines the JSON representation of a resource of the pipeline environment
+type Export struct {
+	Entries []Entry  `json:"entries"`
+	History []Change `json:"history,omitempty"`
+}
+
+// Difference describes how a value differs between two environments
+type Difference struct {
+	Key  string      `json:"key"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+	// Kind is one of "added", "removed" or "changed"
+	Kind string `json:"kind"`
+}
+
+// String returns a human readable representation of the difference
+func (d Difference) String() string {
+	switch d.Kind {
+	case "added":
+		return fmt.Sprintf("+ %v: %v", d.Key, formatValue(d.To))
+	case "removed":
+		return fmt.Sprintf("- %v: %v", d.Key, formatValue(d.From))
+	}
+	return fmt.Sprintf("~ %v: %v -> %v", d.Key, formatValue(d.From), formatValue(d.To))
+}
+
+// Export returns all values of the store together with the complete history
+func (s *Store) Export() (Export, error) {
+	entries, err := s.Entries()
+	if err != nil {
+		return Export{}, err
+	}
+	history, err := s.History("")
+	if err != nil {
+		return Export{}, err
+	}
+	return Export{Entries: entries, History: history}, nil
+}
+
+// ReadExport parses an environment which has been exported in JSON format
+func ReadExport(data []byte) (Export, error) {
+	export := Export{}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return export, errors.Wrap(err, "failed to parse pipeline environment export")
+	}
+	return export, nil
+}
+
+// Diff returns the differences between the values of two environments sorted by key
+func Diff(from, to []Entry) []Difference {
+	fromValues := map[string]interface{}{}
+	for _, entry := range from {
+		fromValues[entry.Key] = entry.Value
+	}
+	toValues := map[string]interface{}{}
+	for _, entry := range to {
+		toValues[entry.Key] = entry.Value
+	}
+
+	differences := []Difference{}
+	for key, fromValue := range fromValues {
+		toValue, ok := toValues[key]
+		if !ok {
+			differences = append(differences, Difference{Key: key, From: fromValue, Kind: "removed"})
+		} else if !reflect.DeepEqual(fromValue, toValue) {
+			differences = append(differences, Difference{Key: key, From: fromValue, To: toValue, Kind: "changed"})
+		}
+	}
+	for key, toValue := range toValues {
+		if _, ok := fromValues[key]; !ok {
+			differences = append(differences, Difference{Key: key, To: toValue, Kind: "added"})
+		}
+	}
+	sort.Slice(differences, func(i, j int) bool { return differences[i].Key < differences[j].Key })
+	return differences
+}
+
+func formatValue(value interface{}) string {
+	if stringValue, ok := value.(string); ok {
+		return stringValue
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
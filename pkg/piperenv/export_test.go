@@ -0,0 +1,53 @@
+package piperenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	from := []Entry{
+		{Key: "artifactVersion", Value: "1.0.0"},
+		{Key: "custom/images", Value: []interface{}{"a"}},
+		{Key: "git/branch", Value: "main"},
+	}
+	to := []Entry{
+		{Key: "artifactVersion", Value: "1.0.1"},
+		{Key: "custom/images", Value: []interface{}{"a"}},
+		{Key: "git/commitId", Value: "abc"},
+	}
+
+	differences := Diff(from, to)
+	require.Len(t, differences, 3)
+	assert.Equal(t, "~ artifactVersion: 1.0.0 -> 1.0.1", differences[0].String())
+	assert.Equal(t, "- git/branch: main", differences[1].String())
+	assert.Equal(t, "+ git/commitId: abc", differences[2].String())
+
+	assert.Empty(t, Diff(from, from))
+}
+
+func TestExport(t *testing.T) {
+	store, cleanup := newTestStore(t, "stepA")
+	defer cleanup()
+	require.NoError(t, store.Set("artifactVersion", "1.0.0"))
+	require.NoError(t, store.Set("custom/images", []string{"a"}))
+
+	export, err := store.Export()
+	require.NoError(t, err)
+	assert.Len(t, export.Entries, 2)
+	assert.Len(t, export.History, 2)
+
+	t.Run("read export", func(t *testing.T) {
+		export, err := ReadExport([]byte(`{"entries":[{"key":"artifactVersion","value":"1.0.0","version":1}]}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []Entry{{Key: "artifactVersion", Value: "1.0.0", Version: 1}}, export.Entries)
+		assert.Empty(t, Diff(export.Entries, []Entry{{Key: "artifactVersion", Value: "1.0.0"}}))
+	})
+
+	t.Run("invalid export", func(t *testing.T) {
+		_, err := ReadExport([]byte(`{`))
+		assert.Contains(t, err.Error(), "failed to parse pipeline environment export")
+	})
+}
@@ -0,0 +1,78 @@
+package piperenv
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	lockRetryInterval = 50 * time.Millisecond
+	// a lock which is older is considered to be left over by a process which has been killed
+	staleLockAge = 2 * time.Minute
+)
+
+// fileLock is a simple cross-platform lock based on the exclusive creation of a lock file
+type fileLock struct {
+	path string
+}
+
+func acquireLock(path string, timeout time.Duration) (*fileLock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+		if err == nil {
+			fmt.Fprintf(file, "%v", os.Getpid())
+			file.Close()
+			return &fileLock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Wrapf(err, "failed to create lock file %v", path)
+		}
+		if isStaleLock(path) && removeStaleLock(path) {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout after %v while waiting for lock file %v", timeout, path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func isStaleLock(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) > staleLockAge
+}
+
+// removeStaleLock removes the lock file in case it is stale and returns true if it has been removed.
+// Checking and removing are guarded by a second lock file, otherwise two processes could detect the same stale lock
+// and the slower one would remove the lock which has been created by the faster one in the meantime.
+func removeStaleLock(path string) bool {
+	breakPath := path + ".break"
+	file, err := os.OpenFile(breakPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+	if err != nil {
+		if isStaleLock(breakPath) {
+			// left over by a process which has been killed while removing a stale lock
+			os.Remove(breakPath)
+		}
+		return false
+	}
+	file.Close()
+	defer os.Remove(breakPath)
+
+	// the lock file might have been replaced since the first check
+	if !isStaleLock(path) {
+		return false
+	}
+	log.Entry().Warningf("removing stale lock file %v", path)
+	return os.Remove(path) == nil
+}
+
+func (l *fileLock) release() {
+	if err := os.Remove(l.path); err != nil {
+		log.Entry().WithError(err).Warningf("failed to remove lock file %v", l.path)
+	}
+}
@@ -0,0 +1,434 @@
+package piperenv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	historyFileName = ".history.jsonl"
+	indexFileName   = ".versions.json"
+	lockFileName    = ".lock"
+	jsonSuffix      = ".json"
+)
+
+// processStartTime and processID identify the writes of the current piper execution
+// in order to detect changes which other executions did in the meantime
+var processStartTime = time.Now()
+var processID = uuid.New().String()
+
+// Store provides versioned and typed access to the values of one resource of the pipeline environment
+// (e.g. the commonPipelineEnvironment). Every value is stored in a dedicated file, as before.
+// In addition all changes are recorded in a history file within the resource directory,
+// an index file keeps the latest change per key. Values are recorded with registered secrets masked.
+// Writes are atomic and protected by a lock file.
+type Store struct {
+	// Path is the directory of the resource, e.g. .pipeline/commonPipelineEnvironment
+	Path string
+	// StepName is recorded as author of all changes
+	StepName string
+	// Since defines from which point in time on changes of other executions are considered as conflicts, defaults to the start of the process
+	Since time.Time
+	// FailOnConflict defines if conflicting changes are rejected, otherwise they are only logged and marked in the history
+	FailOnConflict bool
+	// LockTimeout defines how long to wait for a lock held by another execution
+	LockTimeout time.Duration
+
+	writerID string
+	now      func() time.Time
+}
+
+// Change describes one change of a value in the history of a store
+type Change struct {
+	Key       string    `json:"key"`
+	Version   int       `json:"version"`
+	Value     string    `json:"value"`
+	StepName  string    `json:"stepName,omitempty"`
+	WriterID  string    `json:"writerId,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Conflict  bool      `json:"conflict,omitempty"`
+}
+
+// Entry describes the current state of a value in a store
+type Entry struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	// Version is 0 for values which have been written without history, e.g. by older versions of piper
+	Version  int       `json:"version"`
+	StepName string    `json:"stepName,omitempty"`
+	Modified time.Time `json:"modified,omitempty"`
+}
+
+// versionIndex contains the latest change of every key in order to avoid reading the complete history on every write
+type versionIndex struct {
+	// HistorySize is the size of the history file the index belongs to, a different size indicates an outdated index
+	HistorySize int64             `json:"historySize"`
+	Latest      map[string]Change `json:"latest"`
+}
+
+// ConflictError indicates that a value has been changed concurrently
+type ConflictError struct {
+	Key             string
+	Version         int
+	ExpectedVersion int
+	StepName        string
+}
+
+func (e *ConflictError) Error() string {
+	if e.ExpectedVersion >= 0 {
+		return fmt.Sprintf("conflicting change of '%v': expected version %v but found version %v written by step '%v'", e.Key, e.ExpectedVersion, e.Version, e.StepName)
+	}
+	return fmt.Sprintf("conflicting change of '%v': version %v has been written by step '%v' in the meantime", e.Key, e.Version, e.StepName)
+}
+
+// NewStore creates a store for the resource located in the given directory
+func NewStore(path, stepName string) *Store {
+	return &Store{
+		Path:        path,
+		StepName:    stepName,
+		Since:       processStartTime,
+		LockTimeout: 30 * time.Second,
+		writerID:    processID,
+		now:         time.Now,
+	}
+}
+
+// Set sets the value of the given key. Strings are stored as they are, all other values in JSON format.
+// Empty strings are ignored.
+func (s *Store) Set(key string, value interface{}) error {
+	return s.set(key, value, -1)
+}
+
+// CompareAndSet sets the value of the given key only if the current version of the value matches the expected version.
+// Otherwise a ConflictError is returned.
+func (s *Store) CompareAndSet(key string, value interface{}, expectedVersion int) error {
+	return s.set(key, value, expectedVersion)
+}
+
+// GetString returns the raw value of the given key, an empty string is returned if the key does not exist
+func (s *Store) GetString(key string) string {
+	content, _, _ := s.read(key)
+	return strings.TrimSpace(string(content))
+}
+
+// GetValue unmarshals the value of the given key into the target
+func (s *Store) GetValue(key string, target interface{}) error {
+	content, isJSON, exists := s.read(key)
+	if !exists {
+		return fmt.Errorf("value '%v' does not exist", key)
+	}
+	if !isJSON {
+		// plain strings are stored without JSON encoding
+		content, _ = json.Marshal(strings.TrimSpace(string(content)))
+	}
+	if err := json.Unmarshal(content, target); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal value '%v'", key)
+	}
+	return nil
+}
+
+// GetBool returns the value of the given key as bool
+func (s *Store) GetBool(key string) (bool, error) {
+	value := s.GetString(key)
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Wrapf(err, "value '%v' is not a bool", key)
+	}
+	return result, nil
+}
+
+// GetInt returns the value of the given key as int
+func (s *Store) GetInt(key string) (int, error) {
+	value := s.GetString(key)
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "value '%v' is not an int", key)
+	}
+	return result, nil
+}
+
+// Version returns the current version of the given key, 0 indicates that no change has been recorded yet
+func (s *Store) Version(key string) (int, error) {
+	index, err := s.index()
+	if err != nil {
+		return 0, err
+	}
+	return index.Latest[key].Version, nil
+}
+
+// History returns all recorded changes of the given key, all changes are returned for an empty key
+func (s *Store) History(key string) ([]Change, error) {
+	content, err := ioutil.ReadFile(filepath.Join(s.Path, historyFileName))
+	if os.IsNotExist(err) {
+		return []Change{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read history")
+	}
+	changes := []Change{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		change := Change{}
+		if err := json.Unmarshal([]byte(line), &change); err != nil {
+			return nil, errors.Wrap(err, "failed to parse history")
+		}
+		if len(key) == 0 || change.Key == key {
+			changes = append(changes, change)
+		}
+	}
+	return changes, scanner.Err()
+}
+
+// Entries returns the current state of all values of the store sorted by key
+func (s *Store) Entries() ([]Entry, error) {
+	index, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+	latest := index.Latest
+
+	entries := []Entry{}
+	err = filepath.Walk(s.Path, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == s.Path {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		// skip internal files like the history, the lock and temporary files
+		if strings.HasPrefix(info.Name(), ".") && path != s.Path {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relativePath, _ := filepath.Rel(s.Path, path)
+		key := filepath.ToSlash(relativePath)
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		entry := Entry{Key: key, Value: strings.TrimSpace(string(content))}
+		if strings.HasSuffix(key, jsonSuffix) {
+			entry.Key = strings.TrimSuffix(key, jsonSuffix)
+			var value interface{}
+			if err := json.Unmarshal(content, &value); err == nil {
+				entry.Value = value
+			}
+		}
+		if change, ok := latest[entry.Key]; ok {
+			entry.Version = change.Version
+			entry.StepName = change.StepName
+			entry.Modified = change.Timestamp
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read pipeline environment %v", s.Path)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+func (s *Store) set(key string, value interface{}, expectedVersion int) error {
+	content, isJSON, err := encodeValue(value)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal value of '%v'", key)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.Path, 0777); err != nil {
+		return errors.Wrapf(err, "failed to create directory %v", s.Path)
+	}
+	lock, err := acquireLock(filepath.Join(s.Path, lockFileName), s.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	index, err := s.index()
+	if err != nil {
+		return err
+	}
+	latest, ok := index.Latest[key]
+	if !ok {
+		latest = Change{Key: key}
+	}
+
+	if expectedVersion >= 0 && latest.Version != expectedVersion {
+		return &ConflictError{Key: key, Version: latest.Version, ExpectedVersion: expectedVersion, StepName: latest.StepName}
+	}
+	if current, currentIsJSON, exists := s.read(key); exists && currentIsJSON == isJSON && bytes.Equal(current, content) {
+		log.Entry().Debugf("value of '%v' is unchanged", key)
+		return nil
+	}
+
+	change := Change{
+		Key:       key,
+		Version:   latest.Version + 1,
+		Value:     string(content),
+		StepName:  s.StepName,
+		WriterID:  s.writerID,
+		Timestamp: s.now(),
+	}
+	if len(latest.WriterID) > 0 && latest.WriterID != s.writerID && latest.Timestamp.After(s.Since) {
+		conflictErr := &ConflictError{Key: key, Version: latest.Version, ExpectedVersion: -1, StepName: latest.StepName}
+		if s.FailOnConflict {
+			return conflictErr
+		}
+		log.Entry().Warningf("%v, overwriting it", conflictErr)
+		change.Conflict = true
+	}
+
+	fileName := filepath.Join(s.Path, filepath.FromSlash(key))
+	otherFileName := fileName + jsonSuffix
+	if isJSON {
+		fileName, otherFileName = otherFileName, fileName
+	}
+	if err := writeFileAtomic(fileName, content); err != nil {
+		return err
+	}
+	// a value might change its type, make sure that only the latest one remains
+	if err := os.Remove(otherFileName); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove outdated file %v", otherFileName)
+	}
+	if err := s.appendHistory(change); err != nil {
+		return err
+	}
+	return s.updateIndex(index, change)
+}
+
+// read returns the raw content of the given key and whether it is stored in JSON format
+func (s *Store) read(key string) ([]byte, bool, bool) {
+	fileName := filepath.Join(s.Path, filepath.FromSlash(key))
+	if content, err := ioutil.ReadFile(fileName + jsonSuffix); err == nil {
+		return content, true, true
+	}
+	if content, err := ioutil.ReadFile(fileName); err == nil {
+		return content, false, true
+	}
+	return nil, false, false
+}
+
+// index returns the latest change of every key, the index is rebuilt from the history in case it is missing or outdated
+func (s *Store) index() (versionIndex, error) {
+	index := versionIndex{Latest: map[string]Change{}}
+	info, err := os.Stat(filepath.Join(s.Path, historyFileName))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return index, errors.Wrap(err, "failed to read history")
+	}
+
+	if content, err := ioutil.ReadFile(filepath.Join(s.Path, indexFileName)); err == nil {
+		current := versionIndex{}
+		if err := json.Unmarshal(content, &current); err == nil && current.HistorySize == info.Size() && current.Latest != nil {
+			return current, nil
+		}
+	}
+	log.Entry().Debugf("rebuilding the version index of %v", s.Path)
+	history, err := s.History("")
+	if err != nil {
+		return index, err
+	}
+	for _, change := range history {
+		change.Value = ""
+		index.Latest[change.Key] = change
+	}
+	index.HistorySize = info.Size()
+	return index, nil
+}
+
+func (s *Store) updateIndex(index versionIndex, change Change) error {
+	info, err := os.Stat(filepath.Join(s.Path, historyFileName))
+	if err != nil {
+		return errors.Wrap(err, "failed to read history")
+	}
+	change.Value = ""
+	index.Latest[change.Key] = change
+	index.HistorySize = info.Size()
+	content, err := json.Marshal(index)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal version index")
+	}
+	return writeFileAtomic(filepath.Join(s.Path, indexFileName), content)
+}
+
+func (s *Store) appendHistory(change Change) error {
+	// the history is part of the export of the environment, thus secrets must not be recorded in plain text
+	change.Value = log.MaskSecrets(change.Value)
+	line, err := json.Marshal(change)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal history")
+	}
+	historyFile, err := os.OpenFile(filepath.Join(s.Path, historyFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return errors.Wrap(err, "failed to open history")
+	}
+	defer historyFile.Close()
+	if _, err := historyFile.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write history")
+	}
+	return nil
+}
+
+func encodeValue(value interface{}) ([]byte, bool, error) {
+	if typedValue, ok := value.(string); ok {
+		return []byte(typedValue), false, nil
+	}
+	content, err := json.Marshal(value)
+	return content, true, err
+}
+
+// writeFileAtomic writes to a temporary file first and renames it afterwards
+// in order to make sure that readers never see partially written content
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return errors.Wrapf(err, "failed to create directory %v", dir)
+	}
+	tmpFile, err := ioutil.TempFile(dir, "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file for %v", filename)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return errors.Wrapf(err, "failed to write %v", filename)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %v", filename)
+	}
+	if err := os.Chmod(tmpFile.Name(), 0766); err != nil {
+		return errors.Wrapf(err, "failed to set permissions of %v", filename)
+	}
+	if err := os.Rename(tmpFile.Name(), filename); err != nil {
+		return errors.Wrapf(err, "failed to write %v", filename)
+	}
+	return nil
+}
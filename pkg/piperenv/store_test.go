@@ -0,0 +1,313 @@
+package piperenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, stepName string) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	store := NewStore(filepath.Join(dir, "commonPipelineEnvironment"), stepName)
+	return store, func() { os.RemoveAll(dir) }
+}
+
+func TestStoreSetAndGet(t *testing.T) {
+	store, cleanup := newTestStore(t, "artifactPrepareVersion")
+	defer cleanup()
+
+	require.NoError(t, store.Set("artifactVersion", "1.2.3"))
+	require.NoError(t, store.Set("custom/isRelease", true))
+	require.NoError(t, store.Set("custom/buildNumber", 42))
+	require.NoError(t, store.Set("custom/images", []string{"a", "b"}))
+	require.NoError(t, store.Set("custom/empty", ""))
+
+	assert.Equal(t, "1.2.3", store.GetString("artifactVersion"))
+	isRelease, err := store.GetBool("custom/isRelease")
+	assert.NoError(t, err)
+	assert.True(t, isRelease)
+	buildNumber, err := store.GetInt("custom/buildNumber")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, buildNumber)
+	images := []string{}
+	assert.NoError(t, store.GetValue("custom/images", &images))
+	assert.Equal(t, []string{"a", "b"}, images)
+	version := ""
+	assert.NoError(t, store.GetValue("artifactVersion", &version))
+	assert.Equal(t, "1.2.3", version)
+
+	// files remain compatible with GetResourceParameter
+	assert.Equal(t, "1.2.3", GetResourceParameter(filepath.Dir(store.Path), "commonPipelineEnvironment", "artifactVersion"))
+	assert.Equal(t, "true", GetResourceParameter(filepath.Dir(store.Path), "commonPipelineEnvironment", "custom/isRelease.json"))
+	assert.NoFileExists(t, filepath.Join(store.Path, "custom", "empty"))
+
+	_, err = store.GetInt("artifactVersion")
+	assert.EqualError(t, err, "value 'artifactVersion' is not an int: strconv.Atoi: parsing \"1.2.3\": invalid syntax")
+	assert.EqualError(t, store.GetValue("notExisting", &version), "value 'notExisting' does not exist")
+}
+
+func TestStoreHistory(t *testing.T) {
+	t.Run("versions", func(t *testing.T) {
+		store, cleanup := newTestStore(t, "artifactPrepareVersion")
+		defer cleanup()
+
+		require.NoError(t, store.Set("artifactVersion", "1.0.0"))
+		// unchanged values are not recorded
+		require.NoError(t, store.Set("artifactVersion", "1.0.0"))
+		store.StepName = "mavenBuild"
+		require.NoError(t, store.Set("artifactVersion", "1.0.1"))
+		require.NoError(t, store.Set("git/commitId", "abc"))
+
+		history, err := store.History("artifactVersion")
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, 1, history[0].Version)
+		assert.Equal(t, "artifactPrepareVersion", history[0].StepName)
+		assert.Equal(t, "1.0.0", history[0].Value)
+		assert.Equal(t, 2, history[1].Version)
+		assert.Equal(t, "mavenBuild", history[1].StepName)
+		assert.False(t, history[1].Conflict)
+
+		all, err := store.History("")
+		require.NoError(t, err)
+		assert.Len(t, all, 3)
+
+		version, err := store.Version("artifactVersion")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version)
+		version, err = store.Version("notExisting")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, version)
+	})
+
+	t.Run("type change", func(t *testing.T) {
+		store, cleanup := newTestStore(t, "")
+		defer cleanup()
+
+		require.NoError(t, store.Set("custom/value", "abc"))
+		require.NoError(t, store.Set("custom/value", []string{"abc"}))
+		assert.NoFileExists(t, filepath.Join(store.Path, "custom", "value"))
+		assert.FileExists(t, filepath.Join(store.Path, "custom", "value.json"))
+
+		version, err := store.Version("custom/value")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version)
+	})
+}
+
+func TestStoreVersionIndex(t *testing.T) {
+	t.Run("index is kept up to date", func(t *testing.T) {
+		store, cleanup := newTestStore(t, "stepA")
+		defer cleanup()
+
+		require.NoError(t, store.Set("custom/value", "a"))
+		require.NoError(t, store.Set("custom/value", "b"))
+		assert.FileExists(t, filepath.Join(store.Path, indexFileName))
+
+		index, err := store.index()
+		require.NoError(t, err)
+		assert.Equal(t, 2, index.Latest["custom/value"].Version)
+		assert.Equal(t, "stepA", index.Latest["custom/value"].StepName)
+		assert.Empty(t, index.Latest["custom/value"].Value)
+	})
+
+	t.Run("missing or outdated index", func(t *testing.T) {
+		store, cleanup := newTestStore(t, "stepA")
+		defer cleanup()
+
+		require.NoError(t, store.Set("custom/value", "a"))
+		require.NoError(t, os.Remove(filepath.Join(store.Path, indexFileName)))
+		require.NoError(t, store.Set("custom/value", "b"))
+		version, err := store.Version("custom/value")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version)
+
+		// e.g. a history which has been restored without the index
+		content, err := ioutil.ReadFile(filepath.Join(store.Path, indexFileName))
+		require.NoError(t, err)
+		require.NoError(t, store.Set("custom/value", "c"))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(store.Path, indexFileName), content, 0666))
+		require.NoError(t, store.Set("custom/value", "d"))
+		version, err = store.Version("custom/value")
+		assert.NoError(t, err)
+		assert.Equal(t, 4, version)
+	})
+}
+
+func TestStoreMasksSecretsInHistory(t *testing.T) {
+	store, cleanup := newTestStore(t, "stepA")
+	defer cleanup()
+	log.RegisterSecret("storeTestSecret")
+
+	require.NoError(t, store.Set("custom/token", "storeTestSecret"))
+
+	assert.Equal(t, "storeTestSecret", store.GetString("custom/token"))
+	history, err := store.History("custom/token")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "****", history[0].Value)
+}
+
+func TestStoreConflicts(t *testing.T) {
+	t.Run("compare and set", func(t *testing.T) {
+		store, cleanup := newTestStore(t, "stepA")
+		defer cleanup()
+
+		require.NoError(t, store.CompareAndSet("custom/value", "a", 0))
+		require.NoError(t, store.CompareAndSet("custom/value", "b", 1))
+
+		err := store.CompareAndSet("custom/value", "c", 1)
+		assert.EqualError(t, err, "conflicting change of 'custom/value': expected version 1 but found version 2 written by step 'stepA'")
+		assert.IsType(t, &ConflictError{}, err)
+		assert.Equal(t, "b", store.GetString("custom/value"))
+	})
+
+	t.Run("change by other execution", func(t *testing.T) {
+		store, cleanup := newTestStore(t, "stepA")
+		defer cleanup()
+		require.NoError(t, store.Set("custom/value", "a"))
+
+		other := NewStore(store.Path, "stepB")
+		other.writerID = "otherExecution"
+		other.Since = time.Now().Add(-time.Hour)
+		require.NoError(t, other.Set("custom/value", "b"))
+
+		history, err := other.History("custom/value")
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.True(t, history[1].Conflict)
+		assert.Equal(t, "b", other.GetString("custom/value"))
+
+		store.writerID = "thirdExecution"
+		store.Since = time.Now().Add(-time.Hour)
+		store.FailOnConflict = true
+		err = store.Set("custom/value", "c")
+		assert.EqualError(t, err, "conflicting change of 'custom/value': version 2 has been written by step 'stepB' in the meantime")
+		assert.Equal(t, "b", store.GetString("custom/value"))
+	})
+
+	t.Run("change before start", func(t *testing.T) {
+		store, cleanup := newTestStore(t, "stepA")
+		defer cleanup()
+		store.writerID = "previousExecution"
+		require.NoError(t, store.Set("custom/value", "a"))
+
+		next := NewStore(store.Path, "stepB")
+		next.FailOnConflict = true
+		next.Since = time.Now().Add(time.Hour)
+		assert.NoError(t, next.Set("custom/value", "b"))
+	})
+}
+
+func TestStoreEntries(t *testing.T) {
+	store, cleanup := newTestStore(t, "stepA")
+	defer cleanup()
+
+	entries, err := store.Entries()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, store.Set("artifactVersion", "1.0.0"))
+	require.NoError(t, store.Set("custom/images", []string{"a"}))
+	// values written without history, e.g. by the Groovy part of the library
+	require.NoError(t, os.MkdirAll(filepath.Join(store.Path, "git"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(store.Path, "git", "branch"), []byte("main"), 0666))
+
+	entries, err = store.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "artifactVersion", entries[0].Key)
+	assert.Equal(t, "1.0.0", entries[0].Value)
+	assert.Equal(t, 1, entries[0].Version)
+	assert.Equal(t, "stepA", entries[0].StepName)
+	assert.Equal(t, "custom/images", entries[1].Key)
+	assert.Equal(t, []interface{}{"a"}, entries[1].Value)
+	assert.Equal(t, "git/branch", entries[2].Key)
+	assert.Equal(t, 0, entries[2].Version)
+}
+
+func TestAcquireLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	lockFile := filepath.Join(dir, lockFileName)
+
+	lock, err := acquireLock(lockFile, time.Second)
+	require.NoError(t, err)
+
+	_, err = acquireLock(lockFile, 100*time.Millisecond)
+	assert.Contains(t, err.Error(), "timeout after 100ms while waiting for lock file")
+
+	lock.release()
+	lock, err = acquireLock(lockFile, time.Second)
+	assert.NoError(t, err)
+
+	// stale locks are removed
+	staleTime := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(lockFile, staleTime, staleTime))
+	lock, err = acquireLock(lockFile, 100*time.Millisecond)
+	assert.NoError(t, err)
+	lock.release()
+	assert.NoFileExists(t, lockFile)
+	assert.NoFileExists(t, lockFile+".break")
+
+	// a lock which has been replaced after it has been detected as stale is not removed
+	lock, err = acquireLock(lockFile, time.Second)
+	require.NoError(t, err)
+	assert.False(t, removeStaleLock(lockFile))
+	assert.FileExists(t, lockFile)
+
+	// a stale lock is not removed while another process removes it
+	require.NoError(t, os.Chtimes(lockFile, staleTime, staleTime))
+	require.NoError(t, ioutil.WriteFile(lockFile+".break", []byte{}, 0666))
+	assert.False(t, removeStaleLock(lockFile))
+	assert.FileExists(t, lockFile)
+	require.NoError(t, os.Remove(lockFile+".break"))
+	assert.True(t, removeStaleLock(lockFile))
+	assert.NoFileExists(t, lockFile)
+}
+
+func TestAcquireLockConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	lockFile := filepath.Join(dir, lockFileName)
+	require.NoError(t, ioutil.WriteFile(lockFile, []byte{}, 0666))
+	staleTime := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(lockFile, staleTime, staleTime))
+
+	// all processes detect the stale lock, still only one may hold the lock at a time
+	var holders, maxHolders int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := acquireLock(lockFile, 5*time.Second)
+			if !assert.NoError(t, err) {
+				return
+			}
+			current := atomic.AddInt32(&holders, 1)
+			for {
+				max := atomic.LoadInt32(&maxHolders)
+				if current <= max || atomic.CompareAndSwapInt32(&maxHolders, max, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&holders, -1)
+			lock.release()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), maxHolders)
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/SAP/jenkins-library/pkg/reporting"
 )
 
 //ReportData is representing the data of the step report JSON
@@ -43,6 +45,47 @@ func WriteReport(data ReportData, reportPath string, reportFileName string, resu
 	return writeJSON(reportPath, reportFileName, data, writeToFile)
 }
 
+// WriteSarif writes the relevant vulnerabilities of the scan result as SARIF file
+// Only exact matches which are neither excluded nor triaged are considered.
+func WriteSarif(result Result, excludeCVEs string, reportPath string, reportFileName string, writeToFile func(f string, d []byte, p os.FileMode) error) error {
+	report := reporting.FindingsReport{
+		StepName:       "protecodeExecuteScan",
+		ToolName:       "Protecode",
+		InformationURI: "https://www.synopsys.com/software-integrity/security-testing/software-composition-analysis.html",
+		Successful:     result.Status != statusFailed,
+		Findings:       []reporting.Finding{},
+	}
+	for _, component := range result.Components {
+		for _, vulnerability := range component.Vulns {
+			if !isExact(vulnerability) || isExcluded(vulnerability, excludeCVEs) || isTriaged(vulnerability) {
+				continue
+			}
+			componentName := reporting.ComponentName(component.Lib)
+			score, _ := strconv.ParseFloat(vulnerability.Vuln.Cvss3Score, 64)
+			if score == 0 {
+				// CVSS v3 not set, fallback to CVSS v2
+				score = vulnerability.Vuln.Cvss
+			}
+			report.AddFinding(reporting.Finding{
+				RuleID:           vulnerability.Vuln.Cve,
+				RuleName:         vulnerability.Vuln.Cve,
+				Message:          fmt.Sprintf("%v in %v %v", vulnerability.Vuln.Cve, componentName, component.Version),
+				Severity:         reporting.SeverityFromCVSS(score),
+				Score:            score,
+				VulnerabilityID:  vulnerability.Vuln.Cve,
+				Component:        componentName,
+				ComponentVersion: component.Version,
+			})
+		}
+	}
+
+	sarif, err := report.ToSARIF()
+	if err != nil {
+		return err
+	}
+	return writeToFile(filepath.Join(reportPath, reportFileName), sarif, 0644)
+}
+
 func writeJSON(path, name string, data interface{}, writeToFile func(f string, d []byte, p os.FileMode) error) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
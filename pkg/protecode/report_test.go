@@ -29,3 +29,27 @@ func TestWriteReport(t *testing.T) {
 	assert.Equal(t, fileContent, expected, "content should be not empty")
 	assert.NoError(t, err)
 }
+
+func TestWriteSarif(t *testing.T) {
+	result := Result{Status: statusReady, Components: []Component{
+		{Lib: "openssl", Version: "1.0.2", Vulns: []Vulnerability{
+			{Exact: true, Vuln: Vuln{Cve: "CVE-2021-0001", Cvss: 5.0, Cvss3Score: "9.8"}},
+			{Exact: true, Vuln: Vuln{Cve: "CVE-2021-0002", Cvss: 7.5, Cvss3Score: "0"}},
+			{Exact: true, Vuln: Vuln{Cve: "CVE-2021-0003", Cvss3Score: "8.0"}, Triage: []Triage{{ID: 1}}},
+			{Exact: true, Vuln: Vuln{Cve: "CVE-2021-0004", Cvss3Score: "8.0"}},
+			{Exact: false, Vuln: Vuln{Cve: "CVE-2021-0005", Cvss3Score: "8.0"}},
+		}},
+	}}
+
+	err := WriteSarif(result, "CVE-2021-0004", ".", "protecode.sarif", writeToFileMock)
+
+	assert.NoError(t, err)
+	assert.Contains(t, fileContent, `"ruleId": "CVE-2021-0001"`)
+	assert.Contains(t, fileContent, `"security-severity": "9.8"`)
+	assert.Contains(t, fileContent, `"ruleId": "CVE-2021-0002"`)
+	assert.Contains(t, fileContent, `"security-severity": "7.5"`)
+	assert.Contains(t, fileContent, `"component": "openssl"`)
+	assert.NotContains(t, fileContent, "CVE-2021-0003")
+	assert.NotContains(t, fileContent, "CVE-2021-0004")
+	assert.NotContains(t, fileContent, "CVE-2021-0005")
+}
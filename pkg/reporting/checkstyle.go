@@ -0,0 +1,50 @@
+package reporting
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// checkstyle format as e.g. provided by hadolint or by the ABAP ATC API
+type checkstyleResult struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     string `xml:"line,attr"`
+	Column   string `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// FindingsFromCheckstyle converts a report in checkstyle XML format into findings
+func FindingsFromCheckstyle(data []byte) ([]Finding, error) {
+	findings := []Finding{}
+	result := checkstyleResult{}
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return findings, errors.Wrap(err, "failed to parse checkstyle report")
+	}
+	for _, file := range result.Files {
+		for _, checkstyleError := range file.Errors {
+			// line and column are optional, thus ignore conversion errors
+			line, _ := strconv.Atoi(checkstyleError.Line)
+			column, _ := strconv.Atoi(checkstyleError.Column)
+			findings = append(findings, Finding{
+				RuleID:   checkstyleError.Source,
+				Message:  checkstyleError.Message,
+				Severity: ParseSeverity(checkstyleError.Severity),
+				Location: FindingLocation{File: file.Name, StartLine: line, StartColumn: column},
+			})
+		}
+	}
+	return findings, nil
+}
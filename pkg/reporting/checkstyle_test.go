@@ -0,0 +1,44 @@
+package reporting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindingsFromCheckstyle(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		report := `<?xml version='1.0' encoding='UTF-8'?>
+<checkstyle version='4.3'>
+	<file name='Dockerfile'>
+		<error line='3' column='1' severity='warning' message='Pin versions in apt get install.' source='DL3008' />
+		<error line='5' column='1' severity='info' message='Delete the apt-get lists after installing something' source='DL3009' />
+	</file>
+	<file name='src/ZCL_TEST.abap'>
+		<error line='10' severity='error' message='Syntax error' source='SYNTAX_CHECK' />
+	</file>
+</checkstyle>`
+
+		findings, err := FindingsFromCheckstyle([]byte(report))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Finding{
+			{RuleID: "DL3008", Message: "Pin versions in apt get install.", Severity: SeverityMedium, Location: FindingLocation{File: "Dockerfile", StartLine: 3, StartColumn: 1}},
+			{RuleID: "DL3009", Message: "Delete the apt-get lists after installing something", Severity: SeverityInfo, Location: FindingLocation{File: "Dockerfile", StartLine: 5, StartColumn: 1}},
+			{RuleID: "SYNTAX_CHECK", Message: "Syntax error", Severity: SeverityHigh, Location: FindingLocation{File: "src/ZCL_TEST.abap", StartLine: 10}},
+		}, findings)
+	})
+
+	t.Run("no findings", func(t *testing.T) {
+		findings, err := FindingsFromCheckstyle([]byte(`<checkstyle version='4.3'></checkstyle>`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Finding{}, findings)
+	})
+
+	t.Run("invalid report", func(t *testing.T) {
+		_, err := FindingsFromCheckstyle([]byte(`not xml`))
+
+		assert.EqualError(t, err, "failed to parse checkstyle report: EOF")
+	})
+}
@@ -0,0 +1,129 @@
+package reporting
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Severity defines the tool-independent severity of a finding
+type Severity string
+
+// severities of findings, ordered from most to least severe
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+	SeverityUnknown  Severity = "unknown"
+)
+
+// Severities contains all known severities ordered from most to least severe
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo, SeverityUnknown}
+
+// SeverityFromCVSS maps a CVSS score to a severity according to the CVSS v3 qualitative severity rating scale
+func SeverityFromCVSS(score float64) Severity {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+// ParseSeverity maps the severity naming of the various scan tools to a severity
+func ParseSeverity(severity string) Severity {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "blocker", "urgent":
+		return SeverityCritical
+	case "high", "error", "major":
+		return SeverityHigh
+	case "medium", "warning", "minor":
+		return SeverityMedium
+	case "low", "style":
+		return SeverityLow
+	case "info", "information", "informational", "note":
+		return SeverityInfo
+	}
+	return SeverityUnknown
+}
+
+// ComponentName returns the name of a component in the naming scheme which is shared by all scan tools:
+// the lower case package name without namespace, group and version.
+// Besides plain names it accepts package URLs like pkg:maven/org.apache.commons/commons-text@1.9
+// and Maven coordinates like org.apache.commons:commons-text:1.9.
+func ComponentName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strings.HasPrefix(name, "pkg:") {
+		name = strings.SplitN(strings.SplitN(name, "?", 2)[0], "#", 2)[0]
+		name = strings.SplitN(name, "@", 2)[0]
+		return name[strings.LastIndex(name, "/")+1:]
+	}
+	if parts := strings.Split(name, ":"); len(parts) >= 2 {
+		return parts[1]
+	}
+	return name
+}
+
+// FindingLocation defines where a finding has been detected
+type FindingLocation struct {
+	File        string `json:"file,omitempty"`
+	StartLine   int    `json:"startLine,omitempty"`
+	StartColumn int    `json:"startColumn,omitempty"`
+	EndLine     int    `json:"endLine,omitempty"`
+}
+
+// Finding defines a single finding of a scan tool in a tool-independent way.
+// Components are named according to the scheme of ComponentName in order to allow matching findings across tools.
+type Finding struct {
+	RuleID           string          `json:"ruleId"`
+	RuleName         string          `json:"ruleName,omitempty"`
+	Message          string          `json:"message"`
+	Severity         Severity        `json:"severity"`
+	Score            float64         `json:"score,omitempty"`
+	VulnerabilityID  string          `json:"vulnerabilityId,omitempty"`
+	Component        string          `json:"component,omitempty"`
+	ComponentVersion string          `json:"componentVersion,omitempty"`
+	Location         FindingLocation `json:"location,omitempty"`
+	HelpURI          string          `json:"helpUri,omitempty"`
+	Audited          bool            `json:"audited,omitempty"`
+}
+
+// FindingsReport contains all findings of one scan tool execution
+// Findings being nil indicates that the tool does not provide details about its findings,
+// an empty list indicates that the tool did not detect any findings.
+type FindingsReport struct {
+	StepName       string    `json:"stepName"`
+	ToolName       string    `json:"toolName"`
+	ToolVersion    string    `json:"toolVersion,omitempty"`
+	InformationURI string    `json:"informationUri,omitempty"`
+	Successful     bool      `json:"successful"`
+	Findings       []Finding `json:"findings"`
+}
+
+// AddFinding adds a finding to the report
+func (f *FindingsReport) AddFinding(finding Finding) {
+	if len(finding.Severity) == 0 {
+		finding.Severity = SeverityUnknown
+	}
+	f.Findings = append(f.Findings, finding)
+}
+
+// CountBySeverity returns the number of findings per severity
+func (f *FindingsReport) CountBySeverity() map[Severity]int {
+	counts := map[Severity]int{}
+	for _, finding := range f.Findings {
+		counts[finding.Severity]++
+	}
+	return counts
+}
+
+// ToJSON returns the findings report in JSON format
+func (f *FindingsReport) ToJSON() ([]byte, error) {
+	return json.Marshal(f)
+}
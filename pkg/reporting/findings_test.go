@@ -0,0 +1,54 @@
+package reporting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverityFromCVSS(t *testing.T) {
+	assert.Equal(t, SeverityCritical, SeverityFromCVSS(9.8))
+	assert.Equal(t, SeverityHigh, SeverityFromCVSS(7.0))
+	assert.Equal(t, SeverityMedium, SeverityFromCVSS(5.3))
+	assert.Equal(t, SeverityLow, SeverityFromCVSS(0.1))
+	assert.Equal(t, SeverityUnknown, SeverityFromCVSS(0))
+}
+
+func TestParseSeverity(t *testing.T) {
+	assert.Equal(t, SeverityCritical, ParseSeverity("BLOCKER"))
+	assert.Equal(t, SeverityHigh, ParseSeverity("High"))
+	assert.Equal(t, SeverityHigh, ParseSeverity("error"))
+	assert.Equal(t, SeverityMedium, ParseSeverity("warning"))
+	assert.Equal(t, SeverityLow, ParseSeverity("low"))
+	assert.Equal(t, SeverityInfo, ParseSeverity("Information"))
+	assert.Equal(t, SeverityUnknown, ParseSeverity("something"))
+}
+
+func TestFindingsReport(t *testing.T) {
+	t.Run("add finding and count", func(t *testing.T) {
+		report := FindingsReport{ToolName: "tool"}
+		report.AddFinding(Finding{RuleID: "rule1", Severity: SeverityHigh})
+		report.AddFinding(Finding{RuleID: "rule2", Severity: SeverityHigh})
+		report.AddFinding(Finding{RuleID: "rule3"})
+
+		assert.Equal(t, SeverityUnknown, report.Findings[2].Severity)
+		assert.Equal(t, map[Severity]int{SeverityHigh: 2, SeverityUnknown: 1}, report.CountBySeverity())
+	})
+
+	t.Run("to JSON", func(t *testing.T) {
+		report := FindingsReport{StepName: "step", ToolName: "tool", Successful: true, Findings: []Finding{{RuleID: "rule1", Message: "message", Severity: SeverityLow}}}
+
+		res, err := report.ToJSON()
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"stepName":"step","toolName":"tool","successful":true,"findings":[{"ruleId":"rule1","message":"message","severity":"low","location":{}}]}`, string(res))
+	})
+}
+
+func TestComponentName(t *testing.T) {
+	assert.Equal(t, "commons-text", ComponentName("commons-text"))
+	assert.Equal(t, "commons-text", ComponentName(" Commons-Text "))
+	assert.Equal(t, "commons-text", ComponentName("org.apache.commons:commons-text:1.9"))
+	assert.Equal(t, "commons-text", ComponentName("pkg:maven/org.apache.commons/commons-text@1.9?type=jar"))
+	assert.Equal(t, "lodash", ComponentName("pkg:npm/lodash@4.17.20"))
+}
@@ -0,0 +1,286 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SARIF specification: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// SARIF defines the top-level structure of a SARIF 2.1.0 log file
+type SARIF struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+// SarifRun defines a single run of a scan tool
+// Results being nil indicates that no results have been determined by the tool
+type SarifRun struct {
+	Tool        SarifTool         `json:"tool"`
+	Invocations []SarifInvocation `json:"invocations,omitempty"`
+	Results     *[]SarifResult    `json:"results,omitempty"`
+	Properties  map[string]string `json:"properties,omitempty"`
+}
+
+// SarifTool describes the scan tool which produced the results
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+// SarifDriver describes the main component of the scan tool
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SarifRule `json:"rules,omitempty"`
+}
+
+// SarifRule describes a rule which has been violated by one or more results
+type SarifRule struct {
+	ID               string               `json:"id"`
+	Name             string               `json:"name,omitempty"`
+	ShortDescription *SarifMessage        `json:"shortDescription,omitempty"`
+	HelpURI          string               `json:"helpUri,omitempty"`
+	Properties       *SarifRuleProperties `json:"properties,omitempty"`
+}
+
+// SarifRuleProperties contains additional properties of a rule
+// security-severity is evaluated by GitHub code scanning to classify security findings
+type SarifRuleProperties struct {
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+}
+
+// SarifInvocation describes the invocation of the scan tool
+type SarifInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+// SarifResult describes a single result (finding) of a scan
+type SarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    SarifMessage           `json:"message"`
+	Locations  []SarifLocation        `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SarifMessage defines a plain text message
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+// SarifLocation defines the location of a result
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// SarifPhysicalLocation defines the file and the region within the file of a result
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+
+// SarifArtifactLocation defines the location of a file
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SarifRegion defines a region within a file
+type SarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+}
+
+// SarifLevel maps a severity to the corresponding SARIF result level
+func SarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium, SeverityUnknown:
+		return "warning"
+	}
+	return "note"
+}
+
+// SarifFileName returns the name of the SARIF file which is written next to the given report file
+func SarifFileName(reportFile string) string {
+	return strings.TrimSuffix(reportFile, filepath.Ext(reportFile)) + ".sarif"
+}
+
+// ToSARIF creates a SARIF 2.1.0 version of the findings report
+func (f *FindingsReport) ToSARIF() ([]byte, error) {
+	driver := SarifDriver{
+		Name:           f.ToolName,
+		Version:        f.ToolVersion,
+		InformationURI: f.InformationURI,
+	}
+	run := SarifRun{
+		Invocations: []SarifInvocation{{ExecutionSuccessful: f.Successful}},
+	}
+	if len(f.StepName) > 0 {
+		run.Properties = map[string]string{"stepName": f.StepName}
+	}
+
+	if f.Findings != nil {
+		results := []SarifResult{}
+		ruleIndex := map[string]int{}
+		for _, finding := range f.Findings {
+			index, known := ruleIndex[finding.RuleID]
+			if !known {
+				index = len(driver.Rules)
+				ruleIndex[finding.RuleID] = index
+				driver.Rules = append(driver.Rules, sarifRule(finding))
+			}
+			results = append(results, sarifResult(finding, index))
+		}
+		run.Results = &results
+	}
+	run.Tool = SarifTool{Driver: driver}
+
+	sarif := SARIF{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []SarifRun{run},
+	}
+	// URIs and messages are written without HTML escaping in order to keep them readable
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sarif); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func sarifRule(finding Finding) SarifRule {
+	rule := SarifRule{
+		ID:      finding.RuleID,
+		Name:    finding.RuleName,
+		HelpURI: finding.HelpURI,
+	}
+	if len(finding.RuleName) > 0 {
+		rule.ShortDescription = &SarifMessage{Text: finding.RuleName}
+	}
+	if finding.Score > 0 || len(finding.VulnerabilityID) > 0 {
+		rule.Properties = &SarifRuleProperties{Tags: []string{"security"}}
+		if finding.Score > 0 {
+			rule.Properties.SecuritySeverity = fmt.Sprintf("%.1f", finding.Score)
+		}
+	}
+	return rule
+}
+
+func sarifResult(finding Finding, ruleIndex int) SarifResult {
+	result := SarifResult{
+		RuleID:    finding.RuleID,
+		RuleIndex: ruleIndex,
+		Level:     SarifLevel(finding.Severity),
+		Message:   SarifMessage{Text: finding.Message},
+		Properties: map[string]interface{}{
+			"severity": string(finding.Severity),
+		},
+	}
+	if len(finding.Location.File) > 0 {
+		location := SarifLocation{PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: SarifArtifactLocation{URI: finding.Location.File}}}
+		if finding.Location.StartLine > 0 {
+			location.PhysicalLocation.Region = &SarifRegion{
+				StartLine:   finding.Location.StartLine,
+				StartColumn: finding.Location.StartColumn,
+				EndLine:     finding.Location.EndLine,
+			}
+		}
+		result.Locations = []SarifLocation{location}
+	}
+	if len(finding.VulnerabilityID) > 0 {
+		result.Properties["vulnerabilityId"] = finding.VulnerabilityID
+	}
+	if len(finding.Component) > 0 {
+		result.Properties["component"] = finding.Component
+	}
+	if len(finding.ComponentVersion) > 0 {
+		result.Properties["componentVersion"] = finding.ComponentVersion
+	}
+	if finding.Audited {
+		result.Properties["audited"] = true
+	}
+	return result
+}
+
+// FindingsFromSARIF creates findings reports from the runs contained in a SARIF file
+func FindingsFromSARIF(data []byte) ([]FindingsReport, error) {
+	sarif := SARIF{}
+	if err := json.Unmarshal(data, &sarif); err != nil {
+		return nil, fmt.Errorf("failed to parse SARIF report: %w", err)
+	}
+
+	reports := []FindingsReport{}
+	for _, run := range sarif.Runs {
+		report := FindingsReport{
+			StepName:       run.Properties["stepName"],
+			ToolName:       run.Tool.Driver.Name,
+			ToolVersion:    run.Tool.Driver.Version,
+			InformationURI: run.Tool.Driver.InformationURI,
+			Successful:     true,
+		}
+		for _, invocation := range run.Invocations {
+			report.Successful = report.Successful && invocation.ExecutionSuccessful
+		}
+		if run.Results != nil {
+			report.Findings = []Finding{}
+			for _, result := range *run.Results {
+				report.AddFinding(findingFromSarifResult(result, run.Tool.Driver.Rules))
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func findingFromSarifResult(result SarifResult, rules []SarifRule) Finding {
+	finding := Finding{
+		RuleID:  result.RuleID,
+		Message: result.Message.Text,
+	}
+	if result.RuleIndex >= 0 && result.RuleIndex < len(rules) && rules[result.RuleIndex].ID == result.RuleID {
+		rule := rules[result.RuleIndex]
+		finding.RuleName = rule.Name
+		finding.HelpURI = rule.HelpURI
+		if rule.Properties != nil {
+			finding.Score, _ = strconv.ParseFloat(rule.Properties.SecuritySeverity, 64)
+		}
+	}
+	if len(result.Locations) > 0 {
+		physicalLocation := result.Locations[0].PhysicalLocation
+		finding.Location.File = physicalLocation.ArtifactLocation.URI
+		if physicalLocation.Region != nil {
+			finding.Location.StartLine = physicalLocation.Region.StartLine
+			finding.Location.StartColumn = physicalLocation.Region.StartColumn
+			finding.Location.EndLine = physicalLocation.Region.EndLine
+		}
+	}
+
+	// the severity property is only available for SARIF files written by ToSARIF, fall back to the level otherwise
+	if severity, ok := result.Properties["severity"].(string); ok {
+		finding.Severity = ParseSeverity(severity)
+	} else {
+		finding.Severity = ParseSeverity(result.Level)
+	}
+	finding.VulnerabilityID, _ = result.Properties["vulnerabilityId"].(string)
+	finding.Component, _ = result.Properties["component"].(string)
+	finding.ComponentVersion, _ = result.Properties["componentVersion"].(string)
+	finding.Audited, _ = result.Properties["audited"].(bool)
+	return finding
+}
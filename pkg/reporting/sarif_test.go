@@ -0,0 +1,143 @@
+package reporting
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSarifFileName(t *testing.T) {
+	assert.Equal(t, "hadolint.sarif", SarifFileName("hadolint.xml"))
+	assert.Equal(t, "target/CxSASTResults_1.sarif", SarifFileName("target/CxSASTResults_1.xml"))
+	assert.Equal(t, "report.sarif", SarifFileName("report"))
+}
+
+func TestSarifLevel(t *testing.T) {
+	assert.Equal(t, "error", SarifLevel(SeverityCritical))
+	assert.Equal(t, "error", SarifLevel(SeverityHigh))
+	assert.Equal(t, "warning", SarifLevel(SeverityMedium))
+	assert.Equal(t, "warning", SarifLevel(SeverityUnknown))
+	assert.Equal(t, "note", SarifLevel(SeverityLow))
+	assert.Equal(t, "note", SarifLevel(SeverityInfo))
+}
+
+func TestToSARIF(t *testing.T) {
+	t.Run("with findings", func(t *testing.T) {
+		report := FindingsReport{
+			StepName:       "testStep",
+			ToolName:       "testTool",
+			ToolVersion:    "1.0",
+			InformationURI: "https://example.org",
+			Successful:     true,
+			Findings: []Finding{
+				{RuleID: "DL3008", RuleName: "Pin versions", Message: "Pin versions in apt get install", Severity: SeverityMedium, Location: FindingLocation{File: "Dockerfile", StartLine: 3, StartColumn: 1}},
+				{RuleID: "CVE-2021-1234", Message: "vulnerable library", Severity: SeverityCritical, Score: 9.8, VulnerabilityID: "CVE-2021-1234", Component: "lib", ComponentVersion: "1.2.3", Location: FindingLocation{File: "lib.jar"}},
+				{RuleID: "DL3008", Message: "Pin versions in apt get install", Severity: SeverityMedium, Location: FindingLocation{File: "Dockerfile", StartLine: 7}},
+			},
+		}
+
+		res, err := report.ToSARIF()
+		require.NoError(t, err)
+
+		sarif := SARIF{}
+		require.NoError(t, json.Unmarshal(res, &sarif))
+		assert.Equal(t, "2.1.0", sarif.Version)
+		assert.Equal(t, "https://json.schemastore.org/sarif-2.1.0.json", sarif.Schema)
+		require.Len(t, sarif.Runs, 1)
+		run := sarif.Runs[0]
+		assert.Equal(t, "testTool", run.Tool.Driver.Name)
+		assert.Equal(t, "1.0", run.Tool.Driver.Version)
+		assert.Equal(t, "https://example.org", run.Tool.Driver.InformationURI)
+		assert.Equal(t, map[string]string{"stepName": "testStep"}, run.Properties)
+		assert.Equal(t, []SarifInvocation{{ExecutionSuccessful: true}}, run.Invocations)
+
+		require.Len(t, run.Tool.Driver.Rules, 2)
+		assert.Equal(t, SarifRule{ID: "DL3008", Name: "Pin versions", ShortDescription: &SarifMessage{Text: "Pin versions"}}, run.Tool.Driver.Rules[0])
+		assert.Equal(t, SarifRule{ID: "CVE-2021-1234", Properties: &SarifRuleProperties{SecuritySeverity: "9.8", Tags: []string{"security"}}}, run.Tool.Driver.Rules[1])
+
+		require.NotNil(t, run.Results)
+		results := *run.Results
+		require.Len(t, results, 3)
+		assert.Equal(t, "DL3008", results[0].RuleID)
+		assert.Equal(t, 0, results[0].RuleIndex)
+		assert.Equal(t, "warning", results[0].Level)
+		assert.Equal(t, "Pin versions in apt get install", results[0].Message.Text)
+		assert.Equal(t, []SarifLocation{{PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: SarifArtifactLocation{URI: "Dockerfile"}, Region: &SarifRegion{StartLine: 3, StartColumn: 1}}}}, results[0].Locations)
+		assert.Equal(t, 1, results[1].RuleIndex)
+		assert.Equal(t, "error", results[1].Level)
+		assert.Nil(t, results[1].Locations[0].PhysicalLocation.Region)
+		assert.Equal(t, map[string]interface{}{"severity": "critical", "vulnerabilityId": "CVE-2021-1234", "component": "lib", "componentVersion": "1.2.3"}, results[1].Properties)
+		assert.Equal(t, 0, results[2].RuleIndex)
+	})
+
+	t.Run("without findings", func(t *testing.T) {
+		report := FindingsReport{ToolName: "testTool", Findings: []Finding{}}
+
+		res, err := report.ToSARIF()
+
+		assert.NoError(t, err)
+		assert.Contains(t, string(res), `"results": []`)
+	})
+
+	t.Run("findings not available", func(t *testing.T) {
+		report := FindingsReport{ToolName: "testTool"}
+
+		res, err := report.ToSARIF()
+
+		assert.NoError(t, err)
+		assert.NotContains(t, string(res), `"results"`)
+		assert.Contains(t, string(res), `"executionSuccessful": false`)
+	})
+}
+
+func TestFindingsFromSARIF(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		report := FindingsReport{
+			StepName:   "testStep",
+			ToolName:   "testTool",
+			Successful: true,
+			Findings: []Finding{
+				{RuleID: "CVE-2021-1234", RuleName: "CVE-2021-1234", Message: "vulnerable library", Severity: SeverityCritical, Score: 9.8, VulnerabilityID: "CVE-2021-1234", Component: "lib", ComponentVersion: "1.2.3", Location: FindingLocation{File: "lib.jar"}},
+				{RuleID: "DL3008", Message: "Pin versions", Severity: SeverityMedium, Location: FindingLocation{File: "Dockerfile", StartLine: 7, StartColumn: 2, EndLine: 8}, Audited: true},
+			},
+		}
+		sarif, err := report.ToSARIF()
+		require.NoError(t, err)
+
+		reports, err := FindingsFromSARIF(sarif)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []FindingsReport{report}, reports)
+	})
+
+	t.Run("without results", func(t *testing.T) {
+		report := FindingsReport{StepName: "testStep", ToolName: "testTool", Successful: false}
+		sarif, err := report.ToSARIF()
+		require.NoError(t, err)
+
+		reports, err := FindingsFromSARIF(sarif)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []FindingsReport{report}, reports)
+	})
+
+	t.Run("foreign SARIF file", func(t *testing.T) {
+		sarif := `{"runs":[{"tool":{"driver":{"name":"otherTool"}},"results":[{"ruleId":"R1","level":"error","message":{"text":"finding"}}]}]}`
+
+		reports, err := FindingsFromSARIF([]byte(sarif))
+
+		assert.NoError(t, err)
+		if assert.Len(t, reports, 1) {
+			assert.True(t, reports[0].Successful)
+			assert.Equal(t, []Finding{{RuleID: "R1", Message: "finding", Severity: SeverityHigh}}, reports[0].Findings)
+		}
+	})
+
+	t.Run("invalid SARIF file", func(t *testing.T) {
+		_, err := FindingsFromSARIF([]byte("{"))
+
+		assert.Contains(t, err.Error(), "failed to parse SARIF report")
+	})
+}
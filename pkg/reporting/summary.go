@@ -0,0 +1,271 @@
+package reporting
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+// ScanSummary defines the aggregated result of all scans of a pipeline run
+type ScanSummary struct {
+	Scans      []ScanSummaryEntry `json:"scans"`
+	Totals     map[Severity]int   `json:"totals"`
+	Duplicates int                `json:"duplicates"`
+	Findings   []SummaryFinding   `json:"findings"`
+	Compliant  bool               `json:"compliant"`
+	Violations []string           `json:"policyViolations,omitempty"`
+	ReportTime time.Time          `json:"reportTime"`
+}
+
+// ScanSummaryEntry defines the result of a single scan
+type ScanSummaryEntry struct {
+	StepName   string           `json:"stepName"`
+	ToolName   string           `json:"toolName"`
+	Successful bool             `json:"successful"`
+	Counts     map[Severity]int `json:"counts"`
+	// FindingsAvailable is false in case the tool does not provide details about its findings
+	FindingsAvailable bool `json:"findingsAvailable"`
+}
+
+// SummaryFinding defines a finding together with all tools which reported it
+type SummaryFinding struct {
+	Finding
+	Tools []string `json:"tools"`
+}
+
+// ScanPolicy defines the limits which the aggregated scan results need to satisfy
+type ScanPolicy struct {
+	// MaxFindings defines the maximum number of findings per severity across all tools, severities not contained are not limited
+	MaxFindings map[Severity]int `json:"maxFindings,omitempty"`
+	// MaxTotalFindings defines the maximum number of findings across all severities, a negative value disables the check
+	MaxTotalFindings *int `json:"maxTotalFindings,omitempty"`
+	// FailOnUnsuccessfulScan defines if a scan which did not finish successfully violates the policy
+	FailOnUnsuccessfulScan bool `json:"failOnUnsuccessfulScan,omitempty"`
+	// IgnoreAudited defines if findings which have already been audited are excluded from the limits
+	IgnoreAudited bool `json:"ignoreAudited,omitempty"`
+}
+
+// ParseScanPolicy parses a scan policy in YAML or JSON format
+func ParseScanPolicy(data []byte) (ScanPolicy, error) {
+	policy := ScanPolicy{}
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse scan policy: %w", err)
+	}
+	for severity := range policy.MaxFindings {
+		if ParseSeverity(string(severity)) != severity {
+			return policy, fmt.Errorf("failed to parse scan policy: unknown severity '%v', supported are %v", severity, Severities)
+		}
+	}
+	return policy, nil
+}
+
+// NewScanSummary aggregates the findings reports of all scans.
+// Findings with the same vulnerability ID (e.g. a CVE) in the same component are only counted once,
+// even if they are reported by multiple tools.
+func NewScanSummary(reports []FindingsReport) ScanSummary {
+	summary := ScanSummary{
+		Scans:     []ScanSummaryEntry{},
+		Totals:    map[Severity]int{},
+		Findings:  []SummaryFinding{},
+		Compliant: true,
+	}
+
+	known := map[string]int{}
+	for _, report := range reports {
+		summary.Scans = append(summary.Scans, ScanSummaryEntry{
+			StepName:          report.StepName,
+			ToolName:          report.ToolName,
+			Successful:        report.Successful,
+			Counts:            report.CountBySeverity(),
+			FindingsAvailable: report.Findings != nil,
+		})
+		for _, finding := range report.Findings {
+			key := deduplicationKey(finding)
+			if index, ok := known[key]; ok {
+				summary.Duplicates++
+				summary.Findings[index].merge(finding, report.ToolName)
+				continue
+			}
+			if len(key) > 0 {
+				known[key] = len(summary.Findings)
+			}
+			summary.Findings = append(summary.Findings, SummaryFinding{Finding: finding, Tools: []string{report.ToolName}})
+		}
+	}
+
+	for _, finding := range summary.Findings {
+		summary.Totals[finding.Severity]++
+	}
+
+	// sort according to severity, most severe findings first
+	sort.SliceStable(summary.Findings, func(i, j int) bool {
+		return severityRank(summary.Findings[i].Severity) < severityRank(summary.Findings[j].Severity)
+	})
+	return summary
+}
+
+// ApplyPolicy checks the summary against the policy and records all violations
+func (s *ScanSummary) ApplyPolicy(policy ScanPolicy) {
+	counts := map[Severity]int{}
+	total := 0
+	for _, finding := range s.Findings {
+		if policy.IgnoreAudited && finding.Audited {
+			continue
+		}
+		counts[finding.Severity]++
+		total++
+	}
+
+	violations := []string{}
+	for _, severity := range Severities {
+		if limit, ok := policy.MaxFindings[severity]; ok && counts[severity] > limit {
+			violations = append(violations, fmt.Sprintf("%v %v findings (max. %v)", counts[severity], severity, limit))
+		}
+	}
+	if policy.MaxTotalFindings != nil && *policy.MaxTotalFindings >= 0 && total > *policy.MaxTotalFindings {
+		violations = append(violations, fmt.Sprintf("%v findings in total (max. %v)", total, *policy.MaxTotalFindings))
+	}
+	if policy.FailOnUnsuccessfulScan {
+		for _, scan := range s.Scans {
+			if !scan.Successful {
+				violations = append(violations, fmt.Sprintf("scan %v (%v) was not successful", scan.ToolName, scan.StepName))
+			}
+		}
+	}
+
+	s.Violations = violations
+	s.Compliant = len(violations) == 0
+}
+
+// ToJSON returns the summary in JSON format
+func (s *ScanSummary) ToJSON() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// ToScanReport converts the summary into a scan report which can be rendered as HTML or Markdown
+func (s *ScanSummary) ToScanReport() ScanReport {
+	verdict := OverviewRow{Description: "Compliance verdict", Details: "compliant", Style: Green}
+	if !s.Compliant {
+		verdict = OverviewRow{Description: "Compliance verdict", Details: "not compliant", Style: Red}
+	}
+	scanReport := ScanReport{
+		StepName:       "pipelineCreateScanSummary",
+		Title:          "Scan Summary",
+		Overview:       []OverviewRow{verdict},
+		ReportTime:     s.ReportTime,
+		SuccessfulScan: s.Compliant,
+	}
+	for _, violation := range s.Violations {
+		scanReport.Overview = append(scanReport.Overview, OverviewRow{Description: "Policy violation", Details: violation, Style: Red})
+	}
+	for _, severity := range Severities {
+		scanReport.Overview = append(scanReport.Overview, OverviewRow{Description: fmt.Sprintf("Number of %v findings", severity), Details: fmt.Sprint(s.Totals[severity])})
+	}
+	scanReport.Overview = append(scanReport.Overview, OverviewRow{Description: "Number of findings reported by more than one tool", Details: fmt.Sprint(s.Duplicates)})
+
+	for _, scan := range s.Scans {
+		details := "no details available"
+		if scan.FindingsAvailable {
+			counts := []string{}
+			for _, severity := range Severities {
+				if scan.Counts[severity] > 0 {
+					counts = append(counts, fmt.Sprintf("%v %v", scan.Counts[severity], severity))
+				}
+			}
+			details = strings.Join(counts, ", ")
+			if len(details) == 0 {
+				details = "no findings"
+			}
+		}
+		if !scan.Successful {
+			details += " (scan not successful)"
+		}
+		scanReport.AddSubHeader(fmt.Sprintf("%v (%v)", scan.ToolName, scan.StepName), details)
+	}
+
+	scanReport.DetailTable = ScanDetailTable{
+		NoRowsMessage: "No findings detected",
+		Headers:       []string{"Severity", "Rule / Vulnerability", "Component", "Location", "Tools", "Message"},
+		WithCounter:   true,
+		CounterHeader: "Entry #",
+	}
+	for _, finding := range s.Findings {
+		row := ScanRow{}
+		row.AddColumn(finding.Severity, severityStyle(finding.Severity))
+		row.AddColumn(finding.ruleOrVulnerability(), 0)
+		row.AddColumn(strings.TrimSpace(finding.Component+" "+finding.ComponentVersion), 0)
+		row.AddColumn(finding.Location.String(), 0)
+		row.AddColumn(strings.Join(finding.Tools, ", "), 0)
+		row.AddColumn(finding.Message, 0)
+		scanReport.DetailTable.Rows = append(scanReport.DetailTable.Rows, row)
+	}
+	return scanReport
+}
+
+// String returns the location in the form file:line
+func (l FindingLocation) String() string {
+	if l.StartLine > 0 {
+		return fmt.Sprintf("%v:%v", l.File, l.StartLine)
+	}
+	return l.File
+}
+
+func (f *SummaryFinding) merge(finding Finding, toolName string) {
+	// keep the most severe rating in case the tools disagree
+	if severityRank(finding.Severity) < severityRank(f.Severity) {
+		f.Severity = finding.Severity
+	}
+	if finding.Score > f.Score {
+		f.Score = finding.Score
+	}
+	f.Audited = f.Audited && finding.Audited
+	for _, tool := range f.Tools {
+		if tool == toolName {
+			return
+		}
+	}
+	f.Tools = append(f.Tools, toolName)
+}
+
+func (f *SummaryFinding) ruleOrVulnerability() string {
+	if len(f.VulnerabilityID) > 0 {
+		return f.VulnerabilityID
+	}
+	if len(f.RuleName) > 0 {
+		return f.RuleName
+	}
+	return f.RuleID
+}
+
+// deduplicationKey returns the key which identifies the same finding across tools,
+// findings without vulnerability ID are never deduplicated.
+// Component name and version are normalized since the tools identify components differently, e.g. via coordinates or package URLs.
+func deduplicationKey(finding Finding) string {
+	if len(finding.VulnerabilityID) == 0 {
+		return ""
+	}
+	return strings.ToUpper(finding.VulnerabilityID) + "|" + ComponentName(finding.Component) + "|" + strings.TrimPrefix(finding.ComponentVersion, "v")
+}
+
+func severityRank(severity Severity) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+func severityStyle(severity Severity) ColumnStyle {
+	switch severity {
+	case SeverityCritical, SeverityHigh:
+		return Red
+	case SeverityMedium:
+		return Yellow
+	}
+	return Grey
+}
@@ -0,0 +1,189 @@
+package reporting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFindingsReports() []FindingsReport {
+	return []FindingsReport{
+		{
+			StepName:   "protecodeExecuteScan",
+			ToolName:   "Protecode",
+			Successful: true,
+			Findings: []Finding{
+				{RuleID: "CVE-2021-0002", Severity: SeverityMedium, Score: 5.0, VulnerabilityID: "CVE-2021-0002", Component: "zlib", ComponentVersion: "1.2.11"},
+				{RuleID: "CVE-2021-0001", Severity: SeverityHigh, Score: 8.8, VulnerabilityID: "CVE-2021-0001", Component: "openssl", ComponentVersion: "1.0.2"},
+			},
+		},
+		{
+			StepName:   "whitesourceExecuteScan",
+			ToolName:   "WhiteSource",
+			Successful: true,
+			Findings: []Finding{
+				{RuleID: "CVE-2021-0001", Severity: SeverityCritical, Score: 9.1, VulnerabilityID: "cve-2021-0001", Component: "OpenSSL", ComponentVersion: "1.0.2"},
+				{RuleID: "CVE-2021-0001", Severity: SeverityCritical, Score: 9.1, VulnerabilityID: "CVE-2021-0001", Component: "openssl", ComponentVersion: "1.1.1", Audited: true},
+			},
+		},
+		{
+			StepName:   "hadolintExecute",
+			ToolName:   "hadolint",
+			Successful: true,
+			Findings: []Finding{
+				{RuleID: "DL3008", Severity: SeverityMedium, Location: FindingLocation{File: "Dockerfile", StartLine: 3}},
+				{RuleID: "DL3008", Severity: SeverityMedium, Location: FindingLocation{File: "Dockerfile", StartLine: 3}},
+			},
+		},
+		{
+			StepName:   "detectExecuteScan",
+			ToolName:   "Synopsys Detect",
+			Successful: false,
+		},
+	}
+}
+
+func TestNewScanSummary(t *testing.T) {
+	summary := NewScanSummary(testFindingsReports())
+
+	assert.True(t, summary.Compliant)
+	assert.Equal(t, 1, summary.Duplicates)
+	assert.Equal(t, map[Severity]int{SeverityCritical: 2, SeverityMedium: 3}, summary.Totals)
+	if assert.Len(t, summary.Findings, 5) {
+		// the most severe rating and the highest score is kept for duplicates
+		assert.Equal(t, SeverityCritical, summary.Findings[0].Severity)
+		assert.Equal(t, 9.1, summary.Findings[0].Score)
+		assert.Equal(t, "openssl", summary.Findings[0].Component)
+		assert.Equal(t, []string{"Protecode", "WhiteSource"}, summary.Findings[0].Tools)
+		assert.Equal(t, "1.1.1", summary.Findings[1].ComponentVersion)
+		assert.Equal(t, []string{"WhiteSource"}, summary.Findings[1].Tools)
+	}
+	if assert.Len(t, summary.Scans, 4) {
+		assert.Equal(t, map[Severity]int{SeverityCritical: 2}, summary.Scans[1].Counts)
+		assert.True(t, summary.Scans[1].FindingsAvailable)
+		assert.False(t, summary.Scans[3].FindingsAvailable)
+	}
+}
+
+func TestNewScanSummaryDeduplication(t *testing.T) {
+	t.Run("same CVE reported by two tools with different component naming", func(t *testing.T) {
+		reports := []FindingsReport{
+			{StepName: "protecodeExecuteScan", ToolName: "Protecode", Successful: true, Findings: []Finding{
+				{RuleID: "CVE-2022-42889", VulnerabilityID: "CVE-2022-42889", Severity: SeverityCritical, Score: 9.8, Component: "commons-text", ComponentVersion: "1.9"},
+			}},
+			{StepName: "detectExecuteScan", ToolName: "Synopsys Detect", Successful: true, Findings: []Finding{
+				{RuleID: "CVE-2022-42889", VulnerabilityID: "cve-2022-42889", Severity: SeverityCritical, Score: 9.8, Component: "org.apache.commons:commons-text:1.9", ComponentVersion: "1.9"},
+			}},
+			{StepName: "whitesourceExecuteScan", ToolName: "WhiteSource", Successful: true, Findings: []Finding{
+				{RuleID: "CVE-2022-42889", VulnerabilityID: "CVE-2022-42889", Severity: SeverityCritical, Score: 9.8, Component: "pkg:maven/org.apache.commons/commons-text@1.9", ComponentVersion: "1.9"},
+			}},
+		}
+
+		summary := NewScanSummary(reports)
+
+		assert.Equal(t, 2, summary.Duplicates)
+		assert.Equal(t, map[Severity]int{SeverityCritical: 1}, summary.Totals)
+		if assert.Len(t, summary.Findings, 1) {
+			assert.Equal(t, []string{"Protecode", "Synopsys Detect", "WhiteSource"}, summary.Findings[0].Tools)
+		}
+	})
+
+	t.Run("same CVE in different versions", func(t *testing.T) {
+		reports := []FindingsReport{
+			{StepName: "protecodeExecuteScan", ToolName: "Protecode", Successful: true, Findings: []Finding{
+				{RuleID: "CVE-2022-42889", VulnerabilityID: "CVE-2022-42889", Severity: SeverityCritical, Component: "commons-text", ComponentVersion: "1.9"},
+			}},
+			{StepName: "detectExecuteScan", ToolName: "Synopsys Detect", Successful: true, Findings: []Finding{
+				{RuleID: "CVE-2022-42889", VulnerabilityID: "CVE-2022-42889", Severity: SeverityCritical, Component: "commons-text", ComponentVersion: "1.8"},
+			}},
+		}
+
+		summary := NewScanSummary(reports)
+
+		assert.Equal(t, 0, summary.Duplicates)
+		assert.Len(t, summary.Findings, 2)
+	})
+}
+
+func TestApplyPolicy(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+
+	t.Run("compliant", func(t *testing.T) {
+		summary := NewScanSummary(testFindingsReports())
+		summary.ApplyPolicy(ScanPolicy{MaxFindings: map[Severity]int{SeverityCritical: 2, SeverityHigh: 0}, MaxTotalFindings: intPtr(-1)})
+
+		assert.True(t, summary.Compliant)
+		assert.Empty(t, summary.Violations)
+	})
+
+	t.Run("not compliant", func(t *testing.T) {
+		summary := NewScanSummary(testFindingsReports())
+		summary.ApplyPolicy(ScanPolicy{MaxFindings: map[Severity]int{SeverityCritical: 1, SeverityMedium: 3}, MaxTotalFindings: intPtr(4), FailOnUnsuccessfulScan: true})
+
+		assert.False(t, summary.Compliant)
+		assert.Equal(t, []string{
+			"2 critical findings (max. 1)",
+			"5 findings in total (max. 4)",
+			"scan Synopsys Detect (detectExecuteScan) was not successful",
+		}, summary.Violations)
+	})
+
+	t.Run("audited findings ignored", func(t *testing.T) {
+		summary := NewScanSummary(testFindingsReports())
+		summary.ApplyPolicy(ScanPolicy{MaxFindings: map[Severity]int{SeverityCritical: 1}, IgnoreAudited: true})
+
+		assert.True(t, summary.Compliant)
+	})
+}
+
+func TestParseScanPolicy(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		policy, err := ParseScanPolicy([]byte("maxFindings:\n  critical: 0\n  high: 5\nmaxTotalFindings: 10\nfailOnUnsuccessfulScan: true\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[Severity]int{SeverityCritical: 0, SeverityHigh: 5}, policy.MaxFindings)
+		assert.Equal(t, 10, *policy.MaxTotalFindings)
+		assert.True(t, policy.FailOnUnsuccessfulScan)
+		assert.False(t, policy.IgnoreAudited)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		policy, err := ParseScanPolicy([]byte(`{"maxFindings":{"medium":3},"ignoreAudited":true}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[Severity]int{SeverityMedium: 3}, policy.MaxFindings)
+		assert.Nil(t, policy.MaxTotalFindings)
+		assert.True(t, policy.IgnoreAudited)
+	})
+
+	t.Run("unknown severity", func(t *testing.T) {
+		_, err := ParseScanPolicy([]byte("maxFindings:\n  blocker: 0\n"))
+
+		assert.EqualError(t, err, "failed to parse scan policy: unknown severity 'blocker', supported are [critical high medium low info unknown]")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := ParseScanPolicy([]byte("maxFindings: 5"))
+
+		assert.Contains(t, err.Error(), "failed to parse scan policy")
+	})
+}
+
+func TestScanSummaryToScanReport(t *testing.T) {
+	summary := NewScanSummary(testFindingsReports())
+	summary.ApplyPolicy(ScanPolicy{MaxFindings: map[Severity]int{SeverityCritical: 0}})
+
+	scanReport := summary.ToScanReport()
+
+	assert.False(t, scanReport.SuccessfulScan)
+	assert.Equal(t, OverviewRow{Description: "Compliance verdict", Details: "not compliant", Style: Red}, scanReport.Overview[0])
+	assert.Equal(t, OverviewRow{Description: "Policy violation", Details: "2 critical findings (max. 0)", Style: Red}, scanReport.Overview[1])
+	assert.Equal(t, Subheader{Description: "Synopsys Detect (detectExecuteScan)", Details: "no details available (scan not successful)"}, scanReport.Subheaders[3])
+	assert.Equal(t, Subheader{Description: "WhiteSource (whitesourceExecuteScan)", Details: "2 critical"}, scanReport.Subheaders[1])
+	if assert.Len(t, scanReport.DetailTable.Rows, 5) {
+		assert.Equal(t, "CVE-2021-0001", scanReport.DetailTable.Rows[0].Columns[1].Content)
+		assert.Equal(t, "openssl 1.0.2", scanReport.DetailTable.Rows[0].Columns[2].Content)
+		assert.Equal(t, "Protecode, WhiteSource", scanReport.DetailTable.Rows[0].Columns[4].Content)
+		assert.Equal(t, "Dockerfile:3", scanReport.DetailTable.Rows[3].Columns[3].Content)
+	}
+}
@@ -0,0 +1,72 @@
+package secretstore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	piperhttp "github.com/SAP/jenkins-library/pkg/http"
+	"github.com/pkg/errors"
+)
+
+const azureDevOpsAPIVersion = "6.0-preview.2"
+
+// AzureDevOpsStore writes secrets as secret variables into an Azure DevOps variable group
+type AzureDevOpsStore struct {
+	// OrganizationURL is the url of the organization, e.g. https://dev.azure.com/myOrganization
+	OrganizationURL string
+	Project         string
+	VariableGroupID int
+
+	client piperhttp.Sender
+}
+
+// NewAzureDevOpsStore creates a store for the variable group authenticating via personal access token
+func NewAzureDevOpsStore(organizationURL, project string, variableGroupID int, token string) *AzureDevOpsStore {
+	client := &piperhttp.Client{}
+	client.SetOptions(piperhttp.ClientOptions{Token: "Basic " + base64.StdEncoding.EncodeToString([]byte(":"+token))})
+	return &AzureDevOpsStore{OrganizationURL: strings.TrimSuffix(organizationURL, "/"), Project: project, VariableGroupID: variableGroupID, client: client}
+}
+
+// SetSecret creates or updates the secret variable with the given name in the variable group.
+// All other properties and variables of the group remain unchanged.
+func (a *AzureDevOpsStore) SetSecret(name, value string) error {
+	if len(a.OrganizationURL) == 0 || len(a.Project) == 0 || a.VariableGroupID <= 0 {
+		return fmt.Errorf("organization url, project and variable group ID are required for writing an Azure DevOps secret")
+	}
+	header := http.Header{"Content-Type": []string{"application/json"}, "Accept": []string{"application/json"}}
+
+	getURL := fmt.Sprintf("%v/%v/_apis/distributedtask/variablegroups/%v?api-version=%v", a.OrganizationURL, a.Project, a.VariableGroupID, azureDevOpsAPIVersion)
+	response, err := a.client.SendRequest(http.MethodGet, getURL, nil, header, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve variable group %v", a.VariableGroupID)
+	}
+	defer response.Body.Close()
+	// unknown properties are kept as they are since the update replaces the whole group
+	group := map[string]interface{}{}
+	if err := piperhttp.ParseHTTPResponseBodyJSON(response, &group); err != nil {
+		return errors.Wrapf(err, "failed to parse variable group %v", a.VariableGroupID)
+	}
+
+	variables, ok := group["variables"].(map[string]interface{})
+	if !ok {
+		variables = map[string]interface{}{}
+	}
+	variables[name] = map[string]interface{}{"value": value, "isSecret": true}
+	group["variables"] = variables
+
+	body, err := json.Marshal(group)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal variable group")
+	}
+	updateURL := fmt.Sprintf("%v/_apis/distributedtask/variablegroups/%v?api-version=%v", a.OrganizationURL, a.VariableGroupID, azureDevOpsAPIVersion)
+	updateResponse, err := a.client.SendRequest(http.MethodPut, updateURL, bytes.NewReader(body), header, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update variable group %v", a.VariableGroupID)
+	}
+	updateResponse.Body.Close()
+	return nil
+}
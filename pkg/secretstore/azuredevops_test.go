@@ -0,0 +1,105 @@
+package secretstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAzureDevOps is a local fake of the Azure DevOps variable groups API
+type fakeAzureDevOps struct {
+	server *httptest.Server
+	mutex  sync.Mutex
+	groups map[int]map[string]interface{}
+}
+
+func newFakeAzureDevOps() *fakeAzureDevOps {
+	fake := &fakeAzureDevOps{groups: map[int]map[string]interface{}{
+		42: {
+			"id":          42,
+			"name":        "vault",
+			"type":        "Vsts",
+			"description": "Vault credentials",
+			"variables": map[string]interface{}{
+				"VAULT_ROLE_ID": map[string]interface{}{"value": "roleId"},
+			},
+		},
+	}}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	return fake
+}
+
+func (f *fakeAzureDevOps) handle(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(":testToken")) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.URL.Query().Get("api-version") != azureDevOpsAPIVersion {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var id int
+	switch r.Method {
+	case http.MethodGet:
+		if _, err := fmt.Sscanf(r.URL.Path, "/organization/project/_apis/distributedtask/variablegroups/%d", &id); err != nil || f.groups[id] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(f.groups[id])
+	case http.MethodPut:
+		if _, err := fmt.Sscanf(r.URL.Path, "/organization/_apis/distributedtask/variablegroups/%d", &id); err != nil || f.groups[id] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		group := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.groups[id] = group
+		json.NewEncoder(w).Encode(group)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestAzureDevOpsStore(t *testing.T) {
+	fake := newFakeAzureDevOps()
+	defer fake.server.Close()
+
+	t.Run("success", func(t *testing.T) {
+		store := NewAzureDevOpsStore(fake.server.URL+"/organization/", "project", 42, "testToken")
+		require.NoError(t, store.SetSecret("VAULT_SECRET_ID", "newSecretId"))
+
+		group := fake.groups[42]
+		assert.Equal(t, "Vault credentials", group["description"])
+		variables := group["variables"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"value": "roleId"}, variables["VAULT_ROLE_ID"])
+		assert.Equal(t, map[string]interface{}{"value": "newSecretId", "isSecret": true}, variables["VAULT_SECRET_ID"])
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		store := NewAzureDevOpsStore(fake.server.URL+"/organization", "project", 1, "testToken")
+		err := store.SetSecret("VAULT_SECRET_ID", "newSecretId")
+		assert.Contains(t, err.Error(), "failed to retrieve variable group 1")
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		store := NewAzureDevOpsStore(fake.server.URL+"/organization", "project", 42, "wrongToken")
+		assert.Error(t, store.SetSecret("VAULT_SECRET_ID", "newSecretId"))
+	})
+
+	t.Run("missing configuration", func(t *testing.T) {
+		store := NewAzureDevOpsStore(fake.server.URL+"/organization", "project", 0, "testToken")
+		assert.EqualError(t, store.SetSecret("VAULT_SECRET_ID", "newSecretId"), "organization url, project and variable group ID are required for writing an Azure DevOps secret")
+	})
+}
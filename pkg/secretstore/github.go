@@ -0,0 +1,97 @@
+package secretstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	piperGithub "github.com/SAP/jenkins-library/pkg/github"
+	"github.com/google/go-github/v32/github"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/box"
+)
+
+type githubActionsService interface {
+	GetRepoPublicKey(ctx context.Context, owner, repo string) (*github.PublicKey, *github.Response, error)
+	GetOrgPublicKey(ctx context.Context, org string) (*github.PublicKey, *github.Response, error)
+	CreateOrUpdateRepoSecret(ctx context.Context, owner, repo string, eSecret *github.EncryptedSecret) (*github.Response, error)
+	CreateOrUpdateOrgSecret(ctx context.Context, org string, eSecret *github.EncryptedSecret) (*github.Response, error)
+}
+
+// GitHubStore writes secrets as GitHub Actions secrets of a repository or an organization
+type GitHubStore struct {
+	Owner      string
+	Repository string
+	// Visibility defines the visibility of organization secrets, repository secrets are written if it is empty
+	Visibility string
+
+	ctx     context.Context
+	actions githubActionsService
+}
+
+// NewGitHubStore creates a store for GitHub Actions secrets
+func NewGitHubStore(token, apiURL, owner, repository, visibility string) (*GitHubStore, error) {
+	ctx, client, err := piperGithub.NewClient(token, apiURL, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create GitHub client")
+	}
+	return &GitHubStore{Owner: owner, Repository: repository, Visibility: visibility, ctx: ctx, actions: client.Actions}, nil
+}
+
+// SetSecret creates or updates the GitHub Actions secret with the given name.
+// The value is encrypted with the public key of the repository or organization using a libsodium sealed box.
+func (g *GitHubStore) SetSecret(name, value string) error {
+	if len(g.Visibility) > 0 {
+		publicKey, _, err := g.actions.GetOrgPublicKey(g.ctx, g.Owner)
+		if err != nil {
+			return errors.Wrapf(err, "failed to retrieve public key of organization %v", g.Owner)
+		}
+		secret, err := encryptGitHubSecret(name, value, publicKey)
+		if err != nil {
+			return err
+		}
+		secret.Visibility = g.Visibility
+		if _, err := g.actions.CreateOrUpdateOrgSecret(g.ctx, g.Owner, secret); err != nil {
+			return errors.Wrapf(err, "failed to write secret %v of organization %v", name, g.Owner)
+		}
+		return nil
+	}
+
+	if len(g.Owner) == 0 || len(g.Repository) == 0 {
+		return fmt.Errorf("owner and repository are required for writing a repository secret")
+	}
+	publicKey, _, err := g.actions.GetRepoPublicKey(g.ctx, g.Owner, g.Repository)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve public key of repository %v/%v", g.Owner, g.Repository)
+	}
+	secret, err := encryptGitHubSecret(name, value, publicKey)
+	if err != nil {
+		return err
+	}
+	if _, err := g.actions.CreateOrUpdateRepoSecret(g.ctx, g.Owner, g.Repository, secret); err != nil {
+		return errors.Wrapf(err, "failed to write secret %v of repository %v/%v", name, g.Owner, g.Repository)
+	}
+	return nil
+}
+
+func encryptGitHubSecret(name, value string, publicKey *github.PublicKey) (*github.EncryptedSecret, error) {
+	decodedKey, err := base64.StdEncoding.DecodeString(publicKey.GetKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode public key")
+	}
+	if len(decodedKey) != 32 {
+		return nil, fmt.Errorf("invalid public key: expected 32 bytes but got %v", len(decodedKey))
+	}
+	var recipient [32]byte
+	copy(recipient[:], decodedKey)
+	encrypted, err := box.SealAnonymous(nil, []byte(value), &recipient, rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt secret")
+	}
+	return &github.EncryptedSecret{
+		Name:           name,
+		KeyID:          publicKey.GetKeyID(),
+		EncryptedValue: base64.StdEncoding.EncodeToString(encrypted),
+	}, nil
+}
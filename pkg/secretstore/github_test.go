@@ -0,0 +1,124 @@
+package secretstore
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// fakeGitHub is a local fake of the GitHub Actions secrets API which decrypts the received secrets
+type fakeGitHub struct {
+	server     *httptest.Server
+	publicKey  *[32]byte
+	privateKey *[32]byte
+	mutex      sync.Mutex
+	// secrets contains the decrypted secrets by path, e.g. repos/owner/repo/name
+	secrets    map[string]string
+	visibility map[string]string
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	fake := &fakeGitHub{publicKey: publicKey, privateKey: privateKey, secrets: map[string]string{}, visibility: map[string]string{}}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	return fake
+}
+
+func (f *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if r.Header.Get("Authorization") != "Bearer testToken" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/actions/secrets/public-key"):
+		if strings.HasPrefix(path, "repos/notExisting") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"key_id": "testKeyId", "key": base64.StdEncoding.EncodeToString(f.publicKey[:])})
+	case r.Method == http.MethodPut && len(parts) >= 4 && parts[len(parts)-2] == "secrets":
+		body := struct {
+			KeyID          string `json:"key_id"`
+			EncryptedValue string `json:"encrypted_value"`
+			Visibility     string `json:"visibility"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.KeyID != "testKeyId" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		encrypted, _ := base64.StdEncoding.DecodeString(body.EncryptedValue)
+		decrypted, ok := box.OpenAnonymous(nil, encrypted, f.publicKey, f.privateKey)
+		if !ok {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		key := strings.Join(append(parts[:len(parts)-3], parts[len(parts)-1]), "/")
+		f.secrets[key] = string(decrypted)
+		f.visibility[key] = body.Visibility
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGitHubStore(t *testing.T) {
+	fake := newFakeGitHub(t)
+	defer fake.server.Close()
+
+	t.Run("repository secret", func(t *testing.T) {
+		store, err := NewGitHubStore("testToken", fake.server.URL, "owner", "repo", "")
+		require.NoError(t, err)
+		assert.NoError(t, store.SetSecret("VAULT_SECRET_ID", "newSecretId"))
+		assert.Equal(t, "newSecretId", fake.secrets["repos/owner/repo/VAULT_SECRET_ID"])
+	})
+
+	t.Run("organization secret", func(t *testing.T) {
+		store, err := NewGitHubStore("testToken", fake.server.URL, "owner", "", "private")
+		require.NoError(t, err)
+		assert.NoError(t, store.SetSecret("VAULT_SECRET_ID", "orgSecretId"))
+		assert.Equal(t, "orgSecretId", fake.secrets["orgs/owner/VAULT_SECRET_ID"])
+		assert.Equal(t, "private", fake.visibility["orgs/owner/VAULT_SECRET_ID"])
+	})
+
+	t.Run("missing repository", func(t *testing.T) {
+		store, err := NewGitHubStore("testToken", fake.server.URL, "owner", "", "")
+		require.NoError(t, err)
+		assert.EqualError(t, store.SetSecret("VAULT_SECRET_ID", "newSecretId"), "owner and repository are required for writing a repository secret")
+	})
+
+	t.Run("unknown repository", func(t *testing.T) {
+		store, err := NewGitHubStore("testToken", fake.server.URL, "notExisting", "repo", "")
+		require.NoError(t, err)
+		err = store.SetSecret("VAULT_SECRET_ID", "newSecretId")
+		assert.Contains(t, err.Error(), "failed to retrieve public key of repository notExisting/repo")
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		store, err := NewGitHubStore("wrongToken", fake.server.URL, "owner", "repo", "")
+		require.NoError(t, err)
+		assert.Error(t, store.SetSecret("VAULT_SECRET_ID", "newSecretId"))
+	})
+}
+
+func TestEncryptGitHubSecret(t *testing.T) {
+	t.Run("invalid key", func(t *testing.T) {
+		key, keyID := base64.StdEncoding.EncodeToString([]byte("short")), "keyId"
+		_, err := encryptGitHubSecret("name", "value", &github.PublicKey{Key: &key, KeyID: &keyID})
+		assert.EqualError(t, err, "invalid public key: expected 32 bytes but got 5")
+	})
+}
@@ -0,0 +1,28 @@
+package secretstore
+
+import (
+	"net/http"
+
+	"github.com/SAP/jenkins-library/pkg/jenkins"
+	"github.com/pkg/errors"
+)
+
+// JenkinsStore writes secrets as 'Secret text' credentials to Jenkins
+type JenkinsStore struct {
+	URL      string
+	Username string
+	Token    string
+	// Domain is the credential domain, "_" is the global domain
+	Domain string
+}
+
+// SetSecret creates or updates the Jenkins credential with the given ID
+func (j *JenkinsStore) SetSecret(name, value string) error {
+	instance, err := jenkins.Instance(&http.Client{}, j.URL, j.Username, j.Token)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to Jenkins")
+	}
+	credManager := jenkins.NewCredentialsManager(instance)
+	credential := jenkins.StringCredentials{ID: name, Secret: value}
+	return jenkins.UpdateCredential(credManager, j.Domain, credential)
+}
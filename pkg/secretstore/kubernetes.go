@@ -0,0 +1,74 @@
+package secretstore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	piperhttp "github.com/SAP/jenkins-library/pkg/http"
+	"github.com/pkg/errors"
+)
+
+// KubernetesStore writes secrets into a key of a Kubernetes secret via the Kubernetes API
+type KubernetesStore struct {
+	APIServer string
+	Namespace string
+	// Key is the key within the data of the Kubernetes secret
+	Key string
+
+	client piperhttp.Sender
+}
+
+// NewKubernetesStore creates a store for Kubernetes secrets authenticating via bearer token, e.g. of a service account
+func NewKubernetesStore(apiServer, namespace, key, token string, skipTLSVerification bool) *KubernetesStore {
+	client := &piperhttp.Client{}
+	client.SetOptions(piperhttp.ClientOptions{Token: "Bearer " + token, TransportSkipVerification: skipTLSVerification})
+	return &KubernetesStore{APIServer: strings.TrimSuffix(apiServer, "/"), Namespace: namespace, Key: key, client: client}
+}
+
+// SetSecret sets the value of the configured key in the Kubernetes secret with the given name.
+// The secret is created in case it does not exist yet, other keys of an existing secret remain unchanged.
+func (k *KubernetesStore) SetSecret(name, value string) error {
+	if len(k.APIServer) == 0 || len(k.Namespace) == 0 || len(k.Key) == 0 {
+		return fmt.Errorf("API server, namespace and key are required for writing a Kubernetes secret")
+	}
+	secretsURL := fmt.Sprintf("%v/api/v1/namespaces/%v/secrets", k.APIServer, k.Namespace)
+	data := map[string]string{k.Key: base64.StdEncoding.EncodeToString([]byte(value))}
+
+	response, err := k.client.SendRequest(http.MethodGet, secretsURL+"/"+name, nil, http.Header{"Accept": []string{"application/json"}}, nil)
+	if response != nil && response.Body != nil {
+		response.Body.Close()
+	}
+	if response != nil && response.StatusCode == http.StatusNotFound {
+		secret := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "Opaque",
+			"metadata":   map[string]string{"name": name, "namespace": k.Namespace},
+			"data":       data,
+		}
+		return k.send(http.MethodPost, secretsURL, "application/json", secret, fmt.Sprintf("failed to create secret %v", name))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve secret %v", name)
+	}
+	patch := map[string]interface{}{"data": data}
+	return k.send(http.MethodPatch, secretsURL+"/"+name, "application/merge-patch+json", patch, fmt.Sprintf("failed to update secret %v", name))
+}
+
+func (k *KubernetesStore) send(method, url, contentType string, content interface{}, errorMessage string) error {
+	body, err := json.Marshal(content)
+	if err != nil {
+		return errors.Wrap(err, errorMessage)
+	}
+	header := http.Header{"Content-Type": []string{contentType}, "Accept": []string{"application/json"}}
+	response, err := k.client.SendRequest(method, url, bytes.NewReader(body), header, nil)
+	if err != nil {
+		return errors.Wrap(err, errorMessage)
+	}
+	response.Body.Close()
+	return nil
+}
@@ -0,0 +1,114 @@
+package secretstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKubernetes is a local fake of the secrets API of a Kubernetes API server
+type fakeKubernetes struct {
+	server *httptest.Server
+	mutex  sync.Mutex
+	// secrets contains the data of the secrets by <namespace>/<name>
+	secrets map[string]map[string]string
+}
+
+func newFakeKubernetes() *fakeKubernetes {
+	fake := &fakeKubernetes{secrets: map[string]map[string]string{
+		"piper/vault": {"roleId": base64.StdEncoding.EncodeToString([]byte("roleId"))},
+	}}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	return fake
+}
+
+func (f *fakeKubernetes) handle(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if r.Header.Get("Authorization") != "Bearer testToken" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	// /api/v1/namespaces/<namespace>/secrets[/<name>]
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 5 || parts[0] != "api" || parts[1] != "v1" || parts[2] != "namespaces" || parts[4] != "secrets" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	namespace := parts[3]
+	secret := struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Data map[string]string `json:"data"`
+	}{}
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 6:
+		data, ok := f.secrets[namespace+"/"+parts[5]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Secret", "data": data})
+	case r.Method == http.MethodPost && len(parts) == 5:
+		if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.secrets[namespace+"/"+secret.Metadata.Name] = secret.Data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPatch && len(parts) == 6:
+		data, ok := f.secrets[namespace+"/"+parts[5]]
+		if !ok || r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for key, value := range secret.Data {
+			data[key] = value
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestKubernetesStore(t *testing.T) {
+	fake := newFakeKubernetes()
+	defer fake.server.Close()
+	encoded := base64.StdEncoding.EncodeToString([]byte("newSecretId"))
+
+	t.Run("update existing secret", func(t *testing.T) {
+		store := NewKubernetesStore(fake.server.URL, "piper", "secretId", "testToken", false)
+		require.NoError(t, store.SetSecret("vault", "newSecretId"))
+		assert.Equal(t, encoded, fake.secrets["piper/vault"]["secretId"])
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("roleId")), fake.secrets["piper/vault"]["roleId"])
+	})
+
+	t.Run("create secret", func(t *testing.T) {
+		store := NewKubernetesStore(fake.server.URL+"/", "piper", "secretId", "testToken", false)
+		require.NoError(t, store.SetSecret("newVault", "newSecretId"))
+		assert.Equal(t, map[string]string{"secretId": encoded}, fake.secrets["piper/newVault"])
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		store := NewKubernetesStore(fake.server.URL, "piper", "secretId", "wrongToken", false)
+		err := store.SetSecret("vault", "newSecretId")
+		assert.Contains(t, err.Error(), "failed to retrieve secret vault")
+	})
+
+	t.Run("missing configuration", func(t *testing.T) {
+		store := NewKubernetesStore("", "piper", "secretId", "testToken", false)
+		assert.EqualError(t, store.SetSecret("vault", "newSecretId"), "API server, namespace and key are required for writing a Kubernetes secret")
+	})
+}
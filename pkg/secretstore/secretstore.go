@@ -0,0 +1,7 @@
+package secretstore
+
+// SecretStore is a store to which secrets can be written, e.g. the credentials of a CI/CD system
+type SecretStore interface {
+	// SetSecret creates or updates the secret with the given name
+	SetSecret(name, value string) error
+}
@@ -2,6 +2,7 @@ package sonar
 
 import (
 	"net/http"
+	"strconv"
 
 	sonargo "github.com/magicsong/sonargo/sonar"
 	"github.com/pkg/errors"
@@ -10,6 +11,12 @@ import (
 // EndpointIssuesSearch API endpoint for https://sonarcloud.io/web_api/api/issues/search
 const EndpointIssuesSearch = "issues/search"
 
+// maximum page size supported by the issues API
+const issuesPageSize = 500
+
+// the issues API does not return more than 10.000 issues in total
+const issuesLimit = 10000
+
 // IssueService ...
 type IssueService struct {
 	Organization string
@@ -44,12 +51,10 @@ func (service *IssueService) SearchIssues(options *IssuesSearchOption) (*sonargo
 	return result, response, nil
 }
 
-func (service *IssueService) getIssueCount(severity issueSeverity) (int, error) {
+func (service *IssueService) searchOptions() *IssuesSearchOption {
 	options := &IssuesSearchOption{
 		ComponentKeys: service.Project,
-		Severities:    severity.ToString(),
 		Resolved:      "false",
-		Ps:            "1",
 	}
 	if len(service.Branch) > 0 {
 		options.Branch = service.Branch
@@ -60,6 +65,13 @@ func (service *IssueService) getIssueCount(severity issueSeverity) (int, error)
 	if len(service.PullRequest) > 0 {
 		options.PullRequest = service.PullRequest
 	}
+	return options
+}
+
+func (service *IssueService) getIssueCount(severity issueSeverity) (int, error) {
+	options := service.searchOptions()
+	options.Severities = severity.ToString()
+	options.Ps = "1"
 	result, _, err := service.SearchIssues(options)
 	if err != nil {
 		return -1, errors.Wrapf(err, "failed to fetch the numer of '%s' issues", severity)
@@ -92,6 +104,26 @@ func (service *IssueService) GetNumberOfInfoIssues() (int, error) {
 	return service.getIssueCount(info)
 }
 
+// GetIssues returns all unresolved issues of the project.
+// Due to a limitation of the issues API at most 10.000 issues are returned.
+func (service *IssueService) GetIssues() ([]*sonargo.Issue, error) {
+	issues := []*sonargo.Issue{}
+	for page := 1; page*issuesPageSize <= issuesLimit; page++ {
+		options := service.searchOptions()
+		options.P = strconv.Itoa(page)
+		options.Ps = strconv.Itoa(issuesPageSize)
+		result, _, err := service.SearchIssues(options)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch page %v of the issues", page)
+		}
+		issues = append(issues, result.Issues...)
+		if len(result.Issues) < issuesPageSize || len(issues) >= result.Total {
+			break
+		}
+	}
+	return issues, nil
+}
+
 // NewIssuesService returns a new instance of a service for the issues API endpoint.
 func NewIssuesService(host, token, project, organization, branch, pullRequest string, client Sender) *IssueService {
 	return &IssueService{
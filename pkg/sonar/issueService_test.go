@@ -68,6 +68,25 @@ func TestIssueService(t *testing.T) {
 		assert.Equal(t, 111, countInfo)
 		assert.Equal(t, 3, httpmock.GetTotalCallCount(), "unexpected number of requests")
 	})
+	t.Run("all issues", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		sender := &piperhttp.Client{}
+		sender.SetOptions(piperhttp.ClientOptions{UseDefaultTransport: true})
+		// add response handler
+		httpmock.RegisterResponder(http.MethodGet, testURL+"/api/"+EndpointIssuesSearch+"", httpmock.NewStringResponder(http.StatusOK, responseIssueSearchCritical))
+		// create service instance
+		serviceUnderTest := NewIssuesService(testURL, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, sender)
+		// test
+		issues, err := serviceUnderTest.GetIssues()
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(issues)) {
+			assert.Equal(t, "go:S3776", issues[0].Rule)
+		}
+		assert.Equal(t, 1, httpmock.GetTotalCallCount(), "unexpected number of requests")
+	})
 }
 
 const responseIssueSearchError = `{
@@ -2,12 +2,20 @@ package sonar
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/SAP/jenkins-library/pkg/reporting"
+	sonargo "github.com/magicsong/sonargo/sonar"
 )
 
 const reportFileName = "sonarscan.json"
 
+// SarifReportFileName defines the name of the SARIF report
+const SarifReportFileName = "sonarscan.sarif"
+
 //ReportData is representing the data of the step report JSON
 type ReportData struct {
 	ServerURL      string `json:"serverUrl"`
@@ -36,3 +44,39 @@ func WriteReport(data ReportData, reportPath string, writeToFile func(f string,
 	}
 	return writeToFile(filepath.Join(reportPath, reportFileName), jsonData, 0644)
 }
+
+// WriteSarif writes the issues as SARIF report
+func WriteSarif(issues []*sonargo.Issue, data ReportData, reportPath string, writeToFile func(f string, d []byte, p os.FileMode) error) error {
+	report := reporting.FindingsReport{
+		StepName:       "sonarExecuteScan",
+		ToolName:       "SonarQube",
+		InformationURI: "https://www.sonarqube.org",
+		Successful:     true,
+		Findings:       []reporting.Finding{},
+	}
+	for _, issue := range issues {
+		finding := reporting.Finding{
+			RuleID:   issue.Rule,
+			Message:  issue.Message,
+			Severity: reporting.ParseSeverity(issue.Severity),
+			HelpURI:  fmt.Sprintf("%v/coding_rules?open=%v&rule_key=%v", data.ServerURL, issue.Rule, issue.Rule),
+			Location: reporting.FindingLocation{
+				// component keys are prefixed with the project key
+				File:      strings.TrimPrefix(issue.Component, data.ProjectKey+":"),
+				StartLine: issue.Line,
+			},
+		}
+		if issue.TextRange != nil {
+			finding.Location.StartLine = issue.TextRange.StartLine
+			finding.Location.EndLine = issue.TextRange.EndLine
+			finding.Location.StartColumn = issue.TextRange.StartOffset + 1
+		}
+		report.AddFinding(finding)
+	}
+
+	sarif, err := report.ToSARIF()
+	if err != nil {
+		return err
+	}
+	return writeToFile(filepath.Join(reportPath, SarifReportFileName), sarif, 0644)
+}
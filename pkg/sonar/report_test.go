@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	sonargo "github.com/magicsong/sonargo/sonar"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -38,3 +39,28 @@ func TestWriteReport(t *testing.T) {
 	assert.Equal(t, expected, fileContent)
 	assert.Equal(t, reportFileName, fileName)
 }
+
+func TestWriteSarif(t *testing.T) {
+	// init
+	issues := []*sonargo.Issue{
+		{
+			Rule:      "go:S3776",
+			Severity:  "CRITICAL",
+			Component: "SAP_jenkins-library:cmd/fortifyExecuteScan.go",
+			Line:      647,
+			Message:   "Refactor this method to reduce its Cognitive Complexity from 16 to the 15 allowed.",
+			TextRange: &sonargo.TextRange{StartLine: 647, EndLine: 648, StartOffset: 5},
+		},
+	}
+	testData := ReportData{ServerURL: "https://sonarcloud.io", ProjectKey: "SAP_jenkins-library"}
+	// test
+	err := WriteSarif(issues, testData, "", writeToFileMock)
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, SarifReportFileName, fileName)
+	assert.Contains(t, fileContent, `"ruleId": "go:S3776"`)
+	assert.Contains(t, fileContent, `"level": "error"`)
+	assert.Contains(t, fileContent, `"uri": "cmd/fortifyExecuteScan.go"`)
+	assert.Contains(t, fileContent, `"startColumn": 6`)
+	assert.Contains(t, fileContent, `"helpUri": "https://sonarcloud.io/coding_rules?open=go:S3776&rule_key=go:S3776"`)
+}
@@ -1,21 +1,24 @@
 package telemetry
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/url"
+	"reflect"
+	"strings"
+	"time"
 )
 
 // BaseData object definition containing the base data and it's mapping information
 type BaseData struct {
 	// SWA receives the fields custom1 - custom30 and e_a, e_2 - e_30 for custom values.
-	ActionName      string `json:"action_name"`
-	EventType       string `json:"event_type"`
-	SiteID          string `json:"idsite"`
-	URL             string `json:"url"`
-	StepName        string `json:"e_3"` // set by step generator
-	StageName       string `json:"e_10"`
-	PipelineURLHash string `json:"e_4"` // defaults to sha1 of env.JOB_URl
-	BuildURLHash    string `json:"e_5"` // defaults to sha1 of env.BUILD_URL
+	ActionName      string `json:"-" swa:"action_name"`
+	EventType       string `json:"-" swa:"event_type"`
+	SiteID          string `json:"-" swa:"idsite"`
+	URL             string `json:"libraryRepository,omitempty" swa:"url"`
+	StepName        string `json:"stepName" swa:"e_3"` // set by step generator
+	StageName       string `json:"stageName,omitempty" swa:"e_10"`
+	PipelineURLHash string `json:"pipelineUrlHash" swa:"e_4"` // defaults to sha1 of env.JOB_URl
+	BuildURLHash    string `json:"buildUrlHash" swa:"e_5"`    // defaults to sha1 of env.BUILD_URL
 }
 
 var baseData BaseData
@@ -23,13 +26,13 @@ var baseData BaseData
 // BaseMetaData object definition containing the labels for the base data and it's mapping information
 type BaseMetaData struct {
 	// SWA receives the fields custom1 - custom30 and e_a, e_2 - e_30 for custom values.
-	StepNameLabel        string `json:"custom3"`
-	StageNameLabel       string `json:"custom10"`
-	PipelineURLHashLabel string `json:"custom4"`
-	BuildURLHashLabel    string `json:"custom5"`
-	DurationLabel        string `json:"custom11,omitempty"`
-	ExitCodeLabel        string `json:"custom12,omitempty"`
-	ErrorCategoryLabel   string `json:"custom13,omitempty"`
+	StepNameLabel        string `swa:"custom3"`
+	StageNameLabel       string `swa:"custom10"`
+	PipelineURLHashLabel string `swa:"custom4"`
+	BuildURLHashLabel    string `swa:"custom5"`
+	DurationLabel        string `swa:"custom11"`
+	ExitCodeLabel        string `swa:"custom12"`
+	ErrorCategoryLabel   string `swa:"custom13"`
 }
 
 // baseMetaData object containing the labels for the base data
@@ -47,34 +50,59 @@ var baseMetaData BaseMetaData = BaseMetaData{
 type CustomData struct {
 	// SWA receives the fields custom1 - custom30 and e_a, e_2 - e_30 for custom values.
 	// Piper uses the values custom11 - custom25 & e_11 - e_25 for library related reporting
-	// and custom26 - custom30 & e_26 - e_30 for step  related reporting.
-	Duration      string `json:"e_11,omitempty"`
-	ErrorCode     string `json:"e_12,omitempty"`
-	ErrorCategory string `json:"e_13,omitempty"`
-	Custom1Label  string `json:"custom26,omitempty"`
-	Custom2Label  string `json:"custom27,omitempty"`
-	Custom3Label  string `json:"custom28,omitempty"`
-	Custom4Label  string `json:"custom29,omitempty"`
-	Custom5Label  string `json:"custom30,omitempty"`
-	Custom1       string `json:"e_26,omitempty"`
-	Custom2       string `json:"e_27,omitempty"`
-	Custom3       string `json:"e_28,omitempty"`
-	Custom4       string `json:"e_29,omitempty"`
-	Custom5       string `json:"e_30,omitempty"`
+	// and custom26 - custom30 & e_26 - e_30 for step related reporting.
+	Duration      string `json:"duration,omitempty" swa:"e_11"`
+	ErrorCode     string `json:"errorCode,omitempty" swa:"e_12"`
+	ErrorCategory string `json:"errorCategory,omitempty" swa:"e_13"`
+
+	// Step related data, a step sets only the fields relevant for it.
+	// The swa tag contains the value field, the label field and optionally the label, by default the JSON name of the field is used as label.
+	// Different steps may use the same SWA fields.
+	BuildTool             string `json:"buildTool,omitempty" swa:"e_26,custom26"`
+	FilePath              string `json:"filePath,omitempty" swa:"e_27,custom27"`
+	ContainerBuildOptions string `json:"containerBuildOptions,omitempty" swa:"e_26,custom26,ContainerBuildOptions"`
 }
 
 // Data object definition containing all telemetry data
 type Data struct {
+	Time time.Time `json:"time"`
 	BaseData
-	BaseMetaData
 	CustomData
 }
 
-// toMap transfers the data object into a map using JSON tags
-func (d *Data) toMap() (result map[string]string) {
-	jsonObj, _ := json.Marshal(d)
-	json.Unmarshal(jsonObj, &result)
-	return
+// toMap transfers the data object into a map of SWA parameters using the swa tags
+func (d *Data) toMap() map[string]string {
+	result := map[string]string{}
+	for _, value := range []interface{}{d.BaseData, baseMetaData, d.CustomData} {
+		addSWAParameters(reflect.ValueOf(value), result)
+	}
+	return result
+}
+
+func addSWAParameters(value reflect.Value, result map[string]string) {
+	for i := 0; i < value.NumField(); i++ {
+		tag := value.Type().Field(i).Tag.Get("swa")
+		if len(tag) == 0 {
+			continue
+		}
+		fieldValue := fmt.Sprint(value.Field(i).Interface())
+		names := strings.Split(tag, ",")
+		if len(names) == 1 {
+			result[names[0]] = fieldValue
+			continue
+		}
+		// step related fields are only transferred in case they are set
+		if value.Field(i).IsZero() {
+			continue
+		}
+		result[names[0]] = fieldValue
+		if len(names) > 2 {
+			// keeps labels which differ from the JSON name, e.g. for the continuity of the reporting
+			result[names[1]] = names[2]
+		} else {
+			result[names[1]] = jsonName(value.Type().Field(i))
+		}
+	}
 }
 
 // toPayloadString transfers the data object into a 'key=value&..' string
@@ -89,3 +117,12 @@ func (d *Data) toPayloadString() string {
 
 	return parameters.Encode()
 }
+
+// jsonName returns the name of a field in JSON format
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if len(name) == 0 {
+		return field.Name
+	}
+	return name
+}
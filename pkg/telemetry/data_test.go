@@ -8,7 +8,7 @@ import (
 
 func TestDataToMap(t *testing.T) {
 	// init
-	testData := Data{BaseData: BaseData{ActionName: "testAction"}, CustomData: CustomData{Custom2Label: "label", Custom2: "value"}}
+	testData := Data{BaseData: BaseData{ActionName: "testAction"}, CustomData: CustomData{FilePath: "value"}}
 	// test
 	result := testData.toMap()
 	// assert
@@ -27,10 +27,28 @@ func TestDataToMap(t *testing.T) {
 	assert.Contains(t, result, "custom5")
 	assert.Contains(t, result, "custom10")
 
-	assert.Contains(t, result, "e_27")
-	assert.Contains(t, result, "custom27")
+	assert.Contains(t, result, "e_11")
+	assert.Contains(t, result, "e_12")
+	assert.Contains(t, result, "e_13")
+	assert.Equal(t, "duration", result["custom11"])
+	assert.Equal(t, "exitCode", result["custom12"])
+	assert.Equal(t, "errorCategory", result["custom13"])
 
-	assert.Equal(t, 14, len(result))
+	assert.Equal(t, "value", result["e_27"])
+	assert.Equal(t, "filePath", result["custom27"])
+	assert.NotContains(t, result, "e_26", "unset step data must not be contained")
+	assert.NotContains(t, result, "custom26", "unset step data must not be contained")
+
+	assert.Equal(t, 20, len(result))
+}
+
+func TestDataToMapLabel(t *testing.T) {
+	testData := Data{CustomData: CustomData{ContainerBuildOptions: "--skip-tls-verify-pull"}}
+
+	result := testData.toMap()
+
+	assert.Equal(t, "--skip-tls-verify-pull", result["e_26"])
+	assert.Equal(t, "ContainerBuildOptions", result["custom26"], "label differing from the JSON name must be kept")
 }
 
 func TestDataToPayload(t *testing.T) {
@@ -0,0 +1,295 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	piperhttp "github.com/SAP/jenkins-library/pkg/http"
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Types of the sinks which can be configured in addition to SWA
+const (
+	SinkTypeFile        = "file"
+	SinkTypePushgateway = "pushgateway"
+	SinkTypeWebhook     = "webhook"
+)
+
+// Sink receives the telemetry data of a step run
+type Sink interface {
+	Send(data *Data) error
+}
+
+// SinkConfiguration defines a sink, e.g. as part of the hook configuration
+type SinkConfiguration struct {
+	Type string `json:"type"`
+	// Path of the file for type file
+	Path string `json:"path,omitempty"`
+	// URL of the Pushgateway or of the webhook
+	URL string `json:"url,omitempty"`
+	// Job is the job label of the metrics pushed to the Pushgateway, defaults to piper
+	Job string `json:"job,omitempty"`
+	// Headers are sent with each request to the Pushgateway or the webhook, e.g. for authentication
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+var sinks []Sink
+
+// ConfigureSinks sets the sinks which receive the telemetry data in addition to SWA.
+// They are an explicit opt-in and thus also receive the data in case telemetry reporting is deactivated.
+// Invalid configurations are logged but do not fail the step.
+func ConfigureSinks(configurations []SinkConfiguration) {
+	sinks = nil
+	for _, configuration := range configurations {
+		sink, err := NewSink(configuration)
+		if err != nil {
+			log.Entry().WithError(err).Warning("Telemetry sink deactivated")
+			continue
+		}
+		sinks = append(sinks, sink)
+	}
+}
+
+// NewSink creates the sink for a configuration
+func NewSink(configuration SinkConfiguration) (Sink, error) {
+	for _, value := range configuration.Headers {
+		log.RegisterSecret(value)
+	}
+	switch configuration.Type {
+	case SinkTypeFile:
+		if len(configuration.Path) == 0 {
+			return nil, fmt.Errorf("telemetry sink of type %v requires a path", configuration.Type)
+		}
+		return &fileSink{path: configuration.Path}, nil
+	case SinkTypePushgateway:
+		if len(configuration.URL) == 0 {
+			return nil, fmt.Errorf("telemetry sink of type %v requires a url", configuration.Type)
+		}
+		job := configuration.Job
+		if len(job) == 0 {
+			job = "piper"
+		}
+		return &pushgatewaySink{url: strings.TrimSuffix(configuration.URL, "/"), job: job, headers: configuration.Headers, client: newSinkClient()}, nil
+	case SinkTypeWebhook:
+		if len(configuration.URL) == 0 {
+			return nil, fmt.Errorf("telemetry sink of type %v requires a url", configuration.Type)
+		}
+		return &webhookSink{url: configuration.URL, headers: configuration.Headers, client: newSinkClient()}, nil
+	}
+	return nil, fmt.Errorf("unknown telemetry sink type '%v'", configuration.Type)
+}
+
+func newSinkClient() piperhttp.Sender {
+	client := &piperhttp.Client{}
+	client.SetOptions(piperhttp.ClientOptions{MaxRequestDuration: 5 * time.Second})
+	return client
+}
+
+// swaSink sends the data to SAP Web Analytics, it is used unless telemetry reporting is deactivated
+type swaSink struct {
+	client piperhttp.Sender
+}
+
+func (s *swaSink) Send(data *Data) error {
+	request, _ := url.Parse(baseURL)
+	request.Path = endpoint
+	request.RawQuery = data.toPayloadString()
+	log.Entry().WithField("request", request.String()).Debug("Sending telemetry data")
+	response, err := s.client.SendRequest(http.MethodGet, request.String(), nil, nil, nil)
+	closeBody(response)
+	return err
+}
+
+// fileSink appends the data as one line of JSON to a local file.
+// Since each entry is written at once, several steps running in parallel can share the same file.
+type fileSink struct {
+	path string
+}
+
+func (s *fileSink) Send(data *Data) error {
+	line, err := json.Marshal(data.masked())
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal telemetry data")
+	}
+	if dir := filepath.Dir(s.path); len(dir) > 0 {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return errors.Wrapf(err, "failed to create directory of telemetry file %v", s.path)
+		}
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open telemetry file %v", s.path)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return errors.Wrapf(err, "failed to write telemetry file %v", s.path)
+}
+
+// webhookSink posts the data in JSON format to an http(s) endpoint
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  piperhttp.Sender
+}
+
+func (s *webhookSink) Send(data *Data) error {
+	body, err := json.Marshal(data.masked())
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal telemetry data")
+	}
+	header := toHeader(s.headers)
+	header.Set("Content-Type", "application/json")
+	response, err := s.client.SendRequest(http.MethodPost, s.url, bytes.NewReader(body), header, nil)
+	closeBody(response)
+	return errors.Wrapf(err, "failed to send telemetry data to %v", s.url)
+}
+
+// pushgatewaySink pushes the data as metrics to a Prometheus Pushgateway.
+// The metrics are grouped by job and step, i.e. each step run replaces the metrics of the previous run of the step.
+type pushgatewaySink struct {
+	url     string
+	job     string
+	headers map[string]string
+	client  piperhttp.Sender
+}
+
+func (s *pushgatewaySink) Send(data *Data) error {
+	pushURL := fmt.Sprintf("%v/metrics/job/%v/step/%v", s.url, url.PathEscape(s.job), url.PathEscape(data.StepName))
+	header := toHeader(s.headers)
+	header.Set("Content-Type", "text/plain; version=0.0.4")
+	response, err := s.client.SendRequest(http.MethodPut, pushURL, strings.NewReader(data.masked().toMetrics()), header, nil)
+	closeBody(response)
+	return errors.Wrapf(err, "failed to push telemetry data to %v", s.url)
+}
+
+// masked returns a copy of the data in which secrets are masked in all string fields.
+// Masking happens before the data is encoded since encoding may change the representation of a secret, e.g. by escaping quotes.
+func (d *Data) masked() *Data {
+	masked := *d
+	maskStringFields(reflect.ValueOf(&masked).Elem())
+	return &masked
+}
+
+func maskStringFields(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(log.MaskSecrets(field.String()))
+		case field.Kind() == reflect.Struct && value.Type().Field(i).Anonymous:
+			// BaseData and CustomData
+			maskStringFields(field)
+		}
+	}
+}
+
+// toMetrics transfers the data object into the Prometheus text format.
+// Numeric fields of the step related data result in a metric each, all other fields are labels of the metric piper_step_info.
+func (d *Data) toMetrics() string {
+	labels := map[string]string{
+		"stage":             d.StageName,
+		"pipeline_url_hash": d.PipelineURLHash,
+		"build_url_hash":    d.BuildURLHash,
+		"error_category":    d.ErrorCategory,
+	}
+	metrics := map[string]float64{"piper_step_info": 1}
+	if duration, err := strconv.ParseFloat(d.Duration, 64); err == nil {
+		metrics["piper_step_duration_seconds"] = duration / 1000
+	}
+	failed := 0.0
+	if d.ErrorCode != "0" {
+		failed = 1
+	}
+	metrics["piper_step_failed"] = failed
+
+	value := reflect.ValueOf(d.CustomData)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !strings.Contains(field.Tag.Get("swa"), ",") || value.Field(i).IsZero() {
+			continue
+		}
+		name := toSnakeCase(jsonName(field))
+		switch fieldValue := value.Field(i).Interface().(type) {
+		case int, int64, float64:
+			metrics["piper_step_"+name], _ = strconv.ParseFloat(fmt.Sprint(fieldValue), 64)
+		case bool:
+			metrics["piper_step_"+name] = 0
+			if fieldValue {
+				metrics["piper_step_"+name] = 1
+			}
+		default:
+			labels[name] = fmt.Sprint(fieldValue)
+		}
+	}
+
+	names := []string{}
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result strings.Builder
+	for _, name := range names {
+		metricLabels := ""
+		if name == "piper_step_info" {
+			metricLabels = formatLabels(labels)
+		}
+		fmt.Fprintf(&result, "# TYPE %v gauge\n%v%v %v\n", name, name, metricLabels, strconv.FormatFloat(metrics[name], 'f', -1, 64))
+	}
+	return result.String()
+}
+
+func formatLabels(labels map[string]string) string {
+	names := []string{}
+	for name, value := range labels {
+		if len(value) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	pairs := []string{}
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%v="%v"`, name, escaper.Replace(labels[name])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// toSnakeCase converts a camel case name like buildTool into build_tool
+func toSnakeCase(name string) string {
+	var result strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				result.WriteRune('_')
+			}
+			r += 'a' - 'A'
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+func toHeader(headers map[string]string) http.Header {
+	header := http.Header{}
+	for name, value := range headers {
+		header.Set(name, value)
+	}
+	return header
+}
+
+func closeBody(response *http.Response) {
+	if response != nil && response.Body != nil {
+		response.Body.Close()
+	}
+}
@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSink(t *testing.T) {
+	t.Run("success case", func(t *testing.T) {
+		sink, err := NewSink(SinkConfiguration{Type: SinkTypePushgateway, URL: "http://pushgateway:9091/"})
+		require.NoError(t, err)
+		assert.Equal(t, "http://pushgateway:9091", sink.(*pushgatewaySink).url)
+		assert.Equal(t, "piper", sink.(*pushgatewaySink).job)
+	})
+
+	t.Run("error case", func(t *testing.T) {
+		_, err := NewSink(SinkConfiguration{Type: "unknown"})
+		assert.EqualError(t, err, "unknown telemetry sink type 'unknown'")
+		_, err = NewSink(SinkConfiguration{Type: SinkTypeFile})
+		assert.EqualError(t, err, "telemetry sink of type file requires a path")
+		_, err = NewSink(SinkConfiguration{Type: SinkTypeWebhook})
+		assert.EqualError(t, err, "telemetry sink of type webhook requires a url")
+	})
+
+	t.Run("invalid configurations are skipped", func(t *testing.T) {
+		defer ConfigureSinks(nil)
+		ConfigureSinks([]SinkConfiguration{{Type: "unknown"}, {Type: SinkTypeFile, Path: "telemetry.jsonl"}})
+		assert.Len(t, sinks, 1)
+	})
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics", "telemetry.jsonl")
+	sink, err := NewSink(SinkConfiguration{Type: SinkTypeFile, Path: path})
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Send(&Data{BaseData: BaseData{StepName: "mavenBuild", ActionName: actionName}, CustomData: CustomData{ErrorCode: "0", BuildTool: "maven"}}))
+	log.RegisterSecret(`file"Secret&`)
+	require.NoError(t, sink.Send(&Data{BaseData: BaseData{StepName: "kanikoExecute"}, CustomData: CustomData{ErrorCode: "1", FilePath: `sub/file"Secret&/Dockerfile`}}))
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	data := Data{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &data))
+	assert.Equal(t, "mavenBuild", data.StepName)
+	assert.Equal(t, "maven", data.BuildTool)
+	assert.Empty(t, data.ActionName, "SWA specific data must not be written")
+	assert.Contains(t, lines[1], `"errorCode":"1"`)
+	assert.Contains(t, lines[1], `"filePath":"sub/****/Dockerfile"`)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var request *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+	// encoding the secret as JSON changes its representation
+	log.RegisterSecret(`telemetry"Secret&`)
+
+	sink, err := NewSink(SinkConfiguration{Type: SinkTypeWebhook, URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(&Data{BaseData: BaseData{StepName: "kanikoExecute"}, CustomData: CustomData{ContainerBuildOptions: `--build-arg password=telemetry"Secret&`}}))
+
+	assert.Equal(t, http.MethodPost, request.Method)
+	assert.Equal(t, "Bearer token", request.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), `"stepName":"kanikoExecute"`)
+	assert.Contains(t, string(body), `"containerBuildOptions":"--build-arg password=****"`)
+}
+
+func TestPushgatewaySink(t *testing.T) {
+	t.Run("success case", func(t *testing.T) {
+		var request *http.Request
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request = r
+			body, _ = ioutil.ReadAll(r.Body)
+		}))
+		defer server.Close()
+
+		sink, err := NewSink(SinkConfiguration{Type: SinkTypePushgateway, URL: server.URL, Job: "my pipeline"})
+		require.NoError(t, err)
+		require.NoError(t, sink.Send(&Data{
+			BaseData:   BaseData{StepName: "artifactPrepareVersion", PipelineURLHash: "n/a", BuildURLHash: "n/a"},
+			CustomData: CustomData{Duration: "1500", ErrorCode: "0", ErrorCategory: "undefined", BuildTool: "maven", FilePath: `path\"pom.xml"`},
+		}))
+
+		assert.Equal(t, http.MethodPut, request.Method)
+		assert.Equal(t, "/metrics/job/my%20pipeline/step/artifactPrepareVersion", request.URL.EscapedPath())
+		assert.Equal(t, `# TYPE piper_step_duration_seconds gauge
+piper_step_duration_seconds 1.5
+# TYPE piper_step_failed gauge
+piper_step_failed 0
+# TYPE piper_step_info gauge
+piper_step_info{build_tool="maven",build_url_hash="n/a",error_category="undefined",file_path="path\\\"pom.xml\"",pipeline_url_hash="n/a"} 1
+`, string(body))
+	})
+
+	t.Run("error case", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		sink, err := NewSink(SinkConfiguration{Type: SinkTypePushgateway, URL: server.URL})
+		require.NoError(t, err)
+		err = sink.Send(&Data{BaseData: BaseData{StepName: "mavenBuild"}, CustomData: CustomData{ErrorCode: "1"}})
+		assert.Contains(t, err.Error(), "failed to push telemetry data to "+server.URL)
+	})
+}
+
+func TestToSnakeCase(t *testing.T) {
+	assert.Equal(t, "build_tool", toSnakeCase("buildTool"))
+	assert.Equal(t, "container_build_options", toSnakeCase("containerBuildOptions"))
+	assert.Equal(t, "tool", toSnakeCase("Tool"))
+}
@@ -6,9 +6,6 @@ import (
 	"os"
 	"time"
 
-	"net/http"
-	"net/url"
-
 	piperhttp "github.com/SAP/jenkins-library/pkg/http"
 	"github.com/SAP/jenkins-library/pkg/log"
 )
@@ -35,15 +32,18 @@ func Initialize(telemetryDisabled bool, stepName string) {
 	// skip if telemetry is dieabled
 	if disabled {
 		log.Entry().Info("Telemetry reporting deactivated")
-		return
-	}
-
-	if client == nil {
-		client = &piperhttp.Client{}
+		// the configured sinks receive the data nevertheless, see ConfigureSinks
+		if len(sinks) == 0 {
+			return
+		}
+	} else {
+		if client == nil {
+			client = &piperhttp.Client{}
+		}
+
+		client.SetOptions(piperhttp.ClientOptions{MaxRequestDuration: 5 * time.Second})
 	}
 
-	client.SetOptions(piperhttp.ClientOptions{MaxRequestDuration: 5 * time.Second})
-
 	if len(LibraryRepository) == 0 {
 		LibraryRepository = "https://github.com/n/a"
 	}
@@ -86,22 +86,22 @@ const baseURL = "https://webanalytics.cfapps.eu10.hana.ondemand.com"
 // SWA endpoint
 const endpoint = "/tracker/log"
 
-// Send ...
+// Send sends the telemetry data to SWA, unless telemetry reporting is deactivated, and to the configured sinks.
+// Failures are logged but do not fail the step.
 func Send(customData *CustomData) {
 	data := Data{
-		BaseData:     baseData,
-		BaseMetaData: baseMetaData,
-		CustomData:   *customData,
+		Time:       time.Now().UTC(),
+		BaseData:   baseData,
+		CustomData: *customData,
 	}
 
-	// skip if telemetry is dieabled
-	if disabled {
-		return
+	targets := sinks
+	if !disabled {
+		targets = append([]Sink{&swaSink{client: client}}, sinks...)
+	}
+	for _, sink := range targets {
+		if err := sink.Send(&data); err != nil {
+			log.Entry().WithError(err).Warning("Failed to send telemetry data")
+		}
 	}
-
-	request, _ := url.Parse(baseURL)
-	request.Path = endpoint
-	request.RawQuery = data.toPayloadString()
-	log.Entry().WithField("request", request.String()).Debug("Sending telemetry data")
-	client.SendRequest(http.MethodGet, request.String(), nil, nil, nil)
 }
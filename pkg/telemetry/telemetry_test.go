@@ -6,10 +6,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
 
 	piperhttp "github.com/SAP/jenkins-library/pkg/http"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type clientMock struct {
@@ -39,6 +41,18 @@ func TestInitialise(t *testing.T) {
 		assert.Equal(t, BaseData{}, baseData)
 	})
 
+	t.Run("with disabled telemetry and configured sinks", func(t *testing.T) {
+		// init
+		client = nil
+		ConfigureSinks([]SinkConfiguration{{Type: SinkTypeFile, Path: "telemetry.jsonl"}})
+		defer ConfigureSinks(nil)
+		// test
+		Initialize(true, "testStep")
+		// assert
+		assert.Equal(t, nil, client)
+		assert.Equal(t, "testStep", baseData.StepName)
+	})
+
 	t.Run("", func(t *testing.T) {
 		// init
 		client = nil
@@ -72,17 +86,37 @@ func TestSend(t *testing.T) {
 		}
 		// test
 		Send(&CustomData{
-			Custom1:      "test",
-			Custom1Label: "label",
+			BuildTool: "maven",
 		})
 		// assert
 		assert.Equal(t, "GET", mock.httpMethod)
 		assert.Contains(t, mock.urlsCalled, baseURL)
-		assert.Contains(t, mock.urlsCalled, "custom26=label")
-		assert.Contains(t, mock.urlsCalled, "e_26=test")
+		assert.Contains(t, mock.urlsCalled, "custom26=buildTool")
+		assert.Contains(t, mock.urlsCalled, "e_26=maven")
 		assert.Contains(t, mock.urlsCalled, "action_name=testAction")
 	})
+
+	t.Run("with configured sinks", func(t *testing.T) {
+		// init
+		mock = clientMock{}
+		client = &mock
+		disabled = true
+		dir, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		ConfigureSinks([]SinkConfiguration{{Type: SinkTypeFile, Path: filepath.Join(dir, "telemetry.jsonl")}})
+		defer ConfigureSinks(nil)
+		baseData = BaseData{StepName: "testStep"}
+		// test
+		Send(&CustomData{ErrorCode: "0"})
+		// assert
+		assert.Equal(t, 0, len(mock.urlsCalled), "SWA must not be called in case telemetry is deactivated")
+		content, err := ioutil.ReadFile(filepath.Join(dir, "telemetry.jsonl"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"stepName":"testStep"`)
+	})
 }
+
 func TestEnvVars(t *testing.T) {
 	t.Run("without values", func(t *testing.T) {
 		// init
@@ -0,0 +1,79 @@
+// +build !release
+
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Collector is an in-process OTLP/HTTP receiver which records the exported spans, it is intended for tests
+type Collector struct {
+	server  *httptest.Server
+	mutex   sync.Mutex
+	spans   []SpanData
+	headers []http.Header
+}
+
+// NewCollector starts a collector, its Endpoint can be used as Options.Endpoint
+func NewCollector() *Collector {
+	c := &Collector{}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		request := ExportTraceServiceRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.headers = append(c.headers, r.Header)
+		for _, resourceSpans := range request.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				c.spans = append(c.spans, scopeSpans.Spans...)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	return c
+}
+
+// Endpoint returns the base URL of the collector
+func (c *Collector) Endpoint() string {
+	return c.server.URL
+}
+
+// Spans returns all received spans
+func (c *Collector) Spans() []SpanData {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]SpanData{}, c.spans...)
+}
+
+// Span returns the first received span with the name
+func (c *Collector) Span(name string) (SpanData, bool) {
+	for _, span := range c.Spans() {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return SpanData{}, false
+}
+
+// Headers returns the headers of the received export requests
+func (c *Collector) Headers() []http.Header {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]http.Header{}, c.headers...)
+}
+
+// Close stops the collector
+func (c *Collector) Close() {
+	c.server.Close()
+}
@@ -0,0 +1,219 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+)
+
+// The types below define the JSON encoding of OTLP/HTTP, see https://github.com/open-telemetry/opentelemetry-proto
+
+// ExportTraceServiceRequest is the payload of an OTLP trace export
+type ExportTraceServiceRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+// ResourceSpans contains the spans of a resource, i.e. a step run
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+// Resource describes the entity producing the spans
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+// ScopeSpans contains the spans of an instrumentation scope
+type ScopeSpans struct {
+	Scope Scope      `json:"scope"`
+	Spans []SpanData `json:"spans"`
+}
+
+// Scope is the instrumentation scope
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// SpanData is an exported span
+type SpanData struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              SpanKind   `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Status            Status     `json:"status"`
+}
+
+// Attribute returns the value of an attribute or nil
+func (s SpanData) Attribute(key string) interface{} {
+	for _, attribute := range s.Attributes {
+		if attribute.Key == key {
+			return attribute.Value.Value()
+		}
+	}
+	return nil
+}
+
+// KeyValue is an attribute
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue is the value of an attribute, exactly one field is set
+type AnyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+	ArrayValue  *ArrayValue `json:"arrayValue,omitempty"`
+}
+
+// ArrayValue is a list of values
+type ArrayValue struct {
+	Values []AnyValue `json:"values"`
+}
+
+// Value returns the value as string, bool, int64, float64 or []interface{}
+func (v AnyValue) Value() interface{} {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		value, _ := strconv.ParseInt(*v.IntValue, 10, 64)
+		return value
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.ArrayValue != nil:
+		values := []interface{}{}
+		for _, value := range v.ArrayValue.Values {
+			values = append(values, value.Value())
+		}
+		return values
+	}
+	return nil
+}
+
+// Status is the status of a span
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type exporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+func newExporter(endpoint string, headers map[string]string) *exporter {
+	return &exporter{endpoint: endpoint, headers: headers, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (e *exporter) export(resource map[string]interface{}, spans []*Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	request := ExportTraceServiceRequest{ResourceSpans: []ResourceSpans{{
+		Resource:   Resource{Attributes: toKeyValues(resource)},
+		ScopeSpans: []ScopeSpans{{Scope: Scope{Name: "github.com/SAP/jenkins-library"}}},
+	}}}
+	for _, span := range spans {
+		request.ResourceSpans[0].ScopeSpans[0].Spans = append(request.ResourceSpans[0].ScopeSpans[0].Spans, span.data())
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	httpRequest, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	for name, value := range e.headers {
+		httpRequest.Header.Set(name, value)
+	}
+	response, err := e.client.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("failed to send spans to %v: %w", e.endpoint, err)
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("sending spans to %v failed with status %v", e.endpoint, response.Status)
+	}
+	return nil
+}
+
+func (s *Span) data() SpanData {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data := SpanData{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		Attributes:        toKeyValues(s.attributes),
+		Status:            Status{Code: s.status, Message: log.MaskSecrets(s.message)},
+	}
+	if s.parentSpanID != [8]byte{} {
+		data.ParentSpanID = hex.EncodeToString(s.parentSpanID[:])
+	}
+	return data
+}
+
+func toKeyValues(attributes map[string]interface{}) []KeyValue {
+	keys := []string{}
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	keyValues := []KeyValue{}
+	for _, key := range keys {
+		keyValues = append(keyValues, KeyValue{Key: key, Value: toAnyValue(attributes[key])})
+	}
+	return keyValues
+}
+
+func toAnyValue(value interface{}) AnyValue {
+	switch v := value.(type) {
+	case string:
+		masked := log.MaskSecrets(v)
+		return AnyValue{StringValue: &masked}
+	case bool:
+		return AnyValue{BoolValue: &v}
+	case int:
+		intValue := strconv.Itoa(v)
+		return AnyValue{IntValue: &intValue}
+	case int64:
+		intValue := strconv.FormatInt(v, 10)
+		return AnyValue{IntValue: &intValue}
+	case float64:
+		return AnyValue{DoubleValue: &v}
+	case []string:
+		values := []AnyValue{}
+		for _, entry := range v {
+			values = append(values, toAnyValue(entry))
+		}
+		return AnyValue{ArrayValue: &ArrayValue{Values: values}}
+	}
+	return toAnyValue(fmt.Sprint(value))
+}
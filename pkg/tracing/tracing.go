@@ -0,0 +1,279 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+)
+
+// SpanKind describes the relationship of a span to its parent, the values correspond to OpenTelemetry
+type SpanKind int
+
+// Span kinds used by piper
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindClient   SpanKind = 3
+)
+
+// Status codes of a span, the values correspond to OpenTelemetry
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// Options configure the tracing of a step run
+type Options struct {
+	// Endpoint is the base URL of an OTLP/HTTP receiver, e.g. http://localhost:4318, spans are sent to <Endpoint>/v1/traces.
+	// In case it is empty the environment variables OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and OTEL_EXPORTER_OTLP_ENDPOINT are used.
+	Endpoint string
+	// Headers are sent with each export, in addition to the ones of the environment variable OTEL_EXPORTER_OTLP_HEADERS
+	Headers       map[string]string
+	Step          string
+	Stage         string
+	CorrelationID string
+	// TraceParent is the W3C trace context of the parent span, the step span becomes the root of a new trace if it is empty
+	TraceParent string
+	Start       time.Time
+}
+
+// Span is a timed operation within a trace, all methods can be called on a nil span which is returned in case tracing is disabled
+type Span struct {
+	mutex        sync.Mutex
+	tracer       *tracer
+	traceID      [16]byte
+	spanID       [8]byte
+	parentSpanID [8]byte
+	name         string
+	kind         SpanKind
+	start        time.Time
+	end          time.Time
+	attributes   map[string]interface{}
+	status       int
+	message      string
+	ended        bool
+}
+
+type tracer struct {
+	exporter *exporter
+	resource map[string]interface{}
+	stepSpan *Span
+	mutex    sync.Mutex
+	spans    []*Span
+}
+
+var current *tracer
+
+// Initialize starts the span of the step run, tracing is disabled in case no endpoint is configured
+func Initialize(options Options) {
+	current = nil
+	endpoint := options.Endpoint
+	if len(endpoint) > 0 {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	} else if endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); len(endpoint) == 0 {
+		if endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); len(endpoint) > 0 {
+			endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+		}
+	}
+	if len(endpoint) == 0 {
+		return
+	}
+
+	headers := parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	for name, value := range options.Headers {
+		headers[name] = value
+	}
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if len(serviceName) == 0 {
+		serviceName = "piper"
+	}
+
+	t := &tracer{
+		exporter: newExporter(endpoint, headers),
+		resource: map[string]interface{}{"service.name": serviceName, "piper.correlation_id": options.CorrelationID},
+	}
+
+	start := options.Start
+	if start.IsZero() {
+		start = time.Now()
+	}
+	span := &Span{tracer: t, name: options.Step, kind: SpanKindInternal, start: start, attributes: map[string]interface{}{}}
+	if traceID, spanID, err := ParseTraceParent(options.TraceParent); err == nil {
+		span.traceID, span.parentSpanID = traceID, spanID
+	} else {
+		if len(options.TraceParent) > 0 {
+			log.Entry().WithError(err).Warn("ignoring trace context")
+		}
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.spanID[:])
+	span.SetAttribute("piper.step", options.Step)
+	if len(options.Stage) > 0 {
+		span.SetAttribute("piper.stage", options.Stage)
+	}
+	t.stepSpan = span
+	current = t
+}
+
+// Enabled returns whether spans are recorded
+func Enabled() bool {
+	return current != nil
+}
+
+// StepSpan returns the span of the step run
+func StepSpan() *Span {
+	if current == nil {
+		return nil
+	}
+	return current.stepSpan
+}
+
+// StartSpan starts a span as child of the span within the context or of the step span
+func StartSpan(ctx context.Context, name string, kind SpanKind) *Span {
+	t := current
+	if t == nil {
+		return nil
+	}
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		parent = t.stepSpan
+	}
+	span := &Span{tracer: t, traceID: parent.traceID, parentSpanID: parent.spanID, name: name, kind: kind, start: time.Now(), attributes: map[string]interface{}{}}
+	rand.Read(span.spanID[:])
+	return span
+}
+
+// StepFinished ends the step span with the result of the step and exports all spans of the step run
+func StepFinished(errorCode, errorCategory string) {
+	t := current
+	if t == nil {
+		return
+	}
+	current = nil
+
+	span := t.stepSpan
+	span.SetAttribute("piper.error_code", errorCode)
+	span.SetAttribute("piper.error_category", errorCategory)
+	if errorCode != "0" {
+		span.SetStatus(StatusError, fmt.Sprintf("step failed with error category %v", errorCategory))
+	} else {
+		span.SetStatus(StatusOK, "")
+	}
+	span.End()
+
+	t.mutex.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mutex.Unlock()
+	if err := t.exporter.export(t.resource, spans); err != nil {
+		log.Entry().WithError(err).Warn("failed to export traces")
+	}
+}
+
+type contextKey struct{}
+
+// ContextWithSpan returns a context containing the span as parent for further spans
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, span)
+}
+
+// SpanFromContext returns the span of the context or nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(contextKey{}).(*Span)
+	return span
+}
+
+// SetAttribute sets an attribute of the span, registered secrets within string values are masked when the span is exported
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attributes[key] = value
+}
+
+// SetStatus sets the status of the span
+func (s *Span) SetStatus(code int, message string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status, s.message = code, message
+}
+
+// SetError marks the span as failed in case of an error
+func (s *Span) SetError(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+// End ends the span, it is exported together with the step span
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mutex.Unlock()
+
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// TraceParent returns the W3C trace context of the span, see https://www.w3.org/TR/trace-context/#traceparent-header
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%x-%x-01", s.traceID, s.spanID)
+}
+
+// ParseTraceParent returns the trace id and the span id of a W3C trace context
+func ParseTraceParent(traceParent string) ([16]byte, [8]byte, error) {
+	var traceID [16]byte
+	var spanID [8]byte
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return traceID, spanID, fmt.Errorf("invalid trace context '%v'", traceParent)
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return traceID, spanID, fmt.Errorf("invalid trace id in trace context '%v'", traceParent)
+	}
+	if _, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil || spanID == [8]byte{} {
+		return traceID, spanID, fmt.Errorf("invalid span id in trace context '%v'", traceParent)
+	}
+	return traceID, spanID, nil
+}
+
+// parseHeaders parses headers of the form name1=value1,name2=value2
+func parseHeaders(value string) map[string]string {
+	headers := map[string]string{}
+	for _, header := range strings.Split(value, ",") {
+		if index := strings.Index(header, "="); index > 0 {
+			headers[strings.TrimSpace(header[:index])] = strings.TrimSpace(header[index+1:])
+		}
+	}
+	return headers
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracing(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		os.Unsetenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+
+		Initialize(Options{Step: "mavenBuild"})
+
+		assert.False(t, Enabled())
+		span := StartSpan(context.Background(), "mvn", SpanKindInternal)
+		assert.Nil(t, span)
+		// calls on a disabled span must not fail
+		span.SetAttribute("key", "value")
+		span.SetError(errors.New("error"))
+		span.End()
+		assert.Empty(t, span.TraceParent())
+		StepFinished("0", "undefined")
+	})
+
+	t.Run("step with child spans", func(t *testing.T) {
+		collector := NewCollector()
+		defer collector.Close()
+		log.RegisterSecret("tracingSecret")
+
+		Initialize(Options{Endpoint: collector.Endpoint(), Headers: map[string]string{"Authorization": "Bearer token"}, Step: "mavenBuild", Stage: "Build", CorrelationID: "https://build.url"})
+		require.True(t, Enabled())
+
+		command := StartSpan(context.Background(), "mvn", SpanKindInternal)
+		command.SetAttribute("process.command_args", []string{"install", "-Dpassword=tracingSecret"})
+		command.SetAttribute("process.exit_code", 1)
+		command.SetError(errors.New("exit status 1"))
+		ctx := ContextWithSpan(context.Background(), command)
+		request := StartSpan(ctx, "HTTP GET", SpanKindClient)
+		request.SetAttribute("http.status_code", 200)
+		request.End()
+		command.End()
+		command.End()
+		StepFinished("1", "build")
+
+		assert.False(t, Enabled())
+		spans := collector.Spans()
+		require.Len(t, spans, 3)
+		step, _ := collector.Span("mavenBuild")
+		mvn, _ := collector.Span("mvn")
+		get, _ := collector.Span("HTTP GET")
+
+		assert.Empty(t, step.ParentSpanID)
+		assert.Equal(t, step.SpanID, mvn.ParentSpanID)
+		assert.Equal(t, mvn.SpanID, get.ParentSpanID)
+		assert.Equal(t, step.TraceID, mvn.TraceID)
+		assert.Equal(t, step.TraceID, get.TraceID)
+
+		assert.Equal(t, "Build", step.Attribute("piper.stage"))
+		assert.Equal(t, "build", step.Attribute("piper.error_category"))
+		assert.Equal(t, Status{Code: StatusError, Message: "step failed with error category build"}, step.Status)
+		assert.Equal(t, []interface{}{"install", "-Dpassword=****"}, mvn.Attribute("process.command_args"))
+		assert.Equal(t, int64(1), mvn.Attribute("process.exit_code"))
+		assert.Equal(t, StatusError, mvn.Status.Code)
+		assert.Equal(t, SpanKindClient, get.Kind)
+		assert.Equal(t, int64(200), get.Attribute("http.status_code"))
+
+		assert.Equal(t, "Bearer token", collector.Headers()[0].Get("Authorization"))
+		assert.Equal(t, "application/json", collector.Headers()[0].Get("Content-Type"))
+	})
+
+	t.Run("trace context from environment", func(t *testing.T) {
+		collector := NewCollector()
+		defer collector.Close()
+		os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.Endpoint()+"/")
+		os.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-tenant=piper, x-other = value")
+		os.Setenv("OTEL_SERVICE_NAME", "pipeline")
+		defer os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		defer os.Unsetenv("OTEL_EXPORTER_OTLP_HEADERS")
+		defer os.Unsetenv("OTEL_SERVICE_NAME")
+
+		start := time.Now().Add(-time.Minute)
+		Initialize(Options{Step: "npmExecuteScripts", TraceParent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", Start: start})
+		assert.Regexp(t, "^00-0af7651916cd43dd8448eb211c80319c-[0-9a-f]{16}-01$", StepSpan().TraceParent())
+		StepFinished("0", "undefined")
+
+		spans := collector.Spans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", spans[0].TraceID)
+		assert.Equal(t, "b7ad6b7169203331", spans[0].ParentSpanID)
+		assert.Equal(t, StatusOK, spans[0].Status.Code)
+		assert.Equal(t, "piper", collector.Headers()[0].Get("x-tenant"))
+		assert.Equal(t, "value", collector.Headers()[0].Get("x-other"))
+	})
+
+	t.Run("export failure", func(t *testing.T) {
+		collector := NewCollector()
+		endpoint := collector.Endpoint()
+		collector.Close()
+
+		Initialize(Options{Endpoint: endpoint, Step: "mavenBuild"})
+		// failures are only logged
+		StepFinished("0", "undefined")
+		assert.False(t, Enabled())
+	})
+}
+
+func TestParseTraceParent(t *testing.T) {
+	traceID, spanID, err := ParseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	assert.NoError(t, err)
+	assert.Equal(t, [16]byte{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c}, traceID)
+	assert.Equal(t, [8]byte{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31}, spanID)
+
+	for _, invalid := range []string{"", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331", "00-00000000000000000000000000000000-b7ad6b7169203331-01", "00-0af7651916cd43dd8448eb211c80319c-zzad6b7169203331-01"} {
+		_, _, err := ParseTraceParent(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
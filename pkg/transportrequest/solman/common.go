@@ -13,7 +13,7 @@ type Exec interface {
 
 // Connection Everything we need for connecting to CTS
 type Connection struct {
-	Endpoint string
-	User     string
-	Password string
+	Endpoint string `validate:"required"`
+	User     string `validate:"required"`
+	Password string `validate:"required"`
 }
@@ -13,8 +13,8 @@ import (
 // CreateAction Collects all the properties we need for creating a transport request
 type CreateAction struct {
 	Connection          Connection
-	ChangeDocumentID    string
-	DevelopmentSystemID string
+	ChangeDocumentID    string `validate:"required"`
+	DevelopmentSystemID string `validate:"required"`
 	CMOpts              []string
 }
 
@@ -55,13 +55,7 @@ func (a *CreateAction) Perform(command Exec) (string, error) {
 
 	log.Entry().Infof("Creating new transport request via '%s'.", a.Connection.Endpoint)
 
-	missingParameters, err := validation.FindEmptyStringsInConfigStruct(*a)
-
-	if err == nil {
-		if len(missingParameters) != 0 {
-			err = fmt.Errorf("the following parameters are not available %s", missingParameters)
-		}
-	}
+	err := validation.ValidateStruct(*a)
 
 	var transportRequestID string
 
@@ -81,11 +81,11 @@ func TestSolmanCreateTransportRequest(t *testing.T) {
 
 		if assert.Error(t, err) {
 			// I don't want to rely on the order of the parameters
-			assert.Contains(t, err.Error(), "cannot create transport request: the following parameters are not available")
-			assert.Contains(t, err.Error(), "Connection.Endpoint")
-			assert.Contains(t, err.Error(), "Connection.User")
-			assert.Contains(t, err.Error(), "Connection.Password")
-			assert.Contains(t, err.Error(), "ChangeDocumentID")
+			assert.Contains(t, err.Error(), "cannot create transport request: the configuration is invalid")
+			assert.Contains(t, err.Error(), "parameter 'Connection.Endpoint' is required")
+			assert.Contains(t, err.Error(), "parameter 'Connection.User' is required")
+			assert.Contains(t, err.Error(), "parameter 'Connection.Password' is required")
+			assert.Contains(t, err.Error(), "parameter 'ChangeDocumentID' is required")
 			assert.Empty(t, e.Calls)
 		}
 	})
@@ -17,10 +17,10 @@ type FileSystem interface {
 // UploadAction Collects all the properties we need for the deployment
 type UploadAction struct {
 	Connection         Connection
-	ChangeDocumentID   string
-	TransportRequestID string
-	ApplicationID      string
-	File               string
+	ChangeDocumentID   string `validate:"required"`
+	TransportRequestID string `validate:"required"`
+	ApplicationID      string `validate:"required"`
+	File               string `validate:"required"`
 	CMOpts             []string
 }
 
@@ -76,14 +76,7 @@ func (a *UploadAction) Perform(fs FileSystem, command Exec) error {
 	log.Entry().Infof("Deploying artifact '%s' to '%s'.",
 		a.File, a.Connection.Endpoint)
 
-	missingParameters, err := validation.FindEmptyStringsInConfigStruct(*a)
-
-	if err == nil {
-		notInitialized := len(missingParameters) != 0
-		if notInitialized {
-			err = fmt.Errorf("the following parameters are not available '%s'", missingParameters)
-		}
-	}
+	err := validation.ValidateStruct(*a)
 
 	if err == nil {
 		var exists bool
@@ -76,9 +76,9 @@ func TestSolmanUpload(t *testing.T) {
 		err := uploadAction.Perform(f, e)
 		if assert.Error(t, err) {
 			// we should not rely on the order of the missing parameters
-			assert.Contains(t, err.Error(), "cannot upload artifact 'myDeployable.xxx': the following parameters are not available")
-			assert.Contains(t, err.Error(), "Connection.Endpoint")
-			assert.Contains(t, err.Error(), "TransportRequestID")
+			assert.Contains(t, err.Error(), "cannot upload artifact 'myDeployable.xxx': the configuration is invalid")
+			assert.Contains(t, err.Error(), "parameter 'Connection.Endpoint' is required")
+			assert.Contains(t, err.Error(), "parameter 'TransportRequestID' is required")
 		}
 	})
 
@@ -16,6 +16,7 @@ import (
 type Client struct {
 	lClient logicalClient
 	config  *Config
+	leases  *leaseManager
 }
 
 // Config contains the vault client configuration
@@ -47,7 +48,7 @@ func NewClient(config *Config, token string) (Client, error) {
 
 	client.SetToken(token)
 	log.Entry().Debugf("Login to vault %s in namespace %s successfull", config.Address, config.Namespace)
-	return Client{client.Logical(), config}, nil
+	return Client{lClient: client.Logical(), config: config, leases: newLeaseManager()}, nil
 }
 
 // NewClientWithAppRole instantiates a new client and obtains a token via the AppRole auth method
@@ -27,7 +27,7 @@ func TestGetKV2Secret(t *testing.T) {
 
 	t.Run("Test missing secret", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		setupMockKvV2(vaultMock)
 		vaultMock.On("Read", "secret/data/notexist").Return(nil, nil)
 		secret, err := client.GetKvSecret("secret/notexist")
@@ -42,7 +42,7 @@ func TestGetKV2Secret(t *testing.T) {
 		t.Run("Getting secret from KV engine (v2)", func(t *testing.T) {
 			vaultMock := &mocks.VaultMock{}
 			setupMockKvV2(vaultMock)
-			client := Client{vaultMock, &Config{}}
+			client := Client{lClient: vaultMock, config: &Config{}}
 			vaultMock.On("Read", secretAPIPath).Return(kv2Secret(SecretData{"key1": "value1"}), nil)
 			secret, err := client.GetKvSecret(secretName)
 			assert.NoError(t, err, "Expect GetKvSecret to succeed")
@@ -53,7 +53,7 @@ func TestGetKV2Secret(t *testing.T) {
 		t.Run("field ignored when 'data' field can't be parsed", func(t *testing.T) {
 			vaultMock := &mocks.VaultMock{}
 			setupMockKvV2(vaultMock)
-			client := Client{vaultMock, &Config{}}
+			client := Client{lClient: vaultMock, config: &Config{}}
 			vaultMock.On("Read", secretAPIPath).Return(kv2Secret(SecretData{"key1": "value1", "key2": 5}), nil)
 			secret, err := client.GetKvSecret(secretName)
 			assert.NoError(t, err)
@@ -63,7 +63,7 @@ func TestGetKV2Secret(t *testing.T) {
 		t.Run("error is thrown when data field is missing", func(t *testing.T) {
 			vaultMock := &mocks.VaultMock{}
 			setupMockKvV2(vaultMock)
-			client := Client{vaultMock, &Config{}}
+			client := Client{lClient: vaultMock, config: &Config{}}
 			vaultMock.On("Read", secretAPIPath).Return(kv1Secret(SecretData{"key1": "value1"}), nil)
 			secret, err := client.GetKvSecret(secretName)
 			assert.Error(t, err, "Expected to fail since 'data' field is missing")
@@ -79,7 +79,7 @@ func TestGetKV1Secret(t *testing.T) {
 	t.Run("Test missing secret", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
 		setupMockKvV1(vaultMock)
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 
 		vaultMock.On("Read", mock.AnythingOfType("string")).Return(nil, nil)
 		secret, err := client.GetKvSecret("secret/notexist")
@@ -90,7 +90,7 @@ func TestGetKV1Secret(t *testing.T) {
 	t.Run("Test parsing KV1 secrets", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
 		setupMockKvV1(vaultMock)
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 
 		vaultMock.On("Read", secretName).Return(kv1Secret(SecretData{"key1": "value1"}), nil)
 		secret, err := client.GetKvSecret(secretName)
@@ -102,7 +102,7 @@ func TestGetKV1Secret(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
 		setupMockKvV1(vaultMock)
 		vaultMock.On("Read", secretName).Return(kv1Secret(SecretData{"key1": 5}), nil)
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 
 		secret, err := client.GetKvSecret(secretName)
 		assert.NoError(t, err)
@@ -119,7 +119,7 @@ func TestSecretIDGeneration(t *testing.T) {
 
 	t.Run("Test generating new secret-id", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		now := time.Now()
 		expiry := now.Add(5 * time.Hour).Format(time.RFC3339)
 		metadata := map[string]interface{}{
@@ -144,7 +144,7 @@ func TestSecretIDGeneration(t *testing.T) {
 
 	t.Run("Test with no secret-id returned", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		now := time.Now()
 		expiry := now.Add(5 * time.Hour).Format(time.RFC3339)
 		metadata := map[string]interface{}{
@@ -167,7 +167,7 @@ func TestSecretIDGeneration(t *testing.T) {
 
 	t.Run("Test with no new secret-id returned", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		now := time.Now()
 		expiry := now.Add(5 * time.Hour).Format(time.RFC3339)
 		metadata := map[string]interface{}{
@@ -197,7 +197,7 @@ func TestSecretIDTtl(t *testing.T) {
 
 	t.Run("Test fetching secreID TTL", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		now := time.Now()
 		expiry := now.Add(5 * time.Hour).Format(time.RFC3339)
 		vaultMock.On("Write", path.Join(appRolePath, "secret-id/lookup"), mapWith("secret_id", secretID)).Return(kv1Secret(SecretData{
@@ -211,7 +211,7 @@ func TestSecretIDTtl(t *testing.T) {
 
 	t.Run("Test with no expiration time", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Write", path.Join(appRolePath, "secret-id/lookup"), mapWith("secret_id", secretID)).Return(kv1Secret(SecretData{}), nil)
 		ttl, err := client.GetAppRoleSecretIDTtl(secretID, appRoleName)
 		assert.EqualError(t, err, fmt.Sprintf("Could not load secret-id information from path %s", appRolePath))
@@ -220,7 +220,7 @@ func TestSecretIDTtl(t *testing.T) {
 
 	t.Run("Test with wrong date format", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Write", path.Join(appRolePath, "secret-id/lookup"), mapWith("secret_id", secretID)).Return(kv1Secret(SecretData{
 			"expiration_time": time.Now().String(),
 		}), nil)
@@ -231,7 +231,7 @@ func TestSecretIDTtl(t *testing.T) {
 
 	t.Run("Test with expired secret-id", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		now := time.Now()
 		expiry := now.Add(-5 * time.Hour).Format(time.RFC3339)
 		vaultMock.On("Write", path.Join(appRolePath, "secret-id/lookup"), mapWith("secret_id", secretID)).Return(kv1Secret(SecretData{
@@ -250,7 +250,7 @@ func TestGetAppRoleName(t *testing.T) {
 
 	t.Run("Test that correct role name is returned", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Read", "auth/token/lookup-self").Return(kv1Secret(SecretData{
 			"meta": SecretData{
 				"role_name": "test",
@@ -264,7 +264,7 @@ func TestGetAppRoleName(t *testing.T) {
 
 	t.Run("Test without secret data", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Read", "auth/token/lookup-self").Return(kv1Secret(nil), nil)
 
 		appRoleName, err := client.GetAppRoleName()
@@ -274,7 +274,7 @@ func TestGetAppRoleName(t *testing.T) {
 
 	t.Run("Test without metadata data", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Read", "auth/token/lookup-self").Return(kv1Secret(SecretData{}), nil)
 
 		appRoleName, err := client.GetAppRoleName()
@@ -284,7 +284,7 @@ func TestGetAppRoleName(t *testing.T) {
 
 	t.Run("Test without role name in metadata", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Read", "auth/token/lookup-self").Return(kv1Secret(SecretData{
 			"meta": SecretData{},
 		}), nil)
@@ -296,7 +296,7 @@ func TestGetAppRoleName(t *testing.T) {
 
 	t.Run("Test that different role_name types are ignored", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Read", "auth/token/lookup-self").Return(kv1Secret(SecretData{
 			"meta": SecretData{
 				"role_name": 5,
@@ -313,7 +313,7 @@ func TestTokenRevocation(t *testing.T) {
 	t.Parallel()
 	t.Run("Test that revocation error is returned", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Write",
 			"auth/token/revoke-self",
 			mock.IsType(map[string]interface{}{})).Return(nil, errors.New("Test"))
@@ -324,7 +324,7 @@ func TestTokenRevocation(t *testing.T) {
 
 	t.Run("Test that revocation endpoint is called", func(t *testing.T) {
 		vaultMock := &mocks.VaultMock{}
-		client := Client{vaultMock, &Config{}}
+		client := Client{lClient: vaultMock, config: &Config{}}
 		vaultMock.On("Write",
 			"auth/token/revoke-self",
 			mock.IsType(map[string]interface{}{})).Return(nil, nil)
@@ -335,7 +335,7 @@ func TestTokenRevocation(t *testing.T) {
 
 func TestUnknownKvVersion(t *testing.T) {
 	vaultMock := &mocks.VaultMock{}
-	client := Client{vaultMock, &Config{}}
+	client := Client{lClient: vaultMock, config: &Config{}}
 
 	vaultMock.On("Read", "sys/internal/ui/mounts/secret/secret").Return(&api.Secret{
 		Data: map[string]interface{}{
@@ -352,7 +352,7 @@ func TestUnknownKvVersion(t *testing.T) {
 }
 
 func TestSetAppRoleMountPont(t *testing.T) {
-	client := Client{nil, &Config{}}
+	client := Client{config: &Config{}}
 	const newMountpoint = "auth/test"
 
 	client.SetAppRoleMountPoint("auth/test")
@@ -0,0 +1,199 @@
+package vault
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SAP/jenkins-library/pkg/log"
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+)
+
+// leaseCheckInterval is the interval in which the leases are checked for renewal
+var leaseCheckInterval = 10 * time.Second
+
+// Lease is the lease of a dynamic secret
+type Lease struct {
+	ID        string
+	Path      string
+	Renewable bool
+	Duration  time.Duration
+	// RenewAt is the time at which the lease is renewed, i.e. after two thirds of its duration
+	RenewAt time.Time
+}
+
+// leaseManager tracks the leases of dynamic secrets, renews them while the step runs and revokes them at the end of the step
+type leaseManager struct {
+	mutex  sync.Mutex
+	leases []*Lease
+	stop   chan struct{}
+	now    func() time.Time
+}
+
+func newLeaseManager() *leaseManager {
+	return &leaseManager{now: time.Now}
+}
+
+// GetDynamicSecret reads the credentials of a dynamic secrets engine, e.g. database/creds/my-role or aws/creds/my-role.
+// Parameters for engines which require them are passed as query, e.g. kubernetes/creds/my-role?kubernetes_namespace=build.
+// The lease of the credentials is renewed while the step runs until RevokeLeases is called.
+func (v Client) GetDynamicSecret(path string) (map[string]string, error) {
+	path = sanitizePath(path)
+	var secret *api.Secret
+	var err error
+	if index := strings.Index(path, "?"); index >= 0 {
+		query, parseErr := url.ParseQuery(path[index+1:])
+		if parseErr != nil {
+			return nil, errors.Wrapf(parseErr, "invalid parameters of dynamic secret %v", path)
+		}
+		path = sanitizePath(path[:index])
+		data := map[string]interface{}{}
+		for key := range query {
+			data[key] = query.Get(key)
+		}
+		secret, err = v.lClient.Write(path, data)
+	} else {
+		secret, err = v.lClient.Read(path)
+	}
+	if secret == nil || err != nil {
+		return nil, err
+	}
+
+	if secret.LeaseID != "" && v.leases != nil {
+		v.leases.add(&Lease{ID: secret.LeaseID, Path: path, Renewable: secret.Renewable, Duration: time.Duration(secret.LeaseDuration) * time.Second})
+		log.Entry().Debugf("Obtained lease '%v' of dynamic secret '%v' valid for %v", secret.LeaseID, path, time.Duration(secret.LeaseDuration)*time.Second)
+		if secret.Renewable {
+			v.leases.startRenewal(v.renewDue)
+		}
+	}
+
+	secretData := make(map[string]string, len(secret.Data))
+	for k, value := range secret.Data {
+		switch typed := value.(type) {
+		case string:
+			secretData[k] = typed
+		case nil:
+		default:
+			secretData[k] = fmt.Sprint(typed)
+		}
+	}
+	return secretData, nil
+}
+
+// HasLeases returns whether leases of dynamic secrets are held. The token must not be revoked
+// before the leases are no longer needed since revoking the token revokes its leases as well.
+func (v Client) HasLeases() bool {
+	return v.leases != nil && len(v.leases.list()) > 0
+}
+
+// RevokeLeases stops the renewal and revokes all leases of dynamic secrets
+func (v Client) RevokeLeases() error {
+	if v.leases == nil {
+		return nil
+	}
+	v.leases.stopRenewal()
+	var failed []string
+	for _, lease := range v.leases.list() {
+		if _, err := v.lClient.Write("sys/leases/revoke", map[string]interface{}{"lease_id": lease.ID}); err != nil {
+			log.Entry().WithError(err).Warnf("Could not revoke lease '%v'", lease.ID)
+			failed = append(failed, lease.ID)
+			continue
+		}
+		log.Entry().Debugf("Revoked lease '%v' of dynamic secret '%v'", lease.ID, lease.Path)
+	}
+	v.leases.clear()
+	if len(failed) > 0 {
+		return fmt.Errorf("could not revoke leases: %v", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// renewDue renews the token and all renewable leases which are due
+func (v Client) renewDue() {
+	due := []*Lease{}
+	now := v.leases.now()
+	for _, lease := range v.leases.list() {
+		if lease.Renewable && !now.Before(lease.RenewAt) {
+			due = append(due, lease)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+	// the leases are bound to the token, hence it is renewed as well
+	if _, err := v.lClient.Write("auth/token/renew-self", map[string]interface{}{}); err != nil {
+		log.Entry().WithError(err).Warn("Could not renew vault token")
+	}
+	for _, lease := range due {
+		secret, err := v.lClient.Write("sys/leases/renew", map[string]interface{}{"lease_id": lease.ID, "increment": int(lease.Duration.Seconds())})
+		if err != nil || secret == nil {
+			log.Entry().WithError(err).Warnf("Could not renew lease '%v', the credentials of '%v' expire in %v", lease.ID, lease.Path, lease.RenewAt.Add(lease.Duration/3).Sub(now))
+			v.leases.renewed(lease, lease.Duration, false)
+			continue
+		}
+		v.leases.renewed(lease, time.Duration(secret.LeaseDuration)*time.Second, secret.Renewable)
+		log.Entry().Debugf("Renewed lease '%v' for %v", lease.ID, lease.Duration)
+	}
+}
+
+func (l *leaseManager) add(lease *Lease) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	lease.RenewAt = l.now().Add(lease.Duration * 2 / 3)
+	l.leases = append(l.leases, lease)
+}
+
+func (l *leaseManager) renewed(lease *Lease, duration time.Duration, renewable bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	lease.Duration = duration
+	lease.Renewable = renewable
+	lease.RenewAt = l.now().Add(duration * 2 / 3)
+}
+
+func (l *leaseManager) list() []*Lease {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]*Lease{}, l.leases...)
+}
+
+func (l *leaseManager) clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.leases = nil
+}
+
+// startRenewal renews the leases in the background until stopRenewal is called
+func (l *leaseManager) startRenewal(renew func()) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	l.stop = stop
+	ticker := time.NewTicker(leaseCheckInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				renew()
+			}
+		}
+	}()
+}
+
+func (l *leaseManager) stopRenewal() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+}
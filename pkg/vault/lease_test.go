@@ -0,0 +1,138 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault is a local stand-in of a vault dev server providing the endpoints of dynamic secrets engines and leases
+type fakeVault struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	requests []string
+	bodies   map[string]map[string]interface{}
+}
+
+func newFakeVault() *fakeVault {
+	fake := &fakeVault{bodies: map[string]map[string]interface{}{}}
+	fake.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mutex.Lock()
+		defer fake.mutex.Unlock()
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fake.requests = append(fake.requests, r.Method+" "+path)
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		fake.bodies[path] = body
+
+		switch path {
+		case "database/creds/readonly":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       "database/creds/readonly/abc",
+				"lease_duration": 3600,
+				"renewable":      true,
+				"data":           map[string]interface{}{"username": "v-user", "password": "v-password"},
+			})
+		case "kubernetes/creds/deployer":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       "kubernetes/creds/deployer/def",
+				"lease_duration": 600,
+				"renewable":      false,
+				"data":           map[string]interface{}{"service_account_token": "k8s-token", "service_account_namespace": body["kubernetes_namespace"]},
+			})
+		case "sys/leases/renew":
+			json.NewEncoder(w).Encode(map[string]interface{}{"lease_id": body["lease_id"], "lease_duration": 1800, "renewable": true})
+		case "auth/token/renew-self", "sys/leases/revoke", "auth/token/revoke-self":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return fake
+}
+
+func TestDynamicSecrets(t *testing.T) {
+	fake := newFakeVault()
+	defer fake.server.Close()
+	client, err := NewClient(&Config{Config: &api.Config{Address: fake.server.URL}}, "root")
+	require.NoError(t, err)
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	client.leases.now = func() time.Time { return now }
+
+	t.Run("read credentials", func(t *testing.T) {
+		secret, err := client.GetDynamicSecret("/database/creds/readonly")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"username": "v-user", "password": "v-password"}, secret)
+		assert.True(t, client.HasLeases())
+	})
+
+	t.Run("write parameters", func(t *testing.T) {
+		secret, err := client.GetDynamicSecret("kubernetes/creds/deployer?kubernetes_namespace=build")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"service_account_token": "k8s-token", "service_account_namespace": "build"}, secret)
+		assert.Equal(t, map[string]interface{}{"kubernetes_namespace": "build"}, fake.bodies["kubernetes/creds/deployer"])
+	})
+
+	t.Run("unknown secret", func(t *testing.T) {
+		secret, err := client.GetDynamicSecret("database/creds/unknown")
+		assert.NoError(t, err)
+		assert.Nil(t, secret)
+	})
+
+	t.Run("renewal", func(t *testing.T) {
+		fake.requests = nil
+		client.renewDue()
+		assert.Empty(t, fake.requests, "no lease is due")
+
+		// the renewable lease is renewed after two thirds of its duration
+		now = now.Add(40 * time.Minute)
+		client.renewDue()
+		assert.Equal(t, []string{"PUT auth/token/renew-self", "PUT sys/leases/renew"}, fake.requests)
+		assert.Equal(t, map[string]interface{}{"lease_id": "database/creds/readonly/abc", "increment": float64(3600)}, fake.bodies["sys/leases/renew"])
+		leases := client.leases.list()
+		assert.Equal(t, 30*time.Minute, leases[0].Duration)
+		assert.Equal(t, now.Add(20*time.Minute), leases[0].RenewAt)
+	})
+
+	t.Run("revocation", func(t *testing.T) {
+		fake.requests = nil
+		err := client.RevokeLeases()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"PUT sys/leases/revoke", "PUT sys/leases/revoke"}, fake.requests)
+		assert.False(t, client.HasLeases())
+	})
+}
+
+func TestLeaseRenewal(t *testing.T) {
+	interval := leaseCheckInterval
+	leaseCheckInterval = time.Millisecond
+	defer func() { leaseCheckInterval = interval }()
+
+	leases := newLeaseManager()
+	renewed := make(chan bool, 1)
+	leases.startRenewal(func() {
+		select {
+		case renewed <- true:
+		default:
+		}
+	})
+	select {
+	case <-renewed:
+	case <-time.After(time.Second):
+		t.Fatal("leases have not been renewed")
+	}
+	leases.stopRenewal()
+	assert.Nil(t, leases.stop)
+}
@@ -130,7 +130,7 @@ const DateTimeLayout = "2006-01-02 15:04:05 -0700"
 // NewSystem constructs a new System instance
 func NewSystem(serverURL, orgToken, userToken string, timeout time.Duration) *System {
 	httpClient := &piperhttp.Client{}
-	httpClient.SetOptions(piperhttp.ClientOptions{TransportTimeout: timeout})
+	httpClient.SetOptions(piperhttp.ClientOptions{TransportTimeout: timeout}.WithServicePolicies())
 	return &System{
 		serverURL:  serverURL,
 		orgToken:   orgToken,
@@ -0,0 +1,234 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ContainerImage identifies a container image by its name including the registry, e.g. my.registry.com/myImage, and its tag
+type ContainerImage struct {
+	Name string
+	Tag  string
+}
+
+// scalarEdit describes the replacement of a scalar at a position of the original content
+type scalarEdit struct {
+	line   int
+	column int
+	node   *yamlv3.Node
+	value  string
+}
+
+// UpdateImageTags updates the tags of the given images in a YAML document like a Helm values file.
+// Images are detected in the two common forms of Helm charts:
+//
+//   image:
+//     registry: my.registry.com   # optional
+//     repository: myImage
+//     tag: 1.0.0
+//
+//   image: my.registry.com/myImage:1.0.0
+//
+// Only the changed values are replaced within the original content, comments and formatting remain untouched.
+// The names of all images which have been found are returned.
+func UpdateImageTags(content []byte, images []ContainerImage) ([]byte, []string, error) {
+	edits := []scalarEdit{}
+	found := map[string]bool{}
+
+	decoder := yamlv3.NewDecoder(bytes.NewReader(content))
+	for {
+		document := yamlv3.Node{}
+		if err := decoder.Decode(&document); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, errors.Wrap(err, "failed to parse YAML")
+		}
+		collectImageTagEdits(&document, images, &edits, found)
+	}
+
+	updated, err := applyScalarEdits(content, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	foundImages := []string{}
+	for name := range found {
+		foundImages = append(foundImages, name)
+	}
+	sort.Strings(foundImages)
+	return updated, foundImages, nil
+}
+
+func collectImageTagEdits(node *yamlv3.Node, images []ContainerImage, edits *[]scalarEdit, found map[string]bool) {
+	switch node.Kind {
+	case yamlv3.DocumentNode, yamlv3.SequenceNode:
+		for _, child := range node.Content {
+			collectImageTagEdits(child, images, edits, found)
+		}
+	case yamlv3.MappingNode:
+		values := map[string]*yamlv3.Node{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			values[node.Content[i].Value] = node.Content[i+1]
+		}
+
+		// image:
+		//   repository: ...
+		//   tag: ...
+		repository, tag := scalarValue(values["repository"]), values["tag"]
+		if repository != nil && tag != nil && tag.Kind == yamlv3.ScalarNode {
+			names := []string{repository.Value}
+			if registry := scalarValue(values["registry"]); registry != nil && len(registry.Value) > 0 {
+				names = []string{strings.TrimSuffix(registry.Value, "/") + "/" + repository.Value, repository.Value}
+			}
+			if image := matchImage(names, images); image != nil {
+				found[image.Name] = true
+				if tag.Value != image.Tag {
+					*edits = append(*edits, scalarEdit{line: tag.Line, column: tag.Column, node: tag, value: image.Tag})
+				}
+			}
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			// image: my.registry.com/myImage:1.0.0
+			if key.Value == "image" && value.Kind == yamlv3.ScalarNode {
+				name, currentTag := splitImageTag(value.Value)
+				if image := matchImage([]string{name}, images); image != nil {
+					found[image.Name] = true
+					if currentTag != image.Tag {
+						*edits = append(*edits, scalarEdit{line: value.Line, column: value.Column, node: value, value: name + ":" + image.Tag})
+					}
+				}
+				continue
+			}
+			collectImageTagEdits(value, images, edits, found)
+		}
+	}
+}
+
+func scalarValue(node *yamlv3.Node) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.ScalarNode {
+		return nil
+	}
+	return node
+}
+
+// matchImage returns the image matching one of the names, the registry of the image is optional within the names
+func matchImage(names []string, images []ContainerImage) *ContainerImage {
+	for i, image := range images {
+		for _, name := range names {
+			if name == image.Name || name == imageNameWithoutRegistry(image.Name) {
+				return &images[i]
+			}
+		}
+	}
+	return nil
+}
+
+func imageNameWithoutRegistry(name string) string {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[1]
+	}
+	return name
+}
+
+// splitImageTag splits an image reference into name and tag, the tag is empty if the reference does not contain one
+func splitImageTag(reference string) (string, string) {
+	index := strings.LastIndex(reference, ":")
+	if index < 0 || strings.Contains(reference[index:], "/") {
+		return reference, ""
+	}
+	return reference[:index], reference[index+1:]
+}
+
+// applyScalarEdits replaces the scalars at the positions of the edits, starting from the end of the content
+func applyScalarEdits(content []byte, edits []scalarEdit) ([]byte, error) {
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line > edits[j].line
+		}
+		return edits[i].column > edits[j].column
+	})
+
+	lineStarts := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	result := append([]byte{}, content...)
+	for _, edit := range edits {
+		if edit.line < 1 || edit.line > len(lineStarts) {
+			return nil, fmt.Errorf("invalid position %v:%v", edit.line, edit.column)
+		}
+		// columns count characters, not bytes
+		start := lineStarts[edit.line-1]
+		for column := 1; column < edit.column && start < len(result); column++ {
+			_, size := utf8.DecodeRune(result[start:])
+			start += size
+		}
+		length, err := rawScalarLength(result[start:], edit.node)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to replace value at %v:%v", edit.line, edit.column)
+		}
+		replacement := []byte(formatScalar(edit.value, edit.node.Style))
+		result = append(result[:start], append(replacement, result[start+length:]...)...)
+	}
+	return result, nil
+}
+
+// rawScalarLength returns the length of the scalar as it is written in the content including quotes
+func rawScalarLength(content []byte, node *yamlv3.Node) (int, error) {
+	switch {
+	case node.Style&yamlv3.DoubleQuotedStyle != 0:
+		for i := 1; i < len(content); i++ {
+			if content[i] == '\\' {
+				i++
+				continue
+			}
+			if content[i] == '"' {
+				return i + 1, nil
+			}
+		}
+	case node.Style&yamlv3.SingleQuotedStyle != 0:
+		for i := 1; i < len(content); i++ {
+			if content[i] == '\'' {
+				if i+1 < len(content) && content[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1, nil
+			}
+		}
+	case node.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) == 0:
+		if bytes.HasPrefix(content, []byte(node.Value)) {
+			return len(node.Value), nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported format of value '%v'", node.Value)
+}
+
+// formatScalar formats the value keeping the quoting style. Plain values are quoted if they would not be read as string, e.g. 1.10
+func formatScalar(value string, style yamlv3.Style) string {
+	switch {
+	case style&yamlv3.DoubleQuotedStyle != 0:
+		return fmt.Sprintf("%q", value)
+	case style&yamlv3.SingleQuotedStyle != 0:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	var parsed interface{}
+	if err := yamlv3.Unmarshal([]byte(value), &parsed); err != nil || parsed != value {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
@@ -0,0 +1,99 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateImageTags(t *testing.T) {
+	images := []ContainerImage{
+		{Name: "my.registry.com/frontend", Tag: "1.10"},
+		{Name: "my.registry.com/backend", Tag: "2.0.1"},
+		{Name: "my.registry.com/worker", Tag: "3.0.0"},
+		{Name: "my.registry.com/notUsed", Tag: "1.0.0"},
+	}
+
+	t.Run("helm values", func(t *testing.T) {
+		content := `# values for my app
+frontend:
+  image:
+    repository: my.registry.com/frontend  # the frontend
+    tag: 1.9
+    pullPolicy: IfNotPresent
+
+backend:
+  image:
+    registry: my.registry.com
+    repository: backend
+    tag: "2.0.0" # updated by the pipeline
+  replicas: 2
+workers:
+  - name: worker
+    image: 'my.registry.com/worker:2.0.0'
+  - name: other
+    image: other.registry.com/worker:2.0.0
+sidecar:
+  image:
+    repository: my.registry.com/sidecar
+    tag: 1.0.0
+`
+		updated, found, err := UpdateImageTags([]byte(content), images)
+		require.NoError(t, err)
+		assert.Equal(t, `# values for my app
+frontend:
+  image:
+    repository: my.registry.com/frontend  # the frontend
+    tag: "1.10"
+    pullPolicy: IfNotPresent
+
+backend:
+  image:
+    registry: my.registry.com
+    repository: backend
+    tag: "2.0.1" # updated by the pipeline
+  replicas: 2
+workers:
+  - name: worker
+    image: 'my.registry.com/worker:3.0.0'
+  - name: other
+    image: other.registry.com/worker:2.0.0
+sidecar:
+  image:
+    repository: my.registry.com/sidecar
+    tag: 1.0.0
+`, string(updated))
+		assert.Equal(t, []string{"my.registry.com/backend", "my.registry.com/frontend", "my.registry.com/worker"}, found)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		content := "image:\n  repository: frontend\n  tag: \"1.10\"\n"
+		updated, found, err := UpdateImageTags([]byte(content), images)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(updated))
+		assert.Equal(t, []string{"my.registry.com/frontend"}, found)
+	})
+
+	t.Run("multiple documents", func(t *testing.T) {
+		content := "image: my.registry.com/frontend:1.0 # first\n---\nimage: my.registry.com/backend\n"
+		updated, found, err := UpdateImageTags([]byte(content), images)
+		require.NoError(t, err)
+		assert.Equal(t, "image: my.registry.com/frontend:1.10 # first\n---\nimage: my.registry.com/backend:2.0.1\n", string(updated))
+		assert.Len(t, found, 2)
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		_, _, err := UpdateImageTags([]byte("image: [\n"), images)
+		assert.Contains(t, err.Error(), "failed to parse YAML")
+	})
+}
+
+func TestSplitImageTag(t *testing.T) {
+	name, tag := splitImageTag("my.registry.com:5000/image:1.0")
+	assert.Equal(t, "my.registry.com:5000/image", name)
+	assert.Equal(t, "1.0", tag)
+	name, tag = splitImageTag("my.registry.com:5000/image")
+	assert.Equal(t, "my.registry.com:5000/image", name)
+	assert.Equal(t, "", tag)
+}
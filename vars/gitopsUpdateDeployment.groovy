@@ -6,6 +6,7 @@ import groovy.transform.Field
 void call(Map parameters = [:]) {
     List credentials = [
         [type: 'usernamePassword', id: 'gitHttpsCredentialsId', env: ['PIPER_username', 'PIPER_password']],
+        [type: 'token', id: 'githubTokenCredentialsId', env: ['PIPER_githubToken']],
     ]
     piperExecuteBin(parameters, STEP_NAME, METADATA_FILE, credentials)
 }